@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/updater"
+)
+
+// For testing
+var enableAutoMerge = updater.EnableAutoMerge
+
+// mergeMethodValue is a flag that may be given alone, selecting squash, or
+// with a merge method, as in -auto-merge=rebase
+type mergeMethodValue string
+
+// mergeMethodFlag defines a merge method flag on the command line
+func mergeMethodFlag(name, usage string) *string {
+	value := new(string)
+	flag.Var((*mergeMethodValue)(value), name, usage)
+	return value
+}
+
+// String implements flag.Value
+func (v *mergeMethodValue) String() string {
+	if v == nil {
+		return ""
+	}
+	return string(*v)
+}
+
+// Set implements flag.Value
+func (v *mergeMethodValue) Set(s string) error {
+	switch s {
+	case "", "false":
+		*v = ""
+		return nil
+	case "true":
+		s = ""
+	}
+	method, err := updater.ParseMergeMethod(s)
+	if err != nil {
+		return err
+	}
+	*v = mergeMethodValue(method)
+	return nil
+}
+
+// IsBoolFlag lets the flag be given without a value
+func (v *mergeMethodValue) IsBoolFlag() bool { return true }
+
+// autoMergeMethod returns the -auto-merge method, squash by default
+func autoMergeMethod() string {
+	method, _ := updater.ParseMergeMethod(*autoMerge)
+	return method
+}
+
+// enablePRAutoMerge enables auto-merge on a pull request just created.
+// Failures are logged; the pull request is left for a manual merge.
+func (r *repoRunner) enablePRAutoMerge(ctx context.Context, repoOwner, repoName string, number int) {
+	if number == 0 {
+		return
+	}
+	repository := repoOwner + "/" + repoName
+	client := githubClientFactory(*token)
+	if err := enableAutoMerge(ctx, client, repoOwner, repoName, number, autoMergeMethod()); err != nil {
+		log.Printf("Warning: %s#%d: %v", repository, number, err)
+		return
+	}
+	r.events.Publish(ctx, updater.Event{Type: updater.EventAutoMergeEnabled, Repository: repository, PullRequest: number, Detail: autoMergeMethod()})
+	log.Printf("Enabled %s auto-merge for %s#%d", autoMergeMethod(), repository, number)
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/google/go-github/v72/github"
+)
+
+func TestMergeMethodFlag(t *testing.T) {
+	tests := []struct {
+		args    []string
+		want    string
+		wantErr bool
+	}{
+		{args: nil, want: ""},
+		{args: []string{"-auto-merge"}, want: "squash"},
+		{args: []string{"-auto-merge=rebase"}, want: "rebase"},
+		{args: []string{"-auto-merge=MERGE"}, want: "merge"},
+		{args: []string{"-auto-merge=false"}, want: ""},
+		{args: []string{"-auto-merge=ff"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.args), func(t *testing.T) {
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			fs.SetOutput(io.Discard)
+			value := new(string)
+			fs.Var((*mergeMethodValue)(value), "auto-merge", "")
+			err := fs.Parse(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && *value != tt.want {
+				t.Errorf("value = %q, want %q", *value, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunAutoMerge(t *testing.T) {
+	workflow := "on: push\njobs:\n  build:\n    runs-on: ubuntu-latest\n    steps:\n      - uses: actions/checkout@v3\n"
+	creator := &numberedPRCreator{}
+	setupRunEnv(t, map[string]string{".github/workflows/ci.yml": workflow}, &mockVersionChecker{latestVersion: "v4", latestHash: "abc123"}, creator)
+
+	var merged []string
+	oldEnable := enableAutoMerge
+	defer func() { enableAutoMerge = oldEnable }()
+	enableAutoMerge = func(ctx context.Context, client *github.Client, owner, repo string, number int, method string) error {
+		merged = append(merged, fmt.Sprintf("%s/%s#%d %s", owner, repo, number, method))
+		return nil
+	}
+
+	if err := flag.Set("auto-merge", "rebase"); err != nil {
+		t.Fatal(err)
+	}
+	if err := run(); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	if len(merged) != 1 || merged[0] != "test-owner/test-repo#1 rebase" {
+		t.Errorf("auto-merged %v, want test-owner/test-repo#1 rebase", merged)
+	}
+
+	// Staged changes open no pull request to merge
+	*stage = true
+	if err := run(); err != nil {
+		t.Fatalf("staged run() error = %v", err)
+	}
+	if len(merged) != 1 {
+		t.Errorf("staged run enabled auto-merge: %v", merged)
+	}
+}
+
+func TestRunAutoMergeBatches(t *testing.T) {
+	const hash = "1234567890123456789012345678901234567890"
+	workflow := "on: push\njobs:\n  build:\n    runs-on: ubuntu-latest\n    steps:\n      - uses: actions/checkout@v3\n      - uses: actions/cache@v3\n"
+	creator := &numberedPRCreator{}
+	setupRunEnv(t, map[string]string{".github/workflows/ci.yml": workflow}, &mockVersionChecker{latestVersion: "v4", latestHash: hash}, creator)
+
+	var merged, statuses []string
+	oldEnable, oldSet := enableAutoMerge, setCommitStatus
+	defer func() { enableAutoMerge, setCommitStatus = oldEnable, oldSet }()
+	enableAutoMerge = func(ctx context.Context, client *github.Client, owner, repo string, number int, method string) error {
+		merged = append(merged, fmt.Sprintf("#%d", number))
+		return nil
+	}
+	setCommitStatus = func(ctx context.Context, client *github.Client, owner, repo, sha, kind, description string) error {
+		statuses = append(statuses, sha+": "+description)
+		return nil
+	}
+
+	// Every batch gets auto-merge and a status of its own
+	for name, value := range map[string]string{"auto-merge": "squash", "commit-status": "status", "max-updates-per-pr": "1"} {
+		if err := flag.Set(name, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := run(); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	if fmt.Sprint(merged) != "[#1 #2]" {
+		t.Errorf("auto-merged %v, want [#1 #2]", merged)
+	}
+	if fmt.Sprint(statuses) != "[sha1: 1 actions updated, 0 unpinned remaining sha2: 1 actions updated, 0 unpinned remaining]" {
+		t.Errorf("statuses = %v", statuses)
+	}
+}
@@ -0,0 +1,381 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/report"
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/storage"
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/updater"
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/webhook"
+	"github.com/google/go-github/v72/github"
+)
+
+// campaignOptions configures the campaign being run
+type campaignOptions struct {
+	action       string
+	version      string
+	name         string
+	canaries     []string
+	approve      bool          // Let the rollout proceed past the canaries
+	daemon       bool          // Keep running until the canaries pass, then roll out
+	pollInterval time.Duration // Between canary checks in daemon mode
+}
+
+// Campaign being run; set by runCampaignCommand
+var activeCampaign *campaignOptions
+
+// For testing
+var (
+	pullRequestOutcome = updater.PullRequestOutcome
+	campaignSleep      = func(ctx context.Context, d time.Duration) error {
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+			return nil
+		}
+	}
+)
+
+// campaignFlags are the flags of the campaign subcommand besides those of
+// a regular run
+type campaignFlags struct {
+	action       string
+	version      string
+	name         string
+	canaries     string
+	approve      bool
+	daemon       bool
+	pollInterval time.Duration
+}
+
+// register declares the flags on fs
+func (o *campaignFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.action, "action", "", "Action to bump, as owner/repo")
+	fs.StringVar(&o.version, "to", "", "Version to bump the action to")
+	fs.StringVar(&o.name, "name", "", "Campaign name used to track progress (default derived from -action and -to)")
+	fs.StringVar(&o.canaries, "canary", "", "Comma separated owner/repo list rolled out first; the rest waits until their pull requests are merged or pass CI")
+	fs.BoolVar(&o.approve, "approve", false, "Approve the rollout past the canaries")
+	fs.BoolVar(&o.daemon, "daemon", false, "Keep running until the canaries pass, then roll out to the remaining repositories")
+	fs.DurationVar(&o.pollInterval, "poll-interval", 5*time.Minute, "Interval between canary checks in daemon mode")
+}
+
+// runCampaignCommand implements the "campaign" subcommand:
+//
+//	ghactions-updater campaign -action actions/checkout -to v5 (-org o | -repos-file f) [flags]
+//
+// It accepts the flags of a regular run and, across the selected
+// repositories, creates pull requests bumping only the given action to the
+// given version. With -store, progress is saved after every repository and
+// a re-run resumes the campaign, skipping completed repositories.
+func runCampaignCommand(args []string, stdout io.Writer) error {
+	var opts campaignFlags
+	fs := newFlagSet("campaign", stdout, opts.register)
+	registerGlobalFlags(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := applyConfigFiles(fs, stdout); err != nil {
+		return err
+	}
+
+	for _, required := range []struct{ flag, value string }{
+		{"action", opts.action}, {"to", opts.version},
+	} {
+		if required.value == "" {
+			return fmt.Errorf(common.ErrMissingRequiredFlag, required.flag)
+		}
+	}
+	if !multiRepoMode() {
+		return fmt.Errorf(common.ErrMissingRequiredFlag, "org or repos-file")
+	}
+	if *serveAddr != "" || *interactive {
+		return fmt.Errorf(common.ErrInvalidFlagValue, "campaign", "cannot be combined with -serve or -interactive")
+	}
+	// Canary progress and approvals are shared between runs through the store
+	if (opts.canaries != "" || opts.approve) && *storeLocation == "" {
+		return fmt.Errorf(common.ErrMissingRequiredFlag, "store")
+	}
+	if opts.pollInterval <= 0 {
+		return fmt.Errorf(common.ErrInvalidFlagValue, "poll-interval", "must be positive")
+	}
+	if err := validateFlags(); err != nil {
+		return &common.ValidationError{Err: err}
+	}
+
+	activeCampaign = &campaignOptions{
+		action:       opts.action,
+		version:      opts.version,
+		name:         opts.name,
+		canaries:     splitList(opts.canaries),
+		approve:      opts.approve,
+		daemon:       opts.daemon,
+		pollInterval: opts.pollInterval,
+	}
+	defer func() { activeCampaign = nil }()
+	return run()
+}
+
+// splitList splits a comma separated list, dropping empty entries
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// runCampaign bumps the campaign action in every selected repository that
+// has not completed the campaign yet and prints a completion report.
+// Canaries are rolled out first; the remaining repositories wait until the
+// canaries pass or the rollout is approved.
+func runCampaign(ctx context.Context, runner *repoRunner, opts *campaignOptions) error {
+	campaign, err := updater.ResumeCampaign(ctx, runner.store, opts.name, opts.action, opts.version, time.Now())
+	if err != nil {
+		return err
+	}
+	target, err := campaign.Reference()
+	if err != nil {
+		return err
+	}
+	hash, err := runner.checker.GetCommitHash(ctx, target, campaign.Version)
+	if err != nil {
+		return err
+	}
+
+	client := githubClientFactory(*token)
+	names, _, err := selectRepositories(ctx, client)
+	if err != nil {
+		return err
+	}
+	if len(opts.canaries) > 0 {
+		if campaign.Canaries, err = matchCanaries(opts.canaries, names); err != nil {
+			return err
+		}
+	}
+	if opts.approve {
+		campaign.Approved = true
+	}
+
+	// Only the targeted action is checked, always against the target version;
+	// snoozes, the update policy and the minimum release age do not apply to
+	// campaigns
+	targeted := *runner
+	targeted.checker = updater.NewTargetVersionChecker(runner.checker, campaign.Version, hash)
+	targeted.only = map[string]bool{webhook.ActionRepository(target): true}
+	targeted.forced = true
+
+	var canaries, remaining []string
+	for _, name := range names {
+		if _, ok := campaign.Repositories[name]; !ok {
+			campaign.Record(name, updater.CampaignProgress{Status: updater.CampaignPending}, time.Now())
+		}
+		if campaign.IsCanary(name) {
+			canaries = append(canaries, name)
+		} else {
+			remaining = append(remaining, name)
+		}
+	}
+	log.Printf("Campaign %s: bumping %s to %s in %d repositories", campaign.Name, campaign.Action, campaign.Version, len(names))
+
+	c := &campaignRun{campaign: campaign, runner: &targeted, store: runner.store, client: client, report: newReport(*shardSpec), total: len(names)}
+	runErr := c.rollout(ctx, canaries)
+	if runErr == nil && len(canaries) > 0 {
+		runErr = c.awaitCanaries(ctx, opts)
+	}
+	if runErr == nil && c.campaign.CanariesPassed() {
+		runErr = c.rollout(ctx, remaining)
+	}
+
+	printCampaignSummary(c.campaign)
+	if err := writeReports(ctx, c.report); err != nil {
+		return err
+	}
+	return runErr
+}
+
+// matchCanaries resolves canary names against the campaign repositories
+func matchCanaries(canaries, names []string) ([]string, error) {
+	matched := make([]string, 0, len(canaries))
+	for _, canary := range canaries {
+		found := ""
+		for _, name := range names {
+			if strings.EqualFold(canary, name) {
+				found = name
+				break
+			}
+		}
+		if found == "" {
+			return nil, fmt.Errorf(common.ErrCanaryNotInCampaign, canary)
+		}
+		matched = append(matched, found)
+	}
+	return matched, nil
+}
+
+// campaignRun holds the state of a single campaign run
+type campaignRun struct {
+	campaign  *updater.Campaign
+	runner    *repoRunner   // Targeted at the campaign action
+	store     storage.Store // Optional; holds the campaign progress
+	client    *github.Client
+	report    *report.Report
+	total     int
+	processed int
+}
+
+// rollout processes the repositories that have not completed the campaign
+func (c *campaignRun) rollout(ctx context.Context, names []string) error {
+	for _, name := range names {
+		c.processed++
+		if c.campaign.Done(name) {
+			log.Printf("[%d/%d] %s: already %s", c.processed, c.total, name, c.campaign.Repositories[name].Status)
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf(common.ErrRunCancelled, err)
+		}
+		repoOwner, repoName, err := updater.SplitRepositoryName(name)
+		if err != nil {
+			log.Printf("Warning: %v", err)
+			c.campaign.Record(name, updater.CampaignProgress{Status: updater.CampaignFailed, Error: err.Error()}, time.Now())
+			continue
+		}
+
+		result := processRemoteRepository(ctx, c.runner, c.client, repoOwner, repoName)
+		c.report.Add(result)
+		status := campaignStatus(result)
+		c.campaign.Record(name, updater.CampaignProgress{
+			Status:      status,
+			Updates:     len(result.Updates),
+			PullRequest: result.PullRequest,
+			Error:       result.Error,
+		}, time.Now())
+		log.Printf("[%d/%d] %s: %s", c.processed, c.total, name, status)
+
+		// Save progress after every repository so a re-run resumes here
+		c.save(ctx)
+	}
+	return nil
+}
+
+// awaitCanaries checks the canary pull requests until the canaries pass or
+// the rollout is approved. Without daemon mode it checks once; otherwise it
+// polls, picking up approvals given by other runs, and stops when a canary
+// fails.
+func (c *campaignRun) awaitCanaries(ctx context.Context, opts *campaignOptions) error {
+	for {
+		c.checkCanaries(ctx)
+		c.save(ctx)
+		if c.campaign.CanariesPassed() {
+			log.Printf("Canaries passed; rolling out to the remaining repositories")
+			return nil
+		}
+		if !opts.daemon {
+			log.Printf("Waiting for canaries %v; re-run later, use -daemon, or approve the rollout with -approve", c.campaign.Canaries)
+			return nil
+		}
+		if failed := c.failedCanaries(); len(failed) > 0 {
+			return fmt.Errorf(common.ErrCanariesFailed, strings.Join(failed, ", "))
+		}
+		if err := campaignSleep(ctx, opts.pollInterval); err != nil {
+			return fmt.Errorf(common.ErrRunCancelled, err)
+		}
+		if c.store != nil {
+			stored, err := updater.LoadCampaign(ctx, c.store, c.campaign.Name)
+			// Take over the progress of the approving run, which may
+			// already have rolled out to some repositories
+			if err == nil && stored.Approved {
+				c.campaign = stored
+			}
+		}
+	}
+}
+
+// checkCanaries updates canaries with an open pull request to verified or
+// failed once the outcome of the pull request is known
+func (c *campaignRun) checkCanaries(ctx context.Context) {
+	for _, name := range c.campaign.Canaries {
+		progress := c.campaign.Repositories[name]
+		if progress.Status != updater.CampaignPROpened || progress.PullRequest == 0 {
+			continue
+		}
+		repoOwner, repoName, err := updater.SplitRepositoryName(name)
+		if err != nil {
+			continue
+		}
+		outcome, err := pullRequestOutcome(ctx, c.client, repoOwner, repoName, progress.PullRequest)
+		if err != nil {
+			log.Printf("Warning: failed to check canary %s#%d: %v", name, progress.PullRequest, err)
+			continue
+		}
+		switch outcome {
+		case updater.PullRequestSucceeded:
+			progress.Status = updater.CampaignVerified
+		case updater.PullRequestFailed:
+			progress.Status = updater.CampaignFailed
+			progress.Error = fmt.Sprintf("pull request #%d failed", progress.PullRequest)
+		default:
+			continue
+		}
+		c.campaign.Record(name, progress, time.Now())
+		log.Printf("Canary %s: %s", name, progress.Status)
+	}
+}
+
+// failedCanaries returns the canaries that failed
+func (c *campaignRun) failedCanaries() []string {
+	var failed []string
+	for _, name := range c.campaign.Canaries {
+		if c.campaign.Repositories[name].Status == updater.CampaignFailed {
+			failed = append(failed, name)
+		}
+	}
+	return failed
+}
+
+// save persists the campaign progress when a store is configured
+func (c *campaignRun) save(ctx context.Context) {
+	if c.store == nil {
+		return
+	}
+	if err := updater.SaveCampaign(context.WithoutCancel(ctx), c.store, c.campaign); err != nil {
+		log.Printf("Warning: failed to save campaign progress: %v", err)
+	}
+}
+
+// printCampaignSummary prints the completion report of a campaign
+func printCampaignSummary(campaign *updater.Campaign) {
+	counts := campaign.Counts()
+	fmt.Printf("Campaign %s (%s@%s): %d repositories, %d pull requests opened, %d verified, %d up to date, %d failed, %d pending\n",
+		campaign.Name, campaign.Action, campaign.Version, len(campaign.Repositories),
+		counts[updater.CampaignPROpened], counts[updater.CampaignVerified], counts[updater.CampaignUpToDate],
+		counts[updater.CampaignFailed], counts[updater.CampaignPending])
+	for _, name := range campaign.Failed() {
+		fmt.Printf("- %s: %s\n", name, campaign.Repositories[name].Error)
+	}
+}
+
+// campaignStatus returns the campaign status of a processed repository
+func campaignStatus(result report.RepositoryResult) string {
+	switch {
+	case result.Error != "":
+		return updater.CampaignFailed
+	case len(result.Updates) == 0:
+		return updater.CampaignUpToDate
+	case *dryRun:
+		return updater.CampaignPending
+	}
+	return updater.CampaignPROpened
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/storage"
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/updater"
+	"github.com/google/go-github/v72/github"
+)
+
+// serveCampaignRepositories serves acme/api and acme/web using
+// actions/checkout@v3, acme/new already on v6 and a forbidden acme/locked
+func serveCampaignRepositories(t *testing.T) {
+	t.Helper()
+	workflow := base64.StdEncoding.EncodeToString([]byte("on: push\njobs:\n  build:\n    runs-on: ubuntu-latest\n    steps:\n      - uses: actions/checkout@v3\n      - uses: actions/setup-go@v4\n"))
+	current := base64.StdEncoding.EncodeToString([]byte("on: push\njobs:\n  build:\n    runs-on: ubuntu-latest\n    steps:\n      - uses: actions/checkout@v6\n"))
+
+	mux := http.NewServeMux()
+	for name, content := range map[string]string{"acme/api": workflow, "acme/web": workflow, "acme/new": current} {
+		base := "/repos/" + name + "/contents/.github/workflows"
+		mux.HandleFunc(base, func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `[{"type":"file","name":"ci.yml"}]`)
+		})
+		mux.HandleFunc(base+"/ci.yml", func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `{"type":"file","name":"ci.yml","encoding":"base64","content":%q}`, content)
+		})
+	}
+	mux.HandleFunc("/repos/acme/locked/contents/.github/workflows", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"Forbidden"}`, http.StatusForbidden)
+	})
+	useGitHubServer(t, mux)
+}
+
+// writeCampaignRepositories writes a repository list for the campaign tests
+func writeCampaignRepositories(t *testing.T, dir string, names ...string) string {
+	t.Helper()
+	listFile := filepath.Join(dir, "repos.txt")
+	if err := os.WriteFile(listFile, []byte(strings.Join(names, "\n")), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return listFile
+}
+
+func TestRunCampaignCommand(t *testing.T) {
+	creator := &recordingPRCreator{}
+	setupRunEnv(t, nil, &mockVersionChecker{latestVersion: "v6", latestHash: "1234567890123456789012345678901234567890"}, creator)
+	serveCampaignRepositories(t)
+
+	dir := t.TempDir()
+	listFile := writeCampaignRepositories(t, dir, "acme/api", "acme/web", "acme/new", "acme/locked")
+	store := filepath.Join(dir, "store")
+	args := []string{"-action", "actions/checkout", "-to", "v5", "-repos-file", listFile, "-store", store}
+
+	var out bytes.Buffer
+	if err := runCampaignCommand(args, &out); err != nil {
+		t.Fatalf("runCampaignCommand() error = %v", err)
+	}
+
+	// Only the targeted action is bumped, to the target version, and never downgraded
+	if len(creator.updates) != 2 {
+		t.Fatalf("got %d updates, want 2", len(creator.updates))
+	}
+	for _, update := range creator.updates {
+		if update.Action.FullName() != "actions/checkout" || update.NewVersion != "v5" {
+			t.Errorf("unexpected update %s to %s", update.Action.FullName(), update.NewVersion)
+		}
+	}
+
+	s, err := storage.Open(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	campaign, err := updater.LoadCampaign(context.Background(), s, "actions-checkout-v5")
+	if err != nil {
+		t.Fatalf("LoadCampaign() error = %v", err)
+	}
+	want := map[string]string{
+		"acme/api":    updater.CampaignPROpened,
+		"acme/web":    updater.CampaignPROpened,
+		"acme/new":    updater.CampaignUpToDate,
+		"acme/locked": updater.CampaignFailed,
+	}
+	for name, status := range want {
+		if got := campaign.Repositories[name].Status; got != status {
+			t.Errorf("%s status = %q, want %q", name, got, status)
+		}
+	}
+
+	// A re-run resumes the campaign and only retries unfinished repositories
+	if err := runCampaignCommand(args, &out); err != nil {
+		t.Fatalf("second runCampaignCommand() error = %v", err)
+	}
+	if len(creator.updates) != 2 {
+		t.Errorf("re-run created updates for completed repositories: %d updates", len(creator.updates))
+	}
+
+	// A stored campaign cannot be retargeted
+	retarget := append([]string{}, args...)
+	retarget[3] = "v4"
+	retarget = append(retarget, "-name", "actions-checkout-v5")
+	if err := runCampaignCommand(retarget, &out); err == nil || !strings.Contains(err.Error(), "already targets") {
+		t.Errorf("retargeted campaign error = %v", err)
+	}
+}
+
+// numberedPRCreator numbers the pull requests it records across
+// repositories, listing those of the repository being processed
+type numberedPRCreator struct {
+	recordingPRCreator
+	created int
+	pulls   []updater.PullRequest
+}
+
+func (c *numberedPRCreator) CreatePR(ctx context.Context, updates []*updater.Update) error {
+	c.created++
+	if err := c.recordingPRCreator.CreatePR(ctx, updates); err != nil {
+		return err
+	}
+	c.pulls = append(c.pulls, updater.PullRequest{Number: c.created, HeadSHA: fmt.Sprintf("sha%d", c.created), Updates: updates})
+	return nil
+}
+
+func (c *numberedPRCreator) PullRequests() []updater.PullRequest {
+	return c.pulls
+}
+
+// startRepository forgets the pull requests of the previous repository, as
+// the real creators are made per repository
+func (c *numberedPRCreator) startRepository() {
+	c.pulls = nil
+}
+
+func TestRunCampaignCanaries(t *testing.T) {
+	creator := &numberedPRCreator{}
+	setupRunEnv(t, nil, &mockVersionChecker{latestHash: "1234567890123456789012345678901234567890"}, creator)
+	serveCampaignRepositories(t)
+
+	oldOutcome, oldSleep := pullRequestOutcome, campaignSleep
+	defer func() { pullRequestOutcome, campaignSleep = oldOutcome, oldSleep }()
+	var outcomes []string
+	var checked []string
+	pullRequestOutcome = func(ctx context.Context, client *github.Client, owner, repo string, number int) (string, error) {
+		checked = append(checked, fmt.Sprintf("%s/%s#%d", owner, repo, number))
+		outcome := outcomes[0]
+		if len(outcomes) > 1 {
+			outcomes = outcomes[1:]
+		}
+		return outcome, nil
+	}
+	sleeps := 0
+	campaignSleep = func(ctx context.Context, d time.Duration) error {
+		sleeps++
+		return nil
+	}
+
+	dir := t.TempDir()
+	listFile := writeCampaignRepositories(t, dir, "acme/api", "acme/web")
+	args := []string{"-action", "actions/checkout", "-to", "v5", "-repos-file", listFile, "-store", filepath.Join(dir, "store"), "-canary", "ACME/WEB"}
+	var out bytes.Buffer
+
+	// The first run only rolls out to the canary while its CI is pending
+	outcomes = []string{updater.PullRequestPending}
+	if err := runCampaignCommand(args, &out); err != nil {
+		t.Fatalf("runCampaignCommand() error = %v", err)
+	}
+	if creator.created != 1 || len(checked) != 1 || checked[0] != "acme/web#1" {
+		t.Fatalf("canary run created %d pull requests and checked %v", creator.created, checked)
+	}
+
+	// In daemon mode the run waits for the canary, then rolls out the rest
+	outcomes = []string{updater.PullRequestPending, updater.PullRequestSucceeded}
+	if err := runCampaignCommand(append(args, "-daemon"), &out); err != nil {
+		t.Fatalf("daemon runCampaignCommand() error = %v", err)
+	}
+	if creator.created != 2 || sleeps != 1 {
+		t.Errorf("daemon run created %d pull requests after %d polls, want 2 after 1", creator.created, sleeps)
+	}
+}
+
+func TestRunCampaignCanaryFailure(t *testing.T) {
+	creator := &numberedPRCreator{}
+	setupRunEnv(t, nil, &mockVersionChecker{latestHash: "1234567890123456789012345678901234567890"}, creator)
+	serveCampaignRepositories(t)
+
+	oldOutcome := pullRequestOutcome
+	defer func() { pullRequestOutcome = oldOutcome }()
+	pullRequestOutcome = func(ctx context.Context, client *github.Client, owner, repo string, number int) (string, error) {
+		return updater.PullRequestFailed, nil
+	}
+
+	dir := t.TempDir()
+	listFile := writeCampaignRepositories(t, dir, "acme/api", "acme/web")
+	args := []string{"-action", "actions/checkout", "-to", "v5", "-repos-file", listFile, "-store", filepath.Join(dir, "store"), "-canary", "acme/api"}
+	var out bytes.Buffer
+	err := runCampaignCommand(append(args, "-daemon"), &out)
+	if err == nil || !strings.Contains(err.Error(), "canaries failed: acme/api") {
+		t.Fatalf("runCampaignCommand() error = %v, want failed canary", err)
+	}
+	if creator.created != 1 {
+		t.Errorf("created %d pull requests, want only the canary", creator.created)
+	}
+
+	// Approving the rollout proceeds despite the failed canary
+	if err := runCampaignCommand(append(args, "-approve"), &out); err != nil {
+		t.Fatalf("approved runCampaignCommand() error = %v", err)
+	}
+	if creator.created != 3 {
+		t.Errorf("created %d pull requests after approval, want 3", creator.created)
+	}
+}
+
+func TestRunCampaignCommandValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{name: "missing action", args: []string{"-to", "v5", "-org", "acme"}, wantErr: "action"},
+		{name: "missing version", args: []string{"-action", "actions/checkout", "-org", "acme"}, wantErr: "to"},
+		{name: "single repository", args: []string{"-action", "actions/checkout", "-to", "v5"}, wantErr: "org or repos-file"},
+		{name: "invalid action", args: []string{"-action", "checkout", "-to", "v5", "-org", "acme"}, wantErr: "owner/repo"},
+		{name: "canary without store", args: []string{"-action", "actions/checkout", "-to", "v5", "-org", "acme", "-canary", "acme/api"}, wantErr: "store"},
+		{name: "invalid poll interval", args: []string{"-action", "actions/checkout", "-to", "v5", "-org", "acme", "-poll-interval", "0s"}, wantErr: "poll-interval"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupRunEnv(t, nil, &mockVersionChecker{}, &recordingPRCreator{})
+			var out bytes.Buffer
+			err := runCampaignCommand(tt.args, &out)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("runCampaignCommand() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/updater"
+)
+
+// updateChangeGates checks the change tickets of a repository's gated pull
+// requests, adding created. Approved tickets enable auto-merge and rejected
+// ones release the gate; pending gates are kept for later runs.
+func (r *repoRunner) updateChangeGates(ctx context.Context, repoOwner, repoName string, created ...updater.ChangeGate) {
+	repository := repoOwner + "/" + repoName
+	gates, err := updater.LoadChangeGates(ctx, r.store, repoOwner, repoName)
+	if err != nil {
+		log.Printf("Warning: failed to load change tickets: %v", err)
+		return
+	}
+	// The status of new tickets is known
+	fresh := make(map[int]bool, len(created))
+	for _, gate := range created {
+		r.events.Publish(ctx, updater.Event{Type: updater.EventTicketLinked, Repository: repository, PullRequest: gate.PullRequest, Ticket: gate.Ticket.ID, TicketURL: gate.Ticket.URL})
+		gates = append(gates, gate)
+		fresh[gate.PullRequest] = true
+	}
+
+	client := githubClientFactory(*token)
+	pending := make([]updater.ChangeGate, 0, len(gates))
+	for _, gate := range gates {
+		status := gate.Ticket.Status
+		if !fresh[gate.PullRequest] {
+			if status, err = r.ticketer.TicketStatus(ctx, gate.Ticket); err != nil {
+				log.Printf("Warning: failed to check change ticket %s: %v", gate.Ticket.ID, err)
+				pending = append(pending, gate)
+				continue
+			}
+		}
+
+		event := updater.Event{Repository: repository, PullRequest: gate.PullRequest, Ticket: gate.Ticket.ID, TicketURL: gate.Ticket.URL}
+		switch status {
+		case updater.TicketApproved:
+			if err := enableAutoMerge(ctx, client, repoOwner, repoName, gate.PullRequest, autoMergeMethod()); err != nil {
+				log.Printf("Warning: %s#%d: %v", repository, gate.PullRequest, err)
+				pending = append(pending, gate)
+				continue
+			}
+			event.Type = updater.EventTicketApproved
+			r.events.Publish(ctx, event)
+			event.Type = updater.EventAutoMergeEnabled
+			r.events.Publish(ctx, event)
+			log.Printf("Change ticket %s approved; enabled auto-merge for %s#%d", gate.Ticket.ID, repository, gate.PullRequest)
+		case updater.TicketRejected:
+			event.Type = updater.EventTicketRejected
+			r.events.Publish(ctx, event)
+			log.Printf("Change ticket %s rejected; %s#%d is left for manual review", gate.Ticket.ID, repository, gate.PullRequest)
+		default:
+			pending = append(pending, gate)
+		}
+	}
+
+	if err := updater.SaveChangeGates(context.WithoutCancel(ctx), r.store, repoOwner, repoName, pending); err != nil {
+		log.Printf("Warning: failed to save change tickets: %v", err)
+	}
+}
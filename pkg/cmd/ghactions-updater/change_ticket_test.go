@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/updater"
+	"github.com/google/go-github/v72/github"
+)
+
+func TestRunChangeTicketGate(t *testing.T) {
+	workflow := "on: push\njobs:\n  build:\n    runs-on: ubuntu-latest\n    steps:\n      - uses: actions/checkout@v3\n"
+	creator := &numberedPRCreator{}
+	setupRunEnv(t, map[string]string{".github/workflows/ci.yml": workflow}, &mockVersionChecker{latestVersion: "v4", latestHash: "abc123"}, creator)
+
+	status := updater.TicketPending
+	tickets := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req updater.TicketRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		fmt.Fprintf(w, `{"id":"CHG0001","url":"https://tickets.example.com/CHG0001","status":%q}`, status)
+	}))
+	defer tickets.Close()
+
+	var merged []string
+	oldEnable := enableAutoMerge
+	defer func() { enableAutoMerge = oldEnable }()
+	enableAutoMerge = func(ctx context.Context, client *github.Client, owner, repo string, number int, method string) error {
+		merged = append(merged, fmt.Sprintf("%s/%s#%d", owner, repo, number))
+		return nil
+	}
+
+	dir := t.TempDir()
+	*storeLocation = filepath.Join(dir, "store")
+	*changeTicket = tickets.URL
+	*auditLog = filepath.Join(dir, "audit.jsonl")
+	if err := validateFlags(); err != nil {
+		t.Fatalf("validateFlags() error = %v", err)
+	}
+
+	// The pull request is created with a pending ticket and auto-merge stays off
+	if err := run(); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	if creator.created != 1 || len(merged) != 0 {
+		t.Fatalf("created %d pull requests, auto-merged %v", creator.created, merged)
+	}
+
+	// A later run finds the ticket approved and enables auto-merge; its own
+	// pull request gets an already approved ticket and is enabled right away
+	status = updater.TicketApproved
+	if err := run(); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	if strings.Join(merged, ",") != "test-owner/test-repo#1,test-owner/test-repo#2" {
+		t.Errorf("auto-merged %v, want #1 and #2", merged)
+	}
+
+	// Resolved gates are not checked again
+	if err := run(); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	if len(merged) != 3 {
+		t.Errorf("auto-merged %v, want only the new pull request added", merged)
+	}
+
+	audit, err := os.ReadFile(*auditLog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, event := range []string{updater.EventTicketLinked, updater.EventTicketApproved, updater.EventAutoMergeEnabled} {
+		if !strings.Contains(string(audit), `"event":"`+event+`"`) {
+			t.Errorf("audit log misses %s:\n%s", event, audit)
+		}
+	}
+}
+
+func TestValidateChangeTicketFlags(t *testing.T) {
+	tests := []struct {
+		name    string
+		setup   func()
+		wantErr string
+	}{
+		{name: "invalid spec", setup: func() { *changeTicket = "jira"; *storeLocation = "mem://" }, wantErr: "unknown change ticket integration"},
+		{name: "missing store", setup: func() { *changeTicket = "https://tickets.example.com" }, wantErr: "requires -store"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupRunEnv(t, nil, &mockVersionChecker{}, &recordingPRCreator{})
+			tt.setup()
+			if err := validateFlags(); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validateFlags() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+	"github.com/google/go-github/v72/github"
+)
+
+// Run modes a token can be checked for
+const (
+	authModePR     = "pr"
+	authModeStage  = "stage"
+	authModeDryRun = "dry-run"
+)
+
+// tokenPermission is a repository permission of fine-grained tokens and
+// GitHub Apps
+type tokenPermission struct {
+	name   string
+	access string // read or write
+}
+
+// String formats the permission like GitHub's settings, e.g. "contents: write"
+func (p tokenPermission) String() string {
+	return p.name + ": " + p.access
+}
+
+// modePermissions lists the permissions each mode needs on the target
+// repository. Dry runs and staging only read public action repositories.
+var modePermissions = map[string][]tokenPermission{
+	authModePR: {
+		{name: "metadata", access: "read"},
+		{name: "contents", access: "write"},
+		{name: "pull_requests", access: "write"},
+		{name: "workflows", access: "write"},
+	},
+	authModeStage:  {{name: "metadata", access: "read"}},
+	authModeDryRun: {{name: "metadata", access: "read"}},
+}
+
+// classicScopes names the classic token scopes granting each permission,
+// any one of which is enough
+var classicScopes = map[string][]string{
+	"metadata":      {"repo", "public_repo"},
+	"contents":      {"repo", "public_repo"},
+	"pull_requests": {"repo", "public_repo"},
+	"workflows":     {"workflow"},
+}
+
+// validateAuthMode checks the -mode flag of doctor and check-auth
+func validateAuthMode(mode string) error {
+	if _, ok := modePermissions[mode]; !ok {
+		return fmt.Errorf(common.ErrInvalidFlagValue, "mode", "must be pr, stage or dry-run")
+	}
+	return nil
+}
+
+// checkAuthOptions holds the settings of the check-auth subcommand
+type checkAuthOptions struct {
+	token string
+	owner string
+	repo  string
+	mode  string
+}
+
+// register declares the flags on fs
+func (o *checkAuthOptions) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.token, "token", "", "GitHub token (default from the environment or gh CLI, see the README)")
+	fs.StringVar(&o.owner, "owner", "", "Owner of the repository to check the permissions on")
+	fs.StringVar(&o.repo, "repo-name", "", "Name of the repository to check the permissions on")
+	fs.StringVar(&o.mode, "mode", authModePR, "Mode to check the permissions for: pr, stage or dry-run")
+}
+
+// runCheckAuthCommand implements the "check-auth" subcommand:
+//
+//	ghactions-updater check-auth [-token t] [-owner o -repo-name r] [-mode pr|stage|dry-run]
+//
+// It runs the token checks of doctor, without touching the local checkout.
+func runCheckAuthCommand(args []string, stdout io.Writer) error {
+	opts := checkAuthOptions{}
+	fs := newFlagSet("check-auth", stdout, opts.register)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := validateAuthMode(opts.mode); err != nil {
+		return err
+	}
+	if opts.token == "" {
+		found, err := githubToken()
+		if err != nil {
+			return err
+		}
+		opts.token = found
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	doctor := doctorOptions{token: opts.token, owner: opts.owner, repo: opts.repo, mode: opts.mode}
+	return printChecks(stdout, []doctorCheck{checkToken(doctor), checkTokenPermissions(ctx, doctor)})
+}
+
+// checkTokenPermissions checks that the token grants what the selected mode
+// needs, telling what to grant otherwise. Classic tokens report their
+// scopes on every response; fine-grained and GitHub App tokens do not, so
+// their permissions are probed on the target repository with reads only.
+func checkTokenPermissions(ctx context.Context, opts doctorOptions) doctorCheck {
+	check := doctorCheck{name: "Token permissions"}
+	needed := modePermissions[opts.mode]
+	if opts.token == "" {
+		check.status, check.detail = checkSkip, "no token"
+		return check
+	}
+
+	client := githubClientFactory(opts.token)
+	var repository *github.Repository
+	var resp *github.Response
+	var err error
+	if opts.owner != "" && opts.repo != "" {
+		repository, resp, err = client.Repositories.Get(ctx, opts.owner, opts.repo)
+		if err != nil {
+			check.status, check.detail = checkFail, fmt.Sprintf("%v; %s mode needs %s", common.DiagnoseAccessError(ctx, client, opts.owner, opts.repo, err), opts.mode, joinPermissions(needed))
+			return check
+		}
+	} else if _, resp, err = client.RateLimit.Get(ctx); err != nil {
+		check.status, check.detail = checkFail, err.Error()
+		return check
+	}
+
+	if scopes, ok := resp.Header[http.CanonicalHeaderKey("X-OAuth-Scopes")]; ok {
+		return classicTokenPermissions(check, opts.mode, needed, strings.Join(scopes, ","))
+	}
+	if repository == nil {
+		check.status, check.detail = checkWarn, fmt.Sprintf("fine-grained or GitHub App token; set -owner and -repo-name to check it grants %s", joinPermissions(needed))
+		return check
+	}
+	return fineGrainedTokenPermissions(ctx, check, client, opts, repository, needed)
+}
+
+// classicTokenPermissions checks the scopes of a classic token
+func classicTokenPermissions(check doctorCheck, mode string, needed []tokenPermission, header string) doctorCheck {
+	granted := make(map[string]bool)
+	for _, scope := range strings.Split(header, ",") {
+		granted[strings.TrimSpace(scope)] = true
+	}
+
+	var missing []string
+	for _, permission := range needed {
+		// Reading public metadata needs no scope
+		if permission.name == "metadata" {
+			continue
+		}
+		scopes := classicScopes[permission.name]
+		if !grantsAny(granted, scopes) {
+			missing = append(missing, strings.Join(scopes, " or "))
+		}
+	}
+	if len(missing) > 0 {
+		check.status, check.detail = checkFail, fmt.Sprintf("classic token lacks the %s scope needed by %s mode; add it at https://github.com/settings/tokens", strings.Join(uniqueScopes(missing), ", "), mode)
+		return check
+	}
+	check.status, check.detail = checkPass, fmt.Sprintf("classic token grants %s mode %s", mode, joinPermissions(needed))
+	return check
+}
+
+// fineGrainedTokenPermissions checks a fine-grained or GitHub App token
+// against the repository. Write access to pull requests and workflows can
+// only be verified by writing, so it is left as a warning.
+func fineGrainedTokenPermissions(ctx context.Context, check doctorCheck, client *github.Client, opts doctorOptions, repository *github.Repository, needed []tokenPermission) doctorCheck {
+	var missing, unverified []string
+	for _, permission := range needed {
+		switch permission.name {
+		case "metadata":
+			// Reading the repository needs metadata: read
+		case "contents":
+			// Installation tokens do not report per-user permissions
+			if repository.Permissions == nil {
+				unverified = append(unverified, permission.String())
+			} else if !repository.Permissions["push"] {
+				missing = append(missing, permission.String())
+			}
+		case "pull_requests":
+			_, _, err := client.PullRequests.List(ctx, opts.owner, opts.repo, &github.PullRequestListOptions{ListOptions: github.ListOptions{PerPage: 1}})
+			if common.APIErrorStatus(err) == http.StatusForbidden {
+				missing = append(missing, permission.String())
+			} else {
+				unverified = append(unverified, permission.String())
+			}
+		default:
+			unverified = append(unverified, permission.String())
+		}
+	}
+
+	target := opts.owner + "/" + opts.repo
+	switch {
+	case len(missing) > 0:
+		check.status, check.detail = checkFail, fmt.Sprintf("token lacks %s on %s needed by %s mode; grant it in the token or GitHub App settings", strings.Join(missing, ", "), target, opts.mode)
+	case len(unverified) > 0:
+		check.status, check.detail = checkWarn, fmt.Sprintf("%s mode also needs %s on %s, which cannot be verified without writing; make sure the token grants it", opts.mode, strings.Join(unverified, ", "), target)
+	default:
+		check.status, check.detail = checkPass, fmt.Sprintf("token grants %s mode %s on %s", opts.mode, joinPermissions(needed), target)
+	}
+	return check
+}
+
+// joinPermissions formats permissions as a list
+func joinPermissions(permissions []tokenPermission) string {
+	names := make([]string, len(permissions))
+	for i, permission := range permissions {
+		names[i] = permission.String()
+	}
+	return strings.Join(names, ", ")
+}
+
+// grantsAny reports whether any of scopes is granted
+func grantsAny(granted map[string]bool, scopes []string) bool {
+	for _, scope := range scopes {
+		if granted[scope] {
+			return true
+		}
+	}
+	return false
+}
+
+// uniqueScopes returns scopes without repetitions, in order
+func uniqueScopes(scopes []string) []string {
+	seen := make(map[string]bool, len(scopes))
+	var unique []string
+	for _, scope := range scopes {
+		if !seen[scope] {
+			seen[scope] = true
+			unique = append(unique, scope)
+		}
+	}
+	return unique
+}
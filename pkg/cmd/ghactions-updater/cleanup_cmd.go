@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/updater"
+)
+
+// cleanupOptions are the flags of the cleanup subcommand
+type cleanupOptions struct {
+	owner    string
+	repo     string
+	token    string
+	template string
+	dryRun   bool
+}
+
+// register declares the flags on fs
+func (o *cleanupOptions) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.owner, "owner", "", "Repository owner")
+	fs.StringVar(&o.repo, "repo-name", "", "Repository name")
+	fs.StringVar(&o.token, "token", "", "GitHub token (default from the environment or gh CLI, see the README)")
+	fs.StringVar(&o.template, "branch-template", updater.DefaultBranchTemplate, "Template the update branches were named with")
+	fs.BoolVar(&o.dryRun, "dry-run", false, "Only list the branches that would be deleted")
+}
+
+// runCleanupCommand implements the "cleanup" subcommand:
+//
+//	ghactions-updater cleanup -owner o -repo-name r [-token t] [-branch-template t] [-dry-run]
+//
+// It deletes the update branches that no open pull request uses, such as
+// the branches left behind by merged or closed pull requests.
+func runCleanupCommand(args []string, stdout io.Writer) error {
+	var opts cleanupOptions
+	fs := newFlagSet("cleanup", stdout, opts.register)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	for _, required := range []struct{ flag, value string }{
+		{"owner", opts.owner}, {"repo-name", opts.repo},
+	} {
+		if required.value == "" {
+			return fmt.Errorf(common.ErrMissingRequiredFlag, required.flag)
+		}
+	}
+	branches, err := updater.ParseBranchTemplate(opts.template)
+	if err != nil {
+		return fmt.Errorf(common.ErrInvalidFlagValue, "branch-template", err.Error())
+	}
+	if opts.token == "" {
+		found, err := githubToken()
+		if err != nil {
+			return err
+		}
+		opts.token = found
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	client := githubClientFactory(opts.token)
+	stale, err := updater.StaleBranches(ctx, client, opts.owner, opts.repo, branches)
+	if err != nil {
+		return err
+	}
+	for _, branch := range stale {
+		if opts.dryRun {
+			_, _ = fmt.Fprintf(stdout, "Would delete branch %s\n", branch)
+			continue
+		}
+		if err := updater.DeleteBranch(ctx, client, opts.owner, opts.repo, branch); err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(stdout, "Deleted branch %s\n", branch)
+	}
+	_, _ = fmt.Fprintf(stdout, "%d stale update branches\n", len(stale))
+	return nil
+}
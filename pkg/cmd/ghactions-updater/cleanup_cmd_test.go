@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRunCleanupCommand(t *testing.T) {
+	setupRunEnv(t, nil, &mockVersionChecker{}, &recordingPRCreator{})
+	var deleted []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/octo/app/pulls", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"head":{"ref":"action-updates-20240102-030405"}}]`)
+	})
+	mux.HandleFunc("/repos/octo/app/git/matching-refs/heads/action-updates-", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"ref":"refs/heads/action-updates-20240102-030405"},{"ref":"refs/heads/action-updates-20240101-030405"},{"ref":"refs/heads/action-updates-manual"}]`)
+	})
+	mux.HandleFunc("/repos/octo/app/git/refs/heads/", func(w http.ResponseWriter, r *http.Request) {
+		deleted = append(deleted, r.Method+" "+r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	useGitHubServer(t, mux)
+
+	var out bytes.Buffer
+	if err := runCleanupCommand([]string{"-owner", "octo", "-repo-name", "app", "-dry-run"}, &out); err != nil {
+		t.Fatalf("runCleanupCommand(-dry-run) error = %v", err)
+	}
+	if !strings.Contains(out.String(), "Would delete branch action-updates-20240101-030405\n1 stale update branches") || len(deleted) != 0 {
+		t.Errorf("-dry-run output = %q, deleted = %v", out.String(), deleted)
+	}
+
+	out.Reset()
+	if err := runCleanupCommand([]string{"-owner", "octo", "-repo-name", "app"}, &out); err != nil {
+		t.Fatalf("runCleanupCommand() error = %v", err)
+	}
+	if len(deleted) != 1 || deleted[0] != "DELETE /repos/octo/app/git/refs/heads/action-updates-20240101-030405" {
+		t.Errorf("deleted = %v", deleted)
+	}
+
+	if err := runCleanupCommand([]string{"-owner", "octo"}, &out); err == nil || !strings.Contains(err.Error(), "repo-name") {
+		t.Errorf("runCleanupCommand() without -repo-name error = %v", err)
+	}
+}
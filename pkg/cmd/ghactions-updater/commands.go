@@ -0,0 +1,128 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+)
+
+// subcommand is a command of the CLI, selected by the first argument. The
+// tree of subcommands and their flags also generates the docs and shell
+// completions.
+type subcommand struct {
+	name     string
+	summary  string
+	args     string                 // Positional arguments after the flags
+	flags    func(fs *flag.FlagSet) // Declares the flags; nil for none
+	global   bool                   // Also takes the global flags
+	commands []subcommand           // Nested commands, e.g. "report merge"
+	run      func(name string, args []string, stdout io.Writer) error
+}
+
+// commandTree lists the commands of the CLI. Without one, the global flags
+// select the update run as before subcommands existed.
+func commandTree() []subcommand {
+	return []subcommand{
+		{name: "scan", summary: "List the action references of the workflows", flags: func(fs *flag.FlagSet) { new(scanOptions).register(fs) }, run: withoutName(runScanCommand)},
+		{name: "update", summary: "Apply updates to the local checkout (-dry-run only shows them)", global: true, run: runUpdateCommand},
+		{name: "pr", summary: "Apply updates in a pull request", global: true, run: runUpdateCommand},
+		{name: "report", summary: "Merge the JSON reports of sharded runs", run: withoutName(runReportCommand), commands: []subcommand{
+			{name: "merge", summary: "Merge the JSON reports of sharded runs", args: "report.json...", flags: func(fs *flag.FlagSet) { new(reportMergeOptions).register(fs) }},
+		}},
+		{name: "cleanup", summary: "Delete update branches no open pull request uses", flags: func(fs *flag.FlagSet) { new(cleanupOptions).register(fs) }, run: withoutName(runCleanupCommand)},
+		{name: "campaign", summary: "Bump one action to a version across repositories", flags: func(fs *flag.FlagSet) { new(campaignFlags).register(fs) }, global: true, run: withoutName(runCampaignCommand)},
+		{name: "config", summary: "Migrate a configuration file to the current schema", run: withoutName(runConfigCommand), commands: []subcommand{
+			{name: "migrate", summary: "Migrate a configuration file to the current schema", args: "[file]", flags: func(fs *flag.FlagSet) { new(configMigrateOptions).register(fs) }},
+		}},
+		{name: "doctor", summary: "Diagnose the token, API access and workflows", flags: func(fs *flag.FlagSet) { new(doctorOptions).register(fs) }, run: withoutName(runDoctorCommand)},
+		{name: "check-auth", summary: "Check the token grants what a mode needs", flags: func(fs *flag.FlagSet) { new(checkAuthOptions).register(fs) }, run: withoutName(runCheckAuthCommand)},
+		{name: "pin", summary: "Pin the actions of one workflow, read from stdin, to stdout", args: "-|file", flags: func(fs *flag.FlagSet) { new(pinOptions).register(fs) }, run: withoutName(runPinCommand)},
+		{name: "runners", summary: "List jobs running on mutable runner labels", flags: func(fs *flag.FlagSet) { new(runnersOptions).register(fs) }, run: withoutName(runRunnersCommand)},
+		{name: "snooze", summary: "Defer an update for a repository", args: "owner/action[@version]", flags: func(fs *flag.FlagSet) { new(snoozeFlags).register(fs) }, run: runSnoozeCommand},
+		{name: "unsnooze", summary: "Remove a snooze", args: "owner/action[@version]", flags: func(fs *flag.FlagSet) { new(snoozeFlags).register(fs) }, run: runSnoozeCommand},
+		{name: "docs", summary: "Print the reference of every command and flag as Markdown", run: withoutName(runDocsCommand)},
+		{name: "completion", summary: "Print a shell completion script", args: "bash|zsh|fish", run: withoutName(runCompletionCommand)},
+		{name: "help", summary: "List the commands", run: runHelpCommand},
+	}
+}
+
+// withoutName adapts a subcommand that does not need its name
+func withoutName(run func(args []string, stdout io.Writer) error) func(string, []string, io.Writer) error {
+	return func(_ string, args []string, stdout io.Writer) error {
+		return run(args, stdout)
+	}
+}
+
+// runCommand runs cmd with args. A request for help with -h is no error:
+// the flag set has already printed the usage.
+func runCommand(cmd subcommand, args []string, stdout io.Writer) error {
+	if err := cmd.run(cmd.name, args, stdout); err != nil && !errors.Is(err, flag.ErrHelp) {
+		return err
+	}
+	return nil
+}
+
+// newFlagSet creates the flag set of a subcommand with the flags declared
+// by register
+func newFlagSet(name string, output io.Writer, register func(fs *flag.FlagSet)) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(output)
+	register(fs)
+	return fs
+}
+
+// registerGlobalFlags declares the flags of a regular run on fs, sharing
+// their values with the global flags
+func registerGlobalFlags(fs *flag.FlagSet) {
+	flag.CommandLine.VisitAll(func(f *flag.Flag) {
+		fs.Var(f.Value, f.Name, f.Usage)
+	})
+}
+
+// commandFlags returns the flags declared by cmd, without the global flags
+func commandFlags(cmd subcommand) []*flag.Flag {
+	if cmd.flags == nil {
+		return nil
+	}
+	return visitFlags(newFlagSet(cmd.name, io.Discard, cmd.flags))
+}
+
+// globalFlags returns the global flags
+func globalFlags() []*flag.Flag {
+	return visitFlags(newFlagSet("global", io.Discard, registerGlobalFlags))
+}
+
+// visitFlags returns the flags of fs sorted by name
+func visitFlags(fs *flag.FlagSet) []*flag.Flag {
+	var flags []*flag.Flag
+	fs.VisitAll(func(f *flag.Flag) { flags = append(flags, f) })
+	return flags
+}
+
+// findSubcommand returns the subcommand called name
+func findSubcommand(name string) (subcommand, bool) {
+	for _, cmd := range commandTree() {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	return subcommand{}, false
+}
+
+// runHelpCommand implements the "help" subcommand
+func runHelpCommand(_ string, _ []string, stdout io.Writer) error {
+	printCommands(stdout)
+	return nil
+}
+
+// printCommands lists the subcommands
+func printCommands(w io.Writer) {
+	_, _ = fmt.Fprintln(w, "Usage: ghactions-updater [command] [flags]")
+	_, _ = fmt.Fprintln(w, "\nCommands:")
+	for _, cmd := range commandTree() {
+		_, _ = fmt.Fprintf(w, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+	_, _ = fmt.Fprintln(w, "\nWithout a command, the flags below run an update as \"update\" with -dry-run or -stage, and as \"pr\" otherwise.")
+	_, _ = fmt.Fprintln(w, "Run \"ghactions-updater <command> -h\" for the flags of a command, or \"ghactions-updater docs\" for all of them.")
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+)
+
+func TestRunUpdateCommandModes(t *testing.T) {
+	workflow := "on: push\njobs:\n  build:\n    runs-on: ubuntu-latest\n    steps:\n      - uses: actions/checkout@v3\n"
+	checker := &mockVersionChecker{latestVersion: "v4.2.1", latestHash: "1234567890123456789012345678901234567890"}
+	creator := &recordingPRCreator{}
+	dir := setupRunEnv(t, map[string]string{".github/workflows/ci.yml": workflow}, checker, creator)
+	*owner, *repo = "", ""
+
+	*dryRun = true
+	if err := runUpdateCommand("pr", nil, nil); !errors.Is(err, common.ErrValidation) || !strings.Contains(err.Error(), "not supported by pr") {
+		t.Errorf("pr -dry-run error = %v", err)
+	}
+
+	*dryRun = false
+	if err := runUpdateCommand("update", nil, nil); err != nil {
+		t.Fatalf("update error = %v", err)
+	}
+	if !*stage || len(creator.updates) != 0 {
+		t.Errorf("update did not stage: stage = %v, PR updates = %d", *stage, len(creator.updates))
+	}
+	if content := readRepoFile(t, dir, ".github/workflows/ci.yml"); !strings.Contains(content, "actions/checkout@1234567890123456789012345678901234567890") {
+		t.Errorf("update did not apply the update: %q", content)
+	}
+
+	*stage = false
+	err := runUpdateCommand("pr", nil, nil)
+	if !strings.Contains(fmt.Sprint(err), "-owner and -repo-name are required and could not be detected") || common.CategoryOf(err).ExitCode() != 2 {
+		t.Errorf("pr without -owner error = %v", err)
+	}
+}
+
+func TestDetectRepositoryFromRemote(t *testing.T) {
+	config := "[remote \"origin\"]\n\turl = git@github.com:octo/tool.git\n[remote \"upstream\"]\n\turl = https://github.com/acme/tool\n"
+	setupRunEnv(t, map[string]string{".git/config": config}, &mockVersionChecker{}, &recordingPRCreator{})
+
+	*owner, *repo = "", ""
+	if err := detectRepository(); err != nil || *owner != "octo" || *repo != "tool" {
+		t.Errorf("detectRepository() = %s/%s, %v; want octo/tool from origin", *owner, *repo, err)
+	}
+
+	// Flags that are given win over the remote
+	*owner, *repo, *gitRemote = "", "fork", "upstream"
+	if err := detectRepository(); err != nil || *owner != "acme" || *repo != "fork" {
+		t.Errorf("detectRepository() = %s/%s, %v; want acme/fork", *owner, *repo, err)
+	}
+
+	// Only pull requests and an explicit -remote need the detection to work
+	*owner, *repo, *gitRemote, *dryRun = "", "", "", true
+	*repoPath = t.TempDir()
+	if err := detectRepository(); err != nil {
+		t.Errorf("detectRepository() error = %v for a dry run outside a clone", err)
+	}
+	*gitRemote = "origin"
+	if err := detectRepository(); err == nil || !strings.Contains(err.Error(), "could not be detected") {
+		t.Errorf("detectRepository() error = %v, want a detection error for -remote", err)
+	}
+}
+
+func TestHelpCommand(t *testing.T) {
+	var out bytes.Buffer
+	if err := runHelpCommand("help", nil, &out); err != nil {
+		t.Fatalf("runHelpCommand() error = %v", err)
+	}
+	for _, name := range []string{"scan", "update", "pr", "report", "cleanup", "help"} {
+		if _, ok := findSubcommand(name); !ok || !strings.Contains(out.String(), "  "+name+" ") {
+			t.Errorf("help output = %q, missing %s", out.String(), name)
+		}
+	}
+	if _, ok := findSubcommand("-dry-run"); ok {
+		t.Error("findSubcommand(-dry-run) found a subcommand")
+	}
+}
+
+func TestRunCommandHelpSucceeds(t *testing.T) {
+	// update and pr parse the global flags, which exit on -h by themselves
+	for _, args := range [][]string{
+		{"scan"}, {"report", "merge"}, {"cleanup"}, {"campaign"}, {"config", "migrate"}, {"doctor"},
+		{"check-auth"}, {"pin"}, {"runners"}, {"snooze"}, {"unsnooze"}, {"docs"},
+	} {
+		cmd, ok := findSubcommand(args[0])
+		if !ok {
+			t.Fatalf("findSubcommand(%s) found no subcommand", args[0])
+		}
+		var out bytes.Buffer
+		if err := runCommand(cmd, append(args[1:], "-h"), &out); err != nil {
+			t.Errorf("%s -h error = %v, want nil for exit code 0", strings.Join(args, " "), err)
+		}
+		if !strings.Contains(out.String(), "Usage of") {
+			t.Errorf("%s -h output = %q, want the usage", strings.Join(args, " "), out.String())
+		}
+	}
+
+	cmd, _ := findSubcommand("scan")
+	if err := runCommand(cmd, []string{"-no-such-flag"}, io.Discard); err == nil {
+		t.Error("scan -no-such-flag error = nil")
+	}
+}
+
+func TestDocsCommand(t *testing.T) {
+	var out bytes.Buffer
+	if err := runDocsCommand(nil, &out); err != nil {
+		t.Fatalf("runDocsCommand() error = %v", err)
+	}
+	for _, want := range []string{"## scan\n", "| `-out` |", "## report merge\n", "## Global flags", "| `-dry-run` |", "[global flags](#global-flags)"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("docs output missing %q", want)
+		}
+	}
+}
+
+func TestCompletionCommand(t *testing.T) {
+	tests := []struct {
+		shell   string
+		want    []string
+		wantErr bool
+	}{
+		{shell: "bash", want: []string{"complete -o default -F _ghactions_updater", `"report merge") words=`, "-out", "-dry-run"}},
+		{shell: "zsh", want: []string{"#compdef ghactions-updater", "'scan:List the action references of the workflows'", "'-out:"}},
+		{shell: "fish", want: []string{"-a scan", "'__fish_seen_subcommand_from report; and __fish_seen_subcommand_from merge'", "-o dry-run -d"}},
+		{shell: "powershell", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.shell, func(t *testing.T) {
+			var out bytes.Buffer
+			err := runCompletionCommand([]string{tt.shell}, &out)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("runCompletionCommand(%s) error = %v, wantErr %v", tt.shell, err, tt.wantErr)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("%s completion missing %q", tt.shell, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/updater"
+)
+
+// For testing
+var setCommitStatus = updater.SetCommitStatus
+
+// reportCommitStatus sets the -commit-status on the head commit of a pull
+// request just created. Failures are logged and never fail the run.
+func reportCommitStatus(ctx context.Context, repoOwner, repoName string, rep *updater.Report, pull updater.PullRequest) {
+	if pull.HeadSHA == "" {
+		return
+	}
+	description := updater.UpdateStatusDescription(len(pull.Updates), updater.UnpinnedRemaining(rep.RemoteActions, rep.Updates))
+	client := githubClientFactory(*token)
+	if err := setCommitStatus(ctx, client, repoOwner, repoName, pull.HeadSHA, *commitStatus, description); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+}
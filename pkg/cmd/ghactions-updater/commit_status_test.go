@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v72/github"
+)
+
+func TestRunCommitStatus(t *testing.T) {
+	const hash = "1234567890123456789012345678901234567890"
+	workflow := "on: push\njobs:\n  build:\n    runs-on: ubuntu-latest\n    steps:\n      - uses: actions/checkout@v3\n      - uses: actions/cache@v3\n"
+	creator := &numberedPRCreator{}
+	setupRunEnv(t, map[string]string{".github/workflows/ci.yml": workflow}, &mockVersionChecker{latestVersion: "v4", latestHash: hash}, creator)
+
+	var statuses []string
+	oldSet := setCommitStatus
+	defer func() { setCommitStatus = oldSet }()
+	setCommitStatus = func(ctx context.Context, client *github.Client, owner, repo, sha, kind, description string) error {
+		statuses = append(statuses, fmt.Sprintf("%s/%s@%s %s: %s", owner, repo, sha, kind, description))
+		return nil
+	}
+
+	*commitStatus = "check-run"
+	if err := validateFlags(); err != nil {
+		t.Fatalf("validateFlags() error = %v", err)
+	}
+	if err := run(); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	want := "test-owner/test-repo@sha1 check-run: 2 actions updated, 0 unpinned remaining"
+	if len(statuses) != 1 || statuses[0] != want {
+		t.Errorf("statuses = %v, want %q", statuses, want)
+	}
+
+	// Dry runs create no pull request and set no status
+	*dryRun = true
+	if err := run(); err != nil {
+		t.Fatalf("dry run error = %v", err)
+	}
+	if len(statuses) != 1 {
+		t.Errorf("dry run set a status: %v", statuses)
+	}
+
+	*commitStatus = "commit"
+	if err := validateFlags(); err == nil || !strings.Contains(err.Error(), "status or check-run") {
+		t.Errorf("validateFlags() error = %v, want invalid commit-status", err)
+	}
+}
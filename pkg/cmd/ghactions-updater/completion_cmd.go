@@ -0,0 +1,228 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+)
+
+// runCompletionCommand implements the "completion" subcommand:
+//
+//	source <(ghactions-updater completion bash)
+//	ghactions-updater completion zsh > "${fpath[1]}/_ghactions-updater"
+//	ghactions-updater completion fish > ~/.config/fish/completions/ghactions-updater.fish
+//
+// The scripts complete commands and flags from the command tree.
+func runCompletionCommand(args []string, stdout io.Writer) error {
+	if len(args) != 1 {
+		return fmt.Errorf(common.ErrInvalidFlagValue, "completion", "expected one shell: bash, zsh or fish")
+	}
+	var script string
+	switch args[0] {
+	case "bash":
+		script = bashCompletion()
+	case "zsh":
+		script = zshCompletion()
+	case "fish":
+		script = fishCompletion()
+	default:
+		return fmt.Errorf(common.ErrInvalidFlagValue, "completion", "unsupported shell "+args[0]+": expected bash, zsh or fish")
+	}
+	_, err := io.WriteString(stdout, script)
+	return err
+}
+
+// completionEntry is a completion context: the command words typed so far,
+// and the commands and flags completed after them
+type completionEntry struct {
+	path     string // e.g. "", "scan" or "report merge"
+	commands []subcommand
+	flags    []*flag.Flag
+}
+
+// completionEntries walks the command tree. The entry with the empty path
+// completes the first word: commands and the global flags.
+func completionEntries() []completionEntry {
+	entries := []completionEntry{{commands: commandTree(), flags: globalFlags()}}
+	var walk func(prefix string, cmds []subcommand)
+	walk = func(prefix string, cmds []subcommand) {
+		for _, cmd := range cmds {
+			entry := completionEntry{path: strings.TrimSpace(prefix + " " + cmd.name), commands: cmd.commands, flags: commandFlags(cmd)}
+			if cmd.global {
+				entry.flags = append(entry.flags, globalFlags()...)
+			}
+			entries = append(entries, entry)
+			walk(entry.path, cmd.commands)
+		}
+	}
+	walk("", commandTree())
+	return entries
+}
+
+// nestedCommands returns the names of the commands that have nested ones
+func nestedCommands() []string {
+	var names []string
+	for _, cmd := range commandTree() {
+		if len(cmd.commands) > 0 {
+			names = append(names, cmd.name)
+		}
+	}
+	return names
+}
+
+// isBoolFlag reports whether f takes no value
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+// bashCompletion returns the bash completion script
+func bashCompletion() string {
+	var b strings.Builder
+	b.WriteString("# bash completion for ghactions-updater\n")
+	b.WriteString("_ghactions_updater() {\n")
+	b.WriteString("    local cur=${COMP_WORDS[COMP_CWORD]} cmd=\"\" words\n")
+	b.WriteString("    if [[ $COMP_CWORD -gt 1 ]]; then cmd=${COMP_WORDS[1]}; fi\n")
+	fmt.Fprintf(&b, "    if [[ $COMP_CWORD -gt 2 && \" %s \" == *\" $cmd \"* ]]; then cmd=\"$cmd ${COMP_WORDS[2]}\"; fi\n", strings.Join(nestedCommands(), " "))
+	b.WriteString("    case \"$cmd\" in\n")
+	var root string
+	for _, entry := range completionEntries() {
+		words := completionWords(entry)
+		if entry.path == "" {
+			root = words
+			continue
+		}
+		fmt.Fprintf(&b, "        %q) words=%q ;;\n", entry.path, words)
+	}
+	fmt.Fprintf(&b, "        \"\") words=%q ;;\n", root)
+	// Without a command, the first word is a global flag
+	fmt.Fprintf(&b, "        *) words=%q ;;\n", flagWords(globalFlags()))
+	b.WriteString("    esac\n")
+	b.WriteString("    COMPREPLY=($(compgen -W \"$words\" -- \"$cur\"))\n")
+	b.WriteString("}\n")
+	b.WriteString("complete -o default -F _ghactions_updater ghactions-updater\n")
+	return b.String()
+}
+
+// completionWords returns the commands and flags of entry
+func completionWords(entry completionEntry) string {
+	var words []string
+	for _, cmd := range entry.commands {
+		words = append(words, cmd.name)
+	}
+	if flags := flagWords(entry.flags); flags != "" {
+		words = append(words, flags)
+	}
+	return strings.Join(words, " ")
+}
+
+// flagWords returns the flags as -name words
+func flagWords(flags []*flag.Flag) string {
+	words := make([]string, 0, len(flags))
+	for _, f := range flags {
+		words = append(words, "-"+f.Name)
+	}
+	return strings.Join(words, " ")
+}
+
+// zshCompletion returns the zsh completion script
+func zshCompletion() string {
+	var b strings.Builder
+	b.WriteString("#compdef ghactions-updater\n\n")
+	b.WriteString("_ghactions_updater() {\n")
+	b.WriteString("    local -a commands flags\n")
+	b.WriteString("    local cmd=\"\"\n")
+	b.WriteString("    (( CURRENT > 2 )) && cmd=${words[2]}\n")
+	fmt.Fprintf(&b, "    (( CURRENT > 3 )) && [[ \" %s \" == *\" $cmd \"* ]] && cmd=\"$cmd ${words[3]}\"\n", strings.Join(nestedCommands(), " "))
+	b.WriteString("    case \"$cmd\" in\n")
+	var root completionEntry
+	for _, entry := range completionEntries() {
+		if entry.path == "" {
+			root = entry
+			continue
+		}
+		fmt.Fprintf(&b, "        %q)\n", entry.path)
+		writeZshEntry(&b, entry)
+		b.WriteString("            ;;\n")
+	}
+	b.WriteString("        \"\")\n")
+	writeZshEntry(&b, root)
+	b.WriteString("            ;;\n")
+	b.WriteString("        *)\n")
+	writeZshEntry(&b, completionEntry{flags: globalFlags()})
+	b.WriteString("            ;;\n")
+	b.WriteString("    esac\n")
+	b.WriteString("    _describe 'command' commands || _describe 'flag' flags || _files\n")
+	b.WriteString("}\n\n")
+	b.WriteString("compdef _ghactions_updater ghactions-updater\n")
+	return b.String()
+}
+
+// writeZshEntry sets the commands and flags arrays of entry
+func writeZshEntry(b *strings.Builder, entry completionEntry) {
+	b.WriteString("            commands=(")
+	for _, cmd := range entry.commands {
+		b.WriteString(" " + zshQuote(cmd.name+":"+strings.ReplaceAll(cmd.summary, ":", `\:`)))
+	}
+	b.WriteString(" )\n            flags=(")
+	for _, f := range entry.flags {
+		b.WriteString(" " + zshQuote("-"+f.Name+":"+strings.ReplaceAll(firstLine(f.Usage), ":", `\:`)))
+	}
+	b.WriteString(" )\n")
+}
+
+// zshQuote quotes s for zsh in single quotes
+func zshQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// fishCompletion returns the fish completion script
+func fishCompletion() string {
+	var b strings.Builder
+	b.WriteString("# fish completion for ghactions-updater\n")
+	for _, entry := range completionEntries() {
+		// Conditions under which the entry's commands and flags apply
+		var condition string
+		switch {
+		case entry.path == "":
+			condition = "__fish_use_subcommand"
+		case !strings.Contains(entry.path, " "):
+			condition = "__fish_seen_subcommand_from " + entry.path
+			if len(entry.commands) > 0 {
+				var nested []string
+				for _, cmd := range entry.commands {
+					nested = append(nested, cmd.name)
+				}
+				condition += "; and not __fish_seen_subcommand_from " + strings.Join(nested, " ")
+			}
+		default:
+			parent, name, _ := strings.Cut(entry.path, " ")
+			condition = "__fish_seen_subcommand_from " + parent + "; and __fish_seen_subcommand_from " + name
+		}
+		for _, cmd := range entry.commands {
+			fmt.Fprintf(&b, "complete -c ghactions-updater -f -n %s -a %s -d %s\n", fishQuote(condition), cmd.name, fishQuote(cmd.summary))
+		}
+		for _, f := range entry.flags {
+			value := " -r -F"
+			if isBoolFlag(f) {
+				value = ""
+			}
+			fmt.Fprintf(&b, "complete -c ghactions-updater -n %s -o %s%s -d %s\n", fishQuote(condition), f.Name, value, fishQuote(firstLine(f.Usage)))
+		}
+	}
+	return b.String()
+}
+
+// fishQuote quotes s for fish in single quotes
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(s) + "'"
+}
+
+// firstLine returns the first line of a flag's usage
+func firstLine(usage string) string {
+	line, _, _ := strings.Cut(usage, "\n")
+	return line
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/config"
+)
+
+// configMigrateOptions are the flags of the config migrate subcommand
+type configMigrateOptions struct {
+	write bool
+}
+
+// register declares the flags on fs
+func (o *configMigrateOptions) register(fs *flag.FlagSet) {
+	fs.BoolVar(&o.write, "w", false, "Rewrite the file in place instead of printing it")
+}
+
+// runConfigCommand implements "config migrate", which upgrades a configuration
+// file to the current schema version
+func runConfigCommand(args []string, stdout io.Writer) error {
+	if len(args) == 0 || args[0] != "migrate" {
+		return fmt.Errorf(common.ErrInvalidFlagValue, "config", "expected subcommand: migrate")
+	}
+
+	var opts configMigrateOptions
+	fs := newFlagSet("config migrate", stdout, opts.register)
+	if err := parseFlags(fs, args[1:]); err != nil {
+		return err
+	}
+	path := config.DefaultFile
+	if fs.NArg() > 0 {
+		path = fs.Arg(0)
+	}
+
+	content, err := os.ReadFile(path) // #nosec G304 - path is provided by the user
+	if err != nil {
+		return fmt.Errorf(common.ErrReadingConfig, path, err)
+	}
+	migrated, changes, err := config.Migrate(content)
+	if err != nil {
+		return fmt.Errorf(common.ErrParsingConfig, path, err)
+	}
+
+	if !opts.write {
+		_, err = stdout.Write(migrated)
+		return err
+	}
+	if len(changes) == 0 {
+		_, _ = fmt.Fprintf(stdout, "%s is already at schema version %d\n", path, config.CurrentVersion())
+		return nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf(common.ErrReadingConfig, path, err)
+	}
+	if err := os.WriteFile(path, migrated, info.Mode().Perm()); err != nil {
+		return fmt.Errorf(common.ErrWritingConfig, path, err)
+	}
+	for _, change := range changes {
+		_, _ = fmt.Fprintf(stdout, "%s: %s\n", path, change)
+	}
+	return nil
+}
+
+// applyConfigFiles sets the flags of fs from -config and -central-config.
+// Command line flags take precedence over -config, which takes precedence
+// over the central configuration.
+func applyConfigFiles(fs *flag.FlagSet, warnings io.Writer) error {
+	if *configPath != "" {
+		if err := applyConfigFile(fs, *configPath, warnings); err != nil {
+			return err
+		}
+	}
+	if *centralConfig == "" {
+		return nil
+	}
+	src, err := config.ParseSource(*centralConfig)
+	if err != nil {
+		return err
+	}
+	apiToken := *token
+	if apiToken == "" {
+		if apiToken, err = githubToken(); err != nil {
+			return err
+		}
+	}
+	cfg, err := config.Fetch(context.Background(), githubClientFactory(apiToken), src, centralConfigCache(), *centralTTL)
+	if err != nil {
+		return err
+	}
+	// The central configuration cannot point at another one
+	if _, ok := cfg.Settings["central-config"]; ok {
+		return fmt.Errorf(common.ErrUnknownConfigKey, src, "central-config")
+	}
+	return applyConfig(fs, cfg, src.String(), warnings)
+}
+
+// centralConfigCache returns the directory caching central configurations,
+// or "" when the user has no cache directory
+func centralConfigCache() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "ghactions-updater", "config")
+}
+
+// applyConfigFile sets the flags of fs from the configuration file at path.
+// Flags given on the command line keep their values.
+func applyConfigFile(fs *flag.FlagSet, path string, warnings io.Writer) error {
+	cfg, err := config.Load(path)
+	if err != nil {
+		return err
+	}
+	return applyConfig(fs, cfg, path, warnings)
+}
+
+// applyConfig sets the flags of fs from cfg, read from source. Flags already
+// set, on the command line or by another configuration, keep their values.
+func applyConfig(fs *flag.FlagSet, cfg *config.Config, source string, warnings io.Writer) error {
+	for _, warning := range cfg.Warnings {
+		_, _ = fmt.Fprintln(warnings, warning)
+	}
+
+	explicit := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+
+	for name, value := range cfg.Settings {
+		if fs.Lookup(name) == nil || name == "config" {
+			return fmt.Errorf(common.ErrUnknownConfigKey, source, name)
+		}
+		if explicit[name] {
+			continue
+		}
+		if err := fs.Set(name, value); err != nil {
+			return fmt.Errorf(common.ErrConfigValue, source, name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestApplyConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	content := "version: 1\nworkflows-path: ci/workflows\ndry-run: true\nowner: from-config\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	workflows := fs.String("workflows-path", ".github/workflows", "")
+	dry := fs.Bool("dry-run", false, "")
+	own := fs.String("owner", "", "")
+	if err := fs.Parse([]string{"-owner", "from-flag"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := applyConfigFile(fs, path, &bytes.Buffer{}); err != nil {
+		t.Fatalf("applyConfigFile() error = %v", err)
+	}
+	if *workflows != "ci/workflows" || !*dry {
+		t.Errorf("flags = %q, %v, want values from config", *workflows, *dry)
+	}
+	if *own != "from-flag" {
+		t.Errorf("owner = %q, want command line value to win", *own)
+	}
+
+	if err := os.WriteFile(path, []byte("bogus: 1\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := applyConfigFile(fs, path, &bytes.Buffer{}); err == nil || !strings.Contains(err.Error(), "unknown setting") {
+		t.Errorf("applyConfigFile() error = %v, want unknown setting", err)
+	}
+}
+
+func TestApplyCentralConfig(t *testing.T) {
+	setupRunEnv(t, nil, nil, nil)
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	central := "version: 1\nworkflows-path: central\nbase-branch: central\nbranch-template: central-{date}\n"
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/my-org/.github/contents/ghactions-updater.yml", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"type":"file","encoding":"base64","content":"%s"}`, base64.StdEncoding.EncodeToString([]byte(central)))
+	})
+	useGitHubServer(t, mux)
+
+	local := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(local, []byte("workflows-path: local\nbase-branch: local\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	*configPath, *centralConfig = local, "my-org/.github"
+
+	// Precedence: central < -config < command line
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	workflows := fs.String("workflows-path", ".github/workflows", "")
+	base := fs.String("base-branch", "", "")
+	branches := fs.String("branch-template", "", "")
+	if err := fs.Parse([]string{"-base-branch", "cli"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := applyConfigFiles(fs, &bytes.Buffer{}); err != nil {
+		t.Fatalf("applyConfigFiles() error = %v", err)
+	}
+	if *base != "cli" || *workflows != "local" || *branches != "central-{date}" {
+		t.Errorf("flags = %q, %q, %q, want cli, local and central values", *base, *workflows, *branches)
+	}
+
+	central = "central-config: other/.github\n"
+	*configPath, *centralConfig = "", "my-org/.github/ghactions-updater.yml@main"
+	err := applyConfigFiles(flag.NewFlagSet("test", flag.ContinueOnError), &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), `unknown setting "central-config"`) {
+		t.Errorf("applyConfigFiles() with a nested central config error = %v", err)
+	}
+
+	*centralConfig = "my-org"
+	if err := applyConfigFiles(fs, &bytes.Buffer{}); err == nil || !strings.Contains(err.Error(), "invalid central config") {
+		t.Errorf("applyConfigFiles() with an invalid source error = %v", err)
+	}
+}
+
+func TestRunConfigCommand(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(path, []byte("# comment\nversion: 1\ndry-run: true\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := runConfigCommand([]string{"migrate", path}, &out); err != nil {
+		t.Fatalf("runConfigCommand() error = %v", err)
+	}
+	if !strings.Contains(out.String(), "# comment") || !strings.Contains(out.String(), "dry-run: true") {
+		t.Errorf("output = %q", out.String())
+	}
+
+	out.Reset()
+	if err := runConfigCommand([]string{"migrate", "-w", path}, &out); err != nil {
+		t.Fatalf("runConfigCommand(-w) error = %v", err)
+	}
+	if !strings.Contains(out.String(), "already at schema version 1") {
+		t.Errorf("output = %q", out.String())
+	}
+
+	if err := runConfigCommand(nil, &out); err == nil {
+		t.Error("runConfigCommand() without subcommand succeeded, want error")
+	}
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/credentials"
+)
+
+// credentialTimeout bounds the search for a token, e.g. a hanging gh CLI
+const credentialTimeout = 30 * time.Second
+
+// credentialChain returns where a GitHub token is looked for when -token is
+// omitted; tests replace it to keep the developer's login out of them
+var credentialChain = func() credentials.Chain {
+	return credentials.Default(credentials.GitHubHost)
+}
+
+// githubToken returns the first token of the credential chain, "" when
+// there is none
+func githubToken() (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), credentialTimeout)
+	defer cancel()
+	token, source, err := credentialChain().Token(ctx)
+	if err != nil {
+		return "", err
+	}
+	if token != "" {
+		log.Printf("Using the token from %s", source)
+	}
+	return token, nil
+}
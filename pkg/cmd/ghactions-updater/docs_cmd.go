@@ -0,0 +1,82 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// runDocsCommand implements the "docs" subcommand:
+//
+//	ghactions-updater docs > docs/cli.md
+//
+// It prints the reference of every command and flag as Markdown, generated
+// from the command tree so it cannot fall out of date.
+func runDocsCommand(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("docs", flag.ContinueOnError)
+	fs.SetOutput(stdout)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	b.WriteString("# ghactions-updater\n\n")
+	b.WriteString("```\nghactions-updater [command] [flags]\n```\n\n")
+	b.WriteString("Without a command, the global flags run an update like `update` with `-dry-run` or `-stage`, and like `pr` otherwise.\n\n")
+	b.WriteString("Every flag can also be set through the environment as `GHUP_` followed by its name in upper case with `-` replaced by `_`, e.g. `GHUP_DRY_RUN=true`. Command line flags take precedence over the environment, which takes precedence over configuration files.\n\n")
+	b.WriteString("| Command | Description |\n|---------|-------------|\n")
+	for _, cmd := range commandTree() {
+		fmt.Fprintf(&b, "| `%s` | %s |\n", cmd.name, cmd.summary)
+	}
+
+	for _, cmd := range commandTree() {
+		writeCommandDocs(&b, "ghactions-updater", cmd)
+	}
+	b.WriteString("\n## Global flags\n\nThe flags of `update`, `pr` and runs without a command.\n\n")
+	writeFlagTable(&b, globalFlags())
+
+	_, err := io.WriteString(stdout, b.String())
+	return err
+}
+
+// writeCommandDocs writes the section of cmd and its nested commands
+func writeCommandDocs(b *strings.Builder, parent string, cmd subcommand) {
+	path := parent + " " + cmd.name
+	fmt.Fprintf(b, "\n## %s\n\n%s.\n", strings.TrimPrefix(path, "ghactions-updater "), cmd.summary)
+	if len(cmd.commands) > 0 {
+		for _, nested := range cmd.commands {
+			writeCommandDocs(b, path, nested)
+		}
+		return
+	}
+
+	usage := path
+	if cmd.flags != nil || cmd.global {
+		usage += " [flags]"
+	}
+	if cmd.args != "" {
+		usage += " " + cmd.args
+	}
+	fmt.Fprintf(b, "\n```\n%s\n```\n", usage)
+	if cmd.flags != nil {
+		b.WriteString("\n")
+		writeFlagTable(b, commandFlags(cmd))
+	}
+	if cmd.global {
+		b.WriteString("\nTakes the [global flags](#global-flags).\n")
+	}
+}
+
+// writeFlagTable writes flags as a Markdown table
+func writeFlagTable(b *strings.Builder, flags []*flag.Flag) {
+	b.WriteString("| Flag | Default | Description |\n|------|---------|-------------|\n")
+	for _, f := range flags {
+		def := ""
+		if f.DefValue != "" {
+			def = "`" + f.DefValue + "`"
+		}
+		usage := strings.ReplaceAll(f.Usage, "|", `\|`)
+		fmt.Fprintf(b, "| `-%s` | %s | %s |\n", f.Name, def, usage)
+	}
+}
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/updater"
+)
+
+// Doctor check outcomes
+const (
+	checkPass = "PASS"
+	checkWarn = "WARN"
+	checkFail = "FAIL"
+	checkSkip = "SKIP"
+)
+
+// For testing
+var (
+	lookPath   = exec.LookPath
+	gitVersion = func(ctx context.Context, path string) (string, error) {
+		out, err := exec.CommandContext(ctx, path, "--version").Output() // #nosec G204 - path comes from exec.LookPath("git")
+		return strings.TrimSpace(string(out)), err
+	}
+)
+
+// doctorCheck is one line of the doctor checklist
+type doctorCheck struct {
+	name   string
+	status string
+	detail string
+}
+
+// doctorOptions holds the settings checked by the doctor subcommand
+type doctorOptions struct {
+	token         string
+	owner         string
+	repo          string
+	repoPath      string
+	workflowsPath string
+	mode          string
+}
+
+// register declares the flags on fs
+func (o *doctorOptions) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.token, "token", "", "GitHub token (default from the environment or gh CLI, see the README)")
+	fs.StringVar(&o.owner, "owner", "", "Owner of the repository to check write access for")
+	fs.StringVar(&o.repo, "repo-name", "", "Name of the repository to check write access for")
+	fs.StringVar(&o.repoPath, "repo", ".", "Path to the local repository")
+	fs.StringVar(&o.workflowsPath, "workflows-path", ".github/workflows", "Path to workflow files (relative to repository root)")
+	fs.StringVar(&o.mode, "mode", authModePR, "Mode to check the token for: pr, stage or dry-run")
+}
+
+// runDoctorCommand implements the "doctor" subcommand:
+//
+//	ghactions-updater doctor [-token t] [-owner o -repo-name r] [-repo path] [-workflows-path p] [-mode m]
+//
+// It prints a pass/fail checklist and fails if any check failed.
+func runDoctorCommand(args []string, stdout io.Writer) error {
+	opts := doctorOptions{}
+	fs := newFlagSet("doctor", stdout, opts.register)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := validateAuthMode(opts.mode); err != nil {
+		return err
+	}
+	if opts.token == "" {
+		found, err := githubToken()
+		if err != nil {
+			return err
+		}
+		opts.token = found
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	return printChecks(stdout, runDoctorChecks(ctx, opts))
+}
+
+// printChecks prints the checklist and fails if any check failed
+func printChecks(stdout io.Writer, checks []doctorCheck) error {
+	failed := 0
+	for _, check := range checks {
+		_, _ = fmt.Fprintf(stdout, "[%s] %s: %s\n", check.status, check.name, check.detail)
+		if check.status == checkFail {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf(common.ErrDoctorFailed, failed, len(checks))
+	}
+	_, _ = fmt.Fprintln(stdout, "All checks passed")
+	return nil
+}
+
+// runDoctorChecks runs every check in order
+func runDoctorChecks(ctx context.Context, opts doctorOptions) []doctorCheck {
+	var checks []doctorCheck
+	checks = append(checks, checkToken(opts))
+	checks = append(checks, checkAPI(ctx, opts)...)
+	checks = append(checks, checkTokenScopes(ctx, opts))
+	checks = append(checks, checkTokenPermissions(ctx, opts))
+	checks = append(checks, checkRepositoryAccess(ctx, opts))
+	checks = append(checks, checkGit(ctx))
+	checks = append(checks, checkWorkflows(opts)...)
+	return checks
+}
+
+// checkToken checks that a token is configured and well formed
+func checkToken(opts doctorOptions) doctorCheck {
+	check := doctorCheck{name: "Token"}
+	if opts.token == "" {
+		check.status, check.detail = checkWarn, "no token; unauthenticated requests are limited to 60 per hour and pull requests cannot be created"
+		return check
+	}
+	info, err := common.ValidateGitHubToken(opts.token)
+	if err != nil {
+		check.status, check.detail = checkFail, err.Error()
+		return check
+	}
+	check.status, check.detail = checkPass, info.Type+" token"
+	return check
+}
+
+// checkAPI checks connectivity and the remaining rate limit. Reading the
+// rate limit does not count against it.
+func checkAPI(ctx context.Context, opts doctorOptions) []doctorCheck {
+	connectivity := doctorCheck{name: "API connectivity"}
+	rate := doctorCheck{name: "Rate limit"}
+
+	limits, _, err := githubClientFactory(opts.token).RateLimit.Get(ctx)
+	if err != nil {
+		connectivity.status, connectivity.detail = checkFail, err.Error()
+		rate.status, rate.detail = checkSkip, "API not reachable"
+		return []doctorCheck{connectivity, rate}
+	}
+	connectivity.status, connectivity.detail = checkPass, "GitHub API reachable"
+
+	core := limits.GetCore()
+	if core == nil {
+		rate.status, rate.detail = checkWarn, common.ErrNoRateLimitInfo
+		return []doctorCheck{connectivity, rate}
+	}
+	rate.detail = fmt.Sprintf("%d of %d core requests remaining, resets at %s", core.Remaining, core.Limit, core.Reset.Format(time.RFC3339))
+	switch {
+	case core.Remaining == 0:
+		rate.status = checkFail
+	case core.Remaining*10 < core.Limit:
+		rate.status = checkWarn
+	default:
+		rate.status = checkPass
+	}
+	return []doctorCheck{connectivity, rate}
+}
+
+// checkTokenScopes validates the scopes needed to create pull requests
+func checkTokenScopes(ctx context.Context, opts doctorOptions) doctorCheck {
+	check := doctorCheck{name: "Token scopes"}
+	if opts.token == "" {
+		check.status, check.detail = checkSkip, "no token"
+		return check
+	}
+	if opts.mode != authModePR {
+		check.status, check.detail = checkSkip, opts.mode+" mode creates no pull requests"
+		return check
+	}
+	if err := tokenValidatorFactory(opts.token)(ctx); err != nil {
+		check.status, check.detail = checkFail, err.Error()
+		return check
+	}
+	check.status, check.detail = checkPass, "token can create pull requests"
+	return check
+}
+
+// checkRepositoryAccess checks that the token can push to the target repository
+func checkRepositoryAccess(ctx context.Context, opts doctorOptions) doctorCheck {
+	check := doctorCheck{name: "Repository write access"}
+	if opts.owner == "" || opts.repo == "" {
+		check.status, check.detail = checkSkip, "-owner and -repo-name not set"
+		return check
+	}
+	if opts.mode != authModePR {
+		check.status, check.detail = checkSkip, opts.mode+" mode does not push"
+		return check
+	}
+
+	client := githubClientFactory(opts.token)
+	repository, _, err := client.Repositories.Get(ctx, opts.owner, opts.repo)
+	if err != nil {
+		check.status, check.detail = checkFail, common.DiagnoseAccessError(ctx, client, opts.owner, opts.repo, err).Error()
+		return check
+	}
+
+	permissions := repository.GetPermissions()
+	switch {
+	case permissions == nil:
+		// Installation tokens do not report per-user permissions
+		check.status, check.detail = checkWarn, fmt.Sprintf("%s/%s is readable; the API did not report push permission", opts.owner, opts.repo)
+	case permissions["push"]:
+		check.status, check.detail = checkPass, fmt.Sprintf("token can push to %s/%s", opts.owner, opts.repo)
+	default:
+		check.status, check.detail = checkFail, fmt.Sprintf("token cannot push to %s/%s", opts.owner, opts.repo)
+	}
+	return check
+}
+
+// checkGit checks that git is installed
+func checkGit(ctx context.Context) doctorCheck {
+	check := doctorCheck{name: "git"}
+	path, err := lookPath("git")
+	if err != nil {
+		check.status, check.detail = checkFail, "git not found in PATH"
+		return check
+	}
+	out, err := gitVersion(ctx, path)
+	if err != nil {
+		check.status, check.detail = checkFail, fmt.Sprintf("%s --version: %v", path, err)
+		return check
+	}
+	check.status, check.detail = checkPass, out
+	return check
+}
+
+// checkWorkflows checks that the workflow files parse and that their
+// directory is writable for stage mode
+func checkWorkflows(opts doctorOptions) []doctorCheck {
+	syntax := doctorCheck{name: "Workflow syntax"}
+	writable := doctorCheck{name: "Local write access"}
+
+	root, err := filepath.Abs(opts.repoPath)
+	if err != nil {
+		syntax.status, syntax.detail = checkFail, err.Error()
+		writable.status, writable.detail = checkSkip, "repository path invalid"
+		return []doctorCheck{syntax, writable}
+	}
+	dir := filepath.Join(root, opts.workflowsPath)
+
+	scanner := updater.NewScanner(root)
+	files, err := scanner.ScanWorkflows(dir)
+	if err != nil {
+		syntax.status, syntax.detail = checkFail, err.Error()
+		writable.status, writable.detail = checkSkip, "workflows directory not readable"
+		return []doctorCheck{syntax, writable}
+	}
+
+	var invalid []string
+	for _, file := range files {
+		if _, err := scanner.ParseActionReferences(file); err != nil {
+			rel, _ := filepath.Rel(root, file)
+			invalid = append(invalid, fmt.Sprintf("%s (%v)", rel, err))
+		}
+	}
+	switch {
+	case len(files) == 0:
+		syntax.status, syntax.detail = checkWarn, fmt.Sprintf("no workflow files in %s", dir)
+	case len(invalid) > 0:
+		syntax.status, syntax.detail = checkFail, fmt.Sprintf("%d of %d files invalid: %s", len(invalid), len(files), strings.Join(invalid, "; "))
+	default:
+		syntax.status, syntax.detail = checkPass, fmt.Sprintf("%d workflow files parsed", len(files))
+	}
+
+	probe, err := os.CreateTemp(dir, ".ghactions-updater-doctor-")
+	if err != nil {
+		writable.status, writable.detail = checkFail, err.Error()
+		return []doctorCheck{syntax, writable}
+	}
+	_ = probe.Close()
+	_ = os.Remove(probe.Name())
+	writable.status, writable.detail = checkPass, dir+" is writable"
+	return []doctorCheck{syntax, writable}
+}
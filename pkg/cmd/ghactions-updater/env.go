@@ -0,0 +1,59 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+)
+
+// envPrefix starts the environment variable of every flag
+const envPrefix = "GHUP_"
+
+// envAliases are further variables read for a flag, after its own
+var envAliases = map[string][]string{
+	"owner":          {"OWNER"},
+	"repo-name":      {"GHUP_REPO", "REPO_NAME"},
+	"workflows-path": {"WORKFLOWS_PATH"},
+}
+
+// envName returns the environment variable of a flag, e.g. GHUP_DRY_RUN for
+// -dry-run
+func envName(flagName string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// parseFlags sets the flags of fs from the environment and then from args.
+// Flags on the command line take precedence over the environment, which
+// takes precedence over configuration files since those only set flags that
+// are not set yet.
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := applyEnv(fs, os.LookupEnv); err != nil {
+		return err
+	}
+	return fs.Parse(args)
+}
+
+// applyEnv sets each flag of fs whose environment variable, or one of its
+// aliases, is set according to lookup
+func applyEnv(fs *flag.FlagSet, lookup func(string) (string, bool)) error {
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		if err != nil {
+			return
+		}
+		for _, name := range append([]string{envName(f.Name)}, envAliases[f.Name]...) {
+			value, ok := lookup(name)
+			if !ok || value == "" {
+				continue
+			}
+			if setErr := fs.Set(f.Name, value); setErr != nil {
+				err = fmt.Errorf(common.ErrConfigValue, name, f.Name, setErr)
+			}
+			return
+		}
+	})
+	return err
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEnvName(t *testing.T) {
+	tests := map[string]string{
+		"owner":        "GHUP_OWNER",
+		"dry-run":      "GHUP_DRY_RUN",
+		"check-lock":   "GHUP_CHECK_LOCK",
+		"cache-ttl":    "GHUP_CACHE_TTL",
+		"version-info": "GHUP_VERSION_INFO",
+	}
+	for flagName, want := range tests {
+		if got := envName(flagName); got != want {
+			t.Errorf("envName(%q) = %q, want %q", flagName, got, want)
+		}
+	}
+}
+
+func TestParseFlagsEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	content := "version: 1\nowner: from-config\nrepo-name: from-config\nworkflows-path: ci/workflows\ndry-run: false\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GHUP_OWNER", "from-env")
+	t.Setenv("GHUP_DRY_RUN", "true")
+	t.Setenv("REPO_NAME", "from-alias")
+	t.Setenv("GHUP_WORKFLOWS_PATH", "")
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	own := fs.String("owner", "", "")
+	name := fs.String("repo-name", "", "")
+	workflows := fs.String("workflows-path", ".github/workflows", "")
+	dry := fs.Bool("dry-run", false, "")
+	if err := parseFlags(fs, []string{"-repo-name", "from-flag"}); err != nil {
+		t.Fatalf("parseFlags() error = %v", err)
+	}
+	if err := applyConfigFile(fs, path, &bytes.Buffer{}); err != nil {
+		t.Fatalf("applyConfigFile() error = %v", err)
+	}
+
+	// Flags win over the environment, which wins over the configuration
+	if *name != "from-flag" {
+		t.Errorf("repo-name = %q, want the command line value", *name)
+	}
+	if *own != "from-env" || !*dry {
+		t.Errorf("owner = %q, dry-run = %v, want the environment values", *own, *dry)
+	}
+	if *workflows != "ci/workflows" {
+		t.Errorf("workflows-path = %q, want the config value for an empty variable", *workflows)
+	}
+
+	t.Setenv("GHUP_DRY_RUN", "maybe")
+	err := parseFlags(flag.NewFlagSet("test", flag.ContinueOnError), nil)
+	if err != nil {
+		t.Errorf("parseFlags() error = %v for a variable of an undeclared flag", err)
+	}
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Bool("dry-run", false, "")
+	if err := parseFlags(fs, nil); err == nil || !strings.Contains(err.Error(), "GHUP_DRY_RUN") {
+		t.Errorf("parseFlags() error = %v, want an invalid GHUP_DRY_RUN value", err)
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/metrics"
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/storage"
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/updater"
+)
+
+// newEventBus creates the event bus of a run: events are counted in the
+// metrics and written to the audit log and the -event-sink sinks. Events
+// spooled by earlier runs are redelivered first.
+func newEventBus(ctx context.Context, store storage.Store) *updater.EventBus {
+	bus := updater.NewEventBus(store, retryPolicy())
+	bus.Subscribe(updater.MetricsEventSink{Registry: metrics.Default})
+	if *auditLog != "" {
+		bus.Subscribe(updater.NewFileEventSink(*auditLog))
+	}
+	for _, spec := range splitList(*eventSinks) {
+		sink, err := updater.NewEventSink(spec)
+		if err != nil {
+			log.Printf("Warning: %v", err)
+			continue
+		}
+		bus.Subscribe(sink)
+	}
+
+	delivered, err := bus.Redeliver(ctx)
+	if err != nil {
+		log.Printf("Warning: failed to redeliver events: %v", err)
+	}
+	if delivered > 0 {
+		log.Printf("Redelivered %d events from earlier runs", delivered)
+	}
+	return bus
+}
+
+// publishUpdates publishes the planned updates of a repository and the pull
+// requests opened for them
+func (r *repoRunner) publishUpdates(ctx context.Context, repository string, rep *updater.Report) {
+	for _, update := range rep.Updates {
+		r.events.Publish(ctx, updater.Event{
+			Type:       updater.EventUpdatePlanned,
+			Repository: repository,
+			Action:     update.Action.FullName(),
+			OldVersion: update.OldVersion,
+			NewVersion: update.NewVersion,
+		})
+	}
+	for _, pull := range rep.PullRequests {
+		r.events.Publish(ctx, updater.Event{Type: updater.EventPROpened, Repository: repository, PullRequest: pull.Number})
+	}
+}
+
+// publishError publishes a failure to process a repository. It is delivered
+// even when the run was cancelled.
+func (r *repoRunner) publishError(ctx context.Context, repository string, err error) {
+	r.events.Publish(context.WithoutCancel(ctx), updater.Event{Type: updater.EventError, Repository: repository, Detail: err.Error()})
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/updater"
+)
+
+func TestRunEventSinks(t *testing.T) {
+	workflow := "on: push\njobs:\n  build:\n    runs-on: ubuntu-latest\n    steps:\n      - uses: actions/checkout@v3\n      - uses: actions/setup-go@v4\n"
+	creator := &numberedPRCreator{}
+	setupRunEnv(t, map[string]string{".github/workflows/ci.yml": workflow}, &mockVersionChecker{latestVersion: "v5", latestHash: "abc123"}, creator)
+
+	var mu sync.Mutex
+	available := false
+	var received []updater.Event
+	sink := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if !available {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var event updater.Event
+		_ = json.NewDecoder(r.Body).Decode(&event)
+		received = append(received, event)
+	}))
+	defer sink.Close()
+
+	*storeLocation = filepath.Join(t.TempDir(), "store")
+	*eventSinks = "log," + sink.URL
+	*retryAttempts = 1
+	if err := validateFlags(); err != nil {
+		t.Fatalf("validateFlags() error = %v", err)
+	}
+
+	// Events the sink rejects are spooled to the store
+	if err := run(); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	if len(received) != 0 {
+		t.Fatalf("received %d events from an unavailable sink", len(received))
+	}
+
+	// The next run delivers them first, then its own events
+	available = true
+	if err := run(); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	var types []string
+	for _, event := range received {
+		types = append(types, event.Type)
+	}
+	want := "update-planned,update-planned,pr-opened,update-planned,update-planned,pr-opened"
+	if strings.Join(types, ",") != want {
+		t.Errorf("received %v, want %s", types, want)
+	}
+	if received[2].PullRequest != 1 || received[5].PullRequest != 2 || received[0].Action != "actions/checkout" {
+		t.Errorf("unexpected events %+v", received)
+	}
+}
+
+func TestValidateEventSinkFlag(t *testing.T) {
+	setupRunEnv(t, nil, &mockVersionChecker{}, &recordingPRCreator{})
+	*eventSinks = "log,slack"
+	if err := validateFlags(); err == nil || !strings.Contains(err.Error(), "unknown event sink") {
+		t.Errorf("validateFlags() error = %v, want unknown event sink", err)
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/report"
+)
+
+// inGitHubActions reports whether the tool runs as a GitHub Actions step
+func inGitHubActions() bool {
+	return os.Getenv("GITHUB_ACTIONS") == "true"
+}
+
+// githubOutputPath returns the file receiving the step outputs: -github-output,
+// or $GITHUB_OUTPUT inside GitHub Actions
+func githubOutputPath() string {
+	if *githubOutput != "" || !inGitHubActions() {
+		return *githubOutput
+	}
+	return os.Getenv("GITHUB_OUTPUT")
+}
+
+// writeAnnotations writes the findings of the repository at absPath as
+// workflow command annotations. Files are named relative to
+// $GITHUB_WORKSPACE when the repository is inside it.
+func writeAnnotations(w io.Writer, absPath string, rep *report.Report) {
+	root := absPath
+	if workspace := os.Getenv("GITHUB_WORKSPACE"); workspace != "" {
+		if rel, err := filepath.Rel(workspace, absPath); err == nil && !strings.HasPrefix(rel, "..") {
+			root = workspace
+		}
+	}
+	if err := rep.EncodeAnnotations(w, root); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+}
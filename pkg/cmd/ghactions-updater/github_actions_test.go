@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/report"
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/updater"
+)
+
+func TestWriteAnnotations(t *testing.T) {
+	workspace := t.TempDir()
+	repoDir := filepath.Join(workspace, "repo")
+	t.Setenv("GITHUB_WORKSPACE", workspace)
+
+	refs := []updater.ActionReference{
+		{Owner: "actions", Name: "checkout", Version: "v3", Path: filepath.Join(repoDir, ".github/workflows/ci.yml"), Line: 6},
+		{Owner: "actions", Name: "cache", Version: "v4", CommitHash: "1234567890123456789012345678901234567890", Path: filepath.Join(repoDir, ".github/workflows/ci.yml"), Line: 7},
+	}
+	rep := report.New("")
+	rep.Add(report.RepositoryResult{Owner: "o", Repo: "r", Unpinned: report.EntriesFromUnpinned(refs)})
+	var out bytes.Buffer
+	writeAnnotations(&out, repoDir, rep)
+
+	want := "::warning file=repo/.github/workflows/ci.yml,line=6,title=Unpinned action::actions/checkout@v3 is not pinned to a commit SHA\n"
+	if out.String() != want {
+		t.Errorf("annotations = %q, want %q", out.String(), want)
+	}
+
+	// Outside the workspace, files are named relative to the repository
+	t.Setenv("GITHUB_WORKSPACE", t.TempDir())
+	out.Reset()
+	writeAnnotations(&out, repoDir, rep)
+	if !strings.Contains(out.String(), "file=.github/workflows/ci.yml,") {
+		t.Errorf("annotations = %q, want a repository-relative file", out.String())
+	}
+}
+
+func TestRunGitHubOutputs(t *testing.T) {
+	workflow := "on: push\njobs:\n  build:\n    runs-on: ubuntu-latest\n    steps:\n      - uses: actions/checkout@v3\n"
+	checker := &mockVersionChecker{latestVersion: "v4.2.1", latestHash: "1234567890123456789012345678901234567890"}
+	setupRunEnv(t, map[string]string{".github/workflows/ci.yml": workflow}, checker, &recordingPRCreator{})
+
+	output := filepath.Join(t.TempDir(), "output")
+	t.Setenv("GITHUB_ACTIONS", "true")
+	t.Setenv("GITHUB_OUTPUT", output)
+	*dryRun = true
+	if err := run(); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+
+	content := readRepoFile(t, filepath.Dir(output), "output")
+	for _, want := range []string{"updates_count=1\n", "has_updates=true\n", "unpinned_count=1\n", "pr_url=\n"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("outputs = %q, want %q", content, want)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRunGitLabCIIncludes(t *testing.T) {
+	files := map[string]string{
+		".gitlab-ci.yml": "include:\n  - project: my-group/templates\n    ref: v1.0.0\n    file: /build.yml\n",
+	}
+	checker := &mockVersionChecker{latestVersion: "v2.0.0", latestHash: "1234567890123456789012345678901234567890"}
+
+	tests := []struct {
+		name      string
+		gitlab    bool
+		wantFile  string
+		wantError bool
+	}{
+		{name: "pinned with -gitlab-ci", gitlab: true, wantFile: "    ref: 1234567890123456789012345678901234567890  # v2.0.0\n"},
+		{name: "ignored without -gitlab-ci", gitlab: false, wantFile: "    ref: v1.0.0\n", wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := setupRunEnv(t, files, checker, &recordingPRCreator{})
+			*stage = true
+			*gitlabCI = tt.gitlab
+
+			err := run()
+			if (err != nil) != tt.wantError {
+				t.Fatalf("run() error = %v, wantError %v", err, tt.wantError)
+			}
+			if content := readRepoFile(t, dir, ".gitlab-ci.yml"); !strings.Contains(content, tt.wantFile) {
+				t.Errorf(".gitlab-ci.yml = %q, want containing %q", content, tt.wantFile)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/credentials"
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/updater"
+	"github.com/google/go-github/v72/github"
+)
+
+// defaultFlagSet is the flag set all package flags were registered on. Older
+// tests replace flag.CommandLine, so it is captured here for resetting.
+var defaultFlagSet = flag.CommandLine
+
+// Tests only see tokens they set in GITHUB_TOKEN, never the developer's gh
+// login or other credentials of the environment
+func init() {
+	credentialChain = func() credentials.Chain {
+		return credentials.Chain{credentials.Env("GITHUB_TOKEN")}
+	}
+}
+
+// setupRunEnv creates a temporary repository containing files (paths relative
+// to the repository root), points the run flags at it with all other flags at
+// their defaults, and installs the given mocks. Everything is restored when
+// the test finishes.
+func setupRunEnv(t *testing.T, files map[string]string, checker updater.VersionChecker, creator updater.PRCreator) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	// Runs under GitHub Actions must not annotate or write outputs of the job
+	t.Setenv("GITHUB_ACTIONS", "")
+
+	defaultFlagSet.VisitAll(func(f *flag.Flag) {
+		old := f.Value.String()
+		_ = f.Value.Set(f.DefValue)
+		t.Cleanup(func() { _ = f.Value.Set(old) })
+	})
+
+	oldRepoPath, oldOwner, oldRepo, oldToken := *repoPath, *owner, *repo, *token
+	oldWorkflowsPath, oldDryRun, oldStage := *workflowsPath, *dryRun, *stage
+	oldVersionFactory, oldPRFactory, oldValidator := versionCheckerFactory, prCreatorFactory, tokenValidatorFactory
+	oldClientFactory := githubClientFactory
+	t.Cleanup(func() {
+		*repoPath, *owner, *repo, *token = oldRepoPath, oldOwner, oldRepo, oldToken
+		*workflowsPath, *dryRun, *stage = oldWorkflowsPath, oldDryRun, oldStage
+		versionCheckerFactory, prCreatorFactory, tokenValidatorFactory = oldVersionFactory, oldPRFactory, oldValidator
+		githubClientFactory = oldClientFactory
+	})
+
+	*repoPath = dir
+	*owner = "test-owner"
+	*repo = "test-repo"
+	*token = ""
+	*workflowsPath = ".github/workflows"
+	*dryRun = false
+	*stage = false
+
+	versionCheckerFactory = func(token string) updater.VersionChecker { return checker }
+	prCreatorFactory = func(token, owner, repo string) updater.PRCreator {
+		if numbered, ok := creator.(*numberedPRCreator); ok {
+			numbered.startRepository()
+		}
+		return creator
+	}
+	tokenValidatorFactory = func(token string) func(context.Context) error {
+		return func(ctx context.Context) error { return nil }
+	}
+
+	return dir
+}
+
+// useGitHubServer points the GitHub client factory at a test server
+// serving mux
+func useGitHubServer(t *testing.T, mux *http.ServeMux) {
+	t.Helper()
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	githubClientFactory = func(token string) *github.Client {
+		client := github.NewClient(nil)
+		client.BaseURL, _ = url.Parse(server.URL + "/")
+		return client
+	}
+}
+
+// recordingPRCreator records the updates passed to CreatePR
+type recordingPRCreator struct {
+	updates []*updater.Update
+	err     error
+}
+
+func (r *recordingPRCreator) CreatePR(ctx context.Context, updates []*updater.Update) error {
+	r.updates = append(r.updates, updates...)
+	return r.err
+}
+
+// readRepoFile reads a file relative to the repository root
+func readRepoFile(t *testing.T, dir, name string) string {
+	t.Helper()
+	content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", name, err)
+	}
+	return string(content)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/updater"
+)
+
+// For testing
+var (
+	interactiveInput  io.Reader = os.Stdin
+	interactiveOutput io.Writer = os.Stdout
+)
+
+// selectUpdates presents each update and returns the ones the user accepts.
+// Answering "always" skips every remaining update of the same action, and
+// "quit" skips all remaining updates.
+func selectUpdates(ctx context.Context, checker updater.VersionChecker, updates []*updater.Update, in io.Reader, out io.Writer) []*updater.Update {
+	reader := bufio.NewReader(in)
+	dates, _ := checker.(updater.ReleaseDateProvider)
+	alwaysSkip := make(map[string]bool)
+	var selected []*updater.Update
+
+	for i, update := range updates {
+		action := update.Action.FullName()
+		if alwaysSkip[action] {
+			continue
+		}
+
+		released := "unknown"
+		if dates != nil {
+			if date, err := dates.GetReleaseDate(ctx, update.Action, update.NewVersion); err == nil {
+				released = date.Format("2006-01-02")
+			}
+		}
+
+		_, _ = fmt.Fprintf(out, "\n[%d/%d] %s (%s:%d)\n", i+1, len(updates), action, update.FilePath, update.LineNumber)
+		_, _ = fmt.Fprintf(out, "  current: %s\n", currentPin(update))
+		_, _ = fmt.Fprintf(out, "  target:  %s (%s), released %s\n", update.NewVersion, update.NewHash, released)
+
+		switch promptChoice(reader, out) {
+		case "y":
+			selected = append(selected, update)
+		case "a":
+			alwaysSkip[action] = true
+		case "q":
+			return selected
+		}
+	}
+	return selected
+}
+
+// promptChoice asks until a valid answer is given. End of input counts as quit.
+func promptChoice(reader *bufio.Reader, out io.Writer) string {
+	for {
+		_, _ = fmt.Fprint(out, "Apply? [y]es / [n]o / [a]lways skip this action / [q]uit: ")
+		line, err := reader.ReadString('\n')
+		answer := strings.ToLower(strings.TrimSpace(line))
+		switch answer {
+		case "y", "yes":
+			return "y"
+		case "n", "no", "s", "skip":
+			return "n"
+		case "a", "always":
+			return "a"
+		case "q", "quit":
+			return "q"
+		}
+		if err != nil {
+			_, _ = fmt.Fprintln(out)
+			return "q"
+		}
+	}
+}
+
+// currentPin describes the current reference of an update
+func currentPin(update *updater.Update) string {
+	if update.OldHash == "" {
+		return update.OldVersion
+	}
+	if update.OldVersion == "" || update.OldVersion == update.OldHash {
+		return update.OldHash
+	}
+	return fmt.Sprintf("%s (%s)", update.OldVersion, update.OldHash)
+}
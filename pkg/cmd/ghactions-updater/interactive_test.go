@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/updater"
+)
+
+// datedVersionChecker adds release dates to mockVersionChecker
+type datedVersionChecker struct {
+	mockVersionChecker
+}
+
+func (d *datedVersionChecker) GetReleaseDate(ctx context.Context, action updater.ActionReference, version string) (time.Time, error) {
+	if action.Name == "nodate" {
+		return time.Time{}, errors.New("no release")
+	}
+	return time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), nil
+}
+
+func TestSelectUpdates(t *testing.T) {
+	newUpdate := func(name string, line int) *updater.Update {
+		return &updater.Update{
+			Action:     updater.ActionReference{Owner: "actions", Name: name},
+			OldVersion: "v3",
+			NewVersion: "v4",
+			NewHash:    "abc",
+			FilePath:   "ci.yml",
+			LineNumber: line,
+		}
+	}
+	updates := []*updater.Update{
+		newUpdate("checkout", 1),
+		newUpdate("cache", 2),
+		newUpdate("checkout", 3),
+		newUpdate("nodate", 4),
+		newUpdate("setup-go", 5),
+	}
+
+	tests := []struct {
+		name      string
+		input     string
+		wantLines []int
+	}{
+		{name: "accept all", input: "y\ny\ny\ny\ny\n", wantLines: []int{1, 2, 3, 4, 5}},
+		{name: "always skip checkout", input: "a\ny\nn\ny\n", wantLines: []int{2, 5}},
+		{name: "invalid answers are asked again", input: "maybe\nyes\nq\n", wantLines: []int{1}},
+		{name: "end of input stops", input: "y\n", wantLines: []int{1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			selected := selectUpdates(context.Background(), &datedVersionChecker{}, updates, strings.NewReader(tt.input), &out)
+
+			var lines []int
+			for _, u := range selected {
+				lines = append(lines, u.LineNumber)
+			}
+			if len(lines) != len(tt.wantLines) {
+				t.Fatalf("selected lines %v, want %v", lines, tt.wantLines)
+			}
+			for i := range lines {
+				if lines[i] != tt.wantLines[i] {
+					t.Fatalf("selected lines %v, want %v", lines, tt.wantLines)
+				}
+			}
+			if !strings.Contains(out.String(), "released 2024-03-01") {
+				t.Errorf("release date not shown:\n%s", out.String())
+			}
+		})
+	}
+}
+
+func TestRunInteractive(t *testing.T) {
+	files := map[string]string{
+		".github/workflows/ci.yml": "on: push\njobs:\n  a:\n    runs-on: ubuntu-latest\n    steps:\n      - uses: actions/checkout@v3\n      - uses: actions/cache@v3\n",
+	}
+	checker := &mockVersionChecker{latestVersion: "v4", latestHash: "1234567890123456789012345678901234567890"}
+	dir := setupRunEnv(t, files, checker, &recordingPRCreator{})
+	*interactive = true
+	*stage = true
+
+	oldIn, oldOut := interactiveInput, interactiveOutput
+	interactiveInput, interactiveOutput = strings.NewReader("n\ny\n"), &bytes.Buffer{}
+	defer func() { interactiveInput, interactiveOutput = oldIn, oldOut }()
+
+	if err := run(); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+
+	workflow := readRepoFile(t, dir, ".github/workflows/ci.yml")
+	if !strings.Contains(workflow, "actions/checkout@v3") {
+		t.Errorf("skipped update was applied:\n%s", workflow)
+	}
+	if !strings.Contains(workflow, "actions/cache@1234567890123456789012345678901234567890") {
+		t.Errorf("accepted update was not applied:\n%s", workflow)
+	}
+}
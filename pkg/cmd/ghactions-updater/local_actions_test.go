@@ -0,0 +1,62 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRunLocalActions(t *testing.T) {
+	files := map[string]string{
+		".github/workflows/ci.yml": `name: CI
+on: [push]
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v3
+      - uses: ./.github/actions/setup
+`,
+		".github/actions/setup/action.yml": `name: Setup
+runs:
+  using: composite
+  steps:
+    - uses: actions/setup-go@v4
+`,
+	}
+
+	tests := []struct {
+		name   string
+		follow bool
+	}{
+		{name: "local actions are skipped", follow: false},
+		{name: "local actions are followed", follow: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			creator := &recordingPRCreator{}
+			checker := &mockVersionChecker{latestVersion: "v5", latestHash: "1234567890123456789012345678901234567890"}
+			dir := setupRunEnv(t, files, checker, creator)
+			*followLocalActions = tt.follow
+			*stage = true
+
+			if err := run(); err != nil {
+				t.Fatalf("run() error = %v", err)
+			}
+
+			workflow := readRepoFile(t, dir, ".github/workflows/ci.yml")
+			if !strings.Contains(workflow, "uses: ./.github/actions/setup") {
+				t.Errorf("local action reference should be untouched:\n%s", workflow)
+			}
+			if !strings.Contains(workflow, "actions/checkout@1234567890123456789012345678901234567890") {
+				t.Errorf("remote action should be pinned:\n%s", workflow)
+			}
+
+			action := readRepoFile(t, dir, ".github/actions/setup/action.yml")
+			pinned := strings.Contains(action, "actions/setup-go@1234567890123456789012345678901234567890")
+			if pinned != tt.follow {
+				t.Errorf("nested action pinned = %v, want %v:\n%s", pinned, tt.follow, action)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/updater"
+)
+
+// lockMode reports whether -check-lock or -write-lock replaces the update run
+func lockMode() bool {
+	return *checkLock || *writeLock
+}
+
+// runLockfile writes or checks the lockfile of the repository checked out
+// at absPath instead of updating it
+func runLockfile(ctx context.Context, checker updater.VersionChecker, absPath string) error {
+	// The filter was checked by validateFlags
+	paths, _ := updater.ParsePathFilter(*includePaths, *excludePaths)
+	symlinkPolicy, _ := updater.ParseLinkPolicy(*symlinks, updater.DefaultSymlinkPolicy)
+	submodulePolicy, _ := updater.ParseLinkPolicy(*submodules, updater.DefaultSubmodulePolicy)
+	rep, err := updater.ScanReferences(ctx, updater.Options{
+		RepoPath:           absPath,
+		WorkflowsPath:      *workflowsPath,
+		Paths:              paths,
+		MaxDepth:           *maxDepth,
+		DiscoverDepth:      *discoverDepth,
+		Symlinks:           symlinkPolicy,
+		Submodules:         submodulePolicy,
+		GitLabCI:           *gitlabCI,
+		WorkflowTemplates:  *workflowTemplates,
+		FollowLocalActions: *followLocalActions,
+	})
+	if err != nil {
+		return err
+	}
+
+	path := *lockfilePath
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(absPath, path)
+	}
+	previous, err := updater.ReadLockfile(path)
+	if err != nil && (*checkLock || !errors.Is(err, os.ErrNotExist)) {
+		return err
+	}
+
+	if *writeLock {
+		lock, err := updater.BuildLockfile(ctx, checker, rep.RemoteActions, previous, time.Now().UTC())
+		if err != nil {
+			return err
+		}
+		if err := lock.Write(path); err != nil {
+			return err
+		}
+		fmt.Printf("Locked %d action references in %s\n", len(lock.Actions), *lockfilePath)
+		return nil
+	}
+
+	mismatches, err := updater.CheckLockfile(ctx, checker, previous, rep.RemoteActions)
+	if err != nil {
+		return err
+	}
+	for _, mismatch := range mismatches {
+		log.Printf("%s: %s", *lockfilePath, mismatch)
+	}
+	if len(mismatches) > 0 {
+		return fmt.Errorf(common.ErrLockfileMismatch, *lockfilePath, len(mismatches))
+	}
+	fmt.Printf("Workflows match %s\n", *lockfilePath)
+	return nil
+}
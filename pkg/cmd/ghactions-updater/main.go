@@ -1,57 +1,240 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
+	"net/url"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/metrics"
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/notify"
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/report"
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/shard"
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/storage"
 	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/updater"
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/webhook"
+	"github.com/google/go-github/v72/github"
 )
 
 var (
 	repoPath      = flag.String("repo", ".", "Path to the repository")
 	owner         = flag.String("owner", "", "Repository owner")
 	repo          = flag.String("repo-name", "", "Repository name")
+	gitRemote     = flag.String("remote", "", "Git remote whose URL gives -owner and -repo-name when they are omitted (default origin, or the only remote)")
 	token         = flag.String("token", "", "GitHub token")
-	version       = flag.Bool("version", false, "Print version information")
+	provider      = flag.String("provider", updater.ProviderGitHub, "API provider: github or gitea (also Forgejo)")
+	providerURL   = flag.String("provider-url", "", "Base URL of the Gitea or Forgejo instance, e.g. https://gitea.example.com")
+	version       = versionFlagVar(flag.CommandLine)
+	configPath    = flag.String("config", "", "Read flag values from this configuration file (command line flags take precedence)")
+	centralConfig = flag.String("central-config", "", "Read flag values from a configuration file in a central repository, as owner/repo[/path][@ref] (default path ghactions-updater.yml); -config and command line flags take precedence")
+	centralTTL    = flag.Duration("central-config-ttl", time.Hour, "How long a fetched -central-config is reused without an API call")
 	workflowsPath = flag.String("workflows-path", ".github/workflows", "Path to workflow files (relative to repository root)")
+	includePaths  = flag.String("include", "", "Only scan workflow files matching these globs, relative to -workflows-path, comma separated (e.g. \"*.yaml,!experimental/**\")")
+	excludePaths  = flag.String("exclude", "", "Skip workflow files matching these globs, relative to -workflows-path, comma separated")
+	maxDepth      = flag.Int("max-depth", 0, "Directory levels scanned in -workflows-path: 1 reads only its own files, 2 also its subdirectories (0: no limit)")
+	discoverDepth = flag.Int("discover-depth", 0, "Also scan the -workflows-path of subprojects up to this many directories below -repo, e.g. 2 for services/api/.github/workflows (0: disabled)")
+	symlinks      = flag.String("symlinks", string(updater.DefaultSymlinkPolicy), "Symbolic links in workflows directories: follow (targets must stay inside -repo), skip or error")
+	submodules    = flag.String("submodules", string(updater.DefaultSubmodulePolicy), "Git submodules in scanned directories: follow, skip or error")
 	dryRun        = flag.Bool("dry-run", false, "Show changes without applying them")
 	stage         = flag.Bool("stage", false, "Apply changes locally without creating a PR")
-)
+	outputPatch   = flag.String("output-patch", "", "Write the updates as a patch for git apply to this file, or one patch per repository to this directory, without changing the workflows or creating a PR")
+	timeout       = flag.Duration("timeout", 0, "Abort the run after this long, e.g. 10m (0 disables)")
+
+	metricsPushURL  = flag.String("metrics-push-url", "", "Prometheus Pushgateway URL to push run metrics to")
+	metricsJob      = flag.String("metrics-job", "ghactions-updater", "Job name used when pushing metrics")
+	metricsTextfile = flag.String("metrics-textfile", "", "Write run metrics to this file in Prometheus text format")
+
+	apiAuditLog = flag.String("api-audit-log", "", "Append every API request that changes something (branches, blobs, trees, commits, PRs, labels) to this file as JSON lines, with its time and request ID")
+
+	rateLimitFloor = flag.Int("rate-limit-floor", 0, "Stop using the API when fewer than this many core requests remain (0 disables)")
+	rateLimitWait  = flag.Bool("rate-limit-wait", false, "Pause until the rate limit resets instead of stopping at -rate-limit-floor")
+
+	retryAttempts = flag.Int("retry-attempts", 3, "Attempts per API call failing with a 5xx, secondary rate limit or network error (1 disables retries)")
+	retryDelay    = flag.Duration("retry-delay", time.Second, "Delay before the first retry; doubles per retry up to 30s")
+	retryJitter   = flag.Float64("retry-jitter", 0.25, "Randomize retry delays by up to this fraction (0 to 1)")
+
+	storeLocation = flag.String("store", "", "Cache and run-state store (directory, file://, s3://, gs://, redis:// or mem://)")
+	cacheTTL      = flag.Duration("cache-ttl", time.Hour, "How long cached version lookups remain valid")
+	etagStore     = flag.String("etag-store", "", "Store for the ETags of release and tag lookups, so unchanged ones are answered with 304s that do not count against the rate limit; defaults to -store, none disables")
+
+	proxyURL      = flag.String("proxy", "", "Proxy for API requests, as http://, https:// or socks5://host:port; defaults to HTTPS_PROXY and NO_PROXY")
+	caFile        = flag.String("ca-file", "", "PEM bundle of CA certificates to trust in addition to the system roots, e.g. of a TLS-inspecting proxy")
+	tlsMinVersion = flag.String("tls-min-version", "1.2", "Lowest TLS version accepted for API requests: 1.2 or 1.3")
+
+	actionTokenEnv = flag.String("action-token-env", "", "Scoped tokens for private actions as owner[/repo]=ENV_VAR, comma separated")
+	actionHosts    = flag.String("action-hosts", "", "GitHub Enterprise hosts serving actions referenced as host/owner/repo@ref, as host[=ENV_VAR], comma separated")
+
+	gitlabCI          = flag.Bool("gitlab-ci", false, "Also pin project includes (include: project/ref) in .gitlab-ci.yml to commit SHAs")
+	workflowTemplates = flag.Bool("workflow-templates", false, "Also scan organization workflow templates in workflow-templates/ and .github/workflow-templates/")
+
+	offline      = flag.Bool("offline", false, "Answer version lookups from the -metadata snapshot instead of the API (requires -dry-run, -stage, -output-patch, -check-lock or -write-lock)")
+	metadataPath = flag.String("metadata", "", "Metadata snapshot read by -offline; without -offline, the version lookups of the run are exported to it")
+
+	checkLock          = flag.Bool("check-lock", false, "Instead of updating, fail when the workflows no longer match the lockfile, e.g. because a tag moved")
+	writeLock          = flag.Bool("write-lock", false, "Instead of updating, record the tag and commit of every action reference in the lockfile")
+	lockfilePath       = flag.String("lockfile", updater.DefaultLockfile, "Lockfile used by -check-lock and -write-lock, relative to -repo")
+	dependabotRules    = flag.Bool("dependabot-rules", true, "Skip updates ignored, or not allowed, by the github-actions entries of .github/dependabot.yml")
+	followLocalActions = flag.Bool("follow-local-actions", false, "Also update remote actions used inside local composite actions (uses: ./path)")
+
+	org             = flag.String("org", "", "Process all repositories of this organization via the API")
+	reposFile       = flag.String("repos-file", "", "Process the repositories listed in this file (owner/repo per line)")
+	shardSpec       = flag.String("shard", "", "Only process shard i of N of the repository list (e.g. 2/4)")
+	checkpointPath  = flag.String("checkpoint", "", "Record completed repositories and action lookups of an -org or -repos-file run in this file, and resume from it when the run is interrupted")
+	reportPath      = flag.String("report", "", "Write a JSON report of the run to this file")
+	summaryFile     = flag.String("summary-file", "", "Append a Markdown summary of the run to this file, e.g. $GITHUB_STEP_SUMMARY")
+	strict          = flag.Bool("strict", false, "Fail the run when any workflow or action reference could not be parsed, checked or updated")
+	inventoryPath   = flag.String("inventory", "", "Write an inventory of every action version used (repositories, workflows, pin status, release age, license) to this file")
+	inventoryFormat = flag.String("inventory-format", report.FormatJSON, "Format of -inventory ("+strings.Join(report.InventoryFormats, ", ")+")")
+	githubOutput    = flag.String("github-output", "", "Append step outputs (updates_count, pr_url, unpinned_count, ...) to this file (default $GITHUB_OUTPUT inside GitHub Actions)")
+	serveAddr       = flag.String("serve", "", "Listen on this address for release/push webhooks and update the -org or -repos-file repositories that use a released action")
 
-// Version information
-const (
-	Version = "development"
-	Commit  = "unknown"
+	interactive          = flag.Bool("interactive", false, "Review each available update and choose which ones to apply")
+	scanResults          = flag.String("in", "", "Only apply updates of the references listed in this file written by \"scan -out\"")
+	minUpdateDelta       = flag.String("min-update-delta", "patch", "Smallest version change to propose: patch, minor or major")
+	minReleaseAge        = flag.String("min-release-age", "", "Hold back updates to versions released less than this long ago, e.g. 7d or 36h, in case they are yanked")
+	maxPinAge            = flag.String("max-pin-age", "", "Report references to versions older than this, e.g. 180d, even when no newer version exists")
+	skipPatchFor         = flag.String("skip-patch-for", "", "Never propose patch-only bumps of these actions, as owner[/repo], comma separated")
+	noMajor              = flag.Bool("no-major", false, "Never propose updates to another major version, such as v3 to v5")
+	majorPR              = flag.Bool("major-pr", false, "Open major version updates in a pull request of their own, labeled major-update")
+	maxUpdatesPerPR      = flag.Int("max-updates-per-pr", 0, "Split the updates into several pull requests of at most this many updates, keeping the updates of a file together where possible (0 means no limit)")
+	graphQL              = flag.Bool("graphql", false, "Resolve the latest releases and tags of all actions in a few batched GraphQL queries, falling back to REST lookups per action")
+	floatingTags         = flag.Bool("floating-tags", false, "Keep references to major tags such as v4 on the commit the tag points to instead of updating them to the latest release")
+	allowPrerelease      = flag.Bool("allow-prerelease", false, "Propose updates to prereleases such as v2.0.0-rc.1; by default only references already on a prerelease get them")
+	pinStyle             = flag.String("pin-style", "", "Write updated references of these actions as tags instead of commit hashes, as owner[/repo]=hash|full-version-tag|major-tag, comma separated (* matches every action)")
+	commentDrift         = flag.String("comment-drift", "", "Check that pinned commits match their version comment: report, fix-comment (rename the comment to a tag of the pinned commit) or fix-pin (re-pin to the commented version)")
+	validateSchema       = flag.Bool("validate-schema", false, "Check each workflow against the GitHub Actions workflow schema and report its structural errors")
+	checkPermissions     = flag.Bool("check-permissions", false, "Report jobs that use third-party actions with write-all permissions or without any permissions set")
+	detectMoves          = flag.Bool("detect-moves", false, "Rename references to actions whose repository was renamed or transferred to its new location (one API call per repository)")
+	keepBackups          = flag.Bool("keep-backups", false, "Keep the original of each updated file as <file>.bak")
+	keepModTime          = flag.Bool("keep-mtime", false, "Keep the modification time of updated files (their permissions, owner and group are always kept)")
+	rewriteStrategy      = flag.String("rewrite-strategy", "yaml", "How workflow files are rewritten: yaml (locate references through the YAML syntax tree) or line")
+	summarize            = flag.String("summarize", "", "Summarize release notes of public actions in the PR body with command:<program> or an https:// endpoint (disabled by default)")
+	changeTicket         = flag.String("change-ticket", "", "Open a change ticket for each PR with command:<program> or an https:// endpoint and only enable auto-merge once it is approved (requires -store)")
+	notifySpecs          = flag.String("notify", "", "Comma-separated endpoints receiving a summary after the run: slack:<url>, teams:<url>, an https:// URL for JSON or smtp[s]://host?from=a&to=b for email")
+	notifyOn             = flag.String("notify-on", notify.OnAlways, "When to send -notify summaries: always, changes (updates or errors) or errors")
+	auditLog             = flag.String("audit-log", "", "Append every published event to this file as JSON lines")
+	autoMerge            = mergeMethodFlag("auto-merge", "Enable auto-merge on created PRs so they merge once CI passes; optionally =squash, =merge or =rebase (default squash)")
+	commitStatus         = flag.String("commit-status", "", "After creating a PR, report the result on its head commit as a \"status\" or a \"check-run\" (check runs need a GitHub App token)")
+	baseBranch           = flag.String("base-branch", "", "Branch PRs are based on and opened against (default: the repository's default branch)")
+	branchTemplate       = flag.String("branch-template", updater.DefaultBranchTemplate, "Name of PR branches; {date}, {action} and {strategy} are replaced by the creation time, the updated action and the largest version change")
+	draftPR              = flag.Bool("draft", false, "Open PRs as drafts (Gitea: as work in progress)")
+	repoLockTTL          = flag.Duration("repo-lock-ttl", 0, "Lock each repository under refs/ghactions-updater/lock for up to this long while creating its PR, so concurrent runs do not race (0 disables)")
+	repoLockWait         = flag.Duration("repo-lock-wait", 0, "How long to wait for a repository locked by another run before failing it")
+	forkPR               = flag.Bool("fork", false, "Push update branches to a fork of the repository, created if needed, and open PRs from it (for tokens without write access)")
+	eventSinks           = flag.String("event-sink", "", "Comma-separated sinks receiving update, pull request and error events: log, file:<path>, an https:// URL or redis://host[/db][?list=name]")
+	redactPatterns       = patternListFlag("redact", "Also redact matches of this regular expression from PR bodies and commit messages, besides common token formats; repeatable (a group named \"secret\" limits the redaction to it)")
+	versionCommentFormat = flag.String("version-comment-format", "", "Format of version comments after pinned hashes, e.g. \"# pin@{version}\" (default keeps the existing style)")
+	checkSelfUpdate      = flag.Bool("check-self-update", false, "Log a notice when a newer release of ghactions-updater exists; with -version, show the latest release")
 )
 
 func validateFlags() error {
-	if *version {
-		fmt.Printf("Version: %s\nCommit: %s\n", Version, Commit)
-		log.Printf("Version: %s\nCommit: %s\n", Version, Commit)
+	forge, err := updater.NewProvider(*provider, *providerURL)
+	if err != nil {
+		return fmt.Errorf(common.ErrInvalidFlagValue, "provider", err.Error())
+	}
+	if _, ok := forge.(updater.GitHubProvider); !ok {
+		if multiRepoMode() || *serveAddr != "" || *actionTokenEnv != "" || *actionHosts != "" {
+			return fmt.Errorf(common.ErrInvalidFlagValue, "provider", "-org, -repos-file, -serve, -action-token-env and -action-hosts require the github provider")
+		}
+		if *token == "" {
+			*token = os.Getenv("GITEA_TOKEN")
+		}
 	}
 
-	if *owner == "" {
-		return fmt.Errorf(common.ErrMissingRequiredFlag, "owner")
+	if *org != "" && *reposFile != "" {
+		return fmt.Errorf(common.ErrInvalidFlagValue, "org/repos-file", "cannot use both flags simultaneously")
 	}
-	if *repo == "" {
-		return fmt.Errorf(common.ErrMissingRequiredFlag, "repo-name")
+	if *checkLock && *writeLock {
+		return fmt.Errorf(common.ErrInvalidFlagValue, "check-lock/write-lock", "cannot use both flags simultaneously")
+	}
+	if *outputPatch != "" {
+		if *stage || lockMode() || activeCampaign != nil {
+			return fmt.Errorf(common.ErrInvalidFlagValue, "output-patch", "cannot be combined with -stage, -check-lock, -write-lock or campaigns")
+		}
+		if (multiRepoMode() || *serveAddr != "") && !patchToDirectory() {
+			return fmt.Errorf(common.ErrInvalidFlagValue, "output-patch", "must be a directory with -org, -repos-file or -serve")
+		}
+	}
+	if lockMode() && (multiRepoMode() || activeCampaign != nil) {
+		return fmt.Errorf(common.ErrInvalidFlagValue, "check-lock/write-lock", "only supported for a local repository")
+	}
+	if *scanResults != "" && (multiRepoMode() || *serveAddr != "" || activeCampaign != nil) {
+		return fmt.Errorf(common.ErrInvalidFlagValue, "in", "only supported for a local repository")
+	}
+	if *offline {
+		if *metadataPath == "" {
+			return fmt.Errorf(common.ErrMissingRequiredFlag, "metadata")
+		}
+		if multiRepoMode() || *serveAddr != "" || activeCampaign != nil {
+			return fmt.Errorf(common.ErrInvalidFlagValue, "offline", "only supported for a local repository")
+		}
+		if runMode() == updater.ModePR && !lockMode() {
+			return fmt.Errorf(common.ErrInvalidFlagValue, "offline", "requires -dry-run, -stage, -output-patch, -check-lock or -write-lock since pull requests need the API")
+		}
+	}
+	if multiRepoMode() {
+		if *stage {
+			return fmt.Errorf(common.ErrInvalidFlagValue, "stage", "not supported with -org or -repos-file")
+		}
+		if *shardSpec != "" {
+			if _, err := shard.Parse(*shardSpec); err != nil {
+				return err
+			}
+		}
+		if *checkpointPath != "" && (*serveAddr != "" || activeCampaign != nil) {
+			return fmt.Errorf(common.ErrInvalidFlagValue, "checkpoint", "not supported with -serve or campaigns")
+		}
+	} else {
+		if *shardSpec != "" {
+			return fmt.Errorf(common.ErrInvalidFlagValue, "shard", "requires -org or -repos-file")
+		}
+		if *checkpointPath != "" {
+			return fmt.Errorf(common.ErrInvalidFlagValue, "checkpoint", "requires -org or -repos-file")
+		}
+		if *serveAddr != "" {
+			return fmt.Errorf(common.ErrInvalidFlagValue, "serve", "requires -org or -repos-file")
+		}
+		if err := detectRepository(); err != nil {
+			return err
+		}
+		// Only pull requests need the repository's name
+		if *owner == "" && !lockMode() && runMode() == updater.ModePR {
+			return fmt.Errorf(common.ErrMissingRequiredFlag, "owner")
+		}
+		if *repo == "" && !lockMode() && runMode() == updater.ModePR {
+			return fmt.Errorf(common.ErrMissingRequiredFlag, "repo-name")
+		}
 	}
 	if *token == "" {
-		// Try to get token from environment
-		*token = os.Getenv("GITHUB_TOKEN")
+		// Look for a token in the environment, a GitHub App or the gh CLI
+		if isGitHubProvider() {
+			found, err := githubToken()
+			if err != nil {
+				return err
+			}
+			*token = found
+		} else {
+			*token = os.Getenv("GITHUB_TOKEN")
+		}
 		if *token == "" {
 			log.Printf(common.ErrNoGithubToken)
 			// Allow empty token - the client will use unauthenticated access
 		}
 	}
 
-	// Validate token format early if token is provided
-	if *token != "" {
+	// Validate token format early if a GitHub token is provided
+	if *token != "" && isGitHubProvider() {
 		tokenInfo, err := common.ValidateGitHubToken(*token)
 		if err != nil {
 			return fmt.Errorf("invalid GitHub token format: %v", err)
@@ -59,9 +242,154 @@ func validateFlags() error {
 		log.Printf("Using %s token", tokenInfo.Type)
 	}
 
-	// Check for environment variable override for workflows path
-	if envPath := os.Getenv("WORKFLOWS_PATH"); envPath != "" {
-		*workflowsPath = envPath
+	if *serveAddr != "" {
+		if os.Getenv(webhookSecretEnv) == "" {
+			return fmt.Errorf(common.ErrWebhookSecretEnv, webhookSecretEnv)
+		}
+		if *interactive {
+			return fmt.Errorf(common.ErrInvalidFlagValue, "interactive", "not supported with -serve")
+		}
+		if *timeout != 0 {
+			return fmt.Errorf(common.ErrInvalidFlagValue, "timeout", "not supported with -serve")
+		}
+	}
+
+	if *inventoryPath != "" && (*serveAddr != "" || lockMode()) {
+		return fmt.Errorf(common.ErrInvalidFlagValue, "inventory", "not supported with -serve, -check-lock or -write-lock")
+	}
+	if !slices.Contains(report.InventoryFormats, *inventoryFormat) {
+		return fmt.Errorf(common.ErrInvalidFlagValue, "inventory-format", *inventoryFormat)
+	}
+
+	if _, err := updater.ParseUpdatePolicy(*minUpdateDelta, *skipPatchFor); err != nil {
+		return fmt.Errorf(common.ErrInvalidFlagValue, "min-update-delta/skip-patch-for", err.Error())
+	}
+	if *noMajor && (*majorPR || strings.EqualFold(strings.TrimSpace(*minUpdateDelta), updater.DeltaMajor)) {
+		return fmt.Errorf(common.ErrInvalidFlagValue, "no-major", "cannot be combined with -major-pr or -min-update-delta major")
+	}
+	if *maxUpdatesPerPR < 0 {
+		return fmt.Errorf(common.ErrInvalidFlagValue, "max-updates-per-pr", "must not be negative")
+	}
+	if _, err := updater.ParseAge(*minReleaseAge); err != nil {
+		return fmt.Errorf(common.ErrInvalidFlagValue, "min-release-age", err.Error())
+	}
+	if _, err := updater.ParseAge(*maxPinAge); err != nil {
+		return fmt.Errorf(common.ErrInvalidFlagValue, "max-pin-age", err.Error())
+	}
+	if _, err := updater.ParsePathFilter(*includePaths, *excludePaths); err != nil {
+		return fmt.Errorf(common.ErrInvalidFlagValue, "include/exclude", err.Error())
+	}
+	if _, err := updater.ParseLinkPolicy(*symlinks, updater.DefaultSymlinkPolicy); err != nil {
+		return fmt.Errorf(common.ErrInvalidFlagValue, "symlinks", err.Error())
+	}
+	if _, err := updater.ParseLinkPolicy(*submodules, updater.DefaultSubmodulePolicy); err != nil {
+		return fmt.Errorf(common.ErrInvalidFlagValue, "submodules", err.Error())
+	}
+	if _, err := updater.ParsePinPolicy(*pinStyle); err != nil {
+		return fmt.Errorf(common.ErrInvalidFlagValue, "pin-style", err.Error())
+	}
+	if err := updater.ValidDriftMode(*commentDrift); err != nil {
+		return fmt.Errorf(common.ErrInvalidFlagValue, "comment-drift", err.Error())
+	}
+	if _, err := updater.ParseRewriteStrategy(*rewriteStrategy); err != nil {
+		return fmt.Errorf(common.ErrInvalidFlagValue, "rewrite-strategy", err.Error())
+	}
+	if _, err := updater.ParseBranchTemplate(*branchTemplate); err != nil {
+		return fmt.Errorf(common.ErrInvalidFlagValue, "branch-template", err.Error())
+	}
+	if _, err := updater.NewRedactor(*redactPatterns); err != nil {
+		return fmt.Errorf(common.ErrInvalidFlagValue, "redact", err.Error())
+	}
+
+	if *changeTicket != "" {
+		if _, err := updater.NewChangeTicketer(*changeTicket); err != nil {
+			return fmt.Errorf(common.ErrInvalidFlagValue, "change-ticket", err.Error())
+		}
+		if *storeLocation == "" {
+			return fmt.Errorf(common.ErrInvalidFlagValue, "change-ticket", "requires -store to track tickets between runs")
+		}
+		if !isGitHubProvider() {
+			return fmt.Errorf(common.ErrInvalidFlagValue, "change-ticket", "requires the github provider")
+		}
+	}
+
+	if *autoMerge != "" && !isGitHubProvider() {
+		return fmt.Errorf(common.ErrInvalidFlagValue, "auto-merge", "requires the github provider")
+	}
+	if *proxyURL != "" {
+		if _, err := common.ParseProxyURL(*proxyURL); err != nil {
+			return fmt.Errorf(common.ErrInvalidFlagValue, "proxy", err.Error())
+		}
+	}
+	if *tlsMinVersion != "1.2" && *tlsMinVersion != "1.3" {
+		return fmt.Errorf(common.ErrInvalidFlagValue, "tls-min-version", "expected 1.2 or 1.3")
+	}
+
+	if *graphQL && !isGitHubProvider() {
+		return fmt.Errorf(common.ErrInvalidFlagValue, "graphql", "requires the github provider")
+	}
+
+	switch *commitStatus {
+	case "", updater.CommitStatusKind, updater.CheckRunKind:
+	default:
+		return fmt.Errorf(common.ErrInvalidFlagValue, "commit-status", "expected status or check-run")
+	}
+	if *commitStatus != "" && !isGitHubProvider() {
+		return fmt.Errorf(common.ErrInvalidFlagValue, "commit-status", "requires the github provider")
+	}
+
+	if *repoLockTTL < 0 {
+		return fmt.Errorf(common.ErrInvalidFlagValue, "repo-lock-ttl", "must not be negative")
+	}
+	if *repoLockWait < 0 {
+		return fmt.Errorf(common.ErrInvalidFlagValue, "repo-lock-wait", "must not be negative")
+	}
+	if *repoLockTTL > 0 && !isGitHubProvider() {
+		return fmt.Errorf(common.ErrInvalidFlagValue, "repo-lock-ttl", "requires the github provider")
+	}
+
+	// Auto-merge, commit statuses and repository locks need write access to
+	// the repository, which fork mode exists to do without
+	if *forkPR {
+		if !isGitHubProvider() {
+			return fmt.Errorf(common.ErrInvalidFlagValue, "fork", "requires the github provider")
+		}
+		if *autoMerge != "" || *commitStatus != "" || *repoLockTTL > 0 {
+			return fmt.Errorf(common.ErrInvalidFlagValue, "fork", "cannot be combined with -auto-merge, -commit-status or -repo-lock-ttl")
+		}
+	}
+
+	for _, spec := range splitList(*eventSinks) {
+		if _, err := updater.NewEventSink(spec); err != nil {
+			return fmt.Errorf(common.ErrInvalidFlagValue, "event-sink", err.Error())
+		}
+	}
+	for _, spec := range splitList(*notifySpecs) {
+		if _, err := notify.New(spec); err != nil {
+			return fmt.Errorf(common.ErrInvalidFlagValue, "notify", err.Error())
+		}
+	}
+	if err := notify.ValidateOn(*notifyOn); err != nil {
+		return fmt.Errorf(common.ErrInvalidFlagValue, "notify-on", err.Error())
+	}
+
+	if *retryAttempts < 1 {
+		return fmt.Errorf(common.ErrInvalidFlagValue, "retry-attempts", "must be at least 1")
+	}
+	if *retryDelay < 0 {
+		return fmt.Errorf(common.ErrInvalidFlagValue, "retry-delay", "must not be negative")
+	}
+	if *retryJitter < 0 || *retryJitter > 1 {
+		return fmt.Errorf(common.ErrInvalidFlagValue, "retry-jitter", "must be between 0 and 1")
+	}
+	if *timeout < 0 {
+		return fmt.Errorf(common.ErrInvalidFlagValue, "timeout", "must not be negative")
+	}
+	if *maxDepth < 0 {
+		return fmt.Errorf(common.ErrInvalidFlagValue, "max-depth", "must not be negative")
+	}
+	if *discoverDepth < 0 {
+		return fmt.Errorf(common.ErrInvalidFlagValue, "discover-depth", "must not be negative")
 	}
 
 	// Validate that dry-run and stage are not both set
@@ -74,10 +402,25 @@ func validateFlags() error {
 
 var (
 	versionCheckerFactory = func(token string) updater.VersionChecker {
-		return updater.NewDefaultVersionChecker(token)
+		return selectedProvider().NewVersionChecker(token)
 	}
 	prCreatorFactory = func(token, owner, repo string) updater.PRCreator {
-		return updater.NewPRCreator(token, owner, repo)
+		return selectedProvider().NewPRCreator(token, owner, repo)
+	}
+	updateManagerFactory = func(baseDir string) updater.UpdateManager {
+		// The strategy and pin styles were checked by validateFlags
+		strategy, _ := updater.ParseRewriteStrategy(*rewriteStrategy)
+		pinPolicy, _ := updater.ParsePinPolicy(*pinStyle)
+		return updater.NewUpdateManagerWithOptions(baseDir, updater.UpdateManagerOptions{
+			VersionCommentFormat: *versionCommentFormat,
+			KeepBackups:          *keepBackups,
+			KeepModTime:          *keepModTime,
+			RewriteStrategy:      strategy,
+			PinPolicy:            pinPolicy,
+		})
+	}
+	githubClientFactory = func(token string) *github.Client {
+		return common.NewGitHubClientWithToken(token)
 	}
 	tokenValidatorFactory = func(token string) func(context.Context) error {
 		return func(ctx context.Context) error {
@@ -90,9 +433,69 @@ var (
 )
 
 func run() error {
-	// Validate token scopes if token is provided and we're not in dry-run or stage mode
-	if *token != "" && !*dryRun && !*stage {
-		ctx := context.Background()
+	// Send API requests through the configured proxy, CA bundle and TLS
+	// version; the other transports wrap this one
+	if *proxyURL != "" || *caFile != "" || *tlsMinVersion != "1.2" {
+		transport, err := common.NewHTTPTransport(common.TransportOptions{
+			ProxyURL:      *proxyURL,
+			CAFile:        *caFile,
+			MinTLSVersion: *tlsMinVersion,
+		})
+		if err != nil {
+			return fmt.Errorf(common.ErrCommandExecution, err)
+		}
+		previous := common.HTTPTransport
+		common.HTTPTransport = transport
+		defer func() { common.HTTPTransport = previous }()
+	}
+
+	// Record metrics for the run if an exporter is configured
+	if *metricsPushURL != "" || *metricsTextfile != "" {
+		previous := common.HTTPTransport
+		common.HTTPTransport = metrics.NewTransport(previous, metrics.Default)
+		defer func() {
+			common.HTTPTransport = previous
+			exportMetrics(metrics.Default)
+		}()
+	}
+
+	// Log every API mutation for change-management audits
+	if *apiAuditLog != "" {
+		previous := common.HTTPTransport
+		common.HTTPTransport = common.NewAuditTransport(previous, *apiAuditLog)
+		defer func() { common.HTTPTransport = previous }()
+	}
+
+	// Leave part of the rate limit to other workloads sharing the token
+	if *rateLimitFloor > 0 {
+		previous := common.HTTPTransport
+		common.HTTPTransport = common.NewBudgetTransport(previous, *rateLimitFloor, *rateLimitWait)
+		defer func() { common.HTTPTransport = previous }()
+	}
+
+	// Revalidate release and tag lookups with the ETags of earlier runs
+	if location := etagStoreLocation(); location != "" && !*offline {
+		store, err := storage.Open(location)
+		if err != nil {
+			return fmt.Errorf(common.ErrOpeningStore, err)
+		}
+		previous := common.HTTPTransport
+		common.HTTPTransport = updater.NewConditionalTransport(previous, store)
+		defer func() { common.HTTPTransport = previous }()
+	}
+
+	// Interrupts and -timeout cancel the run; work stops at the next file or
+	// API call and nothing is applied afterwards
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+
+	// Validate token scopes if token is provided and we're going to create a PR
+	if *token != "" && !*dryRun && !*stage && !lockMode() && !*offline && isGitHubProvider() {
 		validator := tokenValidatorFactory(*token)
 
 		if err := validator(ctx); err != nil {
@@ -102,84 +505,624 @@ func run() error {
 		log.Println("GitHub token validated successfully")
 	}
 
+	// Point out a newer release of the tool itself; this never fails the run
+	if *checkSelfUpdate && !*offline {
+		latest, newer, err := latestRelease(ctx, selfUpdateToken())
+		switch {
+		case err != nil:
+			log.Printf("Warning: %v", err)
+		case newer:
+			log.Printf("ghactions-updater %s is available, this is %s", latest, currentVersion().Version)
+		}
+	}
+
+	runner := &repoRunner{checker: versionCheckerFactory(*token)}
+	if *inventoryPath != "" {
+		runner.inventory = updater.NewInventory()
+	}
+
+	// Resolve actions in batched GraphQL queries, with REST for the rest
+	if *graphQL {
+		rest, ok := runner.checker.(*updater.DefaultVersionChecker)
+		if !ok {
+			return fmt.Errorf(common.ErrCommandExecution, errors.New(common.ErrGraphQLNotSupported))
+		}
+		runner.checker = updater.NewGraphQLVersionChecker(rest)
+	}
+
+	// Release notes are only summarized when a backend is configured
+	summarizer, err := updater.NewSummarizer(*summarize)
+	if err != nil {
+		return fmt.Errorf(common.ErrInvalidFlagValue, "summarize", err.Error())
+	}
+	runner.summarizer = summarizer
+
+	// Pull requests wait for an approved change ticket before auto-merge;
+	// the spec was checked by validateFlags
+	runner.ticketer, _ = updater.NewChangeTicketer(*changeTicket)
+
+	// Resolve private actions with tokens scoped to their owner or repository
+	if *actionTokenEnv != "" {
+		tokens, err := updater.ParseActionTokens(*actionTokenEnv)
+		if err != nil {
+			return fmt.Errorf(common.ErrCommandExecution, err)
+		}
+		scoped, ok := runner.checker.(interface{ SetActionTokens(updater.ActionTokens) })
+		if !ok {
+			return fmt.Errorf(common.ErrCommandExecution, errors.New(common.ErrActionTokensNotSupported))
+		}
+		scoped.SetActionTokens(tokens)
+	}
+
+	// Resolve host-qualified actions against their own API endpoints
+	if *actionHosts != "" {
+		hosts, err := updater.ParseActionHosts(*actionHosts)
+		if err != nil {
+			return fmt.Errorf(common.ErrCommandExecution, err)
+		}
+		hosted, ok := runner.checker.(interface{ SetActionHosts(updater.ActionHosts) })
+		if !ok {
+			return fmt.Errorf(common.ErrCommandExecution, errors.New(common.ErrActionHostsNotSupported))
+		}
+		hosted.SetActionHosts(hosts)
+	}
+
+	// Look for prerelease tags newer than the latest release
+	if *allowPrerelease {
+		if channels, ok := runner.checker.(interface{ SetAllowPrerelease(bool) }); ok {
+			channels.SetAllowPrerelease(true)
+		}
+	}
+
+	// Retry lookups failing with transient API errors
+	runner.checker = updater.NewRetryingVersionChecker(runner.checker, retryPolicy())
+
+	// Share lookups and run state through the configured store
+	if *storeLocation != "" {
+		store, err := storage.Open(*storeLocation)
+		if err != nil {
+			return fmt.Errorf(common.ErrOpeningStore, err)
+		}
+		runner.store = store
+		runner.checker = updater.NewCachingVersionChecker(runner.checker, store, *cacheTTL)
+	}
+
+	// Answer lookups from a metadata snapshot, or export them into one
+	if *offline {
+		snapshot, err := updater.ReadMetadataSnapshot(*metadataPath)
+		if err != nil {
+			return err
+		}
+		runner.checker = updater.NewOfflineVersionChecker(snapshot)
+	} else if *metadataPath != "" {
+		snapshot := updater.NewMetadataSnapshot()
+		runner.checker = updater.NewRecordingVersionChecker(runner.checker, snapshot)
+		defer exportMetadata(snapshot)
+	}
+
+	// Events go to the metrics, the audit log and the configured sinks; the
+	// sinks were checked by validateFlags
+	runner.events = newEventBus(ctx, runner.store)
+
+	if activeCampaign != nil {
+		return runCampaign(ctx, runner, activeCampaign)
+	}
+	if *serveAddr != "" {
+		return serveWebhooks(ctx, runner)
+	}
+	if multiRepoMode() {
+		return runRepositories(ctx, runner)
+	}
+
 	// Convert repo path to absolute path
 	absPath, err := absFunc(*repoPath)
 	if err != nil {
 		return fmt.Errorf(common.ErrCommandExecution, err)
 	}
 
-	// Create scanner with base directory set to repository root
-	scanner := updater.NewScanner(absPath)
+	if lockMode() {
+		return runLockfile(ctx, runner.checker, absPath)
+	}
+	if *scanResults != "" {
+		if runner.scanned, err = readScanResults(*scanResults); err != nil {
+			return err
+		}
+	}
 
-	// Scan for workflow files using configurable path
-	workflowsDir := filepath.Join(absPath, *workflowsPath)
-	files, err := scanner.ScanWorkflows(workflowsDir)
+	result, err := runner.process(ctx, *owner, *repo, absPath)
 	if err != nil {
-		return fmt.Errorf(common.ErrReadingUpdateFile, err)
+		result.Error = err.Error()
+	}
+	rep := newReport("")
+	rep.Add(result)
+	if inGitHubActions() {
+		writeAnnotations(os.Stdout, absPath, rep)
+	}
+	if writeErr := writeReports(ctx, rep); writeErr != nil {
+		log.Printf("Warning: %v", writeErr)
+	}
+	if writeErr := writeInventory(ctx, runner); writeErr != nil {
+		log.Printf("Warning: %v", writeErr)
+	}
+	if err != nil {
+		return err
+	}
+	return checkFailures(rep)
+}
+
+// patchToDirectory reports whether -output-patch names a directory, which
+// holds one patch per repository
+func patchToDirectory() bool {
+	if strings.HasSuffix(*outputPatch, "/") || strings.HasSuffix(*outputPatch, string(filepath.Separator)) {
+		return true
 	}
+	info, err := os.Stat(*outputPatch)
+	return err == nil && info.IsDir()
+}
 
-	if len(files) == 0 {
-		log.Println(common.ErrNoWorkflowsFound)
+// writePatch writes updates of the repository checked out at absPath to
+// -output-patch
+func writePatch(ctx context.Context, manager updater.UpdateManager, repoOwner, repoName, absPath string, updates []*updater.Update) error {
+	name := filepath.Base(absPath)
+	if repoOwner != "" && repoName != "" {
+		name = repoOwner + "-" + repoName
+	}
+	if len(updates) == 0 {
+		log.Printf("No updates for %s; no patch written", name)
 		return nil
 	}
+	path := *outputPatch
+	if patchToDirectory() {
+		path = filepath.Join(path, name+".patch")
+	}
 
-	// Create version checker using factory
-	checker := versionCheckerFactory(*token)
+	var buf bytes.Buffer
+	files, err := updater.WritePatch(ctx, &buf, manager, absPath, updates)
+	if err != nil {
+		return err
+	}
+	options := common.DefaultFileOptions()
+	options.Mode = 0644
+	options.CreateDirs = true
+	if err := common.WriteFileWithOptions(path, buf.Bytes(), options); err != nil {
+		return fmt.Errorf(common.ErrWritingPatch, err)
+	}
+	log.Printf("Wrote %d updates in %d files to %s", len(updates), files, path)
+	return nil
+}
 
-	// Create update manager with repository root as base directory
-	manager := updater.NewUpdateManager(absPath)
+// newReport creates the report of a run
+func newReport(shardSpec string) *report.Report {
+	rep := report.New(shardSpec)
+	rep.Mode = runMode()
+	return rep
+}
 
-	// Create PR creator using factory and set workflows path
-	creator := prCreatorFactory(*token, *owner, *repo)
-	if prCreatorWithPath, ok := creator.(*updater.DefaultPRCreator); ok {
-		prCreatorWithPath.SetWorkflowsPath(*workflowsPath)
+// writeReports writes the report requested by -report, the step outputs
+// and the summary requested by -summary-file, and sends the -notify
+// notifications
+func writeReports(ctx context.Context, rep *report.Report) error {
+	defer sendNotifications(ctx, rep)
+	if *reportPath != "" {
+		if err := rep.Write(*reportPath); err != nil {
+			return err
+		}
+	}
+	if path := githubOutputPath(); path != "" {
+		if err := rep.AppendOutputs(path); err != nil {
+			return err
+		}
+	}
+	if *summaryFile != "" {
+		return rep.AppendSummary(*summaryFile)
 	}
+	return nil
+}
 
-	// Process each workflow file
-	var updates []*updater.Update
-	ctx := context.Background()
+// runRepositories processes every repository of the selected shard by
+// fetching its workflows into a temporary directory
+func runRepositories(ctx context.Context, runner *repoRunner) error {
+	client := githubClientFactory(*token)
+	names, selected, err := selectRepositories(ctx, client)
+	if err != nil {
+		return err
+	}
+	log.Printf("Processing %d repositories in shard %s", len(names), selected)
 
-	for _, file := range files {
-		// Get action references from file
-		refs, err := scanner.ParseActionReferences(file)
+	rep := newReport(*shardSpec)
+	var checkpoint *report.Checkpoint
+	if *checkpointPath != "" {
+		if checkpoint, err = report.LoadCheckpoint(*checkpointPath, rep.Shard, rep.Mode); err != nil {
+			return err
+		}
+		if len(checkpoint.Repositories) > 0 {
+			log.Printf("Resuming from %s: %d repositories already completed", *checkpointPath, len(checkpoint.Repositories))
+		}
+		runner.checker = updater.NewResumingVersionChecker(runner.checker, checkpoint.Actions)
+	}
+	var runErr error
+	incomplete := 0
+	for _, name := range names {
+		// Stop at the next repository once cancelled but keep the report
+		if err := ctx.Err(); err != nil {
+			runErr = fmt.Errorf(common.ErrRunCancelled, err)
+			break
+		}
+		repoOwner, repoName, err := updater.SplitRepositoryName(name)
 		if err != nil {
-			log.Printf(common.ErrFailedToParseWorkflow, file, err)
+			log.Printf("Warning: %v", err)
 			continue
 		}
-
-		// Check each action for updates
-		for _, ref := range refs {
-			latestVersion, latestHash, err := checker.GetLatestVersion(ctx, ref)
-			if err != nil {
-				log.Printf(common.ErrFailedToCheckAction, ref.Owner, ref.Name, err)
+		if checkpoint != nil {
+			if result, ok := checkpoint.Result(repoOwner, repoName); ok {
+				rep.Add(result)
 				continue
 			}
-
-			// Check if update is available
-			available, _, _, err := checker.IsUpdateAvailable(ctx, ref)
-			if err != nil {
-				log.Printf(common.ErrFailedToCheckUpdate, ref.Owner, ref.Name, err)
-				continue
+		}
+		result := processRemoteRepository(ctx, runner, client, repoOwner, repoName)
+		rep.Add(result)
+		// Repositories with errors, such as an exhausted rate limit, are not
+		// recorded, so a resumed run retries them
+		if result.Error != "" || len(result.Failures) > 0 {
+			incomplete++
+			continue
+		}
+		if checkpoint != nil {
+			if err := checkpoint.Record(result); err != nil {
+				log.Printf("Warning: %v", err)
 			}
+		}
+	}
 
-			if available {
-				update, err := manager.CreateUpdate(ctx, file, ref, latestVersion, latestHash)
-				if err != nil {
-					log.Printf(common.ErrFailedToCreateUpdate, ref.Owner, ref.Name, err)
-					continue
-				}
-				updates = append(updates, update)
-			}
+	fmt.Printf("Processed %d repositories with %d updates\n", len(rep.Repositories), rep.UpdateCount())
+	if err := writeReports(ctx, rep); err != nil {
+		return err
+	}
+	if err := writeInventory(ctx, runner); err != nil {
+		return err
+	}
+	if runErr != nil {
+		return runErr
+	}
+	// The next run starts over once every repository was completed
+	if checkpoint != nil && incomplete == 0 {
+		if err := checkpoint.Remove(); err != nil {
+			log.Printf("Warning: %v", err)
 		}
 	}
+	return checkFailures(rep)
+}
 
-	if len(updates) == 0 {
-		log.Println(common.ErrNoUpdatesAvailable)
+// checkFailures prints the files and references the run skipped because of
+// an error, and fails the run with -strict when there are any
+func checkFailures(rep *report.Report) error {
+	if err := rep.EncodeFailures(os.Stdout); err != nil {
+		return err
+	}
+	if count := rep.FailureCount(); *strict && count > 0 {
+		return fmt.Errorf(common.ErrStrictFailures, count)
+	}
+	return nil
+}
+
+// writeInventory writes the inventory requested by -inventory, looking up
+// the metadata of every action version used
+func writeInventory(ctx context.Context, runner *repoRunner) error {
+	if runner.inventory == nil {
 		return nil
 	}
+	entries := runner.inventory.Entries(ctx, runner.checker, time.Now())
+	if err := report.NewInventory(entries).Write(*inventoryPath, *inventoryFormat); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote an inventory of %d action versions to %s\n", len(entries), *inventoryPath)
+	return nil
+}
+
+// selectRepositories lists the repositories given by -org or -repos-file
+// that belong to the selected shard
+func selectRepositories(ctx context.Context, client *github.Client) ([]string, shard.Shard, error) {
+	var names []string
+	var err error
+	if *org != "" {
+		names, err = updater.ListOrganizationRepositories(ctx, client, *org)
+	} else {
+		names, err = updater.ReadRepositoryList(*reposFile)
+	}
+	if err != nil {
+		return nil, shard.All, err
+	}
+
+	selected := shard.All
+	if *shardSpec != "" {
+		if selected, err = shard.Parse(*shardSpec); err != nil {
+			return nil, shard.All, err
+		}
+	}
+	return selected.Filter(names), selected, nil
+}
+
+// processRemoteRepository fetches a repository's workflows and processes them.
+// Failures are recorded in the result so other repositories still run.
+func processRemoteRepository(ctx context.Context, runner *repoRunner, client *github.Client, repoOwner, repoName string) report.RepositoryResult {
+	result := report.RepositoryResult{Owner: repoOwner, Repo: repoName}
+
+	dir, err := os.MkdirTemp("", "ghactions-updater-")
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	if _, err := updater.FetchWorkflowsAt(ctx, client, repoOwner, repoName, *baseBranch, *workflowsPath, dir); err != nil {
+		log.Printf("Warning: %v", err)
+		metrics.Default.IncError(metrics.CategoryScan)
+		runner.publishError(ctx, repoOwner+"/"+repoName, err)
+		result.Error = err.Error()
+		return result
+	}
+
+	if *dependabotRules {
+		if err := updater.FetchDependabotConfig(ctx, client, repoOwner, repoName, *baseBranch, dir); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+
+	result, err = runner.process(ctx, repoOwner, repoName, dir)
+	if err != nil {
+		log.Printf("Warning: %s/%s: %v", repoOwner, repoName, err)
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// repoRunner holds the dependencies shared by every processed repository
+type repoRunner struct {
+	checker   updater.VersionChecker
+	store     storage.Store
+	only      map[string]bool      // When set, only actions hosted in these repositories are checked
+	scanned   map[string]scanEntry // When set, only updates of these references are applied
+	forced    bool                 // Ignore snoozes, the update policy and Dependabot rules
+	inventory *updater.Inventory   // When set, collects the references of every repository
+
+	summarizer updater.Summarizer     // Optional release notes summarizer
+	ticketer   updater.ChangeTicketer // Optional change ticket integration gating auto-merge
+	events     *updater.EventBus      // Receives update, pull request and error events
+}
+
+// process scans, checks and updates the workflows of a single repository
+// checked out at absPath
+func (r *repoRunner) process(ctx context.Context, repoOwner, repoName, absPath string) (result report.RepositoryResult, err error) {
+	result = report.RepositoryResult{Owner: repoOwner, Repo: repoName}
+	defer func() {
+		if err != nil {
+			r.publishError(ctx, repoOwner+"/"+repoName, err)
+		}
+	}()
+
+	// Create PR creator using factory and set workflows path
+	creator := prCreatorFactory(*token, repoOwner, repoName)
+	if prCreatorWithPath, ok := creator.(interface {
+		SetWorkflowsPath(path string)
+		SetRepoRoot(path string)
+	}); ok {
+		prCreatorWithPath.SetWorkflowsPath(*workflowsPath)
+		prCreatorWithPath.SetRepoRoot(absPath)
+	}
+	if prCreatorWithBase, ok := creator.(interface {
+		SetBaseBranch(branch string)
+		SetDraft(draft bool)
+	}); ok {
+		prCreatorWithBase.SetBaseBranch(*baseBranch)
+		prCreatorWithBase.SetDraft(*draftPR)
+	}
+	if prCreatorWithBranches, ok := creator.(interface {
+		SetBranchTemplate(template updater.BranchTemplate)
+	}); ok {
+		// The template was checked by validateFlags
+		prCreatorWithBranches.SetBranchTemplate(updater.BranchTemplate(*branchTemplate))
+	}
+	if prCreatorWithFork, ok := creator.(interface{ SetFork(fork bool) }); ok {
+		prCreatorWithFork.SetFork(*forkPR)
+	}
+	if prCreatorWithRedactor, ok := creator.(interface {
+		SetRedactor(redactor *updater.Redactor)
+	}); ok {
+		// The patterns were checked by validateFlags
+		redactor, _ := updater.NewRedactor(*redactPatterns)
+		prCreatorWithRedactor.SetRedactor(redactor)
+	}
+	if prCreatorWithStrategy, ok := creator.(interface {
+		SetRewriteStrategy(strategy updater.RewriteStrategy)
+	}); ok {
+		// The strategy was checked by validateFlags
+		strategy, _ := updater.ParseRewriteStrategy(*rewriteStrategy)
+		prCreatorWithStrategy.SetRewriteStrategy(strategy)
+	}
+	var ticketing *updater.TicketingPRCreator
+	if r.ticketer != nil {
+		ticketing = updater.NewTicketingPRCreator(creator, r.ticketer, repoOwner+"/"+repoName)
+		creator = ticketing
+	}
+	creator = updater.NewRetryingPRCreator(creator, retryPolicy())
+
+	// The policy was checked by validateFlags
+	policy, _ := updater.ParseUpdatePolicy(*minUpdateDelta, *skipPatchFor)
+	policy.AllowPrerelease = *allowPrerelease
+	policy.SkipMajor = *noMajor
+	paths, _ := updater.ParsePathFilter(*includePaths, *excludePaths)
+	symlinkPolicy, _ := updater.ParseLinkPolicy(*symlinks, updater.DefaultSymlinkPolicy)
+	submodulePolicy, _ := updater.ParseLinkPolicy(*submodules, updater.DefaultSubmodulePolicy)
+	releaseAge, _ := updater.ParseAge(*minReleaseAge)
+	pinAge, _ := updater.ParseAge(*maxPinAge)
+	if r.forced {
+		policy = updater.UpdatePolicy{AllowPrerelease: true}
+		releaseAge = 0
+	}
+
+	opts := updater.Options{
+		RepoPath:           absPath,
+		WorkflowsPath:      *workflowsPath,
+		Paths:              paths,
+		MaxDepth:           *maxDepth,
+		DiscoverDepth:      *discoverDepth,
+		Symlinks:           symlinkPolicy,
+		Submodules:         submodulePolicy,
+		ParseCache:         r.store,
+		GitLabCI:           *gitlabCI,
+		WorkflowTemplates:  *workflowTemplates,
+		FollowLocalActions: *followLocalActions,
+		Mode:               runMode(),
+		Checker:            r.checker,
+		Manager:            updateManagerFactory(absPath),
+		Creator:            creator,
+		Summarizer:         r.summarizer,
+		Policy:             policy,
+		FloatingTags:       *floatingTags,
+		SeparateMajor:      *majorPR,
+		MaxUpdatesPerPR:    *maxUpdatesPerPR,
+		MinReleaseAge:      releaseAge,
+		MaxPinAge:          pinAge,
+		CommentDrift:       *commentDrift,
+		ValidateSchema:     *validateSchema,
+		CheckPermissions:   *checkPermissions,
+		DetectMoves:        *detectMoves,
+		Metrics:            metrics.Default,
+	}
+	if r.only != nil {
+		opts.Filter = func(ref updater.ActionReference) bool {
+			return r.only[webhook.ActionRepository(ref)]
+		}
+	}
+	// Apply only the reviewed references, and let the user pick among them
+	if r.scanned != nil || *interactive {
+		opts.Select = func(ctx context.Context, updates []*updater.Update) []*updater.Update {
+			if r.scanned != nil {
+				updates = selectScanned(absPath, r.scanned, updates)
+			}
+			if *interactive {
+				updates = selectUpdates(ctx, r.checker, updates, interactiveInput, interactiveOutput)
+			}
+			return updates
+		}
+	}
+
+	// Skip updates snoozed for this repository
+	if r.store != nil && !r.forced {
+		snoozes, err := updater.LoadSnoozes(ctx, r.store, repoOwner, repoName)
+		if err != nil {
+			log.Printf("Warning: failed to load snoozes: %v", err)
+		}
+		opts.Snoozes = snoozes
+	}
 
-	// Handle updates based on mode (dry-run, stage, or normal)
-	if *dryRun {
+	// Propose only the updates Dependabot would, so the two do not compete
+	if *dependabotRules && !r.forced {
+		rules, err := updater.LoadDependabotRules(absPath)
+		if err != nil {
+			log.Printf("Warning: %v", err)
+		}
+		opts.Dependabot = rules
+	}
+
+	// Keep concurrent runs from creating branches and PRs here at once
+	if *repoLockTTL > 0 && opts.Mode == updater.ModePR {
+		unlock, err := lockRepository(ctx, repoOwner, repoName)
+		if err != nil {
+			return result, err
+		}
+		defer unlock()
+	}
+
+	// Enable auto-merge for earlier pull requests whose ticket was approved
+	if ticketing != nil && opts.Mode == updater.ModePR {
+		r.updateChangeGates(ctx, repoOwner, repoName)
+	}
+
+	startedAt := time.Now()
+	rep, err := updater.Run(ctx, opts)
+	if rep == nil {
+		return result, err
+	}
+	if r.inventory != nil {
+		r.inventory.Add(repoOwner+"/"+repoName, rep.RemoteActions)
+	}
+	if *outputPatch != "" && err == nil {
+		err = writePatch(ctx, opts.Manager, repoOwner, repoName, absPath, rep.Updates)
+	}
+	result.FilesScanned = rep.FilesScanned
+	result.LocalActions = len(rep.LocalActions)
+	result.Updates = report.EntriesFromUpdates(rep.Updates)
+	result.PinnedActions, result.UnpinnedActions = report.CountPinned(rep.RemoteActions)
+	result.Unpinned = report.EntriesFromUnpinned(rep.RemoteActions)
+	result.Warnings = rep.Warnings
+	if len(rep.Failures) > 0 {
+		result.Failures = report.EntriesFromFailures(rep.Failures)
+	}
+	if len(rep.CommentDrift) > 0 {
+		result.CommentDrift = report.EntriesFromDrift(rep.CommentDrift)
+	}
+	if len(rep.StalePins) > 0 {
+		result.StalePins = report.EntriesFromStalePins(rep.StalePins)
+	}
+	if len(rep.SchemaErrors) > 0 {
+		result.SchemaErrors = report.EntriesFromSchemaErrors(rep.SchemaErrors)
+	}
+	if len(rep.Permissions) > 0 {
+		result.Permissions = report.EntriesFromPermissions(rep.Permissions)
+	}
+	for _, file := range rep.Files {
+		if rel, relErr := filepath.Rel(absPath, file); relErr == nil {
+			file = filepath.ToSlash(rel)
+		}
+		result.Workflows = append(result.Workflows, file)
+	}
+	if len(rep.PullRequests) > 0 {
+		result.PullRequest, result.PullURL = rep.PullRequests[0].Number, rep.PullRequests[0].URL
+		result.PullRequests = report.EntriesFromPullRequests(rep.PullRequests)
+	}
+	r.publishUpdates(ctx, repoOwner+"/"+repoName, rep)
+
+	// Follow up on every pull request, also those opened before a failure
+	var gates []updater.ChangeGate
+	for _, pull := range rep.PullRequests {
+		if *autoMerge != "" && ticketing == nil {
+			r.enablePRAutoMerge(ctx, repoOwner, repoName, pull.Number)
+		}
+		if *commitStatus != "" {
+			reportCommitStatus(ctx, repoOwner, repoName, rep, pull)
+		}
+		if pull.Ticket != nil && pull.Number != 0 {
+			gates = append(gates, updater.ChangeGate{PullRequest: pull.Number, Ticket: *pull.Ticket, CreatedAt: time.Now()})
+		}
+	}
+	if len(gates) > 0 {
+		r.updateChangeGates(ctx, repoOwner, repoName, gates...)
+	}
+
+	// Record run state in the configured store
+	if r.store != nil && rep.FilesScanned > 0 {
+		state := updater.RunState{
+			Owner:        repoOwner,
+			Repo:         repoName,
+			StartedAt:    startedAt,
+			FinishedAt:   time.Now(),
+			FilesScanned: rep.FilesScanned,
+			UpdatesFound: len(rep.Updates),
+			Mode:         runMode(),
+		}
+		// Record the state of cancelled runs too
+		if err := updater.SaveRunState(context.WithoutCancel(ctx), r.store, state); err != nil {
+			log.Printf("Warning: failed to save run state: %v", err)
+		}
+	}
+	if err != nil || len(rep.Updates) == 0 {
+		return result, err
+	}
+
+	updates := rep.Updates
+	switch opts.Mode {
+	case updater.ModeDryRun:
 		// Preview changes without applying them
 		fmt.Printf("DRY RUN: Would update %d actions in %d files\n", len(updates), countUniqueFiles(updates))
 		for _, update := range updates {
@@ -190,22 +1133,144 @@ func run() error {
 				update.OldVersion,
 				update.NewVersion)
 		}
-	} else if *stage {
-		// Apply changes locally without creating a PR
-		if err := manager.ApplyUpdates(ctx, updates); err != nil {
-			return fmt.Errorf(common.ErrApplyingUpdates, err)
+		for _, ref := range rep.LocalActions {
+			fmt.Printf("- %s:%d: local action %s (not checked)\n", ref.Path, ref.Line, ref.LocalPath)
 		}
+	case updater.ModeStage:
 		fmt.Printf("Applied %d updates locally to %d files\n", len(updates), countUniqueFiles(updates))
-	} else {
-		// Normal mode: Create pull request with updates
-		if err := creator.CreatePR(ctx, updates); err != nil {
-			return fmt.Errorf(common.ErrCreatingPR, err)
-		}
+	default:
 		fmt.Printf("Created pull request with %d updates\n", len(updates))
 	}
+	return result, nil
+}
+
+// exportMetadata writes the version lookups of the run to -metadata.
+// Export failures are logged but never fail the run.
+func exportMetadata(snapshot *updater.MetadataSnapshot) {
+	snapshot.ExportedAt = time.Now().UTC()
+	if err := snapshot.Write(*metadataPath); err != nil {
+		log.Printf("Warning: %v", err)
+		return
+	}
+	fmt.Printf("Exported metadata of %d actions to %s\n", len(snapshot.Actions), *metadataPath)
+}
+
+// exportMetrics writes the recorded metrics to the configured textfile and
+// Pushgateway. Export failures are logged but never fail the run.
+func exportMetrics(reg *metrics.Registry) {
+	reg.Set(metrics.LastRunTimestamp, float64(time.Now().Unix()))
+
+	if *metricsTextfile != "" {
+		if err := reg.WriteTextfile(*metricsTextfile); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+	if *metricsPushURL != "" {
+		if err := reg.Push(context.Background(), nil, *metricsPushURL, *metricsJob); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+}
+
+// multiRepoMode reports whether repositories are processed remotely from an
+// organization or a repository list instead of a local checkout
+func multiRepoMode() bool {
+	return *org != "" || *reposFile != ""
+}
+
+// selectedProvider returns the provider chosen with -provider. The flags are
+// checked by validateFlags, so an invalid choice falls back to GitHub.
+func selectedProvider() updater.Provider {
+	forge, err := updater.NewProvider(*provider, *providerURL)
+	if err != nil {
+		return updater.GitHubProvider{}
+	}
+	return forge
+}
+
+// isGitHubProvider reports whether the GitHub API is used
+func isGitHubProvider() bool {
+	_, ok := selectedProvider().(updater.GitHubProvider)
+	return ok
+}
+
+// etagStoreLocation returns where ETags are stored, "" when they are not
+func etagStoreLocation() string {
+	switch *etagStore {
+	case "":
+		return *storeLocation
+	case "none":
+		return ""
+	}
+	return *etagStore
+}
+
+// detectRepository fills an omitted -owner or -repo-name from the git remote
+// of the local clone. Failing to detect them is only an error when they are
+// needed for a pull request or -remote was given.
+func detectRepository() error {
+	if (*owner != "" && *repo != "") || lockMode() {
+		return nil
+	}
+	detectedOwner, detectedRepo, err := updater.DetectRepository(*repoPath, *gitRemote, remoteHost())
+	if err != nil {
+		if runMode() == updater.ModePR || *gitRemote != "" {
+			return fmt.Errorf(common.ErrDetectingRepository, err)
+		}
+		return nil
+	}
+	if *owner == "" {
+		*owner = detectedOwner
+	}
+	if *repo == "" {
+		*repo = detectedRepo
+	}
+	log.Printf("Detected repository %s/%s from the git remote", *owner, *repo)
 	return nil
 }
 
+// remoteHost returns the host the git remote must point at, "" when unknown
+func remoteHost() string {
+	if isGitHubProvider() {
+		return "github.com"
+	}
+	u, err := url.Parse(*providerURL)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
+
+// selfUpdateToken returns the token for looking up releases of the tool,
+// which are on GitHub whatever the provider
+func selfUpdateToken() string {
+	if isGitHubProvider() {
+		return *token
+	}
+	return ""
+}
+
+// runMode returns the name of the selected run mode
+func runMode() string {
+	switch {
+	case *dryRun, *outputPatch != "":
+		return updater.ModeDryRun
+	case *stage:
+		return updater.ModeStage
+	default:
+		return updater.ModePR
+	}
+}
+
+// retryPolicy returns the retry policy configured by the -retry flags
+func retryPolicy() common.RetryPolicy {
+	policy := common.DefaultRetryPolicy()
+	policy.MaxAttempts = *retryAttempts
+	policy.BaseDelay = *retryDelay
+	policy.Jitter = *retryJitter
+	return policy
+}
+
 // countUniqueFiles counts the number of unique files in the updates slice
 func countUniqueFiles(updates []*updater.Update) int {
 	uniqueFiles := make(map[string]struct{})
@@ -216,16 +1281,33 @@ func countUniqueFiles(updates []*updater.Update) int {
 }
 
 // For testing
-var fatalln = log.Fatal
+var fatalln = fatal
 
-func main() {
-	flag.Parse()
-
-	if err := validateFlags(); err != nil {
-		fatalln(err)
+// fatal logs err, with a hint for its category, and exits with the exit
+// code of the category
+func fatal(err error) {
+	category := common.CategoryOf(err)
+	log.Printf("%s: %v", category, err)
+	if hint := category.Hint(); hint != "" {
+		log.Printf("Hint: %s", hint)
 	}
+	os.Exit(category.ExitCode())
+}
 
-	if err := run(); err != nil {
+func main() {
+	// Without a subcommand the global flags select the run as before
+	cmd, args := subcommand{run: runUpdateCommand}, os.Args[1:]
+	if len(args) > 0 {
+		if found, ok := findSubcommand(args[0]); ok {
+			cmd, args = found, args[1:]
+		}
+	}
+	flag.Usage = func() {
+		printCommands(flag.CommandLine.Output())
+		_, _ = fmt.Fprintln(flag.CommandLine.Output(), "\nFlags:")
+		flag.PrintDefaults()
+	}
+	if err := runCommand(cmd, args, os.Stdout); err != nil {
 		fatalln(err)
 	}
 }
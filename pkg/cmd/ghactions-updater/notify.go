@@ -0,0 +1,30 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/notify"
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/report"
+)
+
+// sendNotifications sends the summary of a run to the -notify endpoints.
+// Cancelled and timed out runs are notified too; failures are logged.
+func sendNotifications(ctx context.Context, rep *report.Report) {
+	summary := notify.Summarize(rep)
+	if !summary.Wanted(*notifyOn) {
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+	for _, spec := range splitList(*notifySpecs) {
+		// The specs were checked by validateFlags
+		notifier, err := notify.New(spec)
+		if err != nil {
+			continue
+		}
+		if err := notifier.Notify(ctx, summary); err != nil {
+			log.Printf(common.ErrNotifying, notifier.Name(), err)
+		}
+	}
+}
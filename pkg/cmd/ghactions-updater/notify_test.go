@@ -0,0 +1,68 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRunNotifications(t *testing.T) {
+	workflows := map[bool]string{
+		true:  "on: push\njobs:\n  build:\n    runs-on: ubuntu-latest\n    steps:\n      - uses: actions/checkout@v3\n",
+		false: "on: push\njobs:\n  build:\n    runs-on: ubuntu-latest\n    steps:\n      - run: make\n",
+	}
+
+	tests := []struct {
+		name     string
+		on       string
+		updates  bool
+		wantSent bool
+	}{
+		{name: "always", on: "always", wantSent: true},
+		{name: "changes without updates", on: "changes"},
+		{name: "changes with updates", on: "changes", updates: true, wantSent: true},
+		{name: "errors only", on: "errors", updates: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var bodies []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				data, _ := io.ReadAll(r.Body)
+				bodies = append(bodies, string(data))
+			}))
+			defer server.Close()
+
+			setupRunEnv(t, map[string]string{".github/workflows/ci.yml": workflows[tt.updates]}, &mockVersionChecker{latestVersion: "v4", latestHash: "abc123"}, &recordingPRCreator{})
+			*notifySpecs = "slack:" + server.URL + "," + server.URL
+			*notifyOn = tt.on
+
+			if err := validateFlags(); err != nil {
+				t.Fatalf("validateFlags() error = %v", err)
+			}
+			if err := run(); err != nil {
+				t.Fatalf("run() error = %v", err)
+			}
+			if !tt.wantSent {
+				if len(bodies) != 0 {
+					t.Errorf("sent %d notifications, want none", len(bodies))
+				}
+				return
+			}
+			if len(bodies) != 2 || !strings.Contains(bodies[0], `"text":"GitHub Actions updater (pr)`) || !strings.Contains(bodies[1], `"repositories":1`) {
+				t.Errorf("notifications = %q", bodies)
+			}
+		})
+	}
+
+	setupRunEnv(t, nil, &mockVersionChecker{}, &recordingPRCreator{})
+	*notifyOn = "never"
+	if err := validateFlags(); err == nil || !strings.Contains(err.Error(), "notify-on") {
+		t.Errorf("validateFlags() error = %v, want notify-on error", err)
+	}
+	*notifyOn, *notifySpecs = "always", "http://hooks.example.com/x"
+	if err := validateFlags(); err == nil || !strings.Contains(err.Error(), "must use https") {
+		t.Errorf("validateFlags() error = %v, want insecure URL error", err)
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/updater"
+)
+
+// pinInput is read by "pin -"; for testing
+var pinInput io.Reader = os.Stdin
+
+// pinOptions are the flags of the pin subcommand
+type pinOptions struct {
+	token         string
+	floatingTags  bool
+	commentFormat string
+	pinStyle      string
+	strategy      string
+}
+
+// register declares the flags on fs
+func (o *pinOptions) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.token, "token", "", "GitHub token for version lookups (default from the environment or gh CLI, see the README)")
+	fs.BoolVar(&o.floatingTags, "floating-tags", false, "Pin references to major tags such as v4 to the commit the tag points to instead of updating them to the latest release")
+	fs.StringVar(&o.commentFormat, "version-comment-format", "", "Format of the version comment written after pinned references, e.g. \"# {version}\"")
+	fs.StringVar(&o.pinStyle, "pin-style", "", "Write updated references of these actions as tags instead of commit hashes, as owner[/repo]=hash|full-version-tag|major-tag, comma separated")
+	fs.StringVar(&o.strategy, "rewrite-strategy", "yaml", "How the workflow is rewritten: yaml or line")
+}
+
+// runPinCommand implements the "pin" subcommand:
+//
+//	ghactions-updater pin [-token t] [-floating-tags] [-version-comment-format f] [-pin-style p] [-rewrite-strategy s] -|file
+//
+// It pins and updates the action references of a single workflow, read
+// from stdin for "-", and writes the result to stdout, leaving files as
+// they are. Editor integrations and pre-commit hooks use it as a filter.
+// Lookup failures are logged to stderr and leave their references as they
+// were.
+func runPinCommand(args []string, stdout io.Writer) error {
+	var opts pinOptions
+	fs := newFlagSet("pin", stdout, opts.register)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf(common.ErrInvalidFlagValue, "pin", "expected - or a workflow file")
+	}
+	strategy, err := updater.ParseRewriteStrategy(opts.strategy)
+	if err != nil {
+		return fmt.Errorf(common.ErrInvalidFlagValue, "rewrite-strategy", err.Error())
+	}
+	pinPolicy, err := updater.ParsePinPolicy(opts.pinStyle)
+	if err != nil {
+		return fmt.Errorf(common.ErrInvalidFlagValue, "pin-style", err.Error())
+	}
+
+	name, content, err := readPinInput(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	// The workflow is checked in a repository of its own, so references are
+	// found and rewritten exactly as in a regular run
+	repo, err := os.MkdirTemp("", "ghactions-pin-")
+	if err != nil {
+		return fmt.Errorf(common.ErrCommandExecution, err)
+	}
+	defer func() { _ = os.RemoveAll(repo) }()
+	workflows := filepath.Join(repo, ".github", "workflows")
+	if err := os.MkdirAll(workflows, 0o755); err != nil {
+		return fmt.Errorf(common.ErrCommandExecution, err)
+	}
+	if err := os.WriteFile(filepath.Join(workflows, name), content, 0o600); err != nil {
+		return fmt.Errorf(common.ErrCommandExecution, err)
+	}
+
+	if opts.token == "" {
+		found, err := githubToken()
+		if err != nil {
+			return err
+		}
+		opts.token = found
+	}
+	manager := updater.NewUpdateManagerWithOptions(repo, updater.UpdateManagerOptions{
+		VersionCommentFormat: opts.commentFormat,
+		RewriteStrategy:      strategy,
+		PinPolicy:            pinPolicy,
+	})
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	rep, err := updater.Run(ctx, updater.Options{
+		RepoPath:     repo,
+		Mode:         updater.ModeDryRun,
+		Checker:      versionCheckerFactory(opts.token),
+		Manager:      manager,
+		FloatingTags: opts.floatingTags,
+	})
+	if err != nil {
+		return fmt.Errorf(common.ErrCommandExecution, err)
+	}
+	changes, err := manager.PreviewUpdates(ctx, rep.Updates)
+	if err != nil {
+		return fmt.Errorf(common.ErrCommandExecution, err)
+	}
+	if len(changes) > 0 {
+		content = []byte(changes[0].New)
+	}
+	if _, err := stdout.Write(content); err != nil {
+		return fmt.Errorf(common.ErrCommandExecution, err)
+	}
+	return nil
+}
+
+// readPinInput reads the workflow named by arg and returns the file name it
+// is checked under
+func readPinInput(arg string) (string, []byte, error) {
+	if arg == "-" {
+		content, err := io.ReadAll(pinInput)
+		if err != nil {
+			return "", nil, fmt.Errorf(common.ErrCommandExecution, err)
+		}
+		return "stdin.yml", content, nil
+	}
+	content, err := os.ReadFile(arg)
+	if err != nil {
+		return "", nil, fmt.Errorf(common.ErrCommandExecution, err)
+	}
+	name := filepath.Base(arg)
+	if ext := filepath.Ext(name); ext != ".yml" && ext != ".yaml" {
+		return "", nil, fmt.Errorf(common.ErrInvalidFlagValue, "pin", "workflow files end in .yml or .yaml")
+	}
+	return name, content, nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/updater"
+)
+
+func TestRunPinCommand(t *testing.T) {
+	const hash = "1111111111111111111111111111111111111111"
+	content := "on: push\r\njobs:\r\n  build:\r\n    runs-on: ubuntu-latest\r\n    steps:\r\n      - uses: actions/checkout@v3\r\n      - uses: ./.github/actions/build\r\n"
+	pinned := "on: push\r\njobs:\r\n  build:\r\n    runs-on: ubuntu-latest\r\n    steps:\r\n      - uses: actions/checkout@" + hash + "  # v4.0.0\r\n      - uses: ./.github/actions/build\r\n"
+
+	file := filepath.Join(t.TempDir(), "ci.yaml")
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	other := filepath.Join(t.TempDir(), "ci.txt")
+	if err := os.WriteFile(other, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	oldFactory, oldInput := versionCheckerFactory, pinInput
+	defer func() { versionCheckerFactory, pinInput = oldFactory, oldInput }()
+
+	tests := []struct {
+		name    string
+		args    []string
+		checker *mockVersionChecker
+		want    string
+		wantErr bool
+	}{
+		{name: "stdin", args: []string{"-"}, checker: &mockVersionChecker{latestVersion: "v4.0.0", latestHash: hash}, want: pinned},
+		{name: "file", args: []string{file}, checker: &mockVersionChecker{latestVersion: "v4.0.0", latestHash: hash}, want: pinned},
+		{name: "failed lookup", args: []string{"-"}, checker: &mockVersionChecker{err: errors.New("API down")}, want: content},
+		{name: "no argument", args: nil, wantErr: true},
+		{name: "not a workflow", args: []string{other}, wantErr: true},
+		{name: "missing file", args: []string{filepath.Join(t.TempDir(), "ci.yml")}, wantErr: true},
+		{name: "bad strategy", args: []string{"-rewrite-strategy", "sed", "-"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			versionCheckerFactory = func(token string) updater.VersionChecker { return tt.checker }
+			pinInput = strings.NewReader(content)
+
+			var out bytes.Buffer
+			err := runPinCommand(tt.args, &out)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("runPinCommand() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && out.String() != tt.want {
+				t.Errorf("runPinCommand() output =\n%q\nwant\n%q", out.String(), tt.want)
+			}
+		})
+	}
+
+	// The file given is only read
+	got, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != content {
+		t.Errorf("runPinCommand() changed %s:\n%s", file, got)
+	}
+}
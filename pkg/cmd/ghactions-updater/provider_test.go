@@ -0,0 +1,46 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestValidateProviderFlags(t *testing.T) {
+	tests := []struct {
+		name      string
+		setup     func(t *testing.T)
+		wantErr   bool
+		wantToken string
+	}{
+		{name: "github by default", setup: func(t *testing.T) {}},
+		{name: "gitea without url", setup: func(t *testing.T) { *provider = "gitea" }, wantErr: true},
+		{name: "unknown provider", setup: func(t *testing.T) { *provider = "gitlab" }, wantErr: true},
+		{name: "gitea with org", setup: func(t *testing.T) { *provider = "gitea"; *providerURL = "https://gitea.example.com"; *org = "acme" }, wantErr: true},
+		{name: "gitea with fork", setup: func(t *testing.T) { *provider = "gitea"; *providerURL = "https://gitea.example.com"; *forkPR = true }, wantErr: true},
+		{name: "fork", setup: func(t *testing.T) { *forkPR = true }},
+		{name: "fork with auto-merge", setup: func(t *testing.T) { *forkPR = true; *autoMerge = "squash" }, wantErr: true},
+		{
+			name: "gitea token from environment",
+			setup: func(t *testing.T) {
+				*provider = "gitea"
+				*providerURL = "https://gitea.example.com"
+				t.Setenv("GITEA_TOKEN", "0123456789abcdef0123456789abcdef01234567")
+			},
+			wantToken: "0123456789abcdef0123456789abcdef01234567",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupRunEnv(t, nil, &mockVersionChecker{}, &recordingPRCreator{})
+			t.Setenv("GITHUB_TOKEN", "")
+			tt.setup(t)
+			err := validateFlags()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateFlags() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if *token != tt.wantToken {
+				t.Errorf("token = %q, want %q", *token, tt.wantToken)
+			}
+		})
+	}
+}
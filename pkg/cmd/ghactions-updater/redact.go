@@ -0,0 +1,41 @@
+package main
+
+import (
+	"flag"
+	"strings"
+)
+
+// patternListValue is a flag collecting regular expressions. The flag may
+// be repeated, and a value may give one pattern per line, as a configuration
+// file block scalar does; commas cannot separate patterns as they occur in
+// regular expressions.
+type patternListValue []string
+
+// patternListFlag defines a pattern list flag on the command line
+func patternListFlag(name, usage string) *[]string {
+	value := new([]string)
+	flag.Var((*patternListValue)(value), name, usage)
+	return value
+}
+
+// String implements flag.Value
+func (v *patternListValue) String() string {
+	if v == nil {
+		return ""
+	}
+	return strings.Join(*v, "\n")
+}
+
+// Set implements flag.Value. An empty value clears the list.
+func (v *patternListValue) Set(s string) error {
+	if s == "" {
+		*v = nil
+		return nil
+	}
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			*v = append(*v, line)
+		}
+	}
+	return nil
+}
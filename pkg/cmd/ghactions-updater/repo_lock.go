@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/updater"
+)
+
+// lockHolder identifies this instance in repository locks
+func lockHolder() string {
+	host, _ := os.Hostname()
+	holder := fmt.Sprintf("%s:%d", host, os.Getpid())
+	if runID := os.Getenv("GITHUB_RUN_ID"); runID != "" {
+		holder += " (run " + runID + ")"
+	}
+	return holder
+}
+
+// lockRepository takes the -repo-lock-ttl lock of a repository, waiting up
+// to -repo-lock-wait for another instance to release it. The returned
+// function releases the lock; failing to release it is logged, and the lock
+// expires anyway.
+func lockRepository(ctx context.Context, repoOwner, repoName string) (func(), error) {
+	lock := updater.NewRepositoryLock(githubClientFactory(*token), repoOwner, repoName, lockHolder(), *repoLockTTL)
+	if err := lock.Acquire(ctx, *repoLockWait); err != nil {
+		return nil, err
+	}
+	return func() {
+		// Release the lock of cancelled runs too
+		if err := lock.Release(context.WithoutCancel(ctx)); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}, nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunRepositoryLock(t *testing.T) {
+	const hash = "1234567890123456789012345678901234567890"
+	workflow := "on: push\njobs:\n  build:\n    runs-on: ubuntu-latest\n    steps:\n      - uses: actions/checkout@v3\n"
+	expires := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+
+	tests := []struct {
+		name        string
+		held        bool
+		wantErr     string
+		wantCreated bool
+	}{
+		{name: "free", wantCreated: true},
+		{name: "held", held: true, wantErr: "test-owner/test-repo is locked by other:1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			creator := &recordingPRCreator{}
+			setupRunEnv(t, map[string]string{".github/workflows/ci.yml": workflow}, &mockVersionChecker{latestVersion: "v4", latestHash: hash}, creator)
+
+			ref := ""
+			if tt.held {
+				ref = "held"
+			}
+			var deleted bool
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /repos/test-owner/test-repo/git/ref/ghactions-updater/lock", func(w http.ResponseWriter, r *http.Request) {
+				if ref == "" {
+					http.Error(w, `{"message":"Not Found"}`, http.StatusNotFound)
+					return
+				}
+				fmt.Fprintf(w, `{"object":{"sha":%q}}`, ref)
+			})
+			mux.HandleFunc("GET /repos/test-owner/test-repo/git/commits/held", func(w http.ResponseWriter, r *http.Request) {
+				message := fmt.Sprintf(`ghactions-updater lock
+
+{"holder":"other:1","expires":%q}`, expires)
+				_ = json.NewEncoder(w).Encode(map[string]string{"message": message})
+			})
+			mux.HandleFunc("POST /repos/test-owner/test-repo/git/trees", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `{"sha":"tree"}`)
+			})
+			mux.HandleFunc("POST /repos/test-owner/test-repo/git/commits", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `{"sha":"mine"}`)
+			})
+			mux.HandleFunc("POST /repos/test-owner/test-repo/git/refs", func(w http.ResponseWriter, r *http.Request) {
+				ref = "mine"
+				fmt.Fprint(w, `{"object":{"sha":"mine"}}`)
+			})
+			mux.HandleFunc("DELETE /repos/test-owner/test-repo/git/refs/ghactions-updater/lock", func(w http.ResponseWriter, r *http.Request) {
+				deleted = true
+				w.WriteHeader(http.StatusNoContent)
+			})
+			useGitHubServer(t, mux)
+
+			*repoLockTTL = 10 * time.Minute
+			if err := validateFlags(); err != nil {
+				t.Fatalf("validateFlags() error = %v", err)
+			}
+			err := run()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("run() error = %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("run() error = %v", err)
+			}
+			if created := len(creator.updates) > 0; created != tt.wantCreated {
+				t.Errorf("created PR = %v, want %v", created, tt.wantCreated)
+			}
+			if deleted != tt.wantCreated {
+				t.Errorf("released lock = %v, want %v", deleted, tt.wantCreated)
+			}
+		})
+	}
+}
+
+func TestValidateRepositoryLockFlags(t *testing.T) {
+	setupRunEnv(t, nil, &mockVersionChecker{}, &recordingPRCreator{})
+	tests := []struct {
+		name    string
+		set     func()
+		wantErr string
+	}{
+		{name: "negative ttl", set: func() { *repoLockTTL = -time.Minute }, wantErr: "repo-lock-ttl"},
+		{name: "negative wait", set: func() { *repoLockWait = -time.Minute }, wantErr: "repo-lock-wait"},
+		{name: "fork", set: func() { *repoLockTTL = time.Minute; *forkPR = true }, wantErr: "-repo-lock-ttl"},
+		{name: "gitea", set: func() { *repoLockTTL = time.Minute; *provider = "gitea"; *providerURL = "https://gitea.example.com" }, wantErr: "requires the github provider"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			*repoLockTTL, *repoLockWait, *forkPR = 0, 0, false
+			*provider, *providerURL = "github", ""
+			tt.set()
+			if err := validateFlags(); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validateFlags() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/report"
+)
+
+// reportMergeOptions are the flags of the report merge subcommand
+type reportMergeOptions struct {
+	format string
+	output string
+}
+
+// register declares the flags on fs
+func (o *reportMergeOptions) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.format, "format", report.FormatJSON, "Output format ("+strings.Join(report.Formats, ", ")+")")
+	fs.StringVar(&o.output, "o", "", "Write the merged report to this file instead of stdout")
+}
+
+// runReportCommand implements the "report" subcommand:
+//
+//	ghactions-updater report merge [-format json|markdown|text] [-o file] a.json b.json ...
+func runReportCommand(args []string, stdout io.Writer) error {
+	if len(args) == 0 || args[0] != "merge" {
+		return fmt.Errorf(common.ErrInvalidFlagValue, "report", "expected subcommand: merge")
+	}
+
+	var opts reportMergeOptions
+	fs := newFlagSet("report merge", stdout, opts.register)
+	if err := parseFlags(fs, args[1:]); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return fmt.Errorf(common.ErrNoReportsToMerge)
+	}
+
+	reports := make([]*report.Report, 0, fs.NArg())
+	for _, path := range fs.Args() {
+		r, err := report.Read(path)
+		if err != nil {
+			return err
+		}
+		reports = append(reports, r)
+	}
+
+	merged, err := report.Merge(reports...)
+	if err != nil {
+		return err
+	}
+
+	if opts.output != "" {
+		return merged.WriteFormat(opts.output, opts.format)
+	}
+	return merged.Encode(stdout, opts.format)
+}
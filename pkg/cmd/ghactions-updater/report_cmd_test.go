@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/report"
+)
+
+func TestRunReportCommandMerge(t *testing.T) {
+	dir := t.TempDir()
+	var paths []string
+	for _, name := range []string{"one", "two"} {
+		r := report.New("")
+		r.Add(report.RepositoryResult{Owner: "acme", Repo: name, Updates: []report.UpdateEntry{{Action: "actions/checkout", NewVersion: "v4"}}})
+		path := filepath.Join(dir, name+".json")
+		if err := r.Write(path); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+
+	var out bytes.Buffer
+	if err := runReportCommand(append([]string{"merge", "-format", "text"}, paths...), &out); err != nil {
+		t.Fatalf("runReportCommand() error = %v", err)
+	}
+	if !strings.Contains(out.String(), "2 repositories, 2 updates") {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+
+	merged := filepath.Join(dir, "merged.json")
+	if err := runReportCommand(append([]string{"merge", "-o", merged}, paths...), &out); err != nil {
+		t.Fatalf("runReportCommand() error = %v", err)
+	}
+	r, err := report.Read(merged)
+	if err != nil {
+		t.Fatalf("report.Read() error = %v", err)
+	}
+	if len(r.Repositories) != 2 {
+		t.Errorf("merged report has %d repositories, want 2", len(r.Repositories))
+	}
+}
+
+func TestRunReportCommandErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{name: "no subcommand", args: nil},
+		{name: "unknown subcommand", args: []string{"split"}},
+		{name: "no inputs", args: []string{"merge"}},
+		{name: "missing input", args: []string{"merge", filepath.Join(t.TempDir(), "missing.json")}},
+		{name: "bad flag", args: []string{"merge", "-nope"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := runReportCommand(tt.args, &bytes.Buffer{}); err == nil {
+				t.Error("runReportCommand() expected error, got nil")
+			}
+		})
+	}
+}
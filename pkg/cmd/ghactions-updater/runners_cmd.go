@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/updater"
+)
+
+// Output formats of the runners subcommand
+const (
+	runnersFormatText = "text"
+	runnersFormatJSON = "json"
+)
+
+// runnersOptions are the flags of the runners subcommand
+type runnersOptions struct {
+	root      string
+	workflows string
+	suggest   bool
+	format    string
+}
+
+// register declares the flags on fs
+func (o *runnersOptions) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.root, "repo", ".", "Path to the repository")
+	fs.StringVar(&o.workflows, "workflows-path", ".github/workflows", "Path to workflow files (relative to repository root)")
+	fs.BoolVar(&o.suggest, "suggest", false, "Suggest the pinned image each label currently resolves to")
+	fs.StringVar(&o.format, "format", runnersFormatText, "Output format (text, json)")
+}
+
+// runRunnersCommand implements the "runners" subcommand:
+//
+//	ghactions-updater runners [-repo path] [-workflows-path p] [-suggest] [-format text|json]
+//
+// It reports jobs running on mutable runner labels such as ubuntu-latest.
+func runRunnersCommand(args []string, stdout io.Writer) error {
+	var opts runnersOptions
+	fs := newFlagSet("runners", stdout, opts.register)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if opts.format != runnersFormatText && opts.format != runnersFormatJSON {
+		return fmt.Errorf(common.ErrInvalidFlagValue, "format", opts.format)
+	}
+
+	absRoot, err := filepath.Abs(opts.root)
+	if err != nil {
+		return fmt.Errorf(common.ErrCommandExecution, err)
+	}
+	scanner := updater.NewScanner(absRoot)
+	files, err := scanner.ScanWorkflows(filepath.Join(absRoot, opts.workflows))
+	if err != nil {
+		return fmt.Errorf(common.ErrCommandExecution, err)
+	}
+
+	labels := []updater.RunnerLabel{}
+	for _, file := range files {
+		found, err := scanner.ScanRunnerLabels(file)
+		if err != nil {
+			return fmt.Errorf(common.ErrCommandExecution, err)
+		}
+		for _, label := range found {
+			if rel, err := filepath.Rel(absRoot, label.Path); err == nil {
+				label.Path = filepath.ToSlash(rel)
+			}
+			if !opts.suggest {
+				label.Suggestion = ""
+			}
+			labels = append(labels, label)
+		}
+	}
+
+	if opts.format == runnersFormatJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(labels)
+	}
+	for _, label := range labels {
+		line := fmt.Sprintf("%s:%d: job %q runs on mutable label %s", label.Path, label.Line, label.Job, label.Label)
+		if label.Suggestion != "" {
+			line += fmt.Sprintf(" (pin to %s)", label.Suggestion)
+		}
+		_, _ = fmt.Fprintln(stdout, line)
+	}
+	_, _ = fmt.Fprintf(stdout, "%d mutable runner labels in %d workflow files\n", len(labels), len(files))
+	return nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/updater"
+)
+
+func TestRunRunnersCommand(t *testing.T) {
+	dir := t.TempDir()
+	workflows := filepath.Join(dir, ".github", "workflows")
+	if err := os.MkdirAll(workflows, 0755); err != nil {
+		t.Fatal(err)
+	}
+	content := "on: push\njobs:\n  build:\n    runs-on: ubuntu-latest\n    steps: []\n"
+	if err := os.WriteFile(filepath.Join(workflows, "ci.yml"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		args  []string
+		want  string
+		avoid string
+	}{
+		{name: "text", args: nil, want: `.github/workflows/ci.yml:4: job "build" runs on mutable label ubuntu-latest` + "\n", avoid: "pin to"},
+		{name: "suggest", args: []string{"-suggest"}, want: "(pin to ubuntu-24.04)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := runRunnersCommand(append([]string{"-repo", dir}, tt.args...), &out); err != nil {
+				t.Fatalf("runRunnersCommand() error = %v", err)
+			}
+			if !strings.Contains(out.String(), tt.want) {
+				t.Errorf("output = %q, want %q", out.String(), tt.want)
+			}
+			if tt.avoid != "" && strings.Contains(out.String(), tt.avoid) {
+				t.Errorf("output = %q, should not contain %q", out.String(), tt.avoid)
+			}
+		})
+	}
+
+	var out bytes.Buffer
+	if err := runRunnersCommand([]string{"-repo", dir, "-format", "json", "-suggest"}, &out); err != nil {
+		t.Fatalf("runRunnersCommand(json) error = %v", err)
+	}
+	var labels []updater.RunnerLabel
+	if err := json.Unmarshal(out.Bytes(), &labels); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+	if len(labels) != 1 || labels[0].Path != ".github/workflows/ci.yml" || labels[0].Suggestion != "ubuntu-24.04" {
+		t.Errorf("labels = %+v", labels)
+	}
+
+	if err := runRunnersCommand([]string{"-repo", dir, "-format", "xml"}, &out); err == nil {
+		t.Error("runRunnersCommand() with unknown format succeeded, want error")
+	}
+}
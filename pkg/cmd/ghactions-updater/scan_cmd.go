@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/updater"
+)
+
+// scanEntry is one reference in the output of the scan subcommand
+type scanEntry struct {
+	Path            string `json:"path"`
+	Line            int    `json:"line"`
+	Action          string `json:"action"`
+	Ref             string `json:"ref"`
+	RefType         string `json:"ref_type"`
+	Version         string `json:"version,omitempty"`
+	Latest          string `json:"latest,omitempty"`
+	UpdateAvailable bool   `json:"update_available,omitempty"`
+	Error           string `json:"error,omitempty"`
+}
+
+// scanOptions are the flags of the scan subcommand
+type scanOptions struct {
+	root       string
+	workflows  string
+	include    string
+	exclude    string
+	maxDepth   int
+	discover   int
+	symlinks   string
+	submodules string
+	noCheck    bool
+	format     string
+	token      string
+	out        string
+}
+
+// register declares the flags on fs
+func (o *scanOptions) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.root, "repo", ".", "Path to the repository")
+	fs.StringVar(&o.workflows, "workflows-path", ".github/workflows", "Path to workflow files (relative to repository root)")
+	fs.StringVar(&o.include, "include", "", "Only scan workflow files matching these globs, relative to -workflows-path, comma separated")
+	fs.StringVar(&o.exclude, "exclude", "", "Skip workflow files matching these globs, relative to -workflows-path, comma separated")
+	fs.IntVar(&o.maxDepth, "max-depth", 0, "Directory levels scanned in -workflows-path (0: no limit)")
+	fs.IntVar(&o.discover, "discover-depth", 0, "Also scan the -workflows-path of subprojects up to this many directories below -repo (0: disabled)")
+	fs.StringVar(&o.symlinks, "symlinks", string(updater.DefaultSymlinkPolicy), "Symbolic links in workflows directories: follow, skip or error")
+	fs.StringVar(&o.submodules, "submodules", string(updater.DefaultSubmodulePolicy), "Git submodules in scanned directories: follow, skip or error")
+	fs.BoolVar(&o.noCheck, "no-check", false, "Only list the references found, without looking up versions")
+	fs.StringVar(&o.format, "format", runnersFormatText, "Output format (text, json)")
+	fs.StringVar(&o.token, "token", "", "GitHub token for version lookups (default from the environment or gh CLI, see the README)")
+	fs.StringVar(&o.out, "out", "", "Also write the references as JSON to this file, to review or filter before \"update -in\"")
+}
+
+// runScanCommand implements the "scan" subcommand:
+//
+//	ghactions-updater scan [-repo path] [-workflows-path p] [-include globs] [-exclude globs] [-max-depth n] [-discover-depth n] [-symlinks p] [-submodules p] [-no-check] [-format text|json] [-out file]
+//
+// It lists every action reference in the workflows. With -no-check no API
+// calls are made; otherwise the latest version of each remote action is
+// looked up as well. -out also writes the list as JSON for "update -in".
+func runScanCommand(args []string, stdout io.Writer) error {
+	var opts scanOptions
+	fs := newFlagSet("scan", stdout, opts.register)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if opts.format != runnersFormatText && opts.format != runnersFormatJSON {
+		return fmt.Errorf(common.ErrInvalidFlagValue, "format", opts.format)
+	}
+
+	absRoot, err := filepath.Abs(opts.root)
+	if err != nil {
+		return fmt.Errorf(common.ErrCommandExecution, err)
+	}
+	paths, err := updater.ParsePathFilter(opts.include, opts.exclude)
+	if err != nil {
+		return fmt.Errorf(common.ErrInvalidFlagValue, "include/exclude", err.Error())
+	}
+	symlinkPolicy, err := updater.ParseLinkPolicy(opts.symlinks, updater.DefaultSymlinkPolicy)
+	if err != nil {
+		return fmt.Errorf(common.ErrInvalidFlagValue, "symlinks", err.Error())
+	}
+	submodulePolicy, err := updater.ParseLinkPolicy(opts.submodules, updater.DefaultSubmodulePolicy)
+	if err != nil {
+		return fmt.Errorf(common.ErrInvalidFlagValue, "submodules", err.Error())
+	}
+	scanner := updater.NewScanner(absRoot)
+	scanner.SetPathFilter(paths)
+	scanner.SetMaxDepth(opts.maxDepth)
+	scanner.SetSymlinkPolicy(symlinkPolicy)
+	scanner.SetSubmodulePolicy(submodulePolicy)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	dirs := []string{filepath.Join(absRoot, opts.workflows)}
+	if opts.discover > 0 {
+		nested, err := updater.FindWorkflowDirs(ctx, absRoot, opts.workflows, opts.discover, submodulePolicy)
+		if err != nil {
+			return fmt.Errorf(common.ErrCommandExecution, err)
+		}
+		// Subproject workflows are enough when the root has none
+		if _, err := os.Stat(dirs[0]); err != nil && len(nested) > 0 {
+			dirs = nil
+		}
+		dirs = append(dirs, nested...)
+	}
+	var files []string
+	for _, dir := range dirs {
+		dirFiles, err := scanner.ScanWorkflows(dir)
+		if err != nil {
+			return fmt.Errorf(common.ErrCommandExecution, err)
+		}
+		files = append(files, dirFiles...)
+	}
+
+	var checker updater.VersionChecker
+	if !opts.noCheck {
+		if opts.token == "" {
+			found, err := githubToken()
+			if err != nil {
+				return err
+			}
+			opts.token = found
+		}
+		checker = versionCheckerFactory(opts.token)
+	}
+
+	entries := []scanEntry{}
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf(common.ErrRunCancelled, err)
+		}
+		refs, err := scanner.ParseActionReferences(file)
+		if err != nil {
+			log.Printf(common.ErrFailedToParseWorkflow, file, err)
+			continue
+		}
+		for _, ref := range refs {
+			entry := newScanEntry(absRoot, file, ref)
+			if checker != nil && !ref.IsLocal() {
+				available, latest, _, err := checker.IsUpdateAvailable(ctx, ref)
+				if err != nil {
+					entry.Error = err.Error()
+				} else {
+					entry.Latest, entry.UpdateAvailable = latest, available
+				}
+			}
+			entries = append(entries, entry)
+		}
+	}
+
+	if opts.out != "" {
+		if err := writeScanResults(opts.out, entries); err != nil {
+			return err
+		}
+	}
+
+	if opts.format == runnersFormatJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(entries)
+	}
+	for _, entry := range entries {
+		name := entry.Action
+		if entry.Ref != "" {
+			name += "@" + entry.Ref
+		}
+		line := fmt.Sprintf("%s:%d: %s (%s", entry.Path, entry.Line, name, entry.RefType)
+		if entry.Version != "" {
+			line += ", " + entry.Version
+		}
+		line += ")"
+		switch {
+		case entry.Error != "":
+			line += " check failed: " + entry.Error
+		case entry.UpdateAvailable:
+			line += " update available: " + entry.Latest
+		}
+		_, _ = fmt.Fprintln(stdout, line)
+	}
+	_, _ = fmt.Fprintf(stdout, "%d references in %d workflow files\n", len(entries), len(files))
+	return nil
+}
+
+// newScanEntry describes a reference found in file
+func newScanEntry(absRoot, file string, ref updater.ActionReference) scanEntry {
+	path := file
+	if rel, err := filepath.Rel(absRoot, file); err == nil {
+		path = filepath.ToSlash(rel)
+	}
+	entry := scanEntry{Path: path, Line: ref.Line, RefType: ref.RefType()}
+	switch {
+	case ref.IsLocal():
+		entry.Action = ref.LocalPath
+	case ref.CommitHash != "":
+		entry.Action, entry.Ref = ref.FullName(), ref.CommitHash
+		if ref.Version != ref.CommitHash {
+			entry.Version = ref.Version
+		}
+	default:
+		entry.Action, entry.Ref = ref.FullName(), ref.Version
+	}
+	return entry
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/updater"
+)
+
+func TestRunScanCommand(t *testing.T) {
+	dir := t.TempDir()
+	workflows := filepath.Join(dir, ".github", "workflows")
+	if err := os.MkdirAll(workflows, 0755); err != nil {
+		t.Fatal(err)
+	}
+	content := `on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v3
+      - uses: actions/setup-go@8e5e7e5ab8b370d6c329ec480221332ada57f0ab  # v5
+      - uses: ./.github/actions/build
+      - uses: octo/tool@main
+`
+	if err := os.WriteFile(filepath.Join(workflows, "ci.yml"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	oldFactory := versionCheckerFactory
+	defer func() { versionCheckerFactory = oldFactory }()
+	var lookups int
+	versionCheckerFactory = func(token string) updater.VersionChecker {
+		lookups++
+		return &mockVersionChecker{latestVersion: "v4", latestHash: "abc123"}
+	}
+
+	var out bytes.Buffer
+	if err := runScanCommand([]string{"-repo", dir, "-no-check"}, &out); err != nil {
+		t.Fatalf("runScanCommand() error = %v", err)
+	}
+	if lookups != 0 {
+		t.Errorf("-no-check created a version checker")
+	}
+	for _, want := range []string{
+		".github/workflows/ci.yml:6: actions/checkout@v3 (tag)\n",
+		".github/workflows/ci.yml:7: actions/setup-go@8e5e7e5ab8b370d6c329ec480221332ada57f0ab (sha, v5)\n",
+		".github/workflows/ci.yml:8: ./.github/actions/build (local)\n",
+		".github/workflows/ci.yml:9: octo/tool@main (branch)\n",
+		"4 references in 1 workflow files\n",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output = %q, want %q", out.String(), want)
+		}
+	}
+	if strings.Contains(out.String(), "update available") {
+		t.Errorf("output = %q, should not report updates with -no-check", out.String())
+	}
+
+	out.Reset()
+	if err := runScanCommand([]string{"-repo", dir, "-format", "json"}, &out); err != nil {
+		t.Fatalf("runScanCommand(json) error = %v", err)
+	}
+	var entries []scanEntry
+	if err := json.Unmarshal(out.Bytes(), &entries); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+	if len(entries) != 4 || lookups != 1 {
+		t.Fatalf("entries = %+v, lookups = %d", entries, lookups)
+	}
+	if entries[0].Latest != "v4" || !entries[0].UpdateAvailable || entries[2].Latest != "" {
+		t.Errorf("entries = %+v", entries)
+	}
+
+	if err := runScanCommand([]string{"-repo", dir, "-format", "xml"}, &out); err == nil {
+		t.Error("expected error for unknown format")
+	}
+
+	out.Reset()
+	if err := runScanCommand([]string{"-repo", dir, "-no-check", "-exclude", "ci.yml"}, &out); err != nil {
+		t.Fatalf("runScanCommand(-exclude) error = %v", err)
+	}
+	if !strings.Contains(out.String(), "0 references in 0 workflow files\n") {
+		t.Errorf("output with -exclude = %q", out.String())
+	}
+	if err := runScanCommand([]string{"-repo", dir, "-include", "[", "-no-check"}, &out); err == nil {
+		t.Error("expected error for an invalid pattern")
+	}
+}
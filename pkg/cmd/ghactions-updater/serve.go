@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/updater"
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/webhook"
+	"github.com/google/go-github/v72/github"
+)
+
+// webhookSecretEnv names the environment variable holding the webhook secret
+const webhookSecretEnv = "GITHUB_WEBHOOK_SECRET" // #nosec G101 - variable name, not a credential
+
+// webhookServe runs the webhook HTTP server until ctx is done. For testing.
+var webhookServe = func(ctx context.Context, srv *http.Server) error {
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// serveWebhooks indexes the watched repositories, then updates them as
+// release and push webhooks for the actions they use arrive
+func serveWebhooks(ctx context.Context, runner *repoRunner) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	client := githubClientFactory(*token)
+	names, _, err := selectRepositories(ctx, client)
+	if err != nil {
+		return err
+	}
+
+	index := webhook.NewIndex()
+	for _, name := range names {
+		if err := indexRepository(ctx, client, index, name); err != nil {
+			log.Printf(common.ErrIndexingRepository, name, err)
+		}
+	}
+	log.Printf("Watching %d repositories for action releases", index.Len())
+
+	queue := webhook.NewQueue(func(ctx context.Context, job webhook.Job) error {
+		return runWebhookJob(ctx, runner, client, index, job)
+	})
+	go queue.Run(ctx)
+
+	mux := http.NewServeMux()
+	mux.Handle("/webhook", &webhook.Handler{
+		Secret:   []byte(os.Getenv(webhookSecretEnv)),
+		Index:    index,
+		Queue:    queue,
+		Branches: updater.BranchTemplate(*branchTemplate),
+		Merged: func(repository string, number int) {
+			runner.events.Publish(ctx, updater.Event{Type: updater.EventPRMerged, Repository: repository, PullRequest: number})
+		},
+	})
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, "ok, %d jobs pending\n", queue.Len())
+	})
+
+	srv := &http.Server{
+		Addr:              *serveAddr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	log.Printf("Listening for webhooks on %s/webhook", *serveAddr)
+	if err := webhookServe(ctx, srv); err != nil {
+		return fmt.Errorf(common.ErrWebhookServe, err)
+	}
+	return nil
+}
+
+// runWebhookJob refreshes or updates one watched repository. Only the
+// released actions of the job are checked.
+func runWebhookJob(ctx context.Context, runner *repoRunner, client *github.Client, index *webhook.Index, job webhook.Job) error {
+	if job.Reindex {
+		if err := indexRepository(ctx, client, index, job.Repository); err != nil {
+			return err
+		}
+	}
+	if len(job.Actions) == 0 {
+		return nil
+	}
+
+	repoOwner, repoName, err := updater.SplitRepositoryName(job.Repository)
+	if err != nil {
+		return err
+	}
+	targeted := *runner
+	targeted.only = make(map[string]bool, len(job.Actions))
+	for _, action := range job.Actions {
+		targeted.only[action] = true
+	}
+
+	log.Printf("Updating %s for new releases of %v", job.Repository, job.Actions)
+	result := processRemoteRepository(ctx, &targeted, client, repoOwner, repoName)
+	if result.Error != "" {
+		return errors.New(result.Error)
+	}
+	return nil
+}
+
+// indexRepository records the remote actions used by a repository's workflows
+func indexRepository(ctx context.Context, client *github.Client, index *webhook.Index, name string) error {
+	repoOwner, repoName, err := updater.SplitRepositoryName(name)
+	if err != nil {
+		return err
+	}
+
+	dir, err := os.MkdirTemp("", "ghactions-updater-")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	if _, err := updater.FetchWorkflowsAt(ctx, client, repoOwner, repoName, *baseBranch, *workflowsPath, dir); err != nil {
+		return err
+	}
+
+	// The filter was checked by validateFlags
+	paths, _ := updater.ParsePathFilter(*includePaths, *excludePaths)
+	scanner := updater.NewScanner(dir)
+	scanner.SetPathFilter(paths)
+	scanner.SetMaxDepth(*maxDepth)
+	files, err := scanner.ScanWorkflows(filepath.Join(dir, *workflowsPath))
+	if err != nil {
+		return err
+	}
+	var refs []updater.ActionReference
+	for _, file := range files {
+		fileRefs, err := scanner.ParseActionReferences(file)
+		if err != nil {
+			log.Printf(common.ErrFailedToParseWorkflow, file, err)
+			continue
+		}
+		refs = append(refs, fileRefs...)
+	}
+	index.Set(name, refs)
+	return nil
+}
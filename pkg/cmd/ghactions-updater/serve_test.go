@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/updater"
+)
+
+// notifyingPRCreator sends the updates of each CreatePR call on a channel
+type notifyingPRCreator struct {
+	created chan []*updater.Update
+}
+
+func (n *notifyingPRCreator) CreatePR(ctx context.Context, updates []*updater.Update) error {
+	n.created <- updates
+	return nil
+}
+
+func TestServeWebhooksUpdatesUsersOfReleasedAction(t *testing.T) {
+	creator := &notifyingPRCreator{created: make(chan []*updater.Update, 4)}
+	checker := &mockVersionChecker{latestVersion: "v5", latestHash: "1234567890123456789012345678901234567890"}
+	setupRunEnv(t, nil, checker, creator)
+	t.Setenv(webhookSecretEnv, "s3cret")
+
+	workflows := map[string]string{
+		"acme/web": "steps:\n  - uses: actions/checkout@v3\n  - uses: actions/setup-node@v3\n",
+		"acme/api": "steps:\n  - uses: actions/setup-node@v3\n",
+	}
+	mux := http.NewServeMux()
+	for name, workflow := range workflows {
+		content := base64.StdEncoding.EncodeToString([]byte(workflow))
+		base := "/repos/" + name + "/contents/.github/workflows"
+		mux.HandleFunc(base, func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `[{"type":"file","name":"ci.yml"}]`)
+		})
+		mux.HandleFunc(base+"/ci.yml", func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `{"type":"file","name":"ci.yml","encoding":"base64","content":%q}`, content)
+		})
+	}
+	useGitHubServer(t, mux)
+
+	listFile := filepath.Join(t.TempDir(), "repos.txt")
+	if err := os.WriteFile(listFile, []byte("acme/web\nacme/api\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	*reposFile = listFile
+	*serveAddr = "127.0.0.1:0"
+
+	var updates []*updater.Update
+	oldServe := webhookServe
+	t.Cleanup(func() { webhookServe = oldServe })
+	webhookServe = func(ctx context.Context, srv *http.Server) error {
+		server := httptest.NewServer(srv.Handler)
+		defer server.Close()
+
+		body := `{"action":"published","release":{"tag_name":"v5"},"repository":{"full_name":"actions/checkout"}}`
+		mac := hmac.New(sha256.New, []byte("s3cret"))
+		mac.Write([]byte(body))
+		req, _ := http.NewRequest(http.MethodPost, server.URL+"/webhook", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-GitHub-Event", "release")
+		req.Header.Set("X-Hub-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode != http.StatusAccepted {
+			return fmt.Errorf("webhook status = %d", resp.StatusCode)
+		}
+
+		select {
+		case updates = <-creator.created:
+		case <-time.After(5 * time.Second):
+			return fmt.Errorf("no pull request created")
+		}
+		return nil
+	}
+
+	if err := validateFlags(); err != nil {
+		t.Fatalf("validateFlags() error = %v", err)
+	}
+	if err := run(); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+
+	// Only acme/web uses actions/checkout, and only that action is updated
+	if len(updates) != 1 || updates[0].Action.Name != "checkout" {
+		t.Fatalf("updates = %+v, want only actions/checkout", updates)
+	}
+	select {
+	case extra := <-creator.created:
+		t.Errorf("unexpected pull request with %d updates", len(extra))
+	default:
+	}
+}
+
+func TestValidateServeFlags(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func()
+	}{
+		{name: "without repository list", setup: func() { *serveAddr = ":8080" }},
+		{name: "without secret", setup: func() { *serveAddr = ":8080"; *org = "acme"; os.Unsetenv(webhookSecretEnv) }},
+		{name: "interactive", setup: func() { *serveAddr = ":8080"; *org = "acme"; *interactive = true }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupRunEnv(t, nil, &mockVersionChecker{}, &recordingPRCreator{})
+			t.Setenv(webhookSecretEnv, "s3cret")
+			tt.setup()
+			if err := validateFlags(); err == nil {
+				t.Error("validateFlags() expected error, got nil")
+			}
+		})
+	}
+}
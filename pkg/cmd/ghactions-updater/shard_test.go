@@ -0,0 +1,182 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/report"
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/shard"
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/updater"
+)
+
+// serveWorkflows registers a single ci.yml workflow for each repository
+func serveWorkflows(mux *http.ServeMux, names []string) {
+	workflow := base64.StdEncoding.EncodeToString([]byte(`on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v3
+`))
+	for _, name := range names {
+		base := "/repos/" + name + "/contents/.github/workflows"
+		mux.HandleFunc(base, func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `[{"type":"file","name":"ci.yml"}]`)
+		})
+		mux.HandleFunc(base+"/ci.yml", func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `{"type":"file","name":"ci.yml","encoding":"base64","content":%q}`, workflow)
+		})
+	}
+}
+
+func TestRunShardedRepositories(t *testing.T) {
+	var names []string
+	for i := 0; i < 8; i++ {
+		names = append(names, fmt.Sprintf("acme/repo-%d", i))
+	}
+
+	checker := &mockVersionChecker{latestVersion: "v4", latestHash: "1234567890123456789012345678901234567890"}
+	setupRunEnv(t, nil, checker, &recordingPRCreator{})
+	mux := http.NewServeMux()
+	serveWorkflows(mux, names)
+	useGitHubServer(t, mux)
+
+	dir := t.TempDir()
+	listFile := filepath.Join(dir, "repos.txt")
+	if err := os.WriteFile(listFile, []byte(strings.Join(names, "\n")), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var processed []string
+	for index := 1; index <= 2; index++ {
+		*reposFile = listFile
+		*shardSpec = fmt.Sprintf("%d/2", index)
+		*reportPath = filepath.Join(dir, fmt.Sprintf("report-%d.json", index))
+		*dryRun = true
+
+		if err := validateFlags(); err != nil {
+			t.Fatalf("validateFlags() error = %v", err)
+		}
+		if err := run(); err != nil {
+			t.Fatalf("run() error = %v", err)
+		}
+
+		rep, err := report.Read(*reportPath)
+		if err != nil {
+			t.Fatalf("report.Read() error = %v", err)
+		}
+		if rep.Shard != *shardSpec {
+			t.Errorf("report shard = %q, want %q", rep.Shard, *shardSpec)
+		}
+		want := shard.Shard{Index: index, Count: 2}.Filter(names)
+		if len(rep.Repositories) != len(want) {
+			t.Fatalf("shard %d processed %d repositories, want %d", index, len(rep.Repositories), len(want))
+		}
+		for _, result := range rep.Repositories {
+			if result.Error != "" || len(result.Updates) != 1 {
+				t.Errorf("unexpected result for %s/%s: %+v", result.Owner, result.Repo, result)
+			}
+			processed = append(processed, result.Owner+"/"+result.Repo)
+		}
+	}
+
+	sort.Strings(processed)
+	sort.Strings(names)
+	if strings.Join(processed, ",") != strings.Join(names, ",") {
+		t.Errorf("shards processed %v, want each of %v exactly once", processed, names)
+	}
+}
+
+func TestRunCheckpoint(t *testing.T) {
+	names := []string{"acme/repo-0", "acme/repo-1", "acme/repo-2", "acme/repo-3"}
+	setupRunEnv(t, nil, &mockVersionChecker{latestVersion: "v4", latestHash: "1234567890123456789012345678901234567890"}, &recordingPRCreator{})
+	mux := http.NewServeMux()
+	serveWorkflows(mux, names)
+	useGitHubServer(t, mux)
+
+	dir := t.TempDir()
+	listFile := filepath.Join(dir, "repos.txt")
+	runList := func(names ...string) *report.Report {
+		t.Helper()
+		if err := os.WriteFile(listFile, []byte(strings.Join(names, "\n")), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := validateFlags(); err != nil {
+			t.Fatalf("validateFlags() error = %v", err)
+		}
+		if err := run(); err != nil {
+			t.Fatalf("run() error = %v", err)
+		}
+		rep, err := report.Read(*reportPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return rep
+	}
+	*reposFile, *dryRun = listFile, true
+	*reportPath = filepath.Join(dir, "report.json")
+	*checkpointPath = filepath.Join(dir, "checkpoint.json")
+
+	// acme/missing fails, so the checkpoint is kept for a resumed run
+	runList("acme/repo-0", "acme/repo-1", "acme/missing")
+	checkpoint, err := report.LoadCheckpoint(*checkpointPath, "", updater.ModeDryRun)
+	if err != nil {
+		t.Fatalf("LoadCheckpoint() error = %v", err)
+	}
+	if len(checkpoint.Repositories) != 2 || len(checkpoint.Actions.Actions) != 1 {
+		t.Fatalf("checkpoint has %d repositories and %d actions, want 2 and 1", len(checkpoint.Repositories), len(checkpoint.Actions.Actions))
+	}
+
+	// The resumed run reports the completed repositories without processing
+	// them again and answers recorded lookups from the checkpoint
+	versionCheckerFactory = func(string) updater.VersionChecker {
+		return &mockVersionChecker{err: fmt.Errorf("rate limit exceeded")}
+	}
+	rep := runList(names...)
+	if len(rep.Repositories) != 4 || rep.UpdateCount() != 4 || rep.FailureCount() != 0 {
+		t.Errorf("resumed run: %d repositories, %d updates, %d failures; want 4, 4, 0", len(rep.Repositories), rep.UpdateCount(), rep.FailureCount())
+	}
+	// Every repository completed, so the next run starts over
+	if _, err := os.Stat(*checkpointPath); !os.IsNotExist(err) {
+		t.Errorf("checkpoint not removed after a complete run: %v", err)
+	}
+
+	if err := checkpoint.Save(); err != nil {
+		t.Fatal(err)
+	}
+	*dryRun = false
+	if err := validateFlags(); err != nil {
+		t.Fatal(err)
+	}
+	if err := run(); err == nil || !strings.Contains(err.Error(), "different run") {
+		t.Errorf("run() with a dry-run checkpoint error = %v, want a mismatch", err)
+	}
+}
+
+func TestValidateMultiRepoFlags(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func()
+	}{
+		{name: "org and repos-file", setup: func() { *org = "acme"; *reposFile = "repos.txt" }},
+		{name: "shard without repository list", setup: func() { *shardSpec = "1/2" }},
+		{name: "invalid shard", setup: func() { *org = "acme"; *shardSpec = "3/2" }},
+		{name: "stage with org", setup: func() { *org = "acme"; *stage = true }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupRunEnv(t, nil, &mockVersionChecker{}, &recordingPRCreator{})
+			tt.setup()
+			if err := validateFlags(); err == nil {
+				t.Error("validateFlags() expected error, got nil")
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/storage"
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/updater"
+)
+
+// For testing
+var snoozeNow = time.Now
+
+// snoozeFlags are the flags of the snooze and unsnooze subcommands
+type snoozeFlags struct {
+	store string
+	owner string
+	repo  string
+	days  int
+	list  bool
+}
+
+// register declares the flags on fs
+func (o *snoozeFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.store, "store", "", "Cache and run-state store holding the snoozes")
+	fs.StringVar(&o.owner, "owner", "", "Repository owner")
+	fs.StringVar(&o.repo, "repo-name", "", "Repository name")
+	fs.IntVar(&o.days, "days", 7, "Number of days to snooze the update")
+	fs.BoolVar(&o.list, "list", false, "List the active snoozes of the repository")
+}
+
+// runSnoozeCommand implements the "snooze" and "unsnooze" subcommands:
+//
+//	ghactions-updater snooze -store s -owner o -repo-name r [-days n] owner/action[@version]
+//	ghactions-updater snooze -store s -owner o -repo-name r -list
+//	ghactions-updater unsnooze -store s -owner o -repo-name r owner/action[@version]
+//
+// Snoozes are kept in the state store and skip the update in later runs
+// against the repository until they expire.
+func runSnoozeCommand(name string, args []string, stdout io.Writer) error {
+	var opts snoozeFlags
+	fs := newFlagSet(name, stdout, opts.register)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	for _, required := range []struct{ flag, value string }{
+		{"store", opts.store}, {"owner", opts.owner}, {"repo-name", opts.repo},
+	} {
+		if required.value == "" {
+			return fmt.Errorf(common.ErrMissingRequiredFlag, required.flag)
+		}
+	}
+	if opts.days <= 0 {
+		return fmt.Errorf(common.ErrInvalidFlagValue, "days", "must be positive")
+	}
+	if !opts.list && fs.NArg() != 1 {
+		return fmt.Errorf(common.ErrInvalidFlagValue, name, "expected one owner/action[@version] argument")
+	}
+
+	store, err := storage.Open(opts.store)
+	if err != nil {
+		return fmt.Errorf(common.ErrOpeningStore, err)
+	}
+	ctx := context.Background()
+	now := snoozeNow()
+	snoozes, err := updater.LoadSnoozes(ctx, store, opts.owner, opts.repo)
+	if err != nil {
+		return err
+	}
+
+	if opts.list {
+		for _, snooze := range snoozes {
+			if !snooze.Until.After(now) {
+				continue
+			}
+			target := snooze.Action
+			if snooze.Version != "" {
+				target += "@" + snooze.Version
+			}
+			_, _ = fmt.Fprintf(stdout, "%s snoozed until %s\n", target, snooze.Until.Format(time.RFC3339))
+		}
+		return nil
+	}
+
+	action, version, err := updater.ParseSnoozeTarget(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if name == "unsnooze" {
+		snoozes = snoozes.Remove(action, version)
+		_, _ = fmt.Fprintf(stdout, "Unsnoozed %s in %s/%s\n", fs.Arg(0), opts.owner, opts.repo)
+	} else {
+		until := now.Add(time.Duration(opts.days) * 24 * time.Hour)
+		snoozes = snoozes.Add(action, version, until)
+		_, _ = fmt.Fprintf(stdout, "Snoozed %s in %s/%s until %s\n", fs.Arg(0), opts.owner, opts.repo, until.Format(time.RFC3339))
+	}
+	return updater.SaveSnoozes(ctx, store, opts.owner, opts.repo, snoozes, now)
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunSnoozeCommand(t *testing.T) {
+	store := t.TempDir()
+	oldNow := snoozeNow
+	defer func() { snoozeNow = oldNow }()
+	snoozeNow = func() time.Time { return time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC) }
+
+	base := []string{"-store", store, "-owner", "test-owner", "-repo-name", "test-repo"}
+	run := func(name string, args ...string) string {
+		t.Helper()
+		var out bytes.Buffer
+		if err := runSnoozeCommand(name, append(append([]string{}, base...), args...), &out); err != nil {
+			t.Fatalf("%s %v error = %v", name, args, err)
+		}
+		return out.String()
+	}
+
+	if out := run("snooze", "-days", "3", "actions/checkout@v4"); !strings.Contains(out, "until 2026-05-04T00:00:00Z") {
+		t.Errorf("snooze output = %q", out)
+	}
+	run("snooze", "octo/tool")
+	if out := run("snooze", "-list"); !strings.Contains(out, "actions/checkout@v4 snoozed") || !strings.Contains(out, "octo/tool snoozed until 2026-05-08") {
+		t.Errorf("list output = %q", out)
+	}
+
+	run("unsnooze", "actions/checkout")
+	if out := run("snooze", "-list"); strings.Contains(out, "actions/checkout") || !strings.Contains(out, "octo/tool") {
+		t.Errorf("list output after unsnooze = %q", out)
+	}
+
+	// Snoozes expire
+	snoozeNow = func() time.Time { return time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC) }
+	if out := run("snooze", "-list"); out != "" {
+		t.Errorf("list output after expiry = %q", out)
+	}
+
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{name: "missing store", args: []string{"-owner", "o", "-repo-name", "r", "a/b"}, wantErr: "store"},
+		{name: "missing target", args: base, wantErr: "expected one"},
+		{name: "invalid target", args: append(append([]string{}, base...), "checkout"), wantErr: "invalid snooze target"},
+		{name: "invalid days", args: append(append([]string{}, base...), "-days", "0", "a/b"), wantErr: "days"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := runSnoozeCommand("snooze", tt.args, &out)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("runSnoozeCommand() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRunSkipsSnoozedUpdates(t *testing.T) {
+	workflow := "on: push\njobs:\n  build:\n    runs-on: ubuntu-latest\n    steps:\n      - uses: actions/checkout@v3\n      - uses: actions/setup-go@v4\n"
+	creator := &recordingPRCreator{}
+	setupRunEnv(t, map[string]string{".github/workflows/ci.yml": workflow}, &mockVersionChecker{latestVersion: "v5", latestHash: "abc123"}, creator)
+	*storeLocation = t.TempDir()
+
+	var out bytes.Buffer
+	args := []string{"-store", *storeLocation, "-owner", "test-owner", "-repo-name", "test-repo", "actions/checkout"}
+	if err := runSnoozeCommand("snooze", args, &out); err != nil {
+		t.Fatalf("runSnoozeCommand() error = %v", err)
+	}
+
+	if err := run(); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	if len(creator.updates) != 1 || creator.updates[0].Action.Name != "setup-go" {
+		t.Errorf("updates = %+v, want only setup-go", creator.updates)
+	}
+}
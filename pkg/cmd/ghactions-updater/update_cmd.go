@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/updater"
+)
+
+// runUpdateCommand implements the "update" and "pr" subcommands and the
+// invocation without a subcommand, which all share the global flags:
+//
+//	ghactions-updater update [flags]  # apply updates to the checkout; -dry-run only shows them
+//	ghactions-updater pr [flags]      # apply updates in a pull request
+//	ghactions-updater [flags]         # -dry-run, -stage or a pull request
+func runUpdateCommand(name string, args []string, stdout io.Writer) error {
+	if err := parseFlags(flag.CommandLine, args); err != nil {
+		return err
+	}
+	if err := applyConfigFiles(flag.CommandLine, os.Stderr); err != nil {
+		return err
+	}
+	// -version only describes the binary
+	if *version != "" {
+		return printVersion(context.Background(), stdout, string(*version), *checkSelfUpdate, selfUpdateToken())
+	}
+
+	switch name {
+	case "update":
+		if !*dryRun && *outputPatch == "" {
+			*stage = true
+		}
+	case "pr":
+		if *dryRun || *stage {
+			return &common.ValidationError{Field: "dry-run/stage", Err: fmt.Errorf(common.ErrInvalidFlagValue, "dry-run/stage", "not supported by pr; use update")}
+		}
+	}
+
+	if err := validateFlags(); err != nil {
+		return &common.ValidationError{Err: err}
+	}
+	return run()
+}
+
+// scanResultKey identifies a reference by its location and as written, so
+// a reference changed after the scan no longer matches
+func scanResultKey(entry scanEntry) string {
+	return fmt.Sprintf("%s:%d:%s@%s", entry.Path, entry.Line, entry.Action, entry.Ref)
+}
+
+// writeScanResults writes the references found by the scan subcommand
+func writeScanResults(path string, entries []scanEntry) error {
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf(common.ErrWritingScanResults, path, err)
+	}
+	options := common.DefaultFileOptions()
+	options.Mode = 0644
+	if err := common.WriteFileWithOptions(path, append(data, '\n'), options); err != nil {
+		return fmt.Errorf(common.ErrWritingScanResults, path, err)
+	}
+	return nil
+}
+
+// readScanResults loads the references written by "scan -out", by
+// scanResultKey
+func readScanResults(path string) (map[string]scanEntry, error) {
+	// #nosec G304 - path is provided by the user running the tool
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf(common.ErrReadingScanResults, path, err)
+	}
+	var entries []scanEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf(common.ErrReadingScanResults, path, err)
+	}
+	scanned := make(map[string]scanEntry, len(entries))
+	for _, entry := range entries {
+		scanned[scanResultKey(entry)] = entry
+	}
+	return scanned, nil
+}
+
+// selectScanned keeps the updates of references listed in the scan results.
+// When the scan recorded a latest version, only an update to that version
+// is kept, so nothing newer than what was reviewed is applied.
+func selectScanned(absPath string, scanned map[string]scanEntry, updates []*updater.Update) []*updater.Update {
+	var selected []*updater.Update
+	for _, update := range updates {
+		entry, ok := scanned[scanResultKey(newScanEntry(absPath, update.FilePath, update.Action))]
+		if !ok {
+			continue
+		}
+		if entry.Latest != "" && entry.Latest != update.NewVersion {
+			log.Printf("Skipping %s: the latest version changed from %s to %s since the scan", update.Action.FullName(), entry.Latest, update.NewVersion)
+			continue
+		}
+		selected = append(selected, update)
+	}
+	return selected
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunScanResults(t *testing.T) {
+	const newHash = "1234567890123456789012345678901234567890"
+	workflow := "on: push\njobs:\n  build:\n    runs-on: ubuntu-latest\n    steps:\n" +
+		"      - uses: actions/checkout@v3\n" +
+		"      - uses: actions/cache@v3\n" +
+		"      - uses: actions/setup-go@v3\n"
+	checker := &mockVersionChecker{latestVersion: "v4.2.1", latestHash: newHash}
+	dir := setupRunEnv(t, map[string]string{".github/workflows/ci.yml": workflow}, checker, &recordingPRCreator{})
+
+	results := filepath.Join(t.TempDir(), "refs.json")
+	var out bytes.Buffer
+	if err := runScanCommand([]string{"-repo", dir, "-out", results}, &out); err != nil {
+		t.Fatalf("runScanCommand() error = %v", err)
+	}
+
+	// Review: drop actions/cache and expect an older setup-go than the latest
+	data, err := os.ReadFile(results)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entries []scanEntry
+	if err := json.Unmarshal(data, &entries); err != nil || len(entries) != 3 {
+		t.Fatalf("scan results = %s, %v", data, err)
+	}
+	entries[2].Latest = "v4.0.0"
+	reviewed := []scanEntry{entries[0], entries[2]}
+	if err := writeScanResults(results, reviewed); err != nil {
+		t.Fatal(err)
+	}
+
+	*scanResults, *stage = results, true
+	if err := validateFlags(); err != nil {
+		t.Fatalf("validateFlags() error = %v", err)
+	}
+	if err := run(); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	content := readRepoFile(t, dir, ".github/workflows/ci.yml")
+	for _, want := range []string{"actions/checkout@" + newHash, "actions/cache@v3\n", "actions/setup-go@v3\n"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("updated workflow = %q, want %q", content, want)
+		}
+	}
+
+	*scanResults = filepath.Join(t.TempDir(), "missing.json")
+	if err := run(); err == nil || !strings.Contains(err.Error(), "error reading scan results") {
+		t.Errorf("run() with missing scan results error = %v", err)
+	}
+}
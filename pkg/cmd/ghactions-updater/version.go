@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"regexp"
+	"runtime"
+	"runtime/debug"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/versions"
+)
+
+// Version information, set at build time with
+// -ldflags "-X main.Version=v1.2.3 -X main.Commit=abc1234 -X main.BuildDate=2025-01-02T15:04:05Z"
+var (
+	Version   = "development"
+	Commit    = "unknown"
+	BuildDate = "unknown"
+)
+
+// The repository whose releases -check-self-update compares against
+const (
+	selfOwner = "ThreatFlux"
+	selfRepo  = "githubWorkFlowChecker"
+)
+
+// pseudoVersion matches the timestamp and revision of Go pseudo-versions
+// such as v0.0.0-20250102150405-abcdef123456, given to untagged builds
+var pseudoVersion = regexp.MustCompile(`[-.]\d{14}-[0-9a-f]{12}`)
+
+// describeSuffix matches what git describe appends to the tag of a build of
+// a later commit, as in v1.2.3-4-gabc1234-dirty
+var describeSuffix = regexp.MustCompile(`-\d+-g[0-9a-f]+(-dirty)?$`)
+
+// Output formats of -version
+const (
+	versionText = "text"
+	versionJSON = "json"
+)
+
+// versionFlag is -version: given alone it prints text, -version=json prints
+// JSON
+type versionFlag string
+
+// versionFlagVar registers -version on fs
+func versionFlagVar(fs *flag.FlagSet) *versionFlag {
+	v := new(versionFlag)
+	fs.Var(v, "version", "Print version information and exit; -version=json prints it as JSON")
+	return v
+}
+
+func (v *versionFlag) String() string {
+	if v == nil {
+		return ""
+	}
+	return string(*v)
+}
+
+func (v *versionFlag) Set(value string) error {
+	switch value {
+	case "true", versionText:
+		*v = versionText
+	case versionJSON:
+		*v = versionJSON
+	case "false":
+		*v = ""
+	default:
+		return fmt.Errorf("must be %s or %s", versionText, versionJSON)
+	}
+	return nil
+}
+
+// IsBoolFlag lets -version be given without a value
+func (v *versionFlag) IsBoolFlag() bool {
+	return true
+}
+
+// versionInfo describes the running binary
+type versionInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildDate string `json:"build_date"`
+	GoVersion string `json:"go_version"`
+	Platform  string `json:"platform"`
+	// Latest is the latest release, with -check-self-update
+	Latest          string `json:"latest,omitempty"`
+	UpdateAvailable bool   `json:"update_available,omitempty"`
+	CheckError      string `json:"check_error,omitempty"`
+}
+
+// currentVersion returns the version information of the binary. Builds
+// without -ldflags, such as go install, take it from the module build info.
+func currentVersion() versionInfo {
+	info := versionInfo{
+		Version:   Version,
+		Commit:    Commit,
+		BuildDate: BuildDate,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+	build, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	if info.Version == "development" && build.Main.Version != "" && build.Main.Version != "(devel)" {
+		info.Version = build.Main.Version
+	}
+	for _, setting := range build.Settings {
+		switch {
+		case setting.Key == "vcs.revision" && info.Commit == "unknown":
+			info.Commit = setting.Value
+		case setting.Key == "vcs.time" && info.BuildDate == "unknown":
+			info.BuildDate = setting.Value
+		}
+	}
+	return info
+}
+
+// latestRelease returns the latest release of the tool and whether it is
+// newer than the running version. Development and untagged builds are never
+// outdated.
+func latestRelease(ctx context.Context, token string) (string, bool, error) {
+	release, _, err := githubClientFactory(token).Repositories.GetLatestRelease(ctx, selfOwner, selfRepo)
+	if err != nil {
+		return "", false, fmt.Errorf(common.ErrCheckingSelfUpdate, err)
+	}
+	latest := release.GetTagName()
+	running := describeSuffix.ReplaceAllString(currentVersion().Version, "")
+	released := versions.IsVersion(running) && !pseudoVersion.MatchString(running)
+	return latest, released && versions.IsNewer(latest, running), nil
+}
+
+// printVersion writes the version information in format, including the
+// latest release when check is set. A failed check is reported in the
+// output rather than as an error.
+func printVersion(ctx context.Context, out io.Writer, format string, check bool, token string) error {
+	info := currentVersion()
+	if check {
+		var err error
+		if info.Latest, info.UpdateAvailable, err = latestRelease(ctx, token); err != nil {
+			info.CheckError = err.Error()
+		}
+	}
+
+	if format == versionJSON {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(info)
+	}
+	_, _ = fmt.Fprintf(out, "Version: %s\nCommit: %s\nBuilt: %s\nGo: %s %s\n", info.Version, info.Commit, info.BuildDate, info.GoVersion, info.Platform)
+	switch {
+	case info.CheckError != "":
+		_, _ = fmt.Fprintf(out, "Latest: unknown (%s)\n", info.CheckError)
+	case info.UpdateAvailable:
+		_, _ = fmt.Fprintf(out, "Latest: %s, an update is available\n", info.Latest)
+	case check:
+		_, _ = fmt.Fprintf(out, "Latest: %s\n", info.Latest)
+	}
+	return nil
+}
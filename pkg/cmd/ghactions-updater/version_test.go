@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestVersionFlag(t *testing.T) {
+	tests := []struct {
+		args    []string
+		want    string
+		wantErr bool
+	}{
+		{args: nil, want: ""},
+		{args: []string{"-version"}, want: versionText},
+		{args: []string{"-version=json"}, want: versionJSON},
+		{args: []string{"-version=false"}, want: ""},
+		{args: []string{"-version=yaml"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			fs.SetOutput(&bytes.Buffer{})
+			v := versionFlagVar(fs)
+			err := fs.Parse(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && v.String() != tt.want {
+				t.Errorf("-version = %q, want %q", v.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestPrintVersion(t *testing.T) {
+	setupRunEnv(t, nil, &mockVersionChecker{}, &recordingPRCreator{})
+	oldVersion := Version
+	t.Cleanup(func() { Version = oldVersion })
+
+	latest := "v2.1.0"
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/ThreatFlux/githubWorkFlowChecker/releases/latest", func(w http.ResponseWriter, r *http.Request) {
+		if latest == "" {
+			http.Error(w, `{"message": "Not Found"}`, http.StatusNotFound)
+			return
+		}
+		_, _ = fmt.Fprintf(w, `{"tag_name": %q}`, latest)
+	})
+	useGitHubServer(t, mux)
+
+	tests := []struct {
+		name    string
+		running string
+		latest  string
+		format  string
+		check   bool
+		want    []string
+	}{
+		{name: "text", running: "v2.0.0", format: versionText, want: []string{"Version: v2.0.0\n", "Go: go"}},
+		{name: "outdated", running: "v2.0.0", latest: "v2.1.0", format: versionText, check: true, want: []string{"Latest: v2.1.0, an update is available"}},
+		{name: "current", running: "v2.1.0", latest: "v2.1.0", format: versionText, check: true, want: []string{"Latest: v2.1.0\n"}},
+		{name: "development build", running: "development", latest: "v2.1.0", format: versionText, check: true, want: []string{"Latest: v2.1.0\n"}},
+		{name: "after a tag", running: "v2.1.0-3-gabc1234-dirty", latest: "v2.1.0", format: versionText, check: true, want: []string{"Latest: v2.1.0\n"}},
+		{name: "untagged build", running: "v0.0.0-20250102150405-abcdef123456+dirty", latest: "v2.1.0", format: versionText, check: true, want: []string{"Latest: v2.1.0\n"}},
+		{name: "failed check", running: "v2.0.0", format: versionText, check: true, want: []string{"Latest: unknown (error checking for a newer release"}},
+		{name: "json", running: "v2.0.0", latest: "v2.1.0", format: versionJSON, check: true, want: []string{`"version": "v2.0.0"`, `"latest": "v2.1.0"`, `"update_available": true`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Version, latest = tt.running, tt.latest
+			var out bytes.Buffer
+			if err := printVersion(context.Background(), &out, tt.format, tt.check, ""); err != nil {
+				t.Fatalf("printVersion() error = %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("printVersion() = %q, want it to contain %q", out.String(), want)
+				}
+			}
+			if tt.format == versionJSON {
+				var info versionInfo
+				if err := json.Unmarshal(out.Bytes(), &info); err != nil || info.Platform == "" {
+					t.Errorf("printVersion() JSON = %q, %v", out.String(), err)
+				}
+			}
+		})
+	}
+
+	// -version describes the binary instead of running
+	Version = "v2.0.0"
+	var out bytes.Buffer
+	if err := runUpdateCommand("", []string{"-version"}, &out); err != nil || !strings.HasPrefix(out.String(), "Version: v2.0.0\n") {
+		t.Errorf("runUpdateCommand(-version) = %q, %v", out.String(), err)
+	}
+}
@@ -0,0 +1,169 @@
+package common
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// APIAuditEntry is one line of the API audit log: a request that changed
+// something through the API
+type APIAuditEntry struct {
+	Time       time.Time `json:"time"`
+	Operation  string    `json:"operation"`            // e.g. create-branch, create-commit, create-pull-request
+	Repository string    `json:"repository,omitempty"` // owner/repo the request targeted
+	Method     string    `json:"method"`
+	Path       string    `json:"path"`
+	Status     int       `json:"status,omitempty"`
+	RequestID  string    `json:"request_id,omitempty"` // X-GitHub-Request-Id, for GitHub support
+	DurationMS int64     `json:"duration_ms"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// AuditTransport is an http.RoundTripper appending every mutating API
+// request (any method but GET, HEAD and OPTIONS) to a JSON lines file.
+// Retried requests are logged once per attempt. Failing to write the log
+// does not fail the request.
+type AuditTransport struct {
+	// Base is the underlying transport (http.DefaultTransport if nil)
+	Base http.RoundTripper
+	// Path is the file entries are appended to
+	Path string
+
+	mu  sync.Mutex
+	now func() time.Time
+}
+
+// NewAuditTransport creates an AuditTransport around base logging to path
+func NewAuditTransport(base http.RoundTripper, path string) *AuditTransport {
+	return &AuditTransport{Base: base, Path: path, now: time.Now}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *AuditTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return base.RoundTrip(req)
+	}
+
+	start := t.now()
+	resp, err := base.RoundTrip(req)
+	entry := APIAuditEntry{
+		Time:       start.UTC(),
+		Operation:  auditOperation(req.Method, req.URL.Path),
+		Repository: auditRepository(req.URL.Path),
+		Method:     req.Method,
+		Path:       req.URL.Path,
+		DurationMS: t.now().Sub(start).Milliseconds(),
+	}
+	if resp != nil {
+		entry.Status = resp.StatusCode
+		entry.RequestID = resp.Header.Get("X-GitHub-Request-Id")
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	if writeErr := t.write(entry); writeErr != nil {
+		log.Printf(ErrWritingAPIAudit, t.Path, writeErr)
+	}
+	return resp, err
+}
+
+// write appends entry to the log
+func (t *AuditTransport) write(entry APIAuditEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	file, err := os.OpenFile(t.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600) // #nosec G304 - path is configured by the user
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// repoPathParts returns the segments of path after /repos/{owner}/{repo},
+// also below the /api/v3 and /api/v1 prefixes of GitHub Enterprise and Gitea
+func repoPathParts(path string) (owner, repo string, rest []string, ok bool) {
+	i := strings.Index(path, "/repos/")
+	if i < 0 {
+		return "", "", nil, false
+	}
+	parts := strings.Split(strings.Trim(path[i+len("/repos/"):], "/"), "/")
+	if len(parts) < 2 {
+		return "", "", nil, false
+	}
+	return parts[0], parts[1], parts[2:], true
+}
+
+// auditRepository returns the owner/repo of a repository API path
+func auditRepository(path string) string {
+	owner, repo, _, ok := repoPathParts(path)
+	if !ok {
+		return ""
+	}
+	return owner + "/" + repo
+}
+
+// auditOperation names the change a request makes, or the method and path
+// for requests this tool does not normally make
+func auditOperation(method, path string) string {
+	if strings.HasSuffix(path, "/graphql") {
+		return "graphql-mutation"
+	}
+	_, _, rest, ok := repoPathParts(path)
+	if !ok || len(rest) == 0 {
+		return strings.ToLower(method) + " " + path
+	}
+
+	resource := strings.Join(rest, "/")
+	switch {
+	case method == http.MethodPost && resource == "git/refs":
+		return "create-branch"
+	case method == http.MethodPatch && strings.HasPrefix(resource, "git/refs/"):
+		return "update-ref"
+	case method == http.MethodDelete && strings.HasPrefix(resource, "git/refs/"):
+		return "delete-branch"
+	case method == http.MethodPost && resource == "git/blobs":
+		return "create-blob"
+	case method == http.MethodPost && resource == "git/trees":
+		return "create-tree"
+	case method == http.MethodPost && resource == "git/commits":
+		return "create-commit"
+	case method == http.MethodPost && resource == "pulls":
+		return "create-pull-request"
+	case method == http.MethodPatch && rest[0] == "pulls":
+		return "update-pull-request"
+	case method == http.MethodPost && rest[0] == "issues" && rest[len(rest)-1] == "labels":
+		return "add-labels"
+	case method == http.MethodPost && rest[0] == "issues" && rest[len(rest)-1] == "comments":
+		return "create-comment"
+	case method == http.MethodPost && rest[0] == "statuses":
+		return "create-status"
+	case method == http.MethodPost && resource == "check-runs":
+		return "create-check-run"
+	case method == http.MethodPost && resource == "forks":
+		return "create-fork"
+	case rest[0] == "contents":
+		return "update-file"
+	case method == http.MethodDelete && rest[0] == "branches":
+		return "delete-branch"
+	default:
+		return strings.ToLower(method) + " " + resource
+	}
+}
@@ -0,0 +1,96 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v72/github"
+)
+
+func TestAuditOperation(t *testing.T) {
+	tests := []struct {
+		method string
+		path   string
+		want   string
+	}{
+		{http.MethodPost, "/repos/o/r/git/refs", "create-branch"},
+		{http.MethodPatch, "/repos/o/r/git/refs/heads/main", "update-ref"},
+		{http.MethodDelete, "/api/v3/repos/o/r/git/refs/heads/action-updates-1", "delete-branch"},
+		{http.MethodPost, "/repos/o/r/git/blobs", "create-blob"},
+		{http.MethodPost, "/repos/o/r/git/trees", "create-tree"},
+		{http.MethodPost, "/repos/o/r/git/commits", "create-commit"},
+		{http.MethodPost, "/repos/o/r/pulls", "create-pull-request"},
+		{http.MethodPost, "/repos/o/r/issues/7/labels", "add-labels"},
+		{http.MethodPost, "/repos/o/r/statuses/abc", "create-status"},
+		{http.MethodPost, "/repos/o/r/forks", "create-fork"},
+		{http.MethodPost, "/api/v1/repos/o/r/contents", "update-file"},
+		{http.MethodPost, "/graphql", "graphql-mutation"},
+		{http.MethodPut, "/repos/o/r/topics", "put topics"},
+		{http.MethodPost, "/user/repos", "post /user/repos"},
+	}
+	for _, tt := range tests {
+		if got := auditOperation(tt.method, tt.path); got != tt.want {
+			t.Errorf("auditOperation(%s, %s) = %q, want %q", tt.method, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestAuditTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-GitHub-Request-Id", "ABCD:1234")
+		switch r.URL.Path {
+		case "/repos/o/r/git/refs":
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"ref":"refs/heads/update"}`))
+		case "/repos/o/r/issues/7/labels":
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"message":"Forbidden"}`))
+		default:
+			_, _ = w.Write([]byte(`{}`))
+		}
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "api-audit.jsonl")
+	client := github.NewClient(&http.Client{Transport: NewAuditTransport(nil, path)})
+	client.BaseURL, _ = client.BaseURL.Parse(server.URL + "/")
+
+	ctx := context.Background()
+	if _, _, err := client.Repositories.Get(ctx, "o", "r"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := client.Git.CreateRef(ctx, "o", "r", &github.Reference{Ref: github.Ptr("refs/heads/update"), Object: &github.GitObject{SHA: github.Ptr("abc")}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := client.Issues.AddLabelsToIssue(ctx, "o", "r", 7, []string{"dependencies"}); err == nil {
+		t.Fatal("AddLabelsToIssue() succeeded, want 403")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("logged %d entries, want 2 (reads are not logged):\n%s", len(lines), data)
+	}
+	var created, labeled APIAuditEntry
+	if err := json.Unmarshal([]byte(lines[0]), &created); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &labeled); err != nil {
+		t.Fatal(err)
+	}
+	if created.Operation != "create-branch" || created.Repository != "o/r" || created.Status != http.StatusCreated || created.RequestID != "ABCD:1234" || created.Time.IsZero() {
+		t.Errorf("create entry = %+v", created)
+	}
+	if labeled.Operation != "add-labels" || labeled.Method != http.MethodPost || labeled.Status != http.StatusForbidden {
+		t.Errorf("labels entry = %+v", labeled)
+	}
+}
@@ -0,0 +1,154 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-github/v72/github"
+)
+
+// AccessErrorKind classifies a 403 or 404 response from the GitHub API
+type AccessErrorKind int
+
+const (
+	// AccessDenied means the repository is known but the token lacks permission
+	AccessDenied AccessErrorKind = iota
+	// RepositoryNotFound means the repository does not exist or is invisible to the token
+	RepositoryNotFound
+	// ResourceNotFound means the repository is visible but the requested resource is missing
+	ResourceNotFound
+)
+
+// AccessError explains a 403 or 404 response and carries the hints GitHub
+// returned with it (required permissions, SSO authorization, documentation)
+type AccessError struct {
+	Owner string
+	Repo  string
+	Kind  AccessErrorKind
+	Hints []string
+	Err   error
+}
+
+// Error implements error
+func (e *AccessError) Error() string {
+	format := ErrAccessDenied
+	switch e.Kind {
+	case RepositoryNotFound:
+		format = ErrRepositoryNotFound
+	case ResourceNotFound:
+		format = ErrResourceNotFound
+	}
+	msg := fmt.Sprintf(format, e.Owner, e.Repo, e.Err)
+	if len(e.Hints) > 0 {
+		msg += "; " + strings.Join(e.Hints, "; ")
+	}
+	return msg
+}
+
+// Unwrap returns the underlying API error
+func (e *AccessError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorCategory returns CategoryAuth when access was denied and
+// CategoryNotFound otherwise
+func (e *AccessError) ErrorCategory() ErrorCategory {
+	if e.Kind == AccessDenied {
+		return CategoryAuth
+	}
+	return CategoryNotFound
+}
+
+// Is matches the sentinel of the error's category, ErrAuth or ErrNotFound
+func (e *AccessError) Is(target error) bool {
+	return (target == ErrAuth && e.Kind == AccessDenied) || (target == ErrNotFound && e.Kind != AccessDenied)
+}
+
+// DiagnoseAccessError turns a 403 or 404 from owner/repo into an AccessError.
+// GitHub answers 404 for private repositories the token cannot see, so a 404
+// is checked against the repository itself to tell a missing resource from a
+// missing (or hidden) repository. Other errors are returned unchanged.
+func DiagnoseAccessError(ctx context.Context, client *github.Client, owner, repo string, err error) error {
+	var accessErr *AccessError
+	if errors.As(err, &accessErr) {
+		return err
+	}
+
+	switch APIErrorStatus(err) {
+	case http.StatusForbidden:
+		if IsRateLimitError(err) {
+			return err
+		}
+		return &AccessError{Owner: owner, Repo: repo, Kind: AccessDenied, Hints: accessHints(err), Err: err}
+	case http.StatusNotFound:
+	default:
+		return err
+	}
+
+	kind := ResourceNotFound
+	if client != nil {
+		_, _, repoErr := client.Repositories.Get(ctx, owner, repo)
+		switch {
+		case APIErrorStatus(repoErr) == http.StatusNotFound:
+			kind = RepositoryNotFound
+		case APIErrorStatus(repoErr) == http.StatusForbidden && !IsRateLimitError(repoErr):
+			return &AccessError{Owner: owner, Repo: repo, Kind: AccessDenied, Hints: accessHints(repoErr), Err: err}
+		case repoErr != nil:
+			// The repository check itself failed; report what is known
+			return err
+		}
+	}
+
+	hints := accessHints(err)
+	if kind == RepositoryNotFound {
+		hints = append([]string{HintPrivateRepository}, hints...)
+	}
+	return &AccessError{Owner: owner, Repo: repo, Kind: kind, Hints: hints, Err: err}
+}
+
+// accessHints collects the hints GitHub documents for 403 and 404 responses
+func accessHints(err error) []string {
+	var errResp *github.ErrorResponse
+	if !errors.As(err, &errResp) || errResp.Response == nil {
+		return nil
+	}
+
+	var hints []string
+	header := errResp.Response.Header
+	if permissions := header.Get("X-Accepted-GitHub-Permissions"); permissions != "" {
+		hints = append(hints, fmt.Sprintf(HintRequiredPermissions, permissions))
+	}
+	if sso := header.Get("X-GitHub-SSO"); sso != "" {
+		if _, url, ok := strings.Cut(sso, "url="); ok {
+			hints = append(hints, fmt.Sprintf(HintSSOAuthorization, strings.TrimSpace(url)))
+		} else {
+			hints = append(hints, fmt.Sprintf(HintSSOAuthorization, sso))
+		}
+	}
+	if scopes := header.Get("X-Accepted-OAuth-Scopes"); scopes != "" && errResp.Response.StatusCode == http.StatusForbidden {
+		hints = append(hints, fmt.Sprintf(HintAcceptedScopes, scopes))
+	}
+	if errResp.DocumentationURL != "" {
+		hints = append(hints, fmt.Sprintf(HintDocumentation, errResp.DocumentationURL))
+	}
+	return hints
+}
+
+// APIErrorStatus returns the HTTP status code of a GitHub API error, or 0
+func APIErrorStatus(err error) int {
+	var errResp *github.ErrorResponse
+	if errors.As(err, &errResp) && errResp.Response != nil {
+		return errResp.Response.StatusCode
+	}
+	return 0
+}
+
+// IsRateLimitError reports whether err was caused by a primary or secondary rate limit
+func IsRateLimitError(err error) bool {
+	var rateErr *github.RateLimitError
+	var abuseErr *github.AbuseRateLimitError
+	return errors.As(err, &rateErr) || errors.As(err, &abuseErr)
+}
@@ -0,0 +1,114 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v72/github"
+)
+
+func TestDiagnoseAccessError(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int               // Status of the failing request
+		headers   map[string]string // Headers of the failing response
+		body      string
+		repo      int // Status of GET /repos/o/r
+		wantKind  AccessErrorKind
+		wantPlain bool // The error is returned unchanged
+		wantHints []string
+	}{
+		{
+			name:      "forbidden with permission and SSO hints",
+			status:    http.StatusForbidden,
+			headers:   map[string]string{"X-Accepted-GitHub-Permissions": "contents=write", "X-GitHub-SSO": "required; url=https://github.com/orgs/o/sso?authorization_request=1"},
+			body:      `{"message":"Resource not accessible by personal access token","documentation_url":"https://docs.github.com/rest"}`,
+			repo:      http.StatusOK,
+			wantKind:  AccessDenied,
+			wantHints: []string{"contents=write", "https://github.com/orgs/o/sso?authorization_request=1", "see https://docs.github.com/rest"},
+		},
+		{
+			name:      "missing or hidden repository",
+			status:    http.StatusNotFound,
+			body:      `{"message":"Not Found"}`,
+			repo:      http.StatusNotFound,
+			wantKind:  RepositoryNotFound,
+			wantHints: []string{HintPrivateRepository},
+		},
+		{
+			name:     "missing resource in visible repository",
+			status:   http.StatusNotFound,
+			body:     `{"message":"Not Found"}`,
+			repo:     http.StatusOK,
+			wantKind: ResourceNotFound,
+		},
+		{
+			name:      "rate limited",
+			status:    http.StatusForbidden,
+			headers:   map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": "1"},
+			body:      `{"message":"API rate limit exceeded"}`,
+			wantPlain: true,
+		},
+		{
+			name:      "server error",
+			status:    http.StatusInternalServerError,
+			body:      `{"message":"boom"}`,
+			wantPlain: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/repos/o/r/git/ref/tags/v1", func(w http.ResponseWriter, r *http.Request) {
+				for k, v := range tt.headers {
+					w.Header().Set(k, v)
+				}
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			})
+			mux.HandleFunc("/repos/o/r", func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.repo)
+				fmt.Fprint(w, `{"name":"r"}`)
+			})
+			server := httptest.NewServer(mux)
+			defer server.Close()
+
+			client := github.NewClient(nil)
+			client.BaseURL, _ = url.Parse(server.URL + "/")
+			_, _, apiErr := client.Git.GetRef(context.Background(), "o", "r", "tags/v1")
+			if apiErr == nil {
+				t.Fatal("GetRef() expected error")
+			}
+
+			err := DiagnoseAccessError(context.Background(), client, "o", "r", fmt.Errorf("wrapped: %w", apiErr))
+			var accessErr *AccessError
+			if !errors.As(err, &accessErr) {
+				if !tt.wantPlain {
+					t.Fatalf("DiagnoseAccessError() = %v, want AccessError", err)
+				}
+				return
+			}
+			if tt.wantPlain {
+				t.Fatalf("DiagnoseAccessError() = %v, want the error unchanged", err)
+			}
+			if accessErr.Kind != tt.wantKind {
+				t.Errorf("Kind = %v, want %v", accessErr.Kind, tt.wantKind)
+			}
+			for _, hint := range tt.wantHints {
+				if !strings.Contains(err.Error(), hint) {
+					t.Errorf("error %q does not contain hint %q", err, hint)
+				}
+			}
+			if !errors.Is(err, apiErr) {
+				t.Error("AccessError does not unwrap to the API error")
+			}
+		})
+	}
+}
@@ -33,6 +33,8 @@ const (
 	ErrCreatingDirectories   = "error creating directories: %w"
 	ErrWritingTempFile       = "error writing temporary file: %w"
 	ErrReplacingOriginalFile = "error replacing original file: %w"
+	ErrCreatingBackup        = "error creating backup: %w"
+	ErrRestoringFile         = "error restoring %s after failed update: %w"
 	ErrOpeningSourceFile     = "error opening source file: %w"
 	ErrCreatingDestFile      = "error creating destination file: %w"
 	ErrCopyingFileContents   = "error copying file contents: %w"
@@ -53,6 +55,17 @@ const (
 	ErrParsingWorkflowYAML     = "error parsing workflow YAML: %w"
 	ErrEmptyYAMLDocument       = "empty YAML document"
 	ErrParsingWorkflowContent  = "error parsing workflow content: %w"
+	ErrParsingWorkflowFile     = "error parsing %s: %w"
+	ErrInvalidLocalAction      = "invalid local action %s: %w"
+	ErrLocalActionNotFound     = "no action.yml or action.yaml found for local action %s"
+	ErrInvalidPathPattern      = "invalid path pattern %q: %w"
+	ErrSymlinkNotAllowed       = "symbolic links are not allowed: %s"
+	ErrSubmoduleNotAllowed     = "git submodules are not allowed: %s"
+	ErrInvalidLinkPolicy       = "invalid link policy %q: want follow, skip or error"
+	ErrSchemaViolation         = "Warning: %s:%d: %s"
+	ErrValidatingSchema        = "Failed to validate %s against the workflow schema: %v"
+	ErrExcessivePermissions    = "Warning: %s:%d: %s"
+	ErrCheckingPermissions     = "Failed to check the permissions of %s: %v"
 )
 
 // TestErrors contains constants for test error messages - these maintain capitalization from the original test file
@@ -75,6 +88,88 @@ const (
 	ErrGettingAnnotatedTag = "error getting annotated tag %s: %w"
 	ErrNoCommitHashInTag   = "no commit hash found in annotated tag %s"
 	ErrContextIsNil        = "context is nil"
+	ErrGettingReleaseDate  = "error getting release date for %s: %w"
+	ErrGettingReleaseNotes = "error getting release notes for %s: %w"
+	ErrGettingLicense      = "error getting license of %s: %w"
+	ErrReleasesFallback    = "Warning: releases API failed for %s/%s (%v); resolving the latest version from tags"
+	ErrReleasesUnavailable = "Warning: releases API failed %d times in a row; using tags only for the rest of the run"
+	ErrGettingCommitTags   = "error getting tags of commit %s: %w"
+	ErrGettingCommitDate   = "error getting date of commit %s: %w"
+	ErrCheckingDrift       = "Failed to check the version comment of %s@%s: %v"
+	ErrCommentDrift        = "Warning: %s:%d: %s is pinned to %s, which is not %s"
+	ErrResolvingVersion    = "error resolving the version of commit %s: %w"
+	ErrFailedToResolve     = "Failed to resolve the version of %s@%s: %v"
+	ErrGraphQLQuery        = "GraphQL query failed: %w"
+	ErrGraphQLPrefetch     = "Warning: batched lookup failed (%v); resolving actions one by one"
+
+	// Moved repository errors
+	ErrGettingRepositoryLocation = "error getting the location of %s: %w"
+	ErrDetectingMove             = "Warning: failed to check whether %s moved: %v"
+	ErrActionMoved               = "Repository %s moved to %s; renaming its references"
+
+	// Release notes summarizer errors
+	ErrUnknownSummarizer     = "unknown summarizer %q: expected command:<program> or an https:// URL"
+	ErrInsecureSummarizerURL = "summarizer URL %s must use https (plain http is only allowed for localhost)"
+	ErrSummarizerFailed      = "summarizer failed for %s@%s: %w"
+	ErrSummarizerStatus      = "summarizer returned status %d"
+
+	// Change ticket errors
+	ErrUnknownChangeTicketer = "unknown change ticket integration %q: expected command:<program> or an https:// URL"
+	ErrInsecureTicketURL     = "change ticket URL %s must use https (plain http is only allowed for localhost)"
+	ErrChangeTicketStatus    = "change ticket integration returned status %d"
+	ErrInvalidChangeTicket   = "change ticket integration returned no ticket id"
+	ErrCreatingChangeTicket  = "failed to create change ticket: %w"
+	ErrEnablingAutoMerge     = "failed to enable auto-merge: %s"
+	ErrInvalidMergeMethod    = "invalid merge method %q: expected squash, merge or rebase"
+	ErrSettingCommitStatus   = "failed to set %s on %s: %w"
+
+	// Event bus errors
+	ErrUnknownEventSink     = "unknown event sink %q: expected log, file:<path>, an https:// URL or a redis:// queue"
+	ErrInsecureEventSinkURL = "event sink URL %s must use https (plain http is only allowed for localhost)"
+	ErrEventSinkStatus      = "event sink returned status %d"
+	ErrDeliveringEvent      = "Warning: failed to deliver %s event %s to %s: %v"
+	ErrSpoolingEvent        = "Warning: dropped %s event %s for %s: %v"
+
+	// Notification errors
+	ErrUnknownNotifier      = "unknown notifier %q: expected slack:<url>, teams:<url>, an https:// URL or an smtp:// URL"
+	ErrInsecureNotifyURL    = "notification URL for %s must use https (plain http is only allowed for localhost)"
+	ErrInvalidNotifyOn      = "invalid notify-on value %q: expected always, changes or errors"
+	ErrNotifierStatus       = "notifier returned status %d"
+	ErrNotifying            = "Warning: failed to send %s notification: %v"
+	ErrInvalidEmailNotifier = "invalid smtp notifier for %s: %s"
+	ErrEmailTemplate        = "error in email template %s: %w"
+
+	// Private action repository errors
+	HintPrivateAction      = "for private actions grant the token read access to the repository contents, include it in the app installation, or configure a scoped token with -action-token-env"
+	ErrInvalidActionToken  = "invalid action token entry %q: expected owner[/repo]=ENV_VAR"
+	ErrActionTokenEnvEmpty = "environment variable %s for action token scope %s is empty"
+	ErrInvalidActionHost   = "invalid action host entry %q: expected host[=ENV_VAR]"
+
+	// Update policy errors
+	ErrInvalidVersion      = "invalid version %q: expected a tag such as v4 or v4.1.0"
+	ErrInvalidVersionDelta = "invalid version delta %q: expected patch, minor or major"
+	ErrInvalidActionScope  = "invalid action scope %q: expected owner or owner/repo"
+	ErrUpdateBelowPolicy   = "Skipping update of %s from %s to %s: below the update policy"
+	ErrUpdatePrerelease    = "Skipping update of %s from %s to %s: prereleases are not allowed"
+	ErrUpdateMajor         = "Skipping update of %s from %s to %s: major version update"
+	ErrUpdateSnoozed       = "Skipping update of %s from %s to %s: snoozed"
+	ErrUpdateIgnored       = "Skipping update of %s from %s to %s: ignored by the Dependabot configuration"
+	ErrInvalidAge          = "invalid age %q: expected a duration such as 7d or 36h"
+	ErrUpdateTooNew        = "Skipping update of %s from %s to %s: released %s, less than %s ago"
+	ErrReleaseAgeUnknown   = "Warning: release date of %s %s unknown, not holding the update back: %v"
+	ErrStalePin            = "Warning: %s:%d: %s@%s is from %s, more than %s ago"
+	ErrReadingDependabot   = "error reading Dependabot configuration %s: %w"
+	ErrInvalidRequirement  = "invalid Dependabot version requirement %q"
+	ErrInvalidSnoozeTarget = "invalid snooze target %q: expected owner/repo[@version]"
+	ErrInvalidCampaign     = "invalid campaign: %s"
+	ErrInvalidPinStyle     = "invalid pin style entry %q: expected owner[/repo]=hash, full-version-tag or major-tag"
+	ErrCanaryNotInCampaign = "canary %s is not one of the campaign repositories"
+	ErrCanariesFailed      = "campaign halted, canaries failed: %s"
+
+	// Gitea provider errors
+	ErrGiteaAPI        = "%s %s: %d %s"
+	ErrInvalidGiteaURL = "invalid Gitea URL %q: expected http(s)://host[/path]"
+	ErrUnknownProvider = "unknown provider %q: expected github or gitea"
 )
 
 // PRCreatorErrors contains constants for PR creator error messages
@@ -84,19 +179,44 @@ const (
 	ErrCreatingPR              = "error creating pull request: %w"
 	ErrGettingRepository       = "error getting repository: %w"
 	ErrGettingDefaultBranchRef = "error getting default branch ref: %w"
+	ErrGettingBaseBranchRef    = "error getting ref of base branch %s: %w"
 	ErrGettingFileContents     = "error getting file contents: %w"
 	ErrDecodingContent         = "error decoding content: %w"
 	ErrCreatingBlob            = "error creating blob: %w"
 	ErrGettingBranchRef        = "error getting branch ref: %w"
 	ErrCreatingTree            = "error creating tree: %w"
+	ErrInvalidBranchTemplate   = "invalid branch template %q: %s"
+	ErrCreatingFork            = "error creating fork of %s/%s: %w"
+	ErrForkNotReady            = "fork %s/%s is not ready: %w"
+	ErrNoChanges               = "the updates do not change branch %s"
+	ErrNothingToDo             = "Nothing to do: %v"
+	ErrRecoveringPR            = "error recovering the pull request of branch %s: %w"
+
+	// Pre-flight checks before creating a pull request
+	ErrPreflightBaseBranch       = "cannot open a pull request in %s/%s, the base branch is missing: %v"
+	ErrPreflightWriteAccess      = "cannot open a pull request in %s/%s, the token cannot push to it: %v"
+	WarnBranchCreationRestricted = "rules of %s/%s restrict creating branch %s; the pull request fails unless the token may bypass them"
+	WarnSignaturesRequired       = "rules of %s/%s require signed commits on branch %s; commits created through the API are only signed for GitHub App tokens"
+	WarnRequiredStatusChecks     = "base branch %s requires status checks %s; pull requests merge once they pass"
 )
 
 // UpdateManagerErrors contains constants for update manager error messages
 const (
-	ErrInvalidUpdatePath = "invalid update path: %w"
-	ErrReadingUpdateFile = "error reading file: %w"
-	ErrWritingUpdateFile = "error writing file: %w"
-	ErrApplyingUpdates   = "error applying updates: %w"
+	ErrInvalidUpdatePath       = "invalid update path: %w"
+	ErrReadingUpdateFile       = "error reading file: %w"
+	ErrWritingUpdateFile       = "error writing file: %w"
+	ErrApplyingUpdates         = "error applying updates: %w"
+	ErrConflictingUpdates      = "conflicting updates of %s at %s:%d: %s"
+	ErrIncludeRefNotFound      = "Warning: skipped %d GitLab include update(s): %v"
+	ErrTemplateValueNotFound   = "Warning: skipped %d templated uses update(s): %v"
+	ErrRewritingFile           = "error rewriting %s: %w"
+	ErrFileChangedDuringUpdate = "%s kept changing while it was being rewritten (%d attempts)"
+	ErrWritingPatch            = "error writing patch: %w"
+	ErrMissingRunOption        = "missing required run option: %s"
+	ErrUnknownRunMode          = "unknown run mode %q: expected pr, stage or dry-run"
+	ErrUnknownDriftMode        = "unknown comment drift mode %q: expected report, fix-comment or fix-pin"
+	ErrRunCancelled            = "run cancelled: %w"
+	ErrUnknownRewriteMode      = "unknown rewrite strategy %q: expected yaml or line"
 )
 
 // GitHubErrors contains constants for GitHub utility error messages
@@ -108,6 +228,25 @@ const (
 	ErrNoRateLimitInfo      = "No rate limit information available"
 	ErrRateLimitFormat      = "Rate limit: %d/%d, resets in %s"
 	ErrInvalidEnterpriseURL = "invalid enterprise URL: %w"
+	ErrRateLimitBudget      = "rate limit budget reached: %d core requests remaining (floor %d), resets at %s"
+	ErrRetryingRequest      = "Warning: transient API error (%v); retrying in %s (attempt %d/%d)"
+	ErrWritingAPIAudit      = "Warning: failed to write API audit log %s: %v"
+	ErrInvalidProxyURL      = "invalid proxy URL %q: expected http://, https:// or socks5://host:port"
+	ErrReadingCAFile        = "error reading CA file %s: %w"
+	ErrNoCertificatesInFile = "no PEM certificates found in CA file %s"
+	ErrInvalidTLSVersion    = "invalid TLS version %q: expected 1.2 or 1.3"
+
+	// Access errors (403/404) and the hints added to them
+	ErrAccessDenied         = "access to %s/%s was denied: %v"
+	ErrRepositoryNotFound   = "repository %s/%s was not found or is not visible to the token: %v"
+	ErrResourceNotFound     = "repository %s/%s exists but the requested resource was not found: %v"
+	HintPrivateRepository   = "GitHub reports private repositories the token cannot access as not found"
+	HintRequiredPermissions = "the endpoint requires these token permissions: %s"
+	HintSSOAuthorization    = "the organization uses SAML SSO; authorize the token at %s"
+	HintAcceptedScopes      = "the endpoint accepts tokens with these scopes: %s"
+	HintDocumentation       = "see %s"
+	HintWriteAccess         = "write requests also return 404 when the token can read but not write the repository"
+	HintFork                = "without write access, open pull requests from a fork of the repository with -fork"
 
 	// Token validation errors
 	ErrInvalidGitHubToken    = "invalid GitHub token: %w" // #nosec G101 - This is an error message, not a credential
@@ -119,16 +258,24 @@ const (
 
 // CommandErrors contains constants for command line errors
 const (
-	ErrMissingRequiredFlag   = "missing required flag: %s"
-	ErrInvalidFlagValue      = "invalid value for flag %s: %s"
-	ErrCommandExecution      = "error executing command: %w"
-	ErrNoGithubToken         = "No GitHub token provided. Using public GitHub API with rate limiting. For higher rate limits, provide a token via -token flag or GITHUB_TOKEN environment variable." // #nosec G101
-	ErrNoWorkflowsFound      = "No workflow files found"
-	ErrNoUpdatesAvailable    = "No updates available"
-	ErrFailedToParseWorkflow = "Failed to parse %s: %v"
-	ErrFailedToCheckAction   = "Failed to check %s/%s: %v"
-	ErrFailedToCheckUpdate   = "Failed to check update availability for %s/%s: %v"
-	ErrFailedToCreateUpdate  = "Failed to create update for %s/%s: %v"
+	ErrMissingRequiredFlag      = "missing required flag: %s"
+	ErrInvalidFlagValue         = "invalid value for flag %s: %s"
+	ErrCommandExecution         = "error executing command: %w"
+	ErrNoGithubToken            = "No GitHub token provided. Using public GitHub API with rate limiting. For higher rate limits, provide a token via -token flag, the GH_TOKEN or GITHUB_TOKEN environment variable, a GitHub App or gh auth login." // #nosec G101
+	ErrNoWorkflowsFound         = "No workflow files found"
+	ErrNoUpdatesAvailable       = "No updates available"
+	ErrFailedToParseWorkflow    = "Failed to parse %s: %v"
+	ErrFailedToCheckAction      = "Failed to check %s/%s: %v"
+	ErrFailedToCheckUpdate      = "Failed to check update availability for %s/%s: %v"
+	ErrFailedToCreateUpdate     = "Failed to create update for %s/%s: %v"
+	ErrStrictFailures           = "%d workflows or action references could not be checked (-strict)"
+	ErrDoctorFailed             = "%d of %d checks failed"
+	ErrActionTokensNotSupported = "version checker does not support scoped action tokens"
+	ErrActionHostsNotSupported  = "version checker does not support action hosts"
+	ErrGraphQLNotSupported      = "version checker does not support GraphQL lookups"
+	ErrReadingScanResults       = "error reading scan results %s: %w"
+	ErrWritingScanResults       = "error writing scan results %s: %w"
+	ErrCheckingSelfUpdate       = "error checking for a newer release of ghactions-updater: %w"
 )
 
 // TestToolErrors contains constants for test tool error messages
@@ -140,6 +287,130 @@ const (
 	ErrCouldNotRemoveDummyFile     = "Warning: could not remove dummy file: %v"
 )
 
+// StorageErrors contains constants for cache and state store error messages
+const (
+	ErrInvalidStoreLocation   = "invalid store location: %s"
+	ErrUnsupportedStoreScheme = "unsupported store scheme: %s"
+	ErrInvalidStoreKey        = "invalid store key: %s"
+	ErrStoreRequest           = "%s store request failed: %w"
+	ErrOpeningStore           = "error opening store: %w"
+	ErrSavingETag             = "Warning: failed to store the response for %s: %v"
+	ErrDecodingCacheEntry     = "error decoding cache entry: %w"
+)
+
+// MetricsErrors contains constants for metrics export error messages
+const (
+	ErrWritingMetrics = "error writing metrics textfile: %w"
+	ErrPushingMetrics = "error pushing metrics: %w"
+)
+
+// ReportErrors contains constants for run report and sharding error messages
+const (
+	ErrWritingReport           = "error writing report: %w"
+	ErrReadingReport           = "error reading report %s: %w"
+	ErrUnsupportedReportSchema = "report %s has unsupported schema version %d (expected %d)"
+	ErrUnsupportedReportFormat = "unsupported report format: %s"
+	ErrNoReportsToMerge        = "no reports to merge"
+	ErrReadingCheckpoint       = "error reading checkpoint %s: %w"
+	ErrWritingCheckpoint       = "error writing checkpoint %s: %w"
+	ErrCheckpointMismatch      = "checkpoint %s was written by a different run (%s); delete it to start over"
+	ErrInvalidShard            = "invalid shard %q: expected i/N with 1 <= i <= N"
+	ErrListingRepositories     = "error listing repositories: %w"
+	ErrReadingRepositoryList   = "error reading repository list: %w"
+	ErrInvalidRepositoryName   = "invalid repository name %q: expected owner/repo"
+	ErrFetchingWorkflows       = "error fetching workflows for %s: %w"
+)
+
+// LockfileErrors contains constants for action lockfile error messages
+const (
+	ErrReadingLockfile     = "error reading lockfile %s: %w"
+	ErrWritingLockfile     = "error writing lockfile %s: %w"
+	ErrUnsupportedLockfile = "lockfile %s has unsupported version %d (expected %d)"
+	ErrLockingAction       = "Warning: could not resolve the commit of %s@%s for the lockfile: %v"
+	ErrCheckingLock        = "error checking %s@%s against the lockfile: %w"
+	ErrLockfileMismatch    = "workflows do not match lockfile %s: %d mismatches"
+)
+
+// MetadataErrors contains constants for offline metadata snapshot error messages
+const (
+	ErrReadingMetadata     = "error reading metadata snapshot %s: %w"
+	ErrWritingMetadata     = "error writing metadata snapshot %s: %w"
+	ErrUnsupportedMetadata = "metadata snapshot %s has unsupported version %d (expected %d)"
+	ErrNotInSnapshot       = "%s is not in the metadata snapshot; export it again with -metadata and without -offline"
+)
+
+// RedactErrors contains constants for redaction error messages
+const (
+	ErrInvalidRedactPattern = "invalid redact pattern %q: %v"
+)
+
+// CleanupErrors contains constants for update branch cleanup error messages
+const (
+	ErrListingBranches     = "error listing branches of %s/%s: %w"
+	ErrListingPullRequests = "error listing pull requests of %s/%s: %w"
+	ErrDeletingBranch      = "error deleting branch %s: %w"
+)
+
+// RepositoryLockErrors contains constants for repository lock error messages
+const (
+	ErrRepositoryLocked  = "%s/%s is locked by %s until %s"
+	ErrAcquiringRepoLock = "error acquiring the lock of %s/%s: %w"
+	ErrReleasingRepoLock = "error releasing the lock of %s/%s: %w"
+)
+
+// GitRemoteErrors contains constants for detecting the repository of a clone
+// from its git remotes
+const (
+	ErrNotGitRepository    = "%s is not inside a git clone"
+	ErrReadingGitConfig    = "error reading git configuration %s: %w"
+	ErrNoGitRemotes        = "git clone %s has no remotes"
+	ErrNoGitRemote         = "git clone %s has no remote %q"
+	ErrAmbiguousRemotes    = "cannot tell which of the remotes %s is the repository; set -remote, or -owner and -repo-name"
+	ErrInvalidRemoteURL    = "remote URL %s does not name an owner/repo"
+	ErrRemoteOtherHost     = "remote %s is on %s, not %s"
+	ErrDetectingRepository = "-owner and -repo-name are required and could not be detected: %w"
+)
+
+// CredentialErrors contains constants for finding the token of a run
+const (
+	ErrCredentialSource     = "error getting a token from %s: %w"
+	ErrMissingAppCredential = "%s is set but %s is not"
+	ErrInvalidAppSetting    = "invalid %s %q"
+	ErrParsingAppKey        = "error parsing the GitHub App private key: %w"
+	ErrSigningAppJWT        = "error signing the GitHub App JWT: %w"
+	ErrListingInstallations = "error listing the installations of GitHub App %d: %w"
+	ErrAppInstallations     = "GitHub App %d has %d installations; set GITHUB_APP_INSTALLATION_ID"
+	ErrCreatingAppToken     = "error creating a token for installation %d: %w"
+)
+
+// ConfigErrors contains constants for configuration file error messages
+const (
+	ErrReadingConfig    = "error reading config %s: %w"
+	ErrParsingConfig    = "error parsing config %s: %w"
+	ErrConfigVersion    = "%s has unsupported schema version %d (supported: %d to %d)"
+	ErrConfigValue      = "%s: invalid value for %q: %v"
+	ErrConfigNotMapping = "config must be a mapping of flag names to values"
+	ErrConfigNotScalar  = "expected a scalar or a list of scalars"
+	ErrConfigDeprecated = "Warning: %s uses deprecated schema version %d: %s; run \"ghactions-updater config migrate -w\" to update it"
+	ErrUnknownConfigKey = "%s: unknown setting %q"
+	ErrWritingConfig    = "error writing config %s: %w"
+
+	ErrInvalidConfigSource = "invalid central config %q: expected owner/repo[/path][@ref]"
+	ErrFetchingConfig      = "error fetching config %s: %w"
+	ErrConfigNotFile       = "not a file"
+	ErrUsingCachedConfig   = "Warning: using the cached central config: %v"
+	ErrCachingConfig       = "Warning: failed to cache config %s: %v"
+)
+
+// WebhookErrors contains constants for webhook server error messages
+const (
+	ErrWebhookNoSecret    = "webhook secret is not configured"
+	ErrWebhookRejected    = "Warning: rejected webhook delivery %s: %v"
+	ErrWebhookSecretEnv   = "-serve requires the webhook secret in the %s environment variable"
+	ErrWebhookServe       = "webhook server failed: %w"
+	ErrIndexingRepository = "Warning: failed to index %s: %v"
+)
+
 const (
 	ErrFailedToCloseBody = "Failed to close response body: %v"
 )
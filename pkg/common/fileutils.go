@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 )
 
 // FileLockManager manages locks for file operations to prevent concurrent access
@@ -57,6 +58,14 @@ type FileOptions struct {
 	BaseDir string
 	// ValidateOptions are the options for path validation
 	ValidateOptions PathValidationOptions
+	// Backup if true, keeps the previous contents of an existing file at
+	// path + BackupSuffix before replacing it
+	Backup bool
+	// PreserveMode if true, gives a replaced file the permissions, owner
+	// and group of the file it replaces; Mode then only applies to new files
+	PreserveMode bool
+	// PreserveModTime if true, keeps the modification time of a replaced file
+	PreserveModTime bool
 }
 
 // DefaultFileOptions returns the default options for file operations
@@ -122,21 +131,113 @@ func WriteFileWithOptions(path string, data []byte, options FileOptions) error {
 		}
 	}
 
-	// Write to a temporary file first
-	tempFile := path + ".tmp"
-	if err := os.WriteFile(tempFile, data, options.Mode); err != nil {
-		// Clean up the temporary file if write fails
-		_ = os.Remove(tempFile)
+	// Keep the original contents as a backup if requested
+	if options.Backup {
+		if err := backupFile(path); err != nil {
+			return err
+		}
+	}
+
+	attrs := fileAttrs{mode: options.Mode}
+	if options.PreserveMode || options.PreserveModTime {
+		if info, err := os.Stat(path); err == nil {
+			if options.PreserveMode {
+				attrs.mode = info.Mode() & (os.ModePerm | os.ModeSetuid | os.ModeSetgid | os.ModeSticky)
+				attrs.owner = info
+			}
+			if options.PreserveModTime {
+				attrs.modTime = info.ModTime()
+			}
+		}
+	}
+	return writeFileAtomic(path, data, attrs)
+}
+
+// fileAttrs are the attributes writeFileAtomic gives the file it writes
+type fileAttrs struct {
+	mode    os.FileMode
+	owner   os.FileInfo // Copy the owner and group of this file when set
+	modTime time.Time   // Set as modification time when not zero
+}
+
+// writeFileAtomic writes data to a temporary file in the target directory,
+// syncs it to disk and renames it over path, so readers and crashes only
+// ever observe either the old or the new contents
+func writeFileAtomic(path string, data []byte, attrs fileAttrs) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
 		return fmt.Errorf(ErrWritingTempFile, err)
 	}
+	tempFile := tmp.Name()
+
+	// Clean up the temporary file on any failure
+	cleanup := func(format string, err error) error {
+		_ = tmp.Close()
+		_ = os.Remove(tempFile)
+		return fmt.Errorf(format, err)
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		return cleanup(ErrWritingTempFile, err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return cleanup(ErrWritingTempFile, err)
+	}
+	// Ownership goes first, as changing it clears the setuid and setgid bits.
+	// Only privileged users can give files away, so failing to is no error.
+	if attrs.owner != nil {
+		_ = chownLike(tempFile, attrs.owner)
+	}
+	if err := tmp.Chmod(attrs.mode); err != nil {
+		return cleanup(ErrWritingTempFile, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return cleanup(ErrWritingTempFile, err)
+	}
+	if !attrs.modTime.IsZero() {
+		if err := os.Chtimes(tempFile, attrs.modTime, attrs.modTime); err != nil {
+			_ = os.Remove(tempFile)
+			return fmt.Errorf(ErrWritingTempFile, err)
+		}
+	}
 
 	// Rename the temporary file to the target file (atomic operation)
 	if err := os.Rename(tempFile, path); err != nil {
-		// Clean up the temporary file if rename fails
 		_ = os.Remove(tempFile)
 		return fmt.Errorf(ErrReplacingOriginalFile, err)
 	}
 
+	// Persist the rename itself; not all platforms support syncing directories
+	if d, err := os.Open(dir); err == nil {
+		_ = d.Sync()
+		_ = d.Close()
+	}
+
+	return nil
+}
+
+// BackupSuffix is appended to a file name to form its backup path
+const BackupSuffix = ".bak"
+
+// backupFile copies an existing file to its backup path. Missing files need
+// no backup.
+func backupFile(path string) error {
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf(ErrCreatingBackup, err)
+	}
+	// #nosec G304 - path has been validated by the caller
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf(ErrCreatingBackup, err)
+	}
+	if err := writeFileAtomic(path+BackupSuffix, data, fileAttrs{mode: info.Mode().Perm()}); err != nil {
+		return fmt.Errorf(ErrCreatingBackup, err)
+	}
 	return nil
 }
 
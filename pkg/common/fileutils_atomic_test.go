@@ -0,0 +1,116 @@
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteFileWithOptionsBackup(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "file.txt")
+
+	options := DefaultFileOptions()
+	options.Backup = true
+
+	// A new file has nothing to back up
+	if err := WriteFileWithOptions(path, []byte("first"), options); err != nil {
+		t.Fatalf("WriteFileWithOptions() error = %v", err)
+	}
+	if FileExists(path + BackupSuffix) {
+		t.Error("backup created for a new file")
+	}
+
+	if err := WriteFileWithOptions(path, []byte("second"), options); err != nil {
+		t.Fatalf("WriteFileWithOptions() error = %v", err)
+	}
+	if got, _ := os.ReadFile(path); string(got) != "second" {
+		t.Errorf("file = %q, want %q", got, "second")
+	}
+	if got, _ := os.ReadFile(path + BackupSuffix); string(got) != "first" {
+		t.Errorf("backup = %q, want %q", got, "first")
+	}
+
+	entries, _ := os.ReadDir(dir)
+	for _, entry := range entries {
+		if strings.Contains(entry.Name(), ".tmp") {
+			t.Errorf("temporary file left behind: %s", entry.Name())
+		}
+	}
+}
+
+func TestWriteFileWithOptionsMode(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file.txt")
+	options := DefaultFileOptions()
+	options.Mode = 0640
+	if err := WriteFileWithOptions(path, []byte("data"), options); err != nil {
+		t.Fatalf("WriteFileWithOptions() error = %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0640 {
+		t.Errorf("mode = %v, want 0640", info.Mode().Perm())
+	}
+}
+
+func TestWriteFileWithOptionsPreserveMode(t *testing.T) {
+	tests := []struct {
+		name string
+		mode os.FileMode
+	}{
+		{name: "group readable", mode: 0644},
+		{name: "private", mode: 0600},
+		{name: "read-only", mode: 0444},
+		{name: "executable", mode: 0755},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "file.txt")
+			if err := os.WriteFile(path, []byte("old"), 0600); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Chmod(path, tt.mode); err != nil {
+				t.Fatal(err)
+			}
+			modTime := time.Now().Add(-24 * time.Hour).Truncate(time.Second)
+			if err := os.Chtimes(path, modTime, modTime); err != nil {
+				t.Fatal(err)
+			}
+
+			options := DefaultFileOptions()
+			options.PreserveMode = true
+			options.PreserveModTime = true
+			if err := WriteFileWithOptions(path, []byte("new"), options); err != nil {
+				t.Fatalf("WriteFileWithOptions() error = %v", err)
+			}
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Mode().Perm() != tt.mode {
+				t.Errorf("mode = %v, want %v", info.Mode().Perm(), tt.mode)
+			}
+			if !info.ModTime().Equal(modTime) {
+				t.Errorf("modification time = %v, want %v", info.ModTime(), modTime)
+			}
+			if got, _ := os.ReadFile(path); string(got) != "new" {
+				t.Errorf("file = %q, want %q", got, "new")
+			}
+		})
+	}
+
+	// New files still get Mode
+	path := filepath.Join(t.TempDir(), "new.txt")
+	options := DefaultFileOptions()
+	options.PreserveMode = true
+	if err := WriteFileWithOptions(path, []byte("data"), options); err != nil {
+		t.Fatalf("WriteFileWithOptions() error = %v", err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("new file mode = %v, %v, want 0600", info.Mode().Perm(), err)
+	}
+}
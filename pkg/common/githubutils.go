@@ -26,8 +26,17 @@ type GitHubClientOptions struct {
 	RetryDelay time.Duration
 	// MaxRetryDelay is the maximum delay between retries
 	MaxRetryDelay time.Duration
+	// Transport is the base HTTP transport for API requests (optional)
+	Transport http.RoundTripper
+	// HTTPClient sends the API requests instead of a client around
+	// Transport; the token is added on top of its transport (optional)
+	HTTPClient *http.Client
 }
 
+// HTTPTransport is the base transport used by clients created with the default
+// options. It can be replaced to add instrumentation such as metrics collection.
+var HTTPTransport http.RoundTripper
+
 // DefaultGitHubClientOptions returns the default options for GitHub client creation
 func DefaultGitHubClientOptions() GitHubClientOptions {
 	return GitHubClientOptions{
@@ -35,6 +44,7 @@ func DefaultGitHubClientOptions() GitHubClientOptions {
 		RetryCount:    3,
 		RetryDelay:    1 * time.Second,
 		MaxRetryDelay: 60 * time.Second,
+		Transport:     HTTPTransport,
 	}
 }
 
@@ -71,13 +81,24 @@ func CalculateBackoff(attempt int, baseDelay time.Duration, maxDelay time.Durati
 
 // NewGitHubClient creates a new GitHub client with the given options
 func NewGitHubClient(options GitHubClientOptions) *github.Client {
-	var httpClient *http.Client
+	httpClient := options.HTTPClient
+	if httpClient == nil && options.Transport != nil {
+		httpClient = &http.Client{Transport: options.Transport}
+	}
 
 	if options.Token != "" {
 		ts := oauth2.StaticTokenSource(
 			&oauth2.Token{AccessToken: options.Token},
 		)
-		httpClient = oauth2.NewClient(context.Background(), ts)
+		ctx := context.Background()
+		var timeout time.Duration
+		if httpClient != nil {
+			// Use the custom transport underneath the OAuth2 transport
+			ctx = context.WithValue(ctx, oauth2.HTTPClient, httpClient)
+			timeout = httpClient.Timeout
+		}
+		httpClient = oauth2.NewClient(ctx, ts)
+		httpClient.Timeout = timeout
 	}
 
 	client := github.NewClient(httpClient)
@@ -303,9 +324,9 @@ func ValidateTokenScopes(ctx context.Context, client *github.Client) error {
 	user, resp, err := client.Users.Get(ctx, "")
 	if err != nil {
 		if resp != nil && resp.StatusCode == http.StatusUnauthorized {
-			return fmt.Errorf(ErrInvalidGitHubToken, err)
+			return &AuthError{Err: fmt.Errorf(ErrInvalidGitHubToken, err)}
 		}
-		return fmt.Errorf(ErrFailedToValidateToken, err)
+		return Categorize(fmt.Errorf(ErrFailedToValidateToken, err))
 	}
 
 	// For unauthenticated clients, we can't check scopes
@@ -335,13 +356,13 @@ func ValidateTokenScopes(ctx context.Context, client *github.Client) error {
 	}
 
 	if !hasRepoScope {
-		return fmt.Errorf(ErrTokenMissingScope, "repo or public_repo")
+		return &AuthError{Err: fmt.Errorf(ErrTokenMissingScope, "repo or public_repo")}
 	}
 
 	// Check for other required scopes
 	for _, required := range requiredScopes {
 		if !strings.Contains(scopesHeader, required) {
-			return fmt.Errorf(ErrTokenMissingScope, required)
+			return &AuthError{Err: fmt.Errorf(ErrTokenMissingScope, required)}
 		}
 	}
 
@@ -0,0 +1,76 @@
+package common
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+// TransportOptions configures the base HTTP transport for API requests in
+// corporate networks
+type TransportOptions struct {
+	// ProxyURL is the proxy for every request. When empty, HTTPS_PROXY,
+	// HTTP_PROXY and NO_PROXY from the environment apply.
+	ProxyURL string
+	// CAFile is a PEM bundle of certificates trusted in addition to the
+	// system roots, e.g. of a TLS-inspecting proxy
+	CAFile string
+	// MinTLSVersion is the lowest TLS version accepted: "1.2" (default) or "1.3"
+	MinTLSVersion string
+}
+
+// NewHTTPTransport creates a transport like http.DefaultTransport with the
+// proxy, CA bundle and TLS version of options
+func NewHTTPTransport(options TransportOptions) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if options.ProxyURL != "" {
+		proxy, err := ParseProxyURL(options.ProxyURL)
+		if err != nil {
+			return nil, err
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	switch options.MinTLSVersion {
+	case "", "1.2":
+	case "1.3":
+		tlsConfig.MinVersion = tls.VersionTLS13
+	default:
+		return nil, fmt.Errorf(ErrInvalidTLSVersion, options.MinTLSVersion)
+	}
+
+	if options.CAFile != "" {
+		pem, err := os.ReadFile(options.CAFile) // #nosec G304 - path provided by the operator
+		if err != nil {
+			return nil, fmt.Errorf(ErrReadingCAFile, options.CAFile, err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf(ErrNoCertificatesInFile, options.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	transport.TLSClientConfig = tlsConfig
+	return transport, nil
+}
+
+// ParseProxyURL parses a proxy URL, which needs a scheme and a host
+func ParseProxyURL(raw string) (*url.URL, error) {
+	proxy, err := url.Parse(raw)
+	if err != nil || proxy.Host == "" {
+		return nil, fmt.Errorf(ErrInvalidProxyURL, raw)
+	}
+	switch proxy.Scheme {
+	case "http", "https", "socks5":
+		return proxy, nil
+	}
+	return nil, fmt.Errorf(ErrInvalidProxyURL, raw)
+}
@@ -0,0 +1,113 @@
+package common
+
+import (
+	"crypto/tls"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNewHTTPTransport(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caFile, cert, 0600); err != nil {
+		t.Fatal(err)
+	}
+	notPEM := filepath.Join(dir, "ca.txt")
+	if err := os.WriteFile(notPEM, []byte("not a certificate"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		options     TransportOptions
+		wantErr     bool
+		wantTrusted bool
+	}{
+		{name: "defaults"},
+		{name: "ca file", options: TransportOptions{CAFile: caFile}, wantTrusted: true},
+		{name: "tls 1.3", options: TransportOptions{CAFile: caFile, MinTLSVersion: "1.3"}, wantTrusted: true},
+		{name: "proxy", options: TransportOptions{ProxyURL: "http://proxy.example.com:3128"}},
+		{name: "invalid proxy", options: TransportOptions{ProxyURL: "proxy.example.com"}, wantErr: true},
+		{name: "unsupported proxy scheme", options: TransportOptions{ProxyURL: "ftp://proxy.example.com"}, wantErr: true},
+		{name: "missing ca file", options: TransportOptions{CAFile: filepath.Join(dir, "missing.pem")}, wantErr: true},
+		{name: "ca file without certificates", options: TransportOptions{CAFile: notPEM}, wantErr: true},
+		{name: "invalid tls version", options: TransportOptions{MinTLSVersion: "1.1"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport, err := NewHTTPTransport(tt.options)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewHTTPTransport() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if tt.options.ProxyURL != "" {
+				req, _ := http.NewRequest(http.MethodGet, "https://api.github.com/", nil)
+				if proxy, err := transport.Proxy(req); err != nil || proxy.String() != tt.options.ProxyURL {
+					t.Errorf("Proxy() = %v, %v; want %s", proxy, err, tt.options.ProxyURL)
+				}
+				return
+			}
+			if tt.options.MinTLSVersion == "1.3" && transport.TLSClientConfig.MinVersion != tls.VersionTLS13 {
+				t.Errorf("MinVersion = %x, want TLS 1.3", transport.TLSClientConfig.MinVersion)
+			}
+
+			client := &http.Client{Transport: transport, Timeout: 5 * time.Second}
+			resp, err := client.Get(server.URL)
+			if err == nil {
+				_ = resp.Body.Close()
+			}
+			if (err == nil) != tt.wantTrusted {
+				t.Errorf("GET with the test server's certificate error = %v, want trusted %v", err, tt.wantTrusted)
+			}
+		})
+	}
+}
+
+func TestNewGitHubClientHTTPClient(t *testing.T) {
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"login":"octocat"}`))
+	}))
+	defer server.Close()
+
+	calls := 0
+	base := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		calls++
+		return http.DefaultTransport.RoundTrip(req)
+	})
+	options := DefaultGitHubClientOptions()
+	options.Token = "secret"
+	options.BaseURL = server.URL + "/"
+	options.HTTPClient = &http.Client{Transport: base, Timeout: 7 * time.Second}
+	client := NewGitHubClient(options)
+
+	if _, _, err := client.Users.Get(t.Context(), ""); err != nil {
+		t.Fatalf("Users.Get() error = %v", err)
+	}
+	if calls != 1 || authorization != "Bearer secret" {
+		t.Errorf("custom transport calls = %d, authorization = %q", calls, authorization)
+	}
+	if timeout := client.Client().Timeout; timeout != 7*time.Second {
+		t.Errorf("timeout = %v, want the custom client's 7s", timeout)
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
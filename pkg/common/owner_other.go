@@ -0,0 +1,10 @@
+//go:build !unix
+
+package common
+
+import "os"
+
+// chownLike does nothing where files have no Unix owner and group
+func chownLike(path string, info os.FileInfo) error {
+	return nil
+}
@@ -0,0 +1,17 @@
+//go:build unix
+
+package common
+
+import (
+	"os"
+	"syscall"
+)
+
+// chownLike gives path the owner and group of the file described by info
+func chownLike(path string, info os.FileInfo) error {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+	return os.Lchown(path, int(stat.Uid), int(stat.Gid))
+}
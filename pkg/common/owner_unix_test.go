@@ -0,0 +1,60 @@
+//go:build unix
+
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestWriteFileWithOptionsSetgidDirectory(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Chmod(dir, 0750|os.ModeSetgid); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "file.txt")
+	if err := os.WriteFile(path, []byte("old"), 0640); err != nil {
+		t.Fatal(err)
+	}
+
+	// Give the file a group other than the directory's, which a rewrite in a
+	// setgid directory would otherwise replace
+	dirGID := gid(t, dir)
+	fileGID := dirGID + 1
+	if err := os.Chown(path, -1, fileGID); err != nil {
+		t.Skipf("cannot change the group of files: %v", err)
+	}
+
+	options := DefaultFileOptions()
+	options.PreserveMode = true
+	if err := WriteFileWithOptions(path, []byte("new"), options); err != nil {
+		t.Fatalf("WriteFileWithOptions() error = %v", err)
+	}
+	if got := gid(t, path); got != fileGID {
+		t.Errorf("group = %d, want %d", got, fileGID)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0640 {
+		t.Errorf("mode = %v, %v, want 0640", info.Mode().Perm(), err)
+	}
+
+	// Without PreserveMode the new file takes the directory's group
+	options.PreserveMode = false
+	if err := WriteFileWithOptions(path, []byte("newer"), options); err != nil {
+		t.Fatalf("WriteFileWithOptions() error = %v", err)
+	}
+	if got := gid(t, path); got != dirGID {
+		t.Errorf("group without PreserveMode = %d, want %d", got, dirGID)
+	}
+}
+
+// gid returns the group of path
+func gid(t *testing.T, path string) int {
+	t.Helper()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return int(info.Sys().(*syscall.Stat_t).Gid)
+}
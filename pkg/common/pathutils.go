@@ -78,18 +78,21 @@ func ValidatePath(baseDir, path string, options PathValidationOptions) error {
 		return fmt.Errorf(ErrFailedToResolvePath, err)
 	}
 
-	// Check if path is within base directory
-	if !strings.HasPrefix(absPath, absBase) {
-		return fmt.Errorf(ErrPathOutsideAllowedDir, path)
-	}
-
-	// Check for path traversal attempts
+	// Check if path is within base directory. filepath.Rel compares Windows
+	// volumes and separators correctly, where a string prefix would not.
 	rel, err := filepath.Rel(absBase, absPath)
 	if err != nil {
+		if filepath.VolumeName(absBase) != filepath.VolumeName(absPath) {
+			return fmt.Errorf(ErrPathOutsideAllowedDir, path)
+		}
 		return fmt.Errorf(ErrFailedToDetermineRelPath, err)
 	}
+	if !relWithin(rel) {
+		return fmt.Errorf(ErrPathOutsideAllowedDir, path)
+	}
 
-	if strings.HasPrefix(rel, "..") || strings.HasPrefix(rel, "/") {
+	// Check for path traversal attempts
+	if filepath.IsAbs(rel) || filepath.VolumeName(rel) != "" {
 		return errors.New(ErrPathTraversalDetected)
 	}
 
@@ -122,7 +125,7 @@ func ValidatePath(baseDir, path string, options PathValidationOptions) error {
 
 			// Check if the resolved symlink target is within the resolved base directory
 			relPath, err := filepath.Rel(absEvalBase, absEvalPath)
-			if err != nil || strings.HasPrefix(relPath, "..") {
+			if err != nil || !relWithin(relPath) {
 				return fmt.Errorf(ErrSymlinkOutsideAllowedDir, path)
 			}
 		}
@@ -145,6 +148,19 @@ func ValidatePath(baseDir, path string, options PathValidationOptions) error {
 	return nil
 }
 
+// IsWithinDir reports whether path lies in the directory base, or is base
+// itself, after cleaning both. It works on Windows paths as well, where
+// drive letters and separators differ between spellings of one path.
+func IsWithinDir(base, path string) bool {
+	rel, err := filepath.Rel(base, path)
+	return err == nil && relWithin(rel)
+}
+
+// relWithin reports whether a path relative to a directory stays inside it
+func relWithin(rel string) bool {
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
 // ValidatePathWithDefaults validates a path using default options
 func ValidatePathWithDefaults(baseDir, path string) error {
 	return ValidatePath(baseDir, path, DefaultPathValidationOptions())
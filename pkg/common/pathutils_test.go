@@ -251,6 +251,26 @@ func TestIsPathSafe(t *testing.T) {
 	}
 }
 
+func TestIsWithinDir(t *testing.T) {
+	base := filepath.Join("repo", "project")
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{path: base, want: true},
+		{path: filepath.Join(base, ".github", "workflows", "ci.yml"), want: true},
+		{path: filepath.Join(base, "..file.yml"), want: true},
+		{path: filepath.Join(base, "..", "project2", "ci.yml")},
+		{path: filepath.Join(base, "..")},
+		{path: "repo"},
+	}
+	for _, tt := range tests {
+		if got := IsWithinDir(base, tt.path); got != tt.want {
+			t.Errorf("IsWithinDir(%q, %q) = %v, want %v", base, tt.path, got, tt.want)
+		}
+	}
+}
+
 func TestJoinAndValidatePath(t *testing.T) {
 	// Create a temporary directory for testing
 	tempDir, err := os.MkdirTemp("", "pathutils-test")
@@ -0,0 +1,134 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BudgetTransport is an http.RoundTripper that keeps a reserve of core API
+// requests for other workloads sharing the same token. Once the remaining
+// core requests drop below Floor, requests either fail with
+// ErrRateLimitBudget or, when Wait is set, pause until the limit resets.
+type BudgetTransport struct {
+	// Base is the underlying transport (http.DefaultTransport if nil)
+	Base http.RoundTripper
+	// Floor is the number of core requests to leave for other workloads
+	Floor int
+	// Wait pauses until the rate limit resets instead of failing
+	Wait bool
+
+	mu        sync.Mutex
+	remaining int // -1 until the first response has been observed
+	reset     time.Time
+
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// NewBudgetTransport creates a BudgetTransport around base
+func NewBudgetTransport(base http.RoundTripper, floor int, wait bool) *BudgetTransport {
+	return &BudgetTransport{
+		Base:      base,
+		Floor:     floor,
+		Wait:      wait,
+		remaining: -1,
+		now:       time.Now,
+		sleep:     sleepContext,
+	}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *BudgetTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	core := isCoreRequest(req)
+	if core {
+		if err := t.reserve(req.Context()); err != nil {
+			return nil, err
+		}
+	}
+
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	resp, err := base.RoundTrip(req)
+	if err == nil && core {
+		t.observe(resp)
+	}
+	return resp, err
+}
+
+// Remaining returns the last observed number of remaining core requests, or
+// -1 if no response has been seen yet
+func (t *BudgetTransport) Remaining() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.remaining
+}
+
+// reserve blocks or fails while the remaining budget is below the floor
+func (t *BudgetTransport) reserve(ctx context.Context) error {
+	t.mu.Lock()
+	remaining, reset := t.remaining, t.reset
+	t.mu.Unlock()
+
+	if remaining < 0 || remaining >= t.Floor {
+		return nil
+	}
+	wait := reset.Sub(t.now())
+	if wait <= 0 {
+		return nil
+	}
+	if !t.Wait {
+		return &RateLimitError{Reset: reset, Err: fmt.Errorf(ErrRateLimitBudget, remaining, t.Floor, reset.Format(time.RFC3339))}
+	}
+
+	// Give GitHub a moment past the reset time before resuming
+	if err := t.sleep(ctx, wait+time.Second); err != nil {
+		return err
+	}
+	t.mu.Lock()
+	t.remaining = -1
+	t.mu.Unlock()
+	return nil
+}
+
+// observe records the rate limit headers of a core API response
+func (t *BudgetTransport) observe(resp *http.Response) {
+	remaining, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+	resetUnix, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return
+	}
+
+	t.mu.Lock()
+	t.remaining = remaining
+	t.reset = time.Unix(resetUnix, 0)
+	t.mu.Unlock()
+}
+
+// isCoreRequest reports whether a request counts against the core rate
+// limit rather than the separate search or GraphQL limits
+func isCoreRequest(req *http.Request) bool {
+	path := strings.TrimPrefix(req.URL.Path, "/api/v3")
+	return !strings.HasPrefix(path, "/search/") && !strings.HasPrefix(path, "/graphql") && !strings.HasPrefix(path, "/api/graphql")
+}
+
+// sleepContext sleeps for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,98 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestBudgetTransport(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	remaining := 1000
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		remaining--
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(now.Add(10*time.Minute).Unix(), 10))
+		fmt.Fprint(w, "{}")
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		wait       bool
+		wantErr    bool
+		wantSleeps int
+	}{
+		{name: "fails below floor", wait: false, wantErr: true},
+		{name: "waits for reset below floor", wait: true, wantSleeps: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			remaining = 1000
+			var slept []time.Duration
+			transport := NewBudgetTransport(nil, 999, tt.wait)
+			transport.now = func() time.Time { return now }
+			transport.sleep = func(ctx context.Context, d time.Duration) error {
+				slept = append(slept, d)
+				return nil
+			}
+			client := &http.Client{Transport: transport}
+
+			// The first request is allowed and reveals that 999 remain
+			resp, err := client.Get(server.URL + "/repos/o/r")
+			if err != nil {
+				t.Fatalf("first request error = %v", err)
+			}
+			_ = resp.Body.Close()
+			if transport.Remaining() != 999 {
+				t.Fatalf("Remaining() = %d, want 999", transport.Remaining())
+			}
+
+			// Dropping to 998 puts the budget below the floor
+			resp, err = client.Get(server.URL + "/repos/o/r")
+			if err != nil {
+				t.Fatalf("second request error = %v", err)
+			}
+			_ = resp.Body.Close()
+
+			resp, err = client.Get(server.URL + "/repos/o/r")
+			if resp != nil {
+				_ = resp.Body.Close()
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("third request error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(slept) != tt.wantSleeps {
+				t.Errorf("slept %d times, want %d", len(slept), tt.wantSleeps)
+			}
+			if tt.wantSleeps > 0 && slept[0] < 10*time.Minute {
+				t.Errorf("slept %v, want at least until reset", slept[0])
+			}
+
+			// Search requests use a separate limit and are never held back
+			resp, err = client.Get(server.URL + "/search/code")
+			if err != nil {
+				t.Errorf("search request error = %v", err)
+			} else {
+				_ = resp.Body.Close()
+			}
+		})
+	}
+}
+
+func TestBudgetTransportResumesAfterReset(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	transport := NewBudgetTransport(nil, 100, false)
+	transport.now = func() time.Time { return now }
+	transport.remaining = 5
+	transport.reset = now.Add(-time.Second)
+
+	if err := transport.reserve(context.Background()); err != nil {
+		t.Errorf("reserve() after reset error = %v", err)
+	}
+}
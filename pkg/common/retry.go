@@ -0,0 +1,117 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/google/go-github/v72/github"
+)
+
+// RetryPolicy retries operations that failed with a transient error
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts; 1 or less disables retries
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry; it doubles per retry
+	BaseDelay time.Duration
+	// MaxDelay caps the delay between attempts
+	MaxDelay time.Duration
+	// Jitter randomizes each delay by up to this fraction (0 to 1)
+	Jitter float64
+	// Retryable decides which errors are retried; it defaults to IsTransientError
+	Retryable func(error) bool
+
+	sleep func(ctx context.Context, d time.Duration) error // For testing
+}
+
+// DefaultRetryPolicy returns the policy used by the CLI
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   time.Second,
+		MaxDelay:    30 * time.Second,
+		Jitter:      0.25,
+	}
+}
+
+// Do calls fn until it succeeds, fails with an error that is not retryable,
+// the attempts are used up or ctx is done. It returns the last error.
+func (p RetryPolicy) Do(ctx context.Context, fn func() error) error {
+	sleep := p.sleep
+	if sleep == nil {
+		sleep = sleepContext
+	}
+	retryable := p.Retryable
+	if retryable == nil {
+		retryable = IsTransientError
+	}
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = fn()
+		if err == nil || attempt >= p.MaxAttempts || !retryable(err) {
+			return err
+		}
+		delay := p.delay(attempt, err)
+		log.Printf(ErrRetryingRequest, err, delay.Round(time.Millisecond), attempt+1, p.MaxAttempts)
+		if sleepErr := sleep(ctx, delay); sleepErr != nil {
+			return err
+		}
+	}
+}
+
+// delay returns the wait before the given retry. A secondary rate limit's
+// Retry-After takes precedence over the exponential backoff.
+func (p RetryPolicy) delay(attempt int, err error) time.Duration {
+	var abuseErr *github.AbuseRateLimitError
+	if errors.As(err, &abuseErr) && abuseErr.RetryAfter != nil && *abuseErr.RetryAfter > 0 {
+		return *abuseErr.RetryAfter
+	}
+
+	delay := p.BaseDelay << (attempt - 1)
+	if delay <= 0 || (p.MaxDelay > 0 && delay > p.MaxDelay) {
+		delay = p.MaxDelay
+	}
+	if p.Jitter > 0 {
+		jitter := (rand.Float64()*2 - 1) * p.Jitter * float64(delay) // #nosec G404 - Non-cryptographic randomness is acceptable for timing jitter
+		delay += time.Duration(jitter)
+	}
+	if delay < 0 {
+		delay = 0
+	}
+	return delay
+}
+
+// IsTransientError reports whether an API call failed in a way that may
+// succeed on retry: a 5xx response, a secondary rate limit or a network
+// failure. Primary rate limits, client errors and cancellation are final.
+func IsTransientError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var abuseErr *github.AbuseRateLimitError
+	if errors.As(err, &abuseErr) {
+		return true
+	}
+	var rateErr *github.RateLimitError
+	if errors.As(err, &rateErr) {
+		return false
+	}
+	if status := APIErrorStatus(err); status != 0 {
+		return status >= http.StatusInternalServerError
+	}
+
+	var opErr *net.OpError
+	if errors.As(err, &opErr) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var urlErr *url.Error
+	return errors.As(err, &urlErr) && urlErr.Timeout()
+}
@@ -0,0 +1,123 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v72/github"
+)
+
+// apiError builds a GitHub API error with the given status
+func apiError(status int) error {
+	return &github.ErrorResponse{Response: &http.Response{StatusCode: status}, Message: http.StatusText(status)}
+}
+
+func TestIsTransientError(t *testing.T) {
+	retryAfter := 2 * time.Second
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "bad gateway", err: apiError(http.StatusBadGateway), want: true},
+		{name: "wrapped server error", err: fmt.Errorf("lookup: %w", apiError(http.StatusInternalServerError)), want: true},
+		{name: "not found", err: apiError(http.StatusNotFound), want: false},
+		{name: "forbidden", err: apiError(http.StatusForbidden), want: false},
+		{name: "secondary rate limit", err: &github.AbuseRateLimitError{RetryAfter: &retryAfter}, want: true},
+		{name: "primary rate limit", err: &github.RateLimitError{}, want: false},
+		{name: "connection reset", err: &url.Error{Op: "Get", URL: "https://api.github.com", Err: &net.OpError{Op: "read", Err: errors.New("connection reset by peer")}}, want: true},
+		{name: "unexpected EOF", err: &url.Error{Op: "Get", URL: "https://api.github.com", Err: io.ErrUnexpectedEOF}, want: true},
+		{name: "budget reached", err: &url.Error{Op: "Get", URL: "https://api.github.com", Err: fmt.Errorf(ErrRateLimitBudget, 1, 10, "soon")}, want: false},
+		{name: "cancelled", err: &url.Error{Op: "Get", URL: "https://api.github.com", Err: context.Canceled}, want: false},
+		{name: "plain error", err: errors.New("boom"), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsTransientError(tt.err); got != tt.want {
+				t.Errorf("IsTransientError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyDo(t *testing.T) {
+	retryAfter := 5 * time.Second
+	tests := []struct {
+		name       string
+		errs       []error
+		wantCalls  int
+		wantDelays []time.Duration
+		wantErr    bool
+		retryable  func(error) bool
+	}{
+		{name: "success", errs: nil, wantCalls: 1},
+		{name: "recovers", errs: []error{apiError(502), apiError(503)}, wantCalls: 3, wantDelays: []time.Duration{time.Second, 2 * time.Second}},
+		{name: "gives up", errs: []error{apiError(502), apiError(502), apiError(502), apiError(502)}, wantCalls: 3, wantDelays: []time.Duration{time.Second, 2 * time.Second}, wantErr: true},
+		{name: "final error", errs: []error{apiError(404)}, wantCalls: 1, wantErr: true},
+		{name: "retry after", errs: []error{&github.AbuseRateLimitError{RetryAfter: &retryAfter}}, wantCalls: 2, wantDelays: []time.Duration{retryAfter}},
+		{name: "custom retryable", errs: []error{apiError(404)}, wantCalls: 2, wantDelays: []time.Duration{time.Second}, retryable: func(error) bool { return true }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var delays []time.Duration
+			policy := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Second, MaxDelay: 30 * time.Second, Retryable: tt.retryable}
+			policy.sleep = func(ctx context.Context, d time.Duration) error {
+				delays = append(delays, d)
+				return nil
+			}
+
+			calls := 0
+			err := policy.Do(context.Background(), func() error {
+				calls++
+				if calls <= len(tt.errs) {
+					return tt.errs[calls-1]
+				}
+				return nil
+			})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Do() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", calls, tt.wantCalls)
+			}
+			if fmt.Sprint(delays) != fmt.Sprint(tt.wantDelays) {
+				t.Errorf("delays = %v, want %v", delays, tt.wantDelays)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyJitterAndCancel(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 5, BaseDelay: 4 * time.Second, MaxDelay: 10 * time.Second, Jitter: 0.5}
+	for attempt := 1; attempt <= 4; attempt++ {
+		want := 4 * time.Second << (attempt - 1)
+		if want > 10*time.Second {
+			want = 10 * time.Second
+		}
+		for i := 0; i < 20; i++ {
+			if d := policy.delay(attempt, apiError(502)); d < want/2 || d > want*3/2 {
+				t.Fatalf("delay(%d) = %v, want within 50%% of %v", attempt, d, want)
+			}
+		}
+	}
+
+	// A cancelled context stops retrying
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	calls := 0
+	err := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Hour}.Do(ctx, func() error {
+		calls++
+		return apiError(502)
+	})
+	if err == nil || calls != 1 {
+		t.Errorf("Do() = %v after %d calls, want the first error", err, calls)
+	}
+}
@@ -0,0 +1,262 @@
+package common
+
+import (
+	"errors"
+	"io/fs"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/google/go-github/v72/github"
+)
+
+// ErrorCategory classifies errors so the CLI can exit with a distinct code
+// and add a hint on what to do about them
+type ErrorCategory int
+
+// Error categories
+const (
+	CategoryUnknown ErrorCategory = iota
+	CategoryAuth
+	CategoryRateLimit
+	CategoryNotFound
+	CategoryValidation
+	CategoryFileSystem
+)
+
+// Sentinel errors matching every error of a category with errors.Is
+var (
+	ErrAuth       = errors.New("authentication failed")
+	ErrRateLimit  = errors.New("rate limit exceeded")
+	ErrNotFound   = errors.New("not found")
+	ErrValidation = errors.New("invalid input")
+	ErrFileSystem = errors.New("file system error")
+)
+
+var categoryNames = map[ErrorCategory]string{
+	CategoryUnknown:    "error",
+	CategoryAuth:       "authentication error",
+	CategoryRateLimit:  "rate limit error",
+	CategoryNotFound:   "not found",
+	CategoryValidation: "invalid input",
+	CategoryFileSystem: "file system error",
+}
+
+var categoryHints = map[ErrorCategory]string{
+	CategoryAuth:       "check that the token is valid and has the required scopes; ghactions-updater check-auth lists what is missing",
+	CategoryRateLimit:  "wait for the rate limit to reset, or reduce API usage with -store, -graphql or a token with a higher limit",
+	CategoryNotFound:   "check the owner, repository and action names, and that the token can see private repositories",
+	CategoryValidation: "run ghactions-updater -help for the accepted flags and values",
+	CategoryFileSystem: "check that the path exists and is readable and writable",
+}
+
+// String returns the name of c as used in messages
+func (c ErrorCategory) String() string {
+	return categoryNames[c]
+}
+
+// Hint returns advice on resolving errors of category c, or ""
+func (c ErrorCategory) Hint() string {
+	return categoryHints[c]
+}
+
+// ExitCode returns the process exit code for errors of category c
+func (c ErrorCategory) ExitCode() int {
+	switch c {
+	case CategoryValidation:
+		return 2
+	case CategoryAuth:
+		return 3
+	case CategoryRateLimit:
+		return 4
+	case CategoryNotFound:
+		return 5
+	case CategoryFileSystem:
+		return 6
+	}
+	return 1
+}
+
+// AuthError is returned when the API rejects the token or its permissions
+type AuthError struct {
+	Err error
+}
+
+// Error implements error
+func (e *AuthError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *AuthError) Unwrap() error {
+	return e.Err
+}
+
+// Is matches ErrAuth
+func (e *AuthError) Is(target error) bool {
+	return target == ErrAuth
+}
+
+// ErrorCategory returns CategoryAuth
+func (e *AuthError) ErrorCategory() ErrorCategory {
+	return CategoryAuth
+}
+
+// RateLimitError is returned when the API rate limit, or the share of it
+// the run may use, is exhausted
+type RateLimitError struct {
+	Reset time.Time // When the limit resets, if known
+	Err   error
+}
+
+// Error implements error
+func (e *RateLimitError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *RateLimitError) Unwrap() error {
+	return e.Err
+}
+
+// Is matches ErrRateLimit
+func (e *RateLimitError) Is(target error) bool {
+	return target == ErrRateLimit
+}
+
+// ErrorCategory returns CategoryRateLimit
+func (e *RateLimitError) ErrorCategory() ErrorCategory {
+	return CategoryRateLimit
+}
+
+// NotFoundError is returned when a repository, action or version does not
+// exist or is not visible
+type NotFoundError struct {
+	Resource string
+	Err      error
+}
+
+// Error implements error
+func (e *NotFoundError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *NotFoundError) Unwrap() error {
+	return e.Err
+}
+
+// Is matches ErrNotFound
+func (e *NotFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+// ErrorCategory returns CategoryNotFound
+func (e *NotFoundError) ErrorCategory() ErrorCategory {
+	return CategoryNotFound
+}
+
+// ValidationError is returned for invalid flags, configuration or input
+type ValidationError struct {
+	Field string // Flag or setting at fault, if known
+	Err   error
+}
+
+// Error implements error
+func (e *ValidationError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// Is matches ErrValidation
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// ErrorCategory returns CategoryValidation
+func (e *ValidationError) ErrorCategory() ErrorCategory {
+	return CategoryValidation
+}
+
+// FileSystemError is returned when reading or writing local files fails
+type FileSystemError struct {
+	Path string
+	Err  error
+}
+
+// Error implements error
+func (e *FileSystemError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *FileSystemError) Unwrap() error {
+	return e.Err
+}
+
+// Is matches ErrFileSystem
+func (e *FileSystemError) Is(target error) bool {
+	return target == ErrFileSystem
+}
+
+// ErrorCategory returns CategoryFileSystem
+func (e *FileSystemError) ErrorCategory() ErrorCategory {
+	return CategoryFileSystem
+}
+
+// CategoryOf returns the category of err: that of the outermost typed error
+// in its chain, or else one derived from the API and file system errors it
+// wraps
+func CategoryOf(err error) ErrorCategory {
+	if err == nil {
+		return CategoryUnknown
+	}
+	var categorized interface{ ErrorCategory() ErrorCategory }
+	if errors.As(err, &categorized) {
+		return categorized.ErrorCategory()
+	}
+
+	var pathErr *fs.PathError
+	var linkErr *os.LinkError
+	switch {
+	case IsRateLimitError(err):
+		return CategoryRateLimit
+	case APIErrorStatus(err) == http.StatusUnauthorized, APIErrorStatus(err) == http.StatusForbidden:
+		return CategoryAuth
+	case APIErrorStatus(err) == http.StatusNotFound:
+		return CategoryNotFound
+	case errors.As(err, &pathErr), errors.As(err, &linkErr):
+		return CategoryFileSystem
+	}
+	return CategoryUnknown
+}
+
+// Categorize wraps err in the typed error of its category, so errors.Is
+// matches the category sentinels. Errors of no known category, and typed
+// errors, are returned unchanged.
+func Categorize(err error) error {
+	var categorized interface{ ErrorCategory() ErrorCategory }
+	if err == nil || errors.As(err, &categorized) {
+		return err
+	}
+	switch CategoryOf(err) {
+	case CategoryAuth:
+		return &AuthError{Err: err}
+	case CategoryRateLimit:
+		rateErr := &RateLimitError{Err: err}
+		var ghErr *github.RateLimitError
+		if errors.As(err, &ghErr) {
+			rateErr.Reset = ghErr.Rate.Reset.Time
+		}
+		return rateErr
+	case CategoryNotFound:
+		return &NotFoundError{Err: err}
+	case CategoryFileSystem:
+		return &FileSystemError{Err: err}
+	}
+	return err
+}
@@ -0,0 +1,78 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v72/github"
+)
+
+func TestCategoryOf(t *testing.T) {
+	apiError := func(status int) error {
+		return fmt.Errorf("lookup failed: %w", &github.ErrorResponse{Response: &http.Response{StatusCode: status}})
+	}
+	reset := time.Now().Add(time.Hour).Truncate(time.Second)
+	rateLimited := &github.RateLimitError{
+		Rate:     github.Rate{Reset: github.Timestamp{Time: reset}},
+		Response: &http.Response{StatusCode: http.StatusForbidden, Request: &http.Request{Method: http.MethodGet, URL: &url.URL{Path: "/repos/o/r"}}},
+	}
+	_, pathErr := os.ReadFile("/nonexistent/workflow.yml")
+
+	tests := []struct {
+		name     string
+		err      error
+		want     ErrorCategory
+		sentinel error
+		exitCode int
+	}{
+		{name: "nil", err: nil, want: CategoryUnknown, exitCode: 1},
+		{name: "plain", err: errors.New("boom"), want: CategoryUnknown, exitCode: 1},
+		{name: "unauthorized", err: apiError(http.StatusUnauthorized), want: CategoryAuth, sentinel: ErrAuth, exitCode: 3},
+		{name: "forbidden", err: apiError(http.StatusForbidden), want: CategoryAuth, sentinel: ErrAuth, exitCode: 3},
+		{name: "not found", err: apiError(http.StatusNotFound), want: CategoryNotFound, sentinel: ErrNotFound, exitCode: 5},
+		{name: "rate limit", err: rateLimited, want: CategoryRateLimit, sentinel: ErrRateLimit, exitCode: 4},
+		{name: "path error", err: fmt.Errorf("reading workflow: %w", pathErr), want: CategoryFileSystem, sentinel: ErrFileSystem, exitCode: 6},
+		{name: "validation", err: fmt.Errorf("parsing flags: %w", &ValidationError{Field: "owner", Err: errors.New("missing")}), want: CategoryValidation, sentinel: ErrValidation, exitCode: 2},
+		{name: "access denied", err: &AccessError{Kind: AccessDenied, Err: errors.New("denied")}, want: CategoryAuth, sentinel: ErrAuth, exitCode: 3},
+		{name: "repository not found", err: &AccessError{Kind: RepositoryNotFound, Err: errors.New("missing")}, want: CategoryNotFound, sentinel: ErrNotFound, exitCode: 5},
+		// The outermost typed error wins over the API error it wraps
+		{name: "typed over API", err: &ValidationError{Err: apiError(http.StatusNotFound)}, want: CategoryValidation, sentinel: ErrValidation, exitCode: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CategoryOf(tt.err)
+			if got != tt.want {
+				t.Errorf("CategoryOf() = %s, want %s", got, tt.want)
+			}
+			if code := got.ExitCode(); code != tt.exitCode {
+				t.Errorf("ExitCode() = %d, want %d", code, tt.exitCode)
+			}
+			categorized := Categorize(tt.err)
+			if tt.sentinel == nil {
+				if categorized != tt.err {
+					t.Errorf("Categorize() = %v, want the error unchanged", categorized)
+				}
+				return
+			}
+			if !errors.Is(categorized, tt.sentinel) {
+				t.Errorf("Categorize() = %v, want it to match %v", categorized, tt.sentinel)
+			}
+			if categorized.Error() != tt.err.Error() {
+				t.Errorf("Categorize() message = %q, want %q", categorized.Error(), tt.err.Error())
+			}
+			if !errors.Is(categorized, tt.err) {
+				t.Error("Categorize() does not wrap the original error")
+			}
+		})
+	}
+
+	var rateErr *RateLimitError
+	if err := Categorize(rateLimited); !errors.As(err, &rateErr) || !rateErr.Reset.Equal(reset) {
+		t.Errorf("Categorize() of a rate limit error = %v, want reset at %v", err, reset)
+	}
+}
@@ -0,0 +1,117 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+	"github.com/google/go-github/v72/github"
+)
+
+// DefaultCentralPath is the file read from a central repository when the
+// source names no path
+const DefaultCentralPath = "ghactions-updater.yml"
+
+// Source is a configuration file kept in a central repository, e.g. the
+// org/.github repository, so one policy governs every repository of an
+// organization
+type Source struct {
+	Owner string
+	Repo  string
+	Path  string
+	Ref   string // Branch, tag or commit; the default branch when empty
+}
+
+// ParseSource parses owner/repo[/path][@ref], e.g. "my-org/.github" or
+// "my-org/policies/updater.yml@main"
+func ParseSource(spec string) (Source, error) {
+	name, ref, _ := strings.Cut(spec, "@")
+	parts := strings.SplitN(name, "/", 3)
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" || (len(parts) == 3 && parts[2] == "") {
+		return Source{}, fmt.Errorf(common.ErrInvalidConfigSource, spec)
+	}
+	src := Source{Owner: parts[0], Repo: parts[1], Path: DefaultCentralPath, Ref: ref}
+	if len(parts) == 3 {
+		src.Path = parts[2]
+	}
+	return src, nil
+}
+
+// String returns the source as owner/repo/path[@ref]
+func (s Source) String() string {
+	name := s.Owner + "/" + s.Repo + "/" + s.Path
+	if s.Ref != "" {
+		name += "@" + s.Ref
+	}
+	return name
+}
+
+// cacheFile returns the file caching the source in cacheDir
+func (s Source) cacheFile(cacheDir string) string {
+	name := strings.NewReplacer("/", "_", "@", "_", "\\", "_").Replace(s.String())
+	return filepath.Join(cacheDir, name)
+}
+
+// Fetch loads the configuration file of src from GitHub. When cacheDir is
+// set, a copy fetched less than ttl ago is used without an API call, and an
+// older copy is used, with a warning, when the file cannot be fetched.
+func Fetch(ctx context.Context, client *github.Client, src Source, cacheDir string, ttl time.Duration) (*Config, error) {
+	var cached []byte
+	if cacheDir != "" {
+		cacheFile := src.cacheFile(cacheDir)
+		if info, err := os.Stat(cacheFile); err == nil {
+			// #nosec G304 - the cache file name is derived from the source
+			if cached, err = os.ReadFile(cacheFile); err == nil && time.Since(info.ModTime()) < ttl {
+				return Parse(cached, src.String())
+			}
+		}
+	}
+
+	var opts *github.RepositoryContentGetOptions
+	if src.Ref != "" {
+		opts = &github.RepositoryContentGetOptions{Ref: src.Ref}
+	}
+	content, err := fetchContent(ctx, client, src, opts)
+	if err != nil {
+		if cached == nil {
+			return nil, err
+		}
+		cfg, parseErr := Parse(cached, src.String())
+		if parseErr != nil {
+			return nil, err
+		}
+		cfg.Warnings = append(cfg.Warnings, fmt.Sprintf(common.ErrUsingCachedConfig, err))
+		return cfg, nil
+	}
+
+	cfg, err := Parse(content, src.String())
+	if err != nil {
+		return nil, err
+	}
+	if cacheDir != "" {
+		if err := common.WriteFileWithOptions(src.cacheFile(cacheDir), content, common.DefaultFileOptions()); err != nil {
+			cfg.Warnings = append(cfg.Warnings, fmt.Sprintf(common.ErrCachingConfig, src, err))
+		}
+	}
+	return cfg, nil
+}
+
+// fetchContent downloads the content of the configuration file of src
+func fetchContent(ctx context.Context, client *github.Client, src Source, opts *github.RepositoryContentGetOptions) ([]byte, error) {
+	file, _, _, err := client.Repositories.GetContents(ctx, src.Owner, src.Repo, src.Path, opts)
+	if err != nil {
+		return nil, fmt.Errorf(common.ErrFetchingConfig, src, err)
+	}
+	if file == nil {
+		return nil, fmt.Errorf(common.ErrFetchingConfig, src, fmt.Errorf(common.ErrConfigNotFile))
+	}
+	content, err := file.GetContent()
+	if err != nil {
+		return nil, fmt.Errorf(common.ErrFetchingConfig, src, err)
+	}
+	return []byte(content), nil
+}
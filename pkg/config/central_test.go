@@ -0,0 +1,88 @@
+package config
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v72/github"
+)
+
+func TestParseSource(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    Source
+		wantErr bool
+	}{
+		{spec: "my-org/.github", want: Source{Owner: "my-org", Repo: ".github", Path: DefaultCentralPath}},
+		{spec: "my-org/policies/ci/updater.yml@v1", want: Source{Owner: "my-org", Repo: "policies", Path: "ci/updater.yml", Ref: "v1"}},
+		{spec: "my-org", wantErr: true},
+		{spec: "my-org/", wantErr: true},
+		{spec: "my-org/.github/", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := ParseSource(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSource(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseSource(%q) = %+v, want %+v", tt.spec, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFetch(t *testing.T) {
+	requests, status := 0, http.StatusOK
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/my-org/.github/contents/ghactions-updater.yml", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Query().Get("ref") != "main" {
+			t.Errorf("ref = %q, want main", r.URL.Query().Get("ref"))
+		}
+		w.WriteHeader(status)
+		content := base64.StdEncoding.EncodeToString([]byte("version: 1\ndry-run: true\n"))
+		fmt.Fprintf(w, `{"type":"file","encoding":"base64","content":"%s"}`, content)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	src := Source{Owner: "my-org", Repo: ".github", Path: DefaultCentralPath, Ref: "main"}
+	cacheDir := t.TempDir()
+	ctx := context.Background()
+
+	cfg, err := Fetch(ctx, client, src, cacheDir, time.Hour)
+	if err != nil || cfg.Settings["dry-run"] != "true" {
+		t.Fatalf("Fetch() = %+v, %v", cfg, err)
+	}
+	// Served from the cache
+	if _, err := Fetch(ctx, client, src, cacheDir, time.Hour); err != nil || requests != 1 {
+		t.Errorf("cached Fetch() error = %v after %d requests, want 1", err, requests)
+	}
+
+	// A stale copy is only used when the API fails
+	stale := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(src.cacheFile(cacheDir), stale, stale); err != nil {
+		t.Fatal(err)
+	}
+	status = http.StatusInternalServerError
+	cfg, err = Fetch(ctx, client, src, cacheDir, time.Hour)
+	if err != nil || requests != 2 || len(cfg.Warnings) != 1 || !strings.Contains(cfg.Warnings[0], "cached central config") {
+		t.Errorf("Fetch() with stale cache = %+v, %v after %d requests", cfg, err, requests)
+	}
+
+	if _, err := Fetch(ctx, client, src, filepath.Join(t.TempDir(), "empty"), time.Hour); err == nil || !strings.Contains(err.Error(), "error fetching config my-org/.github/ghactions-updater.yml@main") {
+		t.Errorf("Fetch() without cache error = %v", err)
+	}
+}
@@ -0,0 +1,243 @@
+// Package config loads the optional configuration file of the updater and
+// migrates files written for earlier schema versions.
+//
+// A configuration file sets command line flags by name:
+//
+//	version: 1
+//	workflows-path: .github/workflows
+//	dry-run: true
+//	action-token-env: [my-org=MY_ORG_TOKEN, other/repo=OTHER_TOKEN]
+//
+// Lists are joined with commas. Flags given on the command line take
+// precedence over the file. Fetch reads a file kept in a central repository.
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultFile is the conventional name of the configuration file
+const DefaultFile = ".ghactions-updater.yml"
+
+// versionKey is the key holding the schema version
+const versionKey = "version"
+
+// Migration upgrades a file from one schema version to the next
+type Migration struct {
+	Renames map[string]string // Old key -> new key
+	Removed map[string]string // Removed key -> reason, kept as a comment
+}
+
+// migrations[i] upgrades schema version i+1 to i+2. Appending a migration
+// bumps CurrentVersion.
+var migrations []Migration
+
+// CurrentVersion returns the schema version written by this release
+func CurrentVersion() int {
+	return len(migrations) + 1
+}
+
+// Config holds the settings of a configuration file
+type Config struct {
+	Version  int
+	Settings map[string]string // Flag name -> value
+	Warnings []string          // Deprecation warnings for an older schema
+}
+
+// Load reads a configuration file. Files of the previous schema version are
+// migrated in memory and a deprecation warning is recorded for each change.
+func Load(path string) (*Config, error) {
+	content, err := os.ReadFile(path) // #nosec G304 - path is provided by the user
+	if err != nil {
+		return nil, fmt.Errorf(common.ErrReadingConfig, path, err)
+	}
+	return Parse(content, path)
+}
+
+// Parse decodes the content of a configuration file, naming it name in
+// errors and warnings. Like Load, it migrates the previous schema version.
+func Parse(content []byte, name string) (*Config, error) {
+	root, version, err := parse(content)
+	if err != nil {
+		return nil, fmt.Errorf(common.ErrParsingConfig, name, err)
+	}
+
+	cfg := &Config{Version: version, Settings: make(map[string]string)}
+	switch {
+	case version == CurrentVersion():
+	case version == CurrentVersion()-1:
+		changes := migrate(root, version)
+		for _, change := range changes {
+			cfg.Warnings = append(cfg.Warnings, fmt.Sprintf(common.ErrConfigDeprecated, name, version, change))
+		}
+	default:
+		return nil, fmt.Errorf(common.ErrConfigVersion, name, version, CurrentVersion()-1, CurrentVersion())
+	}
+
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i].Value, root.Content[i+1]
+		if key == versionKey {
+			continue
+		}
+		setting, err := settingValue(value)
+		if err != nil {
+			return nil, fmt.Errorf(common.ErrConfigValue, name, key, err)
+		}
+		cfg.Settings[key] = setting
+	}
+	return cfg, nil
+}
+
+// Migrate upgrades the content of a configuration file to the current schema.
+// Comments are preserved and each renamed or removed key is annotated. It
+// returns the new content and a description of every change.
+func Migrate(content []byte) ([]byte, []string, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, nil, err
+	}
+	root, version, err := rootMapping(&doc)
+	if err != nil {
+		return nil, nil, err
+	}
+	if version > CurrentVersion() || version < 1 {
+		return nil, nil, fmt.Errorf(common.ErrConfigVersion, "config", version, 1, CurrentVersion())
+	}
+
+	var changes []string
+	for v := version; v < CurrentVersion(); v++ {
+		changes = append(changes, migrate(root, v)...)
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, nil, err
+	}
+	return buf.Bytes(), changes, nil
+}
+
+// parse decodes a configuration file and returns its root mapping and version
+func parse(content []byte) (*yaml.Node, int, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, 0, err
+	}
+	return rootMapping(&doc)
+}
+
+// rootMapping returns the root mapping of a document and its schema version.
+// Files without a version key are treated as the current version.
+func rootMapping(doc *yaml.Node) (*yaml.Node, int, error) {
+	if len(doc.Content) == 0 {
+		return nil, 0, fmt.Errorf(common.ErrEmptyYAMLDocument)
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, 0, fmt.Errorf(common.ErrConfigNotMapping)
+	}
+	version := CurrentVersion()
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value != versionKey {
+			continue
+		}
+		v, err := strconv.Atoi(root.Content[i+1].Value)
+		if err != nil {
+			return nil, 0, fmt.Errorf(common.ErrConfigValue, "config", versionKey, err)
+		}
+		version = v
+	}
+	return root, version, nil
+}
+
+// migrate applies the migration from version to version+1 to root in place
+func migrate(root *yaml.Node, version int) []string {
+	migration := migrations[version-1]
+	var changes []string
+
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key := root.Content[i]
+		if newKey, ok := migration.Renames[key.Value]; ok {
+			changes = append(changes, fmt.Sprintf("%q was renamed to %q", key.Value, newKey))
+			key.HeadComment = joinComments(key.HeadComment, fmt.Sprintf("# Renamed from %s (schema version %d)", key.Value, version))
+			key.Value = newKey
+		}
+	}
+
+	// Removed keys are kept as comments on the following key
+	removed := make([]string, 0, len(migration.Removed))
+	for key := range migration.Removed {
+		removed = append(removed, key)
+	}
+	sort.Strings(removed)
+	for _, name := range removed {
+		for i := 0; i+1 < len(root.Content); i += 2 {
+			if root.Content[i].Value != name {
+				continue
+			}
+			reason := migration.Removed[name]
+			changes = append(changes, fmt.Sprintf("%q was removed: %s", name, reason))
+			comment := fmt.Sprintf("# Removed in schema version %d (%s): %s: %s", version+1, reason, name, root.Content[i+1].Value)
+			root.Content = append(root.Content[:i], root.Content[i+2:]...)
+			if i < len(root.Content) {
+				root.Content[i].HeadComment = joinComments(comment, root.Content[i].HeadComment)
+			} else {
+				root.FootComment = joinComments(root.FootComment, comment)
+			}
+			break
+		}
+	}
+
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == versionKey {
+			root.Content[i+1].Value = strconv.Itoa(version + 1)
+			root.Content[i+1].Tag = "!!int"
+		}
+	}
+	return changes
+}
+
+// settingValue converts a YAML value into a flag value
+func settingValue(node *yaml.Node) (string, error) {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		return node.Value, nil
+	case yaml.SequenceNode:
+		values := make([]string, 0, len(node.Content))
+		for _, item := range node.Content {
+			if item.Kind != yaml.ScalarNode {
+				return "", fmt.Errorf(common.ErrConfigNotScalar)
+			}
+			values = append(values, item.Value)
+		}
+		return strings.Join(values, ","), nil
+	case yaml.AliasNode:
+		return settingValue(node.Alias)
+	default:
+		return "", fmt.Errorf(common.ErrConfigNotScalar)
+	}
+}
+
+// joinComments joins two comment blocks, skipping empty ones
+func joinComments(a, b string) string {
+	switch {
+	case a == "":
+		return b
+	case b == "":
+		return a
+	default:
+		return a + "\n" + b
+	}
+}
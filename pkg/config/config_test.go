@@ -0,0 +1,160 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// withMigrations installs test migrations for the duration of a test
+func withMigrations(t *testing.T, m ...Migration) {
+	t.Helper()
+	old := migrations
+	migrations = m
+	t.Cleanup(func() { migrations = old })
+}
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), DefaultFile)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		want     map[string]string
+		warnings int
+		wantErr  string
+	}{
+		{
+			name:    "current version",
+			content: "version: 1\nworkflows-path: ci\ndry-run: true\naction-token-env: [a=A, b/c=B]\n",
+			want:    map[string]string{"workflows-path": "ci", "dry-run": "true", "action-token-env": "a=A,b/c=B"},
+		},
+		{
+			name:    "no version",
+			content: "keep-backups: true\n",
+			want:    map[string]string{"keep-backups": "true"},
+		},
+		{
+			name:    "future version",
+			content: "version: 3\n",
+			wantErr: "unsupported schema version 3",
+		},
+		{
+			name:    "nested value",
+			content: "version: 1\nstore:\n  dir: x\n",
+			wantErr: "invalid value for \"store\"",
+		},
+		{
+			name:    "not a mapping",
+			content: "- a\n",
+			wantErr: "must be a mapping",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := Load(writeConfig(t, tt.content))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Load() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if len(cfg.Settings) != len(tt.want) {
+				t.Errorf("Settings = %v, want %v", cfg.Settings, tt.want)
+			}
+			for k, v := range tt.want {
+				if cfg.Settings[k] != v {
+					t.Errorf("Settings[%q] = %q, want %q", k, cfg.Settings[k], v)
+				}
+			}
+			if len(cfg.Warnings) != tt.warnings {
+				t.Errorf("Warnings = %v", cfg.Warnings)
+			}
+		})
+	}
+}
+
+func TestLoadPreviousVersion(t *testing.T) {
+	withMigrations(t, Migration{
+		Renames: map[string]string{"workflows": "workflows-path"},
+		Removed: map[string]string{"legacy": "no longer needed"},
+	})
+
+	cfg, err := Load(writeConfig(t, "version: 1\nworkflows: ci\nlegacy: true\n"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Settings["workflows-path"] != "ci" {
+		t.Errorf("Settings = %v, want renamed workflows-path", cfg.Settings)
+	}
+	if _, ok := cfg.Settings["legacy"]; ok {
+		t.Errorf("Settings = %v, want legacy removed", cfg.Settings)
+	}
+	if len(cfg.Warnings) != 2 || !strings.Contains(cfg.Warnings[0], "deprecated schema version 1") {
+		t.Errorf("Warnings = %v", cfg.Warnings)
+	}
+
+	// Only one previous version is accepted
+	withMigrations(t, Migration{}, Migration{})
+	if _, err := Load(writeConfig(t, "version: 1\n")); err == nil {
+		t.Error("Load() of a version two releases old succeeded, want error")
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	withMigrations(t,
+		Migration{Renames: map[string]string{"workflows": "workflows-dir"}},
+		Migration{
+			Renames: map[string]string{"workflows-dir": "workflows-path"},
+			Removed: map[string]string{"legacy": "always enabled"},
+		},
+	)
+
+	in := "# Updater settings\nversion: 1\n# where workflows live\nworkflows: ci\nlegacy: true\ndry-run: true\n"
+	out, changes, err := Migrate([]byte(in))
+	if err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+	got := string(out)
+	for _, want := range []string{
+		"# Updater settings",
+		"version: 3",
+		"# where workflows live",
+		"# Renamed from workflows (schema version 1)",
+		"# Renamed from workflows-dir (schema version 2)",
+		"workflows-path: ci",
+		"# Removed in schema version 3 (always enabled): legacy: true",
+		"dry-run: true",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Migrate() output missing %q:\n%s", want, got)
+		}
+	}
+	if len(changes) != 3 {
+		t.Errorf("changes = %v, want 3", changes)
+	}
+
+	// Migrated files load without warnings
+	cfg, err := Load(writeConfig(t, got))
+	if err != nil || len(cfg.Warnings) != 0 || cfg.Settings["workflows-path"] != "ci" {
+		t.Errorf("Load(migrated) = %+v, %v", cfg, err)
+	}
+
+	// Files at the current version are unchanged
+	_, changes, err = Migrate(out)
+	if err != nil || len(changes) != 0 {
+		t.Errorf("Migrate(current) changes = %v, err = %v", changes, err)
+	}
+}
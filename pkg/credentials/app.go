@@ -0,0 +1,178 @@
+package credentials
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+	"github.com/google/go-github/v72/github"
+)
+
+// Environment variables configuring a GitHub App
+const (
+	AppIDEnv             = "GITHUB_APP_ID"
+	AppPrivateKeyEnv     = "GITHUB_APP_PRIVATE_KEY" // #nosec G101 - variable name, not a credential
+	AppInstallationIDEnv = "GITHUB_APP_INSTALLATION_ID"
+)
+
+// appJWTLifetime is how long the JWT authenticating as the app is valid;
+// GitHub accepts at most ten minutes
+const appJWTLifetime = 9 * time.Minute
+
+// App creates installation tokens of a GitHub App
+type App struct {
+	ID int64
+	// PrivateKey is the PEM encoded private key of the app
+	PrivateKey []byte
+	// InstallationID selects the installation; 0 uses the only one
+	InstallationID int64
+	// BaseURL is the API URL, "" for github.com
+	BaseURL string
+}
+
+// Name describes the app
+func (a App) Name() string {
+	return fmt.Sprintf("GitHub App %d", a.ID)
+}
+
+// Token creates an installation token, valid for an hour
+func (a App) Token(ctx context.Context) (string, error) {
+	key, err := parsePrivateKey(a.PrivateKey)
+	if err != nil {
+		return "", err
+	}
+	jwt, err := appJWT(a.ID, key, time.Now())
+	if err != nil {
+		return "", err
+	}
+	client := github.NewClient(nil).WithAuthToken(jwt)
+	if a.BaseURL != "" {
+		if client, err = client.WithEnterpriseURLs(a.BaseURL, a.BaseURL); err != nil {
+			return "", err
+		}
+	}
+
+	installation := a.InstallationID
+	if installation == 0 {
+		installations, _, err := client.Apps.ListInstallations(ctx, &github.ListOptions{PerPage: 2})
+		if err != nil {
+			return "", fmt.Errorf(common.ErrListingInstallations, a.ID, err)
+		}
+		if len(installations) != 1 {
+			return "", fmt.Errorf(common.ErrAppInstallations, a.ID, len(installations))
+		}
+		installation = installations[0].GetID()
+	}
+	token, _, err := client.Apps.CreateInstallationToken(ctx, installation, nil)
+	if err != nil {
+		return "", fmt.Errorf(common.ErrCreatingAppToken, installation, err)
+	}
+	return token.GetToken(), nil
+}
+
+// appEnvSource is a GitHub App configured by environment variables
+type appEnvSource struct{}
+
+// AppFromEnv returns a source creating installation tokens of the GitHub App
+// in GITHUB_APP_ID, whose private key is GITHUB_APP_PRIVATE_KEY, either PEM
+// or the path of a PEM file. GITHUB_APP_INSTALLATION_ID is only needed when
+// the app is installed more than once. Without GITHUB_APP_ID it has no token.
+func AppFromEnv() Source {
+	return appEnvSource{}
+}
+
+func (appEnvSource) Name() string {
+	return "GitHub App"
+}
+
+func (appEnvSource) Token(ctx context.Context) (string, error) {
+	app, ok, err := appFromEnv()
+	if !ok || err != nil {
+		return "", err
+	}
+	return app.Token(ctx)
+}
+
+// appFromEnv reads the app configured by the environment, ok is false when
+// none is
+func appFromEnv() (app App, ok bool, err error) {
+	id := strings.TrimSpace(os.Getenv(AppIDEnv))
+	if id == "" {
+		return App{}, false, nil
+	}
+	if app.ID, err = strconv.ParseInt(id, 10, 64); err != nil {
+		return App{}, true, fmt.Errorf(common.ErrInvalidAppSetting, AppIDEnv, id)
+	}
+	key := strings.TrimSpace(os.Getenv(AppPrivateKeyEnv))
+	if key == "" {
+		return App{}, true, fmt.Errorf(common.ErrMissingAppCredential, AppIDEnv, AppPrivateKeyEnv)
+	}
+	if strings.HasPrefix(key, "-----BEGIN") {
+		app.PrivateKey = []byte(key)
+	} else if app.PrivateKey, err = os.ReadFile(key); err != nil { // #nosec G304 - key file configured by the user
+		return App{}, true, err
+	}
+	if installation := strings.TrimSpace(os.Getenv(AppInstallationIDEnv)); installation != "" {
+		if app.InstallationID, err = strconv.ParseInt(installation, 10, 64); err != nil {
+			return App{}, true, fmt.Errorf(common.ErrInvalidAppSetting, AppInstallationIDEnv, installation)
+		}
+	}
+	return app, true, nil
+}
+
+// parsePrivateKey parses a PKCS #1 or PKCS #8 RSA private key
+func parsePrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf(common.ErrParsingAppKey, errors.New("no PEM data"))
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf(common.ErrParsingAppKey, err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf(common.ErrParsingAppKey, errors.New("not an RSA key"))
+	}
+	return key, nil
+}
+
+// appJWT returns the RS256 JWT authenticating as app id at now. It is
+// backdated a minute to allow for clock drift.
+func appJWT(id int64, key *rsa.PrivateKey, now time.Time) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]any{
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(appJWTLifetime).Unix(),
+		"iss": strconv.FormatInt(id, 10),
+	})
+	if err != nil {
+		return "", err
+	}
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf(common.ErrSigningAppJWT, err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
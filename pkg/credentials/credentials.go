@@ -0,0 +1,117 @@
+// Package credentials finds the GitHub token of a run when none is given:
+// in the environment, from GitHub App credentials or from the gh CLI.
+package credentials
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+)
+
+// GitHubHost is the host the gh CLI is asked for a token of by default
+const GitHubHost = "github.com"
+
+// Source provides a token
+type Source interface {
+	// Name describes the source in logs without revealing the token
+	Name() string
+	// Token returns the token, "" when the source has none
+	Token(ctx context.Context) (string, error)
+}
+
+// Chain asks its sources in order for a token
+type Chain []Source
+
+// Token returns the first token found and the name of its source. A source
+// that fails stops the chain, since it was configured but is broken.
+func (c Chain) Token(ctx context.Context) (token, source string, err error) {
+	for _, s := range c {
+		token, err := s.Token(ctx)
+		if err != nil {
+			return "", s.Name(), fmt.Errorf(common.ErrCredentialSource, s.Name(), err)
+		}
+		if token != "" {
+			return token, s.Name(), nil
+		}
+	}
+	return "", "", nil
+}
+
+// Default returns the chain used when no token is given: the GH_TOKEN and
+// GITHUB_TOKEN variables read by the gh CLI, the token input of the GitHub
+// Action, a GitHub App configured in the environment (see AppFromEnv) and
+// finally the login of the gh CLI for host.
+func Default(host string) Chain {
+	return Chain{
+		Env("GH_TOKEN"),
+		Env("GITHUB_TOKEN"),
+		Env("INPUT_TOKEN"),
+		AppFromEnv(),
+		GHCLI(host),
+	}
+}
+
+// envSource reads the token from an environment variable
+type envSource string
+
+// Env returns a source reading the environment variable name
+func Env(name string) Source {
+	return envSource(name)
+}
+
+func (e envSource) Name() string {
+	return "$" + string(e)
+}
+
+func (e envSource) Token(context.Context) (string, error) {
+	return strings.TrimSpace(os.Getenv(string(e))), nil
+}
+
+// commandSource reads the token from the output of a program
+type commandSource struct {
+	name string
+	args []string
+}
+
+// Command returns a source running args and reading the token from its
+// standard output. A program that is not installed or exits with an error,
+// such as a CLI that is not logged in, has no token.
+func Command(name string, args ...string) Source {
+	return commandSource{name: name, args: args}
+}
+
+// GHCLI returns a source asking the gh CLI for the token of its login on host
+func GHCLI(host string) Source {
+	return Command("gh CLI", "gh", "auth", "token", "--hostname", host)
+}
+
+func (c commandSource) Name() string {
+	return c.name
+}
+
+func (c commandSource) Token(ctx context.Context) (string, error) {
+	if len(c.args) == 0 {
+		return "", nil
+	}
+	path, err := exec.LookPath(c.args[0])
+	if err != nil {
+		return "", nil
+	}
+	var stdout bytes.Buffer
+	cmd := exec.CommandContext(ctx, path, c.args[1:]...) // #nosec G204 - fixed programs of the credential chain
+	cmd.Stdout = &stdout
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && ctx.Err() == nil {
+			return "", nil
+		}
+		return "", err
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
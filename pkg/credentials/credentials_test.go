@@ -0,0 +1,112 @@
+package credentials
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// staticSource returns a fixed token or error
+type staticSource struct {
+	name  string
+	token string
+	err   error
+	asked *int
+}
+
+func (s staticSource) Name() string { return s.name }
+
+func (s staticSource) Token(context.Context) (string, error) {
+	if s.asked != nil {
+		*s.asked++
+	}
+	return s.token, s.err
+}
+
+func TestChainToken(t *testing.T) {
+	var later int
+	tests := []struct {
+		name       string
+		chain      Chain
+		wantToken  string
+		wantSource string
+		wantErr    bool
+	}{
+		{
+			name:       "first token wins",
+			chain:      Chain{staticSource{name: "empty"}, staticSource{name: "second", token: "t2"}, staticSource{name: "third", token: "t3", asked: &later}},
+			wantToken:  "t2",
+			wantSource: "second",
+		},
+		{
+			name:       "failing source stops the chain",
+			chain:      Chain{staticSource{name: "broken", err: errors.New("bad key")}, staticSource{name: "later", token: "t", asked: &later}},
+			wantSource: "broken",
+			wantErr:    true,
+		},
+		{
+			name:  "no token",
+			chain: Chain{staticSource{name: "empty"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, source, err := tt.chain.Token(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Token() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if token != tt.wantToken || source != tt.wantSource {
+				t.Errorf("Token() = %q from %q, want %q from %q", token, source, tt.wantToken, tt.wantSource)
+			}
+		})
+	}
+	if later != 0 {
+		t.Errorf("sources after the result were asked %d times", later)
+	}
+}
+
+func TestDefaultChainEnv(t *testing.T) {
+	t.Setenv("GH_TOKEN", "")
+	t.Setenv("GITHUB_TOKEN", " from-github-token\n")
+	t.Setenv("INPUT_TOKEN", "from-input")
+	t.Setenv(AppIDEnv, "")
+
+	token, source, err := Default(GitHubHost).Token(context.Background())
+	if err != nil || token != "from-github-token" || source != "$GITHUB_TOKEN" {
+		t.Errorf("Token() = %q from %q, %v; want GITHUB_TOKEN", token, source, err)
+	}
+
+	t.Setenv("GH_TOKEN", "from-gh-token")
+	if token, _, _ := Default(GitHubHost).Token(context.Background()); token != "from-gh-token" {
+		t.Errorf("Token() = %q, want GH_TOKEN to take precedence", token)
+	}
+}
+
+func TestCommandToken(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    string
+		wantErr bool
+	}{
+		{name: "output", args: []string{"sh", "-c", "echo ' gho_token'"}, want: "gho_token"},
+		{name: "not logged in", args: []string{"sh", "-c", "echo 'not logged in' >&2; exit 1"}},
+		{name: "not installed", args: []string{"ghactions-updater-missing-program"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Command("test", tt.args...).Token(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Token() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Token() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if name := GHCLI("ghe.example.com").Name(); !strings.Contains(name, "gh") {
+		t.Errorf("GHCLI().Name() = %q", name)
+	}
+}
@@ -0,0 +1,277 @@
+// Package metrics records run-level counters for the updater and exports them
+// in the Prometheus text exposition format, either to a Pushgateway or to a
+// textfile consumed by the node exporter's textfile collector.
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+)
+
+// Metric names exported by the updater
+const (
+	FilesScanned       = "ghactions_updater_files_scanned_total"
+	ActionsChecked     = "ghactions_updater_actions_checked_total"
+	UpdatesFound       = "ghactions_updater_updates_found_total"
+	APICalls           = "ghactions_updater_api_calls_total"
+	APINotModified     = "ghactions_updater_api_not_modified_total"
+	RateLimitRemaining = "ghactions_updater_rate_limit_remaining"
+	Errors             = "ghactions_updater_errors_total"
+	Events             = "ghactions_updater_events_total"
+	LastRunTimestamp   = "ghactions_updater_last_run_timestamp_seconds"
+)
+
+// Error categories used as the "category" label of the Errors counter
+const (
+	CategoryScan   = "scan"
+	CategoryParse  = "parse"
+	CategoryCheck  = "check"
+	CategoryUpdate = "update"
+	CategoryPR     = "pr"
+)
+
+// help contains the HELP text for each known metric
+var help = map[string]string{
+	FilesScanned:       "Number of workflow files scanned.",
+	ActionsChecked:     "Number of action references checked for updates.",
+	UpdatesFound:       "Number of available updates found.",
+	APICalls:           "Number of GitHub API calls made.",
+	APINotModified:     "Number of GitHub API calls answered with 304 Not Modified.",
+	RateLimitRemaining: "Remaining GitHub API requests in the current rate limit window.",
+	Errors:             "Number of errors by category.",
+	Events:             "Number of published events by type.",
+	LastRunTimestamp:   "Unix timestamp of the last completed run.",
+}
+
+// gauges lists the metrics that are exported as gauges rather than counters
+var gauges = map[string]bool{
+	RateLimitRemaining: true,
+	LastRunTimestamp:   true,
+}
+
+// Registry holds the metric values for a single run
+type Registry struct {
+	mu     sync.Mutex
+	values map[string]map[string]float64 // metric name -> label string -> value
+}
+
+// NewRegistry creates an empty Registry
+func NewRegistry() *Registry {
+	return &Registry{values: make(map[string]map[string]float64)}
+}
+
+// Default is the registry used by the CLI and the GitHub client transport
+var Default = NewRegistry()
+
+// Add increments the counter name by delta
+func (r *Registry) Add(name string, delta float64) {
+	r.add(name, "", delta)
+}
+
+// Inc increments the counter name by one
+func (r *Registry) Inc(name string) {
+	r.add(name, "", 1)
+}
+
+// IncError increments the error counter for the given category
+func (r *Registry) IncError(category string) {
+	r.add(Errors, fmt.Sprintf(`category=%q`, category), 1)
+}
+
+// IncEvent increments the event counter for the given event type
+func (r *Registry) IncEvent(eventType string) {
+	r.add(Events, fmt.Sprintf(`type=%q`, eventType), 1)
+}
+
+// Set sets the gauge name to value
+func (r *Registry) Set(name string, value float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.series(name)[""] = value
+}
+
+// Get returns the unlabelled value of name
+func (r *Registry) Get(name string) float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.values[name][""]
+}
+
+// GetError returns the error count for the given category
+func (r *Registry) GetError(category string) float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.values[Errors][fmt.Sprintf(`category=%q`, category)]
+}
+
+// GetEvent returns the number of published events of the given type
+func (r *Registry) GetEvent(eventType string) float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.values[Events][fmt.Sprintf(`type=%q`, eventType)]
+}
+
+// Reset clears all recorded values
+func (r *Registry) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.values = make(map[string]map[string]float64)
+}
+
+func (r *Registry) add(name, labels string, delta float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.series(name)[labels] += delta
+}
+
+// series returns the label map for name, creating it if needed. Callers must hold r.mu.
+func (r *Registry) series(name string) map[string]float64 {
+	s, ok := r.values[name]
+	if !ok {
+		s = make(map[string]float64)
+		r.values[name] = s
+	}
+	return s
+}
+
+// WriteText writes all metrics in the Prometheus text exposition format
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	names := make([]string, 0, len(r.values))
+	for name := range r.values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	for _, name := range names {
+		metricType := "counter"
+		if gauges[name] {
+			metricType = "gauge"
+		}
+		if h, ok := help[name]; ok {
+			fmt.Fprintf(&buf, "# HELP %s %s\n", name, h)
+		}
+		fmt.Fprintf(&buf, "# TYPE %s %s\n", name, metricType)
+
+		labels := make([]string, 0, len(r.values[name]))
+		for l := range r.values[name] {
+			labels = append(labels, l)
+		}
+		sort.Strings(labels)
+		for _, l := range labels {
+			value := strconv.FormatFloat(r.values[name][l], 'g', -1, 64)
+			if l == "" {
+				fmt.Fprintf(&buf, "%s %s\n", name, value)
+			} else {
+				fmt.Fprintf(&buf, "%s{%s} %s\n", name, l, value)
+			}
+		}
+	}
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// WriteTextfile writes the metrics to path so they can be picked up by the
+// node exporter textfile collector. The file is replaced atomically.
+func (r *Registry) WriteTextfile(path string) error {
+	var buf bytes.Buffer
+	if err := r.WriteText(&buf); err != nil {
+		return fmt.Errorf(common.ErrWritingMetrics, err)
+	}
+
+	options := common.DefaultFileOptions()
+	options.Mode = 0644 // The collector usually runs as a different user
+	if err := common.WriteFileWithOptions(path, buf.Bytes(), options); err != nil {
+		return fmt.Errorf(common.ErrWritingMetrics, err)
+	}
+	return nil
+}
+
+// Push sends the metrics to a Prometheus Pushgateway, replacing any metrics
+// previously pushed for the same job
+func (r *Registry) Push(ctx context.Context, client *http.Client, gatewayURL, job string) error {
+	if job == "" {
+		job = "ghactions-updater"
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	var buf bytes.Buffer
+	if err := r.WriteText(&buf); err != nil {
+		return fmt.Errorf(common.ErrPushingMetrics, err)
+	}
+
+	endpoint := strings.TrimSuffix(gatewayURL, "/") + "/metrics/job/" + url.PathEscape(job)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, endpoint, &buf)
+	if err != nil {
+		return fmt.Errorf(common.ErrPushingMetrics, err)
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf(common.ErrPushingMetrics, err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			fmt.Printf(common.ErrFailedToCloseBody+"\n", err)
+		}
+	}()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf(common.ErrPushingMetrics, fmt.Errorf("unexpected status %s", resp.Status))
+	}
+	return nil
+}
+
+// Transport is an http.RoundTripper that counts GitHub API calls and records
+// the remaining rate limit reported by the API
+type Transport struct {
+	Base     http.RoundTripper
+	Registry *Registry
+}
+
+// NewTransport wraps base with API call accounting on reg. A nil base uses
+// http.DefaultTransport and a nil reg uses Default.
+func NewTransport(base http.RoundTripper, reg *Registry) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if reg == nil {
+		reg = Default
+	}
+	return &Transport{Base: base, Registry: reg}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.Registry.Inc(APICalls)
+	resp, err := t.Base.RoundTrip(req)
+	if err == nil && resp != nil {
+		if resp.StatusCode == http.StatusNotModified {
+			t.Registry.Inc(APINotModified)
+		}
+		if remaining := resp.Header.Get("X-RateLimit-Remaining"); remaining != "" {
+			if n, convErr := strconv.Atoi(remaining); convErr == nil {
+				t.Registry.Set(RateLimitRemaining, float64(n))
+			}
+		}
+	}
+	return resp, err
+}
@@ -0,0 +1,156 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRegistryWriteText(t *testing.T) {
+	reg := NewRegistry()
+	reg.Add(FilesScanned, 3)
+	reg.Inc(ActionsChecked)
+	reg.Inc(ActionsChecked)
+	reg.IncError(CategoryCheck)
+	reg.IncError(CategoryParse)
+	reg.IncError(CategoryCheck)
+	reg.Set(RateLimitRemaining, 4999)
+	reg.IncEvent("pr-opened")
+
+	var buf bytes.Buffer
+	if err := reg.WriteText(&buf); err != nil {
+		t.Fatalf("WriteText() error = %v", err)
+	}
+	out := buf.String()
+
+	want := []string{
+		"# TYPE ghactions_updater_files_scanned_total counter",
+		"ghactions_updater_files_scanned_total 3",
+		"ghactions_updater_actions_checked_total 2",
+		`ghactions_updater_errors_total{category="check"} 2`,
+		`ghactions_updater_errors_total{category="parse"} 1`,
+		"# TYPE ghactions_updater_rate_limit_remaining gauge",
+		"ghactions_updater_rate_limit_remaining 4999",
+		`ghactions_updater_events_total{type="pr-opened"} 1`,
+	}
+	for _, w := range want {
+		if !strings.Contains(out, w) {
+			t.Errorf("WriteText() output missing %q\n%s", w, out)
+		}
+	}
+
+	if got := reg.GetError(CategoryCheck); got != 2 {
+		t.Errorf("GetError(check) = %v, want 2", got)
+	}
+	if got := reg.GetEvent("pr-opened"); got != 1 {
+		t.Errorf("GetEvent(pr-opened) = %v, want 1", got)
+	}
+
+	reg.Reset()
+	if got := reg.Get(FilesScanned); got != 0 {
+		t.Errorf("Get() after Reset = %v, want 0", got)
+	}
+}
+
+func TestRegistryWriteTextfile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "updater.prom")
+
+	reg := NewRegistry()
+	reg.Add(UpdatesFound, 2)
+	if err := reg.WriteTextfile(path); err != nil {
+		t.Fatalf("WriteTextfile() error = %v", err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read textfile: %v", err)
+	}
+	if !strings.Contains(string(content), "ghactions_updater_updates_found_total 2") {
+		t.Errorf("textfile content = %q", content)
+	}
+}
+
+func TestRegistryPush(t *testing.T) {
+	var gotPath, gotMethod, gotBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotMethod = r.Method
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	reg := NewRegistry()
+	reg.Inc(APICalls)
+	if err := reg.Push(context.Background(), server.Client(), server.URL+"/", "nightly"); err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+
+	if gotMethod != http.MethodPut {
+		t.Errorf("method = %s, want PUT", gotMethod)
+	}
+	if gotPath != "/metrics/job/nightly" {
+		t.Errorf("path = %s, want /metrics/job/nightly", gotPath)
+	}
+	if !strings.Contains(gotBody, "ghactions_updater_api_calls_total 1") {
+		t.Errorf("body = %q", gotBody)
+	}
+}
+
+func TestRegistryPushError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	if err := NewRegistry().Push(context.Background(), nil, server.URL, ""); err == nil {
+		t.Error("Push() expected error for 400 response, got nil")
+	}
+}
+
+func TestTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "42")
+		if r.Header.Get("If-None-Match") != "" {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	reg := NewRegistry()
+	client := &http.Client{Transport: NewTransport(nil, reg)}
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		_ = resp.Body.Close()
+	}
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	req.Header.Set("If-None-Match", `"etag"`)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	_ = resp.Body.Close()
+
+	if got := reg.Get(APICalls); got != 3 {
+		t.Errorf("api calls = %v, want 3", got)
+	}
+	if got := reg.Get(APINotModified); got != 1 {
+		t.Errorf("not modified = %v, want 1", got)
+	}
+	if got := reg.Get(RateLimitRemaining); got != 42 {
+		t.Errorf("rate limit remaining = %v, want 42", got)
+	}
+}
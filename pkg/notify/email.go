@@ -0,0 +1,292 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	htmltemplate "html/template"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"net/url"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/report"
+)
+
+// SMTPPasswordEnv names the variable holding the SMTP password
+const SMTPPasswordEnv = "SMTP_PASSWORD" // #nosec G101 - variable name, not a credential
+
+// defaultTextTemplate is the plain text body of report emails
+const defaultTextTemplate = `{{.Headline}}
+{{range .Repositories}}
+{{.Owner}}/{{.Repo}}{{if .Error}} failed: {{.Error}}{{end}}
+{{- range .CreatedPullRequests}}{{if .URL}}
+  Pull request: {{.URL}}{{end}}{{end}}
+{{- range .Updates}}
+  Outdated: {{.Action}} {{or .OldVersion .OldHash}} -> {{.NewVersion}} ({{.File}}:{{.Line}}){{end}}
+{{- range .Unpinned}}
+  Unpinned: {{.Action}}@{{.Ref}} ({{.File}}:{{.Line}}){{end}}
+{{- if and (not .Error) (not .Updates) (not .Unpinned)}}
+  Up to date{{end}}
+{{end}}`
+
+// defaultHTMLTemplate is the HTML body of report emails
+const defaultHTMLTemplate = `<!DOCTYPE html>
+<html><body style="font-family: sans-serif">
+<h2>{{.Headline}}</h2>
+{{range .Repositories}}
+<h3>{{.Owner}}/{{.Repo}}</h3>
+{{if .Error}}<p style="color: #b00020">Failed: {{.Error}}</p>{{end}}
+{{range .CreatedPullRequests}}{{if .URL}}<p>Pull request: <a href="{{.URL}}">{{.URL}}</a></p>{{end}}{{end}}
+{{if .Updates}}<table border="1" cellpadding="4" cellspacing="0">
+<tr><th>Outdated action</th><th>File</th><th>From</th><th>To</th></tr>
+{{range .Updates}}<tr><td><code>{{.Action}}</code></td><td>{{.File}}:{{.Line}}</td><td>{{or .OldVersion .OldHash}}</td><td>{{.NewVersion}}</td></tr>
+{{end}}</table>{{end}}
+{{if .Unpinned}}<table border="1" cellpadding="4" cellspacing="0">
+<tr><th>Unpinned action</th><th>File</th><th>Ref</th></tr>
+{{range .Unpinned}}<tr><td><code>{{.Action}}</code></td><td>{{.File}}:{{.Line}}</td><td>{{.Ref}}</td></tr>
+{{end}}</table>{{end}}
+{{if and (not .Error) (not .Updates) (not .Unpinned)}}<p>Up to date</p>{{end}}
+{{end}}
+</body></html>
+`
+
+// EmailNotifier mails a report of the run over SMTP: per repository the
+// outdated and unpinned actions, the pull request and any error
+type EmailNotifier struct {
+	Host        string
+	Port        string
+	ImplicitTLS bool // smtps: TLS from the start instead of STARTTLS
+	Username    string
+	Password    string
+	From        string
+	To          []string
+
+	text *template.Template
+	html *htmltemplate.Template
+}
+
+// emailData is what the email templates render
+type emailData struct {
+	Headline     string
+	Summary      Summary
+	Repositories []report.RepositoryResult
+}
+
+// newEmailNotifier parses smtp://[user@]host[:port]?from=a&to=b[&to=c] or
+// smtps://, with optional text-template and html-template files replacing
+// the default bodies. The password is read from SMTP_PASSWORD.
+func newEmailNotifier(u *url.URL) (*EmailNotifier, error) {
+	invalid := func(reason string) error {
+		return fmt.Errorf(common.ErrInvalidEmailNotifier, u.Host, reason)
+	}
+	query := u.Query()
+	n := &EmailNotifier{
+		Host:        u.Hostname(),
+		Port:        u.Port(),
+		ImplicitTLS: u.Scheme == "smtps",
+		Username:    u.User.Username(),
+		Password:    os.Getenv(SMTPPasswordEnv),
+		From:        query.Get("from"),
+		To:          query["to"],
+	}
+	if n.Host == "" {
+		return nil, invalid("missing host")
+	}
+	if _, ok := u.User.Password(); ok {
+		return nil, invalid("pass the password in " + SMTPPasswordEnv + ", not in the URL")
+	}
+	if n.Port == "" {
+		n.Port = "587"
+		if n.ImplicitTLS {
+			n.Port = "465"
+		}
+	}
+	if n.From == "" || len(n.To) == 0 {
+		return nil, invalid("from and to are required")
+	}
+	for _, address := range append([]string{n.From}, n.To...) {
+		if _, err := mail.ParseAddress(address); err != nil {
+			return nil, invalid(err.Error())
+		}
+	}
+
+	var err error
+	if n.text, err = loadTemplate(query.Get("text-template"), defaultTextTemplate, template.New("text").Parse); err != nil {
+		return nil, err
+	}
+	if n.html, err = loadTemplate(query.Get("html-template"), defaultHTMLTemplate, htmltemplate.New("html").Parse); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// loadTemplate parses the template in path, or def when path is empty
+func loadTemplate[T any](path, def string, parse func(string) (T, error)) (T, error) {
+	text := def
+	if path != "" {
+		data, err := os.ReadFile(path) // #nosec G304 - path is configured by the user
+		if err != nil {
+			var zero T
+			return zero, fmt.Errorf(common.ErrEmailTemplate, path, err)
+		}
+		text = string(data)
+	}
+	tmpl, err := parse(text)
+	if err != nil {
+		var zero T
+		return zero, fmt.Errorf(common.ErrEmailTemplate, path, err)
+	}
+	return tmpl, nil
+}
+
+// Name implements Notifier
+func (n *EmailNotifier) Name() string { return "email" }
+
+// Notify implements Notifier
+func (n *EmailNotifier) Notify(ctx context.Context, summary Summary) error {
+	from, err := mail.ParseAddress(n.From)
+	if err != nil {
+		return err
+	}
+	to, err := mail.ParseAddressList(strings.Join(n.To, ", "))
+	if err != nil {
+		return err
+	}
+	message, err := n.message(summary, from, to, time.Now())
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, notifyTimeout)
+	defer cancel()
+	addr := net.JoinHostPort(n.Host, n.Port)
+	tlsConfig := &tls.Config{ServerName: n.Host, MinVersion: tls.VersionTLS12}
+	var conn net.Conn
+	if n.ImplicitTLS {
+		conn, err = (&tls.Dialer{Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	deadline, _ := ctx.Deadline()
+	_ = conn.SetDeadline(deadline)
+
+	client, err := smtp.NewClient(conn, n.Host)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	defer func() { _ = client.Close() }()
+	if ok, _ := client.Extension("STARTTLS"); ok && !n.ImplicitTLS {
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return err
+		}
+	}
+	// PlainAuth refuses to send the password unencrypted, except to localhost
+	if n.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", n.Username, n.Password, n.Host)); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(from.Address); err != nil {
+		return err
+	}
+	for _, recipient := range to {
+		if err := client.Rcpt(recipient.Address); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(message); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// message renders the email with a plain text and an HTML alternative
+func (n *EmailNotifier) message(summary Summary, from *mail.Address, to []*mail.Address, now time.Time) ([]byte, error) {
+	data := emailData{Headline: summary.headline(), Summary: summary}
+	if summary.Report != nil {
+		data.Repositories = summary.Report.Repositories
+	}
+	var text, html bytes.Buffer
+	if err := n.text.Execute(&text, data); err != nil {
+		return nil, fmt.Errorf(common.ErrEmailTemplate, "text", err)
+	}
+	if err := n.html.Execute(&html, data); err != nil {
+		return nil, fmt.Errorf(common.ErrEmailTemplate, "html", err)
+	}
+
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+	for _, part := range []struct {
+		contentType string
+		content     []byte
+	}{{"text/plain", text.Bytes()}, {"text/html", html.Bytes()}} {
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType + "; charset=utf-8"},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write(part.content); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+
+	var msg bytes.Buffer
+	recipients := make([]string, len(to))
+	for i, address := range to {
+		recipients[i] = address.String()
+	}
+	fmt.Fprintf(&msg, "From: %s\r\n", from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(recipients, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", n.subject(summary)))
+	fmt.Fprintf(&msg, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&msg, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", parts.Boundary())
+	msg.Write(body.Bytes())
+	return msg.Bytes(), nil
+}
+
+// subject counts the outdated and unpinned actions
+func (n *EmailNotifier) subject(summary Summary) string {
+	unpinned := 0
+	if summary.Report != nil {
+		for _, repo := range summary.Report.Repositories {
+			unpinned += len(repo.Unpinned)
+		}
+	}
+	subject := fmt.Sprintf("GitHub Actions report: %d outdated, %d unpinned in %d repositories", summary.Updates, unpinned, summary.Repositories)
+	if len(summary.Errors) > 0 {
+		subject += fmt.Sprintf(", %d failed", len(summary.Errors))
+	}
+	return subject
+}
@@ -0,0 +1,281 @@
+// Package notify sends a summary of a run to chat and webhook endpoints:
+// the updates proposed, the pull requests created and the errors.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/report"
+)
+
+const (
+	// notifyTimeout bounds a single notification
+	notifyTimeout = 30 * time.Second
+	// TokenEnv names the variable holding the generic webhook's bearer token
+	TokenEnv = "NOTIFY_TOKEN" // #nosec G101 - variable name, not a credential
+)
+
+// When a run sends notifications
+const (
+	OnAlways  = "always"  // After every run
+	OnChanges = "changes" // When updates were found or a repository failed
+	OnErrors  = "errors"  // Only when a repository failed
+)
+
+// Notifier sends the summary of a run somewhere
+type Notifier interface {
+	// Name identifies the notifier in logs without revealing its URL
+	Name() string
+	Notify(ctx context.Context, summary Summary) error
+}
+
+// New creates a notifier from a spec: "slack:<url>" posts to a Slack
+// incoming webhook, "teams:<url>" to a Microsoft Teams workflow webhook, an
+// https:// URL receives the summary as JSON, and smtp:// or smtps:// URLs
+// mail a report (see newEmailNotifier)
+func New(spec string) (Notifier, error) {
+	if strings.HasPrefix(spec, "smtp://") || strings.HasPrefix(spec, "smtps://") {
+		u, err := url.Parse(spec)
+		if err != nil {
+			return nil, fmt.Errorf(common.ErrUnknownNotifier, "smtp")
+		}
+		return newEmailNotifier(u)
+	}
+
+	kind, target := "", spec
+	if prefix, rest, ok := strings.Cut(spec, ":"); ok && (prefix == "slack" || prefix == "teams") {
+		kind, target = prefix, rest
+	}
+	u, err := url.Parse(target)
+	if err != nil || u.Host == "" || (u.Scheme != "https" && u.Scheme != "http") {
+		return nil, fmt.Errorf(common.ErrUnknownNotifier, spec)
+	}
+	if u.Scheme == "http" && !isLoopbackHost(u.Hostname()) {
+		return nil, fmt.Errorf(common.ErrInsecureNotifyURL, u.Host)
+	}
+
+	switch kind {
+	case "slack":
+		return &SlackNotifier{URL: target}, nil
+	case "teams":
+		return &TeamsNotifier{URL: target}, nil
+	default:
+		return &WebhookNotifier{URL: target, Token: os.Getenv(TokenEnv)}, nil
+	}
+}
+
+// ValidateOn checks a -notify-on value
+func ValidateOn(on string) error {
+	switch on {
+	case OnAlways, OnChanges, OnErrors:
+		return nil
+	default:
+		return fmt.Errorf(common.ErrInvalidNotifyOn, on)
+	}
+}
+
+// isLoopbackHost reports whether host names the local machine
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// Summary is what a notification reports about a run
+type Summary struct {
+	Mode         string        `json:"mode,omitempty"`
+	Repositories int           `json:"repositories"`
+	Updates      int           `json:"updates"`
+	PullRequests []PullRequest `json:"pull_requests,omitempty"`
+	Errors       []Failure     `json:"errors,omitempty"`
+
+	Report *report.Report `json:"-"` // The full report, for detailed notifications
+}
+
+// PullRequest is a pull request created by the run
+type PullRequest struct {
+	Repository string `json:"repository"`
+	Number     int    `json:"number,omitempty"`
+	URL        string `json:"url,omitempty"`
+	Updates    int    `json:"updates"`
+}
+
+// Failure is a repository the run failed to process
+type Failure struct {
+	Repository string `json:"repository"`
+	Error      string `json:"error"`
+}
+
+// Summarize extracts the summary of a run from its report
+func Summarize(rep *report.Report) Summary {
+	summary := Summary{Mode: rep.Mode, Repositories: len(rep.Repositories), Updates: rep.UpdateCount(), Report: rep}
+	for _, repo := range rep.Repositories {
+		name := repo.Owner + "/" + repo.Repo
+		for _, pull := range repo.CreatedPullRequests() {
+			summary.PullRequests = append(summary.PullRequests, PullRequest{Repository: name, Number: pull.Number, URL: pull.URL, Updates: pull.Updates})
+		}
+		if repo.Error != "" {
+			summary.Errors = append(summary.Errors, Failure{Repository: name, Error: repo.Error})
+		}
+	}
+	return summary
+}
+
+// Wanted reports whether a run with this summary is notified under on
+func (s Summary) Wanted(on string) bool {
+	switch on {
+	case OnErrors:
+		return len(s.Errors) > 0
+	case OnChanges:
+		return s.Updates > 0 || len(s.Errors) > 0
+	default:
+		return true
+	}
+}
+
+// headline is the first line of a chat notification
+func (s Summary) headline() string {
+	mode := ""
+	if s.Mode != "" {
+		mode = fmt.Sprintf(" (%s)", s.Mode)
+	}
+	text := fmt.Sprintf("GitHub Actions updater%s: %d updates in %d repositories", mode, s.Updates, s.Repositories)
+	if len(s.Errors) > 0 {
+		text += fmt.Sprintf(", %d failed", len(s.Errors))
+	}
+	return text
+}
+
+// text formats the summary for chat; link formats links and escape the
+// remaining text
+func (s Summary) text(link func(text, url string) string, escape func(string) string) string {
+	lines := []string{escape(s.headline())}
+	for _, pr := range s.PullRequests {
+		ref := fmt.Sprintf("#%d", pr.Number)
+		if pr.Number == 0 {
+			ref = "pull request"
+		}
+		if pr.URL != "" {
+			ref = link(ref, pr.URL)
+		}
+		lines = append(lines, fmt.Sprintf("• %s: %d updates in %s", escape(pr.Repository), pr.Updates, ref))
+	}
+	for _, failure := range s.Errors {
+		lines = append(lines, fmt.Sprintf("• %s failed: %s", escape(failure.Repository), escape(failure.Error)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// SlackNotifier posts to a Slack incoming webhook
+type SlackNotifier struct {
+	URL    string
+	Client *http.Client // Defaults to http.DefaultClient
+}
+
+// Name implements Notifier
+func (n *SlackNotifier) Name() string { return "slack" }
+
+// Notify implements Notifier
+func (n *SlackNotifier) Notify(ctx context.Context, summary Summary) error {
+	escape := strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace
+	text := summary.text(func(text, url string) string { return "<" + url + "|" + text + ">" }, escape)
+	return post(ctx, n.Client, n.URL, "", map[string]string{"text": text})
+}
+
+// TeamsNotifier posts an Adaptive Card to a Microsoft Teams workflow webhook
+type TeamsNotifier struct {
+	URL    string
+	Client *http.Client // Defaults to http.DefaultClient
+}
+
+// Name implements Notifier
+func (n *TeamsNotifier) Name() string { return "teams" }
+
+// Notify implements Notifier
+func (n *TeamsNotifier) Notify(ctx context.Context, summary Summary) error {
+	// Adaptive Card text blocks take Markdown; separate lines need blank
+	// lines between them
+	text := summary.text(func(text, url string) string { return "[" + text + "](" + url + ")" }, func(s string) string { return s })
+	card := map[string]interface{}{
+		"type": "message",
+		"attachments": []map[string]interface{}{{
+			"contentType": "application/vnd.microsoft.card.adaptive",
+			"content": map[string]interface{}{
+				"type":    "AdaptiveCard",
+				"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+				"version": "1.4",
+				"body": []map[string]interface{}{{
+					"type": "TextBlock",
+					"text": strings.ReplaceAll(text, "\n", "\n\n"),
+					"wrap": true,
+				}},
+			},
+		}},
+	}
+	return post(ctx, n.Client, n.URL, "", card)
+}
+
+// WebhookNotifier posts the summary as JSON to an HTTP endpoint
+type WebhookNotifier struct {
+	URL    string
+	Token  string       // Optional bearer token
+	Client *http.Client // Defaults to http.DefaultClient
+}
+
+// Name implements Notifier
+func (n *WebhookNotifier) Name() string { return "webhook" }
+
+// Notify implements Notifier
+func (n *WebhookNotifier) Notify(ctx context.Context, summary Summary) error {
+	return post(ctx, n.Client, n.URL, n.Token, summary)
+}
+
+// post sends payload as JSON to target
+func post(ctx context.Context, client *http.Client, target, token string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, notifyTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		// Webhook URLs are credentials; keep them out of the error
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			return urlErr.Err
+		}
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf(common.ErrNotifierStatus, resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/report"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    string
+		wantErr string
+	}{
+		{spec: "slack:https://hooks.slack.com/services/T/B/x", want: "slack"},
+		{spec: "teams:https://example.webhook.office.com/x", want: "teams"},
+		{spec: "https://hooks.example.com/notify", want: "webhook"},
+		{spec: "http://localhost:8080/notify", want: "webhook"},
+		{spec: "slack:http://hooks.slack.com/x", wantErr: "must use https"},
+		{spec: "email:ops@example.com", wantErr: "unknown notifier"},
+		{spec: "slack:", wantErr: "unknown notifier"},
+	}
+	for _, tt := range tests {
+		notifier, err := New(tt.spec)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("New(%q) error = %v, want %q", tt.spec, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("New(%q) error = %v", tt.spec, err)
+		}
+		if notifier.Name() != tt.want {
+			t.Errorf("New(%q) = %s, want %s", tt.spec, notifier.Name(), tt.want)
+		}
+	}
+}
+
+// testReport has a pull request, a failure and a repository without updates
+func testReport() *report.Report {
+	rep := report.New("")
+	rep.Mode = "pr"
+	rep.Add(report.RepositoryResult{Owner: "o", Repo: "a", Updates: []report.UpdateEntry{{Action: "actions/checkout"}, {Action: "actions/setup-go"}}, PullRequest: 7, PullURL: "https://github.com/o/a/pull/7"})
+	rep.Add(report.RepositoryResult{Owner: "o", Repo: "b", Error: "access <denied>"})
+	rep.Add(report.RepositoryResult{Owner: "o", Repo: "c"})
+	return rep
+}
+
+func TestSummaryWanted(t *testing.T) {
+	quiet := Summarize(report.New(""))
+	changed := Summarize(testReport())
+	for _, tt := range []struct {
+		on      string
+		summary Summary
+		want    bool
+	}{
+		{on: OnAlways, summary: quiet, want: true},
+		{on: OnChanges, summary: quiet, want: false},
+		{on: OnChanges, summary: changed, want: true},
+		{on: OnErrors, summary: Summary{Updates: 2}, want: false},
+		{on: OnErrors, summary: changed, want: true},
+	} {
+		if got := tt.summary.Wanted(tt.on); got != tt.want {
+			t.Errorf("Wanted(%q) with %d updates = %v, want %v", tt.on, tt.summary.Updates, got, tt.want)
+		}
+	}
+}
+
+func TestNotify(t *testing.T) {
+	tests := []struct {
+		kind string
+		want []string
+	}{
+		{kind: "slack:", want: []string{
+			`"text":"GitHub Actions updater (pr): 2 updates in 3 repositories, 1 failed`,
+			`o/a: 2 updates in \u003chttps://github.com/o/a/pull/7|#7\u003e`,
+			`o/b failed: access \u0026lt;denied\u0026gt;`,
+		}},
+		{kind: "teams:", want: []string{
+			`"contentType":"application/vnd.microsoft.card.adaptive"`,
+			`o/a: 2 updates in [#7](https://github.com/o/a/pull/7)`,
+		}},
+		{kind: "", want: []string{
+			`"repositories":3,"updates":2`,
+			`"pull_requests":[{"repository":"o/a","number":7,"url":"https://github.com/o/a/pull/7","updates":2}]`,
+			`"errors":[{"repository":"o/b","error":"access \u003cdenied\u003e"}]`,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.kind, func(t *testing.T) {
+			var body string
+			var auth string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				data, _ := io.ReadAll(r.Body)
+				body, auth = string(data), r.Header.Get("Authorization")
+			}))
+			defer server.Close()
+			t.Setenv(TokenEnv, "secret")
+
+			notifier, err := New(tt.kind + server.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := notifier.Notify(context.Background(), Summarize(testReport())); err != nil {
+				t.Fatalf("Notify() error = %v", err)
+			}
+			if !json.Valid([]byte(body)) {
+				t.Fatalf("invalid JSON: %s", body)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(body, want) {
+					t.Errorf("body missing %s:\n%s", want, body)
+				}
+			}
+			if wantAuth := map[bool]string{true: "Bearer secret"}[tt.kind == ""]; auth != wantAuth {
+				t.Errorf("Authorization = %q, want %q", auth, wantAuth)
+			}
+		})
+	}
+}
+
+func TestNotifyStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	notifier, _ := New("slack:" + server.URL)
+	if err := notifier.Notify(context.Background(), Summary{}); err == nil || !strings.Contains(err.Error(), "status 403") {
+		t.Errorf("Notify() error = %v, want status 403", err)
+	}
+}
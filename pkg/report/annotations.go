@@ -0,0 +1,149 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// Annotation levels of GitHub workflow commands
+const (
+	AnnotationWarning = "warning"
+	AnnotationError   = "error"
+)
+
+// Annotation is a finding shown inline on the workflow run and pull request
+// diffs when written to the output of a GitHub Actions step
+type Annotation struct {
+	Level   string // AnnotationWarning or AnnotationError
+	File    string // Empty for findings about a whole repository
+	Line    int
+	Title   string
+	Message string
+}
+
+// String formats the annotation as a workflow command, e.g.
+// "::warning file=.github/workflows/ci.yml,line=14,title=Outdated action::..."
+func (a Annotation) String() string {
+	var props []string
+	if a.File != "" {
+		props = append(props, "file="+escapeProperty(a.File))
+		if a.Line > 0 {
+			props = append(props, fmt.Sprintf("line=%d", a.Line))
+		}
+	}
+	if a.Title != "" {
+		props = append(props, "title="+escapeProperty(a.Title))
+	}
+	command := "::" + a.Level
+	if len(props) > 0 {
+		command += " " + strings.Join(props, ",")
+	}
+	return command + "::" + escapeData(a.Message)
+}
+
+// Annotations returns an annotation per finding of the report: outdated
+// and unpinned references, comment drift, schema errors, excessive
+// permissions and failed repositories. Files
+// inside root are named relative to it, as annotations expect paths
+// relative to the workspace; other files are named as recorded.
+func (r *Report) Annotations(root string) []Annotation {
+	var annotations []Annotation
+	for _, repo := range r.Repositories {
+		if repo.Error != "" {
+			annotations = append(annotations, Annotation{
+				Level:   AnnotationError,
+				Title:   "Update failed",
+				Message: fmt.Sprintf("%s/%s: %s", repo.Owner, repo.Repo, repo.Error),
+			})
+		}
+
+		outdated := make(map[string]bool, len(repo.Updates))
+		for _, update := range repo.Updates {
+			outdated[fmt.Sprintf("%s:%d", update.File, update.Line)] = true
+			annotations = append(annotations, Annotation{
+				Level: AnnotationWarning,
+				File:  relativeTo(root, update.File),
+				Line:  update.Line,
+				Title: "Outdated action",
+				Message: fmt.Sprintf("%s@%s is outdated; latest is %s",
+					update.Action, versionOrHash(update.OldVersion, update.OldHash), update.NewVersion),
+			})
+		}
+		// Updates pin the references they touch, so those are only reported once
+		for _, ref := range repo.Unpinned {
+			if outdated[fmt.Sprintf("%s:%d", ref.File, ref.Line)] {
+				continue
+			}
+			annotations = append(annotations, Annotation{
+				Level:   AnnotationWarning,
+				File:    relativeTo(root, ref.File),
+				Line:    ref.Line,
+				Title:   "Unpinned action",
+				Message: fmt.Sprintf("%s@%s is not pinned to a commit SHA", ref.Action, ref.Ref),
+			})
+		}
+		for _, drift := range repo.CommentDrift {
+			annotations = append(annotations, Annotation{
+				Level:   AnnotationWarning,
+				File:    relativeTo(root, drift.File),
+				Line:    drift.Line,
+				Title:   "Comment drift",
+				Message: fmt.Sprintf("%s is pinned to %s, which is not %s", drift.Action, drift.Hash, drift.Comment),
+			})
+		}
+		for _, schemaErr := range repo.SchemaErrors {
+			annotations = append(annotations, Annotation{
+				Level:   AnnotationError,
+				File:    relativeTo(root, schemaErr.File),
+				Line:    schemaErr.Line,
+				Title:   "Invalid workflow",
+				Message: schemaErr.message(),
+			})
+		}
+		for _, finding := range repo.Permissions {
+			annotations = append(annotations, Annotation{
+				Level:   AnnotationWarning,
+				File:    relativeTo(root, finding.File),
+				Line:    finding.Line,
+				Title:   "Excessive permissions",
+				Message: finding.Message,
+			})
+		}
+	}
+	return annotations
+}
+
+// EncodeAnnotations writes the annotations of the report, one workflow
+// command per line, with files relative to root
+func (r *Report) EncodeAnnotations(w io.Writer, root string) error {
+	var sb strings.Builder
+	for _, annotation := range r.Annotations(root) {
+		sb.WriteString(annotation.String() + "\n")
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// relativeTo names file relative to root when it is inside it
+func relativeTo(root, file string) string {
+	if root == "" || !filepath.IsAbs(file) {
+		return file
+	}
+	rel, err := filepath.Rel(root, file)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return file
+	}
+	return filepath.ToSlash(rel)
+}
+
+// escapeData escapes the message of a workflow command
+func escapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeProperty escapes a property value of a workflow command
+func escapeProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
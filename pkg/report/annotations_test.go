@@ -0,0 +1,72 @@
+package report
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestAnnotationString(t *testing.T) {
+	tests := []struct {
+		name       string
+		annotation Annotation
+		want       string
+	}{
+		{
+			name:       "file and line",
+			annotation: Annotation{Level: AnnotationWarning, File: ".github/workflows/ci.yml", Line: 14, Title: "Outdated action", Message: "actions/checkout@v2 is outdated"},
+			want:       "::warning file=.github/workflows/ci.yml,line=14,title=Outdated action::actions/checkout@v2 is outdated",
+		},
+		{
+			name:       "escaped",
+			annotation: Annotation{Level: AnnotationWarning, File: "a,b:c.yml", Title: "100%", Message: "50%\nmore"},
+			want:       "::warning file=a%2Cb%3Ac.yml,title=100%25::50%25%0Amore",
+		},
+		{
+			name:       "repository",
+			annotation: Annotation{Level: AnnotationError, Message: "org/repo: boom"},
+			want:       "::error::org/repo: boom",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.annotation.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodeAnnotations(t *testing.T) {
+	r := New("")
+	r.Add(RepositoryResult{
+		Owner: "org", Repo: "one",
+		Updates: []UpdateEntry{{Action: "actions/checkout", File: "/work/.github/workflows/ci.yml", Line: 14, OldVersion: "v2", NewVersion: "v4.2.1"}},
+		Unpinned: []ReferenceEntry{
+			{Action: "actions/checkout", File: "/work/.github/workflows/ci.yml", Line: 14, Ref: "v2"},
+			{Action: "org/tool", File: "/work/.github/workflows/ci.yml", Line: 20, Ref: "main"},
+		},
+		CommentDrift: []DriftEntry{{Action: "actions/cache", File: "/work/.github/workflows/ci.yml", Line: 22, Hash: "abc", Comment: "v4"}},
+		SchemaErrors: []SchemaEntry{{File: "/work/.github/workflows/ci.yml", Line: 30, Path: "jobs.test", Message: `unknown key "step"`}},
+		Permissions:  []PermissionEntry{{File: "/work/.github/workflows/ci.yml", Line: 3, Job: "build", Issue: "write-all", Message: `job "build" grants write-all permissions to third-party actions: org/tool`}},
+	})
+	r.Add(RepositoryResult{Owner: "org", Repo: "two", Error: "boom"})
+
+	var out bytes.Buffer
+	if err := r.EncodeAnnotations(&out, "/work"); err != nil {
+		t.Fatalf("EncodeAnnotations() error = %v", err)
+	}
+	want := "::warning file=.github/workflows/ci.yml,line=14,title=Outdated action::actions/checkout@v2 is outdated; latest is v4.2.1\n" +
+		"::warning file=.github/workflows/ci.yml,line=20,title=Unpinned action::org/tool@main is not pinned to a commit SHA\n" +
+		"::warning file=.github/workflows/ci.yml,line=22,title=Comment drift::actions/cache is pinned to abc, which is not v4\n" +
+		"::error file=.github/workflows/ci.yml,line=30,title=Invalid workflow::jobs.test: unknown key \"step\"\n" +
+		"::warning file=.github/workflows/ci.yml,line=3,title=Excessive permissions::job \"build\" grants write-all permissions to third-party actions: org/tool\n" +
+		"::error title=Update failed::org/two: boom\n"
+	if out.String() != want {
+		t.Errorf("annotations = %q, want %q", out.String(), want)
+	}
+
+	out.Reset()
+	if err := r.Encode(&out, FormatGitHub); err != nil || !bytes.Contains(out.Bytes(), []byte("file=/work/.github/workflows/ci.yml,line=14")) {
+		t.Errorf("Encode(github) = %q, %v", out.String(), err)
+	}
+}
@@ -0,0 +1,102 @@
+package report
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/updater"
+)
+
+// CheckpointVersion is the version of the checkpoint format written by this
+// build
+const CheckpointVersion = 1
+
+// Checkpoint records the progress of a run over many repositories: the
+// results of the repositories it completed and the action lookups it made.
+// A run interrupted by a crash or an exhausted rate limit resumes from it
+// instead of starting over.
+type Checkpoint struct {
+	Version      int                       `json:"version"`
+	Shard        string                    `json:"shard,omitempty"`
+	Mode         string                    `json:"mode,omitempty"`
+	UpdatedAt    time.Time                 `json:"updated_at"`
+	Repositories []RepositoryResult        `json:"repositories"` // Completed repositories
+	Actions      *updater.MetadataSnapshot `json:"actions"`      // Lookups made so far
+
+	path string
+}
+
+// LoadCheckpoint loads the checkpoint at path, or starts an empty one when
+// the file does not exist. A checkpoint of another shard or mode is an
+// error, since its results do not belong in this run.
+func LoadCheckpoint(path, shard, mode string) (*Checkpoint, error) {
+	c := &Checkpoint{Version: CheckpointVersion, Shard: shard, Mode: mode, Actions: updater.NewMetadataSnapshot(), path: path}
+	// #nosec G304 - path is provided by the user running the tool
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf(common.ErrReadingCheckpoint, path, err)
+	}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf(common.ErrReadingCheckpoint, path, err)
+	}
+	switch {
+	case c.Version != CheckpointVersion:
+		return nil, fmt.Errorf(common.ErrCheckpointMismatch, path, fmt.Sprintf("version %d", c.Version))
+	case c.Shard != shard:
+		return nil, fmt.Errorf(common.ErrCheckpointMismatch, path, fmt.Sprintf("shard %q", c.Shard))
+	case c.Mode != mode:
+		return nil, fmt.Errorf(common.ErrCheckpointMismatch, path, fmt.Sprintf("mode %q", c.Mode))
+	}
+	if c.Actions == nil || c.Actions.Actions == nil {
+		c.Actions = updater.NewMetadataSnapshot()
+	}
+	return c, nil
+}
+
+// Result returns the recorded result of owner/repo, if it was completed
+func (c *Checkpoint) Result(owner, repo string) (RepositoryResult, bool) {
+	for _, result := range c.Repositories {
+		if strings.EqualFold(result.Owner, owner) && strings.EqualFold(result.Repo, repo) {
+			return result, true
+		}
+	}
+	return RepositoryResult{}, false
+}
+
+// Record adds the result of a completed repository and saves the checkpoint
+func (c *Checkpoint) Record(result RepositoryResult) error {
+	c.Repositories = append(c.Repositories, result)
+	return c.Save()
+}
+
+// Save writes the checkpoint, replacing the file atomically
+func (c *Checkpoint) Save() error {
+	c.UpdatedAt = time.Now().UTC()
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf(common.ErrWritingCheckpoint, c.path, err)
+	}
+	options := common.DefaultFileOptions()
+	options.Mode = 0600
+	if err := common.WriteFileWithOptions(c.path, append(data, '\n'), options); err != nil {
+		return fmt.Errorf(common.ErrWritingCheckpoint, c.path, err)
+	}
+	return nil
+}
+
+// Remove deletes the checkpoint once the run is complete
+func (c *Checkpoint) Remove() error {
+	if err := os.Remove(c.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf(common.ErrWritingCheckpoint, c.path, err)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/updater"
+)
+
+func TestCheckpoint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+	checkpoint, err := LoadCheckpoint(path, "1/2", updater.ModeDryRun)
+	if err != nil {
+		t.Fatalf("LoadCheckpoint() of a new checkpoint error = %v", err)
+	}
+	if err := checkpoint.Record(RepositoryResult{Owner: "acme", Repo: "api", FilesScanned: 2}); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+
+	loaded, err := LoadCheckpoint(path, "1/2", updater.ModeDryRun)
+	if err != nil {
+		t.Fatalf("LoadCheckpoint() error = %v", err)
+	}
+	if result, ok := loaded.Result("ACME", "Api"); !ok || result.FilesScanned != 2 {
+		t.Errorf("Result(acme/api) = %+v, %v", result, ok)
+	}
+	if _, ok := loaded.Result("acme", "web"); ok {
+		t.Error("Result(acme/web) found a repository that was not completed")
+	}
+
+	for _, tt := range []struct{ shard, mode, want string }{
+		{shard: "2/2", mode: updater.ModeDryRun, want: `shard "1/2"`},
+		{shard: "1/2", mode: updater.ModePR, want: `mode "dry-run"`},
+	} {
+		if _, err := LoadCheckpoint(path, tt.shard, tt.mode); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("LoadCheckpoint(%s, %s) error = %v, want %s", tt.shard, tt.mode, err, tt.want)
+		}
+	}
+
+	if err := loaded.Remove(); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("checkpoint still exists: %v", err)
+	}
+	if err := loaded.Remove(); err != nil {
+		t.Errorf("Remove() of a removed checkpoint error = %v", err)
+	}
+}
@@ -0,0 +1,293 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/updater"
+)
+
+// Supported output formats
+const (
+	FormatJSON     = "json"
+	FormatMarkdown = "markdown"
+	FormatText     = "text"
+	FormatGitHub   = "github" // Workflow command annotations
+)
+
+// Formats lists the supported output formats
+var Formats = []string{FormatJSON, FormatMarkdown, FormatText, FormatGitHub}
+
+// Encode writes the report to w in the given format
+func (r *Report) Encode(w io.Writer, format string) error {
+	var err error
+	switch strings.ToLower(format) {
+	case FormatJSON, "":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(r)
+	case FormatMarkdown, "md":
+		err = r.encodeMarkdown(w)
+	case FormatText:
+		err = r.encodeText(w)
+	case FormatGitHub:
+		err = r.EncodeAnnotations(w, "")
+	default:
+		return fmt.Errorf(common.ErrUnsupportedReportFormat, format)
+	}
+	if err != nil {
+		return fmt.Errorf(common.ErrWritingReport, err)
+	}
+	return nil
+}
+
+// encodeMarkdown writes a summary table followed by the updates per repository
+func (r *Report) encodeMarkdown(w io.Writer) error {
+	var sb strings.Builder
+	sb.WriteString("# GitHub Actions Update Report\n\n")
+	sb.WriteString(fmt.Sprintf("%d repositories, %d updates\n\n", len(r.Repositories), r.UpdateCount()))
+	sb.WriteString("| Repository | Files | Updates | Status |\n")
+	sb.WriteString("|------------|-------|---------|--------|\n")
+	for _, repo := range r.Repositories {
+		status := "ok"
+		if repo.Error != "" {
+			status = "error: " + strings.ReplaceAll(repo.Error, "|", "\\|")
+		} else if len(repo.Failures) > 0 {
+			status = fmt.Sprintf("%d failures", len(repo.Failures))
+		}
+		sb.WriteString(fmt.Sprintf("| %s/%s | %d | %d | %s |\n", repo.Owner, repo.Repo, repo.FilesScanned, len(repo.Updates), status))
+	}
+
+	for _, repo := range r.Repositories {
+		if len(repo.Updates) == 0 {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("\n## %s/%s\n\n", repo.Owner, repo.Repo))
+		for _, update := range repo.Updates {
+			sb.WriteString(fmt.Sprintf("* `%s` in %s:%d: %s → %s\n", update.Action, update.File, update.Line, versionOrHash(update.OldVersion, update.OldHash), update.NewVersion))
+		}
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// encodeText writes one line per update, prefixed by the repository name
+func (r *Report) encodeText(w io.Writer) error {
+	var sb strings.Builder
+	for _, repo := range r.Repositories {
+		name := repo.Owner + "/" + repo.Repo
+		if repo.Error != "" {
+			sb.WriteString(fmt.Sprintf("%s: error: %s\n", name, repo.Error))
+			continue
+		}
+		for _, update := range repo.Updates {
+			sb.WriteString(fmt.Sprintf("%s: %s:%d: %s %s -> %s\n", name, update.File, update.Line, update.Action, versionOrHash(update.OldVersion, update.OldHash), update.NewVersion))
+		}
+		for _, failure := range repo.Failures {
+			sb.WriteString(fmt.Sprintf("%s: %s: %s failed: %s\n", name, failure.location(), failure.Stage, failure.Message))
+		}
+		for _, schemaErr := range repo.SchemaErrors {
+			sb.WriteString(fmt.Sprintf("%s: %s:%d: invalid workflow: %s\n", name, schemaErr.File, schemaErr.Line, schemaErr.message()))
+		}
+		for _, finding := range repo.Permissions {
+			sb.WriteString(fmt.Sprintf("%s: %s:%d: %s\n", name, finding.File, finding.Line, finding.Message))
+		}
+	}
+	sb.WriteString(fmt.Sprintf("%d repositories, %d updates\n", len(r.Repositories), r.UpdateCount()))
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// EncodeFailures writes a table of the files and references that could not
+// be parsed, checked or updated, or nothing when there are none
+func (r *Report) EncodeFailures(w io.Writer) error {
+	count := r.FailureCount()
+	if count == 0 {
+		return nil
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(tw, "%d failures:\n", count)
+	_, _ = fmt.Fprintln(tw, "REPOSITORY\tSTAGE\tACTION\tFILE\tERROR")
+	for _, repo := range r.Repositories {
+		for _, failure := range repo.Failures {
+			_, _ = fmt.Fprintf(tw, "%s/%s\t%s\t%s\t%s\t%s\n", repo.Owner, repo.Repo, failure.Stage, failure.action(), failure.location(), failure.Message)
+		}
+	}
+	return tw.Flush()
+}
+
+// action returns the reference that failed as action@ref, or "-"
+func (f FailureEntry) action() string {
+	if f.Action == "" {
+		return "-"
+	}
+	return f.Action + "@" + f.Ref
+}
+
+// location returns the file of the failure, with the line when known
+func (f FailureEntry) location() string {
+	if f.Line == 0 {
+		return f.File
+	}
+	return fmt.Sprintf("%s:%d", f.File, f.Line)
+}
+
+// message returns the schema error prefixed by its location in the workflow
+func (e SchemaEntry) message() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return e.Path + ": " + e.Message
+}
+
+// versionOrHash returns the version, falling back to the hash
+func versionOrHash(version, hash string) string {
+	if version != "" {
+		return version
+	}
+	return hash
+}
+
+// EncodeSummary writes a Markdown run summary suitable for
+// $GITHUB_STEP_SUMMARY: per repository the scanned workflows, pinned and
+// unpinned actions, the updates applied or proposed, and any errors
+func (r *Report) EncodeSummary(w io.Writer) error {
+	verb := "proposed"
+	switch r.Mode {
+	case updater.ModeStage:
+		verb = "applied"
+	case updater.ModeDryRun:
+		verb = "found (dry run)"
+	}
+
+	var sb strings.Builder
+	sb.WriteString("## GitHub Actions Update Summary\n\n")
+	sb.WriteString("| Repository | Workflows | Pinned | Unpinned | Updates | Status |\n")
+	sb.WriteString("|------------|-----------|--------|----------|---------|--------|\n")
+	for _, repo := range r.Repositories {
+		status := "ok"
+		if repo.Error != "" {
+			status = "error"
+		} else if len(repo.Failures) > 0 {
+			status = fmt.Sprintf("%d failures", len(repo.Failures))
+		} else if len(repo.SchemaErrors) > 0 {
+			status = fmt.Sprintf("%d schema errors", len(repo.SchemaErrors))
+		} else if len(repo.Warnings) > 0 {
+			status = fmt.Sprintf("%d warnings", len(repo.Warnings))
+		}
+		sb.WriteString(fmt.Sprintf("| %s/%s | %d | %d | %d | %d | %s |\n",
+			repo.Owner, repo.Repo, repo.FilesScanned, repo.PinnedActions, repo.UnpinnedActions, len(repo.Updates), status))
+	}
+
+	for _, repo := range r.Repositories {
+		sb.WriteString(fmt.Sprintf("\n### %s/%s\n", repo.Owner, repo.Repo))
+		if len(repo.Workflows) > 0 {
+			sb.WriteString("\n**Workflows scanned**\n\n")
+			for _, workflow := range repo.Workflows {
+				sb.WriteString(fmt.Sprintf("- `%s`\n", workflow))
+			}
+		}
+		if len(repo.Updates) > 0 {
+			sb.WriteString(fmt.Sprintf("\n**Updates %s**", verb))
+			var numbers []string
+			for _, pull := range repo.CreatedPullRequests() {
+				if pull.Number != 0 {
+					numbers = append(numbers, fmt.Sprintf("#%d", pull.Number))
+				}
+			}
+			if len(numbers) > 0 {
+				sb.WriteString(" in " + strings.Join(numbers, ", "))
+			}
+			sb.WriteString("\n\n| Action | File | From | To |\n|--------|------|------|----|\n")
+			for _, update := range repo.Updates {
+				sb.WriteString(fmt.Sprintf("| `%s` | %s:%d | %s | %s |\n",
+					update.Action, update.File, update.Line, versionOrHash(update.OldVersion, update.OldHash), update.NewVersion))
+			}
+		} else if repo.Error == "" {
+			sb.WriteString("\nNo updates needed.\n")
+		}
+		if len(repo.CommentDrift) > 0 {
+			sb.WriteString("\n**Comment drift**\n\n| Action | File | Pinned | Comment | Tags | Fixed |\n|--------|------|--------|---------|------|-------|\n")
+			for _, drift := range repo.CommentDrift {
+				fixed := drift.Fixed
+				if fixed == "" {
+					fixed = "no"
+				}
+				sb.WriteString(fmt.Sprintf("| `%s` | %s:%d | `%s` | %s | %s | %s |\n",
+					drift.Action, drift.File, drift.Line, drift.Hash, drift.Comment, strings.Join(drift.Tags, ", "), fixed))
+			}
+		}
+		if len(repo.StalePins) > 0 {
+			sb.WriteString("\n**Stale pins**\n\n| Action | File | Version | Date |\n|--------|------|---------|------|\n")
+			for _, pin := range repo.StalePins {
+				sb.WriteString(fmt.Sprintf("| `%s` | %s:%d | %s | %s |\n", pin.Action, pin.File, pin.Line, pin.Ref, pin.Date.Format("2006-01-02")))
+			}
+		}
+		if len(repo.SchemaErrors) > 0 {
+			sb.WriteString("\n**Schema errors**\n\n| File | Location | Error |\n|------|----------|-------|\n")
+			for _, schemaErr := range repo.SchemaErrors {
+				sb.WriteString(fmt.Sprintf("| %s:%d | %s | %s |\n",
+					schemaErr.File, schemaErr.Line, schemaErr.Path, strings.ReplaceAll(schemaErr.Message, "|", "\\|")))
+			}
+		}
+		if len(repo.Permissions) > 0 {
+			sb.WriteString("\n**Excessive permissions**\n\n| Job | File | Permissions | Third-party actions |\n|-----|------|-------------|---------------------|\n")
+			for _, finding := range repo.Permissions {
+				sb.WriteString(fmt.Sprintf("| %s | %s:%d | %s | %s |\n",
+					finding.Job, finding.File, finding.Line, finding.Issue, strings.Join(finding.Actions, ", ")))
+			}
+		}
+		failed := make(map[string]bool)
+		if len(repo.Failures) > 0 {
+			sb.WriteString("\n**Failures**\n\n| Stage | Action | File | Error |\n|-------|--------|------|-------|\n")
+			for _, failure := range repo.Failures {
+				failed[failure.Message] = true
+				sb.WriteString(fmt.Sprintf("| %s | %s | %s | %s |\n",
+					failure.Stage, failure.action(), failure.location(), strings.ReplaceAll(failure.Message, "|", "\\|")))
+			}
+		}
+		var errs []string
+		if repo.Error != "" {
+			errs = append(errs, repo.Error)
+		}
+		for _, warning := range repo.Warnings {
+			if !failed[warning] {
+				errs = append(errs, warning)
+			}
+		}
+		if len(errs) > 0 {
+			sb.WriteString("\n**Errors**\n\n")
+			for _, msg := range errs {
+				sb.WriteString(fmt.Sprintf("- %s\n", msg))
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// AppendSummary appends the Markdown run summary to path, creating it if
+// needed, so it can be pointed at $GITHUB_STEP_SUMMARY
+func (r *Report) AppendSummary(path string) error {
+	// #nosec G304 - path is provided by the user running the tool
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf(common.ErrWritingReport, err)
+	}
+	if err := r.EncodeSummary(file); err != nil {
+		_ = file.Close()
+		return fmt.Errorf(common.ErrWritingReport, err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf(common.ErrWritingReport, err)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package report
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/updater"
+)
+
+// FormatCSV writes the inventory as comma separated values
+const FormatCSV = "csv"
+
+// InventoryFormats lists the supported inventory formats
+var InventoryFormats = []string{FormatJSON, FormatMarkdown, FormatCSV}
+
+// Inventory lists every distinct action version used by a run
+type Inventory struct {
+	SchemaVersion int                      `json:"schema_version"`
+	GeneratedAt   time.Time                `json:"generated_at"`
+	Actions       []updater.InventoryEntry `json:"actions"`
+}
+
+// NewInventory creates an inventory of entries
+func NewInventory(entries []updater.InventoryEntry) *Inventory {
+	if entries == nil {
+		entries = []updater.InventoryEntry{}
+	}
+	return &Inventory{SchemaVersion: SchemaVersion, GeneratedAt: time.Now().UTC(), Actions: entries}
+}
+
+// Encode writes the inventory to w in the given format
+func (inv *Inventory) Encode(w io.Writer, format string) error {
+	var err error
+	switch strings.ToLower(format) {
+	case FormatJSON, "":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(inv)
+	case FormatMarkdown, "md":
+		err = inv.encodeMarkdown(w)
+	case FormatCSV:
+		err = inv.encodeCSV(w)
+	default:
+		return fmt.Errorf(common.ErrUnsupportedReportFormat, format)
+	}
+	if err != nil {
+		return fmt.Errorf(common.ErrWritingReport, err)
+	}
+	return nil
+}
+
+// Write writes the inventory to path in the given format
+func (inv *Inventory) Write(path, format string) error {
+	var buf bytes.Buffer
+	if err := inv.Encode(&buf, format); err != nil {
+		return err
+	}
+	options := common.DefaultFileOptions()
+	options.Mode = 0644
+	if err := common.WriteFileWithOptions(path, buf.Bytes(), options); err != nil {
+		return fmt.Errorf(common.ErrWritingReport, err)
+	}
+	return nil
+}
+
+// encodeMarkdown writes one table row per action version
+func (inv *Inventory) encodeMarkdown(w io.Writer) error {
+	var sb strings.Builder
+	sb.WriteString("# GitHub Actions Inventory\n\n")
+	sb.WriteString(fmt.Sprintf("%d action versions\n\n", len(inv.Actions)))
+	sb.WriteString("| Action | Version | Latest | Age (days) | License | Pinned | Workflows | Repositories |\n")
+	sb.WriteString("|--------|---------|--------|------------|---------|--------|-----------|--------------|\n")
+	for _, entry := range inv.Actions {
+		sb.WriteString(fmt.Sprintf("| `%s` | %s | %s | %s | %s | %s | %d | %s |\n",
+			entry.Action, entry.Version, entry.Latest, ageDays(entry), entry.License, entry.PinStatus, entry.Workflows, strings.Join(entry.Repositories, ", ")))
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// encodeCSV writes a header and one record per action version
+func (inv *Inventory) encodeCSV(w io.Writer) error {
+	out := csv.NewWriter(w)
+	_ = out.Write([]string{"action", "version", "latest", "released_at", "age_days", "license", "pin_status", "workflows", "references", "repositories"})
+	for _, entry := range inv.Actions {
+		released := ""
+		if entry.ReleasedAt != nil {
+			released = entry.ReleasedAt.Format(time.RFC3339)
+		}
+		_ = out.Write([]string{
+			entry.Action, entry.Version, entry.Latest, released, ageDays(entry), entry.License, entry.PinStatus,
+			strconv.Itoa(entry.Workflows), strconv.Itoa(entry.References), strings.Join(entry.Repositories, " "),
+		})
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// ageDays formats the age of the entry's version, or "" when unknown
+func ageDays(entry updater.InventoryEntry) string {
+	if entry.ReleasedAt == nil {
+		return ""
+	}
+	return strconv.Itoa(entry.AgeDays)
+}
@@ -0,0 +1,51 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/updater"
+)
+
+func TestInventoryEncode(t *testing.T) {
+	released := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	inv := NewInventory([]updater.InventoryEntry{
+		{Action: "actions/checkout", Version: "v3", Repositories: []string{"o/a", "o/b"}, Workflows: 3, References: 4, PinStatus: updater.PinStatusMixed, Latest: "v4", ReleasedAt: &released, AgeDays: 30, License: "MIT"},
+		{Action: "actions/cache", Version: "main", Repositories: []string{"o/b"}, Workflows: 1, References: 1, PinStatus: updater.PinStatusUnpinned},
+	})
+
+	tests := []struct {
+		format string
+		want   []string
+	}{
+		{FormatJSON, []string{`"schema_version": 1`, `"pin_status": "mixed"`, `"released_at": "2024-01-01T00:00:00Z"`}},
+		{FormatMarkdown, []string{"2 action versions", "| `actions/checkout` | v3 | v4 | 30 | MIT | mixed | 3 | o/a, o/b |", "| `actions/cache` | main |  |  |  | unpinned | 1 | o/b |"}},
+		{FormatCSV, []string{"action,version,latest,released_at,age_days,license,pin_status,workflows,references,repositories\n", "actions/checkout,v3,v4,2024-01-01T00:00:00Z,30,MIT,mixed,3,4,o/a o/b\n"}},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := inv.Encode(&buf, tt.format); err != nil {
+			t.Fatalf("Encode(%s) error = %v", tt.format, err)
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(buf.String(), want) {
+				t.Errorf("Encode(%s) missing %q:\n%s", tt.format, want, buf.String())
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := inv.Encode(&buf, FormatText); err == nil {
+		t.Error("Encode(text) expected error")
+	}
+	if err := NewInventory(nil).Encode(&buf, FormatJSON); err != nil {
+		t.Fatal(err)
+	}
+	var empty Inventory
+	if err := json.Unmarshal(buf.Bytes(), &empty); err != nil || empty.Actions == nil {
+		t.Errorf("empty inventory = %s", buf.String())
+	}
+}
@@ -0,0 +1,58 @@
+package report
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+)
+
+// Merge combines partial reports, e.g. from the shards of a run, into a
+// single report. When a repository appears in several reports, a successful
+// result wins over a failed one and otherwise the most recent report wins.
+// Repositories are sorted by name.
+func Merge(reports ...*Report) (*Report, error) {
+	if len(reports) == 0 {
+		return nil, fmt.Errorf(common.ErrNoReportsToMerge)
+	}
+
+	type candidate struct {
+		result      RepositoryResult
+		generatedAt time.Time
+	}
+	byName := make(map[string]candidate)
+
+	for _, r := range reports {
+		if r.SchemaVersion != SchemaVersion {
+			return nil, fmt.Errorf(common.ErrUnsupportedReportSchema, "input", r.SchemaVersion, SchemaVersion)
+		}
+		for _, result := range r.Repositories {
+			key := strings.ToLower(result.Owner + "/" + result.Repo)
+			existing, ok := byName[key]
+			if ok && !replaces(result, r.GeneratedAt, existing.result, existing.generatedAt) {
+				continue
+			}
+			byName[key] = candidate{result: result, generatedAt: r.GeneratedAt}
+		}
+	}
+
+	merged := New("")
+	for _, c := range byName {
+		merged.Add(c.result)
+	}
+	sort.Slice(merged.Repositories, func(i, j int) bool {
+		a, b := merged.Repositories[i], merged.Repositories[j]
+		return strings.ToLower(a.Owner+"/"+a.Repo) < strings.ToLower(b.Owner+"/"+b.Repo)
+	})
+	return merged, nil
+}
+
+// replaces reports whether result should replace the existing result
+func replaces(result RepositoryResult, at time.Time, existing RepositoryResult, existingAt time.Time) bool {
+	if (result.Error == "") != (existing.Error == "") {
+		return result.Error == ""
+	}
+	return !at.Before(existingAt)
+}
@@ -0,0 +1,190 @@
+package report
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/updater"
+)
+
+func TestMerge(t *testing.T) {
+	older := New("1/2")
+	older.GeneratedAt = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	older.Add(RepositoryResult{Owner: "acme", Repo: "b", Updates: []UpdateEntry{{Action: "actions/checkout"}}})
+	older.Add(RepositoryResult{Owner: "acme", Repo: "c", FilesScanned: 1})
+	older.Add(RepositoryResult{Owner: "acme", Repo: "d", FilesScanned: 1})
+
+	newer := New("2/2")
+	newer.GeneratedAt = older.GeneratedAt.Add(time.Hour)
+	newer.Add(RepositoryResult{Owner: "acme", Repo: "a"})
+	newer.Add(RepositoryResult{Owner: "ACME", Repo: "C", FilesScanned: 2})
+	newer.Add(RepositoryResult{Owner: "acme", Repo: "d", Error: "rate limited"})
+
+	merged, err := Merge(older, newer)
+	if err != nil {
+		t.Fatalf("Merge() error = %v", err)
+	}
+
+	var names []string
+	for _, repo := range merged.Repositories {
+		names = append(names, repo.Owner+"/"+repo.Repo)
+	}
+	if got := strings.Join(names, ","); got != "acme/a,acme/b,ACME/C,acme/d" {
+		t.Errorf("merged repositories = %s", got)
+	}
+	if merged.Repositories[2].FilesScanned != 2 {
+		t.Errorf("newer result should win for duplicates: %+v", merged.Repositories[2])
+	}
+	if merged.Repositories[3].Error != "" {
+		t.Errorf("successful result should win over failure: %+v", merged.Repositories[3])
+	}
+	if merged.Shard != "" || merged.SchemaVersion != SchemaVersion {
+		t.Errorf("unexpected merged metadata: shard=%q schema=%d", merged.Shard, merged.SchemaVersion)
+	}
+	if merged.UpdateCount() != 1 {
+		t.Errorf("UpdateCount() = %d, want 1", merged.UpdateCount())
+	}
+}
+
+func TestMergeErrors(t *testing.T) {
+	if _, err := Merge(); err == nil {
+		t.Error("Merge() expected error with no reports")
+	}
+	old := New("")
+	old.SchemaVersion = 99
+	if _, err := Merge(New(""), old); err == nil {
+		t.Error("Merge() expected error for unsupported schema version")
+	}
+}
+
+func TestEncodeFormats(t *testing.T) {
+	r := New("")
+	r.Add(RepositoryResult{Owner: "acme", Repo: "one", FilesScanned: 1, Updates: []UpdateEntry{{
+		Action: "actions/checkout", File: ".github/workflows/ci.yml", Line: 7, OldVersion: "v3", NewVersion: "v4",
+	}}})
+	r.Add(RepositoryResult{Owner: "acme", Repo: "two", Error: "not found"})
+
+	tests := map[string][]string{
+		FormatJSON:     {`"schema_version": 1`, `"action": "actions/checkout"`},
+		FormatMarkdown: {"| acme/one | 1 | 1 | ok |", "| acme/two | 0 | 0 | error: not found |", "`actions/checkout` in .github/workflows/ci.yml:7: v3 → v4"},
+		FormatText:     {"acme/one: .github/workflows/ci.yml:7: actions/checkout v3 -> v4", "acme/two: error: not found", "2 repositories, 1 updates"},
+	}
+	for format, wants := range tests {
+		var buf bytes.Buffer
+		if err := r.Encode(&buf, format); err != nil {
+			t.Fatalf("Encode(%s) error = %v", format, err)
+		}
+		for _, want := range wants {
+			if !strings.Contains(buf.String(), want) {
+				t.Errorf("Encode(%s) missing %q:\n%s", format, want, buf.String())
+			}
+		}
+	}
+
+	if err := r.Encode(&bytes.Buffer{}, "xml"); err == nil {
+		t.Error("Encode() expected error for unsupported format")
+	}
+}
+
+func TestAppendSummary(t *testing.T) {
+	r := New("")
+	r.Mode = updater.ModeStage
+	r.Add(RepositoryResult{Owner: "acme", Repo: "one", FilesScanned: 1, Workflows: []string{".github/workflows/ci.yml"},
+		PinnedActions: 1, UnpinnedActions: 2, PullRequest: 4, Warnings: []string{"failed to check actions/cache"},
+		Updates: []UpdateEntry{{Action: "actions/checkout", File: ".github/workflows/ci.yml", Line: 7, OldVersion: "v3", NewVersion: "v4"}}})
+	r.Add(RepositoryResult{Owner: "acme", Repo: "two", Error: "not found"})
+	r.Add(RepositoryResult{Owner: "acme", Repo: "three", FilesScanned: 1,
+		SchemaErrors: []SchemaEntry{{File: ".github/workflows/ci.yml", Line: 4, Path: "jobs.build", Message: `missing required key "runs-on"`}},
+		Permissions:  []PermissionEntry{{File: ".github/workflows/ci.yml", Line: 3, Job: "build", Issue: "missing", Actions: []string{"octo/tool", "octo/deploy"}}}})
+
+	path := filepath.Join(t.TempDir(), "summary.md")
+	if err := os.WriteFile(path, []byte("# Existing\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.AppendSummary(path); err != nil {
+		t.Fatalf("AppendSummary() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"# Existing\n",
+		"| acme/one | 1 | 1 | 2 | 1 | 1 warnings |",
+		"| acme/two | 0 | 0 | 0 | 0 | error |",
+		"- `.github/workflows/ci.yml`",
+		"**Updates applied** in #4",
+		"| `actions/checkout` | .github/workflows/ci.yml:7 | v3 | v4 |",
+		"- failed to check actions/cache",
+		"- not found",
+		"| acme/three | 1 | 0 | 0 | 0 | 1 schema errors |",
+		"| .github/workflows/ci.yml:4 | jobs.build | missing required key \"runs-on\" |",
+		"| build | .github/workflows/ci.yml:3 | missing | octo/tool, octo/deploy |",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("summary missing %q:\n%s", want, data)
+		}
+	}
+
+	if err := r.AppendSummary(filepath.Join(path, "missing", "summary.md")); err == nil {
+		t.Error("AppendSummary() expected error for invalid path")
+	}
+}
+
+func TestEncodeFailures(t *testing.T) {
+	checkout := &updater.ActionReference{Owner: "actions", Name: "checkout", Version: "v3", Line: 7}
+	failures := EntriesFromFailures([]updater.Failure{
+		{Stage: updater.FailureParse, File: "broken.yml", Message: "Failed to parse broken.yml: bad | yaml"},
+		{Stage: updater.FailureCheck, File: "ci.yml", Action: checkout, Message: "Failed to check actions/checkout: boom"},
+	})
+	r := New("")
+	r.Add(RepositoryResult{Owner: "acme", Repo: "one", Failures: failures,
+		Warnings: []string{"Failed to parse broken.yml: bad | yaml", "Failed to check actions/checkout: boom", "stale pin"}})
+	r.Add(RepositoryResult{Owner: "acme", Repo: "two"})
+	if r.FailureCount() != 2 {
+		t.Errorf("FailureCount() = %d, want 2", r.FailureCount())
+	}
+
+	var table bytes.Buffer
+	if err := r.EncodeFailures(&table); err != nil {
+		t.Fatalf("EncodeFailures() error = %v", err)
+	}
+	var summary bytes.Buffer
+	if err := r.EncodeSummary(&summary); err != nil {
+		t.Fatalf("EncodeSummary() error = %v", err)
+	}
+	var text bytes.Buffer
+	if err := r.Encode(&text, FormatText); err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	tests := map[string][]string{
+		table.String(): {
+			"2 failures:",
+			"acme/one    parse  -                    broken.yml  Failed to parse broken.yml: bad | yaml",
+			"acme/one    check  actions/checkout@v3  ci.yml:7    Failed to check actions/checkout: boom",
+		},
+		summary.String(): {
+			"| acme/one | 0 | 0 | 0 | 0 | 2 failures |",
+			"| parse | - | broken.yml | Failed to parse broken.yml: bad \\| yaml |",
+			"| check | actions/checkout@v3 | ci.yml:7 | Failed to check actions/checkout: boom |",
+			"**Errors**\n\n- stale pin\n",
+		},
+		text.String(): {"acme/one: ci.yml:7: check failed: Failed to check actions/checkout: boom"},
+	}
+	for out, wants := range tests {
+		for _, want := range wants {
+			if !strings.Contains(out, want) {
+				t.Errorf("output missing %q:\n%s", want, out)
+			}
+		}
+	}
+
+	table.Reset()
+	if err := New("").EncodeFailures(&table); err != nil || table.Len() != 0 {
+		t.Errorf("EncodeFailures() without failures = %q, %v", table.String(), err)
+	}
+}
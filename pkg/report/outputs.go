@@ -0,0 +1,88 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+)
+
+// Output is a step output of a run inside GitHub Actions
+type Output struct {
+	Name  string
+	Value string
+}
+
+// Outputs returns the step outputs of the report:
+//
+//	updates_count   updates found across all repositories
+//	has_updates     "true" when updates were found
+//	pinned_count    remote references pinned to a commit SHA
+//	unpinned_count  remote references to a tag or branch
+//	pr_number       number of the pull request created, when only one was
+//	pr_url          web URLs of the pull requests created, space-separated
+//	error_count     repositories that failed
+func (r *Report) Outputs() []Output {
+	var pinned, unpinned, failed int
+	var numbers, urls []string
+	for _, repo := range r.Repositories {
+		pinned += repo.PinnedActions
+		unpinned += repo.UnpinnedActions
+		if repo.Error != "" {
+			failed++
+		}
+		for _, pull := range repo.CreatedPullRequests() {
+			if pull.Number != 0 {
+				numbers = append(numbers, strconv.Itoa(pull.Number))
+			}
+			if pull.URL != "" {
+				urls = append(urls, pull.URL)
+			}
+		}
+	}
+	number := ""
+	if len(numbers) == 1 {
+		number = numbers[0]
+	}
+	return []Output{
+		{Name: "updates_count", Value: strconv.Itoa(r.UpdateCount())},
+		{Name: "has_updates", Value: strconv.FormatBool(r.UpdateCount() > 0)},
+		{Name: "pinned_count", Value: strconv.Itoa(pinned)},
+		{Name: "unpinned_count", Value: strconv.Itoa(unpinned)},
+		{Name: "pr_number", Value: number},
+		{Name: "pr_url", Value: strings.Join(urls, " ")},
+		{Name: "error_count", Value: strconv.Itoa(failed)},
+	}
+}
+
+// EncodeOutputs writes the step outputs as name=value lines, the format of
+// $GITHUB_OUTPUT
+func (r *Report) EncodeOutputs(w io.Writer) error {
+	var sb strings.Builder
+	for _, output := range r.Outputs() {
+		fmt.Fprintf(&sb, "%s=%s\n", output.Name, output.Value)
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// AppendOutputs appends the step outputs to path, creating it if needed,
+// so it can be pointed at $GITHUB_OUTPUT
+func (r *Report) AppendOutputs(path string) error {
+	// #nosec G304 - path is provided by the user running the tool
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf(common.ErrWritingReport, err)
+	}
+	if err := r.EncodeOutputs(file); err != nil {
+		_ = file.Close()
+		return fmt.Errorf(common.ErrWritingReport, err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf(common.ErrWritingReport, err)
+	}
+	return nil
+}
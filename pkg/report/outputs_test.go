@@ -0,0 +1,66 @@
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAppendOutputs(t *testing.T) {
+	tests := []struct {
+		name    string
+		results []RepositoryResult
+		want    string
+	}{
+		{
+			name: "pull request",
+			results: []RepositoryResult{{
+				Owner: "org", Repo: "one",
+				Updates:     []UpdateEntry{{Action: "actions/checkout", NewVersion: "v4"}},
+				PullRequest: 7, PullURL: "https://github.com/org/one/pull/7",
+				PinnedActions: 1, UnpinnedActions: 2,
+			}},
+			want: "updates_count=1\nhas_updates=true\npinned_count=1\nunpinned_count=2\npr_number=7\npr_url=https://github.com/org/one/pull/7\nerror_count=0\n",
+		},
+		{
+			name: "several pull requests",
+			results: []RepositoryResult{{
+				Owner: "org", Repo: "one",
+				PullRequest: 7, PullURL: "https://github.com/org/one/pull/7",
+				PullRequests: []PullRequestEntry{{Number: 7, URL: "https://github.com/org/one/pull/7"}, {Number: 8, URL: "https://github.com/org/one/pull/8"}},
+			}},
+			want: "updates_count=0\nhas_updates=false\npinned_count=0\nunpinned_count=0\npr_number=\npr_url=https://github.com/org/one/pull/7 https://github.com/org/one/pull/8\nerror_count=0\n",
+		},
+		{
+			name: "several repositories",
+			results: []RepositoryResult{
+				{Owner: "org", Repo: "one", PullRequest: 7, PullURL: "https://github.com/org/one/pull/7", UnpinnedActions: 1},
+				{Owner: "org", Repo: "two", PullRequest: 3, PullURL: "https://github.com/org/two/pull/3"},
+				{Owner: "org", Repo: "three", Error: "boom"},
+			},
+			want: "updates_count=0\nhas_updates=false\npinned_count=0\nunpinned_count=1\npr_number=\npr_url=https://github.com/org/one/pull/7 https://github.com/org/two/pull/3\nerror_count=1\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := New("")
+			for _, result := range tt.results {
+				r.Add(result)
+			}
+			path := filepath.Join(t.TempDir(), "output")
+			if err := os.WriteFile(path, []byte("earlier=step\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := r.AppendOutputs(path); err != nil {
+				t.Fatalf("AppendOutputs() error = %v", err)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := string(data); got != "earlier=step\n"+tt.want {
+				t.Errorf("outputs = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
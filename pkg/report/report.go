@@ -0,0 +1,338 @@
+// Package report defines the machine-readable JSON report written after a run.
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/updater"
+)
+
+// SchemaVersion is the version of the report format written by this build
+const SchemaVersion = 1
+
+// Report is the result of a run over one or more repositories
+type Report struct {
+	SchemaVersion int                `json:"schema_version"`
+	GeneratedAt   time.Time          `json:"generated_at"`
+	Shard         string             `json:"shard,omitempty"`
+	Mode          string             `json:"mode,omitempty"` // updater.ModePR, ModeStage or ModeDryRun
+	Repositories  []RepositoryResult `json:"repositories"`
+}
+
+// RepositoryResult is the outcome of processing a single repository
+type RepositoryResult struct {
+	Owner        string        `json:"owner"`
+	Repo         string        `json:"repo"`
+	FilesScanned int           `json:"files_scanned"`
+	LocalActions int           `json:"local_actions,omitempty"`
+	Updates      []UpdateEntry `json:"updates,omitempty"`
+	PullRequest  int           `json:"pull_request,omitempty"`     // Number of the (first) pull request created, when known
+	PullURL      string        `json:"pull_request_url,omitempty"` // Web URL of that pull request, when known
+	Error        string        `json:"error,omitempty"`
+
+	PullRequests []PullRequestEntry `json:"pull_requests,omitempty"` // Every pull request created, e.g. with -max-updates-per-pr
+
+	Workflows       []string         `json:"workflows,omitempty"`        // Scanned files, relative to the repository root
+	PinnedActions   int              `json:"pinned_actions,omitempty"`   // Remote references pinned to a commit SHA
+	UnpinnedActions int              `json:"unpinned_actions,omitempty"` // Remote references to a tag or branch
+	Unpinned        []ReferenceEntry `json:"unpinned,omitempty"`         // Where those references are
+	Warnings        []string         `json:"warnings,omitempty"`         // Failures that did not stop the run
+	Failures        []FailureEntry   `json:"failures,omitempty"`         // Files and references that were skipped
+
+	CommentDrift []DriftEntry      `json:"comment_drift,omitempty"` // Pins whose version comment names another commit
+	StalePins    []StalePinEntry   `json:"stale_pins,omitempty"`    // References older than -max-pin-age
+	SchemaErrors []SchemaEntry     `json:"schema_errors,omitempty"` // Structural errors found by -validate-schema
+	Permissions  []PermissionEntry `json:"permissions,omitempty"`   // Jobs found by -check-permissions
+}
+
+// UpdateEntry describes a single proposed action update
+type UpdateEntry struct {
+	Action     string `json:"action"`
+	File       string `json:"file"`
+	Line       int    `json:"line"`
+	OldVersion string `json:"old_version,omitempty"`
+	NewVersion string `json:"new_version"`
+	OldHash    string `json:"old_hash,omitempty"`
+	NewHash    string `json:"new_hash"`
+	MovedTo    string `json:"moved_to,omitempty"` // New name of an action whose repository moved
+}
+
+// PullRequestEntry describes a pull request created for a repository
+type PullRequestEntry struct {
+	Number  int    `json:"number,omitempty"`
+	URL     string `json:"url,omitempty"`
+	Updates int    `json:"updates"` // Updates it applies
+}
+
+// ReferenceEntry locates an action reference
+type ReferenceEntry struct {
+	Action string `json:"action"`
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Ref    string `json:"ref"`
+}
+
+// DriftEntry describes a reference pinned to a commit that is not the
+// version named in its comment
+type DriftEntry struct {
+	Action  string   `json:"action"`
+	File    string   `json:"file"`
+	Line    int      `json:"line"`
+	Hash    string   `json:"hash"`           // Pinned commit
+	Comment string   `json:"comment"`        // Version named in the comment
+	Tags    []string `json:"tags,omitempty"` // Tags pointing at the pinned commit
+	Fixed   string   `json:"fixed,omitempty"`
+}
+
+// StalePinEntry describes a reference to a version older than the maximum
+// pin age
+type StalePinEntry struct {
+	Action string    `json:"action"`
+	File   string    `json:"file"`
+	Line   int       `json:"line"`
+	Ref    string    `json:"ref"`  // Version in use, or the pinned commit
+	Date   time.Time `json:"date"` // Release of the version or date of the pinned commit
+}
+
+// SchemaEntry describes a place where a workflow breaks the workflow schema
+type SchemaEntry struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column,omitempty"`
+	Path    string `json:"path,omitempty"` // Location in the workflow, e.g. jobs.build.steps[0]
+	Message string `json:"message"`
+}
+
+// PermissionEntry describes a job that hands a token with broad permissions
+// to third-party actions
+type PermissionEntry struct {
+	File    string   `json:"file"`
+	Line    int      `json:"line"`
+	Job     string   `json:"job"`
+	Issue   string   `json:"issue"` // updater.PermissionsWriteAll or PermissionsMissing
+	Actions []string `json:"actions"`
+	Message string   `json:"message"`
+}
+
+// FailureEntry describes a file or reference skipped because of an error
+type FailureEntry struct {
+	Stage   string `json:"stage"` // updater.FailureParse, FailureCheck or FailureUpdate
+	Action  string `json:"action,omitempty"`
+	Ref     string `json:"ref,omitempty"`
+	File    string `json:"file"`
+	Line    int    `json:"line,omitempty"`
+	Message string `json:"message"`
+}
+
+// CreatedPullRequests returns the pull requests created for the repository,
+// falling back to PullRequest and PullURL for results that do not list them
+func (r RepositoryResult) CreatedPullRequests() []PullRequestEntry {
+	if len(r.PullRequests) > 0 || (r.PullRequest == 0 && r.PullURL == "") {
+		return r.PullRequests
+	}
+	return []PullRequestEntry{{Number: r.PullRequest, URL: r.PullURL, Updates: len(r.Updates)}}
+}
+
+// New creates an empty report for the given shard ("" when not sharded)
+func New(shard string) *Report {
+	return &Report{
+		SchemaVersion: SchemaVersion,
+		GeneratedAt:   time.Now().UTC(),
+		Shard:         shard,
+		Repositories:  []RepositoryResult{},
+	}
+}
+
+// Add appends a repository result to the report
+func (r *Report) Add(result RepositoryResult) {
+	r.Repositories = append(r.Repositories, result)
+}
+
+// UpdateCount returns the total number of updates across all repositories
+func (r *Report) UpdateCount() int {
+	count := 0
+	for _, repo := range r.Repositories {
+		count += len(repo.Updates)
+	}
+	return count
+}
+
+// FailureCount returns the total number of failures across all repositories
+func (r *Report) FailureCount() int {
+	count := 0
+	for _, repo := range r.Repositories {
+		count += len(repo.Failures)
+	}
+	return count
+}
+
+// EntriesFromUpdates converts updater updates into report entries
+func EntriesFromUpdates(updates []*updater.Update) []UpdateEntry {
+	entries := make([]UpdateEntry, 0, len(updates))
+	for _, update := range updates {
+		entries = append(entries, UpdateEntry{
+			Action:     update.Action.FullName(),
+			File:       update.FilePath,
+			Line:       update.LineNumber,
+			OldVersion: update.OldVersion,
+			NewVersion: update.NewVersion,
+			OldHash:    update.OldHash,
+			NewHash:    update.NewHash,
+			MovedTo:    update.MovedTo,
+		})
+	}
+	return entries
+}
+
+// EntriesFromPullRequests converts updater pull requests into report entries
+func EntriesFromPullRequests(pulls []updater.PullRequest) []PullRequestEntry {
+	entries := make([]PullRequestEntry, 0, len(pulls))
+	for _, pull := range pulls {
+		entries = append(entries, PullRequestEntry{Number: pull.Number, URL: pull.URL, Updates: len(pull.Updates)})
+	}
+	return entries
+}
+
+// EntriesFromDrift converts updater comment drifts into report entries
+func EntriesFromDrift(drifts []updater.CommentDrift) []DriftEntry {
+	entries := make([]DriftEntry, 0, len(drifts))
+	for _, drift := range drifts {
+		entries = append(entries, DriftEntry{
+			Action:  drift.Action.FullName(),
+			File:    drift.File,
+			Line:    drift.Action.Line,
+			Hash:    drift.Action.CommitHash,
+			Comment: drift.Action.Version,
+			Tags:    drift.Tags,
+			Fixed:   drift.Fixed,
+		})
+	}
+	return entries
+}
+
+// EntriesFromStalePins converts updater stale pins into report entries
+func EntriesFromStalePins(pins []updater.StalePin) []StalePinEntry {
+	entries := make([]StalePinEntry, 0, len(pins))
+	for _, pin := range pins {
+		entries = append(entries, StalePinEntry{
+			Action: pin.Action.FullName(),
+			File:   pin.File,
+			Line:   pin.Action.Line,
+			Ref:    pin.Action.Version,
+			Date:   pin.Date.UTC(),
+		})
+	}
+	return entries
+}
+
+// EntriesFromSchemaErrors converts updater schema errors into report entries
+func EntriesFromSchemaErrors(errs []updater.SchemaError) []SchemaEntry {
+	entries := make([]SchemaEntry, 0, len(errs))
+	for _, schemaErr := range errs {
+		entries = append(entries, SchemaEntry{
+			File:    schemaErr.File,
+			Line:    schemaErr.Line,
+			Column:  schemaErr.Column,
+			Path:    schemaErr.Path,
+			Message: schemaErr.Message,
+		})
+	}
+	return entries
+}
+
+// EntriesFromPermissions converts updater permission findings into report
+// entries
+func EntriesFromPermissions(findings []updater.PermissionFinding) []PermissionEntry {
+	entries := make([]PermissionEntry, 0, len(findings))
+	for _, finding := range findings {
+		entries = append(entries, PermissionEntry{
+			File:    finding.Path,
+			Line:    finding.Line,
+			Job:     finding.Job,
+			Issue:   finding.Issue,
+			Actions: finding.Actions,
+			Message: finding.Message(),
+		})
+	}
+	return entries
+}
+
+// EntriesFromFailures converts updater failures into report entries
+func EntriesFromFailures(failures []updater.Failure) []FailureEntry {
+	entries := make([]FailureEntry, 0, len(failures))
+	for _, failure := range failures {
+		entry := FailureEntry{Stage: failure.Stage, File: failure.File, Message: failure.Message}
+		if failure.Action != nil {
+			entry.Action = failure.Action.FullName()
+			entry.Ref = failure.Action.Version
+			entry.Line = failure.Action.Line
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// EntriesFromUnpinned lists the references not pinned to a commit SHA
+func EntriesFromUnpinned(refs []updater.ActionReference) []ReferenceEntry {
+	var entries []ReferenceEntry
+	for _, ref := range refs {
+		if ref.RefType() == updater.RefTypeSHA {
+			continue
+		}
+		entries = append(entries, ReferenceEntry{Action: ref.FullName(), File: ref.Path, Line: ref.Line, Ref: ref.Version})
+	}
+	return entries
+}
+
+// CountPinned counts the references pinned to a commit SHA and the others
+func CountPinned(refs []updater.ActionReference) (pinned, unpinned int) {
+	for _, ref := range refs {
+		if ref.RefType() == updater.RefTypeSHA {
+			pinned++
+		} else {
+			unpinned++
+		}
+	}
+	return pinned, unpinned
+}
+
+// Write writes the report as indented JSON to path
+func (r *Report) Write(path string) error {
+	return r.WriteFormat(path, FormatJSON)
+}
+
+// WriteFormat writes the report to path in the given output format
+func (r *Report) WriteFormat(path, format string) error {
+	var buf bytes.Buffer
+	if err := r.Encode(&buf, format); err != nil {
+		return err
+	}
+	options := common.DefaultFileOptions()
+	options.Mode = 0644
+	if err := common.WriteFileWithOptions(path, buf.Bytes(), options); err != nil {
+		return fmt.Errorf(common.ErrWritingReport, err)
+	}
+	return nil
+}
+
+// Read loads a report from path
+func Read(path string) (*Report, error) {
+	// #nosec G304 - path is provided by the user running the tool
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf(common.ErrReadingReport, path, err)
+	}
+	var r Report
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf(common.ErrReadingReport, path, err)
+	}
+	if r.SchemaVersion != SchemaVersion {
+		return nil, fmt.Errorf(common.ErrUnsupportedReportSchema, path, r.SchemaVersion, SchemaVersion)
+	}
+	return &r, nil
+}
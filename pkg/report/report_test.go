@@ -0,0 +1,65 @@
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/updater"
+)
+
+func TestWriteAndRead(t *testing.T) {
+	r := New("2/4")
+	r.Add(RepositoryResult{
+		Owner:        "org",
+		Repo:         "one",
+		FilesScanned: 2,
+		Updates: EntriesFromUpdates([]*updater.Update{{
+			Action:     updater.ActionReference{Owner: "actions", Name: "checkout"},
+			FilePath:   ".github/workflows/ci.yml",
+			LineNumber: 7,
+			OldVersion: "v3",
+			NewVersion: "v4",
+			NewHash:    "abc",
+		}}),
+	})
+	r.Add(RepositoryResult{Owner: "org", Repo: "two", Error: "boom"})
+
+	path := filepath.Join(t.TempDir(), "out", "report.json")
+	if err := r.Write(path); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	got, err := Read(path)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if got.Shard != "2/4" || len(got.Repositories) != 2 {
+		t.Fatalf("unexpected report: %+v", got)
+	}
+	if got.UpdateCount() != 1 {
+		t.Errorf("UpdateCount() = %d, want 1", got.UpdateCount())
+	}
+	entry := got.Repositories[0].Updates[0]
+	if entry.Action != "actions/checkout" || entry.Line != 7 || entry.NewVersion != "v4" {
+		t.Errorf("unexpected entry: %+v", entry)
+	}
+	if got.Repositories[1].Error != "boom" {
+		t.Errorf("error not preserved: %+v", got.Repositories[1])
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := Read(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("Read() expected error for missing file")
+	}
+
+	bad := filepath.Join(dir, "bad.json")
+	if err := os.WriteFile(bad, []byte("{not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Read(bad); err == nil {
+		t.Error("Read() expected error for invalid JSON")
+	}
+}
@@ -0,0 +1,70 @@
+// Package shard splits a list of repositories deterministically across
+// several independent jobs.
+package shard
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+)
+
+// Shard identifies one of Count partitions. Index is 1-based so it can be
+// taken directly from CI matrix values such as "2/4".
+type Shard struct {
+	Index int
+	Count int
+}
+
+// All is the single shard containing every repository
+var All = Shard{Index: 1, Count: 1}
+
+// Parse parses a shard specification of the form "i/N"
+func Parse(spec string) (Shard, error) {
+	parts := strings.Split(strings.TrimSpace(spec), "/")
+	if len(parts) != 2 {
+		return Shard{}, fmt.Errorf(common.ErrInvalidShard, spec)
+	}
+	index, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return Shard{}, fmt.Errorf(common.ErrInvalidShard, spec)
+	}
+	count, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return Shard{}, fmt.Errorf(common.ErrInvalidShard, spec)
+	}
+	if count < 1 || index < 1 || index > count {
+		return Shard{}, fmt.Errorf(common.ErrInvalidShard, spec)
+	}
+	return Shard{Index: index, Count: count}, nil
+}
+
+// String returns the shard in "i/N" form
+func (s Shard) String() string {
+	return fmt.Sprintf("%d/%d", s.Index, s.Count)
+}
+
+// Contains reports whether the named repository belongs to this shard. The
+// assignment depends only on the name, so every job agrees on it regardless
+// of the order in which repositories were listed.
+func (s Shard) Contains(name string) bool {
+	if s.Count <= 1 {
+		return true
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(strings.ToLower(name)))
+	return int(h.Sum32()%uint32(s.Count)) == s.Index-1
+}
+
+// Filter returns the repositories that belong to this shard, preserving order
+func (s Shard) Filter(names []string) []string {
+	var result []string
+	for _, name := range names {
+		if s.Contains(name) {
+			result = append(result, name)
+		}
+	}
+	return result
+}
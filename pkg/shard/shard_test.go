@@ -0,0 +1,76 @@
+package shard
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    Shard
+		wantErr bool
+	}{
+		{spec: "1/1", want: Shard{Index: 1, Count: 1}},
+		{spec: "2/4", want: Shard{Index: 2, Count: 4}},
+		{spec: " 4/4 ", want: Shard{Index: 4, Count: 4}},
+		{spec: "0/4", wantErr: true},
+		{spec: "5/4", wantErr: true},
+		{spec: "1/0", wantErr: true},
+		{spec: "1", wantErr: true},
+		{spec: "a/b", wantErr: true},
+		{spec: "1/2/3", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := Parse(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("Parse(%q) = %v, want %v", tt.spec, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterPartitionsRepositories(t *testing.T) {
+	var repos []string
+	for i := 0; i < 200; i++ {
+		repos = append(repos, fmt.Sprintf("org/repo-%d", i))
+	}
+
+	const count = 4
+	seen := make(map[string]int)
+	for index := 1; index <= count; index++ {
+		part := Shard{Index: index, Count: count}.Filter(repos)
+		if len(part) == 0 {
+			t.Errorf("shard %d/%d is empty", index, count)
+		}
+		for _, name := range part {
+			seen[name]++
+		}
+	}
+
+	for _, name := range repos {
+		if seen[name] != 1 {
+			t.Errorf("%s assigned to %d shards, want 1", name, seen[name])
+		}
+	}
+}
+
+func TestContainsIsStable(t *testing.T) {
+	s := Shard{Index: 3, Count: 5}
+	for _, name := range []string{"ThreatFlux/githubWorkFlowChecker", "actions/checkout"} {
+		if s.Contains(name) != s.Contains(name) {
+			t.Errorf("Contains(%q) is not deterministic", name)
+		}
+	}
+	if s.Contains("Org/Repo") != s.Contains("org/repo") {
+		t.Error("Contains should be case-insensitive")
+	}
+	if !All.Contains("anything") {
+		t.Error("All should contain every repository")
+	}
+}
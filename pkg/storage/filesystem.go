@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+)
+
+// FileStore stores each key as a file below a base directory
+type FileStore struct {
+	baseDir string
+	locks   *common.FileLockManager
+}
+
+// NewFileStore creates a FileStore rooted at dir, creating it if needed
+func NewFileStore(dir string) (*FileStore, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf(common.ErrInvalidStoreLocation, dir)
+	}
+	if err := os.MkdirAll(absDir, 0750); err != nil {
+		return nil, fmt.Errorf(common.ErrCreatingDirectories, err)
+	}
+	return &FileStore{baseDir: absDir, locks: common.NewFileLockManager()}, nil
+}
+
+// path maps a key to a validated path inside the base directory
+func (s *FileStore) path(key string) (string, error) {
+	if err := validateKey(key); err != nil {
+		return "", err
+	}
+	return common.JoinAndValidatePath(s.baseDir, s.baseDir, filepath.FromSlash(key))
+}
+
+// Get implements Store
+func (s *FileStore) Get(ctx context.Context, key string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	p, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	if !common.FileExists(p) {
+		return nil, ErrNotFound
+	}
+
+	var data []byte
+	err = s.locks.WithFileLock(p, func() error {
+		var readErr error
+		data, readErr = common.ReadFile(p)
+		return readErr
+	})
+	return data, err
+}
+
+// Put implements Store
+func (s *FileStore) Put(ctx context.Context, key string, value []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	return s.locks.WithFileLock(p, func() error {
+		return common.WriteFile(p, value)
+	})
+}
+
+// Delete implements Store
+func (s *FileStore) Delete(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	return s.locks.WithFileLock(p, func() error {
+		if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	})
+}
+
+// List implements Store
+func (s *FileStore) List(ctx context.Context, prefix string) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	err := filepath.Walk(s.baseDir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || strings.HasSuffix(p, ".tmp") {
+			return nil
+		}
+		rel, err := filepath.Rel(s.baseDir, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf(common.ErrScanningDirectory, err)
+	}
+
+	sort.Strings(keys)
+	return keys, nil
+}
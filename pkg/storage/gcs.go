@@ -0,0 +1,155 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+)
+
+// GCSStore stores keys as objects in a Google Cloud Storage bucket using the
+// JSON API. The OAuth2 access token is read from GOOGLE_OAUTH_ACCESS_TOKEN,
+// e.g. as produced by `gcloud auth print-access-token` or workload identity.
+type GCSStore struct {
+	bucket   string
+	prefix   string
+	endpoint string
+	token    string
+	client   *http.Client
+}
+
+// NewGCSStore creates a GCSStore. An empty endpoint uses storage.googleapis.com.
+func NewGCSStore(bucket, prefix, endpoint string) (*GCSStore, error) {
+	if bucket == "" {
+		return nil, fmt.Errorf(common.ErrInvalidStoreLocation, "gs://")
+	}
+	if endpoint == "" {
+		endpoint = "https://storage.googleapis.com"
+	}
+	return &GCSStore{
+		bucket:   bucket,
+		prefix:   prefix,
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		token:    os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"),
+		client:   &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// newRequest builds an authenticated request for a JSON API path
+func (s *GCSStore) newRequest(ctx context.Context, method, path string, query url.Values, body []byte) (*http.Request, error) {
+	endpoint := s.endpoint + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+	return req, nil
+}
+
+// objectPath returns the JSON API path for an object
+func (s *GCSStore) objectPath(key string) string {
+	return "/storage/v1/b/" + url.PathEscape(s.bucket) + "/o/" + url.PathEscape(joinKey(s.prefix, key))
+}
+
+// Get implements Store
+func (s *GCSStore) Get(ctx context.Context, key string) ([]byte, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+	req, err := s.newRequest(ctx, http.MethodGet, s.objectPath(key), url.Values{"alt": {"media"}}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return doHTTP(s.client, "gcs", req)
+}
+
+// Put implements Store
+func (s *GCSStore) Put(ctx context.Context, key string, value []byte) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	path := "/upload/storage/v1/b/" + url.PathEscape(s.bucket) + "/o"
+	query := url.Values{"uploadType": {"media"}, "name": {joinKey(s.prefix, key)}}
+	req, err := s.newRequest(ctx, http.MethodPost, path, query, value)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	_, err = doHTTP(s.client, "gcs", req)
+	return err
+}
+
+// Delete implements Store
+func (s *GCSStore) Delete(ctx context.Context, key string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	req, err := s.newRequest(ctx, http.MethodDelete, s.objectPath(key), nil, nil)
+	if err != nil {
+		return err
+	}
+	if _, err := doHTTP(s.client, "gcs", req); err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+// gcsListResult is the subset of the objects.list response we need
+type gcsListResult struct {
+	Items []struct {
+		Name string `json:"name"`
+	} `json:"items"`
+	NextPageToken string `json:"nextPageToken"`
+}
+
+// List implements Store
+func (s *GCSStore) List(ctx context.Context, prefix string) ([]string, error) {
+	trim := joinKey(s.prefix, "")
+	path := "/storage/v1/b/" + url.PathEscape(s.bucket) + "/o"
+
+	var keys []string
+	pageToken := ""
+	for {
+		query := url.Values{"prefix": {joinKey(s.prefix, prefix)}}
+		if pageToken != "" {
+			query.Set("pageToken", pageToken)
+		}
+		req, err := s.newRequest(ctx, http.MethodGet, path, query, nil)
+		if err != nil {
+			return nil, err
+		}
+		body, err := doHTTP(s.client, "gcs", req)
+		if err != nil {
+			return nil, err
+		}
+
+		var result gcsListResult
+		if err := json.Unmarshal(body, &result); err != nil {
+			return nil, fmt.Errorf(common.ErrStoreRequest, "gcs", err)
+		}
+		for _, item := range result.Items {
+			keys = append(keys, strings.TrimPrefix(item.Name, trim))
+		}
+		if result.NextPageToken == "" {
+			break
+		}
+		pageToken = result.NextPageToken
+	}
+
+	sort.Strings(keys)
+	return keys, nil
+}
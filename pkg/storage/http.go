@@ -0,0 +1,38 @@
+package storage
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+)
+
+// maxObjectSize limits how much data is read from a remote object
+const maxObjectSize = 32 << 20
+
+// doHTTP executes req and returns the response body. A 404 maps to
+// ErrNotFound and any other non-2xx status to an error naming the backend.
+func doHTTP(client *http.Client, backend string, req *http.Request) ([]byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf(common.ErrStoreRequest, backend, err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			fmt.Printf(common.ErrFailedToCloseBody+"\n", err)
+		}
+	}()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxObjectSize))
+	if err != nil {
+		return nil, fmt.Errorf(common.ErrStoreRequest, backend, err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf(common.ErrStoreRequest, backend, fmt.Errorf("unexpected status %s", resp.Status))
+	}
+	return body, nil
+}
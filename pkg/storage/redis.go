@@ -0,0 +1,281 @@
+package storage
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+)
+
+// RedisStore stores keys in a Redis database using a minimal RESP client.
+// A single connection is used and requests are serialized.
+type RedisStore struct {
+	addr     string
+	password string
+	db       int
+	prefix   string
+	timeout  time.Duration
+
+	mu   sync.Mutex
+	conn net.Conn
+	rd   *bufio.Reader
+}
+
+// NewRedisStore creates a RedisStore from a redis:// URL. The optional
+// "prefix" query parameter namespaces all keys (default "ghactions-updater").
+func NewRedisStore(u *url.URL) (*RedisStore, error) {
+	if u.Host == "" {
+		return nil, fmt.Errorf(common.ErrInvalidStoreLocation, u.String())
+	}
+
+	store := &RedisStore{
+		addr:    u.Host,
+		prefix:  "ghactions-updater",
+		timeout: 10 * time.Second,
+	}
+	if !strings.Contains(u.Host, ":") {
+		store.addr = u.Host + ":6379"
+	}
+	if u.User != nil {
+		store.password, _ = u.User.Password()
+	}
+	if db := strings.Trim(u.Path, "/"); db != "" {
+		n, err := strconv.Atoi(db)
+		if err != nil {
+			return nil, fmt.Errorf(common.ErrInvalidStoreLocation, u.String())
+		}
+		store.db = n
+	}
+	if p := u.Query().Get("prefix"); p != "" {
+		store.prefix = p
+	}
+	return store, nil
+}
+
+// connect opens the connection if needed. Callers must hold s.mu.
+func (s *RedisStore) connect(ctx context.Context) error {
+	if s.conn != nil {
+		return nil
+	}
+
+	dialer := net.Dialer{Timeout: s.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf(common.ErrStoreRequest, "redis", err)
+	}
+	s.conn = conn
+	s.rd = bufio.NewReader(conn)
+
+	if s.password != "" {
+		if _, err := s.roundTrip(ctx, "AUTH", s.password); err != nil {
+			s.close()
+			return err
+		}
+	}
+	if s.db != 0 {
+		if _, err := s.roundTrip(ctx, "SELECT", strconv.Itoa(s.db)); err != nil {
+			s.close()
+			return err
+		}
+	}
+	return nil
+}
+
+// close drops the connection. Callers must hold s.mu.
+func (s *RedisStore) close() {
+	if s.conn != nil {
+		_ = s.conn.Close()
+	}
+	s.conn = nil
+	s.rd = nil
+}
+
+// do sends a command and returns its reply, reconnecting on I/O failures
+func (s *RedisStore) do(ctx context.Context, args ...string) (interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.connect(ctx); err != nil {
+		return nil, err
+	}
+	reply, err := s.roundTrip(ctx, args...)
+	if err != nil {
+		if _, isReplyErr := err.(redisError); !isReplyErr {
+			s.close()
+		}
+		return nil, fmt.Errorf(common.ErrStoreRequest, "redis", err)
+	}
+	return reply, nil
+}
+
+// roundTrip writes a command and reads one reply. Callers must hold s.mu.
+func (s *RedisStore) roundTrip(ctx context.Context, args ...string) (interface{}, error) {
+	deadline := time.Now().Add(s.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	_ = s.conn.SetDeadline(deadline)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&sb, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := io.WriteString(s.conn, sb.String()); err != nil {
+		return nil, err
+	}
+	return readRESP(s.rd)
+}
+
+// redisError is an error reply sent by the server
+type redisError string
+
+func (e redisError) Error() string { return string(e) }
+
+// readRESP reads a single RESP2 value
+func readRESP(rd *bufio.Reader) (interface{}, error) {
+	line, err := rd.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, fmt.Errorf("empty reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(rd, buf); err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		items := make([]interface{}, n)
+		for i := range items {
+			if items[i], err = readRESP(rd); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("unexpected reply %q", line)
+	}
+}
+
+// Get implements Store
+func (s *RedisStore) Get(ctx context.Context, key string) ([]byte, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+	reply, err := s.do(ctx, "GET", joinKey(s.prefix, key))
+	if err != nil {
+		return nil, err
+	}
+	value, ok := reply.([]byte)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return value, nil
+}
+
+// Put implements Store
+func (s *RedisStore) Put(ctx context.Context, key string, value []byte) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	_, err := s.do(ctx, "SET", joinKey(s.prefix, key), string(value))
+	return err
+}
+
+// Delete implements Store
+func (s *RedisStore) Delete(ctx context.Context, key string) error {
+	_, err := s.do(ctx, "DEL", joinKey(s.prefix, key))
+	return err
+}
+
+// List implements Store. It uses SCAN so large databases are not blocked.
+func (s *RedisStore) List(ctx context.Context, prefix string) ([]string, error) {
+	pattern := joinKey(s.prefix, escapeRedisPattern(prefix)) + "*"
+	trim := joinKey(s.prefix, "")
+
+	var keys []string
+	cursor := "0"
+	for {
+		reply, err := s.do(ctx, "SCAN", cursor, "MATCH", pattern, "COUNT", "100")
+		if err != nil {
+			return nil, err
+		}
+		parts, ok := reply.([]interface{})
+		if !ok || len(parts) != 2 {
+			return nil, fmt.Errorf(common.ErrStoreRequest, "redis", fmt.Errorf("unexpected SCAN reply"))
+		}
+		next, _ := parts[0].([]byte)
+		items, _ := parts[1].([]interface{})
+		for _, item := range items {
+			if b, ok := item.([]byte); ok {
+				keys = append(keys, strings.TrimPrefix(string(b), trim))
+			}
+		}
+		cursor = string(next)
+		if cursor == "0" || cursor == "" {
+			break
+		}
+	}
+
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// Push appends value to the Redis list named list, which lets the database
+// double as a simple work queue
+func (s *RedisStore) Push(ctx context.Context, list string, value []byte) error {
+	if err := validateKey(list); err != nil {
+		return err
+	}
+	_, err := s.do(ctx, "RPUSH", joinKey(s.prefix, list), string(value))
+	return err
+}
+
+// Close closes the underlying connection
+func (s *RedisStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.close()
+	return nil
+}
+
+// escapeRedisPattern escapes glob characters in a SCAN MATCH pattern
+func escapeRedisPattern(s string) string {
+	replacer := strings.NewReplacer(`*`, `\*`, `?`, `\?`, `[`, `\[`, `]`, `\]`, `\`, `\\`)
+	return replacer.Replace(s)
+}
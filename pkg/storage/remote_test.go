@@ -0,0 +1,254 @@
+package storage
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeRedis serves a tiny subset of the Redis protocol backed by a map
+func fakeRedis(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+
+	var mu sync.Mutex
+	data := map[string]string{}
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer func() { _ = conn.Close() }()
+				rd := bufio.NewReader(conn)
+				for {
+					reply, err := readRESP(rd)
+					if err != nil {
+						return
+					}
+					items := reply.([]interface{})
+					args := make([]string, len(items))
+					for i, item := range items {
+						args[i] = string(item.([]byte))
+					}
+
+					mu.Lock()
+					switch strings.ToUpper(args[0]) {
+					case "GET":
+						if v, ok := data[args[1]]; ok {
+							_, _ = fmt.Fprintf(conn, "$%d\r\n%s\r\n", len(v), v)
+						} else {
+							_, _ = io.WriteString(conn, "$-1\r\n")
+						}
+					case "SET":
+						data[args[1]] = args[2]
+						_, _ = io.WriteString(conn, "+OK\r\n")
+					case "RPUSH":
+						if data[args[1]] != "" {
+							data[args[1]] += "\n"
+						}
+						data[args[1]] += args[2]
+						_, _ = io.WriteString(conn, ":1\r\n")
+					case "DEL":
+						delete(data, args[1])
+						_, _ = io.WriteString(conn, ":1\r\n")
+					case "SCAN":
+						prefix := strings.TrimSuffix(strings.ReplaceAll(args[3], `\`, ""), "*")
+						var keys []string
+						for k := range data {
+							if strings.HasPrefix(k, prefix) {
+								keys = append(keys, k)
+							}
+						}
+						_, _ = fmt.Fprintf(conn, "*2\r\n$1\r\n0\r\n*%d\r\n", len(keys))
+						for _, k := range keys {
+							_, _ = fmt.Fprintf(conn, "$%d\r\n%s\r\n", len(k), k)
+						}
+					default:
+						_, _ = io.WriteString(conn, "-ERR unknown command\r\n")
+					}
+					mu.Unlock()
+				}
+			}(conn)
+		}
+	}()
+
+	return ln.Addr().String()
+}
+
+func TestRedisStore(t *testing.T) {
+	addr := fakeRedis(t)
+	u, _ := url.Parse("redis://" + addr + "?prefix=test")
+	s, err := NewRedisStore(u)
+	if err != nil {
+		t.Fatalf("NewRedisStore() error = %v", err)
+	}
+	defer func() { _ = s.Close() }()
+	exerciseStore(t, s)
+
+	ctx := context.Background()
+	for _, value := range []string{"one", "two"} {
+		if err := s.Push(ctx, "queue/events", []byte(value)); err != nil {
+			t.Fatalf("Push() error = %v", err)
+		}
+	}
+	// The fake joins list entries with newlines
+	if got, err := s.Get(ctx, "queue/events"); err != nil || string(got) != "one\ntwo" {
+		t.Errorf("pushed list = %q, %v", got, err)
+	}
+	if err := s.Push(ctx, "../events", []byte("x")); err == nil {
+		t.Error("Push() expected error for invalid list name")
+	}
+}
+
+// objectServer is an in-memory object store used to fake S3 and GCS
+type objectServer struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (o *objectServer) list(prefix string) []string {
+	var keys []string
+	for k := range o.objects {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestS3Store(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+
+	objects := &objectServer{objects: map[string][]byte{}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		objects.mu.Lock()
+		defer objects.mu.Unlock()
+
+		key := strings.TrimPrefix(r.URL.Path, "/bucket/")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/bucket":
+			type content struct {
+				Key string `xml:"Key"`
+			}
+			var result struct {
+				XMLName  xml.Name  `xml:"ListBucketResult"`
+				Contents []content `xml:"Contents"`
+			}
+			for _, k := range objects.list(r.URL.Query().Get("prefix")) {
+				result.Contents = append(result.Contents, content{Key: k})
+			}
+			_ = xml.NewEncoder(w).Encode(result)
+		case r.Method == http.MethodGet:
+			v, ok := objects.objects[key]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write(v)
+		case r.Method == http.MethodPut:
+			body, _ := io.ReadAll(r.Body)
+			objects.objects[key] = body
+		case r.Method == http.MethodDelete:
+			delete(objects.objects, key)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	s, err := NewS3Store("bucket", "prefix", "us-east-1", server.URL)
+	if err != nil {
+		t.Fatalf("NewS3Store() error = %v", err)
+	}
+	exerciseStore(t, s)
+
+	if _, ok := objects.objects["prefix/state/runs/owner/repo.json"]; !ok {
+		t.Errorf("expected object stored under prefix, have %v", objects.list(""))
+	}
+}
+
+func TestGCSStore(t *testing.T) {
+	t.Setenv("GOOGLE_OAUTH_ACCESS_TOKEN", "gcs-token")
+
+	objects := &objectServer{objects: map[string][]byte{}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer gcs-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		objects.mu.Lock()
+		defer objects.mu.Unlock()
+
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/upload/storage/v1/b/bucket/o":
+			body, _ := io.ReadAll(r.Body)
+			objects.objects[r.URL.Query().Get("name")] = body
+		case r.Method == http.MethodGet && r.URL.Path == "/storage/v1/b/bucket/o":
+			type item struct {
+				Name string `json:"name"`
+			}
+			var result struct {
+				Items []item `json:"items"`
+			}
+			for _, k := range objects.list(r.URL.Query().Get("prefix")) {
+				result.Items = append(result.Items, item{Name: k})
+			}
+			_ = json.NewEncoder(w).Encode(result)
+		default:
+			name := path.Base(r.URL.EscapedPath())
+			key, _ := url.PathUnescape(name)
+			if r.Method == http.MethodDelete {
+				delete(objects.objects, key)
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			v, ok := objects.objects[key]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write(v)
+		}
+	}))
+	defer server.Close()
+
+	s, err := NewGCSStore("bucket", "", server.URL)
+	if err != nil {
+		t.Fatalf("NewGCSStore() error = %v", err)
+	}
+	exerciseStore(t, s)
+}
+
+func TestAWSEscape(t *testing.T) {
+	if got := awsEscape("a b/c~"); got != "a%20b%2Fc~" {
+		t.Errorf("awsEscape() = %q", got)
+	}
+	q := url.Values{"prefix": {"cache/"}, "list-type": {"2"}}
+	if got := awsCanonicalQuery(q); got != "list-type=2&prefix=cache%2F" {
+		t.Errorf("awsCanonicalQuery() = %q", got)
+	}
+}
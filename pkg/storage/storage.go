@@ -0,0 +1,92 @@
+// Package storage provides pluggable key/value backends used for the version
+// cache and run state, so that serverless and multi-replica deployments can
+// share state between runs.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+)
+
+// ErrNotFound is returned by Get when a key does not exist
+var ErrNotFound = errors.New("key not found")
+
+// Store is a minimal key/value store. Keys are slash separated paths such as
+// "cache/versions/actions/checkout".
+type Store interface {
+	// Get returns the value stored at key or ErrNotFound
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Put stores value at key, replacing any existing value
+	Put(ctx context.Context, key string, value []byte) error
+	// Delete removes key. Deleting a missing key is not an error.
+	Delete(ctx context.Context, key string) error
+	// List returns all keys starting with prefix in lexical order
+	List(ctx context.Context, prefix string) ([]string, error)
+}
+
+// Open creates a Store from a location string. Supported forms are:
+//
+//	/path/to/dir or file:///path/to/dir   filesystem
+//	mem://                                in-memory (not shared)
+//	redis://[:password@]host:port[/db]    Redis
+//	s3://bucket[/prefix]?region=&endpoint= Amazon S3 or S3-compatible storage
+//	gs://bucket[/prefix]                  Google Cloud Storage
+func Open(location string) (Store, error) {
+	if location == "" {
+		return nil, fmt.Errorf(common.ErrInvalidStoreLocation, location)
+	}
+	if !strings.Contains(location, "://") {
+		return NewFileStore(location)
+	}
+
+	u, err := url.Parse(location)
+	if err != nil {
+		return nil, fmt.Errorf(common.ErrInvalidStoreLocation, location)
+	}
+
+	prefix := strings.Trim(u.Path, "/")
+	switch u.Scheme {
+	case "file":
+		return NewFileStore(u.Path)
+	case "mem", "memory":
+		return NewMemoryStore(), nil
+	case "redis":
+		return NewRedisStore(u)
+	case "s3":
+		return NewS3Store(u.Host, prefix, u.Query().Get("region"), u.Query().Get("endpoint"))
+	case "gs", "gcs":
+		return NewGCSStore(u.Host, prefix, u.Query().Get("endpoint"))
+	default:
+		return nil, fmt.Errorf(common.ErrUnsupportedStoreScheme, u.Scheme)
+	}
+}
+
+// validateKey rejects keys that could escape the store's namespace
+func validateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return fmt.Errorf(common.ErrInvalidStoreKey, key)
+	}
+	if path.Clean(key) != key {
+		return fmt.Errorf(common.ErrInvalidStoreKey, key)
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == ".." || part == "." {
+			return fmt.Errorf(common.ErrInvalidStoreKey, key)
+		}
+	}
+	return nil
+}
+
+// joinKey joins a backend prefix and a key
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "/" + key
+}
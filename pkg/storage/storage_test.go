@@ -0,0 +1,126 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// exerciseStore runs the common Store contract against s
+func exerciseStore(t *testing.T, s Store) {
+	t.Helper()
+	ctx := context.Background()
+
+	if _, err := s.Get(ctx, "cache/missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get(missing) error = %v, want ErrNotFound", err)
+	}
+
+	entries := map[string]string{
+		"cache/latest/actions/checkout": `{"version":"v4"}`,
+		"cache/latest/actions/setup-go": `{"version":"v5"}`,
+		"state/runs/owner/repo.json":    `{}`,
+	}
+	for key, value := range entries {
+		if err := s.Put(ctx, key, []byte(value)); err != nil {
+			t.Fatalf("Put(%s) error = %v", key, err)
+		}
+	}
+
+	got, err := s.Get(ctx, "cache/latest/actions/checkout")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if string(got) != `{"version":"v4"}` {
+		t.Errorf("Get() = %s", got)
+	}
+
+	keys, err := s.List(ctx, "cache/")
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	want := []string{"cache/latest/actions/checkout", "cache/latest/actions/setup-go"}
+	if !reflect.DeepEqual(keys, want) {
+		t.Errorf("List() = %v, want %v", keys, want)
+	}
+
+	if err := s.Delete(ctx, "cache/latest/actions/checkout"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if err := s.Delete(ctx, "cache/latest/actions/checkout"); err != nil {
+		t.Fatalf("Delete() of missing key error = %v", err)
+	}
+	if _, err := s.Get(ctx, "cache/latest/actions/checkout"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() after Delete error = %v, want ErrNotFound", err)
+	}
+
+	if err := s.Put(ctx, "../escape", []byte("x")); err == nil {
+		t.Error("Put() with traversal key expected error, got nil")
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	exerciseStore(t, NewMemoryStore())
+}
+
+func TestFileStore(t *testing.T) {
+	s, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	exerciseStore(t, s)
+}
+
+func TestOpen(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		location string
+		wantType interface{}
+		wantErr  bool
+	}{
+		{location: dir, wantType: &FileStore{}},
+		{location: "file://" + filepath.Join(dir, "sub"), wantType: &FileStore{}},
+		{location: "mem://", wantType: &MemoryStore{}},
+		{location: "redis://localhost:6379/2?prefix=test", wantType: &RedisStore{}},
+		{location: "s3://bucket/prefix?region=eu-west-1", wantType: &S3Store{}},
+		{location: "gs://bucket/prefix", wantType: &GCSStore{}},
+		{location: "", wantErr: true},
+		{location: "ftp://example.com", wantErr: true},
+		{location: "redis://localhost/notanumber", wantErr: true},
+		{location: "s3://", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.location, func(t *testing.T) {
+			s, err := Open(tt.location)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Open(%q) expected error, got nil", tt.location)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Open(%q) error = %v", tt.location, err)
+			}
+			if reflect.TypeOf(s) != reflect.TypeOf(tt.wantType) {
+				t.Errorf("Open(%q) = %T, want %T", tt.location, s, tt.wantType)
+			}
+		})
+	}
+}
+
+func TestValidateKey(t *testing.T) {
+	valid := []string{"cache/a", "state/runs/o/r.json"}
+	invalid := []string{"", "/abs", "a/../b", "a//b", `a\b`, "./a", "a/."}
+	for _, key := range valid {
+		if err := validateKey(key); err != nil {
+			t.Errorf("validateKey(%q) error = %v", key, err)
+		}
+	}
+	for _, key := range invalid {
+		if err := validateKey(key); err == nil {
+			t.Errorf("validateKey(%q) expected error, got nil", key)
+		}
+	}
+}
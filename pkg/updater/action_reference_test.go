@@ -64,3 +64,27 @@ func TestFormatActionReference(t *testing.T) {
 		})
 	}
 }
+
+func TestActionReferenceRefType(t *testing.T) {
+	sha := "8e5e7e5ab8b370d6c329ec480221332ada57f0ab"
+	tests := []struct {
+		name string
+		ref  ActionReference
+		want string
+	}{
+		{name: "local", ref: ActionReference{LocalPath: "./.github/actions/build"}, want: RefTypeLocal},
+		{name: "pinned with comment", ref: ActionReference{Version: "v4", CommitHash: sha}, want: RefTypeSHA},
+		{name: "pinned", ref: ActionReference{Version: sha, CommitHash: sha}, want: RefTypeSHA},
+		{name: "short sha", ref: ActionReference{Version: "8e5e7e5"}, want: RefTypeSHA},
+		{name: "major tag", ref: ActionReference{Version: "v4"}, want: RefTypeTag},
+		{name: "semver tag", ref: ActionReference{Version: "1.2.3"}, want: RefTypeTag},
+		{name: "branch", ref: ActionReference{Version: "main"}, want: RefTypeBranch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.ref.RefType(); got != tt.want {
+				t.Errorf("RefType() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
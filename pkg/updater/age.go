@@ -0,0 +1,95 @@
+package updater
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+)
+
+// day is the unit of ages written as Nd
+const day = 24 * time.Hour
+
+// StalePin is a reference whose version is older than Options.MaxPinAge
+type StalePin struct {
+	Action ActionReference
+	File   string
+	Date   time.Time // Release of the version, or date of the pinned commit
+}
+
+// ParseAge parses an age written in days (7d) or as a Go duration (36h);
+// an empty string is no age
+func ParseAge(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf(common.ErrInvalidAge, s)
+		}
+		return time.Duration(n) * day, nil
+	}
+	age, err := time.ParseDuration(s)
+	if err != nil || age < 0 {
+		return 0, fmt.Errorf(common.ErrInvalidAge, s)
+	}
+	return age, nil
+}
+
+// FormatAge writes whole days as Nd and other ages as Go durations
+func FormatAge(age time.Duration) string {
+	if age%day == 0 {
+		return fmt.Sprintf("%dd", age/day)
+	}
+	return age.String()
+}
+
+// publishedAt returns when version of action was released, falling back to
+// the date of its commit hash (looked up from version when empty) for tags
+// without a release
+func publishedAt(ctx context.Context, checker VersionChecker, action ActionReference, version, hash string) (time.Time, error) {
+	var releaseErr error
+	if dates, ok := checker.(ReleaseDateProvider); ok && version != "" && version != hash {
+		date, err := dates.GetReleaseDate(ctx, action, version)
+		if err == nil {
+			return date, nil
+		}
+		releaseErr = err
+	}
+
+	commits, ok := checker.(CommitDateProvider)
+	if !ok {
+		if releaseErr != nil {
+			return time.Time{}, releaseErr
+		}
+		return time.Time{}, fmt.Errorf(common.ErrGettingReleaseDate, version, fmt.Errorf("not supported"))
+	}
+	if hash == "" {
+		var err error
+		if hash, err = checker.GetCommitHash(ctx, action, version); err != nil {
+			return time.Time{}, err
+		}
+	}
+	return commits.GetCommitDate(ctx, action, hash)
+}
+
+// pinDate returns the date of the version a reference uses: the date of
+// the pinned commit, or the release of a tag. Branches move and have none.
+func pinDate(ctx context.Context, checker VersionChecker, ref ActionReference) (time.Time, error) {
+	switch ref.RefType() {
+	case RefTypeSHA:
+		hash := ref.CommitHash
+		if hash == "" {
+			hash = ref.Version
+		}
+		return publishedAt(ctx, checker, ref, "", hash)
+	case RefTypeTag:
+		return publishedAt(ctx, checker, ref, ref.Version, "")
+	}
+	return time.Time{}, nil
+}
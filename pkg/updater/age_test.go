@@ -0,0 +1,160 @@
+package updater
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+	"github.com/google/go-github/v72/github"
+)
+
+func TestParseAge(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		format  string
+		wantErr bool
+	}{
+		{in: "", want: 0, format: "0d"},
+		{in: "7d", want: 7 * day, format: "7d"},
+		{in: " 180d ", want: 180 * day, format: "180d"},
+		{in: "36h", want: 36 * time.Hour, format: "36h0m0s"},
+		{in: "48h", want: 2 * day, format: "2d"},
+		{in: "-1d", wantErr: true},
+		{in: "-2h", wantErr: true},
+		{in: "xd", wantErr: true},
+		{in: "week", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseAge(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseAge(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got != tt.want || FormatAge(got) != tt.format {
+				t.Errorf("ParseAge(%q) = %v (%s), want %v (%s)", tt.in, got, FormatAge(got), tt.want, tt.format)
+			}
+		})
+	}
+}
+
+// datedChecker serves release dates of tags and dates of commits
+type datedChecker struct {
+	countingChecker
+	latestReleased time.Time
+	commitDate     time.Time
+}
+
+func (c *datedChecker) GetReleaseDate(_ context.Context, action ActionReference, version string) (time.Time, error) {
+	switch version {
+	case "v4.0.0":
+		return c.latestReleased, nil
+	case "v3":
+		return time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), nil
+	}
+	return time.Time{}, fmt.Errorf("no release of %s", version)
+}
+
+func (c *datedChecker) GetCommitDate(_ context.Context, action ActionReference, sha string) (time.Time, error) {
+	return c.commitDate, nil
+}
+
+func TestRunReleaseAndPinAge(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name          string
+		minReleaseAge time.Duration
+		maxPinAge     time.Duration
+		wantUpdates   int
+		wantStale     []string
+	}{
+		{name: "no thresholds", wantUpdates: 2},
+		{name: "fresh release held back", minReleaseAge: 7 * day, wantUpdates: 0},
+		{name: "release old enough", minReleaseAge: day, wantUpdates: 2},
+		// actions/checkout@v3 dates from 2020, octo/tool@v1 has no release
+		// and falls back to its commit from 30 days ago
+		{name: "stale pins", maxPinAge: 180 * day, wantUpdates: 2, wantStale: []string{"actions/checkout@v3"}},
+		{name: "every pin stale", maxPinAge: 7 * day, wantUpdates: 2, wantStale: []string{"actions/checkout@v3", "octo/tool@v1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, _ := writeRunRepo(t)
+			checker := &datedChecker{latestReleased: now.Add(-2 * day), commitDate: now.Add(-30 * day)}
+			rep, err := Run(context.Background(), Options{
+				RepoPath:      dir,
+				Mode:          ModeDryRun,
+				Checker:       checker,
+				MinReleaseAge: tt.minReleaseAge,
+				MaxPinAge:     tt.maxPinAge,
+			})
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if len(rep.Updates) != tt.wantUpdates {
+				t.Errorf("got %d updates, want %d", len(rep.Updates), tt.wantUpdates)
+			}
+			var stale []string
+			for _, pin := range rep.StalePins {
+				stale = append(stale, pin.Action.FullName()+"@"+pin.Action.Version)
+			}
+			if fmt.Sprint(stale) != fmt.Sprint(tt.wantStale) {
+				t.Errorf("stale pins = %v, want %v", stale, tt.wantStale)
+			}
+		})
+	}
+}
+
+func TestRunReleaseAgeUnknown(t *testing.T) {
+	dir, _ := writeRunRepo(t)
+	// Without release or commit dates updates are not held back
+	rep, err := Run(context.Background(), Options{RepoPath: dir, Mode: ModeDryRun, Checker: &countingChecker{}, MinReleaseAge: 7 * day})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(rep.Updates) != 2 {
+		t.Errorf("got %d updates, want 2", len(rep.Updates))
+	}
+}
+
+func TestGetCommitDate(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/o/r/commits/abc123", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"sha":"abc123","commit":{"author":{"date":"2024-01-01T00:00:00Z"},"committer":{"date":"2024-02-01T00:00:00Z"}}}`)
+	})
+	mux.HandleFunc("/repos/o/r/commits/def456", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"sha":"def456","commit":{"author":{"date":"2024-01-01T00:00:00Z"}}}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	checker := NewRetryingVersionChecker(NewCachingVersionChecker(&DefaultVersionChecker{client: client}, nil, 0), common.RetryPolicy{MaxAttempts: 1})
+	action := ActionReference{Owner: "o", Name: "r"}
+
+	tests := []struct {
+		sha     string
+		want    time.Time
+		wantErr bool
+	}{
+		{sha: "abc123", want: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{sha: "def456", want: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{sha: "missing", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := checker.GetCommitDate(context.Background(), action, tt.sha)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("GetCommitDate(%s) error = %v, wantErr %v", tt.sha, err, tt.wantErr)
+		} else if !tt.wantErr && !got.Equal(tt.want) {
+			t.Errorf("GetCommitDate(%s) = %v, want %v", tt.sha, got, tt.want)
+		}
+	}
+}
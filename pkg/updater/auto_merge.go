@@ -0,0 +1,76 @@
+package updater
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+	"github.com/google/go-github/v72/github"
+)
+
+// enableAutoMergeMutation enables auto-merge; it is only available via GraphQL
+const enableAutoMergeMutation = `mutation($id: ID!, $method: PullRequestMergeMethod!) {
+  enablePullRequestAutoMerge(input: {pullRequestId: $id, mergeMethod: $method}) { clientMutationId }
+}`
+
+// Auto-merge methods
+const (
+	MergeMethodSquash = "squash"
+	MergeMethodMerge  = "merge"
+	MergeMethodRebase = "rebase"
+)
+
+// ParseMergeMethod validates an auto-merge method; empty selects squash
+func ParseMergeMethod(method string) (string, error) {
+	switch method = strings.ToLower(strings.TrimSpace(method)); method {
+	case "":
+		return MergeMethodSquash, nil
+	case MergeMethodSquash, MergeMethodMerge, MergeMethodRebase:
+		return method, nil
+	}
+	return "", fmt.Errorf(common.ErrInvalidMergeMethod, method)
+}
+
+// EnableAutoMerge turns on auto-merge with the given method (squash, merge
+// or rebase) for a pull request, so it merges once its required checks and
+// reviews pass
+func EnableAutoMerge(ctx context.Context, client *github.Client, owner, repo string, number int, method string) error {
+	method, err := ParseMergeMethod(method)
+	if err != nil {
+		return err
+	}
+	pr, _, err := client.PullRequests.Get(ctx, owner, repo, number)
+	if err != nil {
+		return err
+	}
+
+	req, err := client.NewRequest("POST", graphQLPath(client), map[string]any{
+		"query":     enableAutoMergeMutation,
+		"variables": map[string]any{"id": pr.GetNodeID(), "method": strings.ToUpper(method)},
+	})
+	if err != nil {
+		return err
+	}
+	var resp struct {
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if _, err := client.Do(ctx, req, &resp); err != nil {
+		return err
+	}
+	if len(resp.Errors) > 0 {
+		return fmt.Errorf(common.ErrEnablingAutoMerge, resp.Errors[0].Message)
+	}
+	return nil
+}
+
+// graphQLPath returns the GraphQL endpoint relative to the client's REST
+// base URL: /graphql on github.com and /api/graphql on Enterprise Server
+func graphQLPath(client *github.Client) string {
+	if strings.HasSuffix(client.BaseURL.Path, "/api/v3/") {
+		return "../graphql"
+	}
+	return "graphql"
+}
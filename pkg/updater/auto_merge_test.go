@@ -0,0 +1,67 @@
+package updater
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestEnableAutoMerge(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		response   string
+		wantMethod string
+		wantErr    string
+	}{
+		{name: "enabled", response: `{"data":{"enablePullRequestAutoMerge":{"clientMutationId":null}}}`, wantMethod: "SQUASH"},
+		{name: "rebase", method: "rebase", response: `{"data":{"enablePullRequestAutoMerge":{"clientMutationId":null}}}`, wantMethod: "REBASE"},
+		{name: "graphql error", method: "merge", response: `{"errors":[{"message":"Auto merge is not allowed for this repository"}]}`, wantMethod: "MERGE", wantErr: "not allowed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var variables map[string]any
+			mux := http.NewServeMux()
+			mux.HandleFunc("/repos/acme/api/pulls/7", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `{"number":7,"node_id":"PR_kwDOA"}`)
+			})
+			mux.HandleFunc("/graphql", func(w http.ResponseWriter, r *http.Request) {
+				var body struct {
+					Query     string         `json:"query"`
+					Variables map[string]any `json:"variables"`
+				}
+				_ = json.NewDecoder(r.Body).Decode(&body)
+				if !strings.Contains(body.Query, "enablePullRequestAutoMerge") {
+					t.Errorf("unexpected query %q", body.Query)
+				}
+				variables = body.Variables
+				fmt.Fprint(w, tt.response)
+			})
+
+			err := EnableAutoMerge(context.Background(), newRepositoriesTestClient(t, mux), "acme", "api", 7, tt.method)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("EnableAutoMerge() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("EnableAutoMerge() error = %v, want %q", err, tt.wantErr)
+			}
+			if variables["id"] != "PR_kwDOA" || variables["method"] != tt.wantMethod {
+				t.Errorf("mutation variables = %v", variables)
+			}
+		})
+	}
+}
+
+func TestParseMergeMethod(t *testing.T) {
+	for input, want := range map[string]string{"": "squash", "Squash": "squash", "merge": "merge", " rebase ": "rebase"} {
+		if got, err := ParseMergeMethod(input); err != nil || got != want {
+			t.Errorf("ParseMergeMethod(%q) = %q, %v, want %q", input, got, err, want)
+		}
+	}
+	if _, err := ParseMergeMethod("fast-forward"); err == nil {
+		t.Error("ParseMergeMethod(fast-forward) expected error")
+	}
+}
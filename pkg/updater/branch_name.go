@@ -0,0 +1,179 @@
+package updater
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+)
+
+// DefaultBranchTemplate names the branches created for pull requests unless
+// another template is configured
+const DefaultBranchTemplate = BranchPrefix + "{date}"
+
+// Branch template tokens
+const (
+	branchTokenDate     = "{date}"
+	branchTokenAction   = "{action}"
+	branchTokenStrategy = "{strategy}"
+)
+
+const (
+	// branchDateFormat formats the {date} token
+	branchDateFormat = "20060102-150405"
+	// branchStrategyPin is the {strategy} of updates that only pin versions
+	branchStrategyPin = "pin"
+	// branchActionMultiple is the {action} of updates to several actions
+	branchActionMultiple = "multiple"
+)
+
+// branchTokenPattern matches a token of a branch template
+var branchTokenPattern = regexp.MustCompile(`\{[^{}]*\}`)
+
+// branchActionUnsafe matches the runs of characters replaced in {action}
+var branchActionUnsafe = regexp.MustCompile(`[^a-z0-9_-]+`)
+
+// BranchTemplate names the branches created for pull requests. When a branch
+// is created, {date} is replaced by the time (20060102-150405), {action} by
+// the updated action as owner-repo, or "multiple" when several actions are
+// updated, and {strategy} by the largest version change: major, minor, patch,
+// or pin when the versions stay the same. An empty template is
+// DefaultBranchTemplate.
+type BranchTemplate string
+
+// ParseBranchTemplate checks that template only uses known tokens and
+// produces branch names that follow Git's ref-name rules
+func ParseBranchTemplate(template string) (BranchTemplate, error) {
+	for _, token := range branchTokenPattern.FindAllString(template, -1) {
+		switch token {
+		case branchTokenDate, branchTokenAction, branchTokenStrategy:
+		default:
+			return "", fmt.Errorf(common.ErrInvalidBranchTemplate, template, "unknown token "+token)
+		}
+	}
+	if strings.ContainsAny(branchTokenPattern.ReplaceAllString(template, ""), "{}") {
+		return "", fmt.Errorf(common.ErrInvalidBranchTemplate, template, "unmatched brace")
+	}
+
+	t := BranchTemplate(template)
+	if reason := checkBranchName(t.expand(branchDateFormat, "actions-checkout", DeltaMajor)); reason != "" {
+		return "", fmt.Errorf(common.ErrInvalidBranchTemplate, template, reason)
+	}
+	return t, nil
+}
+
+// Name returns the name of the branch for updates created at now
+func (t BranchTemplate) Name(updates []*Update, now time.Time) string {
+	return t.expand(now.Format(branchDateFormat), branchAction(updates), branchStrategy(updates))
+}
+
+// nameAfter returns Name, unless one of previous, the branches of the
+// earlier pull requests of the run, has it: major version updates opened
+// apart from the others, or further batches of updates, created in the same
+// second get the same name from a template without {strategy}, so their
+// {strategy} is appended, and then a number
+func (t BranchTemplate) nameAfter(updates []*Update, now time.Time, previous ...string) string {
+	name := t.Name(updates, now)
+	if !slices.Contains(previous, name) {
+		return name
+	}
+	base := name + "-" + branchStrategy(updates)
+	name = base
+	for n := 2; slices.Contains(previous, name); n++ {
+		name = fmt.Sprintf("%s-%d", base, n)
+	}
+	return name
+}
+
+// Matches reports whether branch could have been named by the template
+func (t BranchTemplate) Matches(branch string) bool {
+	pattern := strings.NewReplacer(
+		regexp.QuoteMeta(branchTokenDate), `\d{8}-\d{6}`,
+		regexp.QuoteMeta(branchTokenAction), `[a-z0-9_-]+`,
+		regexp.QuoteMeta(branchTokenStrategy), `(major|minor|patch|pin)`,
+	).Replace(regexp.QuoteMeta(string(t.template())))
+	matched, err := regexp.MatchString("^"+pattern+`(-(major|minor|patch|pin)(-\d+)?)?$`, branch)
+	return err == nil && matched
+}
+
+// Prefix returns the text every branch named by the template starts with
+func (t BranchTemplate) Prefix() string {
+	prefix, _, _ := strings.Cut(string(t.template()), "{")
+	return prefix
+}
+
+// template returns the template, or the default one when empty
+func (t BranchTemplate) template() BranchTemplate {
+	if t == "" {
+		return DefaultBranchTemplate
+	}
+	return t
+}
+
+// expand replaces the tokens of the template
+func (t BranchTemplate) expand(date, action, strategy string) string {
+	return strings.NewReplacer(
+		branchTokenDate, date,
+		branchTokenAction, action,
+		branchTokenStrategy, strategy,
+	).Replace(string(t.template()))
+}
+
+// branchAction returns the {action} of updates
+func branchAction(updates []*Update) string {
+	action := ""
+	for _, update := range updates {
+		name := strings.ToLower(update.Action.Owner + "/" + update.Action.Name)
+		if action != "" && action != name {
+			return branchActionMultiple
+		}
+		action = name
+	}
+	action = strings.Trim(branchActionUnsafe.ReplaceAllString(action, "-"), "-")
+	if action == "" {
+		return branchActionMultiple
+	}
+	return action
+}
+
+// branchStrategy returns the {strategy} of updates
+func branchStrategy(updates []*Update) string {
+	strategy := branchStrategyPin
+	for _, update := range updates {
+		if delta := VersionDelta(update.OldVersion, update.NewVersion); deltaRank[delta] > deltaRank[strategy] {
+			strategy = delta
+		}
+	}
+	return strategy
+}
+
+// checkBranchName returns why name is not a valid Git branch name, or ""
+// when it is (see git check-ref-format)
+func checkBranchName(name string) string {
+	switch {
+	case name == "" || name == "@":
+		return fmt.Sprintf("%q is not a branch name", name)
+	case strings.HasPrefix(name, "-"):
+		return "branch names cannot start with \"-\""
+	case strings.HasPrefix(name, "/"), strings.HasSuffix(name, "/"), strings.Contains(name, "//"):
+		return "branch names cannot have empty path components"
+	case strings.HasSuffix(name, "."):
+		return "branch names cannot end with \".\""
+	case strings.Contains(name, ".."), strings.Contains(name, "@{"):
+		return "branch names cannot contain \"..\" or \"@{\""
+	}
+	if i := strings.IndexFunc(name, func(r rune) bool {
+		return r < 0x20 || r == 0x7f || strings.ContainsRune(" ~^:?*[\\", r)
+	}); i >= 0 {
+		return fmt.Sprintf("branch names cannot contain %q", string(name[i]))
+	}
+	for _, component := range strings.Split(name, "/") {
+		if strings.HasPrefix(component, ".") || strings.HasSuffix(component, ".lock") {
+			return fmt.Sprintf("path component %q cannot start with \".\" or end with \".lock\"", component)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,91 @@
+package updater
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseBranchTemplate(t *testing.T) {
+	tests := []struct {
+		template string
+		wantErr  string
+	}{
+		{template: ""},
+		{template: DefaultBranchTemplate},
+		{template: "deps/{action}-{strategy}-{date}"},
+		{template: "deps/{actions}", wantErr: "unknown token {actions}"},
+		{template: "deps/{date", wantErr: "unmatched brace"},
+		{template: "deps updates/{date}", wantErr: "cannot contain \" \""},
+		{template: "deps//{date}", wantErr: "empty path components"},
+		{template: "-{date}", wantErr: "cannot start with \"-\""},
+		{template: "deps/.{date}", wantErr: "path component \".20060102-150405\""},
+		{template: "deps/{date}.lock", wantErr: "end with \".lock\""},
+		{template: "deps..{date}", wantErr: "\"..\""},
+		{template: "deps/{date}.", wantErr: "cannot end with \".\""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			_, err := ParseBranchTemplate(tt.template)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ParseBranchTemplate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParseBranchTemplate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBranchTemplateName(t *testing.T) {
+	now := time.Date(2026, 5, 1, 13, 4, 5, 0, time.UTC)
+	checkout := CreateTestUpdate("actions", "checkout", "v3", "v4", "ci.yml")
+	cache := CreateTestUpdate("actions", "cache", "v4.1.0", "v4.2.0", "ci.yml")
+	pin := CreateTestUpdate("Docker", "Build_Push.Action", "v5", "v5", "ci.yml")
+	template := BranchTemplate("deps/{action}/{strategy}-{date}")
+
+	tests := []struct {
+		name     string
+		template BranchTemplate
+		updates  []*Update
+		want     string
+	}{
+		{name: "default", updates: []*Update{checkout}, want: "action-updates-20260501-130405"},
+		{name: "single action", template: template, updates: []*Update{checkout}, want: "deps/actions-checkout/major-20260501-130405"},
+		{name: "several actions", template: template, updates: []*Update{cache, checkout}, want: "deps/multiple/major-20260501-130405"},
+		{name: "minor", template: template, updates: []*Update{cache}, want: "deps/actions-cache/minor-20260501-130405"},
+		{name: "pin only", template: template, updates: []*Update{pin}, want: "deps/docker-build_push-action/pin-20260501-130405"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name := tt.template.Name(tt.updates, now)
+			if name != tt.want {
+				t.Errorf("Name() = %q, want %q", name, tt.want)
+			}
+			if !tt.template.Matches(name) {
+				t.Errorf("Matches(%q) = false", name)
+			}
+			if !strings.HasPrefix(name, tt.template.Prefix()) {
+				t.Errorf("Prefix() = %q, not a prefix of %q", tt.template.Prefix(), name)
+			}
+		})
+	}
+
+	if template.Matches("deps/actions-checkout/major-latest") || BranchTemplate("").Matches("feature/login") {
+		t.Error("Matches() accepted a branch the template cannot produce")
+	}
+
+	// A second pull request in the same second gets its {strategy} appended
+	first := BranchTemplate("").nameAfter([]*Update{cache}, now, "")
+	second := BranchTemplate("").nameAfter([]*Update{checkout}, now, first)
+	if first != "action-updates-20260501-130405" || second != first+"-major" || !BranchTemplate("").Matches(second) {
+		t.Errorf("nameAfter() = %q then %q", first, second)
+	}
+	third := BranchTemplate("").nameAfter([]*Update{checkout}, now, first, second)
+	if third != second+"-2" || !BranchTemplate("").Matches(third) {
+		t.Errorf("nameAfter() = %q for a third pull request", third)
+	}
+}
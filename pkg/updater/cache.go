@@ -0,0 +1,179 @@
+package updater
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/storage"
+)
+
+// Key prefixes used in the shared store
+const (
+	cacheKeyPrefix = "cache"
+	stateKeyPrefix = "state"
+)
+
+// cacheEntry is the serialized form of a cached lookup
+type cacheEntry struct {
+	Version   string    `json:"version,omitempty"`
+	Hash      string    `json:"hash"`
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// CachingVersionChecker wraps a VersionChecker and caches latest-version and
+// tag-to-hash lookups in a storage.Store for the configured TTL
+type CachingVersionChecker struct {
+	checker VersionChecker
+	store   storage.Store
+	ttl     time.Duration
+	now     func() time.Time
+}
+
+// NewCachingVersionChecker creates a caching wrapper around checker
+func NewCachingVersionChecker(checker VersionChecker, store storage.Store, ttl time.Duration) *CachingVersionChecker {
+	return &CachingVersionChecker{
+		checker: checker,
+		store:   store,
+		ttl:     ttl,
+		now:     time.Now,
+	}
+}
+
+// GetLatestVersion implements VersionChecker
+func (c *CachingVersionChecker) GetLatestVersion(ctx context.Context, action ActionReference) (string, string, error) {
+	key := c.latestKey(action)
+	if entry, ok := c.load(ctx, key); ok {
+		return entry.Version, entry.Hash, nil
+	}
+
+	version, hash, err := c.checker.GetLatestVersion(ctx, action)
+	if err != nil {
+		return "", "", err
+	}
+	c.save(ctx, key, cacheEntry{Version: version, Hash: hash})
+	return version, hash, nil
+}
+
+// IsUpdateAvailable implements VersionChecker using the cached latest version
+func (c *CachingVersionChecker) IsUpdateAvailable(ctx context.Context, action ActionReference) (bool, string, string, error) {
+	latestVersion, latestHash, err := c.GetLatestVersion(ctx, action)
+	if err != nil {
+		return false, "", "", err
+	}
+	return isUpdateAvailable(action, latestVersion, latestHash), latestVersion, latestHash, nil
+}
+
+// GetCommitHash implements VersionChecker
+func (c *CachingVersionChecker) GetCommitHash(ctx context.Context, action ActionReference, version string) (string, error) {
+	key := fmt.Sprintf("%s/hashes/%s/%s", cacheKeyPrefix, action.Repository(), version)
+	if entry, ok := c.load(ctx, key); ok {
+		return entry.Hash, nil
+	}
+
+	hash, err := c.checker.GetCommitHash(ctx, action, version)
+	if err != nil {
+		return "", err
+	}
+	c.save(ctx, key, cacheEntry{Version: version, Hash: hash})
+	return hash, nil
+}
+
+// Prefetch implements BatchPrefetcher when the wrapped checker does, for
+// the actions whose latest version is not cached
+func (c *CachingVersionChecker) Prefetch(ctx context.Context, actions []ActionReference) error {
+	prefetcher, ok := c.checker.(BatchPrefetcher)
+	if !ok {
+		return nil
+	}
+	var missing []ActionReference
+	for _, action := range actions {
+		if _, ok := c.load(ctx, c.latestKey(action)); !ok {
+			missing = append(missing, action)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	return prefetcher.Prefetch(ctx, missing)
+}
+
+// latestKey is the cache key of the latest version of action
+func (c *CachingVersionChecker) latestKey(action ActionReference) string {
+	return fmt.Sprintf("%s/latest/%s", cacheKeyPrefix, action.Repository())
+}
+
+// load returns a fresh cache entry for key. Store errors are treated as misses.
+func (c *CachingVersionChecker) load(ctx context.Context, key string) (cacheEntry, bool) {
+	var entry cacheEntry
+	data, err := c.store.Get(ctx, key)
+	if err != nil {
+		if !errors.Is(err, storage.ErrNotFound) {
+			log.Printf("Warning: cache read failed for %s: %v", key, err)
+		}
+		return entry, false
+	}
+	if err := json.Unmarshal(data, &entry); err != nil {
+		log.Printf("Warning: %v", fmt.Errorf(common.ErrDecodingCacheEntry, err))
+		return entry, false
+	}
+	if c.ttl > 0 && c.now().Sub(entry.FetchedAt) > c.ttl {
+		return entry, false
+	}
+	return entry, true
+}
+
+// save writes a cache entry. Failures are logged since the cache is best-effort.
+func (c *CachingVersionChecker) save(ctx context.Context, key string, entry cacheEntry) {
+	entry.FetchedAt = c.now()
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	if err := c.store.Put(ctx, key, data); err != nil {
+		log.Printf("Warning: cache write failed for %s: %v", key, err)
+	}
+}
+
+// RunState records the outcome of the last run against a repository
+type RunState struct {
+	Owner        string    `json:"owner"`
+	Repo         string    `json:"repo"`
+	StartedAt    time.Time `json:"started_at"`
+	FinishedAt   time.Time `json:"finished_at"`
+	FilesScanned int       `json:"files_scanned"`
+	UpdatesFound int       `json:"updates_found"`
+	Mode         string    `json:"mode"`
+}
+
+// runStateKey returns the store key for a repository's run state
+func runStateKey(owner, repo string) string {
+	return fmt.Sprintf("%s/runs/%s/%s.json", stateKeyPrefix, owner, repo)
+}
+
+// SaveRunState persists the run state for a repository
+func SaveRunState(ctx context.Context, store storage.Store, state RunState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return store.Put(ctx, runStateKey(state.Owner, state.Repo), data)
+}
+
+// LoadRunState loads the last run state for a repository. It returns
+// storage.ErrNotFound when the repository has not been processed before.
+func LoadRunState(ctx context.Context, store storage.Store, owner, repo string) (*RunState, error) {
+	data, err := store.Get(ctx, runStateKey(owner, repo))
+	if err != nil {
+		return nil, err
+	}
+	var state RunState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf(common.ErrDecodingCacheEntry, err)
+	}
+	return &state, nil
+}
@@ -0,0 +1,116 @@
+package updater
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/storage"
+)
+
+// countingChecker counts calls to the wrapped lookups
+type countingChecker struct {
+	latestCalls int
+	hashCalls   int
+	err         error
+}
+
+func (c *countingChecker) GetLatestVersion(ctx context.Context, action ActionReference) (string, string, error) {
+	c.latestCalls++
+	if c.err != nil {
+		return "", "", c.err
+	}
+	return "v4.0.0", "1111111111111111111111111111111111111111", nil
+}
+
+func (c *countingChecker) IsUpdateAvailable(ctx context.Context, action ActionReference) (bool, string, string, error) {
+	version, hash, err := c.GetLatestVersion(ctx, action)
+	return err == nil, version, hash, err
+}
+
+func (c *countingChecker) GetCommitHash(ctx context.Context, action ActionReference, version string) (string, error) {
+	c.hashCalls++
+	return "2222222222222222222222222222222222222222", nil
+}
+
+func TestCachingVersionChecker(t *testing.T) {
+	ctx := context.Background()
+	inner := &countingChecker{}
+	checker := NewCachingVersionChecker(inner, storage.NewMemoryStore(), time.Hour)
+	action := ActionReference{Owner: "actions", Name: "checkout", Version: "v3"}
+
+	for i := 0; i < 3; i++ {
+		version, hash, err := checker.GetLatestVersion(ctx, action)
+		if err != nil {
+			t.Fatalf("GetLatestVersion() error = %v", err)
+		}
+		if version != "v4.0.0" || hash != "1111111111111111111111111111111111111111" {
+			t.Errorf("GetLatestVersion() = %s, %s", version, hash)
+		}
+	}
+	if inner.latestCalls != 1 {
+		t.Errorf("inner GetLatestVersion calls = %d, want 1", inner.latestCalls)
+	}
+
+	available, _, _, err := checker.IsUpdateAvailable(ctx, action)
+	if err != nil || !available {
+		t.Errorf("IsUpdateAvailable() = %v, %v; want true, nil", available, err)
+	}
+	if inner.latestCalls != 1 {
+		t.Errorf("IsUpdateAvailable should use cache, inner calls = %d", inner.latestCalls)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := checker.GetCommitHash(ctx, action, "v3"); err != nil {
+			t.Fatalf("GetCommitHash() error = %v", err)
+		}
+	}
+	if inner.hashCalls != 1 {
+		t.Errorf("inner GetCommitHash calls = %d, want 1", inner.hashCalls)
+	}
+
+	// Expire the cache entry
+	checker.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	if _, _, err := checker.GetLatestVersion(ctx, action); err != nil {
+		t.Fatalf("GetLatestVersion() error = %v", err)
+	}
+	if inner.latestCalls != 2 {
+		t.Errorf("expired entry should be refetched, inner calls = %d", inner.latestCalls)
+	}
+}
+
+func TestCachingVersionCheckerError(t *testing.T) {
+	inner := &countingChecker{err: errors.New("api down")}
+	store := storage.NewMemoryStore()
+	checker := NewCachingVersionChecker(inner, store, time.Hour)
+
+	if _, _, err := checker.GetLatestVersion(context.Background(), ActionReference{Owner: "a", Name: "b"}); err == nil {
+		t.Fatal("GetLatestVersion() expected error, got nil")
+	}
+	keys, _ := store.List(context.Background(), "")
+	if len(keys) != 0 {
+		t.Errorf("errors should not be cached, got keys %v", keys)
+	}
+}
+
+func TestRunState(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+
+	if _, err := LoadRunState(ctx, store, "owner", "repo"); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("LoadRunState() error = %v, want ErrNotFound", err)
+	}
+
+	state := RunState{Owner: "owner", Repo: "repo", FilesScanned: 3, UpdatesFound: 2, Mode: "stage"}
+	if err := SaveRunState(ctx, store, state); err != nil {
+		t.Fatalf("SaveRunState() error = %v", err)
+	}
+	got, err := LoadRunState(ctx, store, "owner", "repo")
+	if err != nil {
+		t.Fatalf("LoadRunState() error = %v", err)
+	}
+	if got.FilesScanned != 3 || got.UpdatesFound != 2 || got.Mode != "stage" {
+		t.Errorf("LoadRunState() = %+v", got)
+	}
+}
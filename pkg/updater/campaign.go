@@ -0,0 +1,239 @@
+package updater
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/storage"
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/versions"
+)
+
+// Campaign repository statuses
+const (
+	CampaignPending  = "pending"    // Not processed yet, or only previewed
+	CampaignPROpened = "pr-opened"  // A pull request bumping the action was created
+	CampaignVerified = "verified"   // The canary pull request was merged or passed CI
+	CampaignUpToDate = "up-to-date" // Nothing to bump
+	CampaignFailed   = "failed"
+)
+
+// Campaign tracks bumping one action to one version across repositories
+type Campaign struct {
+	Name         string                      `json:"name"`
+	Action       string                      `json:"action"` // Full action name, e.g. "actions/checkout"
+	Version      string                      `json:"version"`
+	StartedAt    time.Time                   `json:"started_at"`
+	UpdatedAt    time.Time                   `json:"updated_at"`
+	Repositories map[string]CampaignProgress `json:"repositories"` // owner/repo -> progress
+
+	// Canaries are rolled out first; the remaining repositories follow once
+	// every canary is verified or up to date, or the rollout is approved
+	Canaries []string `json:"canaries,omitempty"`
+	Approved bool     `json:"approved,omitempty"`
+}
+
+// CampaignProgress is the campaign state of a single repository
+type CampaignProgress struct {
+	Status      string    `json:"status"`
+	Updates     int       `json:"updates,omitempty"`
+	PullRequest int       `json:"pull_request,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+var campaignNameChars = regexp.MustCompile(`[^a-z0-9._-]+`)
+
+// CampaignName returns the default campaign name for bumping action to version
+func CampaignName(action, version string) string {
+	return strings.Trim(campaignNameChars.ReplaceAllString(strings.ToLower(action+"-"+version), "-"), "-")
+}
+
+// campaignKey returns the store key for a campaign
+func campaignKey(name string) string {
+	return fmt.Sprintf("%s/campaigns/%s.json", stateKeyPrefix, name)
+}
+
+// NewCampaign starts a campaign bumping action (owner/repo) to version
+func NewCampaign(name, action, version string, now time.Time) (*Campaign, error) {
+	action = strings.ToLower(strings.TrimSpace(action))
+	if strings.Count(action, "/") < 1 || strings.HasPrefix(action, "./") || strings.Contains(action, "@") {
+		return nil, fmt.Errorf(common.ErrInvalidCampaign, "action must be owner/repo")
+	}
+	if version == "" {
+		return nil, fmt.Errorf(common.ErrInvalidCampaign, "missing target version")
+	}
+	if name == "" {
+		name = CampaignName(action, version)
+	}
+	return &Campaign{
+		Name:         name,
+		Action:       action,
+		Version:      version,
+		StartedAt:    now,
+		UpdatedAt:    now,
+		Repositories: make(map[string]CampaignProgress),
+	}, nil
+}
+
+// LoadCampaign loads a campaign. It returns storage.ErrNotFound when the
+// campaign has not been started.
+func LoadCampaign(ctx context.Context, store storage.Store, name string) (*Campaign, error) {
+	data, err := store.Get(ctx, campaignKey(name))
+	if err != nil {
+		return nil, err
+	}
+	var campaign Campaign
+	if err := json.Unmarshal(data, &campaign); err != nil {
+		return nil, fmt.Errorf(common.ErrDecodingCacheEntry, err)
+	}
+	if campaign.Repositories == nil {
+		campaign.Repositories = make(map[string]CampaignProgress)
+	}
+	return &campaign, nil
+}
+
+// ResumeCampaign loads the named campaign or starts a new one. A stored
+// campaign targeting another action or version is an error.
+func ResumeCampaign(ctx context.Context, store storage.Store, name, action, version string, now time.Time) (*Campaign, error) {
+	campaign, err := NewCampaign(name, action, version, now)
+	if err != nil || store == nil {
+		return campaign, err
+	}
+	stored, err := LoadCampaign(ctx, store, campaign.Name)
+	if errors.Is(err, storage.ErrNotFound) {
+		return campaign, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if stored.Action != campaign.Action || stored.Version != campaign.Version {
+		return nil, fmt.Errorf(common.ErrInvalidCampaign,
+			fmt.Sprintf("%s already targets %s@%s", stored.Name, stored.Action, stored.Version))
+	}
+	return stored, nil
+}
+
+// SaveCampaign persists a campaign
+func SaveCampaign(ctx context.Context, store storage.Store, campaign *Campaign) error {
+	data, err := json.MarshalIndent(campaign, "", "  ")
+	if err != nil {
+		return err
+	}
+	return store.Put(ctx, campaignKey(campaign.Name), data)
+}
+
+// Reference returns a reference to the targeted action at the target version
+func (c *Campaign) Reference() (ActionReference, error) {
+	ref, err := parseActionReference(c.Action+"@"+c.Version, "", nil)
+	if err != nil {
+		return ActionReference{}, fmt.Errorf(common.ErrInvalidCampaign, err.Error())
+	}
+	return *ref, nil
+}
+
+// Record sets the progress of a repository
+func (c *Campaign) Record(repository string, progress CampaignProgress, now time.Time) {
+	progress.UpdatedAt = now
+	c.Repositories[repository] = progress
+	c.UpdatedAt = now
+}
+
+// Done reports whether a repository needs no further work
+func (c *Campaign) Done(repository string) bool {
+	switch c.Repositories[repository].Status {
+	case CampaignPROpened, CampaignVerified, CampaignUpToDate:
+		return true
+	}
+	return false
+}
+
+// IsCanary reports whether a repository is one of the canaries
+func (c *Campaign) IsCanary(repository string) bool {
+	for _, canary := range c.Canaries {
+		if strings.EqualFold(canary, repository) {
+			return true
+		}
+	}
+	return false
+}
+
+// CanariesPassed reports whether the rollout may proceed past the canaries:
+// it was approved, or every canary is verified or up to date
+func (c *Campaign) CanariesPassed() bool {
+	if c.Approved {
+		return true
+	}
+	for _, canary := range c.Canaries {
+		switch c.Repositories[canary].Status {
+		case CampaignVerified, CampaignUpToDate:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// Counts returns the number of repositories in each status
+func (c *Campaign) Counts() map[string]int {
+	counts := make(map[string]int)
+	for _, progress := range c.Repositories {
+		counts[progress.Status]++
+	}
+	return counts
+}
+
+// Failed returns the failed repositories, sorted by name
+func (c *Campaign) Failed() []string {
+	var failed []string
+	for name, progress := range c.Repositories {
+		if progress.Status == CampaignFailed {
+			failed = append(failed, name)
+		}
+	}
+	sort.Strings(failed)
+	return failed
+}
+
+// TargetVersionChecker proposes a fixed version of every action it is asked
+// about; campaigns pair it with a filter on the targeted action. References
+// already newer than the target are never downgraded.
+type TargetVersionChecker struct {
+	checker VersionChecker
+	version string
+	hash    string
+}
+
+// NewTargetVersionChecker returns a checker proposing version (at hash).
+// Commit hashes of other versions are resolved by checker.
+func NewTargetVersionChecker(checker VersionChecker, version, hash string) *TargetVersionChecker {
+	return &TargetVersionChecker{checker: checker, version: version, hash: hash}
+}
+
+// GetLatestVersion returns the target version
+func (c *TargetVersionChecker) GetLatestVersion(ctx context.Context, action ActionReference) (string, string, error) {
+	return c.version, c.hash, nil
+}
+
+// IsUpdateAvailable reports whether action differs from the target and is
+// not newer than it
+func (c *TargetVersionChecker) IsUpdateAvailable(ctx context.Context, action ActionReference) (bool, string, string, error) {
+	if versions.IsVersion(action.Version) && versions.IsNewer(action.Version, c.version) {
+		return false, c.version, c.hash, nil
+	}
+	return isUpdateAvailable(action, c.version, c.hash), c.version, c.hash, nil
+}
+
+// GetCommitHash returns the target hash for the target version
+func (c *TargetVersionChecker) GetCommitHash(ctx context.Context, action ActionReference, version string) (string, error) {
+	if version == c.version {
+		return c.hash, nil
+	}
+	return c.checker.GetCommitHash(ctx, action, version)
+}
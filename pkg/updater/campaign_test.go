@@ -0,0 +1,106 @@
+package updater
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/storage"
+)
+
+func TestCampaignProgress(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	campaign, err := ResumeCampaign(ctx, store, "", "Actions/Checkout", "v5", now)
+	if err != nil {
+		t.Fatalf("ResumeCampaign() error = %v", err)
+	}
+	if campaign.Name != "actions-checkout-v5" || campaign.Action != "actions/checkout" {
+		t.Errorf("campaign = %+v", campaign)
+	}
+
+	campaign.Record("acme/api", CampaignProgress{Status: CampaignPROpened, Updates: 1}, now)
+	campaign.Record("acme/web", CampaignProgress{Status: CampaignFailed, Error: "boom"}, now)
+	campaign.Record("acme/new", CampaignProgress{Status: CampaignPending}, now)
+	if err := SaveCampaign(ctx, store, campaign); err != nil {
+		t.Fatalf("SaveCampaign() error = %v", err)
+	}
+
+	resumed, err := ResumeCampaign(ctx, store, "", "actions/checkout", "v5", now.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("ResumeCampaign() error = %v", err)
+	}
+	if !resumed.Done("acme/api") || resumed.Done("acme/web") || resumed.Done("acme/new") {
+		t.Errorf("Done() mismatch: %+v", resumed.Repositories)
+	}
+	if counts := resumed.Counts(); counts[CampaignPROpened] != 1 || counts[CampaignFailed] != 1 || counts[CampaignPending] != 1 {
+		t.Errorf("Counts() = %v", counts)
+	}
+	if failed := resumed.Failed(); len(failed) != 1 || failed[0] != "acme/web" {
+		t.Errorf("Failed() = %v", failed)
+	}
+
+	// The rollout waits for every canary unless approved
+	resumed.Canaries = []string{"acme/api", "acme/new"}
+	if resumed.CanariesPassed() {
+		t.Error("CanariesPassed() = true with unverified canaries")
+	}
+	resumed.Record("acme/api", CampaignProgress{Status: CampaignVerified}, now)
+	resumed.Record("acme/new", CampaignProgress{Status: CampaignUpToDate}, now)
+	if !resumed.CanariesPassed() || !resumed.IsCanary("ACME/API") || resumed.IsCanary("acme/web") {
+		t.Error("canaries mismatch after verification")
+	}
+	resumed.Record("acme/new", CampaignProgress{Status: CampaignFailed}, now)
+	resumed.Approved = true
+	if !resumed.CanariesPassed() {
+		t.Error("CanariesPassed() = false after approval")
+	}
+
+	if _, err := ResumeCampaign(ctx, store, "actions-checkout-v5", "actions/checkout", "v4", now); err == nil {
+		t.Error("ResumeCampaign() with another version expected error")
+	}
+	for _, action := range []string{"checkout", "./local", "actions/checkout@v4"} {
+		if _, err := NewCampaign("", action, "v5", now); err == nil {
+			t.Errorf("NewCampaign(%q) expected error", action)
+		}
+	}
+}
+
+func TestTargetVersionChecker(t *testing.T) {
+	const hash = "1234567890123456789012345678901234567890"
+	checker := NewTargetVersionChecker(&countingChecker{}, "v5", hash)
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		ref  ActionReference
+		want bool
+	}{
+		{name: "older tag", ref: ActionReference{Owner: "actions", Name: "checkout", Version: "v4"}, want: true},
+		{name: "same tag", ref: ActionReference{Owner: "actions", Name: "checkout", Version: "v5"}, want: false},
+		{name: "newer tag", ref: ActionReference{Owner: "actions", Name: "checkout", Version: "v6.1.0"}, want: false},
+		{name: "older pinned hash", ref: ActionReference{Owner: "actions", Name: "checkout", Version: "v4", CommitHash: "abcdefabcdefabcdefabcdefabcdefabcdefabcd"}, want: true},
+		{name: "target hash", ref: ActionReference{Owner: "actions", Name: "checkout", Version: hash, CommitHash: hash}, want: false},
+		{name: "branch", ref: ActionReference{Owner: "actions", Name: "checkout", Version: "main"}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			available, version, gotHash, err := checker.IsUpdateAvailable(ctx, tt.ref)
+			if err != nil {
+				t.Fatalf("IsUpdateAvailable() error = %v", err)
+			}
+			if available != tt.want || version != "v5" || gotHash != hash {
+				t.Errorf("IsUpdateAvailable() = %v, %s, %s, want %v, v5, %s", available, version, gotHash, tt.want, hash)
+			}
+		})
+	}
+
+	if got, _ := checker.GetCommitHash(ctx, ActionReference{Owner: "actions", Name: "checkout"}, "v5"); got != hash {
+		t.Errorf("GetCommitHash(v5) = %s, want %s", got, hash)
+	}
+}
@@ -0,0 +1,66 @@
+package updater
+
+import (
+	"context"
+
+	"github.com/google/go-github/v72/github"
+)
+
+// Outcomes reported by PullRequestOutcome
+const (
+	PullRequestPending   = "pending"
+	PullRequestSucceeded = "succeeded" // Merged, or open with every check passed
+	PullRequestFailed    = "failed"    // Closed without merging, or a check failed
+)
+
+// PullRequestOutcome reports whether a pull request has succeeded: it was
+// merged (for example by auto-merge), or it is open and its head commit has
+// statuses or check runs that all passed. A pull request without any CI
+// stays pending until it is merged.
+func PullRequestOutcome(ctx context.Context, client *github.Client, owner, repo string, number int) (string, error) {
+	pr, _, err := client.PullRequests.Get(ctx, owner, repo, number)
+	if err != nil {
+		return "", err
+	}
+	if pr.GetMerged() {
+		return PullRequestSucceeded, nil
+	}
+	if pr.GetState() == "closed" {
+		return PullRequestFailed, nil
+	}
+
+	sha := pr.GetHead().GetSHA()
+	status, _, err := client.Repositories.GetCombinedStatus(ctx, owner, repo, sha, nil)
+	if err != nil {
+		return "", err
+	}
+	runs, _, err := client.Checks.ListCheckRunsForRef(ctx, owner, repo, sha, &github.ListCheckRunsOptions{
+		ListOptions: github.ListOptions{PerPage: 100},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	pending := status.GetTotalCount() == 0 && runs.GetTotal() == 0
+	switch status.GetState() {
+	case "failure", "error":
+		return PullRequestFailed, nil
+	case "pending":
+		pending = pending || status.GetTotalCount() > 0
+	}
+	for _, run := range runs.CheckRuns {
+		if run.GetStatus() != "completed" {
+			pending = true
+			continue
+		}
+		switch run.GetConclusion() {
+		case "success", "neutral", "skipped":
+		default:
+			return PullRequestFailed, nil
+		}
+	}
+	if pending {
+		return PullRequestPending, nil
+	}
+	return PullRequestSucceeded, nil
+}
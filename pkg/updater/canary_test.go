@@ -0,0 +1,53 @@
+package updater
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestPullRequestOutcome(t *testing.T) {
+	tests := []struct {
+		name     string
+		pr       string
+		statuses string
+		checks   string
+		want     string
+	}{
+		{name: "merged", pr: `{"state":"closed","merged":true}`, want: PullRequestSucceeded},
+		{name: "closed", pr: `{"state":"closed","merged":false}`, want: PullRequestFailed},
+		{name: "no ci", pr: `{"state":"open"}`, statuses: `{"state":"pending","total_count":0}`, checks: `{"total_count":0}`, want: PullRequestPending},
+		{name: "checks passed", pr: `{"state":"open"}`, statuses: `{"state":"pending","total_count":0}`,
+			checks: `{"total_count":2,"check_runs":[{"status":"completed","conclusion":"success"},{"status":"completed","conclusion":"skipped"}]}`, want: PullRequestSucceeded},
+		{name: "check running", pr: `{"state":"open"}`, statuses: `{"state":"success","total_count":1}`,
+			checks: `{"total_count":1,"check_runs":[{"status":"in_progress"}]}`, want: PullRequestPending},
+		{name: "check failed", pr: `{"state":"open"}`, statuses: `{"state":"success","total_count":1}`,
+			checks: `{"total_count":1,"check_runs":[{"status":"completed","conclusion":"failure"}]}`, want: PullRequestFailed},
+		{name: "status failed", pr: `{"state":"open"}`, statuses: `{"state":"failure","total_count":1}`, checks: `{"total_count":0}`, want: PullRequestFailed},
+		{name: "status passed", pr: `{"state":"open"}`, statuses: `{"state":"success","total_count":1}`, checks: `{"total_count":0}`, want: PullRequestSucceeded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/repos/acme/api/pulls/7", func(w http.ResponseWriter, r *http.Request) {
+				pr := tt.pr[:len(tt.pr)-1] + `,"head":{"sha":"abc123"}}`
+				fmt.Fprint(w, pr)
+			})
+			mux.HandleFunc("/repos/acme/api/commits/abc123/status", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, tt.statuses)
+			})
+			mux.HandleFunc("/repos/acme/api/commits/abc123/check-runs", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, tt.checks)
+			})
+
+			got, err := PullRequestOutcome(context.Background(), newRepositoriesTestClient(t, mux), "acme", "api", 7)
+			if err != nil {
+				t.Fatalf("PullRequestOutcome() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("PullRequestOutcome() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,307 @@
+package updater
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/storage"
+)
+
+const (
+	// ticketTimeout bounds a single change ticket integration call
+	ticketTimeout = time.Minute
+	// ChangeTicketTokenEnv names the variable holding the HTTP integration's bearer token
+	ChangeTicketTokenEnv = "CHANGE_TICKET_TOKEN"
+)
+
+// Change ticket statuses
+const (
+	TicketPending  = "pending"
+	TicketApproved = "approved"
+	TicketRejected = "rejected"
+)
+
+// Ticket is a change ticket in an external system such as Jira or ServiceNow
+type Ticket struct {
+	ID     string `json:"id"`
+	URL    string `json:"url,omitempty"`
+	Status string `json:"status,omitempty"`
+}
+
+// TicketRequest is sent to a change ticket integration. Action is "create"
+// for a new ticket, with the repository and updates, or "status" to look up
+// Ticket.
+type TicketRequest struct {
+	Action     string         `json:"action"`
+	Repository string         `json:"repository,omitempty"`
+	Title      string         `json:"title,omitempty"`
+	Updates    []TicketUpdate `json:"updates,omitempty"`
+	Ticket     *Ticket        `json:"ticket,omitempty"`
+}
+
+// TicketUpdate describes one update in a ticket request
+type TicketUpdate struct {
+	Action     string `json:"action"`
+	OldVersion string `json:"old_version"`
+	NewVersion string `json:"new_version"`
+}
+
+// ChangeTicketer creates change tickets and reports their approval status
+type ChangeTicketer interface {
+	CreateTicket(ctx context.Context, repository string, updates []*Update) (*Ticket, error)
+	TicketStatus(ctx context.Context, ticket Ticket) (string, error)
+}
+
+// NewChangeTicketer creates a change ticket integration from a spec:
+// "command:<program> [args]" runs a local program, and an https:// URL posts
+// to an HTTP endpoint. Both receive a TicketRequest as JSON and answer with a
+// Ticket as JSON, which lets any ticketing system be plugged in. An empty
+// spec disables change tickets and returns nil.
+func NewChangeTicketer(spec string) (ChangeTicketer, error) {
+	switch {
+	case spec == "":
+		return nil, nil
+	case strings.HasPrefix(spec, "command:"):
+		args := strings.Fields(strings.TrimPrefix(spec, "command:"))
+		if len(args) == 0 {
+			return nil, fmt.Errorf(common.ErrUnknownChangeTicketer, spec)
+		}
+		return &ticketClient{call: commandTicketCall(args)}, nil
+	case strings.HasPrefix(spec, "https://"), strings.HasPrefix(spec, "http://"):
+		u, err := url.Parse(spec)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf(common.ErrUnknownChangeTicketer, spec)
+		}
+		if u.Scheme == "http" && !isLoopbackHost(u.Hostname()) {
+			return nil, fmt.Errorf(common.ErrInsecureTicketURL, u.Redacted())
+		}
+		return &ticketClient{call: httpTicketCall(spec, os.Getenv(ChangeTicketTokenEnv), http.DefaultClient)}, nil
+	default:
+		return nil, fmt.Errorf(common.ErrUnknownChangeTicketer, spec)
+	}
+}
+
+// ticketClient implements ChangeTicketer on top of a request/response call
+type ticketClient struct {
+	call func(ctx context.Context, req TicketRequest) (*Ticket, error)
+}
+
+// CreateTicket implements ChangeTicketer
+func (c *ticketClient) CreateTicket(ctx context.Context, repository string, updates []*Update) (*Ticket, error) {
+	req := TicketRequest{
+		Action:     "create",
+		Repository: repository,
+		Title:      fmt.Sprintf("Update GitHub Actions dependencies in %s", repository),
+	}
+	for _, update := range updates {
+		req.Updates = append(req.Updates, TicketUpdate{
+			Action:     update.Action.FullName(),
+			OldVersion: update.OldVersion,
+			NewVersion: update.NewVersion,
+		})
+	}
+	ticket, err := c.call(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if ticket.ID == "" {
+		return nil, errors.New(common.ErrInvalidChangeTicket)
+	}
+	ticket.Status = normalizeTicketStatus(ticket.Status)
+	return ticket, nil
+}
+
+// TicketStatus implements ChangeTicketer
+func (c *ticketClient) TicketStatus(ctx context.Context, ticket Ticket) (string, error) {
+	result, err := c.call(ctx, TicketRequest{Action: "status", Ticket: &ticket})
+	if err != nil {
+		return "", err
+	}
+	return normalizeTicketStatus(result.Status), nil
+}
+
+// normalizeTicketStatus maps unknown statuses to pending
+func normalizeTicketStatus(status string) string {
+	switch status = strings.ToLower(strings.TrimSpace(status)); status {
+	case TicketApproved, TicketRejected:
+		return status
+	}
+	return TicketPending
+}
+
+// commandTicketCall runs a local program with the request as JSON on stdin
+// and reads the ticket from stdout. The program gets a minimal environment
+// so tokens of the run are not passed on.
+func commandTicketCall(args []string) func(ctx context.Context, req TicketRequest) (*Ticket, error) {
+	return func(ctx context.Context, req TicketRequest) (*Ticket, error) {
+		input, err := json.Marshal(req)
+		if err != nil {
+			return nil, err
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, ticketTimeout)
+		defer cancel()
+		cmd := exec.CommandContext(ctx, args[0], args[1:]...) // #nosec G204 - program is configured by the user
+		cmd.Env = minimalEnv()
+		cmd.Stdin = bytes.NewReader(input)
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+		}
+		var ticket Ticket
+		if err := json.Unmarshal(out, &ticket); err != nil {
+			return nil, err
+		}
+		return &ticket, nil
+	}
+}
+
+// httpTicketCall posts the request as JSON to endpoint and decodes the ticket
+func httpTicketCall(endpoint, token string, client *http.Client) func(ctx context.Context, req TicketRequest) (*Ticket, error) {
+	return func(ctx context.Context, req TicketRequest) (*Ticket, error) {
+		body, err := json.Marshal(req)
+		if err != nil {
+			return nil, err
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, ticketTimeout)
+		defer cancel()
+		httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		httpReq.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			httpReq.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		resp, err := client.Do(httpReq)
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = resp.Body.Close() }()
+		if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+			return nil, fmt.Errorf(common.ErrChangeTicketStatus, resp.StatusCode)
+		}
+
+		var ticket Ticket
+		if err := json.NewDecoder(io.LimitReader(resp.Body, 64*1024)).Decode(&ticket); err != nil {
+			return nil, err
+		}
+		return &ticket, nil
+	}
+}
+
+// TicketingPRCreator opens a change ticket for the updates of each pull
+// request before creating it, and links the ticket in the pull request body
+// when the wrapped creator supports it. Retries of a failed CreatePR reuse
+// its ticket.
+type TicketingPRCreator struct {
+	creator    PRCreator
+	ticketer   ChangeTicketer
+	repository string
+	ticket     *Ticket         // Ticket of the pull request not created yet
+	tickets    map[int]*Ticket // Tickets of the pull requests created, by number
+}
+
+// NewTicketingPRCreator wraps creator for pull requests in repository (owner/repo)
+func NewTicketingPRCreator(creator PRCreator, ticketer ChangeTicketer, repository string) *TicketingPRCreator {
+	return &TicketingPRCreator{creator: creator, ticketer: ticketer, repository: repository}
+}
+
+// CreatePR implements PRCreator
+func (c *TicketingPRCreator) CreatePR(ctx context.Context, updates []*Update) error {
+	if len(updates) == 0 {
+		return nil
+	}
+	if c.ticket == nil {
+		ticket, err := c.ticketer.CreateTicket(ctx, c.repository, updates)
+		if err != nil {
+			return fmt.Errorf(common.ErrCreatingChangeTicket, err)
+		}
+		c.ticket = ticket
+	}
+	if linker, ok := c.creator.(interface{ SetChangeTicket(*Ticket) }); ok {
+		linker.SetChangeTicket(c.ticket)
+	}
+	if err := c.creator.CreatePR(ctx, updates); err != nil {
+		return err
+	}
+	c.ticketCreated()
+	return nil
+}
+
+// ticketCreated assigns the ticket to the pull request just created, so the
+// next pull request gets a ticket of its own
+func (c *TicketingPRCreator) ticketCreated() {
+	if pull, ok := lastPullRequest(c.creator); ok {
+		if c.tickets == nil {
+			c.tickets = make(map[int]*Ticket)
+		}
+		c.tickets[pull.Number] = c.ticket
+	}
+	c.ticket = nil
+}
+
+// changeTicketNote links a change ticket in a pull request body
+func changeTicketNote(ticket *Ticket) string {
+	ref := ticket.ID
+	if ticket.URL != "" {
+		ref = fmt.Sprintf("[%s](%s)", ticket.ID, ticket.URL)
+	}
+	return fmt.Sprintf("Change ticket: %s. Auto-merge is enabled once the ticket is approved.", ref)
+}
+
+// ChangeGate holds a pull request back from auto-merge until its change
+// ticket is approved
+type ChangeGate struct {
+	PullRequest int       `json:"pull_request"`
+	Ticket      Ticket    `json:"ticket"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// changeGatesKey returns the store key for a repository's open change gates
+func changeGatesKey(owner, repo string) string {
+	return fmt.Sprintf("%s/tickets/%s/%s.json", stateKeyPrefix, owner, repo)
+}
+
+// LoadChangeGates loads the open change gates of a repository
+func LoadChangeGates(ctx context.Context, store storage.Store, owner, repo string) ([]ChangeGate, error) {
+	data, err := store.Get(ctx, changeGatesKey(owner, repo))
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var gates []ChangeGate
+	if err := json.Unmarshal(data, &gates); err != nil {
+		return nil, fmt.Errorf(common.ErrDecodingCacheEntry, err)
+	}
+	return gates, nil
+}
+
+// SaveChangeGates persists the open change gates of a repository
+func SaveChangeGates(ctx context.Context, store storage.Store, owner, repo string, gates []ChangeGate) error {
+	if len(gates) == 0 {
+		return store.Delete(ctx, changeGatesKey(owner, repo))
+	}
+	data, err := json.MarshalIndent(gates, "", "  ")
+	if err != nil {
+		return err
+	}
+	return store.Put(ctx, changeGatesKey(owner, repo), data)
+}
@@ -0,0 +1,164 @@
+package updater
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/storage"
+)
+
+func TestNewChangeTicketer(t *testing.T) {
+	tests := []struct {
+		spec    string
+		wantNil bool
+		wantErr bool
+	}{
+		{spec: "", wantNil: true},
+		{spec: "command:./ticket.sh create"},
+		{spec: "https://tickets.example.com/api"},
+		{spec: "http://localhost:8080/tickets"},
+		{spec: "http://tickets.example.com", wantErr: true},
+		{spec: "command:", wantErr: true},
+		{spec: "jira", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			ticketer, err := NewChangeTicketer(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewChangeTicketer() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (ticketer == nil) != tt.wantNil {
+				t.Errorf("NewChangeTicketer() = %v, want nil %v", ticketer, tt.wantNil)
+			}
+		})
+	}
+}
+
+func TestHTTPChangeTicketer(t *testing.T) {
+	var requests []TicketRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req TicketRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decoding request: %v", err)
+		}
+		requests = append(requests, req)
+		if req.Action == "create" {
+			_, _ = w.Write([]byte(`{"id":"CHG0001","url":"https://tickets.example.com/CHG0001","status":"New"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":"CHG0001","status":"Approved"}`))
+	}))
+	defer server.Close()
+
+	ticketer, err := NewChangeTicketer(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	updates := []*Update{{Action: ActionReference{Owner: "actions", Name: "checkout"}, OldVersion: "v3", NewVersion: "v4"}}
+	ticket, err := ticketer.CreateTicket(ctx, "acme/api", updates)
+	if err != nil {
+		t.Fatalf("CreateTicket() error = %v", err)
+	}
+	if ticket.ID != "CHG0001" || ticket.Status != TicketPending {
+		t.Errorf("CreateTicket() = %+v", ticket)
+	}
+	status, err := ticketer.TicketStatus(ctx, *ticket)
+	if err != nil || status != TicketApproved {
+		t.Errorf("TicketStatus() = %q, %v", status, err)
+	}
+
+	if len(requests) != 2 || requests[0].Repository != "acme/api" || len(requests[0].Updates) != 1 ||
+		requests[0].Updates[0].Action != "actions/checkout" || requests[1].Ticket == nil || requests[1].Ticket.ID != "CHG0001" {
+		t.Errorf("unexpected requests %+v", requests)
+	}
+}
+
+// linkingPRCreator records the change ticket it was given
+type linkingPRCreator struct {
+	capturingPRCreator
+	ticket *Ticket
+	calls  int
+}
+
+func (c *linkingPRCreator) SetChangeTicket(ticket *Ticket) { c.ticket = ticket }
+
+func (c *linkingPRCreator) CreatePR(ctx context.Context, updates []*Update) error {
+	c.calls++
+	return c.capturingPRCreator.CreatePR(ctx, updates)
+}
+
+// stubTicketer hands out numbered tickets
+type stubTicketer struct {
+	created int
+}
+
+func (s *stubTicketer) CreateTicket(ctx context.Context, repository string, updates []*Update) (*Ticket, error) {
+	s.created++
+	return &Ticket{ID: "CHG" + repository, Status: TicketPending}, nil
+}
+
+func (s *stubTicketer) TicketStatus(ctx context.Context, ticket Ticket) (string, error) {
+	return TicketPending, nil
+}
+
+func TestTicketingPRCreator(t *testing.T) {
+	inner := &linkingPRCreator{}
+	ticketer := &stubTicketer{}
+	creator := NewTicketingPRCreator(inner, ticketer, "acme/api")
+	updates := []*Update{{Action: ActionReference{Owner: "actions", Name: "checkout"}}}
+
+	// Retries of a failed CreatePR reuse its ticket
+	inner.err = errors.New("unavailable")
+	if err := creator.CreatePR(context.Background(), updates); err == nil {
+		t.Fatal("CreatePR() expected error")
+	}
+	inner.err = nil
+	if err := creator.CreatePR(context.Background(), updates); err != nil {
+		t.Fatalf("CreatePR() error = %v", err)
+	}
+	first := inner.ticket
+
+	// The next pull request gets a ticket of its own
+	if err := creator.CreatePR(context.Background(), updates); err != nil {
+		t.Fatalf("CreatePR() error = %v", err)
+	}
+	if ticketer.created != 2 || inner.calls != 3 {
+		t.Errorf("created %d tickets for %d CreatePR calls, want 2 for 3", ticketer.created, inner.calls)
+	}
+	pulls := creator.PullRequests()
+	if len(pulls) != 2 || pulls[0].Ticket != first || pulls[1].Ticket != inner.ticket || first == inner.ticket || first.ID != "CHGacme/api" {
+		t.Errorf("tickets not linked: %+v", pulls)
+	}
+
+	pr := &DefaultPRCreator{}
+	pr.SetChangeTicket(&Ticket{ID: "CHG0001", URL: "https://tickets.example.com/CHG0001"})
+	if body := pr.generatePRBody(updates); !strings.HasPrefix(body, "Change ticket: [CHG0001](https://tickets.example.com/CHG0001).") {
+		t.Errorf("PR body does not link the ticket:\n%s", body)
+	}
+}
+
+func TestChangeGates(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	gates := []ChangeGate{{PullRequest: 7, Ticket: Ticket{ID: "CHG0001"}, CreatedAt: time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)}}
+	if err := SaveChangeGates(ctx, store, "acme", "api", gates); err != nil {
+		t.Fatalf("SaveChangeGates() error = %v", err)
+	}
+	loaded, err := LoadChangeGates(ctx, store, "acme", "api")
+	if err != nil || len(loaded) != 1 || loaded[0].Ticket.ID != "CHG0001" {
+		t.Fatalf("LoadChangeGates() = %+v, %v", loaded, err)
+	}
+	if err := SaveChangeGates(ctx, store, "acme", "api", nil); err != nil {
+		t.Fatalf("SaveChangeGates(nil) error = %v", err)
+	}
+	if loaded, err := LoadChangeGates(ctx, store, "acme", "api"); err != nil || len(loaded) != 0 {
+		t.Errorf("LoadChangeGates() after clearing = %+v, %v", loaded, err)
+	}
+}
@@ -0,0 +1,61 @@
+package updater
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+	"github.com/google/go-github/v72/github"
+)
+
+// StaleBranches returns the branches of owner/repo named by template that
+// no open pull request uses, such as the branches of merged or closed
+// update pull requests
+func StaleBranches(ctx context.Context, client *github.Client, owner, repo string, template BranchTemplate) ([]string, error) {
+	open := make(map[string]bool)
+	prOpts := &github.PullRequestListOptions{State: "open", ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		prs, resp, err := client.PullRequests.List(ctx, owner, repo, prOpts)
+		if err != nil {
+			return nil, fmt.Errorf(common.ErrListingPullRequests, owner, repo, err)
+		}
+		for _, pr := range prs {
+			open[pr.GetHead().GetRef()] = true
+		}
+		if resp == nil || resp.NextPage == 0 {
+			break
+		}
+		prOpts.Page = resp.NextPage
+	}
+
+	var stale []string
+	refOpts := &github.ReferenceListOptions{Ref: "heads/" + template.Prefix(), ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		refs, resp, err := client.Git.ListMatchingRefs(ctx, owner, repo, refOpts)
+		if err != nil {
+			return nil, fmt.Errorf(common.ErrListingBranches, owner, repo, err)
+		}
+		for _, ref := range refs {
+			branch := strings.TrimPrefix(ref.GetRef(), "refs/heads/")
+			if template.Matches(branch) && !open[branch] {
+				stale = append(stale, branch)
+			}
+		}
+		if resp == nil || resp.NextPage == 0 {
+			break
+		}
+		refOpts.Page = resp.NextPage
+	}
+	sort.Strings(stale)
+	return stale, nil
+}
+
+// DeleteBranch deletes a branch of owner/repo
+func DeleteBranch(ctx context.Context, client *github.Client, owner, repo, branch string) error {
+	if _, err := client.Git.DeleteRef(ctx, owner, repo, "heads/"+branch); err != nil {
+		return fmt.Errorf(common.ErrDeletingBranch, branch, err)
+	}
+	return nil
+}
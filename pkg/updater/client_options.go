@@ -0,0 +1,39 @@
+package updater
+
+import (
+	"net/http"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+	"github.com/google/go-github/v72/github"
+)
+
+// ClientOptions configures how the GitHub API clients of a version checker or
+// pull request creator are built, e.g. to send requests through a corporate
+// proxy. The zero value uses common.HTTPTransport.
+type ClientOptions struct {
+	// HTTPClient sends the API requests; the token is added on top of its
+	// transport (optional)
+	HTTPClient *http.Client
+	// Transport is the base transport when HTTPClient is not set (optional)
+	Transport http.RoundTripper
+	// BaseURL is the REST API URL of a GitHub Enterprise Server, such as
+	// https://ghe.example.com/api/v3/ (optional)
+	BaseURL string
+}
+
+// newClient returns a client for the API at o.BaseURL authenticated with token
+func (o ClientOptions) newClient(token string) *github.Client {
+	return o.newClientAt(o.BaseURL, token)
+}
+
+// newClientAt returns a client for the API at baseURL authenticated with token
+func (o ClientOptions) newClientAt(baseURL, token string) *github.Client {
+	options := common.DefaultGitHubClientOptions()
+	options.Token = token
+	options.BaseURL = baseURL
+	options.HTTPClient = o.HTTPClient
+	if o.Transport != nil {
+		options.Transport = o.Transport
+	}
+	return common.NewGitHubClient(options)
+}
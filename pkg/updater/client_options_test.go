@@ -0,0 +1,66 @@
+package updater
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// redirectTransport sends every request to server, recording the host and
+// token each was meant for
+type redirectTransport struct {
+	server   *url.URL
+	requests []string
+}
+
+func (t *redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requests = append(t.requests, req.URL.Host+" "+strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer "))
+	req = req.Clone(req.Context())
+	req.URL.Scheme, req.URL.Host = t.server.Scheme, t.server.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestClientOptions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if strings.HasSuffix(r.URL.Path, "/git/ref/tags/v1") {
+			fmt.Fprint(w, `{"ref":"refs/tags/v1","object":{"sha":"abc123","type":"commit"}}`)
+			return
+		}
+		fmt.Fprint(w, `{"name":"repo"}`)
+	}))
+	defer server.Close()
+	serverURL, _ := url.Parse(server.URL)
+	transport := &redirectTransport{server: serverURL}
+	options := ClientOptions{Transport: transport}
+
+	// The default, scoped token and other host clients all use the transport
+	checker := NewDefaultVersionCheckerWithOptions("default-token", options)
+	checker.SetActionTokens(ActionTokens{"private": "scoped-token"})
+	checker.SetActionHosts(ActionHosts{"ghe.example.com": {APIURL: "https://ghe.example.com/api/v3/", Token: "ghe-token"}})
+	for _, action := range []ActionReference{
+		{Owner: "actions", Name: "checkout"},
+		{Owner: "private", Name: "action"},
+		{Owner: "o", Name: "r", Host: "ghe.example.com"},
+	} {
+		if hash, err := checker.GetCommitHash(context.Background(), action, "v1"); err != nil || hash != "abc123" {
+			t.Errorf("GetCommitHash(%s) = %s, %v", action.FullName(), hash, err)
+		}
+	}
+
+	creator := NewPRCreatorWithOptions("pr-token", "owner", "repo", options)
+	if _, _, err := creator.client.Repositories.Get(context.Background(), "owner", "repo"); err != nil {
+		t.Fatalf("Repositories.Get() error = %v", err)
+	}
+
+	sort.Strings(transport.requests)
+	want := "[api.github.com default-token api.github.com pr-token api.github.com scoped-token ghe.example.com ghe-token]"
+	if got := fmt.Sprint(transport.requests); got != want {
+		t.Errorf("requests = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,187 @@
+package updater
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/versions"
+	"github.com/google/go-github/v72/github"
+)
+
+// Comment drift modes of Run
+const (
+	DriftReport     = "report"      // Only report pins whose version comment is wrong
+	DriftFixComment = "fix-comment" // Rewrite the comment to a tag of the pinned commit
+	DriftFixPin     = "fix-pin"     // Re-pin to the commit of the commented version
+)
+
+// Results of a comment drift in CommentDrift.Fixed
+const (
+	DriftFixedByUpdate = "update" // An update replaces both the pin and the comment
+)
+
+// maxCommitTagPages bounds the pages of tags searched for a commit
+const maxCommitTagPages = 10
+
+// CommitTagsProvider is implemented by version checkers that can find the
+// tags pointing at a commit
+type CommitTagsProvider interface {
+	GetCommitTags(ctx context.Context, action ActionReference, hash string) ([]string, error)
+}
+
+// CommentDrift is a reference pinned to a commit that is not the version
+// named in its version comment, as in "uses: owner/name@<sha>  # v3" where
+// <sha> is not v3
+type CommentDrift struct {
+	Action  ActionReference
+	File    string
+	TagHash string   // Commit of the commented version
+	Tags    []string // Tags pointing at the pinned commit, when known
+	Fixed   string   // How the drift is fixed: DriftFixComment, DriftFixPin, DriftFixedByUpdate or ""
+}
+
+// ValidDriftMode reports an error for an unknown comment drift mode. The
+// empty mode disables the check.
+func ValidDriftMode(mode string) error {
+	switch mode {
+	case "", DriftReport, DriftFixComment, DriftFixPin:
+		return nil
+	}
+	return fmt.Errorf(common.ErrUnknownDriftMode, mode)
+}
+
+// CheckCommentDrift compares the commit ref is pinned to with the commit of
+// the version in its comment. It returns nil when they match or ref has no
+// version comment. The tags of the pinned commit are looked up when checker
+// implements CommitTagsProvider.
+func CheckCommentDrift(ctx context.Context, checker VersionChecker, ref ActionReference) (*CommentDrift, error) {
+	if ref.CommitHash == "" || ref.Version == ref.CommitHash || !versions.IsVersion(ref.Version) {
+		return nil, nil
+	}
+	hash, err := checker.GetCommitHash(ctx, ref, ref.Version)
+	if err != nil {
+		return nil, err
+	}
+	if sameCommit(hash, ref.CommitHash) {
+		return nil, nil
+	}
+
+	drift := &CommentDrift{Action: ref, TagHash: hash}
+	if provider, ok := checker.(CommitTagsProvider); ok {
+		tags, err := provider.GetCommitTags(ctx, ref, ref.CommitHash)
+		if err != nil {
+			return drift, err
+		}
+		drift.Tags = tags
+	}
+	return drift, nil
+}
+
+// sameCommit reports whether two hashes, either possibly abbreviated, name
+// the same commit
+func sameCommit(a, b string) bool {
+	a, b = strings.ToLower(a), strings.ToLower(b)
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	return a != "" && strings.HasPrefix(b, a)
+}
+
+// fixDrift creates the update fixing a drift according to mode, or returns
+// nil when mode does not fix drifts or the comment has no tag to name
+func fixDrift(ctx context.Context, manager UpdateManager, mode string, drift *CommentDrift) (*Update, error) {
+	ref := drift.Action
+	var version, hash string
+	switch mode {
+	case DriftFixComment:
+		version, hash = pickLatestTag(drift.Tags), ref.CommitHash
+	case DriftFixPin:
+		version, hash = ref.Version, drift.TagHash
+	}
+	if version == "" {
+		return nil, nil
+	}
+	update, err := manager.CreateUpdate(ctx, drift.File, ref, version, hash)
+	if err != nil || update == nil {
+		return nil, err
+	}
+	update.Description = fmt.Sprintf("Fix version comment of %s from %s to %s", ref.FullName(), ref.Version, version)
+	if mode == DriftFixPin {
+		update.Description = fmt.Sprintf("Re-pin %s to the commit of %s", ref.FullName(), version)
+	}
+	drift.Fixed = mode
+	return update, nil
+}
+
+// GetCommitTags returns the tags pointing at hash
+func (c *DefaultVersionChecker) GetCommitTags(ctx context.Context, action ActionReference, hash string) ([]string, error) {
+	opts := &github.ListOptions{PerPage: 100}
+	var names []string
+	for page := 0; page < maxCommitTagPages; page++ {
+		tags, resp, err := c.clientFor(action).Repositories.ListTags(ctx, action.Owner, action.Repo(), opts)
+		if err != nil {
+			return nil, fmt.Errorf(common.ErrGettingCommitTags, hash, c.accessError(ctx, action, err))
+		}
+		for _, tag := range tags {
+			if sameCommit(tag.GetCommit().GetSHA(), hash) {
+				names = append(names, tag.GetName())
+			}
+		}
+		if resp == nil || resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return names, nil
+}
+
+// GetCommitTags returns the tags pointing at hash
+func (c *GiteaVersionChecker) GetCommitTags(ctx context.Context, action ActionReference, hash string) ([]string, error) {
+	const limit = 50
+	var names []string
+	for page := 1; page <= maxCommitTagPages; page++ {
+		var tags []giteaTag
+		query := url.Values{"limit": []string{strconv.Itoa(limit)}, "page": []string{strconv.Itoa(page)}}
+		if err := c.client.do(ctx, http.MethodGet, repoPath(action.Owner, action.Repo(), "tags"), query, nil, &tags); err != nil {
+			return nil, fmt.Errorf(common.ErrGettingCommitTags, hash, err)
+		}
+		for _, tag := range tags {
+			if sameCommit(tag.Commit.SHA, hash) {
+				names = append(names, tag.Name)
+			}
+		}
+		if len(tags) < limit {
+			break
+		}
+	}
+	return names, nil
+}
+
+// GetCommitTags implements CommitTagsProvider when the wrapped checker does
+func (c *CachingVersionChecker) GetCommitTags(ctx context.Context, action ActionReference, hash string) ([]string, error) {
+	provider, ok := c.checker.(CommitTagsProvider)
+	if !ok {
+		return nil, fmt.Errorf(common.ErrGettingCommitTags, hash, fmt.Errorf("not supported"))
+	}
+	return provider.GetCommitTags(ctx, action, hash)
+}
+
+// GetCommitTags implements CommitTagsProvider when the wrapped checker does
+func (c *RetryingVersionChecker) GetCommitTags(ctx context.Context, action ActionReference, hash string) ([]string, error) {
+	provider, ok := c.checker.(CommitTagsProvider)
+	if !ok {
+		return nil, fmt.Errorf(common.ErrGettingCommitTags, hash, fmt.Errorf("not supported"))
+	}
+	var tags []string
+	err := c.policy.Do(ctx, func() error {
+		var err error
+		tags, err = provider.GetCommitTags(ctx, action, hash)
+		return err
+	})
+	return tags, err
+}
@@ -0,0 +1,154 @@
+package updater
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v72/github"
+)
+
+const (
+	driftPinnedHash = "a81bbbf8298c0fa03ea29cdc473d45769f953675"
+	driftTagHash    = "2222222222222222222222222222222222222222"
+)
+
+// driftChecker is a version checker whose v3.5.0 tag is not the commit
+// pinned in the workflows, which v3.4.0 points at instead
+type driftChecker struct {
+	latest string
+}
+
+func (c *driftChecker) GetLatestVersion(ctx context.Context, action ActionReference) (string, string, error) {
+	return c.latest, driftTagHash, nil
+}
+
+func (c *driftChecker) IsUpdateAvailable(ctx context.Context, action ActionReference) (bool, string, string, error) {
+	return c.latest != action.Version, c.latest, driftTagHash, nil
+}
+
+func (c *driftChecker) GetCommitHash(ctx context.Context, action ActionReference, version string) (string, error) {
+	return driftTagHash, nil
+}
+
+func (c *driftChecker) GetCommitTags(ctx context.Context, action ActionReference, hash string) ([]string, error) {
+	if hash != driftPinnedHash {
+		return nil, fmt.Errorf("unexpected hash %s", hash)
+	}
+	return []string{"v3.4.0", "v3.4"}, nil
+}
+
+func TestRunCommentDrift(t *testing.T) {
+	tests := []struct {
+		name      string
+		mode      string
+		latest    string
+		want      string
+		wantFixed string
+	}{
+		{name: "report", mode: DriftReport, latest: "v3.5.0", want: "actions/checkout@" + driftPinnedHash + "  # v3.5.0"},
+		{name: "fix comment", mode: DriftFixComment, latest: "v3.5.0", want: "actions/checkout@" + driftPinnedHash + "  # v3.4.0", wantFixed: DriftFixComment},
+		{name: "fix pin", mode: DriftFixPin, latest: "v3.5.0", want: "actions/checkout@" + driftTagHash + "  # v3.5.0", wantFixed: DriftFixPin},
+		{name: "fixed by update", mode: DriftReport, latest: "v4.0.0", want: "actions/checkout@" + driftTagHash + "  # v4.0.0", wantFixed: DriftFixedByUpdate},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			workflow := filepath.Join(dir, ".github", "workflows", "ci.yml")
+			if err := os.MkdirAll(filepath.Dir(workflow), 0750); err != nil {
+				t.Fatal(err)
+			}
+			content := "jobs:\n  a:\n    steps:\n      - uses: actions/checkout@" + driftPinnedHash + "  # v3.5.0\n"
+			if err := os.WriteFile(workflow, []byte(content), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			rep, err := Run(context.Background(), Options{
+				RepoPath:     dir,
+				Mode:         ModeStage,
+				Checker:      &driftChecker{latest: tt.latest},
+				CommentDrift: tt.mode,
+			})
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if len(rep.CommentDrift) != 1 {
+				t.Fatalf("CommentDrift = %+v, want 1 drift", rep.CommentDrift)
+			}
+			drift := rep.CommentDrift[0]
+			if drift.File != workflow || drift.TagHash != driftTagHash || drift.Fixed != tt.wantFixed ||
+				!reflect.DeepEqual(drift.Tags, []string{"v3.4.0", "v3.4"}) {
+				t.Errorf("drift = %+v", drift)
+			}
+
+			got, _ := os.ReadFile(workflow)
+			if !strings.Contains(string(got), "      - uses: "+tt.want+"\n") {
+				t.Errorf("updated content = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := Run(context.Background(), Options{RepoPath: t.TempDir(), Checker: &driftChecker{}, CommentDrift: "fix"}); err == nil ||
+		!strings.Contains(err.Error(), "unknown comment drift mode") {
+		t.Errorf("Run() error = %v, want unknown comment drift mode", err)
+	}
+}
+
+func TestCheckCommentDrift(t *testing.T) {
+	checker := &driftChecker{}
+	tests := []struct {
+		name string
+		ref  ActionReference
+		want bool
+	}{
+		{name: "drift", ref: ActionReference{Version: "v3.5.0", CommitHash: driftPinnedHash}, want: true},
+		{name: "match", ref: ActionReference{Version: "v3.5.0", CommitHash: driftTagHash[:12]}},
+		{name: "no comment", ref: ActionReference{Version: driftPinnedHash, CommitHash: driftPinnedHash}},
+		{name: "branch comment", ref: ActionReference{Version: "main", CommitHash: driftPinnedHash}},
+		{name: "tag", ref: ActionReference{Version: "v3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			drift, err := CheckCommentDrift(context.Background(), checker, tt.ref)
+			if err != nil {
+				t.Fatalf("CheckCommentDrift() error = %v", err)
+			}
+			if (drift != nil) != tt.want {
+				t.Errorf("CheckCommentDrift() = %+v, want drift %v", drift, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetCommitTags(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/o/r/tags", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "" {
+			w.Header().Set("Link", `<`+"http://"+r.Host+`/repos/o/r/tags?page=2>; rel="next"`)
+			fmt.Fprintf(w, `[{"name":"v3.5.0","commit":{"sha":%q}},{"name":"v3","commit":{"sha":%q}}]`, driftTagHash, driftPinnedHash)
+			return
+		}
+		fmt.Fprintf(w, `[{"name":"v3.4.0","commit":{"sha":%q}}]`, driftPinnedHash)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	checker := &DefaultVersionChecker{client: client}
+
+	tags, err := checker.GetCommitTags(context.Background(), ActionReference{Owner: "o", Name: "r"}, driftPinnedHash)
+	if err != nil {
+		t.Fatalf("GetCommitTags() error = %v", err)
+	}
+	if !reflect.DeepEqual(tags, []string{"v3", "v3.4.0"}) {
+		t.Errorf("GetCommitTags() = %v, want [v3 v3.4.0]", tags)
+	}
+}
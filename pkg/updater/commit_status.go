@@ -0,0 +1,80 @@
+package updater
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+	"github.com/google/go-github/v72/github"
+)
+
+// Ways of reporting the update result on a pull request's head commit
+const (
+	CommitStatusKind = "status"
+	CheckRunKind     = "check-run"
+)
+
+// commitStatusContext names the commit status and check run
+const commitStatusContext = "ghactions-updater"
+
+// UnpinnedRemaining returns how many of refs are not pinned to a commit hash
+// once updates are applied, counting references a pin policy writes as tags
+func UnpinnedRemaining(refs []ActionReference, updates []*Update) int {
+	remaining := 0
+	for _, ref := range refs {
+		if ref.RefType() != RefTypeSHA {
+			remaining++
+		}
+	}
+	for _, update := range updates {
+		pinned := update.Action.RefType() == RefTypeSHA
+		switch {
+		case !pinned && update.NewHash != "" && !update.pinsTag():
+			remaining--
+		case pinned && update.pinsTag():
+			remaining++
+		}
+	}
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// UpdateStatusDescription summarizes an update for a commit status
+func UpdateStatusDescription(updated, unpinned int) string {
+	return fmt.Sprintf("%d actions updated, %d unpinned remaining", updated, unpinned)
+}
+
+// SetCommitStatus reports the update result on sha as a commit status or,
+// with kind CheckRunKind, as a completed check run through the Checks API.
+// Both are named "ghactions-updater" so protected-branch UIs show them next
+// to the other checks. Check runs require a GitHub App token.
+func SetCommitStatus(ctx context.Context, client *github.Client, owner, repo, sha, kind, description string) error {
+	var err error
+	switch kind {
+	case CheckRunKind:
+		_, _, err = client.Checks.CreateCheckRun(ctx, owner, repo, github.CreateCheckRunOptions{
+			Name:        commitStatusContext,
+			HeadSHA:     sha,
+			Status:      github.Ptr("completed"),
+			Conclusion:  github.Ptr("success"),
+			CompletedAt: &github.Timestamp{Time: time.Now()},
+			Output: &github.CheckRunOutput{
+				Title:   github.Ptr(commitStatusContext + ": " + description),
+				Summary: github.Ptr(description),
+			},
+		})
+	default:
+		_, _, err = client.Repositories.CreateStatus(ctx, owner, repo, sha, &github.RepoStatus{
+			State:       github.Ptr("success"),
+			Context:     github.Ptr(commitStatusContext),
+			Description: github.Ptr(description),
+		})
+	}
+	if err != nil {
+		return fmt.Errorf(common.ErrSettingCommitStatus, kind, sha, err)
+	}
+	return nil
+}
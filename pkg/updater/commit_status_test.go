@@ -0,0 +1,78 @@
+package updater
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestSetCommitStatus(t *testing.T) {
+	const sha = "abc123"
+	tests := []struct {
+		kind string
+		path string
+		want map[string]any
+	}{
+		{kind: CommitStatusKind, path: "/repos/acme/api/statuses/" + sha, want: map[string]any{"state": "success", "context": "ghactions-updater", "description": "2 actions updated, 0 unpinned remaining"}},
+		{kind: CheckRunKind, path: "/repos/acme/api/check-runs", want: map[string]any{"name": "ghactions-updater", "head_sha": sha, "status": "completed", "conclusion": "success"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.kind, func(t *testing.T) {
+			var got map[string]any
+			mux := http.NewServeMux()
+			mux.HandleFunc(tt.path, func(w http.ResponseWriter, r *http.Request) {
+				_ = json.NewDecoder(r.Body).Decode(&got)
+				w.WriteHeader(http.StatusCreated)
+				_, _ = w.Write([]byte(`{}`))
+			})
+
+			client := newRepositoriesTestClient(t, mux)
+			if err := SetCommitStatus(context.Background(), client, "acme", "api", sha, tt.kind, UpdateStatusDescription(2, 0)); err != nil {
+				t.Fatalf("SetCommitStatus() error = %v", err)
+			}
+			for key, want := range tt.want {
+				if got[key] != want {
+					t.Errorf("%s = %v, want %v", key, got[key], want)
+				}
+			}
+		})
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/acme/api/check-runs", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"Resource not accessible by integration"}`, http.StatusForbidden)
+	})
+	err := SetCommitStatus(context.Background(), newRepositoriesTestClient(t, mux), "acme", "api", sha, CheckRunKind, "x")
+	if err == nil || !strings.Contains(err.Error(), "check-run") {
+		t.Errorf("SetCommitStatus() error = %v, want check-run failure", err)
+	}
+}
+
+func TestUnpinnedRemaining(t *testing.T) {
+	const hash = "1234567890123456789012345678901234567890"
+	refs := []ActionReference{
+		{Owner: "actions", Name: "checkout", Version: "v3"},
+		{Owner: "actions", Name: "cache", Version: hash, CommitHash: hash},
+		{Owner: "actions", Name: "setup-go", Version: "main"},
+	}
+	updates := []*Update{
+		{Action: refs[0], NewVersion: "v4", NewHash: hash},
+		{Action: refs[1], NewVersion: "v4", NewHash: hash},
+	}
+	if got := UnpinnedRemaining(refs, updates); got != 1 {
+		t.Errorf("UnpinnedRemaining() = %d, want 1", got)
+	}
+	if got := UnpinnedRemaining(refs, nil); got != 2 {
+		t.Errorf("UnpinnedRemaining() without updates = %d, want 2", got)
+	}
+
+	// References written as tags stay unpinned
+	for _, update := range updates {
+		update.PinStyle = PinMajorTag
+	}
+	if got := UnpinnedRemaining(refs, updates); got != 3 {
+		t.Errorf("UnpinnedRemaining() with major tags = %d, want 3", got)
+	}
+}
@@ -0,0 +1,63 @@
+package updater
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+)
+
+// UpdateConflictError is returned when several updates of the same reference
+// would write it differently. No file is rewritten.
+type UpdateConflictError struct {
+	File   string
+	Line   int
+	Action string   // Full name of the action as written
+	Refs   []string // What each conflicting update would write
+}
+
+// Error implements error
+func (e *UpdateConflictError) Error() string {
+	return fmt.Sprintf(common.ErrConflictingUpdates, e.Action, e.File, e.Line, strings.Join(e.Refs, ", "))
+}
+
+// mergeUpdates drops updates that repeat another update of the same
+// reference: the same action at the same old ref on the same line of a file.
+// Updates of one reference that would write different refs or version
+// comments are rejected with an UpdateConflictError instead of letting the
+// last one win.
+func mergeUpdates(updates []*Update) ([]*Update, error) {
+	first := make(map[string]*Update)
+	merged := make([]*Update, 0, len(updates))
+	for _, update := range updates {
+		oldRef := update.OldHash
+		if oldRef == "" {
+			oldRef = update.OldVersion
+		}
+		key := fmt.Sprintf("%s:%d:%s@%s", update.FilePath, update.LineNumber, strings.ToLower(update.Action.FullName()), oldRef)
+		kept, ok := first[key]
+		if !ok {
+			first[key] = update
+			merged = append(merged, update)
+			continue
+		}
+		if written(kept) != written(update) {
+			return nil, &UpdateConflictError{
+				File:   update.FilePath,
+				Line:   update.LineNumber,
+				Action: update.Action.FullName(),
+				Refs:   []string{written(kept), written(update)},
+			}
+		}
+	}
+	return merged, nil
+}
+
+// written returns the reference and version comment an update writes
+func written(update *Update) string {
+	ref := update.NewFullName() + "@" + update.NewRef()
+	if comment := updateVersionComment(update); comment != "" {
+		ref += " " + comment
+	}
+	return ref
+}
@@ -0,0 +1,52 @@
+package updater
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMergeUpdates(t *testing.T) {
+	update := func(line int, oldVersion, newVersion, newHash string) *Update {
+		return &Update{
+			Action:     ActionReference{Owner: "actions", Name: "checkout", Version: oldVersion, Line: line},
+			OldVersion: oldVersion,
+			NewVersion: newVersion,
+			NewHash:    newHash,
+			FilePath:   "ci.yml",
+			LineNumber: line,
+		}
+	}
+	hashV4 := "1111111111111111111111111111111111111111"
+	hashV5 := "2222222222222222222222222222222222222222"
+
+	tests := []struct {
+		name         string
+		updates      []*Update
+		want         int
+		wantConflict bool
+	}{
+		{name: "distinct lines", updates: []*Update{update(3, "v3", "v4", hashV4), update(5, "v3", "v4", hashV4)}, want: 2},
+		{name: "repeated update", updates: []*Update{update(3, "v3", "v4", hashV4), update(3, "v3", "v4", hashV4)}, want: 1},
+		// Flow sequences put several references on one line
+		{name: "different old refs", updates: []*Update{update(3, "v3", "v4", hashV4), update(3, "v2", "v5", hashV5)}, want: 2},
+		{name: "conflict", updates: []*Update{update(3, "v3", "v4", hashV4), update(3, "v3", "v5", hashV5)}, wantConflict: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := mergeUpdates(tt.updates)
+			var conflict *UpdateConflictError
+			if errors.As(err, &conflict) != tt.wantConflict {
+				t.Fatalf("mergeUpdates() error = %v, wantConflict %v", err, tt.wantConflict)
+			}
+			if tt.wantConflict {
+				if conflict.Line != 3 || conflict.Action != "actions/checkout" || len(conflict.Refs) != 2 {
+					t.Errorf("mergeUpdates() conflict = %+v", conflict)
+				}
+				return
+			}
+			if len(got) != tt.want || got[0] != tt.updates[0] {
+				t.Errorf("mergeUpdates() = %d updates, want %d", len(got), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,224 @@
+package updater
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/versions"
+	"gopkg.in/yaml.v3"
+)
+
+// DependabotConfigPaths are the locations of a repository's Dependabot
+// configuration, relative to its root
+var DependabotConfigPaths = []string{".github/dependabot.yml", ".github/dependabot.yaml"}
+
+// dependabotEcosystem is the package ecosystem of GitHub Actions
+const dependabotEcosystem = "github-actions"
+
+// Dependabot update types of ignore rules, by version delta
+var dependabotUpdateTypes = map[string]string{
+	"version-update:semver-major": DeltaMajor,
+	"version-update:semver-minor": DeltaMinor,
+	"version-update:semver-patch": DeltaPatch,
+}
+
+// dependabotConfig is the part of .github/dependabot.yml that is honored
+type dependabotConfig struct {
+	Updates []struct {
+		Ecosystem string           `yaml:"package-ecosystem"`
+		Allow     []dependabotRule `yaml:"allow"`
+		Ignore    []dependabotRule `yaml:"ignore"`
+	} `yaml:"updates"`
+}
+
+// dependabotRule is an allow or ignore entry
+type dependabotRule struct {
+	DependencyName string   `yaml:"dependency-name"`
+	Versions       []string `yaml:"versions"`
+	UpdateTypes    []string `yaml:"update-types"`
+}
+
+// DependabotRules are the allow and ignore rules of the github-actions
+// entries of a Dependabot configuration, so both tools propose the same
+// updates
+type DependabotRules struct {
+	allow  []dependabotRule
+	ignore []dependabotRule
+}
+
+// LoadDependabotRules reads the Dependabot configuration of the repository
+// checked out at repoPath. It returns nil when there is none or it has no
+// github-actions entry.
+func LoadDependabotRules(repoPath string) (*DependabotRules, error) {
+	for _, name := range DependabotConfigPaths {
+		path := filepath.Join(repoPath, filepath.FromSlash(name))
+		// #nosec G304 - path is inside the repository being updated
+		content, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf(common.ErrReadingDependabot, name, err)
+		}
+		rules, err := ParseDependabotRules(content)
+		if err != nil {
+			return nil, fmt.Errorf(common.ErrReadingDependabot, name, err)
+		}
+		return rules, nil
+	}
+	return nil, nil
+}
+
+// ParseDependabotRules parses a Dependabot configuration. It returns nil
+// when the configuration has no github-actions entry.
+func ParseDependabotRules(content []byte) (*DependabotRules, error) {
+	var config dependabotConfig
+	if err := yaml.Unmarshal(content, &config); err != nil {
+		return nil, err
+	}
+
+	var rules *DependabotRules
+	for _, update := range config.Updates {
+		if update.Ecosystem != dependabotEcosystem {
+			continue
+		}
+		if rules == nil {
+			rules = &DependabotRules{}
+		}
+		// Reject version requirements that could not be evaluated
+		for _, rule := range update.Ignore {
+			for _, requirement := range rule.Versions {
+				if _, err := matchesRequirement("0", requirement); err != nil {
+					return nil, err
+				}
+			}
+		}
+		rules.allow = append(rules.allow, update.Allow...)
+		rules.ignore = append(rules.ignore, update.Ignore...)
+	}
+	return rules, nil
+}
+
+// Allows reports whether Dependabot would propose the update of action to
+// newVersion. Nil rules allow every update.
+func (r *DependabotRules) Allows(action ActionReference, newVersion string) bool {
+	if r == nil {
+		return true
+	}
+	if len(r.allow) > 0 {
+		allowed := false
+		for _, rule := range r.allow {
+			// Rules by dependency type allow every action
+			if rule.DependencyName == "" || matchesDependency(rule.DependencyName, action) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return false
+		}
+	}
+	for _, rule := range r.ignore {
+		if rule.DependencyName != "" && matchesDependency(rule.DependencyName, action) && rule.ignores(action, newVersion) {
+			return false
+		}
+	}
+	return true
+}
+
+// ignores reports whether an ignore rule matching action covers newVersion.
+// A rule without versions or update types ignores every update.
+func (rule dependabotRule) ignores(action ActionReference, newVersion string) bool {
+	if len(rule.Versions) == 0 && len(rule.UpdateTypes) == 0 {
+		return true
+	}
+	for _, requirement := range rule.Versions {
+		if matched, err := matchesRequirement(newVersion, requirement); err == nil && matched {
+			return true
+		}
+	}
+	delta := VersionDelta(action.Version, newVersion)
+	for _, updateType := range rule.UpdateTypes {
+		if delta != "" && dependabotUpdateTypes[updateType] == delta {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesDependency reports whether a dependency name pattern, in which "*"
+// matches any text, names the action. Dependabot names actions by their
+// repository; the name with a subdirectory matches as well.
+func matchesDependency(pattern string, action ActionReference) bool {
+	expr := "(?i)^" + strings.ReplaceAll(regexp.QuoteMeta(pattern), `\*`, ".*") + "$"
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return false
+	}
+	return re.MatchString(action.Owner+"/"+action.Repo()) || re.MatchString(action.Owner+"/"+action.Name)
+}
+
+// requirementPattern matches a single version requirement, e.g. ">= 2.1"
+var requirementPattern = regexp.MustCompile(`^(>=|<=|!=|~>|>|<|=)?\s*v?(\d+|[x*])((?:\.(?:\d+|[x*]))*)$`)
+
+// matchesRequirement reports whether version satisfies a comma separated
+// list of requirements such as "4.x", ">= 2.0, < 3" or "~> 3.1"
+func matchesRequirement(version, requirement string) (bool, error) {
+	matched := true
+	for _, part := range strings.Split(requirement, ",") {
+		groups := requirementPattern.FindStringSubmatch(strings.TrimSpace(part))
+		if groups == nil {
+			return false, fmt.Errorf(common.ErrInvalidRequirement, requirement)
+		}
+		op, parts := groups[1], strings.Split(groups[2]+groups[3], ".")
+		wildcard := strings.ContainsAny(groups[2]+groups[3], "x*")
+		if wildcard && op != "" && op != "=" {
+			return false, fmt.Errorf(common.ErrInvalidRequirement, requirement)
+		}
+		if !satisfies(version, op, parts, wildcard) {
+			matched = false
+		}
+	}
+	return matched, nil
+}
+
+// satisfies reports whether version satisfies a single requirement
+func satisfies(version, op string, parts []string, wildcard bool) bool {
+	// Refs that are not versions compare as 0
+	v, _ := versions.Parse(version)
+	var required versions.Version
+	for _, part := range parts {
+		if part == "x" || part == "*" {
+			break
+		}
+		n, _ := strconv.Atoi(part)
+		required.Core = append(required.Core, n)
+	}
+	if wildcard {
+		return required.Contains(v)
+	}
+
+	cmp := v.Compare(required)
+	switch op {
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case "!=":
+		return cmp != 0
+	case "~>":
+		// At least the version, keeping all but its last given component
+		return cmp >= 0 && versions.Version{Core: required.Core[:len(required.Core)-1]}.Contains(v)
+	}
+	return cmp == 0
+}
@@ -0,0 +1,134 @@
+package updater
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const dependabotYAML = `version: 2
+updates:
+  - package-ecosystem: npm
+    directory: /
+    ignore:
+      - dependency-name: "*"
+  - package-ecosystem: github-actions
+    directory: /
+    schedule:
+      interval: weekly
+    ignore:
+      - dependency-name: actions/checkout
+        versions: ["5.x"]
+      - dependency-name: "docker/*"
+        update-types: ["version-update:semver-major"]
+      - dependency-name: github/codeql-action
+        versions: [">= 3.0, < 3.28"]
+      - dependency-name: octo/frozen
+`
+
+func TestDependabotRulesAllows(t *testing.T) {
+	rules, err := ParseDependabotRules([]byte(dependabotYAML))
+	if err != nil || rules == nil {
+		t.Fatalf("ParseDependabotRules() = %v, %v", rules, err)
+	}
+
+	tests := []struct {
+		action     ActionReference
+		newVersion string
+		want       bool
+	}{
+		{action: ActionReference{Owner: "actions", Name: "checkout", Version: "v4"}, newVersion: "v5.0.1", want: false},
+		{action: ActionReference{Owner: "actions", Name: "checkout", Version: "v4"}, newVersion: "v4.2.1", want: true},
+		{action: ActionReference{Owner: "Docker", Name: "build-push-action", Version: "v5.1.0"}, newVersion: "v6.0.0", want: false},
+		{action: ActionReference{Owner: "docker", Name: "build-push-action", Version: "v5.1.0"}, newVersion: "v5.2.0", want: true},
+		{action: ActionReference{Owner: "github", Name: "codeql-action/init", Version: "v2"}, newVersion: "v3.27.9", want: false},
+		{action: ActionReference{Owner: "github", Name: "codeql-action/init", Version: "v2"}, newVersion: "v3.28.0", want: true},
+		{action: ActionReference{Owner: "octo", Name: "frozen", Version: "v1"}, newVersion: "v1.0.1", want: false},
+		{action: ActionReference{Owner: "octo", Name: "tool", Version: "v1"}, newVersion: "v2", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.action.FullName()+"@"+tt.newVersion, func(t *testing.T) {
+			if got := rules.Allows(tt.action, tt.newVersion); got != tt.want {
+				t.Errorf("Allows() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	var none *DependabotRules
+	if !none.Allows(tests[0].action, tests[0].newVersion) {
+		t.Error("nil rules rejected an update")
+	}
+}
+
+func TestDependabotRulesAllow(t *testing.T) {
+	rules, err := ParseDependabotRules([]byte(`version: 2
+updates:
+  - package-ecosystem: github-actions
+    directory: /
+    allow:
+      - dependency-name: "actions/*"
+`))
+	if err != nil {
+		t.Fatalf("ParseDependabotRules() error = %v", err)
+	}
+	if !rules.Allows(ActionReference{Owner: "actions", Name: "cache"}, "v4") || rules.Allows(ActionReference{Owner: "octo", Name: "tool"}, "v2") {
+		t.Error("Allows() does not follow the allow rules")
+	}
+}
+
+func TestMatchesRequirement(t *testing.T) {
+	tests := []struct {
+		version     string
+		requirement string
+		want        bool
+		wantErr     bool
+	}{
+		{version: "v4.2.1", requirement: "4.x", want: true},
+		{version: "v4.2.1", requirement: "4.3.*", want: false},
+		{version: "v4.2.1", requirement: "4.2.1", want: true},
+		{version: "v4.2.1", requirement: "> 4.2", want: true},
+		{version: "v4.2.1", requirement: ">= 4, < 4.2", want: false},
+		{version: "v4.2.1", requirement: "!= 4.2.1", want: false},
+		{version: "v4.9.0", requirement: "~> 4.2", want: true},
+		{version: "v5.0.0", requirement: "~> 4.2", want: false},
+		{version: "v4.2.9", requirement: "~> 4.2.1", want: true},
+		{version: "v4.3.0", requirement: "~> 4.2.1", want: false},
+		{version: "v4.2.1", requirement: "latest", wantErr: true},
+		{version: "v4.2.1", requirement: ">= 4.x", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version+" "+tt.requirement, func(t *testing.T) {
+			got, err := matchesRequirement(tt.version, tt.requirement)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("matchesRequirement() = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadDependabotRules(t *testing.T) {
+	dir := t.TempDir()
+	if rules, err := LoadDependabotRules(dir); rules != nil || err != nil {
+		t.Errorf("LoadDependabotRules() without a configuration = %v, %v", rules, err)
+	}
+
+	path := filepath.Join(dir, ".github", "dependabot.yaml")
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(dependabotYAML), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if rules, err := LoadDependabotRules(dir); rules == nil || err != nil {
+		t.Errorf("LoadDependabotRules() = %v, %v", rules, err)
+	}
+
+	invalid := "updates:\n  - package-ecosystem: github-actions\n    ignore:\n      - dependency-name: actions/checkout\n        versions: [latest]\n"
+	if err := os.WriteFile(path, []byte(invalid), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadDependabotRules(dir); err == nil || !strings.Contains(err.Error(), "invalid Dependabot version requirement") {
+		t.Errorf("LoadDependabotRules() error = %v", err)
+	}
+}
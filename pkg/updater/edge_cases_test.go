@@ -230,7 +230,7 @@ jobs:
     runs-on: ubuntu-latest
     steps:
       - uses: ${{ matrix.action }}`,
-			wantRefs: 1, // Should only count direct uses
+			wantRefs: 2, // Resolved from the matrix values
 			wantErr:  false,
 		},
 		{
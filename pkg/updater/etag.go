@@ -0,0 +1,164 @@
+package updater
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/storage"
+)
+
+// etagKeyPrefix is the key prefix of stored responses in the shared store
+const etagKeyPrefix = "etag"
+
+// maxConditionalBody limits the size of responses stored for revalidation
+const maxConditionalBody = 1 << 20
+
+// conditionalLookup matches the release and tag lookups of an action's
+// repository on GitHub (/repos/...) and Gitea (/api/v1/repos/...)
+var conditionalLookup = regexp.MustCompile(`/repos/[^/]+/[^/]+/(releases|tags|git/refs?/tags|git/tags)(/|$)`)
+
+// etagEntry is the serialized form of a stored response
+type etagEntry struct {
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	Status       int       `json:"status"`
+	ContentType  string    `json:"content_type,omitempty"`
+	Body         []byte    `json:"body"`
+	FetchedAt    time.Time `json:"fetched_at"`
+}
+
+// ConditionalTransport is an http.RoundTripper that stores the ETag and
+// Last-Modified validators of release and tag lookups, and sends them with
+// the next request for the same URL. An unchanged response comes back as a
+// 304, which does not count against the core rate limit, and is answered
+// with the stored body.
+type ConditionalTransport struct {
+	// Base is the underlying transport (http.DefaultTransport if nil)
+	Base http.RoundTripper
+	// Store holds the validators and bodies between runs
+	Store storage.Store
+}
+
+// NewConditionalTransport creates a ConditionalTransport around base
+func NewConditionalTransport(base http.RoundTripper, store storage.Store) *ConditionalTransport {
+	return &ConditionalTransport{Base: base, Store: store}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *ConditionalTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if req.Method != http.MethodGet || !conditionalLookup.MatchString(req.URL.Path) {
+		return base.RoundTrip(req)
+	}
+
+	ctx := req.Context()
+	key := etagKey(req)
+	entry, ok := t.load(ctx, key)
+	if ok && req.Header.Get("If-None-Match") == "" && req.Header.Get("If-Modified-Since") == "" {
+		req = req.Clone(ctx)
+		if entry.ETag != "" {
+			req.Header.Set("If-None-Match", entry.ETag)
+		}
+		if entry.LastModified != "" {
+			req.Header.Set("If-Modified-Since", entry.LastModified)
+		}
+	}
+
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotModified && ok:
+		return entry.response(resp), nil
+	case resp.StatusCode == http.StatusOK && (resp.Header.Get("ETag") != "" || resp.Header.Get("Last-Modified") != ""):
+		return t.save(ctx, key, resp)
+	}
+	return resp, nil
+}
+
+// load returns the stored response for key. Store errors are treated as
+// misses.
+func (t *ConditionalTransport) load(ctx context.Context, key string) (etagEntry, bool) {
+	var entry etagEntry
+	data, err := t.Store.Get(ctx, key)
+	if err != nil {
+		return entry, false
+	}
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return entry, false
+	}
+	return entry, true
+}
+
+// save stores the validators and body of resp and returns resp with its
+// body replaced by the bytes read. Store errors are logged and ignored.
+func (t *ConditionalTransport) save(ctx context.Context, key string, resp *http.Response) (*http.Response, error) {
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxConditionalBody+1))
+	closeErr := resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	if closeErr != nil {
+		log.Printf(common.ErrFailedToCloseBody, closeErr)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if len(body) > maxConditionalBody {
+		return resp, nil
+	}
+
+	data, err := json.Marshal(etagEntry{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		Status:       resp.StatusCode,
+		ContentType:  resp.Header.Get("Content-Type"),
+		Body:         body,
+		FetchedAt:    time.Now(),
+	})
+	if err == nil {
+		err = t.Store.Put(ctx, key, data)
+	}
+	if err != nil {
+		log.Printf(common.ErrSavingETag, key, err)
+	}
+	return resp, nil
+}
+
+// response turns a 304 for the stored response into the response it
+// stands for, keeping the headers of the 304 such as the rate limit
+func (e etagEntry) response(notModified *http.Response) *http.Response {
+	if err := notModified.Body.Close(); err != nil {
+		log.Printf(common.ErrFailedToCloseBody, err)
+	}
+	resp := *notModified
+	resp.StatusCode = e.Status
+	resp.Status = fmt.Sprintf("%d %s", e.Status, http.StatusText(e.Status))
+	resp.Header = notModified.Header.Clone()
+	if e.ContentType != "" {
+		resp.Header.Set("Content-Type", e.ContentType)
+	}
+	resp.Header.Del("Content-Length")
+	resp.Body = io.NopCloser(bytes.NewReader(e.Body))
+	resp.ContentLength = int64(len(e.Body))
+	return &resp
+}
+
+// etagKey is the store key of the response to req. URLs are hashed, as tag
+// names and queries are not valid in every store's keys.
+func etagKey(req *http.Request) string {
+	sum := sha256.Sum256([]byte(req.URL.Host + req.URL.RequestURI()))
+	return etagKeyPrefix + "/" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,84 @@
+package updater
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/storage"
+)
+
+func TestConditionalTransport(t *testing.T) {
+	var requests, notModified int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("X-RateLimit-Remaining", fmt.Sprint(5000-requests))
+		switch r.URL.Path {
+		case "/repos/actions/checkout/releases/latest":
+			if r.Header.Get("If-None-Match") == `"v4"` {
+				notModified++
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", `"v4"`)
+		case "/repos/actions/checkout/tags":
+			if r.Header.Get("If-Modified-Since") == "Mon, 01 Jan 2024 00:00:00 GMT" {
+				notModified++
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("Last-Modified", "Mon, 01 Jan 2024 00:00:00 GMT")
+		default:
+			w.Header().Set("ETag", `"other"`)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"path":%q}`, r.URL.Path)
+	}))
+	defer server.Close()
+
+	store := storage.NewMemoryStore()
+	client := &http.Client{Transport: NewConditionalTransport(nil, store)}
+	get := func(path string) (int, string, string) {
+		t.Helper()
+		resp, err := client.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s error = %v", path, err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode, string(body), resp.Header.Get("X-RateLimit-Remaining")
+	}
+
+	for _, path := range []string{"/repos/actions/checkout/releases/latest", "/repos/actions/checkout/tags"} {
+		for i := 0; i < 2; i++ {
+			status, body, remaining := get(path)
+			if status != http.StatusOK || body != fmt.Sprintf(`{"path":%q}`, path) {
+				t.Errorf("GET %s #%d = %d %s", path, i+1, status, body)
+			}
+			// Headers such as the rate limit come from the latest response
+			if remaining != fmt.Sprint(5000-requests) {
+				t.Errorf("GET %s #%d rate limit = %s, want %d", path, i+1, remaining, 5000-requests)
+			}
+		}
+	}
+	if notModified != 2 {
+		t.Errorf("304 responses = %d, want 2", notModified)
+	}
+
+	// Other requests are not revalidated
+	get("/repos/actions/checkout/pulls")
+	get("/repos/actions/checkout/pulls")
+	keys, err := store.List(context.Background(), etagKeyPrefix+"/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 2 || notModified != 2 {
+		t.Errorf("stored %d responses and got %d 304s, want 2 and 2", len(keys), notModified)
+	}
+}
@@ -0,0 +1,218 @@
+package updater
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/metrics"
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/storage"
+)
+
+const (
+	// eventSinkTimeout bounds a single webhook delivery
+	eventSinkTimeout = 30 * time.Second
+	// EventSinkTokenEnv names the variable holding the webhook sink's bearer token
+	EventSinkTokenEnv = "EVENT_SINK_TOKEN" // #nosec G101 - variable name, not a credential
+	// defaultEventQueue is the Redis list events are pushed to
+	defaultEventQueue = "events"
+)
+
+// NewEventSink creates a sink from a spec: "log" writes events to the log,
+// "file:<path>" appends them as JSON lines, an https:// URL receives each
+// event as a JSON POST, and "redis://host[:port][/db][?list=name]" pushes them
+// onto a Redis list for queue consumers.
+func NewEventSink(spec string) (EventSink, error) {
+	switch {
+	case spec == "log":
+		return LogEventSink{}, nil
+	case strings.HasPrefix(spec, "file:"):
+		path := strings.TrimPrefix(spec, "file:")
+		if path == "" {
+			return nil, fmt.Errorf(common.ErrUnknownEventSink, spec)
+		}
+		return NewFileEventSink(path), nil
+	case strings.HasPrefix(spec, "https://"), strings.HasPrefix(spec, "http://"):
+		u, err := url.Parse(spec)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf(common.ErrUnknownEventSink, spec)
+		}
+		if u.Scheme == "http" && !isLoopbackHost(u.Hostname()) {
+			return nil, fmt.Errorf(common.ErrInsecureEventSinkURL, u.Redacted())
+		}
+		return &WebhookEventSink{URL: spec, Token: os.Getenv(EventSinkTokenEnv)}, nil
+	case strings.HasPrefix(spec, "redis://"):
+		u, err := url.Parse(spec)
+		if err != nil {
+			return nil, fmt.Errorf(common.ErrUnknownEventSink, spec)
+		}
+		store, err := storage.NewRedisStore(u)
+		if err != nil {
+			return nil, err
+		}
+		list := u.Query().Get("list")
+		if list == "" {
+			list = defaultEventQueue
+		}
+		return &QueueEventSink{queue: store, list: list, name: "queue-" + shortHash(u.Redacted())}, nil
+	default:
+		return nil, fmt.Errorf(common.ErrUnknownEventSink, spec)
+	}
+}
+
+// shortHash returns a short stable digest used to name sinks
+func shortHash(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:6])
+}
+
+// LogEventSink writes events to the log
+type LogEventSink struct{}
+
+// Name implements EventSink
+func (LogEventSink) Name() string { return "log" }
+
+// Deliver implements EventSink
+func (LogEventSink) Deliver(ctx context.Context, event Event) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Event %s: %s", event.Type, event.Repository)
+	if event.PullRequest != 0 {
+		fmt.Fprintf(&sb, "#%d", event.PullRequest)
+	}
+	if event.Action != "" {
+		fmt.Fprintf(&sb, " %s %s -> %s", event.Action, event.OldVersion, event.NewVersion)
+	}
+	if event.Ticket != "" {
+		fmt.Fprintf(&sb, " ticket %s", event.Ticket)
+	}
+	if event.Detail != "" {
+		fmt.Fprintf(&sb, ": %s", event.Detail)
+	}
+	log.Print(sb.String())
+	return nil
+}
+
+// FileEventSink appends events as JSON lines to a file, such as an audit log
+type FileEventSink struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileEventSink returns a sink appending to path
+func NewFileEventSink(path string) *FileEventSink {
+	return &FileEventSink{path: path}
+}
+
+// Name implements EventSink
+func (s *FileEventSink) Name() string { return "file-" + shortHash(s.path) }
+
+// Deliver implements EventSink
+func (s *FileEventSink) Deliver(ctx context.Context, event Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	file, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600) // #nosec G304 - path is configured by the user
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// WebhookEventSink posts each event as JSON to an HTTP endpoint. The event
+// ID is also sent in the X-Event-ID header for de-duplication.
+type WebhookEventSink struct {
+	URL    string
+	Token  string       // Optional bearer token
+	Client *http.Client // Defaults to http.DefaultClient
+}
+
+// Name implements EventSink
+func (s *WebhookEventSink) Name() string { return "webhook-" + shortHash(s.URL) }
+
+// Deliver implements EventSink
+func (s *WebhookEventSink) Deliver(ctx context.Context, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, eventSinkTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-ID", event.ID)
+	if s.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.Token)
+	}
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf(common.ErrEventSinkStatus, resp.StatusCode)
+	}
+	return nil
+}
+
+// QueueEventSink pushes events as JSON onto a Redis list
+type QueueEventSink struct {
+	queue interface {
+		Push(ctx context.Context, list string, value []byte) error
+	}
+	list string
+	name string
+}
+
+// Name implements EventSink
+func (s *QueueEventSink) Name() string { return s.name }
+
+// Deliver implements EventSink
+func (s *QueueEventSink) Deliver(ctx context.Context, event Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return s.queue.Push(ctx, s.list, data)
+}
+
+// MetricsEventSink counts events by type in a metrics registry
+type MetricsEventSink struct {
+	Registry *metrics.Registry
+}
+
+// Name implements EventSink
+func (s MetricsEventSink) Name() string { return "metrics" }
+
+// Deliver implements EventSink
+func (s MetricsEventSink) Deliver(ctx context.Context, event Event) error {
+	s.Registry.IncEvent(event.Type)
+	return nil
+}
@@ -0,0 +1,211 @@
+package updater
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/storage"
+)
+
+// Event types published on the event bus
+const (
+	EventUpdatePlanned    = "update-planned"
+	EventPROpened         = "pr-opened"
+	EventPRMerged         = "pr-merged"
+	EventError            = "error"
+	EventTicketLinked     = "ticket-linked"
+	EventTicketApproved   = "ticket-approved"
+	EventTicketRejected   = "ticket-rejected"
+	EventAutoMergeEnabled = "auto-merge-enabled"
+)
+
+// Event is something that happened during a run. Every event has a unique
+// ID, so consumers can drop duplicates of a redelivered event.
+type Event struct {
+	ID          string    `json:"id"`
+	Time        time.Time `json:"time"`
+	Type        string    `json:"event"`
+	Repository  string    `json:"repository"`
+	Action      string    `json:"action,omitempty"`
+	OldVersion  string    `json:"old_version,omitempty"`
+	NewVersion  string    `json:"new_version,omitempty"`
+	PullRequest int       `json:"pull_request,omitempty"`
+	Ticket      string    `json:"ticket,omitempty"`
+	TicketURL   string    `json:"ticket_url,omitempty"`
+	Detail      string    `json:"detail,omitempty"`
+}
+
+// EventSink consumes the events of an EventBus
+type EventSink interface {
+	// Name identifies the sink across runs, so spooled events reach the
+	// same sink again
+	Name() string
+	// Deliver hands one event to the sink
+	Deliver(ctx context.Context, event Event) error
+}
+
+// eventSubscription is a sink and the event types it receives
+type eventSubscription struct {
+	sink  EventSink
+	types map[string]bool // All types when empty
+}
+
+// EventBus publishes events to its sinks with at-least-once delivery. A
+// failed delivery is retried with backoff; events a sink still rejects are
+// spooled to the store and handed to it again by Redeliver, typically on the
+// next run. Without a store undeliverable events are logged and dropped. A
+// nil EventBus discards events.
+type EventBus struct {
+	mu     sync.Mutex
+	subs   []eventSubscription
+	store  storage.Store
+	policy common.RetryPolicy
+	now    func() time.Time // For testing
+}
+
+// NewEventBus creates an event bus spooling to store, which may be nil, and
+// retrying deliveries according to policy
+func NewEventBus(store storage.Store, policy common.RetryPolicy) *EventBus {
+	policy.Retryable = isDeliveryRetryable
+	return &EventBus{store: store, policy: policy, now: time.Now}
+}
+
+// isDeliveryRetryable retries every delivery failure unless the run is over
+func isDeliveryRetryable(err error) bool {
+	return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
+
+// Subscribe adds a sink receiving the events of the given types, or every
+// event when no types are given
+func (b *EventBus) Subscribe(sink EventSink, types ...string) {
+	sub := eventSubscription{sink: sink}
+	if len(types) > 0 {
+		sub.types = make(map[string]bool, len(types))
+		for _, eventType := range types {
+			sub.types[eventType] = true
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subs = append(b.subs, sub)
+}
+
+// subscriptions returns a snapshot of the subscriptions
+func (b *EventBus) subscriptions() []eventSubscription {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]eventSubscription(nil), b.subs...)
+}
+
+// Publish delivers event to every sink subscribed to its type, setting its
+// ID and time when unset. Delivery failures never fail the caller.
+func (b *EventBus) Publish(ctx context.Context, event Event) {
+	if b == nil {
+		return
+	}
+	if event.ID == "" {
+		event.ID = newEventID()
+	}
+	if event.Time.IsZero() {
+		event.Time = b.now().UTC()
+	}
+
+	for _, sub := range b.subscriptions() {
+		if sub.types != nil && !sub.types[event.Type] {
+			continue
+		}
+		err := b.policy.Do(ctx, func() error {
+			return sub.sink.Deliver(ctx, event)
+		})
+		if err != nil {
+			log.Printf(common.ErrDeliveringEvent, event.Type, event.ID, sub.sink.Name(), err)
+			b.spool(context.WithoutCancel(ctx), sub.sink, event)
+		}
+	}
+}
+
+// spool stores an undelivered event for a later Redeliver
+func (b *EventBus) spool(ctx context.Context, sink EventSink, event Event) {
+	if b.store == nil {
+		log.Printf(common.ErrSpoolingEvent, event.Type, event.ID, sink.Name(), "no -store configured")
+		return
+	}
+	data, err := json.Marshal(event)
+	if err == nil {
+		err = b.store.Put(ctx, eventSpoolKey(sink.Name(), event.ID), data)
+	}
+	if err != nil {
+		log.Printf(common.ErrSpoolingEvent, event.Type, event.ID, sink.Name(), err)
+	}
+}
+
+// Redeliver hands the spooled events of every subscribed sink to it again,
+// oldest first, and returns how many were delivered. Delivered events leave
+// the spool; a sink that fails again keeps the rest of its events for later.
+func (b *EventBus) Redeliver(ctx context.Context) (int, error) {
+	if b == nil || b.store == nil {
+		return 0, nil
+	}
+
+	delivered := 0
+	for _, sub := range b.subscriptions() {
+		keys, err := b.store.List(ctx, eventSpoolPrefix(sub.sink.Name()))
+		if err != nil {
+			return delivered, err
+		}
+		events := make([]Event, 0, len(keys))
+		for _, key := range keys {
+			data, err := b.store.Get(ctx, key)
+			if err != nil {
+				return delivered, err
+			}
+			var event Event
+			if err := json.Unmarshal(data, &event); err != nil {
+				return delivered, fmt.Errorf(common.ErrDecodingCacheEntry, err)
+			}
+			events = append(events, event)
+		}
+		sort.SliceStable(events, func(i, j int) bool {
+			return events[i].Time.Before(events[j].Time)
+		})
+
+		for _, event := range events {
+			if err := sub.sink.Deliver(ctx, event); err != nil {
+				log.Printf(common.ErrDeliveringEvent, event.Type, event.ID, sub.sink.Name(), err)
+				break
+			}
+			if err := b.store.Delete(ctx, eventSpoolKey(sub.sink.Name(), event.ID)); err != nil {
+				return delivered, err
+			}
+			delivered++
+		}
+	}
+	return delivered, nil
+}
+
+// eventSpoolPrefix returns the store prefix of a sink's undelivered events
+func eventSpoolPrefix(sink string) string {
+	return fmt.Sprintf("%s/events/%s/", stateKeyPrefix, sink)
+}
+
+// eventSpoolKey returns the store key of an undelivered event
+func eventSpoolKey(sink, id string) string {
+	return eventSpoolPrefix(sink) + id + ".json"
+}
+
+// newEventID returns a random event ID
+func newEventID() string {
+	var id [16]byte
+	_, _ = rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
@@ -0,0 +1,199 @@
+package updater
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/metrics"
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/storage"
+)
+
+// flakySink fails its first failures deliveries
+type flakySink struct {
+	name      string
+	failures  int
+	attempts  int
+	delivered []Event
+}
+
+func (s *flakySink) Name() string { return s.name }
+
+func (s *flakySink) Deliver(ctx context.Context, event Event) error {
+	s.attempts++
+	if s.failures > 0 {
+		s.failures--
+		return errors.New("sink unavailable")
+	}
+	s.delivered = append(s.delivered, event)
+	return nil
+}
+
+func TestEventBus(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	bus := NewEventBus(store, common.RetryPolicy{MaxAttempts: 2})
+
+	sink := &flakySink{name: "flaky", failures: 1}
+	errorsOnly := &flakySink{name: "errors"}
+	reg := metrics.NewRegistry()
+	bus.Subscribe(sink)
+	bus.Subscribe(errorsOnly, EventError)
+	bus.Subscribe(MetricsEventSink{Registry: reg})
+
+	// A failed delivery is retried
+	bus.Publish(ctx, Event{Type: EventUpdatePlanned, Repository: "acme/api"})
+	if sink.attempts != 2 || len(sink.delivered) != 1 || sink.delivered[0].ID == "" || sink.delivered[0].Time.IsZero() {
+		t.Fatalf("retried delivery: %d attempts, delivered %+v", sink.attempts, sink.delivered)
+	}
+	if len(errorsOnly.delivered) != 0 || reg.GetEvent(EventUpdatePlanned) != 1 {
+		t.Errorf("type filter or metrics mismatch: %+v, %v", errorsOnly.delivered, reg.GetEvent(EventUpdatePlanned))
+	}
+
+	// Events the sink still rejects are spooled and redelivered in order
+	sink.failures = 4
+	bus.now = func() time.Time { return time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC) }
+	bus.Publish(ctx, Event{Type: EventError, Repository: "acme/api", Detail: "first"})
+	bus.now = func() time.Time { return time.Date(2026, 5, 2, 0, 0, 0, 0, time.UTC) }
+	bus.Publish(ctx, Event{Type: EventPROpened, Repository: "acme/api", PullRequest: 7})
+	if len(sink.delivered) != 1 || len(errorsOnly.delivered) != 1 {
+		t.Fatalf("delivered %d and %d events", len(sink.delivered), len(errorsOnly.delivered))
+	}
+	if keys, _ := store.List(ctx, eventSpoolPrefix("flaky")); len(keys) != 2 {
+		t.Fatalf("spooled %v, want 2 events", keys)
+	}
+
+	delivered, err := bus.Redeliver(ctx)
+	if err != nil || delivered != 2 {
+		t.Fatalf("Redeliver() = %d, %v", delivered, err)
+	}
+	if sink.delivered[1].Detail != "first" || sink.delivered[2].PullRequest != 7 {
+		t.Errorf("redelivered out of order: %+v", sink.delivered)
+	}
+	if keys, _ := store.List(ctx, eventSpoolPrefix("flaky")); len(keys) != 0 {
+		t.Errorf("spool not emptied: %v", keys)
+	}
+
+	var discarded *EventBus
+	discarded.Publish(ctx, Event{Type: EventError})
+	if n, err := discarded.Redeliver(ctx); n != 0 || err != nil {
+		t.Errorf("nil Redeliver() = %d, %v", n, err)
+	}
+}
+
+func TestNewEventSink(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    string
+		wantErr string
+	}{
+		{spec: "log", want: "log"},
+		{spec: "file:/var/log/updater.jsonl", want: "file-"},
+		{spec: "https://hooks.example.com/updater", want: "webhook-"},
+		{spec: "http://127.0.0.1:8080/events", want: "webhook-"},
+		{spec: "redis://localhost:6379/0?list=updater", want: "queue-"},
+		{spec: "http://hooks.example.com/updater", wantErr: "must use https"},
+		{spec: "file:", wantErr: "unknown event sink"},
+		{spec: "slack", wantErr: "unknown event sink"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			sink, err := NewEventSink(tt.spec)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("NewEventSink() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewEventSink() error = %v", err)
+			}
+			if !strings.HasPrefix(sink.Name(), tt.want) {
+				t.Errorf("Name() = %q, want prefix %q", sink.Name(), tt.want)
+			}
+		})
+	}
+}
+
+func TestEventSinks(t *testing.T) {
+	ctx := context.Background()
+	event := Event{ID: "abc", Type: EventPROpened, Repository: "acme/api", PullRequest: 7}
+
+	var gotID, gotAuth string
+	var got Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotID, gotAuth = r.Header.Get("X-Event-ID"), r.Header.Get("Authorization")
+		body, _ := io.ReadAll(r.Body)
+		got = Event{}
+		_ = json.Unmarshal(body, &got)
+		if got.PullRequest == 0 {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer server.Close()
+
+	webhook := &WebhookEventSink{URL: server.URL, Token: "secret"}
+	if err := webhook.Deliver(ctx, event); err != nil {
+		t.Fatalf("webhook Deliver() error = %v", err)
+	}
+	if gotID != "abc" || gotAuth != "Bearer secret" || got.Type != EventPROpened || got.Repository != "acme/api" {
+		t.Errorf("webhook received %+v with id %q and auth %q", got, gotID, gotAuth)
+	}
+	if err := webhook.Deliver(ctx, Event{ID: "def", Type: EventError}); err == nil || !strings.Contains(err.Error(), "502") {
+		t.Errorf("webhook Deliver() error = %v, want status 502", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	file := NewFileEventSink(path)
+	for _, eventType := range []string{EventTicketLinked, EventAutoMergeEnabled} {
+		if err := file.Deliver(ctx, Event{ID: "1", Type: eventType, Repository: "acme/api"}); err != nil {
+			t.Fatalf("file Deliver() error = %v", err)
+		}
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+	var lines []Event
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var line Event
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("invalid line %q: %v", scanner.Text(), err)
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) != 2 || lines[1].Type != EventAutoMergeEnabled {
+		t.Errorf("file events = %+v", lines)
+	}
+
+	queue := &recordingQueue{}
+	sink := &QueueEventSink{queue: queue, list: "events", name: "queue-test"}
+	if err := sink.Deliver(ctx, event); err != nil {
+		t.Fatalf("queue Deliver() error = %v", err)
+	}
+	if len(queue.pushed) != 1 || !strings.Contains(queue.pushed[0], `"event":"pr-opened"`) {
+		t.Errorf("queue received %v", queue.pushed)
+	}
+}
+
+// recordingQueue records the values pushed by a QueueEventSink
+type recordingQueue struct {
+	pushed []string
+}
+
+func (q *recordingQueue) Push(ctx context.Context, list string, value []byte) error {
+	q.pushed = append(q.pushed, list+": "+string(value))
+	return nil
+}
@@ -0,0 +1,72 @@
+package updater
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+	"github.com/google/go-github/v72/github"
+)
+
+// Fork creation runs in the background on GitHub; a new fork is polled at
+// forkPollInterval until its default branch exists
+var (
+	forkPollInterval = 2 * time.Second
+	forkPollAttempts = 15
+)
+
+// SetFork makes pull requests come from a fork of the repository, created
+// under the token's account if needed, for contributors without write access
+// to the repository itself
+func (c *DefaultPRCreator) SetFork(fork bool) {
+	c.fork = fork
+}
+
+// headRepository returns the repository update branches are pushed to: the
+// fork in fork mode and the repository itself otherwise
+func (c *DefaultPRCreator) headRepository() (string, string) {
+	if c.forkOwner != "" {
+		return c.forkOwner, c.forkRepo
+	}
+	return c.owner, c.repo
+}
+
+// ensureFork creates or finds the fork of the repository and waits until it
+// can take branches
+func (c *DefaultPRCreator) ensureFork(ctx context.Context) error {
+	if c.forkOwner != "" {
+		return nil
+	}
+
+	// Forking a repository that already has a fork of the account returns
+	// the existing fork
+	fork, _, err := c.client.Repositories.CreateFork(ctx, c.owner, c.repo, &github.RepositoryCreateForkOptions{DefaultBranchOnly: true})
+	var accepted *github.AcceptedError
+	if err != nil && !errors.As(err, &accepted) {
+		return fmt.Errorf(common.ErrCreatingFork, c.owner, c.repo, err)
+	}
+	if fork == nil || fork.GetOwner().GetLogin() == "" {
+		return fmt.Errorf(common.ErrCreatingFork, c.owner, c.repo, errors.New("no fork returned"))
+	}
+	owner, repo := fork.GetOwner().GetLogin(), fork.GetName()
+
+	branch := fork.GetDefaultBranch()
+	for attempt := 1; ; attempt++ {
+		if _, _, err = c.client.Git.GetRef(ctx, owner, repo, "refs/heads/"+branch); err == nil {
+			break
+		}
+		if attempt == forkPollAttempts {
+			return fmt.Errorf(common.ErrForkNotReady, owner, repo, err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(forkPollInterval):
+		}
+	}
+
+	c.forkOwner, c.forkRepo = owner, repo
+	return nil
+}
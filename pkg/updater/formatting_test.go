@@ -0,0 +1,92 @@
+package updater
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// genWorkflow builds a workflow with randomly formatted steps around the
+// uses lines it updates, and the content expected after updating them
+func genWorkflow(r *rand.Rand, newHash string) (content, want string, updates []*Update) {
+	pick := func(options ...string) string { return options[r.Intn(len(options))] }
+
+	var lines, wantLines []string
+	add := func(line, want string) {
+		lines, wantLines = append(lines, line), append(wantLines, want)
+	}
+	for _, line := range []string{"name:   CI  # the workflow", "on: [push,  pull_request]", "jobs:", "  build:", "    runs-on: ubuntu-latest", "    steps:"} {
+		add(line, line)
+	}
+
+	for i := 0; i < 1+r.Intn(12); i++ {
+		switch r.Intn(7) {
+		case 0, 1, 2: // an updated reference, as a step or a key of one
+			prefix := "      - uses:"
+			if r.Intn(2) == 0 {
+				add(fmt.Sprintf("      - name: 'Step  %d'   # named", i), fmt.Sprintf("      - name: 'Step  %d'   # named", i))
+				prefix = "        uses:"
+			}
+			line := genUsesValue(r, prefix+pick(" ", "  "), newHash)
+			add(line.content, line.want)
+			updates = append(updates, &Update{
+				Action:         ActionReference{Owner: "actions", Name: "checkout"},
+				LineNumber:     len(lines),
+				OldVersion:     "v3",
+				NewVersion:     "v4",
+				NewHash:        newHash,
+				VersionComment: "# v4",
+			})
+		case 3: // the same reference, not updated
+			line := pick(`      - uses: actions/checkout@v3  # v3`, `      - uses: "actions/checkout@v3"`)
+			add(line, line)
+		case 4: // text that looks like a reference
+			line := pick(`      - run: echo "uses: actions/checkout@v3"   # v3`, `      # uses: actions/checkout@v3`)
+			add(line, line)
+		case 5: // another action
+			add("      - uses: octo/tool@v1 #v1", "      - uses: octo/tool@v1 #v1")
+			add("        with: { path: '.',  depth:  0 }", "        with: { path: '.',  depth:  0 }")
+		default:
+			line := pick("", "   ", "      # comment\t")
+			add(line, line)
+		}
+	}
+	return strings.Join(lines, "\n") + "\n", strings.Join(wantLines, "\n") + "\n", updates
+}
+
+// FuzzRewriteFormatting asserts that rewriting a generated workflow changes
+// it byte for byte only in the updated references and their version
+// comments, with either strategy and line ending
+func FuzzRewriteFormatting(f *testing.F) {
+	for seed := int64(0); seed < 50; seed++ {
+		f.Add(seed, seed%3 == 0)
+	}
+	const newHash = "1111111111111111111111111111111111111111"
+	strategies := map[string]RewriteStrategy{"yaml": YAMLRewriteStrategy, "line": LineRewriteStrategy}
+
+	f.Fuzz(func(t *testing.T, seed int64, crlf bool) {
+		content, want, updates := genWorkflow(rand.New(rand.NewSource(seed)), newHash)
+		if crlf {
+			content, want = strings.ReplaceAll(content, "\n", "\r\n"), strings.ReplaceAll(want, "\n", "\r\n")
+		}
+
+		for name, strategy := range strategies {
+			got, changed, err := rewriteFileContent(content, updates, strategy)
+			if err != nil {
+				t.Fatalf("%s: rewriteFileContent() error = %v", name, err)
+			}
+			if !changed && len(updates) > 0 {
+				t.Fatalf("%s: rewriteFileContent() changed nothing", name)
+			}
+			if changed && got != want {
+				t.Fatalf("%s: rewriteFileContent(%q) =\n%q\nwant\n%q", name, content, got, want)
+			}
+
+			// Rewriting the result again changes nothing
+			if _, changed, err := rewriteFileContent(want, updates, strategy); err != nil || changed {
+				t.Fatalf("%s: rewriteFileContent() of the updated content changed = %v, error = %v", name, changed, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,166 @@
+package updater
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+)
+
+// DefaultRemote is the git remote DetectRepository prefers
+const DefaultRemote = "origin"
+
+// ParseRemoteURL returns the host, owner and repository of a git remote URL
+// in the https, ssh or scp-like (git@host:owner/repo.git) form
+func ParseRemoteURL(remote string) (host, owner, repo string, err error) {
+	var path string
+	if u, parseErr := url.Parse(remote); parseErr == nil && u.Scheme != "" && u.Host != "" {
+		host, path = u.Hostname(), u.Path
+	} else if at := strings.Index(remote, "@"); !strings.Contains(remote, "://") && strings.Contains(remote[at+1:], ":") {
+		// scp-like syntax: [user@]host:owner/repo.git
+		host, path, _ = strings.Cut(remote[at+1:], ":")
+	} else {
+		return "", "", "", fmt.Errorf(common.ErrInvalidRemoteURL, remote)
+	}
+
+	parts := strings.Split(strings.Trim(strings.TrimSuffix(strings.TrimSuffix(path, "/"), ".git"), "/"), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", "", fmt.Errorf(common.ErrInvalidRemoteURL, remote)
+	}
+	return strings.ToLower(host), parts[0], parts[1], nil
+}
+
+// DetectRepository returns the owner and name of the repository on host
+// that the clone containing dir pushes to, read from its git configuration
+// without running git. remote selects the remote; "" uses DefaultRemote, or
+// the only remote when there is no DefaultRemote.
+func DetectRepository(dir, remote, host string) (owner, repo string, err error) {
+	remotes, err := gitRemotes(dir)
+	if err != nil {
+		return "", "", err
+	}
+
+	name := remote
+	if name == "" {
+		switch _, ok := remotes[DefaultRemote]; {
+		case ok:
+			name = DefaultRemote
+		case len(remotes) == 1:
+			for only := range remotes {
+				name = only
+			}
+		case len(remotes) == 0:
+			return "", "", fmt.Errorf(common.ErrNoGitRemotes, dir)
+		default:
+			names := make([]string, 0, len(remotes))
+			for other := range remotes {
+				names = append(names, other)
+			}
+			sort.Strings(names)
+			return "", "", fmt.Errorf(common.ErrAmbiguousRemotes, strings.Join(names, ", "))
+		}
+	}
+	remoteURL, ok := remotes[name]
+	if !ok {
+		return "", "", fmt.Errorf(common.ErrNoGitRemote, dir, name)
+	}
+
+	remoteHost, owner, repo, err := ParseRemoteURL(remoteURL)
+	if err != nil {
+		return "", "", err
+	}
+	if host != "" && remoteHost != strings.ToLower(host) {
+		return "", "", fmt.Errorf(common.ErrRemoteOtherHost, name, remoteHost, host)
+	}
+	return owner, repo, nil
+}
+
+// gitRemotes returns the URL of each remote of the clone containing dir
+func gitRemotes(dir string) (map[string]string, error) {
+	configPath, err := gitConfigPath(dir)
+	if err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(configPath) // #nosec G304 - git configuration of the scanned repository
+	if err != nil {
+		return nil, fmt.Errorf(common.ErrReadingGitConfig, configPath, err)
+	}
+
+	remotes := make(map[string]string)
+	section := ""
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			section = ""
+			// Remote names are case-sensitive, the section name is not
+			if kind, name, ok := strings.Cut(strings.Trim(line, "[]"), " "); ok && strings.EqualFold(kind, "remote") {
+				section = strings.Trim(strings.TrimSpace(name), `"`)
+			}
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if section == "" || !ok || !strings.EqualFold(strings.TrimSpace(key), "url") {
+			continue
+		}
+		// The first url of a remote is the one fetched from
+		if _, seen := remotes[section]; !seen {
+			remotes[section] = strings.Trim(strings.TrimSpace(value), `"`)
+		}
+	}
+	return remotes, nil
+}
+
+// gitConfigPath returns the configuration file of the clone containing dir,
+// including linked worktrees, whose .git file points at their git directory
+func gitConfigPath(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for current := abs; ; current = filepath.Dir(current) {
+		gitPath := filepath.Join(current, ".git")
+		info, err := os.Stat(gitPath)
+		if err == nil && info.IsDir() {
+			return filepath.Join(gitPath, "config"), nil
+		}
+		if err == nil {
+			return worktreeConfigPath(current, gitPath)
+		}
+		if filepath.Dir(current) == current {
+			return "", fmt.Errorf(common.ErrNotGitRepository, dir)
+		}
+	}
+}
+
+// worktreeConfigPath returns the configuration file shared by the linked
+// worktree at dir, whose .git file is at gitFile
+func worktreeConfigPath(dir, gitFile string) (string, error) {
+	content, err := os.ReadFile(gitFile) // #nosec G304 - .git file of the scanned repository
+	if err != nil {
+		return "", fmt.Errorf(common.ErrReadingGitConfig, gitFile, err)
+	}
+	gitDir, ok := strings.CutPrefix(strings.TrimSpace(string(content)), "gitdir:")
+	if !ok {
+		return "", fmt.Errorf(common.ErrNotGitRepository, dir)
+	}
+	gitDir = strings.TrimSpace(gitDir)
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(dir, gitDir)
+	}
+	// Worktrees share the configuration of the main git directory
+	if data, err := os.ReadFile(filepath.Join(gitDir, "commondir")); err == nil { // #nosec G304 - inside the git directory
+		commonDir := strings.TrimSpace(string(data))
+		if !filepath.IsAbs(commonDir) {
+			commonDir = filepath.Join(gitDir, commonDir)
+		}
+		gitDir = commonDir
+	}
+	return filepath.Join(gitDir, "config"), nil
+}
@@ -0,0 +1,344 @@
+package updater
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+)
+
+// giteaRelease is the subset of a Gitea release used here
+type giteaRelease struct {
+	TagName     string     `json:"tag_name"`
+	CreatedAt   *time.Time `json:"created_at"`
+	PublishedAt *time.Time `json:"published_at"`
+}
+
+// giteaTag is the subset of a Gitea tag used here
+type giteaTag struct {
+	Name   string `json:"name"`
+	Commit struct {
+		SHA string `json:"sha"`
+	} `json:"commit"`
+}
+
+// GiteaVersionChecker implements VersionChecker against a Gitea or Forgejo instance
+type GiteaVersionChecker struct {
+	client *GiteaClient
+}
+
+// NewGiteaVersionChecker creates a version checker using client
+func NewGiteaVersionChecker(client *GiteaClient) *GiteaVersionChecker {
+	return &GiteaVersionChecker{client: client}
+}
+
+// GetLatestVersion returns the latest release (or, without releases, the
+// highest version tag) and its commit hash
+func (c *GiteaVersionChecker) GetLatestVersion(ctx context.Context, action ActionReference) (string, string, error) {
+	var release giteaRelease
+	err := c.client.do(ctx, http.MethodGet, repoPath(action.Owner, action.Repo(), "releases", "latest"), nil, nil, &release)
+	tagName := release.TagName
+	switch {
+	case err == nil && tagName != "":
+	case err == nil || isGiteaStatus(err, http.StatusNotFound):
+		// No releases; use tags instead
+		if tagName, err = c.latestTag(ctx, action); err != nil {
+			return "", "", err
+		}
+	default:
+		log.Printf(common.ErrReleasesFallback, action.Owner, action.Repo(), err)
+		if tagName, err = c.latestTag(ctx, action); err != nil {
+			return "", "", err
+		}
+	}
+
+	hash, err := c.GetCommitHash(ctx, action, tagName)
+	if err != nil {
+		return "", "", err
+	}
+	return tagName, hash, nil
+}
+
+// latestTag returns the highest version tag of an action's repository
+func (c *GiteaVersionChecker) latestTag(ctx context.Context, action ActionReference) (string, error) {
+	var tags []giteaTag
+	query := url.Values{"limit": []string{"50"}}
+	if err := c.client.do(ctx, http.MethodGet, repoPath(action.Owner, action.Repo(), "tags"), query, nil, &tags); err != nil {
+		return "", fmt.Errorf(common.ErrGettingTags, err)
+	}
+	names := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		names = append(names, tag.Name)
+	}
+	best := pickLatestTag(names)
+	if best == "" {
+		return "", fmt.Errorf(common.ErrNoVersionInfo, action.Owner, action.Repo())
+	}
+	return best, nil
+}
+
+// IsUpdateAvailable checks if a newer version is available
+func (c *GiteaVersionChecker) IsUpdateAvailable(ctx context.Context, action ActionReference) (bool, string, string, error) {
+	latestVersion, latestHash, err := c.GetLatestVersion(ctx, action)
+	if err != nil {
+		return false, "", "", err
+	}
+	return isUpdateAvailable(action, latestVersion, latestHash), latestVersion, latestHash, nil
+}
+
+// GetCommitHash returns the commit a tag points to. Gitea resolves annotated
+// tags to their commit itself.
+func (c *GiteaVersionChecker) GetCommitHash(ctx context.Context, action ActionReference, version string) (string, error) {
+	var tag giteaTag
+	if err := c.client.do(ctx, http.MethodGet, repoPath(action.Owner, action.Repo(), "tags", url.PathEscape(version)), nil, nil, &tag); err != nil {
+		return "", fmt.Errorf(common.ErrGettingRefForTag, version, err)
+	}
+	if tag.Commit.SHA == "" {
+		return "", fmt.Errorf(common.ErrNoCommitHashForTag, version)
+	}
+	return tag.Commit.SHA, nil
+}
+
+// GetReleaseDate returns the publication date of the release for version
+func (c *GiteaVersionChecker) GetReleaseDate(ctx context.Context, action ActionReference, version string) (time.Time, error) {
+	var release giteaRelease
+	if err := c.client.do(ctx, http.MethodGet, repoPath(action.Owner, action.Repo(), "releases", "tags", url.PathEscape(version)), nil, nil, &release); err != nil {
+		return time.Time{}, fmt.Errorf(common.ErrGettingReleaseDate, version, err)
+	}
+	if release.PublishedAt != nil {
+		return *release.PublishedAt, nil
+	}
+	if release.CreatedAt != nil {
+		return *release.CreatedAt, nil
+	}
+	return time.Time{}, fmt.Errorf(common.ErrGettingReleaseDate, version, fmt.Errorf("no date in release"))
+}
+
+// GiteaPRCreator implements PRCreator against a Gitea or Forgejo instance
+type GiteaPRCreator struct {
+	client        *GiteaClient
+	owner         string
+	repo          string
+	workflowsPath string
+	repoRoot      string
+	baseBranch    string          // Branch pull requests target; the default branch when empty
+	draft         bool            // Open pull requests as work in progress
+	branches      BranchTemplate  // Names the branches of pull requests
+	strategy      RewriteStrategy // How files are rewritten; nil uses YAMLRewriteStrategy
+	pulls         []PullRequest   // Pull requests created, in order
+	pending       string          // Branch of a CreatePR call that has not opened its pull request yet
+	redactor      *Redactor       // Removes secrets from the commit message and body
+	skippedFiles  []Failure       // Files the run could not parse, listed in the body
+}
+
+// NewGiteaPRCreator creates a pull request creator for owner/repo
+func NewGiteaPRCreator(client *GiteaClient, owner, repo string) *GiteaPRCreator {
+	return &GiteaPRCreator{
+		client:        client,
+		owner:         owner,
+		repo:          repo,
+		workflowsPath: ".github/workflows",
+	}
+}
+
+// SetWorkflowsPath sets the path to workflow files
+func (c *GiteaPRCreator) SetWorkflowsPath(path string) {
+	c.workflowsPath = path
+}
+
+// SetRepoRoot sets the local repository root used to compute repository-relative paths
+func (c *GiteaPRCreator) SetRepoRoot(path string) {
+	c.repoRoot = path
+}
+
+// SetBaseBranch makes pull requests branch off and target branch instead of
+// the repository's default branch
+func (c *GiteaPRCreator) SetBaseBranch(branch string) {
+	c.baseBranch = branch
+}
+
+// SetDraft opens pull requests as drafts. Gitea marks a pull request as work
+// in progress by its title prefix.
+func (c *GiteaPRCreator) SetDraft(draft bool) {
+	c.draft = draft
+}
+
+// SetBranchTemplate names the branches of pull requests by template
+func (c *GiteaPRCreator) SetBranchTemplate(template BranchTemplate) {
+	c.branches = template
+}
+
+// SetRewriteStrategy sets how workflow files are rewritten, as for the
+// update manager. A nil strategy selects YAMLRewriteStrategy.
+func (c *GiteaPRCreator) SetRewriteStrategy(strategy RewriteStrategy) {
+	c.strategy = strategy
+}
+
+// SetRedactor sets the redactor applied to commit messages and pull request
+// bodies; nil uses DefaultRedactPatterns
+func (c *GiteaPRCreator) SetRedactor(redactor *Redactor) {
+	c.redactor = redactor
+}
+
+// giteaFileChange is one entry of a Gitea change-files request
+type giteaFileChange struct {
+	Operation string `json:"operation"`
+	Path      string `json:"path"`
+	Content   string `json:"content"`
+	SHA       string `json:"sha,omitempty"`
+}
+
+// CreatePR commits the updates to a new branch in a single commit and opens
+// a pull request against the base branch
+func (c *GiteaPRCreator) CreatePR(ctx context.Context, updates []*Update) error {
+	if len(updates) == 0 {
+		return nil
+	}
+	updates, err := mergeUpdates(updates)
+	if err != nil {
+		return err
+	}
+
+	base := c.baseBranch
+	if base == "" {
+		var repository struct {
+			DefaultBranch string `json:"default_branch"`
+		}
+		if err := c.client.do(ctx, http.MethodGet, repoPath(c.owner, c.repo), nil, nil, &repository); err != nil {
+			return fmt.Errorf(common.ErrGettingRepository, err)
+		}
+		base = repository.DefaultBranch
+	}
+	branchName := c.branches.nameAfter(updates, time.Now(), branchesOf(c.pulls)...)
+
+	// Group updates by file
+	fileUpdates := make(map[string][]*Update)
+	var order []string
+	for _, update := range updates {
+		if _, ok := fileUpdates[update.FilePath]; !ok {
+			order = append(order, update.FilePath)
+		}
+		fileUpdates[update.FilePath] = append(fileUpdates[update.FilePath], update)
+	}
+
+	changes := make([]giteaFileChange, 0, len(order))
+	for _, file := range order {
+		relPath := strings.TrimPrefix(relativeRepoPath(file, c.repoRoot, c.workflowsPath), "/")
+
+		var current struct {
+			Content string `json:"content"`
+			SHA     string `json:"sha"`
+		}
+		change := giteaFileChange{Operation: "update", Path: relPath}
+		err := c.client.do(ctx, http.MethodGet, repoPath(c.owner, c.repo, "contents", relPath), url.Values{"ref": []string{base}}, nil, &current)
+		switch {
+		case err == nil:
+			change.SHA = current.SHA
+		case isGiteaStatus(err, http.StatusNotFound):
+			// A file missing on the base branch has no reference to update
+			continue
+		default:
+			return fmt.Errorf(common.ErrGettingFileContents, err)
+		}
+
+		decoded, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(current.Content, "\n", ""))
+		if err != nil {
+			return fmt.Errorf(common.ErrDecodingContent, err)
+		}
+		// Leave files the base branch already has updated out of the commit
+		content, changed, err := rewriteFileContent(string(decoded), fileUpdates[file], rewriteStrategyOr(c.strategy))
+		if err != nil {
+			return fmt.Errorf(common.ErrRewritingFile, relPath, err)
+		}
+		if !changed || content == string(decoded) {
+			continue
+		}
+		change.Content = base64.StdEncoding.EncodeToString([]byte(content))
+		changes = append(changes, change)
+	}
+
+	if len(changes) == 0 {
+		return &NoChangesError{Base: base}
+	}
+
+	commit := map[string]interface{}{
+		"branch":     base,
+		"new_branch": branchName,
+		"message":    c.redactor.Redact(commitMessage(updates)),
+		"files":      changes,
+	}
+	// RecoverPR removes the branch when the pull request is not opened
+	c.pending = branchName
+	if err := c.client.do(ctx, http.MethodPost, repoPath(c.owner, c.repo, "contents"), nil, commit, nil); err != nil {
+		return fmt.Errorf(common.ErrCreatingCommit, err)
+	}
+
+	var pr giteaPullRequest
+	title := "Update GitHub Actions dependencies"
+	if c.draft {
+		title = "WIP: " + title
+	}
+	pull := map[string]string{
+		"title": title,
+		"body":  c.redactor.Redact(prBody(updates, skippedFilesSection(c.skippedFiles, c.repoRoot, c.workflowsPath))),
+		"head":  branchName,
+		"base":  base,
+	}
+	if err := c.client.do(ctx, http.MethodPost, repoPath(c.owner, c.repo, "pulls"), nil, pull, &pr); err != nil {
+		return fmt.Errorf(common.ErrCreatingPR, err)
+	}
+	c.recordPR(ctx, branchName, pr, updates)
+	return nil
+}
+
+// giteaPullRequest is the subset of a Gitea pull request used here
+type giteaPullRequest struct {
+	Number  int64  `json:"number"`
+	HTMLURL string `json:"html_url"`
+	Head    struct {
+		Ref string `json:"ref"`
+		SHA string `json:"sha"`
+	} `json:"head"`
+}
+
+// recordPR records the pull request opened from branch and labels it
+func (c *GiteaPRCreator) recordPR(ctx context.Context, branch string, pr giteaPullRequest, updates []*Update) {
+	c.pending = ""
+	c.pulls = append(c.pulls, PullRequest{Number: int(pr.Number), URL: pr.HTMLURL, HeadSHA: pr.Head.SHA, Branch: branch, Updates: updates})
+
+	// Don't fail if we couldn't add labels
+	if err := c.addLabels(ctx, pr.Number, prLabels(updates)...); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+}
+
+// addLabels adds the existing repository labels among names to a pull request.
+// Gitea refers to labels by ID, so missing labels are skipped.
+func (c *GiteaPRCreator) addLabels(ctx context.Context, number int64, names ...string) error {
+	var labels []struct {
+		ID   int64  `json:"id"`
+		Name string `json:"name"`
+	}
+	if err := c.client.do(ctx, http.MethodGet, repoPath(c.owner, c.repo, "labels"), nil, nil, &labels); err != nil {
+		return err
+	}
+
+	var ids []int64
+	for _, label := range labels {
+		for _, name := range names {
+			if strings.EqualFold(label.Name, name) {
+				ids = append(ids, label.ID)
+			}
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	return c.client.do(ctx, http.MethodPost, repoPath(c.owner, c.repo, "issues", fmt.Sprint(number), "labels"), nil, map[string][]int64{"labels": ids}, nil)
+}
@@ -0,0 +1,138 @@
+package updater
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+)
+
+// GiteaClient is a minimal client for the REST API of Gitea and Forgejo
+type GiteaClient struct {
+	baseURL    *url.URL // API root, ending in /api/v1/
+	token      string
+	httpClient *http.Client
+}
+
+// GiteaError is a non-2xx response from the Gitea API
+type GiteaError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Message    string
+}
+
+// Error implements error
+func (e *GiteaError) Error() string {
+	return fmt.Sprintf(common.ErrGiteaAPI, e.Method, e.URL, e.StatusCode, e.Message)
+}
+
+// NewGiteaClient creates a client for the instance at baseURL (e.g.
+// https://gitea.example.com). An empty token uses anonymous access.
+func NewGiteaClient(baseURL, token string) (*GiteaClient, error) {
+	apiURL, err := parseGiteaURL(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	return newGiteaClient(apiURL, token), nil
+}
+
+// parseGiteaURL validates an instance URL and returns its API root
+func parseGiteaURL(baseURL string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimSpace(baseURL))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf(common.ErrInvalidGiteaURL, baseURL)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	if !strings.HasSuffix(u.Path, "/api/v1") {
+		u.Path += "/api/v1"
+	}
+	u.Path += "/"
+	return u, nil
+}
+
+// newGiteaClient creates a client for a validated API root
+func newGiteaClient(apiURL *url.URL, token string) *GiteaClient {
+	httpClient := &http.Client{Timeout: 30 * time.Second}
+	if common.HTTPTransport != nil {
+		httpClient.Transport = common.HTTPTransport
+	}
+	return &GiteaClient{baseURL: apiURL, token: token, httpClient: httpClient}
+}
+
+// do sends a request to path (relative to the API root) with an optional
+// JSON body and decodes a JSON response into out when it is not nil
+func (c *GiteaClient) do(ctx context.Context, method, path string, query url.Values, in, out interface{}) error {
+	u, err := url.Parse(c.baseURL.String() + path)
+	if err != nil {
+		return err
+	}
+	u.RawQuery = query.Encode()
+
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "token "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		apiErr := &GiteaError{Method: method, URL: u.String(), StatusCode: resp.StatusCode}
+		var payload struct {
+			Message string `json:"message"`
+		}
+		if data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<16)); err == nil {
+			if json.Unmarshal(data, &payload) == nil && payload.Message != "" {
+				apiErr.Message = payload.Message
+			} else {
+				apiErr.Message = strings.TrimSpace(string(data))
+			}
+		}
+		return apiErr
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// repoPath returns the API path of a repository resource
+func repoPath(owner, repo string, elems ...string) string {
+	parts := append([]string{"repos", url.PathEscape(owner), url.PathEscape(repo)}, elems...)
+	return strings.Join(parts, "/")
+}
+
+// isGiteaStatus reports whether err is a Gitea API error with the given status
+func isGiteaStatus(err error, status int) bool {
+	var apiErr *GiteaError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == status
+}
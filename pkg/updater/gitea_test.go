@@ -0,0 +1,238 @@
+package updater
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newGiteaTestClient returns a client for a test server serving mux
+func newGiteaTestClient(t *testing.T, mux *http.ServeMux) *GiteaClient {
+	t.Helper()
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	client, err := NewGiteaClient(server.URL, "gitea-token")
+	if err != nil {
+		t.Fatalf("NewGiteaClient() error = %v", err)
+	}
+	return client
+}
+
+func TestGiteaVersionChecker(t *testing.T) {
+	tests := []struct {
+		name        string
+		releases    bool
+		wantVersion string
+		wantHash    string
+	}{
+		{name: "latest release", releases: true, wantVersion: "v2.1.0", wantHash: "bbb"},
+		{name: "tags without releases", releases: false, wantVersion: "v2.2.0", wantHash: "ccc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/api/v1/repos/org/action/releases/latest", func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Authorization") != "token gitea-token" {
+					t.Errorf("Authorization = %q", r.Header.Get("Authorization"))
+				}
+				if !tt.releases {
+					w.WriteHeader(http.StatusNotFound)
+					fmt.Fprint(w, `{"message":"release does not exist"}`)
+					return
+				}
+				fmt.Fprint(w, `{"tag_name":"v2.1.0"}`)
+			})
+			mux.HandleFunc("/api/v1/repos/org/action/tags", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `[{"name":"v2.2.0-rc.1"},{"name":"v2.2.0"},{"name":"v1.9.0"}]`)
+			})
+			mux.HandleFunc("/api/v1/repos/org/action/tags/", func(w http.ResponseWriter, r *http.Request) {
+				hashes := map[string]string{"v2.1.0": "bbb", "v2.2.0": "ccc"}
+				tag := strings.TrimPrefix(r.URL.Path, "/api/v1/repos/org/action/tags/")
+				fmt.Fprintf(w, `{"name":%q,"commit":{"sha":%q}}`, tag, hashes[tag])
+			})
+
+			checker := NewGiteaVersionChecker(newGiteaTestClient(t, mux))
+			// Subdirectory actions resolve against their repository
+			action := ActionReference{Owner: "org", Name: "action/sub", Version: "v1.9.0"}
+
+			available, version, hash, err := checker.IsUpdateAvailable(context.Background(), action)
+			if err != nil {
+				t.Fatalf("IsUpdateAvailable() error = %v", err)
+			}
+			if !available || version != tt.wantVersion || hash != tt.wantHash {
+				t.Errorf("IsUpdateAvailable() = %v, %s, %s; want true, %s, %s", available, version, hash, tt.wantVersion, tt.wantHash)
+			}
+		})
+	}
+}
+
+func TestGiteaVersionCheckerErrors(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/repos/org/private/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"message":"token does not have at least one of required scope(s): [read:repository]"}`)
+	})
+
+	checker := NewGiteaVersionChecker(newGiteaTestClient(t, mux))
+	_, _, err := checker.GetLatestVersion(context.Background(), ActionReference{Owner: "org", Name: "private"})
+	if err == nil || !strings.Contains(err.Error(), "read:repository") {
+		t.Errorf("GetLatestVersion() error = %v, want the API message", err)
+	}
+}
+
+func TestGiteaPRCreator(t *testing.T) {
+	workflow := "jobs:\n  build:\n    steps:\n      - uses: actions/checkout@v3\n"
+	var commit struct {
+		Branch    string            `json:"branch"`
+		NewBranch string            `json:"new_branch"`
+		Message   string            `json:"message"`
+		Files     []giteaFileChange `json:"files"`
+	}
+	var pull map[string]string
+	var labels map[string][]int64
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/repos/o/r", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"default_branch":"trunk"}`)
+	})
+	mux.HandleFunc("/api/v1/repos/o/r/contents/.github/workflows/ci.yml", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("ref") != "trunk" {
+			t.Errorf("contents ref = %q, want trunk", r.URL.Query().Get("ref"))
+		}
+		fmt.Fprintf(w, `{"content":%q,"sha":"file-sha"}`, base64.StdEncoding.EncodeToString([]byte(workflow)))
+	})
+	mux.HandleFunc("/api/v1/repos/o/r/contents", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&commit)
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{}`)
+	})
+	mux.HandleFunc("/api/v1/repos/o/r/pulls", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&pull)
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"number":7}`)
+	})
+	mux.HandleFunc("/api/v1/repos/o/r/labels", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"id":3,"name":"dependencies"},{"id":4,"name":"bug"}]`)
+	})
+	mux.HandleFunc("/api/v1/repos/o/r/issues/7/labels", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&labels)
+		fmt.Fprint(w, `[]`)
+	})
+
+	creator := NewGiteaPRCreator(newGiteaTestClient(t, mux), "o", "r")
+	creator.SetRepoRoot("/work/repo")
+	update := CreateTestUpdate("actions", "checkout", "v3", "v4", "/work/repo/.github/workflows/ci.yml")
+	update.LineNumber = 4
+	update.NewHash = "1234567890123456789012345678901234567890"
+
+	if err := creator.CreatePR(context.Background(), []*Update{update}); err != nil {
+		t.Fatalf("CreatePR() error = %v", err)
+	}
+
+	if commit.Branch != "trunk" || !strings.HasPrefix(commit.NewBranch, "action-updates-") || len(commit.Files) != 1 {
+		t.Fatalf("unexpected commit request: %+v", commit)
+	}
+	file := commit.Files[0]
+	content, _ := base64.StdEncoding.DecodeString(file.Content)
+	if file.Operation != "update" || file.SHA != "file-sha" || file.Path != ".github/workflows/ci.yml" {
+		t.Errorf("unexpected file change: %+v", file)
+	}
+	if !strings.Contains(string(content), "actions/checkout@1234567890123456789012345678901234567890") {
+		t.Errorf("updated content = %q", content)
+	}
+	if pull["base"] != "trunk" || pull["head"] != commit.NewBranch {
+		t.Errorf("unexpected pull request: %v", pull)
+	}
+	if len(labels["labels"]) != 1 || labels["labels"][0] != 3 {
+		t.Errorf("labels = %v, want [3]", labels)
+	}
+
+	// Drafts are marked by a WIP title prefix
+	creator.SetBaseBranch("trunk")
+	creator.SetDraft(true)
+	if err := creator.CreatePR(context.Background(), []*Update{update}); err != nil {
+		t.Fatalf("CreatePR() error = %v", err)
+	}
+	if pull["base"] != "trunk" || !strings.HasPrefix(pull["title"], "WIP: ") {
+		t.Errorf("unexpected draft pull request: %v", pull)
+	}
+
+	// Files are rewritten with the configured strategy
+	creator.SetRewriteStrategy(RewriteStrategyFunc(func(content string, updates []*Update) (string, error) {
+		return content + "# rewritten\n", nil
+	}))
+	if err := creator.CreatePR(context.Background(), []*Update{update}); err != nil {
+		t.Fatalf("CreatePR() error = %v", err)
+	}
+	if content, _ := base64.StdEncoding.DecodeString(commit.Files[0].Content); string(content) != workflow+"# rewritten\n" {
+		t.Errorf("content with a custom strategy = %q", content)
+	}
+	creator.SetRewriteStrategy(nil)
+
+	// Nothing is committed once the base branch has the update
+	workflow = "jobs:\n  build:\n    steps:\n      - uses: actions/checkout@1234567890123456789012345678901234567890 # v4\n"
+	commit.NewBranch = ""
+	err := creator.CreatePR(context.Background(), []*Update{update})
+	var noChanges *NoChangesError
+	if !errors.As(err, &noChanges) || noChanges.Base != "trunk" || commit.NewBranch != "" {
+		t.Errorf("CreatePR() error = %v, branch %q, want NoChangesError", err, commit.NewBranch)
+	}
+}
+
+func TestNewProvider(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		want    string
+		wantErr bool
+	}{
+		{name: "", want: "github"},
+		{name: "github", want: "github"},
+		{name: "gitea", url: "https://gitea.example.com", want: "gitea"},
+		{name: "Forgejo", url: "https://codeberg.org/", want: "gitea"},
+		{name: "gitea", url: "", wantErr: true},
+		{name: "gitea", url: "ftp://gitea.example.com", wantErr: true},
+		{name: "gitlab", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name+" "+tt.url, func(t *testing.T) {
+			provider, err := NewProvider(tt.name, tt.url)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewProvider() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			_, isGitHub := provider.(GitHubProvider)
+			if isGitHub != (tt.want == "github") {
+				t.Errorf("NewProvider() = %T, want %s", provider, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseGiteaURL(t *testing.T) {
+	for input, want := range map[string]string{
+		"https://gitea.example.com":          "https://gitea.example.com/api/v1/",
+		"https://example.com/git/":           "https://example.com/git/api/v1/",
+		"http://localhost:3000/api/v1":       "http://localhost:3000/api/v1/",
+		"https://gitea.example.com/api/v1/ ": "https://gitea.example.com/api/v1/",
+	} {
+		got, err := parseGiteaURL(input)
+		if err != nil {
+			t.Errorf("parseGiteaURL(%q) error = %v", input, err)
+			continue
+		}
+		if got.String() != want {
+			t.Errorf("parseGiteaURL(%q) = %s, want %s", input, got, want)
+		}
+	}
+}
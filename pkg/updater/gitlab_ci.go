@@ -0,0 +1,164 @@
+package updater
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/versions"
+	"gopkg.in/yaml.v3"
+)
+
+// GitLabCIFile is the GitLab CI configuration file at the repository root
+const GitLabCIFile = ".gitlab-ci.yml"
+
+// includeRef is a project include found in a GitLab CI file
+type includeRef struct {
+	project string
+	ref     *yaml.Node
+}
+
+// ParseGitLabIncludes extracts the project includes of a GitLab CI file:
+//
+//	include:
+//	  - project: my-group/templates
+//	    ref: v1.2.0
+//	    file: /build.yml
+//
+// The project path is split into Owner (everything before the last slash)
+// and Name, and the ref becomes the version. Includes without a ref or with
+// a branch ref are skipped since they cannot be pinned to a release.
+func (s *Scanner) ParseGitLabIncludes(path string) ([]ActionReference, error) {
+	if err := s.validatePath(path); err != nil {
+		return nil, fmt.Errorf(common.ErrInvalidFilePath, err)
+	}
+	content, err := common.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf(common.ErrReadingWorkflowFile, err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, fmt.Errorf(common.ErrParsingWorkflowYAML, err)
+	}
+
+	lines := strings.Split(string(content), "\n")
+	var refs []ActionReference
+	for _, include := range collectIncludeRefs(&doc) {
+		slash := strings.LastIndex(include.project, "/")
+		if slash <= 0 || slash == len(include.project)-1 {
+			continue
+		}
+		ref := ActionReference{
+			Owner:         include.project[:slash],
+			Name:          include.project[slash+1:],
+			Version:       include.ref.Value,
+			Path:          path,
+			Line:          include.ref.Line,
+			GitLabInclude: true,
+		}
+		if ref.Line > 0 && ref.Line <= len(lines) {
+			ref.VersionComment = trailingComment(lines[ref.Line-1])
+		}
+
+		if len(ref.Version) == 40 && common.IsHexString(ref.Version) {
+			ref.CommitHash = ref.Version
+			if v, ok := ParseVersionComment(ref.VersionComment); ok {
+				ref.Version = v
+			}
+		} else if !versions.IsVersion(ref.Version) {
+			continue
+		}
+		ref.OriginalVersion = ref.Version
+		refs = append(refs, ref)
+	}
+	return refs, nil
+}
+
+// collectIncludeRefs returns the project includes of a GitLab CI document
+// that name a ref. The include keyword takes a single mapping or a list.
+func collectIncludeRefs(doc *yaml.Node) []includeRef {
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil
+	}
+	root := doc.Content[0]
+
+	var includes []includeRef
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value != "include" {
+			continue
+		}
+		entries := []*yaml.Node{root.Content[i+1]}
+		if root.Content[i+1].Kind == yaml.SequenceNode {
+			entries = root.Content[i+1].Content
+		}
+		for _, entry := range entries {
+			if entry.Kind != yaml.MappingNode {
+				continue
+			}
+			var include includeRef
+			for j := 0; j+1 < len(entry.Content); j += 2 {
+				key, value := entry.Content[j], entry.Content[j+1]
+				if value.Kind != yaml.ScalarNode {
+					continue
+				}
+				switch key.Value {
+				case "project":
+					include.project = value.Value
+				case "ref":
+					include.ref = value
+				}
+			}
+			if include.project != "" && include.ref != nil {
+				includes = append(includes, include)
+			}
+		}
+	}
+	return includes
+}
+
+// rewriteIncludeRefs replaces the ref values of GitLab include updates with
+// their new commit hash. Other updates are returned unchanged.
+func rewriteIncludeRefs(content string, updates []*Update) (string, []*Update) {
+	var includeUpdates, remaining []*Update
+	for _, update := range updates {
+		if update.Action.GitLabInclude {
+			includeUpdates = append(includeUpdates, update)
+		} else {
+			remaining = append(remaining, update)
+		}
+	}
+	if len(includeUpdates) == 0 {
+		return content, updates
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(content), &doc); err != nil {
+		log.Printf(common.ErrIncludeRefNotFound, len(includeUpdates), err)
+		return content, remaining
+	}
+	byLine := make(map[int]*yaml.Node)
+	for _, include := range collectIncludeRefs(&doc) {
+		byLine[include.ref.Line] = include.ref
+	}
+
+	lines := strings.Split(content, "\n")
+	var edits []scalarEdit
+	for _, update := range includeUpdates {
+		node := byLine[update.LineNumber]
+		if node == nil {
+			log.Printf(common.ErrIncludeRefNotFound, 1, fmt.Errorf("no include ref on line %d", update.LineNumber))
+			continue
+		}
+		edit, ok := locateScalar(lines, &usesScalar{node: node, line: node.Line, col: node.Column}, update)
+		if !ok {
+			log.Printf(common.ErrIncludeRefNotFound, 1, fmt.Errorf("unsupported ref on line %d", update.LineNumber))
+			continue
+		}
+		edit.value = update.NewHash
+		edits = append(edits, edit)
+	}
+	applyScalarEdits(lines, edits)
+	return strings.Join(lines, "\n"), remaining
+}
@@ -0,0 +1,93 @@
+package updater
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testGitLabCI = `include:
+  - local: /ci/lint.yml
+  - project: my-group/sub/templates
+    ref: v1.2.0
+    file: /build.yml
+  - project: 'my-group/deploy'
+    ref: "0123456789abcdef0123456789abcdef01234567" # v2.0.0
+    file:
+      - /deploy.yml
+  - project: my-group/tracking
+    ref: main
+    file: /track.yml
+  - {project: my-group/flow, ref: v3, file: /flow.yml}
+stages: [build]
+`
+
+func TestParseGitLabIncludes(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, GitLabCIFile)
+	if err := os.WriteFile(path, []byte(testGitLabCI), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	refs, err := NewScanner(dir).ParseGitLabIncludes(path)
+	if err != nil {
+		t.Fatalf("ParseGitLabIncludes() error = %v", err)
+	}
+
+	want := []ActionReference{
+		{Owner: "my-group/sub", Name: "templates", Version: "v1.2.0", Line: 4},
+		{Owner: "my-group", Name: "deploy", Version: "v2.0.0", CommitHash: "0123456789abcdef0123456789abcdef01234567", Line: 7, VersionComment: "# v2.0.0"},
+		{Owner: "my-group", Name: "flow", Version: "v3", Line: 13},
+	}
+	if len(refs) != len(want) {
+		t.Fatalf("ParseGitLabIncludes() returned %d refs, want %d: %+v", len(refs), len(want), refs)
+	}
+	for i, w := range want {
+		got := refs[i]
+		if got.Owner != w.Owner || got.Name != w.Name || got.Version != w.Version || got.CommitHash != w.CommitHash ||
+			got.Line != w.Line || got.VersionComment != w.VersionComment || !got.GitLabInclude {
+			t.Errorf("ref %d = %+v, want %+v", i, got, w)
+		}
+	}
+}
+
+func TestApplyGitLabIncludeUpdates(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, GitLabCIFile)
+	if err := os.WriteFile(path, []byte(testGitLabCI), 0644); err != nil {
+		t.Fatal(err)
+	}
+	refs, err := NewScanner(dir).ParseGitLabIncludes(path)
+	if err != nil {
+		t.Fatalf("ParseGitLabIncludes() error = %v", err)
+	}
+
+	manager := NewUpdateManager(dir)
+	var updates []*Update
+	for i, ref := range refs {
+		hash := strings.Repeat(string(rune('a'+i)), 40)
+		update, err := manager.CreateUpdate(context.Background(), path, ref, "v9.0.0", hash)
+		if err != nil {
+			t.Fatalf("CreateUpdate() error = %v", err)
+		}
+		updates = append(updates, update)
+	}
+	if err := manager.ApplyUpdates(context.Background(), updates); err != nil {
+		t.Fatalf("ApplyUpdates() error = %v", err)
+	}
+
+	content, _ := os.ReadFile(path)
+	for _, want := range []string{
+		"    ref: " + strings.Repeat("a", 40) + "  # v9.0.0\n",
+		`    ref: "` + strings.Repeat("b", 40) + `" # v9.0.0` + "\n",
+		"    ref: main\n",
+		"  - {project: my-group/flow, ref: " + strings.Repeat("c", 40) + ", file: /flow.yml}\n",
+		"  - local: /ci/lint.yml\n",
+	} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("updated file missing %q:\n%s", want, content)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package updater
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/versions"
+)
+
+// graphQLBatchSize is the number of repositories resolved per GraphQL query
+const graphQLBatchSize = 50
+
+// graphQLRepositoryFields selects the latest release and the most recent
+// tags of a repository, with the commit each tag points to
+const graphQLRepositoryFields = `latestRelease { tagName }
+    refs(refPrefix: "refs/tags/", first: 100, orderBy: {field: TAG_COMMIT_DATE, direction: DESC}) {
+      nodes { name target { oid ... on Tag { target { oid } } } }
+    }`
+
+// BatchPrefetcher is implemented by version checkers that can resolve many
+// actions at once before they are looked up one by one
+type BatchPrefetcher interface {
+	Prefetch(ctx context.Context, actions []ActionReference) error
+}
+
+// GraphQLVersionChecker resolves latest releases and tags of many actions in
+// a few batched GraphQL queries. Lookups of actions that were not prefetched,
+// or whose tag is not among the prefetched ones, use the REST API.
+type GraphQLVersionChecker struct {
+	*DefaultVersionChecker
+
+	batchSize int
+	mu        sync.Mutex
+	repos     map[string]*graphQLRepository // Prefetched repositories, by lowercase owner/repo
+}
+
+// graphQLRepository is what a batched query returns for one repository
+type graphQLRepository struct {
+	latestRelease string
+	tags          []string          // Tag names, most recent first
+	commits       map[string]string // Commit of each tag, by tag name
+}
+
+// NewGraphQLVersionChecker creates a GraphQL checker falling back to rest
+func NewGraphQLVersionChecker(rest *DefaultVersionChecker) *GraphQLVersionChecker {
+	return &GraphQLVersionChecker{
+		DefaultVersionChecker: rest,
+		batchSize:             graphQLBatchSize,
+		repos:                 make(map[string]*graphQLRepository),
+	}
+}
+
+// Prefetch implements BatchPrefetcher. Actions on other hosts or with scoped
+// tokens are left to the REST API.
+func (c *GraphQLVersionChecker) Prefetch(ctx context.Context, actions []ActionReference) error {
+	var pending []ActionReference
+	seen := make(map[string]bool)
+	for _, action := range actions {
+		key := graphQLKey(action)
+		if seen[key] || action.Host != "" || c.hasScopedToken(action) {
+			continue
+		}
+		seen[key] = true
+		c.mu.Lock()
+		_, done := c.repos[key]
+		c.mu.Unlock()
+		if !done {
+			pending = append(pending, action)
+		}
+	}
+
+	queries := 0
+	for start := 0; start < len(pending); start += c.batchSize {
+		batch := pending[start:min(start+c.batchSize, len(pending))]
+		if err := c.queryBatch(ctx, batch); err != nil {
+			return err
+		}
+		queries++
+	}
+	if queries > 0 {
+		log.Printf("Resolved %d actions in %d GraphQL queries", len(pending), queries)
+	}
+	return nil
+}
+
+// queryBatch resolves a batch of repositories in one query. Repositories the
+// query cannot see are not recorded, so the REST API reports why.
+func (c *GraphQLVersionChecker) queryBatch(ctx context.Context, batch []ActionReference) error {
+	var query strings.Builder
+	var params []string
+	variables := make(map[string]any, 2*len(batch))
+	for i, action := range batch {
+		params = append(params, fmt.Sprintf("$o%d: String!, $n%d: String!", i, i))
+		fmt.Fprintf(&query, "  r%d: repository(owner: $o%d, name: $n%d) {\n    %s\n  }\n", i, i, i, graphQLRepositoryFields)
+		variables[fmt.Sprintf("o%d", i)] = action.Owner
+		variables[fmt.Sprintf("n%d", i)] = action.Repo()
+	}
+
+	body := map[string]any{
+		"query":     "query(" + strings.Join(params, ", ") + ") {\n" + query.String() + "}",
+		"variables": variables,
+	}
+	req, err := c.client.NewRequest(http.MethodPost, graphQLPath(c.client), body)
+	if err != nil {
+		return fmt.Errorf(common.ErrGraphQLQuery, err)
+	}
+	var resp struct {
+		Data   map[string]*graphQLRepositoryResult `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if _, err := c.client.Do(ctx, req, &resp); err != nil {
+		return fmt.Errorf(common.ErrGraphQLQuery, err)
+	}
+	if resp.Data == nil && len(resp.Errors) > 0 {
+		return fmt.Errorf(common.ErrGraphQLQuery, fmt.Errorf("%s", resp.Errors[0].Message))
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, action := range batch {
+		if result := resp.Data[fmt.Sprintf("r%d", i)]; result != nil {
+			c.repos[graphQLKey(action)] = result.repository()
+		}
+	}
+	return nil
+}
+
+// graphQLRepositoryResult is the JSON form of graphQLRepositoryFields
+type graphQLRepositoryResult struct {
+	LatestRelease *struct {
+		TagName string `json:"tagName"`
+	} `json:"latestRelease"`
+	Refs struct {
+		Nodes []struct {
+			Name   string `json:"name"`
+			Target struct {
+				OID    string `json:"oid"`
+				Target *struct {
+					OID string `json:"oid"`
+				} `json:"target"` // Commit of an annotated tag
+			} `json:"target"`
+		} `json:"nodes"`
+	} `json:"refs"`
+}
+
+func (r *graphQLRepositoryResult) repository() *graphQLRepository {
+	repo := &graphQLRepository{commits: make(map[string]string)}
+	if r.LatestRelease != nil {
+		repo.latestRelease = r.LatestRelease.TagName
+	}
+	for _, node := range r.Refs.Nodes {
+		commit := node.Target.OID
+		if node.Target.Target != nil {
+			commit = node.Target.Target.OID
+		}
+		repo.tags = append(repo.tags, node.Name)
+		repo.commits[node.Name] = commit
+	}
+	return repo
+}
+
+// GetLatestVersion implements VersionChecker from the prefetched releases
+// and tags when possible
+func (c *GraphQLVersionChecker) GetLatestVersion(ctx context.Context, action ActionReference) (string, string, error) {
+	if repo := c.prefetched(action); repo != nil {
+		c.DefaultVersionChecker.mu.Lock()
+		allowPrerelease := c.allowPrerelease
+		c.DefaultVersionChecker.mu.Unlock()
+
+		tagName := repo.latestRelease
+		if tag := pickTag(repo.tags, allowPrerelease); tagName == "" || (allowPrerelease && versions.IsNewer(tag, tagName)) {
+			tagName = tag
+		}
+		if hash, ok := repo.commits[tagName]; ok {
+			return tagName, hash, nil
+		}
+	}
+	return c.DefaultVersionChecker.GetLatestVersion(ctx, action)
+}
+
+// IsUpdateAvailable implements VersionChecker
+func (c *GraphQLVersionChecker) IsUpdateAvailable(ctx context.Context, action ActionReference) (bool, string, string, error) {
+	latestVersion, latestHash, err := c.GetLatestVersion(ctx, action)
+	if err != nil {
+		return false, "", "", err
+	}
+	return isUpdateAvailable(action, latestVersion, latestHash), latestVersion, latestHash, nil
+}
+
+// GetCommitHash implements VersionChecker from the prefetched tags when
+// possible
+func (c *GraphQLVersionChecker) GetCommitHash(ctx context.Context, action ActionReference, version string) (string, error) {
+	if repo := c.prefetched(action); repo != nil {
+		if hash, ok := repo.commits[version]; ok {
+			return hash, nil
+		}
+	}
+	return c.DefaultVersionChecker.GetCommitHash(ctx, action, version)
+}
+
+// prefetched returns the prefetched repository of action, or nil
+func (c *GraphQLVersionChecker) prefetched(action ActionReference) *graphQLRepository {
+	if action.Host != "" {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.repos[graphQLKey(action)]
+}
+
+// hasScopedToken reports whether action is resolved with its own token
+func (c *GraphQLVersionChecker) hasScopedToken(action ActionReference) bool {
+	c.DefaultVersionChecker.mu.Lock()
+	defer c.DefaultVersionChecker.mu.Unlock()
+	_, ok := c.actionTokens.tokenFor(action)
+	return ok
+}
+
+// graphQLKey identifies the repository of action
+func graphQLKey(action ActionReference) string {
+	return strings.ToLower(action.Owner + "/" + action.Repo())
+}
@@ -0,0 +1,176 @@
+package updater
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/storage"
+	"github.com/google/go-github/v72/github"
+)
+
+// graphQLRepositories are the repositories served by newGraphQLServer, by
+// owner/repo; missing ones resolve to null
+var graphQLRepositories = map[string]string{
+	"actions/checkout": `{"latestRelease":{"tagName":"v4.1.0"},"refs":{"nodes":[
+		{"name":"v4.1.0","target":{"oid":"aaaa"}},
+		{"name":"v4","target":{"oid":"tag4","target":{"oid":"bbbb"}}}]}}`,
+	"octo/tool": `{"latestRelease":null,"refs":{"nodes":[
+		{"name":"v1.3.0-rc.1","target":{"oid":"dddd"}},
+		{"name":"v1.2.0","target":{"oid":"cccc"}}]}}`,
+}
+
+func newGraphQLServer(t *testing.T) (*GraphQLVersionChecker, *int, *[]string) {
+	t.Helper()
+	queries, restCalls := 0, []string{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, r *http.Request) {
+		queries++
+		var body struct {
+			Variables map[string]string `json:"variables"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error(err)
+			return
+		}
+		var data, errs []string
+		for i := 0; body.Variables[fmt.Sprintf("o%d", i)] != ""; i++ {
+			name := body.Variables[fmt.Sprintf("o%d", i)] + "/" + body.Variables[fmt.Sprintf("n%d", i)]
+			repo, ok := graphQLRepositories[name]
+			if !ok {
+				repo = "null"
+				errs = append(errs, fmt.Sprintf(`{"message":"Could not resolve to a Repository with the name '%s'."}`, name))
+			}
+			data = append(data, fmt.Sprintf(`"r%d":%s`, i, repo))
+		}
+		fmt.Fprintf(w, `{"data":{%s},"errors":[%s]}`, strings.Join(data, ","), strings.Join(errs, ","))
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		restCalls = append(restCalls, r.URL.Path)
+		http.NotFound(w, r)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	return NewGraphQLVersionChecker(&DefaultVersionChecker{client: client}), &queries, &restCalls
+}
+
+func TestGraphQLVersionChecker(t *testing.T) {
+	checker, queries, restCalls := newGraphQLServer(t)
+	checker.batchSize = 2
+	ctx := context.Background()
+	checkout := ActionReference{Owner: "actions", Name: "checkout", Version: "v3"}
+	tool := ActionReference{Owner: "octo", Name: "tool/sub", Version: "v1.0.0"}
+	missing := ActionReference{Owner: "octo", Name: "gone", Version: "v1"}
+
+	err := checker.Prefetch(ctx, []ActionReference{
+		checkout, tool, checkout, missing,
+		{Owner: "o", Name: "r", Host: "ghe.example.com"},
+	})
+	if err != nil {
+		t.Fatalf("Prefetch() error = %v", err)
+	}
+	// Three repositories in batches of two; the host-qualified action is
+	// left to the REST API
+	if *queries != 2 {
+		t.Errorf("GraphQL queries = %d, want 2", *queries)
+	}
+	if err := checker.Prefetch(ctx, []ActionReference{checkout, tool}); err != nil || *queries != 2 {
+		t.Errorf("Prefetch() of prefetched actions: error = %v, queries = %d", err, *queries)
+	}
+
+	tests := []struct {
+		name            string
+		action          ActionReference
+		allowPrerelease bool
+		wantVersion     string
+		wantHash        string
+	}{
+		{name: "latest release", action: checkout, wantVersion: "v4.1.0", wantHash: "aaaa"},
+		{name: "no release", action: tool, wantVersion: "v1.2.0", wantHash: "cccc"},
+		{name: "prerelease allowed", action: tool, allowPrerelease: true, wantVersion: "v1.3.0-rc.1", wantHash: "dddd"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker.SetAllowPrerelease(tt.allowPrerelease)
+			available, version, hash, err := checker.IsUpdateAvailable(ctx, tt.action)
+			if err != nil {
+				t.Fatalf("IsUpdateAvailable() error = %v", err)
+			}
+			if !available || version != tt.wantVersion || hash != tt.wantHash {
+				t.Errorf("IsUpdateAvailable() = %v, %s, %s; want true, %s, %s", available, version, hash, tt.wantVersion, tt.wantHash)
+			}
+		})
+	}
+	checker.SetAllowPrerelease(false)
+
+	// Annotated tags resolve to the commit they point to
+	if hash, err := checker.GetCommitHash(ctx, checkout, "v4"); err != nil || hash != "bbbb" {
+		t.Errorf("GetCommitHash(v4) = %s, %v; want bbbb", hash, err)
+	}
+	if len(*restCalls) != 0 {
+		t.Errorf("REST calls = %v, want none", *restCalls)
+	}
+
+	// Tags and repositories the queries did not return use the REST API
+	if _, err := checker.GetCommitHash(ctx, checkout, "v2"); err == nil {
+		t.Error("GetCommitHash(v2) error = nil, want the REST error")
+	}
+	if _, _, err := checker.GetLatestVersion(ctx, missing); err == nil {
+		t.Error("GetLatestVersion() of a missing repository error = nil")
+	}
+	if len(*restCalls) == 0 {
+		t.Error("expected REST calls for lookups that were not prefetched")
+	}
+}
+
+func TestGraphQLVersionCheckerQueryError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"errors":[{"message":"Bad credentials"}]}`)
+	}))
+	defer server.Close()
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	checker := NewGraphQLVersionChecker(&DefaultVersionChecker{client: client})
+
+	err := checker.Prefetch(context.Background(), []ActionReference{{Owner: "actions", Name: "checkout"}})
+	if err == nil || !strings.Contains(err.Error(), "Bad credentials") {
+		t.Errorf("Prefetch() error = %v, want Bad credentials", err)
+	}
+}
+
+// prefetchingChecker records the actions it is asked to prefetch
+type prefetchingChecker struct {
+	countingChecker
+	prefetched []string
+}
+
+func (c *prefetchingChecker) Prefetch(_ context.Context, actions []ActionReference) error {
+	for _, action := range actions {
+		c.prefetched = append(c.prefetched, action.FullName()+"@"+action.Version)
+	}
+	return nil
+}
+
+func TestRunPrefetches(t *testing.T) {
+	dir, _ := writeRunRepo(t)
+	checker := &prefetchingChecker{}
+	// Prefetching reaches the checker through the retrying and caching wrappers
+	if _, err := Run(context.Background(), Options{RepoPath: dir, Mode: ModeDryRun, Checker: NewCachingVersionChecker(NewRetryingVersionChecker(checker, common.RetryPolicy{MaxAttempts: 1}), storage.NewMemoryStore(), time.Hour)}); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	sort.Strings(checker.prefetched)
+	if fmt.Sprint(checker.prefetched) != "[actions/checkout@v3 octo/tool@v1]" {
+		t.Errorf("prefetched %v", checker.prefetched)
+	}
+}
@@ -0,0 +1,77 @@
+package updater
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+	"github.com/google/go-github/v72/github"
+)
+
+// ActionHost is the API endpoint of a GitHub Enterprise Server (or other
+// GitHub compatible) host serving actions referenced as host/owner/repo@ref
+type ActionHost struct {
+	APIURL string // e.g. https://ghe.example.com/api/v3/
+	Token  string // Empty for anonymous access
+}
+
+// ActionHosts maps a lowercase host name to its API endpoint
+type ActionHosts map[string]ActionHost
+
+// ParseActionHosts parses a comma separated list of host[=ENV_VAR] entries.
+// Each host is reached at https://<host>/api/v3/, with the token read from
+// the named environment variable or anonymously when none is given.
+func ParseActionHosts(spec string) (ActionHosts, error) {
+	hosts := make(ActionHosts)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		host, envVar, hasEnv := strings.Cut(entry, "=")
+		host, envVar = strings.ToLower(strings.TrimSpace(host)), strings.TrimSpace(envVar)
+		if host == "" || strings.ContainsAny(host, "/@ ") || (hasEnv && envVar == "") {
+			return nil, fmt.Errorf(common.ErrInvalidActionHost, entry)
+		}
+		h := ActionHost{APIURL: "https://" + host + "/api/v3/"}
+		if hasEnv {
+			h.Token = os.Getenv(envVar)
+			if h.Token == "" {
+				return nil, fmt.Errorf(common.ErrActionTokenEnvEmpty, envVar, host)
+			}
+		}
+		hosts[host] = h
+	}
+	return hosts, nil
+}
+
+// SetActionHosts configures the API endpoints of other hosts. References to
+// hosts without an entry are resolved anonymously at https://<host>/api/v3/;
+// the default token is never sent to another host.
+func (c *DefaultVersionChecker) SetActionHosts(hosts ActionHosts) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.actionHosts = hosts
+	c.hostClients = nil
+}
+
+// hostClientLocked returns the client for another host. c.mu must be held.
+func (c *DefaultVersionChecker) hostClientLocked(host string) *github.Client {
+	host = strings.ToLower(host)
+	if client, ok := c.hostClients[host]; ok {
+		return client
+	}
+	h, ok := c.actionHosts[host]
+	if !ok {
+		h = ActionHost{APIURL: "https://" + host + "/api/v3/"}
+	}
+
+	client := c.clientOptions.newClientAt(h.APIURL, h.Token)
+
+	if c.hostClients == nil {
+		c.hostClients = make(map[string]*github.Client)
+	}
+	c.hostClients[host] = client
+	return client
+}
@@ -0,0 +1,110 @@
+package updater
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseActionReferenceHost(t *testing.T) {
+	tests := []struct {
+		ref       string
+		wantHost  string
+		wantOwner string
+		wantName  string
+	}{
+		{ref: "actions/checkout@v4", wantOwner: "actions", wantName: "checkout"},
+		{ref: "github/codeql-action/init@v3", wantOwner: "github", wantName: "codeql-action/init"},
+		{ref: "GHE.example.com/tools/lint@v1", wantHost: "ghe.example.com", wantOwner: "tools", wantName: "lint"},
+		{ref: "ghe.example.com:8443/tools/lint/sub@v1", wantHost: "ghe.example.com:8443", wantOwner: "tools", wantName: "lint/sub"},
+		{ref: "example.com/tools@v1", wantOwner: "example.com", wantName: "tools"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			ref, err := parseActionReference(tt.ref, "ci.yml", nil)
+			if err != nil {
+				t.Fatalf("parseActionReference() error = %v", err)
+			}
+			if ref.Host != tt.wantHost || ref.Owner != tt.wantOwner || ref.Name != tt.wantName {
+				t.Errorf("parseActionReference() = host %q owner %q name %q", ref.Host, ref.Owner, ref.Name)
+			}
+		})
+	}
+}
+
+func TestParseActionHosts(t *testing.T) {
+	t.Setenv("GHE_TOKEN", "ghe")
+
+	tests := []struct {
+		name    string
+		spec    string
+		want    ActionHosts
+		wantErr bool
+	}{
+		{
+			name: "token and anonymous",
+			spec: "GHE.example.com=GHE_TOKEN, public.example.com",
+			want: ActionHosts{
+				"ghe.example.com":    {APIURL: "https://ghe.example.com/api/v3/", Token: "ghe"},
+				"public.example.com": {APIURL: "https://public.example.com/api/v3/"},
+			},
+		},
+		{name: "missing variable name", spec: "ghe.example.com=", wantErr: true},
+		{name: "path in host", spec: "ghe.example.com/api=GHE_TOKEN", wantErr: true},
+		{name: "unset variable", spec: "ghe.example.com=UNSET_HOST_TOKEN", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseActionHosts(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseActionHosts() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("ParseActionHosts() = %v, want %v", got, tt.want)
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("ParseActionHosts()[%s] = %+v, want %+v", k, got[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestActionHostClients(t *testing.T) {
+	var github, ghe int
+	githubServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		github++
+		fmt.Fprint(w, `{"ref":"refs/tags/v1","object":{"type":"commit","sha":"github-sha"}}`)
+	}))
+	defer githubServer.Close()
+	gheServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v3/repos/tools/lint/git/ref/tags/v1" || r.Header.Get("Authorization") != "Bearer ghe-token" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		ghe++
+		fmt.Fprint(w, `{"ref":"refs/tags/v1","object":{"type":"commit","sha":"ghe-sha"}}`)
+	}))
+	defer gheServer.Close()
+
+	checker := newAccessTestChecker(githubServer)
+	checker.SetActionHosts(ActionHosts{"ghe.example.com": {APIURL: gheServer.URL + "/api/v3/", Token: "ghe-token"}})
+
+	hash, err := checker.GetCommitHash(context.Background(), ActionReference{Host: "ghe.example.com", Owner: "tools", Name: "lint"}, "v1")
+	if err != nil || hash != "ghe-sha" {
+		t.Fatalf("GetCommitHash(host action) = %q, %v", hash, err)
+	}
+	hash, err = checker.GetCommitHash(context.Background(), ActionReference{Owner: "tools", Name: "lint"}, "v1")
+	if err != nil || hash != "github-sha" {
+		t.Fatalf("GetCommitHash(github.com action) = %q, %v", hash, err)
+	}
+	if github != 1 || ghe != 1 {
+		t.Errorf("requests: github.com %d, ghe %d; want 1 each", github, ghe)
+	}
+}
@@ -1,6 +1,12 @@
 package updater
 
-import "context"
+import (
+	"context"
+	"strings"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/versions"
+)
 
 // ActionReference represents a GitHub Action reference in a workflow file
 type ActionReference struct {
@@ -13,6 +19,70 @@ type ActionReference struct {
 	Comments        []string
 	VersionComment  string // Comment indicating version (e.g., "# v3")
 	OriginalVersion string // For tracking version history
+	LocalPath       string // Repository-relative path for local actions (e.g., "./.github/actions/foo")
+	GitLabInclude   bool   // GitLab CI include (project/ref) rather than a uses reference
+	Host            string // Host of actions referenced as host/owner/repo@ref (empty for github.com)
+	TemplateSource  bool   // Resolved from a matrix or env value; Line is the line of that value
+	TemplatePrefix  string // Text before the version in that value (e.g. "actions/checkout@")
+}
+
+// FullName returns the action name as written in workflows, e.g.
+// "actions/checkout" or "ghe.example.com/owner/repo"
+func (a ActionReference) FullName() string {
+	if a.Host != "" {
+		return a.Host + "/" + a.Owner + "/" + a.Name
+	}
+	return a.Owner + "/" + a.Name
+}
+
+// Repo returns the repository hosting the action, without the subdirectory
+// of actions such as github/codeql-action/init
+func (a ActionReference) Repo() string {
+	repo, _, _ := strings.Cut(a.Name, "/")
+	return repo
+}
+
+// Repository returns the repository hosting the action as written in
+// workflows, e.g. "github/codeql-action" for github/codeql-action/init
+func (a ActionReference) Repository() string {
+	if a.Host != "" {
+		return a.Host + "/" + a.Owner + "/" + a.Repo()
+	}
+	return a.Owner + "/" + a.Repo()
+}
+
+// Subpath returns the subdirectory of the action within its repository,
+// e.g. "init" for github/codeql-action/init, or ""
+func (a ActionReference) Subpath() string {
+	_, subpath, _ := strings.Cut(a.Name, "/")
+	return subpath
+}
+
+// IsLocal reports whether the reference points to an action in the same repository
+func (a ActionReference) IsLocal() bool {
+	return a.LocalPath != ""
+}
+
+// Reference types reported by RefType
+const (
+	RefTypeLocal  = "local"
+	RefTypeSHA    = "sha"
+	RefTypeTag    = "tag"
+	RefTypeBranch = "branch"
+)
+
+// RefType classifies the reference as written, without any API calls. Refs
+// that do not look like a version or commit SHA are assumed to be branches.
+func (a ActionReference) RefType() string {
+	switch {
+	case a.IsLocal():
+		return RefTypeLocal
+	case a.CommitHash != "", len(a.Version) >= 6 && len(a.Version) <= 40 && common.IsHexString(a.Version):
+		return RefTypeSHA
+	case versions.IsVersion(a.Version):
+		return RefTypeTag
+	}
+	return RefTypeBranch
 }
 
 // Update represents a pending update for a GitHub Action
@@ -28,6 +98,9 @@ type Update struct {
 	Comments        []string // Preserved comments
 	VersionComment  string   // New version comment
 	OriginalVersion string   // For tracking version history
+	ReleaseSummary  string   // Optional summary of the new version's release notes
+	PinStyle        string   // How the new reference is written; see NewRef
+	MovedTo         string   // Full name the reference is renamed to when the action's repository moved
 }
 
 // VersionChecker checks for newer versions of GitHub Actions
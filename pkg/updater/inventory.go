@@ -0,0 +1,162 @@
+package updater
+
+import (
+	"context"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Pin statuses of an inventory entry
+const (
+	PinStatusPinned   = "pinned"   // Every reference is pinned to a commit SHA
+	PinStatusUnpinned = "unpinned" // No reference is pinned
+	PinStatusMixed    = "mixed"    // Some references are pinned
+)
+
+// InventoryEntry is one distinct action version used across the scanned
+// repositories
+type InventoryEntry struct {
+	Action       string     `json:"action"`
+	Version      string     `json:"version"` // Version comment of pinned references, else the ref
+	Repositories []string   `json:"repositories"`
+	Workflows    int        `json:"workflows"` // Workflow files referencing it
+	References   int        `json:"references"`
+	PinStatus    string     `json:"pin_status"`
+	Latest       string     `json:"latest,omitempty"`
+	ReleasedAt   *time.Time `json:"released_at,omitempty"` // Publication of the release of Version
+	AgeDays      int        `json:"age_days,omitempty"`
+	License      string     `json:"license,omitempty"` // SPDX identifier; "none" when the repository has no license
+}
+
+// Inventory collects the remote action references of one or more
+// repositories into a list of every distinct action version used, for
+// supply-chain reviews. It is safe for concurrent use.
+type Inventory struct {
+	mu    sync.Mutex
+	items map[string]*inventoryItem
+}
+
+// inventoryItem accumulates the references of one entry
+type inventoryItem struct {
+	action   ActionReference
+	repos    map[string]bool
+	files    map[string]bool
+	refs     int
+	pinned   int
+	unpinned int
+}
+
+// NewInventory creates an empty inventory
+func NewInventory() *Inventory {
+	return &Inventory{items: make(map[string]*inventoryItem)}
+}
+
+// Add records the references found in repository. Local actions and uses
+// values only known at run time are left out.
+func (inv *Inventory) Add(repository string, refs []ActionReference) {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	for _, ref := range refs {
+		if ref.IsLocal() || (ref.Owner == "matrix" && ref.Version == "dynamic") {
+			continue
+		}
+		key := actionKey(&ref)
+		item := inv.items[key]
+		if item == nil {
+			item = &inventoryItem{action: ref, repos: make(map[string]bool), files: make(map[string]bool)}
+			inv.items[key] = item
+		}
+		item.repos[repository] = true
+		item.files[repository+"\x00"+ref.Path] = true
+		item.refs++
+		if ref.RefType() == RefTypeSHA {
+			item.pinned++
+		} else {
+			item.unpinned++
+		}
+	}
+}
+
+// Entries returns the inventory sorted by action and version. With a
+// checker, the latest version of each action is looked up, and so are the
+// release date of each version and the license of each action repository
+// when the checker is a ReleaseDateProvider or LicenseProvider; metadata
+// that cannot be looked up is left empty.
+func (inv *Inventory) Entries(ctx context.Context, checker VersionChecker, now time.Time) []InventoryEntry {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+
+	latest := make(map[string]string)
+	licenses := make(map[string]string)
+	entries := make([]InventoryEntry, 0, len(inv.items))
+	for _, item := range inv.items {
+		entry := InventoryEntry{
+			Action:     item.action.FullName(),
+			Version:    item.action.Version,
+			Workflows:  len(item.files),
+			References: item.refs,
+			PinStatus:  PinStatusMixed,
+		}
+		for repo := range item.repos {
+			entry.Repositories = append(entry.Repositories, repo)
+		}
+		sort.Strings(entry.Repositories)
+		switch {
+		case item.unpinned == 0:
+			entry.PinStatus = PinStatusPinned
+		case item.pinned == 0:
+			entry.PinStatus = PinStatusUnpinned
+		}
+		if checker != nil && ctx.Err() == nil {
+			inv.resolve(ctx, checker, item.action, &entry, latest, licenses, now)
+		}
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Action != entries[j].Action {
+			return entries[i].Action < entries[j].Action
+		}
+		return entries[i].Version < entries[j].Version
+	})
+	return entries
+}
+
+// resolve fills the metadata of entry, looking up each action and
+// repository once
+func (inv *Inventory) resolve(ctx context.Context, checker VersionChecker, action ActionReference, entry *InventoryEntry, latest, licenses map[string]string, now time.Time) {
+	name := action.FullName()
+	if _, ok := latest[name]; !ok {
+		version, _, err := checker.GetLatestVersion(ctx, action)
+		if err != nil {
+			log.Printf("Warning: %v", err)
+		}
+		latest[name] = version
+	}
+	entry.Latest = latest[name]
+
+	// A commit pinned without a version comment has no release to date
+	if dates, ok := checker.(ReleaseDateProvider); ok && action.Version != action.CommitHash {
+		if released, err := dates.GetReleaseDate(ctx, action, action.Version); err == nil {
+			released = released.UTC()
+			entry.ReleasedAt = &released
+			entry.AgeDays = int(now.Sub(released).Hours() / 24)
+		}
+	}
+
+	if provider, ok := checker.(LicenseProvider); ok {
+		repo := action.Repository()
+		license, seen := licenses[repo]
+		if !seen {
+			var err error
+			if license, err = provider.GetLicense(ctx, action); err != nil {
+				log.Printf("Warning: %v", err)
+			} else if license == "" {
+				license = "none"
+			}
+			licenses[repo] = license
+		}
+		entry.License = license
+	}
+}
@@ -0,0 +1,138 @@
+package updater
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v72/github"
+)
+
+// inventoryChecker serves release dates and licenses
+type inventoryChecker struct {
+	countingChecker
+	licenseCalls int
+}
+
+func (c *inventoryChecker) GetReleaseDate(_ context.Context, action ActionReference, version string) (time.Time, error) {
+	if version != "v3" {
+		return time.Time{}, fmt.Errorf("no release")
+	}
+	return time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), nil
+}
+
+func (c *inventoryChecker) GetLicense(_ context.Context, action ActionReference) (string, error) {
+	c.licenseCalls++
+	if action.Owner == "octo" {
+		return "", nil
+	}
+	return "MIT", nil
+}
+
+func TestInventory(t *testing.T) {
+	const pin = "a81bbbf8298c0fa03ea29cdc473d45769f953675"
+	inv := NewInventory()
+	inv.Add("o/a", []ActionReference{
+		{Owner: "actions", Name: "checkout", Version: "v3", Path: "ci.yml"},
+		{Owner: "actions", Name: "checkout", Version: "v3", Path: "ci.yml"},
+		{Owner: "actions", Name: "checkout", Version: "v3", CommitHash: pin, Path: "release.yml"},
+		{Owner: "octo", Name: "tool", Version: pin, CommitHash: pin, Path: "ci.yml"},
+		{LocalPath: "./local", Path: "ci.yml"},
+		{Owner: "matrix", Name: "action", Version: "dynamic", Path: "ci.yml"},
+	})
+	inv.Add("o/b", []ActionReference{
+		{Owner: "actions", Name: "checkout", Version: "v3", Path: "ci.yml"},
+		{Owner: "actions", Name: "cache", Version: "main", Path: "ci.yml"},
+		{Owner: "octo", Name: "tool/lint", Version: pin, CommitHash: pin, Path: "ci.yml"},
+	})
+
+	checker := &inventoryChecker{}
+	entries := inv.Entries(context.Background(), checker, time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC))
+	if len(entries) != 4 {
+		t.Fatalf("Entries() = %+v, want 4 entries", entries)
+	}
+
+	cache, checkout, tool := entries[0], entries[1], entries[2]
+	if cache.Action != "actions/cache" || cache.PinStatus != PinStatusUnpinned || cache.ReleasedAt != nil || cache.License != "MIT" {
+		t.Errorf("cache entry = %+v", cache)
+	}
+	if checkout.Action != "actions/checkout" || checkout.Version != "v3" || checkout.References != 4 || checkout.Workflows != 3 ||
+		fmt.Sprint(checkout.Repositories) != "[o/a o/b]" || checkout.PinStatus != PinStatusMixed {
+		t.Errorf("checkout entry = %+v", checkout)
+	}
+	if checkout.Latest != "v4.0.0" || checkout.ReleasedAt == nil || checkout.AgeDays != 30 {
+		t.Errorf("checkout metadata = latest %s, released %v, age %d", checkout.Latest, checkout.ReleasedAt, checkout.AgeDays)
+	}
+	if tool.PinStatus != PinStatusPinned || tool.License != "none" || tool.ReleasedAt != nil {
+		t.Errorf("tool entry = %+v", tool)
+	}
+	// Each action and repository is looked up once
+	if checker.latestCalls != 4 || checker.licenseCalls != 3 {
+		t.Errorf("lookups = %d latest, %d license; want 4 and 3", checker.latestCalls, checker.licenseCalls)
+	}
+
+	// Without a checker only the usage is reported
+	if entries := inv.Entries(context.Background(), nil, time.Now()); entries[1].Latest != "" || entries[1].References != 4 {
+		t.Errorf("Entries(nil) = %+v", entries[1])
+	}
+}
+
+func TestGetLicense(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/o/r", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"name":"r","license":{"key":"apache-2.0","spdx_id":"Apache-2.0"}}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	checker := NewCachingVersionChecker(&DefaultVersionChecker{client: client}, nil, 0)
+
+	license, err := checker.GetLicense(context.Background(), ActionReference{Owner: "o", Name: "r/sub"})
+	if err != nil || license != "Apache-2.0" {
+		t.Errorf("GetLicense() = %q, %v; want Apache-2.0", license, err)
+	}
+	if _, err := checker.GetLicense(context.Background(), ActionReference{Owner: "o", Name: "missing"}); err == nil {
+		t.Error("GetLicense() expected error for missing repository")
+	}
+}
+
+func TestLicenseThroughMetadata(t *testing.T) {
+	ctx := context.Background()
+	checkout := ActionReference{Owner: "actions", Name: "checkout", Version: "v3"}
+	tool := ActionReference{Owner: "octo", Name: "tool", Version: "v1"}
+
+	// Licenses looked up with -metadata are recorded, including "none"
+	inner := &inventoryChecker{}
+	snapshot := NewMetadataSnapshot()
+	recorder := NewRecordingVersionChecker(inner, snapshot)
+	for _, action := range []ActionReference{checkout, tool} {
+		if _, err := recorder.GetLicense(ctx, action); err != nil {
+			t.Fatalf("RecordingVersionChecker.GetLicense(%s) error = %v", action.Repository(), err)
+		}
+	}
+
+	// Offline and resumed runs answer them from the snapshot
+	for name, checker := range map[string]LicenseProvider{
+		"offline":  NewOfflineVersionChecker(snapshot),
+		"resuming": NewResumingVersionChecker(inner, snapshot),
+	} {
+		if license, err := checker.GetLicense(ctx, checkout); err != nil || license != "MIT" {
+			t.Errorf("%s GetLicense(checkout) = %q, %v; want MIT", name, license, err)
+		}
+		if license, err := checker.GetLicense(ctx, tool); err != nil || license != "" {
+			t.Errorf("%s GetLicense(tool) = %q, %v; want none", name, license, err)
+		}
+	}
+	if inner.licenseCalls != 2 {
+		t.Errorf("license lookups = %d, want 2", inner.licenseCalls)
+	}
+	if _, err := NewOfflineVersionChecker(snapshot).GetLicense(ctx, ActionReference{Owner: "o", Name: "r"}); err == nil {
+		t.Error("offline GetLicense() expected error for an unrecorded repository")
+	}
+}
@@ -0,0 +1,82 @@
+package updater
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+)
+
+// LicenseProvider is implemented by version checkers that can report the
+// license of an action's repository
+type LicenseProvider interface {
+	// GetLicense returns the SPDX identifier of the license, or "" when the
+	// repository has none GitHub recognizes
+	GetLicense(ctx context.Context, action ActionReference) (string, error)
+}
+
+// GetLicense returns the SPDX identifier of the action repository's license
+func (c *DefaultVersionChecker) GetLicense(ctx context.Context, action ActionReference) (string, error) {
+	repo, _, err := c.clientFor(action).Repositories.Get(ctx, action.Owner, action.Repo())
+	if err != nil {
+		return "", fmt.Errorf(common.ErrGettingLicense, action.Owner+"/"+action.Repo(), err)
+	}
+	return repo.GetLicense().GetSPDXID(), nil
+}
+
+// GetLicense implements LicenseProvider when the wrapped checker does
+func (c *CachingVersionChecker) GetLicense(ctx context.Context, action ActionReference) (string, error) {
+	provider, ok := c.checker.(LicenseProvider)
+	if !ok {
+		return "", fmt.Errorf(common.ErrGettingLicense, action.Owner+"/"+action.Repo(), fmt.Errorf("not supported"))
+	}
+	return provider.GetLicense(ctx, action)
+}
+
+// GetLicense implements LicenseProvider when the wrapped checker does
+func (c *RetryingVersionChecker) GetLicense(ctx context.Context, action ActionReference) (string, error) {
+	provider, ok := c.checker.(LicenseProvider)
+	if !ok {
+		return "", fmt.Errorf(common.ErrGettingLicense, action.Owner+"/"+action.Repo(), fmt.Errorf("not supported"))
+	}
+	var license string
+	err := c.policy.Do(ctx, func() error {
+		var err error
+		license, err = provider.GetLicense(ctx, action)
+		return err
+	})
+	return license, err
+}
+
+// GetLicense implements LicenseProvider from the licenses in the snapshot
+func (c *OfflineVersionChecker) GetLicense(_ context.Context, action ActionReference) (string, error) {
+	if metadata := c.snapshot.lookup(action); metadata != nil && metadata.License != nil {
+		return *metadata.License, nil
+	}
+	return "", fmt.Errorf(common.ErrNotInSnapshot, "license of "+action.Repository())
+}
+
+// GetLicense implements LicenseProvider when the wrapped checker does and
+// records the license for offline runs
+func (c *RecordingVersionChecker) GetLicense(ctx context.Context, action ActionReference) (string, error) {
+	provider, ok := c.checker.(LicenseProvider)
+	if !ok {
+		return "", fmt.Errorf(common.ErrGettingLicense, action.Owner+"/"+action.Repo(), fmt.Errorf("not supported"))
+	}
+	license, err := provider.GetLicense(ctx, action)
+	if err == nil {
+		c.snapshot.record(action, func(m *ActionMetadata) {
+			m.License = &license
+		})
+	}
+	return license, err
+}
+
+// GetLicense implements LicenseProvider, answering licenses recorded in the
+// snapshot before asking the wrapped checker
+func (c *ResumingVersionChecker) GetLicense(ctx context.Context, action ActionReference) (string, error) {
+	if metadata := c.snapshot.lookup(action); metadata != nil && metadata.License != nil {
+		return *metadata.License, nil
+	}
+	return c.RecordingVersionChecker.GetLicense(ctx, action)
+}
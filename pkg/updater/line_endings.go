@@ -0,0 +1,24 @@
+package updater
+
+import "strings"
+
+// toLF converts CRLF line endings to LF, as the rewriters split lines on
+// "\n", and reports whether content mostly used CRLF, as files checked out
+// on Windows runners with core.autocrlf do
+func toLF(content string) (string, bool) {
+	crlf := strings.Count(content, "\r\n")
+	if crlf == 0 {
+		return content, false
+	}
+	mostly := crlf*2 >= strings.Count(content, "\n")
+	return strings.ReplaceAll(content, "\r\n", "\n"), mostly
+}
+
+// fromLF restores the line endings reported by toLF. Files mixing both
+// endings are written with the one most of their lines used.
+func fromLF(content string, crlf bool) string {
+	if !crlf {
+		return content
+	}
+	return strings.ReplaceAll(content, "\n", "\r\n")
+}
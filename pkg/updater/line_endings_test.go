@@ -0,0 +1,74 @@
+package updater
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLineEndings(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		wantCRLF bool
+	}{
+		{name: "lf", content: "a\nb\n"},
+		{name: "crlf", content: "a\r\nb\r\n", wantCRLF: true},
+		{name: "mostly crlf", content: "a\r\nb\r\nc\n", wantCRLF: true},
+		{name: "mostly lf", content: "a\r\nb\nc\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lf, crlf := toLF(tt.content)
+			if strings.Contains(lf, "\r") || crlf != tt.wantCRLF {
+				t.Fatalf("toLF(%q) = %q, %v", tt.content, lf, crlf)
+			}
+			if tt.wantCRLF && fromLF(lf, crlf) != strings.ReplaceAll(lf, "\n", "\r\n") {
+				t.Errorf("fromLF(%q) = %q", lf, fromLF(lf, crlf))
+			}
+		})
+	}
+}
+
+func TestApplyUpdatesKeepsCRLF(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "ci.yml")
+	content := "jobs:\r\n  build:\r\n    steps:\r\n      - uses: actions/checkout@v3 # checkout\r\n      - run: make\r\n"
+	if err := os.WriteFile(file, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	scanner := NewScanner(dir)
+	refs, err := scanner.ParseActionReferences(file)
+	if err != nil || len(refs) != 1 || refs[0].Line != 4 {
+		t.Fatalf("ParseActionReferences() = %+v, %v", refs, err)
+	}
+	update := &Update{
+		Action:     refs[0],
+		OldVersion: "v3",
+		NewVersion: "v4",
+		NewHash:    "b4ffde65f46336ab88eb53be808477a3936bae11",
+		FilePath:   file,
+		LineNumber: refs[0].Line,
+	}
+	if err := NewUpdateManager(dir).ApplyUpdates(context.Background(), []*Update{update}); err != nil {
+		t.Fatalf("ApplyUpdates() error = %v", err)
+	}
+	got, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(string(got), "\r\n") != 5 || strings.Count(string(got), "\n") != 5 {
+		t.Errorf("ApplyUpdates() changed line endings:\n%q", got)
+	}
+	if !strings.Contains(string(got), "actions/checkout@b4ffde65f46336ab88eb53be808477a3936bae11") {
+		t.Errorf("ApplyUpdates() did not update the reference:\n%q", got)
+	}
+
+	// The same holds for files rewritten through the API
+	if rewritten, _, _ := rewriteFileContent(content, []*Update{update}, YAMLRewriteStrategy); strings.Count(rewritten, "\r\n") != 5 {
+		t.Errorf("rewriteFileContent() changed line endings:\n%q", rewritten)
+	}
+}
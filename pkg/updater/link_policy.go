@@ -0,0 +1,70 @@
+package updater
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+)
+
+// LinkPolicy says how a scan treats symbolic links or git submodules
+type LinkPolicy string
+
+// Link policies
+const (
+	LinkFollow LinkPolicy = "follow" // Scan the link target or submodule
+	LinkSkip   LinkPolicy = "skip"   // Leave it out of the scan
+	LinkError  LinkPolicy = "error"  // Fail the scan
+)
+
+// Default link policies: symbolic links inside the repository are followed,
+// as they are part of it, while submodules are other repositories whose
+// workflows cannot be updated from this one
+const (
+	DefaultSymlinkPolicy   = LinkFollow
+	DefaultSubmodulePolicy = LinkSkip
+)
+
+// ParseLinkPolicy parses follow, skip or error; "" selects def
+func ParseLinkPolicy(s string, def LinkPolicy) (LinkPolicy, error) {
+	switch policy := LinkPolicy(strings.ToLower(strings.TrimSpace(s))); policy {
+	case "":
+		return def, nil
+	case LinkFollow, LinkSkip, LinkError:
+		return policy, nil
+	default:
+		return "", fmt.Errorf(common.ErrInvalidLinkPolicy, s)
+	}
+}
+
+// resolveLink resolves the symbolic link at path to its target, named below
+// baseDir. Targets outside baseDir are rejected like in validatePath, so a
+// link cannot make the scan or an update reach outside the repository.
+func resolveLink(baseDir, path string) (string, error) {
+	target, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", fmt.Errorf(common.ErrFailedToEvaluateSymlink, err)
+	}
+	base, err := filepath.EvalSymlinks(baseDir)
+	if err != nil {
+		return "", fmt.Errorf(common.ErrFailedToEvalBaseDir, err)
+	}
+	if !common.IsWithinDir(base, target) {
+		return "", fmt.Errorf(common.ErrSymlinkOutsideAllowedDir, path)
+	}
+	rel, err := filepath.Rel(base, target)
+	if err != nil {
+		return "", fmt.Errorf(common.ErrFailedToDetermineRelPath, err)
+	}
+	return filepath.Join(baseDir, rel), nil
+}
+
+// isSubmodule reports whether dir is the checkout of a git submodule, whose
+// .git is a file pointing into the parent repository (or a directory, for
+// submodules cloned on their own)
+func isSubmodule(dir string) bool {
+	_, err := os.Lstat(filepath.Join(dir, ".git"))
+	return err == nil
+}
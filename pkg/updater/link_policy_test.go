@@ -0,0 +1,120 @@
+package updater
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseLinkPolicy(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    LinkPolicy
+		wantErr bool
+	}{
+		{in: "", want: LinkSkip},
+		{in: "follow", want: LinkFollow},
+		{in: " Error ", want: LinkError},
+		{in: "ignore", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseLinkPolicy(tt.in, LinkSkip)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseLinkPolicy(%q) = %q, %v, want %q", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+// symlink links name below dir to target
+func symlink(t *testing.T, dir, target, name string) {
+	t.Helper()
+	if err := os.Symlink(target, filepath.Join(dir, filepath.FromSlash(name))); err != nil {
+		t.Skipf("symbolic links unavailable: %v", err)
+	}
+}
+
+func TestScanWorkflowsSymlinks(t *testing.T) {
+	dir := writeMonorepo(t, "workflows/ci.yml", "shared/reusable.yml", "shared/nested/deploy.yml")
+	outside := writeMonorepo(t, "evil.yml")
+	workflows := filepath.Join(dir, "workflows")
+	symlink(t, dir, filepath.Join(dir, "shared", "reusable.yml"), "workflows/reusable.yml")
+	symlink(t, dir, filepath.Join(dir, "shared"), "workflows/shared")
+	symlink(t, dir, filepath.Join(dir, "workflows"), "workflows/loop")
+
+	tests := []struct {
+		name    string
+		policy  LinkPolicy
+		extra   string // Link to outside the repository
+		want    string
+		wantErr string
+	}{
+		{name: "follow", policy: LinkFollow, want: "workflows/ci.yml,shared/reusable.yml,shared/nested/deploy.yml"},
+		{name: "skip", policy: LinkSkip, want: "workflows/ci.yml"},
+		{name: "error", policy: LinkError, wantErr: "symbolic links are not allowed"},
+		{name: "outside", policy: LinkFollow, extra: "evil.yml", wantErr: "symlink points outside allowed directory"},
+		{name: "outside skipped", policy: LinkSkip, extra: "evil.yml", want: "workflows/ci.yml"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.extra != "" {
+				symlink(t, dir, filepath.Join(outside, tt.extra), "workflows/"+tt.extra)
+				defer os.Remove(filepath.Join(workflows, tt.extra))
+			}
+			scanner := NewScanner(dir)
+			scanner.SetSymlinkPolicy(tt.policy)
+			files, err := scanner.ScanWorkflows(workflows)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ScanWorkflows() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ScanWorkflows() error = %v", err)
+			}
+			if got := relativeFiles(dir, files); got != tt.want {
+				t.Errorf("ScanWorkflows() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScanWorkflowsSubmodules(t *testing.T) {
+	dir := writeMonorepo(t,
+		".github/workflows/ci.yml",
+		".github/workflows/vendored/lib.yml",
+		"lib/.github/workflows/lib.yml",
+	)
+	for _, sub := range []string{".github/workflows/vendored", "lib"} {
+		if err := os.WriteFile(filepath.Join(dir, filepath.FromSlash(sub), ".git"), []byte("gitdir: ../.git/modules/lib\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		policy  LinkPolicy
+		want    string
+		wantErr bool
+	}{
+		{policy: "", want: ".github/workflows/ci.yml"},
+		{policy: LinkFollow, want: ".github/workflows/ci.yml,.github/workflows/vendored/lib.yml,lib/.github/workflows/lib.yml"},
+		{policy: LinkError, wantErr: true},
+	}
+	for _, tt := range tests {
+		rep, err := ScanReferences(context.Background(), Options{RepoPath: dir, DiscoverDepth: 1, Submodules: tt.policy})
+		if tt.wantErr {
+			if err == nil || !strings.Contains(err.Error(), "git submodules are not allowed") {
+				t.Errorf("ScanReferences() with %q error = %v", tt.policy, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("ScanReferences() with %q error = %v", tt.policy, err)
+		}
+		if got := relativeFiles(dir, rep.Files); got != tt.want {
+			t.Errorf("scanned files with %q = %s, want %s", tt.policy, got, tt.want)
+		}
+	}
+}
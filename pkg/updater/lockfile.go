@@ -0,0 +1,216 @@
+package updater
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/versions"
+)
+
+// DefaultLockfile is the lockfile path, relative to the repository root
+const DefaultLockfile = "actions.lock"
+
+// LockfileVersion is the version of the lockfile format written by this build
+const LockfileVersion = 1
+
+// Reasons of a LockMismatch
+const (
+	LockUnlocked = "unlocked" // The reference has no lockfile entry
+	LockMoved    = "moved"    // The tag or branch now points at another commit
+	LockStale    = "stale"    // The entry is no longer used by any workflow
+)
+
+// Lockfile records the commit every action reference of a repository
+// resolved to, so moved tags can be detected
+type Lockfile struct {
+	Version int            `json:"version"`
+	Actions []LockedAction `json:"actions"`
+}
+
+// LockedAction is the resolution of one action reference
+type LockedAction struct {
+	Action     string    `json:"action"`            // As written, e.g. github/codeql-action/init
+	Ref        string    `json:"ref"`               // As written after "@": a tag, branch or commit hash
+	Version    string    `json:"version,omitempty"` // Version tag of the commit, when known
+	Commit     string    `json:"commit,omitempty"`  // Empty when the reference could not be resolved
+	ResolvedAt time.Time `json:"resolved_at"`
+}
+
+// LockMismatch is a difference between the workflows and the lockfile
+type LockMismatch struct {
+	Action  string
+	Ref     string
+	Reason  string // LockUnlocked, LockMoved or LockStale
+	Locked  string // Locked commit of a moved reference
+	Current string // Current commit of a moved reference
+}
+
+// String describes the mismatch
+func (m LockMismatch) String() string {
+	switch m.Reason {
+	case LockMoved:
+		return fmt.Sprintf("%s@%s moved from %s to %s", m.Action, m.Ref, m.Locked, m.Current)
+	case LockStale:
+		return fmt.Sprintf("%s@%s is locked but no longer used", m.Action, m.Ref)
+	}
+	return fmt.Sprintf("%s@%s is not in the lockfile", m.Action, m.Ref)
+}
+
+// lockRef returns the reference of ref as written after "@"
+func lockRef(ref ActionReference) string {
+	if ref.CommitHash != "" {
+		return ref.CommitHash
+	}
+	return ref.Version
+}
+
+// lockKey identifies an entry of the lockfile
+func lockKey(action, ref string) string {
+	return action + "@" + ref
+}
+
+// ReadLockfile loads a lockfile. A missing file is reported as an error
+// matching os.ErrNotExist.
+func ReadLockfile(path string) (*Lockfile, error) {
+	// #nosec G304 - path is provided by the user running the tool
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf(common.ErrReadingLockfile, path, err)
+	}
+	var lock Lockfile
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf(common.ErrReadingLockfile, path, err)
+	}
+	if lock.Version != LockfileVersion {
+		return nil, fmt.Errorf(common.ErrUnsupportedLockfile, path, lock.Version, LockfileVersion)
+	}
+	return &lock, nil
+}
+
+// Write writes the lockfile as indented JSON, sorted by action and ref
+func (l *Lockfile) Write(path string) error {
+	sort.Slice(l.Actions, func(i, j int) bool {
+		a, b := l.Actions[i], l.Actions[j]
+		return lockKey(a.Action, a.Ref) < lockKey(b.Action, b.Ref)
+	})
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return fmt.Errorf(common.ErrWritingLockfile, path, err)
+	}
+	options := common.DefaultFileOptions()
+	options.Mode = 0644
+	if err := common.WriteFileWithOptions(path, append(data, '\n'), options); err != nil {
+		return fmt.Errorf(common.ErrWritingLockfile, path, err)
+	}
+	return nil
+}
+
+// entries returns the entries of the lockfile by key
+func (l *Lockfile) entries() map[string]LockedAction {
+	entries := make(map[string]LockedAction)
+	if l != nil {
+		for _, entry := range l.Actions {
+			entries[lockKey(entry.Action, entry.Ref)] = entry
+		}
+	}
+	return entries
+}
+
+// BuildLockfile resolves every unique reference of refs. Entries of
+// previous whose commit is unchanged keep their resolution time. References
+// that cannot be resolved, such as branches the checker cannot look up, are
+// logged and locked without a commit.
+func BuildLockfile(ctx context.Context, checker VersionChecker, refs []ActionReference, previous *Lockfile, now time.Time) (*Lockfile, error) {
+	known := previous.entries()
+	seen := make(map[string]bool)
+	lock := &Lockfile{Version: LockfileVersion, Actions: []LockedAction{}}
+
+	for _, ref := range refs {
+		if ref.IsLocal() {
+			continue
+		}
+		entry := LockedAction{Action: ref.FullName(), Ref: lockRef(ref), ResolvedAt: now}
+		key := lockKey(entry.Action, entry.Ref)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf(common.ErrRunCancelled, err)
+		}
+
+		if ref.CommitHash != "" {
+			entry.Commit = ref.CommitHash
+			if ref.Version != ref.CommitHash {
+				entry.Version = ref.Version
+			} else if version, err := resolveBareHash(ctx, checker, ref); err == nil {
+				entry.Version = version
+			}
+		} else {
+			commit, err := checker.GetCommitHash(ctx, ref, ref.Version)
+			if err != nil {
+				log.Printf(common.ErrLockingAction, entry.Action, entry.Ref, err)
+			}
+			entry.Commit = commit
+			if versions.IsVersion(ref.Version) {
+				entry.Version = ref.Version
+			}
+		}
+
+		// Keep when an unchanged resolution was first recorded
+		if old, ok := known[key]; ok && old.Commit == entry.Commit {
+			entry.ResolvedAt = old.ResolvedAt
+		}
+		lock.Actions = append(lock.Actions, entry)
+	}
+	return lock, nil
+}
+
+// CheckLockfile compares the references of refs with lock. References to a
+// tag or branch are resolved again with checker to detect moved tags.
+func CheckLockfile(ctx context.Context, checker VersionChecker, lock *Lockfile, refs []ActionReference) ([]LockMismatch, error) {
+	entries := lock.entries()
+	used := make(map[string]bool)
+	var mismatches []LockMismatch
+
+	for _, ref := range refs {
+		if ref.IsLocal() {
+			continue
+		}
+		action, written := ref.FullName(), lockRef(ref)
+		key := lockKey(action, written)
+		if used[key] {
+			continue
+		}
+		used[key] = true
+
+		entry, ok := entries[key]
+		if !ok {
+			mismatches = append(mismatches, LockMismatch{Action: action, Ref: written, Reason: LockUnlocked})
+			continue
+		}
+		if ref.CommitHash != "" || entry.Commit == "" {
+			continue
+		}
+		commit, err := checker.GetCommitHash(ctx, ref, ref.Version)
+		if err != nil {
+			return nil, fmt.Errorf(common.ErrCheckingLock, action, written, err)
+		}
+		if !sameCommit(commit, entry.Commit) {
+			mismatches = append(mismatches, LockMismatch{Action: action, Ref: written, Reason: LockMoved, Locked: entry.Commit, Current: commit})
+		}
+	}
+
+	for _, entry := range lock.Actions {
+		if !used[lockKey(entry.Action, entry.Ref)] {
+			mismatches = append(mismatches, LockMismatch{Action: entry.Action, Ref: entry.Ref, Reason: LockStale})
+		}
+	}
+	return mismatches, nil
+}
@@ -0,0 +1,94 @@
+package updater
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBuildLockfile(t *testing.T) {
+	const pinned = "a81bbbf8298c0fa03ea29cdc473d45769f953675"
+	const tagged = "2222222222222222222222222222222222222222" // countingChecker's commit of every tag
+	refs := []ActionReference{
+		{Owner: "actions", Name: "checkout", Version: "v4"},
+		{Owner: "actions", Name: "checkout", Version: "v4"},
+		{Owner: "actions", Name: "cache", Version: "v4.1.0", CommitHash: pinned},
+		{Owner: "octo", Name: "tool", Version: pinned, CommitHash: pinned},
+		{LocalPath: "./.github/actions/build"},
+	}
+	earlier := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	now := earlier.Add(time.Hour)
+	previous := &Lockfile{Version: LockfileVersion, Actions: []LockedAction{
+		{Action: "actions/checkout", Ref: "v4", Commit: tagged, ResolvedAt: earlier},
+		{Action: "actions/cache", Ref: pinned, Commit: "0000000", ResolvedAt: earlier},
+	}}
+
+	checker := &resolvingChecker{}
+	lock, err := BuildLockfile(context.Background(), checker, refs, previous, now)
+	if err != nil {
+		t.Fatalf("BuildLockfile() error = %v", err)
+	}
+	want := []LockedAction{
+		{Action: "actions/checkout", Ref: "v4", Version: "v4", Commit: tagged, ResolvedAt: earlier},
+		{Action: "actions/cache", Ref: pinned, Version: "v4.1.0", Commit: pinned, ResolvedAt: now},
+		{Action: "octo/tool", Ref: pinned, Version: "v3.4.0", Commit: pinned, ResolvedAt: now},
+	}
+	if len(lock.Actions) != len(want) {
+		t.Fatalf("BuildLockfile() = %+v, want %d entries", lock.Actions, len(want))
+	}
+	for i, entry := range lock.Actions {
+		if entry != want[i] {
+			t.Errorf("entry %d = %+v, want %+v", i, entry, want[i])
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), DefaultLockfile)
+	if _, err := ReadLockfile(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("ReadLockfile() of a missing file error = %v", err)
+	}
+	if err := lock.Write(path); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	read, err := ReadLockfile(path)
+	if err != nil {
+		t.Fatalf("ReadLockfile() error = %v", err)
+	}
+	if len(read.Actions) != 3 || read.Actions[0].Action != "actions/cache" {
+		t.Errorf("ReadLockfile() = %+v, want entries sorted by action", read.Actions)
+	}
+}
+
+func TestCheckLockfile(t *testing.T) {
+	const pinned = "a81bbbf8298c0fa03ea29cdc473d45769f953675"
+	lock := &Lockfile{Version: LockfileVersion, Actions: []LockedAction{
+		{Action: "actions/checkout", Ref: "v4", Commit: "1111111111111111111111111111111111111111"},
+		{Action: "actions/cache", Ref: pinned, Commit: pinned},
+		{Action: "octo/old", Ref: "v1", Commit: pinned},
+	}}
+	refs := []ActionReference{
+		{Owner: "actions", Name: "checkout", Version: "v4"},
+		{Owner: "actions", Name: "cache", Version: "v4.1.0", CommitHash: pinned},
+		{Owner: "octo", Name: "tool", Version: "v2"},
+	}
+
+	mismatches, err := CheckLockfile(context.Background(), &countingChecker{}, lock, refs)
+	if err != nil {
+		t.Fatalf("CheckLockfile() error = %v", err)
+	}
+	want := []string{
+		"actions/checkout@v4 moved from 1111111111111111111111111111111111111111 to 2222222222222222222222222222222222222222",
+		"octo/tool@v2 is not in the lockfile",
+		"octo/old@v1 is locked but no longer used",
+	}
+	if len(mismatches) != len(want) {
+		t.Fatalf("CheckLockfile() = %v, want %d mismatches", mismatches, len(want))
+	}
+	for i, mismatch := range mismatches {
+		if mismatch.String() != want[i] {
+			t.Errorf("mismatch %d = %q, want %q", i, mismatch, want[i])
+		}
+	}
+}
@@ -0,0 +1,343 @@
+package updater
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+)
+
+// MetadataVersion is the version of the metadata snapshot format written by
+// this build
+const MetadataVersion = 1
+
+// MetadataSnapshot holds the version lookups of a run, so later runs can
+// answer them without API access
+type MetadataSnapshot struct {
+	Version    int                        `json:"version"`
+	ExportedAt time.Time                  `json:"exported_at"`
+	Actions    map[string]*ActionMetadata `json:"actions"` // By lowercase host/owner/repo
+
+	mu sync.Mutex
+}
+
+// ActionMetadata is the recorded metadata of one action repository
+type ActionMetadata struct {
+	Latest     string              `json:"latest,omitempty"`
+	LatestHash string              `json:"latest_hash,omitempty"`
+	Commits    map[string]string   `json:"commits,omitempty"` // Commit of each looked up tag or branch
+	Tags       map[string][]string `json:"tags,omitempty"`    // Tags pointing at each looked up commit
+	License    *string             `json:"license,omitempty"` // SPDX identifier, "" for none; nil when not looked up
+}
+
+// NewMetadataSnapshot creates an empty snapshot
+func NewMetadataSnapshot() *MetadataSnapshot {
+	return &MetadataSnapshot{Version: MetadataVersion, Actions: make(map[string]*ActionMetadata)}
+}
+
+// ReadMetadataSnapshot loads a snapshot written by Write
+func ReadMetadataSnapshot(path string) (*MetadataSnapshot, error) {
+	// #nosec G304 - path is provided by the user running the tool
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf(common.ErrReadingMetadata, path, err)
+	}
+	snapshot := NewMetadataSnapshot()
+	if err := json.Unmarshal(data, snapshot); err != nil {
+		return nil, fmt.Errorf(common.ErrReadingMetadata, path, err)
+	}
+	if snapshot.Version != MetadataVersion {
+		return nil, fmt.Errorf(common.ErrUnsupportedMetadata, path, snapshot.Version, MetadataVersion)
+	}
+	if snapshot.Actions == nil {
+		snapshot.Actions = make(map[string]*ActionMetadata)
+	}
+	return snapshot, nil
+}
+
+// Write writes the snapshot as indented JSON
+func (s *MetadataSnapshot) Write(path string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf(common.ErrWritingMetadata, path, err)
+	}
+	options := common.DefaultFileOptions()
+	options.Mode = 0644
+	if err := common.WriteFileWithOptions(path, append(data, '\n'), options); err != nil {
+		return fmt.Errorf(common.ErrWritingMetadata, path, err)
+	}
+	return nil
+}
+
+// lookup returns the metadata of action, or nil when it was not recorded
+func (s *MetadataSnapshot) lookup(action ActionReference) *ActionMetadata {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.Actions[strings.ToLower(action.Repository())]
+}
+
+// record updates the metadata of action with fn
+func (s *MetadataSnapshot) record(action ActionReference, fn func(*ActionMetadata)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := strings.ToLower(action.Repository())
+	metadata, ok := s.Actions[key]
+	if !ok {
+		metadata = &ActionMetadata{}
+		s.Actions[key] = metadata
+	}
+	fn(metadata)
+}
+
+// OfflineVersionChecker answers version lookups from a metadata snapshot
+// without API access. Lookups missing from the snapshot fail.
+type OfflineVersionChecker struct {
+	snapshot *MetadataSnapshot
+}
+
+// NewOfflineVersionChecker creates a checker reading snapshot
+func NewOfflineVersionChecker(snapshot *MetadataSnapshot) *OfflineVersionChecker {
+	return &OfflineVersionChecker{snapshot: snapshot}
+}
+
+// GetLatestVersion implements VersionChecker
+func (c *OfflineVersionChecker) GetLatestVersion(_ context.Context, action ActionReference) (string, string, error) {
+	metadata := c.snapshot.lookup(action)
+	if metadata == nil || metadata.Latest == "" {
+		return "", "", fmt.Errorf(common.ErrNotInSnapshot, "latest version of "+action.Repository())
+	}
+	return metadata.Latest, metadata.LatestHash, nil
+}
+
+// IsUpdateAvailable implements VersionChecker
+func (c *OfflineVersionChecker) IsUpdateAvailable(ctx context.Context, action ActionReference) (bool, string, string, error) {
+	latestVersion, latestHash, err := c.GetLatestVersion(ctx, action)
+	if err != nil {
+		return false, "", "", err
+	}
+	return isUpdateAvailable(action, latestVersion, latestHash), latestVersion, latestHash, nil
+}
+
+// GetCommitHash implements VersionChecker
+func (c *OfflineVersionChecker) GetCommitHash(_ context.Context, action ActionReference, version string) (string, error) {
+	if metadata := c.snapshot.lookup(action); metadata != nil {
+		if hash, ok := metadata.Commits[version]; ok {
+			return hash, nil
+		}
+	}
+	return "", fmt.Errorf(common.ErrNotInSnapshot, action.Repository()+"@"+version)
+}
+
+// GetCommitTags implements CommitTagsProvider
+func (c *OfflineVersionChecker) GetCommitTags(_ context.Context, action ActionReference, hash string) ([]string, error) {
+	if metadata := c.snapshot.lookup(action); metadata != nil {
+		if tags, ok := metadata.Tags[hash]; ok {
+			return tags, nil
+		}
+	}
+	return nil, fmt.Errorf(common.ErrNotInSnapshot, "tags of "+action.Repository()+"@"+hash)
+}
+
+// ResolveVersionForHash implements VersionResolver
+func (c *OfflineVersionChecker) ResolveVersionForHash(ctx context.Context, action ActionReference, hash string) (string, error) {
+	tags, err := c.GetCommitTags(ctx, action, hash)
+	if err != nil {
+		return "", err
+	}
+	return pickLatestTag(tags), nil
+}
+
+// RecordingVersionChecker wraps a VersionChecker and records every
+// successful lookup in a metadata snapshot for later offline runs
+type RecordingVersionChecker struct {
+	checker  VersionChecker
+	snapshot *MetadataSnapshot
+}
+
+// NewRecordingVersionChecker creates a wrapper recording the lookups of
+// checker in snapshot
+func NewRecordingVersionChecker(checker VersionChecker, snapshot *MetadataSnapshot) *RecordingVersionChecker {
+	return &RecordingVersionChecker{checker: checker, snapshot: snapshot}
+}
+
+// GetLatestVersion implements VersionChecker
+func (c *RecordingVersionChecker) GetLatestVersion(ctx context.Context, action ActionReference) (string, string, error) {
+	version, hash, err := c.checker.GetLatestVersion(ctx, action)
+	if err == nil {
+		c.recordLatest(action, version, hash)
+	}
+	return version, hash, err
+}
+
+// IsUpdateAvailable implements VersionChecker
+func (c *RecordingVersionChecker) IsUpdateAvailable(ctx context.Context, action ActionReference) (bool, string, string, error) {
+	available, version, hash, err := c.checker.IsUpdateAvailable(ctx, action)
+	if err == nil {
+		c.recordLatest(action, version, hash)
+	}
+	return available, version, hash, err
+}
+
+// Prefetch implements BatchPrefetcher when the wrapped checker does
+func (c *RecordingVersionChecker) Prefetch(ctx context.Context, actions []ActionReference) error {
+	if prefetcher, ok := c.checker.(BatchPrefetcher); ok {
+		return prefetcher.Prefetch(ctx, actions)
+	}
+	return nil
+}
+
+// GetCommitHash implements VersionChecker
+func (c *RecordingVersionChecker) GetCommitHash(ctx context.Context, action ActionReference, version string) (string, error) {
+	hash, err := c.checker.GetCommitHash(ctx, action, version)
+	if err == nil {
+		c.snapshot.record(action, func(m *ActionMetadata) {
+			if m.Commits == nil {
+				m.Commits = make(map[string]string)
+			}
+			m.Commits[version] = hash
+		})
+	}
+	return hash, err
+}
+
+// GetCommitTags implements CommitTagsProvider when the wrapped checker does
+func (c *RecordingVersionChecker) GetCommitTags(ctx context.Context, action ActionReference, hash string) ([]string, error) {
+	provider, ok := c.checker.(CommitTagsProvider)
+	if !ok {
+		return nil, fmt.Errorf(common.ErrGettingCommitTags, hash, fmt.Errorf("not supported"))
+	}
+	tags, err := provider.GetCommitTags(ctx, action, hash)
+	if err == nil {
+		c.recordTags(action, hash, tags, true)
+	}
+	return tags, err
+}
+
+// ResolveVersionForHash implements VersionResolver when the wrapped checker
+// does. The version is recorded as the only tag of the commit unless its
+// tags were recorded already.
+func (c *RecordingVersionChecker) ResolveVersionForHash(ctx context.Context, action ActionReference, hash string) (string, error) {
+	resolver, ok := c.checker.(VersionResolver)
+	if !ok {
+		return "", fmt.Errorf(common.ErrResolvingVersion, hash, fmt.Errorf("not supported"))
+	}
+	version, err := resolver.ResolveVersionForHash(ctx, action, hash)
+	if err == nil {
+		var tags []string
+		if version != "" {
+			tags = []string{version}
+		}
+		c.recordTags(action, hash, tags, false)
+	}
+	return version, err
+}
+
+// GetReleaseDate implements ReleaseDateProvider when the wrapped checker does
+func (c *RecordingVersionChecker) GetReleaseDate(ctx context.Context, action ActionReference, version string) (time.Time, error) {
+	dates, ok := c.checker.(ReleaseDateProvider)
+	if !ok {
+		return time.Time{}, fmt.Errorf(common.ErrGettingReleaseDate, version, fmt.Errorf("not supported"))
+	}
+	return dates.GetReleaseDate(ctx, action, version)
+}
+
+// GetCommitDate implements CommitDateProvider when the wrapped checker does
+func (c *RecordingVersionChecker) GetCommitDate(ctx context.Context, action ActionReference, sha string) (time.Time, error) {
+	dates, ok := c.checker.(CommitDateProvider)
+	if !ok {
+		return time.Time{}, fmt.Errorf(common.ErrGettingCommitDate, sha, fmt.Errorf("not supported"))
+	}
+	return dates.GetCommitDate(ctx, action, sha)
+}
+
+// GetReleaseNotes implements ReleaseNotesProvider when the wrapped checker does
+func (c *RecordingVersionChecker) GetReleaseNotes(ctx context.Context, action ActionReference, version string) (ReleaseNotes, error) {
+	provider, ok := c.checker.(ReleaseNotesProvider)
+	if !ok {
+		return ReleaseNotes{}, fmt.Errorf(common.ErrGettingReleaseNotes, version, fmt.Errorf("not supported"))
+	}
+	return provider.GetReleaseNotes(ctx, action, version)
+}
+
+// recordLatest records the latest version of action
+func (c *RecordingVersionChecker) recordLatest(action ActionReference, version, hash string) {
+	c.snapshot.record(action, func(m *ActionMetadata) {
+		m.Latest, m.LatestHash = version, hash
+	})
+}
+
+// recordTags records the tags of a commit, replacing recorded tags only
+// when replace is set. Commits without tags are recorded as well.
+func (c *RecordingVersionChecker) recordTags(action ActionReference, hash string, tags []string, replace bool) {
+	c.snapshot.record(action, func(m *ActionMetadata) {
+		if m.Tags == nil {
+			m.Tags = make(map[string][]string)
+		}
+		if _, ok := m.Tags[hash]; ok && !replace {
+			return
+		}
+		m.Tags[hash] = append([]string{}, tags...)
+	})
+}
+
+// ResumingVersionChecker answers the lookups recorded in a snapshot, such as
+// the one kept by an interrupted run, and records the others it passes to
+// the wrapped checker. Dates, release notes, licenses and moves are looked
+// up through the embedded RecordingVersionChecker.
+type ResumingVersionChecker struct {
+	*RecordingVersionChecker
+}
+
+// NewResumingVersionChecker creates a wrapper answering from snapshot
+// before asking checker
+func NewResumingVersionChecker(checker VersionChecker, snapshot *MetadataSnapshot) *ResumingVersionChecker {
+	return &ResumingVersionChecker{RecordingVersionChecker: NewRecordingVersionChecker(checker, snapshot)}
+}
+
+// GetLatestVersion implements VersionChecker
+func (c *ResumingVersionChecker) GetLatestVersion(ctx context.Context, action ActionReference) (string, string, error) {
+	if metadata := c.snapshot.lookup(action); metadata != nil && metadata.Latest != "" {
+		return metadata.Latest, metadata.LatestHash, nil
+	}
+	return c.RecordingVersionChecker.GetLatestVersion(ctx, action)
+}
+
+// IsUpdateAvailable implements VersionChecker
+func (c *ResumingVersionChecker) IsUpdateAvailable(ctx context.Context, action ActionReference) (bool, string, string, error) {
+	if metadata := c.snapshot.lookup(action); metadata != nil && metadata.Latest != "" {
+		return isUpdateAvailable(action, metadata.Latest, metadata.LatestHash), metadata.Latest, metadata.LatestHash, nil
+	}
+	return c.RecordingVersionChecker.IsUpdateAvailable(ctx, action)
+}
+
+// GetCommitHash implements VersionChecker
+func (c *ResumingVersionChecker) GetCommitHash(ctx context.Context, action ActionReference, version string) (string, error) {
+	if metadata := c.snapshot.lookup(action); metadata != nil {
+		if hash, ok := metadata.Commits[version]; ok {
+			return hash, nil
+		}
+	}
+	return c.RecordingVersionChecker.GetCommitHash(ctx, action, version)
+}
+
+// Prefetch implements BatchPrefetcher for the actions whose latest version
+// was not recorded
+func (c *ResumingVersionChecker) Prefetch(ctx context.Context, actions []ActionReference) error {
+	var pending []ActionReference
+	for _, action := range actions {
+		if metadata := c.snapshot.lookup(action); metadata == nil || metadata.Latest == "" {
+			pending = append(pending, action)
+		}
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+	return c.RecordingVersionChecker.Prefetch(ctx, pending)
+}
@@ -0,0 +1,194 @@
+package updater
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOfflineRunMatchesRecordedRun(t *testing.T) {
+	dir := t.TempDir()
+	workflow := filepath.Join(dir, ".github", "workflows", "ci.yml")
+	if err := os.MkdirAll(filepath.Dir(workflow), 0750); err != nil {
+		t.Fatal(err)
+	}
+	content := "jobs:\n  a:\n    steps:\n" +
+		"      - uses: actions/checkout@" + driftPinnedHash + "\n" +
+		"      - uses: Octo/Tool@v3\n"
+	if err := os.WriteFile(workflow, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	snapshot := NewMetadataSnapshot()
+	recorder := NewRecordingVersionChecker(&resolvingChecker{}, snapshot)
+	online, err := Run(context.Background(), Options{RepoPath: dir, Mode: ModeDryRun, Checker: recorder})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "metadata.json")
+	if err := snapshot.Write(path); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	loaded, err := ReadMetadataSnapshot(path)
+	if err != nil {
+		t.Fatalf("ReadMetadataSnapshot() error = %v", err)
+	}
+	if metadata := loaded.Actions["octo/tool"]; metadata == nil || metadata.Latest != "v4.0.0" {
+		t.Errorf("recorded octo/tool = %+v", metadata)
+	}
+
+	offline, err := Run(context.Background(), Options{RepoPath: dir, Mode: ModeDryRun, Checker: NewOfflineVersionChecker(loaded)})
+	if err != nil {
+		t.Fatalf("offline Run() error = %v", err)
+	}
+	if len(offline.Updates) != len(online.Updates) || len(online.Updates) != 2 {
+		t.Fatalf("offline updates = %d, online updates = %d", len(offline.Updates), len(online.Updates))
+	}
+	for i, update := range offline.Updates {
+		want := online.Updates[i]
+		if update.OldVersion != want.OldVersion || update.NewVersion != want.NewVersion || update.NewHash != want.NewHash {
+			t.Errorf("offline update %d = %+v, want %+v", i, update, want)
+		}
+	}
+}
+
+func TestOfflineVersionCheckerMissingMetadata(t *testing.T) {
+	ctx := context.Background()
+	checker := NewOfflineVersionChecker(NewMetadataSnapshot())
+	action := ActionReference{Owner: "actions", Name: "checkout", Version: "v4"}
+
+	if _, _, err := checker.GetLatestVersion(ctx, action); err == nil || !strings.Contains(err.Error(), "not in the metadata snapshot") {
+		t.Errorf("GetLatestVersion() error = %v", err)
+	}
+	if _, err := checker.GetCommitHash(ctx, action, "v4"); err == nil || !strings.Contains(err.Error(), "actions/checkout@v4") {
+		t.Errorf("GetCommitHash() error = %v", err)
+	}
+	if _, err := checker.ResolveVersionForHash(ctx, action, driftPinnedHash); err == nil {
+		t.Error("ResolveVersionForHash() error = nil")
+	}
+}
+
+func TestReadMetadataSnapshotVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metadata.json")
+	if err := os.WriteFile(path, []byte(`{"version": 2, "actions": {}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadMetadataSnapshot(path); err == nil || !strings.Contains(err.Error(), "unsupported version 2") {
+		t.Errorf("ReadMetadataSnapshot() error = %v", err)
+	}
+}
+
+func TestResumingVersionChecker(t *testing.T) {
+	ctx := context.Background()
+	inner := &countingChecker{}
+	snapshot := NewMetadataSnapshot()
+	checkout := ActionReference{Owner: "actions", Name: "checkout", Version: "v3"}
+	snapshot.record(checkout, func(m *ActionMetadata) {
+		m.Latest, m.LatestHash = "v5.0.0", "5555555555555555555555555555555555555555"
+		m.Commits = map[string]string{"v3": "3333333333333333333333333333333333333333"}
+	})
+	checker := NewResumingVersionChecker(inner, snapshot)
+
+	// Recorded lookups are answered from the snapshot
+	if available, version, _, err := checker.IsUpdateAvailable(ctx, checkout); err != nil || !available || version != "v5.0.0" {
+		t.Errorf("IsUpdateAvailable() = %v, %s, %v; want the recorded v5.0.0", available, version, err)
+	}
+	if hash, err := checker.GetCommitHash(ctx, checkout, "v3"); err != nil || hash != "3333333333333333333333333333333333333333" {
+		t.Errorf("GetCommitHash() = %s, %v", hash, err)
+	}
+	if inner.latestCalls != 0 || inner.hashCalls != 0 {
+		t.Errorf("recorded lookups reached the checker: %d latest, %d hash", inner.latestCalls, inner.hashCalls)
+	}
+
+	// Others are made and recorded for the next resume
+	tool := ActionReference{Owner: "octo", Name: "tool", Version: "v1"}
+	if version, _, err := checker.GetLatestVersion(ctx, tool); err != nil || version != "v4.0.0" {
+		t.Errorf("GetLatestVersion() = %s, %v", version, err)
+	}
+	if _, err := checker.GetCommitHash(ctx, checkout, "v2"); err != nil || inner.latestCalls != 1 || inner.hashCalls != 1 {
+		t.Errorf("lookups = %d latest, %d hash, %v; want 1 and 1", inner.latestCalls, inner.hashCalls, err)
+	}
+	if metadata := snapshot.lookup(tool); metadata == nil || metadata.Latest != "v4.0.0" {
+		t.Errorf("recorded octo/tool = %+v", metadata)
+	}
+}
+
+func TestRecordingVersionCheckerReleaseAge(t *testing.T) {
+	dir, _ := writeRunRepo(t)
+	now := time.Now()
+	checker := NewRecordingVersionChecker(&datedChecker{latestReleased: now.Add(-2 * day), commitDate: now.Add(-30 * day)}, NewMetadataSnapshot())
+
+	// The fresh v4.0.0 is still held back while its lookups are recorded
+	rep, err := Run(context.Background(), Options{RepoPath: dir, Mode: ModeDryRun, Checker: checker, MinReleaseAge: 7 * day})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(rep.Updates) != 0 {
+		t.Errorf("got %d updates, want the fresh release held back", len(rep.Updates))
+	}
+}
+
+// providingChecker serves dates, release notes, licenses and locations
+type providingChecker struct {
+	datedChecker
+}
+
+func (c *providingChecker) GetReleaseNotes(_ context.Context, action ActionReference, version string) (ReleaseNotes, error) {
+	return ReleaseNotes{Body: "notes of " + version, Public: true}, nil
+}
+
+func (c *providingChecker) GetLicense(_ context.Context, action ActionReference) (string, error) {
+	return "MIT", nil
+}
+
+func (c *providingChecker) GetRepositoryLocation(_ context.Context, action ActionReference) (string, error) {
+	return "new-org/" + action.Repo(), nil
+}
+
+func TestResumingVersionCheckerProviders(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	inner := &providingChecker{datedChecker{latestReleased: now.Add(-2 * day), commitDate: now.Add(-30 * day)}}
+	var checker VersionChecker = NewResumingVersionChecker(inner, NewMetadataSnapshot())
+
+	// -checkpoint keeps the release age gate
+	dir, _ := writeRunRepo(t)
+	rep, err := Run(ctx, Options{RepoPath: dir, Mode: ModeDryRun, Checker: checker, MinReleaseAge: 7 * day})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(rep.Updates) != 0 {
+		t.Errorf("got %d updates, want the fresh release held back", len(rep.Updates))
+	}
+
+	action := ActionReference{Owner: "octo", Name: "tool", Version: "v1"}
+	if dates, ok := checker.(ReleaseDateProvider); !ok {
+		t.Error("ResumingVersionChecker does not provide release dates")
+	} else if date, err := dates.GetReleaseDate(ctx, action, "v3"); err != nil || date.Year() != 2020 {
+		t.Errorf("GetReleaseDate() = %v, %v", date, err)
+	}
+	if dates, ok := checker.(CommitDateProvider); !ok {
+		t.Error("ResumingVersionChecker does not provide commit dates")
+	} else if date, err := dates.GetCommitDate(ctx, action, "abc"); err != nil || !date.Equal(inner.commitDate) {
+		t.Errorf("GetCommitDate() = %v, %v", date, err)
+	}
+	if provider, ok := checker.(ReleaseNotesProvider); !ok {
+		t.Error("ResumingVersionChecker does not provide release notes")
+	} else if notes, err := provider.GetReleaseNotes(ctx, action, "v4.0.0"); err != nil || notes.Body != "notes of v4.0.0" {
+		t.Errorf("GetReleaseNotes() = %+v, %v", notes, err)
+	}
+	if provider, ok := checker.(LicenseProvider); !ok {
+		t.Error("ResumingVersionChecker does not provide licenses")
+	} else if license, err := provider.GetLicense(ctx, action); err != nil || license != "MIT" {
+		t.Errorf("GetLicense() = %q, %v", license, err)
+	}
+	if detector, ok := checker.(MoveDetector); !ok {
+		t.Error("ResumingVersionChecker does not detect moves")
+	} else if location, err := detector.GetRepositoryLocation(ctx, action); err != nil || location != "new-org/tool" {
+		t.Errorf("GetRepositoryLocation() = %q, %v", location, err)
+	}
+}
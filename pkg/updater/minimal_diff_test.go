@@ -0,0 +1,112 @@
+package updater
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// usesLine is a randomly generated uses line and the line expected after
+// updating it, built from the same parts
+type usesLine struct {
+	content string
+	want    string
+}
+
+// genUsesLine builds a uses line from random spacing, quoting and comments
+func genUsesLine(r *rand.Rand, newHash string) usesLine {
+	pick := func(options ...string) string { return options[r.Intn(len(options))] }
+	return genUsesValue(r, pick("", "  ", "    ", "\t")+pick("", "- ")+"uses:"+pick(" ", "  ", "\t"), newHash)
+}
+
+// genUsesValue completes a uses line starting with prefix with a randomly
+// quoted reference and random comments
+func genUsesValue(r *rand.Rand, prefix, newHash string) usesLine {
+	pick := func(options ...string) string { return options[r.Intn(len(options))] }
+
+	quote := pick("", `"`, "'")
+	oldRef := "actions/checkout@" + pick("v3", "v3.1.0", "0123456789abcdef0123456789abcdef01234567")
+	newRef := "actions/checkout@" + newHash
+	gap := pick(" ", "  ", "   ", "\t")
+	userComment := pick("# keep me", "#no-space", "# see https://example.com/#anchor", "# v3 is required by X")
+	trailing := pick("", " ", "\t")
+
+	head := prefix + quote + oldRef + quote
+	wantHead := prefix + quote + newRef + quote
+	switch r.Intn(4) {
+	case 0: // no comment
+		want := wantHead + trailing + "# v4"
+		if trailing == "" {
+			want = wantHead + "  # v4"
+		}
+		return usesLine{content: head + trailing, want: want}
+	case 1: // version comment only
+		return usesLine{content: head + gap + "# v3" + trailing, want: wantHead + gap + "# v4" + trailing}
+	case 2: // version comment followed by a user comment
+		sep := pick(" ", "  ", "\t")
+		return usesLine{
+			content: head + gap + "# v3" + sep + userComment + trailing,
+			want:    wantHead + gap + "# v4" + sep + userComment + trailing,
+		}
+	default: // user comment only
+		return usesLine{
+			content: head + gap + userComment + trailing,
+			want:    wantHead + gap + "# v4  " + userComment + trailing,
+		}
+	}
+}
+
+// TestMinimalDiffProperty asserts that updating a line changes nothing but
+// the reference and the managed version comment, through both the YAML
+// rewriter and the line-based fallback
+func TestMinimalDiffProperty(t *testing.T) {
+	const newHash = "1111111111111111111111111111111111111111"
+	r := rand.New(rand.NewSource(1))
+	action := ActionReference{Owner: "actions", Name: "checkout"}
+
+	for i := 0; i < 500; i++ {
+		line := genUsesLine(r, newHash)
+		update := &Update{Action: action, LineNumber: 2, OldVersion: "v3", NewVersion: "v4", NewHash: newHash, VersionComment: "# v4"}
+
+		// YAML rewriter, with untouched lines around the updated one
+		content := "steps:\n" + line.content + "\n# unrelated   comment \n"
+		got, remaining := rewriteUses(content, []*Update{update})
+		if len(remaining) == 0 {
+			want := strings.Replace(content, line.content, line.want, 1)
+			if got != want {
+				t.Fatalf("rewriteUses(%q) =\n%q\nwant\n%q", line.content, got, want)
+			}
+		}
+
+		// Line-based fallback
+		edit, ok := locateUsesRef(line.content, update)
+		if !ok {
+			t.Fatalf("locateUsesRef(%q) found no reference", line.content)
+		}
+		if got := applyScalarEdit(line.content, edit); got != line.want {
+			t.Fatalf("applyScalarEdit(%q) =\n%q\nwant\n%q", line.content, got, line.want)
+		}
+	}
+}
+
+// TestMinimalDiffIdempotent asserts that a second update of an updated line
+// only changes the reference and version again, so comments never pile up
+func TestMinimalDiffIdempotent(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	action := ActionReference{Owner: "actions", Name: "checkout"}
+
+	for i := 0; i < 200; i++ {
+		line := genUsesLine(r, "1111111111111111111111111111111111111111")
+		update := &Update{Action: action, NewVersion: "v5", NewHash: "2222222222222222222222222222222222222222", VersionComment: "# v5"}
+
+		edit, ok := locateUsesRef(line.want, update)
+		if !ok {
+			t.Fatalf("locateUsesRef(%q) found no reference", line.want)
+		}
+		got := applyScalarEdit(line.want, edit)
+		want := strings.Replace(strings.Replace(line.want, "1111111111111111111111111111111111111111", "2222222222222222222222222222222222222222", 1), "# v4", "# v5", 1)
+		if got != want {
+			t.Fatalf("applyScalarEdit(%q) =\n%q\nwant\n%q", line.want, got, want)
+		}
+	}
+}
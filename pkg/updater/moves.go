@@ -0,0 +1,145 @@
+package updater
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+)
+
+// MoveDetector is implemented by version checkers that can tell where the
+// repository of an action lives now
+type MoveDetector interface {
+	// GetRepositoryLocation returns the current owner/repo of the action's
+	// repository, which differs from the one written in workflows when the
+	// repository was renamed or transferred
+	GetRepositoryLocation(ctx context.Context, action ActionReference) (string, error)
+}
+
+// GetRepositoryLocation implements MoveDetector. The API redirects requests
+// for a moved repository to its new location, whose name it returns.
+func (c *DefaultVersionChecker) GetRepositoryLocation(ctx context.Context, action ActionReference) (string, error) {
+	repo, _, err := c.clientFor(action).Repositories.Get(ctx, action.Owner, action.Repo())
+	if err != nil {
+		return "", fmt.Errorf(common.ErrGettingRepositoryLocation, action.Owner+"/"+action.Repo(), c.accessError(ctx, action, err))
+	}
+	return repo.GetFullName(), nil
+}
+
+// GetRepositoryLocation implements MoveDetector when the wrapped checker does
+func (c *CachingVersionChecker) GetRepositoryLocation(ctx context.Context, action ActionReference) (string, error) {
+	detector, ok := c.checker.(MoveDetector)
+	if !ok {
+		return "", fmt.Errorf(common.ErrGettingRepositoryLocation, action.Owner+"/"+action.Repo(), fmt.Errorf("not supported"))
+	}
+	return detector.GetRepositoryLocation(ctx, action)
+}
+
+// GetRepositoryLocation implements MoveDetector when the wrapped checker does
+func (c *RetryingVersionChecker) GetRepositoryLocation(ctx context.Context, action ActionReference) (string, error) {
+	detector, ok := c.checker.(MoveDetector)
+	if !ok {
+		return "", fmt.Errorf(common.ErrGettingRepositoryLocation, action.Owner+"/"+action.Repo(), fmt.Errorf("not supported"))
+	}
+	var location string
+	err := c.policy.Do(ctx, func() error {
+		var err error
+		location, err = detector.GetRepositoryLocation(ctx, action)
+		return err
+	})
+	return location, err
+}
+
+// GetRepositoryLocation implements MoveDetector when the wrapped checker does
+func (c *RecordingVersionChecker) GetRepositoryLocation(ctx context.Context, action ActionReference) (string, error) {
+	detector, ok := c.checker.(MoveDetector)
+	if !ok {
+		return "", fmt.Errorf(common.ErrGettingRepositoryLocation, action.Owner+"/"+action.Repo(), fmt.Errorf("not supported"))
+	}
+	return detector.GetRepositoryLocation(ctx, action)
+}
+
+// NewFullName returns the action name the update writes: the new location
+// of a moved action, or the name as written
+func (u *Update) NewFullName() string {
+	if u.MovedTo != "" {
+		return u.MovedTo
+	}
+	return u.Action.FullName()
+}
+
+// movedName returns the full name of ref at the repository location
+// reported by a MoveDetector, or "" when the repository has not moved
+func movedName(ref ActionReference, location string) string {
+	owner, repo, ok := strings.Cut(location, "/")
+	if !ok || strings.EqualFold(location, ref.Owner+"/"+ref.Repo()) {
+		return ""
+	}
+	moved := ref
+	moved.Owner, moved.Name = owner, repo
+	if subpath := ref.Subpath(); subpath != "" {
+		moved.Name += "/" + subpath
+	}
+	return moved.FullName()
+}
+
+// detectMove looks up whether the repository of ref moved, once per
+// repository, with Options.DetectMoves. References written through templates and GitLab includes are
+// not renamed.
+func detectMove(ctx context.Context, opts Options, ref ActionReference, moves map[string]string) string {
+	detector, ok := opts.Checker.(MoveDetector)
+	if !opts.DetectMoves || !ok || ref.TemplateSource || ref.GitLabInclude {
+		return ""
+	}
+	key := strings.ToLower(ref.Repository())
+	if moved, ok := moves[key]; ok {
+		return movedName(ref, moved)
+	}
+	location, err := detector.GetRepositoryLocation(ctx, ref)
+	if err != nil {
+		log.Printf(common.ErrDetectingMove, ref.Repository(), err)
+	}
+	moves[key] = location
+	if moved := movedName(ref, location); moved != "" {
+		log.Printf(common.ErrActionMoved, ref.Repository(), location)
+		return moved
+	}
+	return ""
+}
+
+// moveUpdate creates the update renaming a reference whose version is kept,
+// because it is current or its update is held back, to its moved location.
+// Unpinned references are pinned to the commit of their version.
+func moveUpdate(ctx context.Context, opts Options, use referenceUse, movedTo string) (*Update, error) {
+	ref := use.ref
+	hash := ref.CommitHash
+	if hash == "" {
+		var err error
+		if hash, err = opts.Checker.GetCommitHash(ctx, ref, ref.Version); err != nil {
+			return nil, err
+		}
+	}
+	original := ref.Version
+	if ref.CommitHash != "" {
+		original = ref.CommitHash
+	}
+	comment := ""
+	if _, ok := ParseVersionComment(ref.VersionComment); ok && ref.CommitHash != "" {
+		comment = ref.VersionComment
+	}
+	return &Update{
+		Action:          ref,
+		OldVersion:      ref.Version,
+		NewVersion:      ref.Version,
+		OldHash:         ref.CommitHash,
+		NewHash:         hash,
+		FilePath:        use.file,
+		LineNumber:      ref.Line,
+		VersionComment:  comment,
+		OriginalVersion: original,
+		Description:     fmt.Sprintf("Rename %s to %s (repository moved)", ref.FullName(), movedTo),
+		MovedTo:         movedTo,
+	}, nil
+}
@@ -0,0 +1,126 @@
+package updater
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v72/github"
+)
+
+// movingChecker reports the repositories in moved at their new location
+type movingChecker struct {
+	countingChecker
+	moved   map[string]string
+	lookups int
+}
+
+func (c *movingChecker) GetRepositoryLocation(ctx context.Context, action ActionReference) (string, error) {
+	c.lookups++
+	if location, ok := c.moved[action.Repository()]; ok {
+		return location, nil
+	}
+	return action.Repository(), nil
+}
+
+func TestGetRepositoryLocation(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/old/tool", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/repositories/42", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/repositories/42", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id":42,"full_name":"new-org/tool"}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	checker := NewCachingVersionChecker(&DefaultVersionChecker{client: client}, nil, 0)
+
+	location, err := checker.GetRepositoryLocation(context.Background(), ActionReference{Owner: "old", Name: "tool/sub"})
+	if err != nil || location != "new-org/tool" {
+		t.Errorf("GetRepositoryLocation() = %q, %v; want new-org/tool", location, err)
+	}
+	if _, err := checker.GetRepositoryLocation(context.Background(), ActionReference{Owner: "old", Name: "missing"}); err == nil {
+		t.Error("GetRepositoryLocation() expected error for missing repository")
+	}
+}
+
+func TestMovedName(t *testing.T) {
+	tests := []struct {
+		name     string
+		ref      ActionReference
+		location string
+		want     string
+	}{
+		{name: "moved", ref: ActionReference{Owner: "old", Name: "tool"}, location: "new-org/tool", want: "new-org/tool"},
+		{name: "subpath", ref: ActionReference{Owner: "old", Name: "tool/setup"}, location: "new-org/new-tool", want: "new-org/new-tool/setup"},
+		{name: "case only", ref: ActionReference{Owner: "Octo", Name: "Tool"}, location: "octo/tool"},
+		{name: "not moved", ref: ActionReference{Owner: "octo", Name: "tool"}, location: "octo/tool"},
+		{name: "lookup failed", ref: ActionReference{Owner: "octo", Name: "tool"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := movedName(tt.ref, tt.location); got != tt.want {
+				t.Errorf("movedName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunDetectMoves(t *testing.T) {
+	const workflow = `on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v3
+      - uses: octo/tool@v1
+      - uses: octo/tool/setup@1111111111111111111111111111111111111111 # v4.0.0
+`
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".github", "workflows", "ci.yml")
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(workflow), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	checker := &movingChecker{moved: map[string]string{"octo/tool": "new-org/tool"}}
+	creator := &capturingPRCreator{}
+	// Moves are detected through the -metadata wrapper as well
+	recorder := NewRecordingVersionChecker(checker, NewMetadataSnapshot())
+	rep, err := Run(context.Background(), Options{RepoPath: dir, Mode: ModeStage, Checker: recorder, Creator: creator, DetectMoves: true})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if checker.lookups != 2 {
+		t.Errorf("location lookups = %d, want one per repository", checker.lookups)
+	}
+	if len(rep.Updates) != 3 {
+		t.Fatalf("Run() updates = %d, want 3", len(rep.Updates))
+	}
+
+	content, _ := os.ReadFile(path)
+	for _, want := range []string{
+		"actions/checkout@1111111111111111111111111111111111111111",
+		"new-org/tool@1111111111111111111111111111111111111111",
+		// The current pin is only renamed
+		"new-org/tool/setup@1111111111111111111111111111111111111111 # v4.0.0",
+	} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("workflow does not contain %s:\n%s", want, content)
+		}
+	}
+	if body := prBody(rep.Updates); strings.Count(body, "Renamed to `new-org/tool") != 2 {
+		t.Errorf("prBody() does not mark the renamed actions:\n%s", body)
+	}
+}
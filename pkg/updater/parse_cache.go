@@ -0,0 +1,71 @@
+package updater
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/storage"
+)
+
+// parseCacheVersion is part of the keys of cached parse results; bump it
+// whenever the scanner changes what it extracts from a workflow
+const parseCacheVersion = 1
+
+// SetParseCache keeps the action references parsed from each workflow in
+// store, keyed by a hash of the file's content, so later scans only parse
+// the files that changed. nil disables the cache.
+func (s *Scanner) SetParseCache(store storage.Store) {
+	s.parseCache = store
+}
+
+// parseCacheKey returns the store key of the references parsed from content
+func parseCacheKey(content []byte) string {
+	sum := sha256.Sum256(content)
+	return fmt.Sprintf("%s/parsed/v%d/%s", cacheKeyPrefix, parseCacheVersion, hex.EncodeToString(sum[:]))
+}
+
+// cachedReferences returns the references cached for content, read from the
+// file at path. Store errors are treated as misses.
+func (s *Scanner) cachedReferences(content []byte, path string) ([]ActionReference, bool) {
+	if s.parseCache == nil {
+		return nil, false
+	}
+	key := parseCacheKey(content)
+	data, err := s.parseCache.Get(context.Background(), key)
+	if err != nil {
+		if !errors.Is(err, storage.ErrNotFound) {
+			log.Printf("Warning: cache read failed for %s: %v", key, err)
+		}
+		return nil, false
+	}
+	var refs []ActionReference
+	if err := json.Unmarshal(data, &refs); err != nil {
+		return nil, false
+	}
+	// Files with the same content share an entry
+	for i := range refs {
+		refs[i].Path = path
+	}
+	return refs, true
+}
+
+// cacheReferences stores the references parsed from content. Failures are
+// logged since the cache is best-effort.
+func (s *Scanner) cacheReferences(content []byte, refs []ActionReference) {
+	if s.parseCache == nil {
+		return
+	}
+	data, err := json.Marshal(refs)
+	if err != nil {
+		return
+	}
+	key := parseCacheKey(content)
+	if err := s.parseCache.Put(context.Background(), key, data); err != nil {
+		log.Printf("Warning: cache write failed for %s: %v", key, err)
+	}
+}
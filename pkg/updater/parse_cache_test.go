@@ -0,0 +1,75 @@
+package updater
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/storage"
+)
+
+func TestParseCache(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	const workflow = `on: push
+jobs:
+  build:
+    strategy:
+      matrix:
+        checkout: ["actions/checkout@v3"]
+    runs-on: ubuntu-latest
+    steps:
+      # Pinned for reproducibility
+      - uses: actions/setup-go@0123456789abcdef0123456789abcdef01234567 # v5.0.0
+      - uses: ${{ matrix.checkout }}
+      - uses: ./.github/actions/build
+`
+	ci := write("ci.yml", workflow)
+	want, err := NewScanner(dir).ParseActionReferences(ci)
+	if err != nil {
+		t.Fatalf("ParseActionReferences() error = %v", err)
+	}
+
+	store := storage.NewMemoryStore()
+	scanner := NewScanner(dir)
+	scanner.SetParseCache(store)
+	for i := 0; i < 2; i++ {
+		got, err := scanner.ParseActionReferences(ci)
+		if err != nil {
+			t.Fatalf("ParseActionReferences() error = %v", err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("ParseActionReferences() run %d = %+v, want %+v", i, got, want)
+		}
+	}
+	keys, _ := store.List(ctx, "cache/parsed/")
+	if len(keys) != 1 || keys[0] != parseCacheKey([]byte(workflow)) {
+		t.Fatalf("cached keys = %v, want one entry keyed by content", keys)
+	}
+
+	// Unchanged content is answered from the cache, under its own path
+	if err := store.Put(ctx, keys[0], []byte(`[{"Owner":"octo","Name":"cached","Version":"v1","Path":"elsewhere.yml","Line":3}]`)); err != nil {
+		t.Fatal(err)
+	}
+	copied := write("copy.yml", workflow)
+	got, err := scanner.ParseActionReferences(copied)
+	if err != nil || len(got) != 1 || got[0].Name != "cached" || got[0].Path != copied {
+		t.Errorf("ParseActionReferences() of unchanged content = %+v, %v; want the cached entry", got, err)
+	}
+
+	// Changed content is parsed again
+	changed := write("ci.yml", workflow+"      - uses: octo/tool@v1\n")
+	got, err = scanner.ParseActionReferences(changed)
+	if err != nil || len(got) != len(want)+1 {
+		t.Errorf("ParseActionReferences() of changed content = %+v, %v", got, err)
+	}
+}
@@ -0,0 +1,179 @@
+package updater
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+)
+
+// patchContext is the number of unchanged lines around each hunk
+const patchContext = 3
+
+// FileChange is the contents of a file before and after its updates
+type FileChange struct {
+	Path string // Absolute path of the file
+	Old  string
+	New  string
+}
+
+// UpdatePreviewer is implemented by update managers that can render updates
+// without applying them
+type UpdatePreviewer interface {
+	PreviewUpdates(ctx context.Context, updates []*Update) ([]FileChange, error)
+}
+
+// WritePatch renders updates with manager and writes them to w as a patch
+// that git apply accepts in repoPath. It returns the number of files in the
+// patch.
+func WritePatch(ctx context.Context, w io.Writer, manager UpdateManager, repoPath string, updates []*Update) (int, error) {
+	previewer, ok := manager.(UpdatePreviewer)
+	if !ok {
+		return 0, fmt.Errorf(common.ErrWritingPatch, fmt.Errorf("the update manager cannot preview updates"))
+	}
+	changes, err := previewer.PreviewUpdates(ctx, updates)
+	if err != nil {
+		return 0, fmt.Errorf(common.ErrWritingPatch, err)
+	}
+	for _, change := range changes {
+		name := change.Path
+		if rel, err := filepath.Rel(repoPath, change.Path); err == nil {
+			name = filepath.ToSlash(rel)
+		}
+		if _, err := io.WriteString(w, unifiedDiff(name, change.Old, change.New)); err != nil {
+			return 0, fmt.Errorf(common.ErrWritingPatch, err)
+		}
+	}
+	return len(changes), nil
+}
+
+// diffOp is a line of a diff: ' ' kept, '-' removed or '+' added
+type diffOp struct {
+	kind     byte
+	line     string // Including its line ending, if any
+	old, new int    // Lines of the old and new file before this one
+}
+
+// unifiedDiff returns the git diff of a file from old to new, or "" when
+// they are equal
+func unifiedDiff(name, old, new string) string {
+	ops := diffLines(splitLines(old), splitLines(new))
+	var sb strings.Builder
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+		// Hunks closer than twice the context are merged
+		start, last := max(0, i-patchContext), i
+		for j := i; j < len(ops) && j-last <= 2*patchContext; j++ {
+			if ops[j].kind != ' ' {
+				last = j
+			}
+		}
+		stop := min(len(ops), last+patchContext+1)
+
+		if sb.Len() == 0 {
+			fmt.Fprintf(&sb, "diff --git a/%s b/%s\n--- a/%s\n+++ b/%s\n", name, name, name, name)
+		}
+		writeHunk(&sb, ops[start:stop])
+		i = stop
+	}
+	return sb.String()
+}
+
+// writeHunk writes the header and lines of a hunk
+func writeHunk(sb *strings.Builder, ops []diffOp) {
+	oldCount, newCount := 0, 0
+	for _, op := range ops {
+		if op.kind != '+' {
+			oldCount++
+		}
+		if op.kind != '-' {
+			newCount++
+		}
+	}
+	// Empty ranges name the line before them
+	oldStart, newStart := ops[0].old, ops[0].new
+	if oldCount > 0 {
+		oldStart++
+	}
+	if newCount > 0 {
+		newStart++
+	}
+	fmt.Fprintf(sb, "@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount)
+	for _, op := range ops {
+		sb.WriteByte(op.kind)
+		sb.WriteString(op.line)
+		if !strings.HasSuffix(op.line, "\n") {
+			sb.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+// splitLines splits s after each newline
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines returns the edit script from a to b. The common prefix and
+// suffix are skipped before the longest common subsequence of the rest is
+// computed, which keeps the table small for the few lines updates change.
+func diffLines(a, b []string) []diffOp {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	midA, midB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+
+	// lcs[i][j] is the length of the longest common subsequence of
+	// midA[i:] and midB[j:]
+	lcs := make([][]int, len(midA)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(midB)+1)
+	}
+	for i := len(midA) - 1; i >= 0; i-- {
+		for j := len(midB) - 1; j >= 0; j-- {
+			if midA[i] == midB[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	ops := make([]diffOp, 0, len(a)+len(b)-prefix-suffix)
+	for i := 0; i < prefix; i++ {
+		ops = append(ops, diffOp{kind: ' ', line: a[i], old: i, new: i})
+	}
+	i, j := 0, 0
+	for i < len(midA) || j < len(midB) {
+		switch {
+		case i < len(midA) && j < len(midB) && midA[i] == midB[j]:
+			ops = append(ops, diffOp{kind: ' ', line: midA[i], old: prefix + i, new: prefix + j})
+			i++
+			j++
+		case j == len(midB) || (i < len(midA) && lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, diffOp{kind: '-', line: midA[i], old: prefix + i, new: prefix + j})
+			i++
+		default:
+			ops = append(ops, diffOp{kind: '+', line: midB[j], old: prefix + i, new: prefix + j})
+			j++
+		}
+	}
+	for k := 0; k < suffix; k++ {
+		ops = append(ops, diffOp{kind: ' ', line: a[len(a)-suffix+k], old: len(a) - suffix + k, new: len(b) - suffix + k})
+	}
+	return ops
+}
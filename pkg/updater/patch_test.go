@@ -0,0 +1,94 @@
+package updater
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	var long []string
+	for i := 1; i <= 20; i++ {
+		long = append(long, "line "+strings.Repeat("x", i%3))
+	}
+	longOld := strings.Join(long, "\n") + "\n"
+	long[1], long[18] = "changed 2", "changed 19"
+	longNew := strings.Join(long, "\n") + "\n"
+
+	tests := []struct {
+		name     string
+		old, new string
+		want     string
+	}{
+		{name: "unchanged", old: "a\nb\n", new: "a\nb\n", want: ""},
+		{
+			name: "changed line",
+			old:  "a\nb\nc\nd\ne\nf\n",
+			new:  "a\nb\nc\nD\ne\nf\n",
+			want: "diff --git a/ci.yml b/ci.yml\n--- a/ci.yml\n+++ b/ci.yml\n@@ -1,6 +1,6 @@\n a\n b\n c\n-d\n+D\n e\n f\n",
+		},
+		{
+			name: "no newline at end of file",
+			old:  "a\nb",
+			new:  "a\nB",
+			want: "diff --git a/ci.yml b/ci.yml\n--- a/ci.yml\n+++ b/ci.yml\n@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+B\n\\ No newline at end of file\n",
+		},
+		{
+			name: "added lines",
+			old:  "",
+			new:  "a\n",
+			want: "diff --git a/ci.yml b/ci.yml\n--- a/ci.yml\n+++ b/ci.yml\n@@ -0,0 +1,1 @@\n+a\n",
+		},
+		{
+			name: "separate hunks",
+			old:  longOld,
+			new:  longNew,
+			want: "diff --git a/ci.yml b/ci.yml\n--- a/ci.yml\n+++ b/ci.yml\n" +
+				"@@ -1,5 +1,5 @@\n line x\n-line xx\n+changed 2\n line \n line x\n line xx\n" +
+				"@@ -16,5 +16,5 @@\n line x\n line xx\n line \n-line x\n+changed 19\n line xx\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := unifiedDiff("ci.yml", tt.old, tt.new); got != tt.want {
+				t.Errorf("unifiedDiff() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWritePatch(t *testing.T) {
+	dir, workflow := writeRunRepo(t)
+	rep, err := Run(context.Background(), Options{RepoPath: dir, Mode: ModeDryRun, Checker: &countingChecker{}})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	var patch strings.Builder
+	files, err := WritePatch(context.Background(), &patch, NewUpdateManager(dir), dir, rep.Updates)
+	if err != nil {
+		t.Fatalf("WritePatch() error = %v", err)
+	}
+	if files != 1 {
+		t.Errorf("WritePatch() files = %d, want 1", files)
+	}
+	for _, want := range []string{
+		"--- a/.github/workflows/ci.yml\n+++ b/.github/workflows/ci.yml\n",
+		"-      - uses: actions/checkout@v3\n+      - uses: actions/checkout@1111111111111111111111111111111111111111  # v4.0.0\n",
+		"-      - uses: octo/tool@v1\n+      - uses: octo/tool@1111111111111111111111111111111111111111  # v4.0.0\n",
+	} {
+		if !strings.Contains(patch.String(), want) {
+			t.Errorf("patch missing %q:\n%s", want, patch.String())
+		}
+	}
+
+	// The workflow is left as it was
+	content, err := os.ReadFile(workflow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != runWorkflow {
+		t.Errorf("WritePatch() changed the workflow:\n%s", content)
+	}
+}
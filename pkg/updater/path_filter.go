@@ -0,0 +1,109 @@
+package updater
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+)
+
+// PathFilter selects the files a scan reads by glob patterns matched
+// against paths relative to the scanned directory, e.g. "ci/build.yml".
+// A file is read when it matches Include (or Include is empty) and does not
+// match Exclude.
+//
+// Within each list the last matching pattern wins and a leading "!"
+// negates a pattern, so "*.yaml,!experimental/**" matches YAML files
+// outside experimental/. "*" matches within a path segment and "**" any
+// number of segments; patterns without a slash match the file name in any
+// directory.
+type PathFilter struct {
+	Include []string
+	Exclude []string
+}
+
+// ParsePathFilter parses comma-separated include and exclude patterns
+func ParsePathFilter(include, exclude string) (PathFilter, error) {
+	var filter PathFilter
+	var err error
+	if filter.Include, err = parsePatterns(include); err != nil {
+		return PathFilter{}, err
+	}
+	if filter.Exclude, err = parsePatterns(exclude); err != nil {
+		return PathFilter{}, err
+	}
+	return filter, nil
+}
+
+// parsePatterns splits a comma-separated pattern list and checks its syntax
+func parsePatterns(list string) ([]string, error) {
+	var patterns []string
+	for _, pattern := range strings.Split(list, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		for _, segment := range strings.Split(strings.TrimPrefix(pattern, "!"), "/") {
+			if _, err := path.Match(segment, ""); err != nil {
+				return nil, fmt.Errorf(common.ErrInvalidPathPattern, pattern, err)
+			}
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, nil
+}
+
+// Match reports whether the file at rel, relative to the scanned directory,
+// is selected
+func (f PathFilter) Match(rel string) bool {
+	rel = strings.TrimPrefix(path.Clean(strings.ReplaceAll(rel, "\\", "/")), "./")
+	if len(f.Include) > 0 && !matchPatterns(f.Include, rel) {
+		return false
+	}
+	return !matchPatterns(f.Exclude, rel)
+}
+
+// IsZero reports whether the filter selects every file
+func (f PathFilter) IsZero() bool {
+	return len(f.Include) == 0 && len(f.Exclude) == 0
+}
+
+// matchPatterns applies patterns in order; the last one matching rel decides
+func matchPatterns(patterns []string, rel string) bool {
+	matched := false
+	for _, pattern := range patterns {
+		negated := strings.HasPrefix(pattern, "!")
+		pattern = strings.TrimPrefix(pattern, "!")
+		if !strings.Contains(pattern, "/") {
+			pattern = "**/" + pattern
+		}
+		if matchGlob(strings.Split(pattern, "/"), strings.Split(rel, "/")) {
+			matched = !negated
+		}
+	}
+	return matched
+}
+
+// matchGlob matches path segments against pattern segments, where "**"
+// matches any number of segments
+func matchGlob(pattern, segments []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(segments); i++ {
+				if matchGlob(pattern[1:], segments[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(segments) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], segments[0]); !ok {
+			return false
+		}
+		pattern, segments = pattern[1:], segments[1:]
+	}
+	return len(segments) == 0
+}
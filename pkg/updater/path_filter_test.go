@@ -0,0 +1,75 @@
+package updater
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPathFilterMatch(t *testing.T) {
+	tests := []struct {
+		name             string
+		include, exclude string
+		path             string
+		want             bool
+	}{
+		{name: "no patterns", path: "ci.yml", want: true},
+		{name: "include by extension", include: "*.yaml", path: "ci.yml", want: false},
+		{name: "extension in subdirectory", include: "*.yaml", path: "team/ci.yaml", want: true},
+		{name: "negated include", include: "*.yaml,!experimental/**", path: "experimental/a/ci.yaml", want: false},
+		{name: "negated include elsewhere", include: "*.yaml,!experimental/**", path: "stable/ci.yaml", want: true},
+		{name: "exclude directory", exclude: "generated/**", path: "generated/x/ci.yml", want: false},
+		{name: "exclude only nested", exclude: "generated/**", path: "ci.yml", want: true},
+		{name: "re-included", exclude: "generated/**,!generated/keep.yml", path: "generated/keep.yml", want: true},
+		{name: "last pattern wins", exclude: "!generated/keep.yml,generated/**", path: "generated/keep.yml", want: false},
+		{name: "double star in the middle", include: "teams/**/deploy-*.yml", path: "teams/a/b/deploy-prod.yml", want: true},
+		{name: "name in any directory", include: "ci.yml", path: "nested/ci.yml", want: true},
+		{name: "path from the scanned directory", include: "team/ci.yml", path: "other/team/ci.yml", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := ParsePathFilter(tt.include, tt.exclude)
+			if err != nil {
+				t.Fatalf("ParsePathFilter() error = %v", err)
+			}
+			if got := filter.Match(tt.path); got != tt.want {
+				t.Errorf("Match(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := ParsePathFilter("[", ""); err == nil || !strings.Contains(err.Error(), `invalid path pattern "["`) {
+		t.Errorf("ParsePathFilter([) error = %v", err)
+	}
+}
+
+func TestScanWorkflowsPathFilter(t *testing.T) {
+	dir := t.TempDir()
+	workflows := filepath.Join(dir, ".github", "workflows")
+	for _, name := range []string{"b.yml", "a.yaml", "experimental/c.yaml", "team/d.yaml"} {
+		path := filepath.Join(workflows, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("on: push\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	scanner := NewScanner(dir)
+	filter, _ := ParsePathFilter("*.yaml,!experimental/**", "")
+	scanner.SetPathFilter(filter)
+	files, err := scanner.ScanWorkflows(workflows)
+	if err != nil {
+		t.Fatalf("ScanWorkflows() error = %v", err)
+	}
+	var got []string
+	for _, file := range files {
+		rel, _ := filepath.Rel(workflows, file)
+		got = append(got, filepath.ToSlash(rel))
+	}
+	if strings.Join(got, ",") != "a.yaml,team/d.yaml" {
+		t.Errorf("ScanWorkflows() = %v, want [a.yaml team/d.yaml]", got)
+	}
+}
@@ -0,0 +1,139 @@
+package updater
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+	"gopkg.in/yaml.v3"
+)
+
+// Issues of a PermissionFinding
+const (
+	PermissionsWriteAll = "write-all" // The job's token may write everything
+	PermissionsMissing  = "missing"   // Neither the workflow nor the job sets permissions
+)
+
+// firstPartyOwners publish the actions maintained by GitHub itself
+var firstPartyOwners = map[string]bool{"actions": true, "github": true}
+
+// PermissionFinding is a job that hands a token with broad permissions to
+// third-party actions
+type PermissionFinding struct {
+	Path    string   `json:"path"`
+	Line    int      `json:"line"`
+	Job     string   `json:"job"`
+	Issue   string   `json:"issue"`   // PermissionsWriteAll or PermissionsMissing
+	Actions []string `json:"actions"` // Third-party actions and reusable workflows the job uses
+}
+
+// Message describes the finding
+func (f PermissionFinding) Message() string {
+	actions := strings.Join(f.Actions, ", ")
+	if f.Issue == PermissionsWriteAll {
+		return fmt.Sprintf("job %q grants write-all permissions to third-party actions: %s", f.Job, actions)
+	}
+	return fmt.Sprintf("job %q sets no permissions, so third-party actions get the default token permissions: %s", f.Job, actions)
+}
+
+// ScanPermissions lists the jobs of a workflow that use third-party actions
+// with write-all permissions, or without permissions at any level, which
+// leaves the token with the repository's default permissions. Actions of
+// the actions and github organizations and local actions are trusted.
+func (s *Scanner) ScanPermissions(path string) ([]PermissionFinding, error) {
+	if err := s.validatePath(path); err != nil {
+		return nil, fmt.Errorf(common.ErrInvalidFilePath, err)
+	}
+	content, err := common.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf(common.ErrReadingWorkflowFile, err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, fmt.Errorf(common.ErrParsingWorkflowYAML, err)
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+
+	root := doc.Content[0]
+	jobs := mappingValue(root, "jobs")
+	if jobs == nil || jobs.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	workflowPermissions := mappingValue(root, "permissions")
+
+	var findings []PermissionFinding
+	for i := 0; i+1 < len(jobs.Content); i += 2 {
+		name, job := jobs.Content[i], resolveAlias(jobs.Content[i+1])
+		actions := thirdPartyActions(job)
+		if len(actions) == 0 {
+			continue
+		}
+		permissions := mappingValue(job, "permissions")
+		if permissions == nil {
+			permissions = workflowPermissions
+		}
+		finding := PermissionFinding{Path: path, Line: name.Line, Job: name.Value, Actions: actions}
+		switch {
+		case permissions == nil:
+			finding.Issue = PermissionsMissing
+		case permissions.Kind == yaml.ScalarNode && permissions.Value == PermissionsWriteAll:
+			finding.Issue, finding.Line = PermissionsWriteAll, permissions.Line
+		default:
+			continue
+		}
+		findings = append(findings, finding)
+	}
+	return findings, nil
+}
+
+// thirdPartyActions returns the third-party actions used by the steps of a
+// job, or the reusable workflow it calls
+func thirdPartyActions(job *yaml.Node) []string {
+	uses := scalarItems(mappingValue(job, "uses"))
+	for _, step := range sequenceItems(mappingValue(job, "steps")) {
+		uses = append(uses, scalarItems(mappingValue(step, "uses"))...)
+	}
+
+	seen := make(map[string]bool)
+	var actions []string
+	for _, node := range uses {
+		action, _, _ := strings.Cut(node.Value, "@")
+		if action == "" || strings.HasPrefix(action, "./") || seen[action] {
+			continue
+		}
+		if !strings.HasPrefix(action, "docker://") {
+			parts := strings.Split(action, "/")
+			// Actions on another host are written as host/owner/repo
+			if len(parts) >= 3 && strings.ContainsAny(parts[0], ".:") {
+				parts = parts[1:]
+			}
+			if firstPartyOwners[strings.ToLower(parts[0])] {
+				continue
+			}
+		}
+		seen[action] = true
+		actions = append(actions, action)
+	}
+	sort.Strings(actions)
+	return actions
+}
+
+// checkPermissions adds the permission findings of a workflow file to
+// report. Files that are not YAML are left to the parse failure reported
+// for them.
+func checkPermissions(scanner *Scanner, file string, report *Report) {
+	findings, err := scanner.ScanPermissions(file)
+	if err != nil {
+		log.Printf(common.ErrCheckingPermissions, file, err)
+		return
+	}
+	for _, finding := range findings {
+		log.Printf(common.ErrExcessivePermissions, file, finding.Line, finding.Message())
+	}
+	report.Permissions = append(report.Permissions, findings...)
+}
@@ -0,0 +1,109 @@
+package updater
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestScanPermissions(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []PermissionFinding
+	}{
+		{
+			name: "no permissions",
+			content: `on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+      - uses: octo/deploy@v1
+      - uses: ./.github/actions/local
+      - uses: docker://alpine:3
+      - uses: octo/deploy@v1
+  lint:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: github/codeql-action/init@v3
+      - run: make lint
+`,
+			want: []PermissionFinding{{Line: 3, Job: "build", Issue: PermissionsMissing, Actions: []string{"docker://alpine:3", "octo/deploy"}}},
+		},
+		{
+			name: "write-all",
+			content: `on: push
+permissions: write-all
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: ghe.example.com/octo/tool@v2
+  restricted:
+    permissions:
+      contents: read
+    runs-on: ubuntu-latest
+    steps:
+      - uses: octo/tool@v2
+  release:
+    permissions: write-all
+    uses: octo/workflows/.github/workflows/release.yml@v1
+`,
+			want: []PermissionFinding{
+				{Line: 2, Job: "build", Issue: PermissionsWriteAll, Actions: []string{"ghe.example.com/octo/tool"}},
+				{Line: 15, Job: "release", Issue: PermissionsWriteAll, Actions: []string{"octo/workflows/.github/workflows/release.yml"}},
+			},
+		},
+		{
+			name: "read permissions",
+			content: `on: push
+permissions:
+  contents: read
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: octo/tool@v2
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "ci.yml")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			for i := range tt.want {
+				tt.want[i].Path = path
+			}
+
+			got, err := NewScanner(dir).ScanPermissions(path)
+			if err != nil {
+				t.Fatalf("ScanPermissions() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ScanPermissions() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunCheckPermissions(t *testing.T) {
+	dir, workflow := writeRunRepo(t)
+	rep, err := Run(context.Background(), Options{RepoPath: dir, Mode: ModeDryRun, Checker: &countingChecker{}, CheckPermissions: true})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	want := []PermissionFinding{{Path: workflow, Line: 3, Job: "build", Issue: PermissionsMissing, Actions: []string{"octo/tool"}}}
+	if !reflect.DeepEqual(rep.Permissions, want) {
+		t.Errorf("Run() permissions = %+v, want %+v", rep.Permissions, want)
+	}
+	if msg := want[0].Message(); msg != `job "build" sets no permissions, so third-party actions get the default token permissions: octo/tool` {
+		t.Errorf("Message() = %q", msg)
+	}
+}
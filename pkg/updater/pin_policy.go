@@ -0,0 +1,93 @@
+package updater
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/versions"
+)
+
+// Pin styles, deciding how an updated reference is written
+const (
+	PinHash           = "hash"             // actions/checkout@<sha> # v4.2.1
+	PinFullVersionTag = "full-version-tag" // actions/checkout@v4.2.1
+	PinMajorTag       = "major-tag"        // actions/checkout@v4
+)
+
+// PinPolicy decides the pin style of each action. Actions without a style
+// are pinned to commit hashes.
+type PinPolicy struct {
+	// Styles maps lowercase owner or owner/repo scopes, or "*" for every
+	// action, to a pin style. The most specific scope wins.
+	Styles map[string]string
+}
+
+// ParsePinPolicy builds a policy from a comma separated list of
+// owner[/repo]=style entries
+func ParsePinPolicy(spec string) (PinPolicy, error) {
+	var policy PinPolicy
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		scope, style, ok := strings.Cut(entry, "=")
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		style = strings.ToLower(strings.TrimSpace(style))
+		if !ok || scope == "" || strings.Count(scope, "/") > 1 {
+			return PinPolicy{}, fmt.Errorf(common.ErrInvalidPinStyle, entry)
+		}
+		switch style {
+		case PinHash, PinFullVersionTag, PinMajorTag:
+		default:
+			return PinPolicy{}, fmt.Errorf(common.ErrInvalidPinStyle, entry)
+		}
+		if policy.Styles == nil {
+			policy.Styles = make(map[string]string)
+		}
+		policy.Styles[scope] = style
+	}
+	return policy, nil
+}
+
+// Style returns the pin style of action
+func (p PinPolicy) Style(action ActionReference) string {
+	owner := strings.ToLower(action.Owner)
+	for _, scope := range []string{owner + "/" + strings.ToLower(action.Repo()), owner, "*"} {
+		if style, ok := p.Styles[scope]; ok {
+			return style
+		}
+	}
+	return PinHash
+}
+
+// NewRef returns the reference written after "@" for the update: the new
+// commit hash, or the version tag its pin style selects
+func (u *Update) NewRef() string {
+	if !u.pinsTag() {
+		return u.NewHash
+	}
+	if u.PinStyle == PinMajorTag {
+		major, _, _ := strings.Cut(u.NewVersion, ".")
+		return major
+	}
+	return u.NewVersion
+}
+
+// pinsTag reports whether the update writes a version tag instead of a hash.
+// New versions that are not version tags, such as branches, are always
+// pinned to hashes.
+func (u *Update) pinsTag() bool {
+	return (u.PinStyle == PinFullVersionTag || u.PinStyle == PinMajorTag) && versions.IsVersion(u.NewVersion)
+}
+
+// allPinHashes reports whether every update is pinned to a commit hash
+func allPinHashes(updates []*Update) bool {
+	for _, update := range updates {
+		if update.pinsTag() {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,113 @@
+package updater
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParsePinPolicy(t *testing.T) {
+	policy, err := ParsePinPolicy(" Actions=Major-Tag , octo/tool=full-version-tag,*=hash")
+	if err != nil {
+		t.Fatalf("ParsePinPolicy() error = %v", err)
+	}
+	checkout := ActionReference{Owner: "actions", Name: "checkout"}
+	tool := ActionReference{Owner: "octo", Name: "tool"}
+	other := ActionReference{Owner: "octo", Name: "other"}
+	if policy.Style(checkout) != PinMajorTag || policy.Style(tool) != PinFullVersionTag || policy.Style(other) != PinHash {
+		t.Errorf("ParsePinPolicy() = %+v", policy)
+	}
+	if style := (PinPolicy{}).Style(checkout); style != PinHash {
+		t.Errorf("empty policy style = %q, want hash", style)
+	}
+
+	for _, spec := range []string{"actions", "actions=latest", "a/b/c=hash", "=hash"} {
+		if _, err := ParsePinPolicy(spec); err == nil || !strings.Contains(err.Error(), "invalid pin style") {
+			t.Errorf("ParsePinPolicy(%q) error = %v", spec, err)
+		}
+	}
+}
+
+func TestApplyUpdatesPinStyles(t *testing.T) {
+	const hash = "a81bbbf8298c0fa03ea29cdc473d45769f953675"
+	const newHash = "1111111111111111111111111111111111111111"
+
+	tests := []struct {
+		name    string
+		uses    string
+		style   string
+		latest  string
+		want    string
+		wantNil bool
+	}{
+		{name: "hash", uses: "actions/checkout@v3", style: PinHash, latest: "v4.2.1", want: "actions/checkout@" + newHash + "  # v4.2.1"},
+		{name: "full version tag", uses: "actions/checkout@v3.1.0", style: PinFullVersionTag, latest: "v4.2.1", want: "actions/checkout@v4.2.1\n"},
+		{name: "major tag", uses: "actions/checkout@v3", style: PinMajorTag, latest: "v4.2.1", want: "actions/checkout@v4\n"},
+		{name: "pinned to major tag", uses: "actions/checkout@" + hash + "  # v3.5.0  # keep", style: PinMajorTag, latest: "v4.2.1", want: "actions/checkout@v4  # keep\n"},
+		{name: "major tag already current", uses: "actions/checkout@v4", style: PinMajorTag, latest: "v4.2.1", wantNil: true},
+		{name: "branch keeps hash", uses: "actions/checkout@v3", style: PinMajorTag, latest: "main", want: "actions/checkout@" + newHash + "  # main"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "workflow.yml")
+			content := "jobs:\n  a:\n    steps:\n      - uses: " + tt.uses + "\n"
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			refs, err := NewScanner(dir).ParseActionReferences(path)
+			if err != nil || len(refs) != 1 {
+				t.Fatalf("ParseActionReferences() = %v, %v", refs, err)
+			}
+
+			manager := NewUpdateManagerWithOptions(dir, UpdateManagerOptions{
+				PinPolicy: PinPolicy{Styles: map[string]string{"actions": tt.style}},
+			})
+			update, err := manager.CreateUpdate(context.Background(), path, refs[0], tt.latest, newHash)
+			if err != nil {
+				t.Fatalf("CreateUpdate() error = %v", err)
+			}
+			if tt.wantNil {
+				if update != nil {
+					t.Errorf("CreateUpdate() = %+v, want no update", update)
+				}
+				return
+			}
+			if err := manager.ApplyUpdates(context.Background(), []*Update{update}); err != nil {
+				t.Fatalf("ApplyUpdates() error = %v", err)
+			}
+
+			got, _ := os.ReadFile(path)
+			if !strings.Contains(string(got), "      - uses: "+tt.want) {
+				t.Errorf("updated content = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPRBodyHashFooter(t *testing.T) {
+	const footer = "This PR uses commit hashes"
+	pinned := &Update{Action: ActionReference{Owner: "actions", Name: "checkout"}, NewVersion: "v4.2.1", NewHash: "1111111111111111111111111111111111111111", PinStyle: PinHash}
+	tagged := &Update{Action: ActionReference{Owner: "actions", Name: "setup-go"}, NewVersion: "v5.0.0", NewHash: "2222222222222222222222222222222222222222", PinStyle: PinMajorTag}
+
+	tests := []struct {
+		name    string
+		updates []*Update
+		want    bool
+	}{
+		{"hashes only", []*Update{pinned}, true},
+		{"default style", []*Update{{NewVersion: "v1.0.0", NewHash: pinned.NewHash}}, true},
+		{"tags only", []*Update{tagged}, false},
+		{"mixed", []*Update{pinned, tagged}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := strings.Contains(prBody(tt.updates), footer); got != tt.want {
+				t.Errorf("prBody() has the hash footer = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,27 +2,76 @@ package updater
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"path/filepath"
 	"strings"
 	"time"
-	"unicode"
 
 	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
 	"github.com/google/go-github/v72/github"
 )
 
+// BranchPrefix starts the name of every branch created for a pull request
+const BranchPrefix = "action-updates-"
+
+// MajorUpdateLabel is added to pull requests with major version updates
+const MajorUpdateLabel = "major-update"
+
+// prLabels returns the labels of a pull request with updates
+func prLabels(updates []*Update) []string {
+	labels := []string{"dependencies", "automated-pr"}
+	for _, update := range updates {
+		if update.IsMajor() {
+			return append(labels, MajorUpdateLabel)
+		}
+	}
+	return labels
+}
+
+// NoChangesError is returned by CreatePR when the updates would leave the
+// files of the base branch as they are, e.g. because they were applied there
+// already. No branch or pull request is created.
+type NoChangesError struct {
+	Base string // Base branch the updates were compared against
+}
+
+// Error implements error
+func (e *NoChangesError) Error() string {
+	return fmt.Sprintf(common.ErrNoChanges, e.Base)
+}
+
 // DefaultPRCreator implements the PRCreator interface
 type DefaultPRCreator struct {
 	client        *github.Client
 	owner         string
 	repo          string
-	workflowsPath string // Path to workflow files (relative to repository root)
+	workflowsPath string          // Path to workflow files (relative to repository root)
+	repoRoot      string          // Local repository root used to relativize file paths (optional)
+	pulls         []PullRequest   // Pull requests created, in order
+	pending       string          // Branch of a CreatePR call that has not opened its pull request yet
+	baseBranch    string          // Branch pull requests target; the default branch when empty
+	draft         bool            // Open pull requests as drafts
+	branches      BranchTemplate  // Names the branches of pull requests
+	strategy      RewriteStrategy // How files are rewritten; nil uses YAMLRewriteStrategy
+	fork          bool            // Push branches to a fork and open pull requests from it
+	forkOwner     string          // Owner of the fork once it exists
+	forkRepo      string          // Name of the fork once it exists
+	redactor      *Redactor       // Removes secrets from commit messages and bodies
+	changeTicket  *Ticket
+	skippedFiles  []Failure // Files the run could not parse, listed in the body
 }
 
 // NewPRCreator creates a new instance of DefaultPRCreator
 func NewPRCreator(token, owner, repo string) *DefaultPRCreator {
-	client := common.NewGitHubClientWithToken(token)
+	return NewPRCreatorWithOptions(token, owner, repo, ClientOptions{})
+}
+
+// NewPRCreatorWithOptions creates a DefaultPRCreator whose API client is
+// built with options
+func NewPRCreatorWithOptions(token, owner, repo string, options ClientOptions) *DefaultPRCreator {
+	client := options.newClient(token)
 
 	return &DefaultPRCreator{
 		client:        client,
@@ -37,21 +86,72 @@ func (c *DefaultPRCreator) SetWorkflowsPath(path string) {
 	c.workflowsPath = path
 }
 
+// SetRepoRoot sets the local repository root used to compute repository-relative
+// paths for files outside the workflows directory (e.g. local composite actions)
+func (c *DefaultPRCreator) SetRepoRoot(path string) {
+	c.repoRoot = path
+}
+
+// SetBaseBranch makes pull requests branch off and target branch instead of
+// the repository's default branch
+func (c *DefaultPRCreator) SetBaseBranch(branch string) {
+	c.baseBranch = branch
+}
+
+// SetDraft opens pull requests as drafts
+func (c *DefaultPRCreator) SetDraft(draft bool) {
+	c.draft = draft
+}
+
+// SetBranchTemplate names the branches of pull requests by template
+func (c *DefaultPRCreator) SetBranchTemplate(template BranchTemplate) {
+	c.branches = template
+}
+
+// SetRewriteStrategy sets how workflow files are rewritten, as for the
+// update manager. A nil strategy selects YAMLRewriteStrategy.
+func (c *DefaultPRCreator) SetRewriteStrategy(strategy RewriteStrategy) {
+	c.strategy = strategy
+}
+
+// SetRedactor sets the redactor applied to commit messages and pull request
+// bodies; nil uses DefaultRedactPatterns
+func (c *DefaultPRCreator) SetRedactor(redactor *Redactor) {
+	c.redactor = redactor
+}
+
+// SetChangeTicket links a change ticket in the body of created pull requests
+func (c *DefaultPRCreator) SetChangeTicket(ticket *Ticket) {
+	c.changeTicket = ticket
+}
+
 // formatRelativePath converts an absolute file path to a repository-relative path
 func (c *DefaultPRCreator) formatRelativePath(file string) string {
+	return relativeRepoPath(file, c.repoRoot, c.workflowsPath)
+}
+
+// relativeRepoPath converts an absolute file path to a path relative to the
+// repository root, falling back to locating workflowsPath within it
+func relativeRepoPath(file, repoRoot, workflowsPath string) string {
 	relPath := file
+	if filepath.IsAbs(relPath) && repoRoot != "" {
+		if rel, err := filepath.Rel(repoRoot, relPath); err == nil && common.IsWithinDir(repoRoot, relPath) {
+			return filepath.ToSlash(rel)
+		}
+	}
 	if filepath.IsAbs(relPath) {
-		// Extract the workflows path part of the path
-		parts := strings.Split(relPath, c.workflowsPath)
+		// Extract the workflows path part of the path; repository paths
+		// use forward slashes on every platform
+		parts := strings.Split(filepath.ToSlash(relPath), filepath.ToSlash(workflowsPath))
 		if len(parts) != 2 {
 			// If we can't find the workflows path, just use the file name
 			relPath = filepath.Base(file)
 		} else {
 			// Join the workflows path with the file path
-			relPath = filepath.Join(c.workflowsPath, strings.TrimPrefix(parts[1], "/"))
+			relPath = filepath.Join(workflowsPath, strings.TrimPrefix(parts[1], "/"))
 		}
 	}
-	return relPath
+	return filepath.ToSlash(relPath)
 }
 
 // CreatePR creates a pull request with the given updates
@@ -59,191 +159,194 @@ func (c *DefaultPRCreator) CreatePR(ctx context.Context, updates []*Update) erro
 	if len(updates) == 0 {
 		return nil
 	}
+	updates, err := mergeUpdates(updates)
+	if err != nil {
+		return err
+	}
+
+	// Contributors without write access push to their fork
+	if c.fork {
+		if err := c.ensureFork(ctx); err != nil {
+			return err
+		}
+	}
+
+	// Check the base branch and permissions before writing anything
+	branchName := c.branches.nameAfter(updates, time.Now(), branchesOf(c.pulls)...)
+	base, baseRef, err := c.preflight(ctx, branchName)
+	if err != nil {
+		return err
+	}
+
+	// Rewrite the files of the base branch first, so updates that change
+	// nothing leave no branch behind
+	entries, err := c.treeEntries(ctx, baseRef.GetObject().GetSHA(), updates)
+	if err != nil {
+		return fmt.Errorf(common.ErrCreatingCommit, c.accessError(ctx, err))
+	}
+	if len(entries) == 0 {
+		return &NoChangesError{Base: base}
+	}
 
-	// Create a new branch for the updates
-	branchName := fmt.Sprintf("action-updates-%s", time.Now().Format("20060102-150405"))
-	if err := c.createBranch(ctx, branchName); err != nil {
-		return fmt.Errorf(common.ErrCreatingBranch, err)
+	// Create a new branch for the updates, which RecoverPR removes when
+	// the pull request is not opened
+	c.pending = branchName
+	if err := c.createBranch(ctx, branchName, baseRef); err != nil {
+		return fmt.Errorf(common.ErrCreatingBranch, c.accessError(ctx, err))
 	}
 
 	// Create commit with all updates
-	if err := c.createCommit(ctx, branchName, updates); err != nil {
-		return fmt.Errorf(common.ErrCreatingCommit, err)
+	head, err := c.createCommit(ctx, branchName, updates, entries)
+	if err != nil {
+		return fmt.Errorf(common.ErrCreatingCommit, c.accessError(ctx, err))
 	}
 
 	// Create pull request
 	title := "Update GitHub Actions dependencies"
 	body := c.generatePRBody(updates)
 
-	pr, _, err := c.client.PullRequests.Create(ctx, c.owner, c.repo, &github.NewPullRequest{
+	newPR := &github.NewPullRequest{
 		Title: &title,
 		Body:  &body,
 		Head:  &branchName,
-		Base:  github.Ptr("main"),
-	})
+		Base:  &base,
+		Draft: &c.draft,
+	}
+	if headOwner, _ := c.headRepository(); headOwner != c.owner {
+		// Pull requests from forks name the fork's owner in the head
+		newPR.Head = github.Ptr(headOwner + ":" + branchName)
+		newPR.MaintainerCanModify = github.Ptr(true)
+	}
+	pr, _, err := c.client.PullRequests.Create(ctx, c.owner, c.repo, newPR)
 
 	if err != nil {
-		return fmt.Errorf(common.ErrCreatingPR, err)
+		return fmt.Errorf(common.ErrCreatingPR, c.accessError(ctx, err))
 	}
+	c.recordPR(ctx, branchName, head, pr, updates)
+	return nil
+}
+
+// recordPR records the pull request opened from branch at commit head and
+// labels it
+func (c *DefaultPRCreator) recordPR(ctx context.Context, branch, head string, pr *github.PullRequest, updates []*Update) {
+	c.pending = ""
+	c.pulls = append(c.pulls, PullRequest{Number: pr.GetNumber(), URL: pr.GetHTMLURL(), HeadSHA: head, Branch: branch, Updates: updates})
 
 	// Add labels if PR was created successfully
 	if pr.Number != nil {
-		_, _, err = c.client.Issues.AddLabelsToIssue(ctx, c.owner, c.repo, *pr.Number,
-			[]string{"dependencies", "automated-pr"})
+		_, _, err := c.client.Issues.AddLabelsToIssue(ctx, c.owner, c.repo, *pr.Number, prLabels(updates))
 		if err != nil {
 			// Don't fail if we couldn't add labels
 			fmt.Printf("Warning: %v\n", err)
 		}
 	}
-
-	return nil
 }
 
-// createBranch creates a new branch from the default branch
-func (c *DefaultPRCreator) createBranch(ctx context.Context, branchName string) error {
-	// Get the default branch's latest commit
-	repo, _, err := c.client.Repositories.Get(ctx, c.owner, c.repo)
-	if err != nil {
-		return fmt.Errorf(common.ErrGettingRepository, err)
-	}
-
-	defaultBranch := repo.GetDefaultBranch()
-	ref, _, err := c.client.Git.GetRef(ctx, c.owner, c.repo, "refs/heads/"+defaultBranch)
-	if err != nil {
-		return fmt.Errorf(common.ErrGettingDefaultBranchRef, err)
+// accessError explains a 403 or 404 from the target repository
+func (c *DefaultPRCreator) accessError(ctx context.Context, err error) error {
+	diagnosed := common.DiagnoseAccessError(ctx, c.client, c.owner, c.repo, err)
+	var accessErr *common.AccessError
+	if errors.As(diagnosed, &accessErr) && accessErr.Kind == common.ResourceNotFound {
+		accessErr.Hints = append(accessErr.Hints, common.HintWriteAccess)
+		if !c.fork {
+			accessErr.Hints = append(accessErr.Hints, common.HintFork)
+		}
 	}
+	return diagnosed
+}
 
-	// Create new branch
+// createBranch creates a new branch at the head of the base branch. In fork
+// mode the branch is created in the fork, which shares the commits of the
+// repository.
+func (c *DefaultPRCreator) createBranch(ctx context.Context, branchName string, base *github.Reference) error {
 	newRef := &github.Reference{
 		Ref:    github.Ptr("refs/heads/" + branchName),
-		Object: ref.Object,
+		Object: base.Object,
 	}
-
-	_, _, err = c.client.Git.CreateRef(ctx, c.owner, c.repo, newRef)
+	headOwner, headRepo := c.headRepository()
+	_, _, err := c.client.Git.CreateRef(ctx, headOwner, headRepo, newRef)
 	return err
 }
 
 // formatActionReference formats an action reference with version comments
 func (c *DefaultPRCreator) formatActionReference(update *Update) string {
+	return formatActionReference(update)
+}
+
+// formatActionReference formats an action reference with version comments
+func formatActionReference(update *Update) string {
 	var sb strings.Builder
 
 	// Add the action reference with hash
 	// Handle multi-part action names correctly (e.g., github/codeql-action/init)
-	actionFullName := update.Action.Owner + "/" + update.Action.Name
-	sb.WriteString(fmt.Sprintf("%s@%s", actionFullName, update.NewHash))
+	actionFullName := update.NewFullName()
+	sb.WriteString(fmt.Sprintf("%s@%s", actionFullName, update.NewRef()))
 
 	// Add current version comment
-	if update.NewVersion != "" {
-		sb.WriteString(fmt.Sprintf("  # %s", update.NewVersion))
+	if comment := updateVersionComment(update); comment != "" {
+		sb.WriteString("  " + comment)
 	}
 
 	return sb.String()
 }
 
-// createCommit creates a commit with all updates
-func (c *DefaultPRCreator) createCommit(ctx context.Context, branch string, updates []*Update) error {
+// treeEntries rewrites the files of the base commit and stores the changed
+// ones as blobs in the head repository. Files the updates leave unchanged
+// are skipped, so no entries means there is nothing to commit.
+func (c *DefaultPRCreator) treeEntries(ctx context.Context, baseSHA string, updates []*Update) ([]*github.TreeEntry, error) {
 	// Group updates by file
 	fileUpdates := make(map[string][]*Update)
+	var files []string
 	for _, update := range updates {
+		if _, ok := fileUpdates[update.FilePath]; !ok {
+			files = append(files, update.FilePath)
+		}
 		fileUpdates[update.FilePath] = append(fileUpdates[update.FilePath], update)
 	}
 
+	// The blobs belong to the fork in fork mode
+	owner, repo := c.headRepository()
+
 	// Create tree entries for each file
 	var entries []*github.TreeEntry
-	for file, fileUpdates := range fileUpdates {
+	for _, file := range files {
 		// Convert absolute path to repository-relative path
 		relPath := c.formatRelativePath(file)
 
-		// Get current file content
+		// Get the file content on the base branch
 		content, _, _, err := c.client.Repositories.GetContents(ctx, c.owner, c.repo, relPath,
-			&github.RepositoryContentGetOptions{Ref: branch})
+			&github.RepositoryContentGetOptions{Ref: baseSHA})
 		if err != nil {
-			// If file doesn't exist in the repository yet, create empty content
-			if strings.Contains(err.Error(), "404") {
-				content = &github.RepositoryContent{
-					Content: github.Ptr(""),
-				}
-			} else {
-				return fmt.Errorf(common.ErrGettingFileContents, err)
+			// A file missing on the base branch has no reference to update
+			var errResp *github.ErrorResponse
+			if errors.As(err, &errResp) && errResp.Response != nil && errResp.Response.StatusCode == http.StatusNotFound {
+				continue
 			}
+			return nil, fmt.Errorf(common.ErrGettingFileContents, err)
 		}
 
 		// Apply updates to content
-		fileContent, err := content.GetContent()
+		original, err := content.GetContent()
 		if err != nil {
-			return fmt.Errorf(common.ErrDecodingContent, err)
+			return nil, fmt.Errorf(common.ErrDecodingContent, err)
 		}
 
-		lines := strings.Split(fileContent, "\n")
-		for _, update := range fileUpdates {
-			// Find the line with the action reference
-			lineIdx := update.LineNumber - 1
-			if lineIdx >= 0 && lineIdx < len(lines) {
-				// Get the line and preserve indentation and structure
-				line := lines[update.LineNumber-1]
-
-				// Extract indentation (whitespace at the beginning of the line)
-				indentation := ""
-				for i, c := range line {
-					if !unicode.IsSpace(c) {
-						indentation = line[:i]
-						break
-					}
-				}
-
-				// Check if the line starts with "- name:" which indicates it's a step definition
-				isStepDefinition := strings.Contains(line, "- name:")
-
-				// Apply the update with improved formatting
-				parts := strings.SplitN(line, "#", 2)
-				mainPart := strings.TrimSpace(parts[0])
-
-				// Check if the line contains "uses:" to avoid duplication
-				usesIdx := strings.Index(mainPart, "uses:")
-
-				// Format the action reference with the new hash
-				newRef := c.formatActionReference(update)
-
-				var newLine string
-
-				if usesIdx >= 0 {
-					// Case 1: Line contains "uses:" - preserve the format
-					beforeUses := mainPart[:usesIdx+5] // +5 to include "uses:"
-
-					// Add version comment (already included in newRef)
-					newLine = fmt.Sprintf("%s%s %s", indentation, beforeUses, strings.TrimPrefix(newRef, "uses: "))
-				} else if isStepDefinition {
-					// Case 2: This is a step definition line, the "uses:" line will be on the next line
-					// Just keep it as is
-					newLine = line
-				} else {
-					// Case 3: This is a line that should have "uses:" but doesn't (possibly already processed incorrectly)
-					// Add proper indentation and "uses:" prefix
-					// Check if this is a step line (should start with "- " or "  - ")
-					if strings.Contains(line, "- name:") {
-						// This is a step definition line, keep it as is
-						newLine = line
-					} else if strings.HasPrefix(strings.TrimSpace(line), "-") {
-						// This is a step line but not a name line, it should have proper indentation
-						newLine = fmt.Sprintf("%s      uses: %s", indentation, strings.TrimPrefix(newRef, "uses: "))
-					} else {
-						// This is some other line, add standard indentation
-						newLine = fmt.Sprintf("%s  %s", indentation, newRef)
-					}
-				}
-
-				lines[lineIdx] = newLine
-			}
+		// Leave files the base branch already has updated out of the commit
+		fileContent, changed, err := rewriteFileContent(original, fileUpdates[file], rewriteStrategyOr(c.strategy))
+		if err != nil {
+			return nil, fmt.Errorf(common.ErrRewritingFile, relPath, err)
+		}
+		if !changed || fileContent == original {
+			continue
 		}
-		fileContent = strings.Join(lines, "\n")
 
 		// Create blob for updated content
-		blob, _, err := c.client.Git.CreateBlob(ctx, c.owner, c.repo, &github.Blob{
+		blob, _, err := c.client.Git.CreateBlob(ctx, owner, repo, &github.Blob{
 			Content:  github.Ptr(fileContent),
 			Encoding: github.Ptr("utf-8"),
 		})
 		if err != nil {
-			return fmt.Errorf(common.ErrCreatingBlob, err)
+			return nil, fmt.Errorf(common.ErrCreatingBlob, err)
 		}
 
 		// Ensure path doesn't start with a slash
@@ -256,37 +359,52 @@ func (c *DefaultPRCreator) createCommit(ctx context.Context, branch string, upda
 			SHA:  blob.SHA,
 		})
 	}
+	return entries, nil
+}
+
+// createCommit commits the tree entries of updates to branch and returns
+// the new commit
+func (c *DefaultPRCreator) createCommit(ctx context.Context, branch string, updates []*Update, entries []*github.TreeEntry) (string, error) {
+	// The branch lives in the fork in fork mode
+	owner, repo := c.headRepository()
 
 	// Get the branch's latest commit
-	ref, _, err := c.client.Git.GetRef(ctx, c.owner, c.repo, "refs/heads/"+branch)
+	ref, _, err := c.client.Git.GetRef(ctx, owner, repo, "refs/heads/"+branch)
 	if err != nil {
-		return fmt.Errorf(common.ErrGettingBranchRef, err)
+		return "", fmt.Errorf(common.ErrGettingBranchRef, err)
 	}
 
 	// Create tree
-	tree, _, err := c.client.Git.CreateTree(ctx, c.owner, c.repo, *ref.Object.SHA, entries)
+	tree, _, err := c.client.Git.CreateTree(ctx, owner, repo, *ref.Object.SHA, entries)
 	if err != nil {
-		return fmt.Errorf(common.ErrCreatingTree, err)
+		return "", fmt.Errorf(common.ErrCreatingTree, err)
 	}
 
 	// Create commit
-	commit, _, err := c.client.Git.CreateCommit(ctx, c.owner, c.repo, &github.Commit{
+	commit, _, err := c.client.Git.CreateCommit(ctx, owner, repo, &github.Commit{
 		Message: github.Ptr(c.generateCommitMessage(updates)),
 		Tree:    tree,
 		Parents: []*github.Commit{{SHA: ref.Object.SHA}},
 	}, &github.CreateCommitOptions{})
 	if err != nil {
-		return fmt.Errorf(common.ErrCreatingCommit, err)
+		return "", fmt.Errorf(common.ErrCreatingCommit, err)
 	}
 
 	// Update branch reference
 	ref.Object.SHA = commit.SHA
-	_, _, err = c.client.Git.UpdateRef(ctx, c.owner, c.repo, ref, false)
-	return err
+	if _, _, err = c.client.Git.UpdateRef(ctx, owner, repo, ref, false); err != nil {
+		return "", err
+	}
+	return commit.GetSHA(), nil
 }
 
 // generateCommitMessage generates a commit message for the updates
 func (c *DefaultPRCreator) generateCommitMessage(updates []*Update) string {
+	return c.redactor.Redact(commitMessage(updates))
+}
+
+// commitMessage lists the updates in a commit message
+func commitMessage(updates []*Update) string {
 	var sb strings.Builder
 	sb.WriteString("Update GitHub Actions dependencies\n\n")
 	for _, update := range updates {
@@ -297,23 +415,52 @@ func (c *DefaultPRCreator) generateCommitMessage(updates []*Update) string {
 
 // generatePRBody generates the body text for the pull request
 func (c *DefaultPRCreator) generatePRBody(updates []*Update) string {
+	body := prBody(updates, skippedFilesSection(c.skippedFiles, c.repoRoot, c.workflowsPath))
+	if c.changeTicket != nil {
+		body = changeTicketNote(c.changeTicket) + "\n\n" + body
+	}
+	return c.redactor.Redact(body)
+}
+
+// prBody describes the updates in a pull request body, followed by notes
+func prBody(updates []*Update, notes ...string) string {
 	var sb strings.Builder
 	sb.WriteString("This PR updates the following GitHub Actions to their latest versions:\n\n")
 
+	summarized := make(map[string]bool) // Each release summary is shown once
+
 	for _, update := range updates {
 		// Handle multi-part action names correctly (e.g., github/codeql-action/init)
-		actionFullName := update.Action.Owner + "/" + update.Action.Name
+		actionFullName := update.Action.FullName()
 		sb.WriteString(fmt.Sprintf("* `%s`\n", actionFullName))
 		sb.WriteString(fmt.Sprintf("  * From: %s (%s)\n", update.OldVersion, update.OldHash))
 		sb.WriteString(fmt.Sprintf("  * To: %s (%s)\n", update.NewVersion, update.NewHash))
 		if update.OriginalVersion != "" && update.OriginalVersion != update.OldVersion {
 			sb.WriteString(fmt.Sprintf("  * Original version: %s\n", update.OriginalVersion))
 		}
+		if update.MovedTo != "" {
+			sb.WriteString(fmt.Sprintf("  * ⚠️ Renamed to `%s`: the action's repository was renamed or transferred\n", update.MovedTo))
+		}
+		// Actions in subdirectories of a repository share its releases
+		release := update.Action.Repository() + "@" + update.NewVersion
+		if update.ReleaseSummary != "" && !summarized[release] {
+			summarized[release] = true
+			sb.WriteString("  * Release notes summary:\n")
+			for _, line := range strings.Split(update.ReleaseSummary, "\n") {
+				sb.WriteString("    > " + line + "\n")
+			}
+		}
 		sb.WriteString("\n")
 	}
 
+	for _, note := range notes {
+		sb.WriteString(note)
+	}
+
 	sb.WriteString("---\n")
-	sb.WriteString("🔒 This PR uses commit hashes for improved security.\n")
+	if allPinHashes(updates) {
+		sb.WriteString("🔒 This PR uses commit hashes for improved security.\n")
+	}
 	sb.WriteString("🤖 This PR was created automatically by the GitHub Actions workflow updater.")
 	return sb.String()
 }
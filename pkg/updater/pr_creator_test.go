@@ -3,13 +3,15 @@ package updater
 import (
 	"context"
 	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"strings"
 	"testing"
-	"unicode"
+	"time"
 
 	"github.com/google/go-github/v72/github"
 	"golang.org/x/oauth2"
@@ -82,6 +84,45 @@ func TestCreatePR(t *testing.T) {
 	}
 }
 
+// TestCreatePR_NoChanges tests that no branch or pull request is created
+// when the updates would not change the base branch
+func TestCreatePR_NoChanges(t *testing.T) {
+	server, creator := SetupPRTestServer(t, NormalServer)
+	defer server.Close()
+	var writes []string
+	handler := server.Config.Handler
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writes = append(writes, r.Method+" "+r.URL.Path)
+		}
+		handler.ServeHTTP(w, r)
+	})
+
+	// The fixture workflow already uses actions/checkout@abc123 # v2
+	tests := []struct {
+		name    string
+		comment string
+	}{
+		{name: "already applied"},
+		{name: "same text", comment: "# v2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writes = nil
+			updates := CreateTestUpdates(1, "actions", "checkout", "v1", "v2", ".github/workflows/test.yml")
+			updates[0].VersionComment = tt.comment
+			err := creator.CreatePR(context.Background(), updates)
+			var noChanges *NoChangesError
+			if !errors.As(err, &noChanges) || noChanges.Base != "main" {
+				t.Fatalf("CreatePR() error = %v, want NoChangesError for main", err)
+			}
+			if len(writes) != 0 || len(creator.PullRequests()) != 0 {
+				t.Errorf("CreatePR() wrote %v and opened %+v", writes, creator.PullRequests())
+			}
+		})
+	}
+}
+
 // TestCreatePR_NoUpdates tests that no error is returned when no updates are provided
 func TestCreatePR_NoUpdates(t *testing.T) {
 	server, creator := SetupPRTestServer(t, NormalServer)
@@ -455,136 +496,308 @@ func setupTestServerWithRefHandlers(t *testing.T, owner, repo string, updates []
 	return server, creator
 }
 
-// TestCreatePR_formatActionReference tests that different line formats are processed correctly
-func TestCreatePR_formatActionReference(t *testing.T) {
-	lines := []struct {
-		name     string
-		input    string
-		newRef   string
-		expected string
+// TestCreatePR_NonExistentFile tests handling non-existent files
+func TestCreatePR_NonExistentFile(t *testing.T) {
+	owner := "test-owner"
+	repo := "test-repo"
+
+	// Create update for non-existent file
+	update := CreateTestUpdate("actions", "checkout", "v2", "v3", "non-existent-file.yml")
+
+	server, creator := setupTestServerWithRefHandlers(t, owner, repo, []*Update{update})
+	defer server.Close()
+
+	// Updating a line of a missing file changes nothing
+	err := creator.CreatePR(context.Background(), []*Update{update})
+	var noChanges *NoChangesError
+	if !errors.As(err, &noChanges) {
+		t.Errorf("CreatePR() with non-existent file error = %v, want NoChangesError", err)
+	}
+}
+
+func TestCreatePR_AccessErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		repo    int // Status of GET /repos/o/r
+		wantErr string
 	}{
-		{
-			name:     "standard uses format",
-			input:    "      - uses: actions/checkout@v2",
-			newRef:   "uses: actions/checkout@v3",
-			expected: "      - uses: actions/checkout@v3",
-		},
-		{
-			name:     "indented format",
-			input:    "        uses: actions/checkout@v2",
-			newRef:   "uses: actions/checkout@v3",
-			expected: "        uses: actions/checkout@v3",
-		},
-		{
-			name:     "step definition line",
-			input:    "      - name: Checkout code",
-			newRef:   "uses: actions/checkout@v3",
-			expected: "      - name: Checkout code", // Should remain unchanged
-		},
-		{
-			name:     "step without uses prefix",
-			input:    "      - actions/checkout@v2",
-			newRef:   "uses: actions/checkout@v3",
-			expected: "            uses: actions/checkout@v3", // Should add uses prefix with indentation
-		},
-		{
-			name:     "line with comment",
-			input:    "      - uses: actions/checkout@v2 # Comment",
-			newRef:   "uses: actions/checkout@v3",
-			expected: "      - uses: actions/checkout@v3", // Comment should be removed
-		},
-		{
-			name:     "indented line with mixed whitespace",
-			input:    "  \t  - uses: actions/checkout@v2",
-			newRef:   "uses: actions/checkout@v3",
-			expected: "  \t  - uses: actions/checkout@v3",
-		},
+		{name: "repository not visible", repo: http.StatusNotFound, wantErr: "was not found or is not visible to the token"},
+		{name: "read-only token", repo: http.StatusForbidden, wantErr: "access to o/r was denied"},
 	}
 
-	// Test each line format directly without needing formatActionReference
-	for _, tt := range lines {
+	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// We don't actually use these, but this is to document what would be used
-			_ = &DefaultPRCreator{}
-			_ = &Update{
-				Action: ActionReference{
-					Owner:   "actions",
-					Name:    "checkout",
-					Version: "v2",
-				},
-				OldVersion: "v2",
-				NewVersion: "v3",
-				OldHash:    "abc123",
-				NewHash:    "def456",
-			}
+			mux := http.NewServeMux()
+			mux.HandleFunc("/repos/o/r", func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Accepted-GitHub-Permissions", "contents=read")
+				w.WriteHeader(tt.repo)
+				fmt.Fprint(w, `{"message":"error"}`)
+			})
+			server := httptest.NewServer(mux)
+			defer server.Close()
 
-			// Mock the formatActionReference call by setting it directly
-			formatted := tt.input
+			client := github.NewClient(nil)
+			client.BaseURL, _ = url.Parse(server.URL + "/")
+			creator := &DefaultPRCreator{client: client, owner: "o", repo: "r"}
 
-			// Extract indentation
-			indentation := ""
-			for i, c := range formatted {
-				if !unicode.IsSpace(c) {
-					indentation = formatted[:i]
-					break
-				}
+			err := creator.CreatePR(context.Background(), CreateTestUpdates(1, "actions", "checkout", "v2", "v3", "test.yml"))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("CreatePR() error = %v, want containing %q", err, tt.wantErr)
 			}
+		})
+	}
+}
 
-			// Check if line is a step definition or has "uses:"
-			isStepDefinition := strings.Contains(formatted, "- name:")
-
-			// Apply similar logic to what's in createCommit
-			parts := strings.SplitN(formatted, "#", 2)
-			mainPart := strings.TrimSpace(parts[0])
+func TestCreatePR_BaseBranchAndDraft(t *testing.T) {
+	var pull github.NewPullRequest
+	var labels []string
+	repoLookups := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /repos/o/r", func(w http.ResponseWriter, r *http.Request) {
+		repoLookups++
+		fmt.Fprint(w, `{"default_branch":"main"}`)
+	})
+	mux.HandleFunc("GET /repos/o/r/git/ref/heads/{ref...}", func(w http.ResponseWriter, r *http.Request) {
+		if ref := r.PathValue("ref"); ref != "release/1.x" && !strings.HasPrefix(ref, "action-updates-") {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `{"ref":"refs/heads/x","object":{"sha":"base-sha","type":"commit"}}`)
+	})
+	mux.HandleFunc("/repos/o/r/git/refs/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"ref":"refs/heads/x","object":{"sha":"new-commit-sha","type":"commit"}}`)
+	})
+	mux.HandleFunc("POST /repos/o/r/git/refs", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"ref":"refs/heads/x","object":{"sha":"base-sha","type":"commit"}}`)
+	})
+	mux.HandleFunc("/repos/o/r/contents/", func(w http.ResponseWriter, r *http.Request) {
+		content := base64.StdEncoding.EncodeToString([]byte(defaultWorkflowContent()))
+		fmt.Fprintf(w, `{"type":"file","encoding":"base64","content":"%s"}`, content)
+	})
+	for _, path := range []string{"blobs", "trees", "commits"} {
+		mux.HandleFunc("POST /repos/o/r/git/"+path, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{"sha":"new-sha"}`)
+		})
+	}
+	mux.HandleFunc("POST /repos/o/r/pulls", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&pull)
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"number":1,"html_url":"https://github.com/o/r/pull/1"}`)
+	})
+	mux.HandleFunc("POST /repos/o/r/issues/1/labels", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&labels)
+		fmt.Fprint(w, `[]`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
 
-			usesIdx := strings.Index(mainPart, "uses:")
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	creator := &DefaultPRCreator{client: client, owner: "o", repo: "r"}
+	creator.SetBaseBranch("release/1.x")
+	creator.SetDraft(true)
 
-			var newLine string
+	updates := CreateTestUpdates(1, "actions", "checkout", "v2", "v3", ".github/workflows/test.yml")
+	if err := creator.CreatePR(context.Background(), updates); err != nil {
+		t.Fatalf("CreatePR() error = %v", err)
+	}
+	if pull.GetBase() != "release/1.x" || !pull.GetDraft() || repoLookups != 0 {
+		t.Errorf("pull request base = %q, draft = %v after %d default branch lookups", pull.GetBase(), pull.GetDraft(), repoLookups)
+	}
+	if pulls := creator.PullRequests(); len(pulls) != 1 || pulls[0].Number != 1 || pulls[0].URL != "https://github.com/o/r/pull/1" || pulls[0].HeadSHA != "new-sha" {
+		t.Errorf("pull requests = %+v", pulls)
+	}
+	// v2 to v3 is a major version update
+	if fmt.Sprint(labels) != "[dependencies automated-pr major-update]" {
+		t.Errorf("labels = %v, want major-update added", labels)
+	}
 
-			if usesIdx >= 0 {
-				// Case 1: Line contains "uses:" - preserve the format
-				beforeUses := mainPart[:usesIdx+5] // +5 to include "uses:"
+	creator.SetBaseBranch("release/2.x")
+	err := creator.CreatePR(context.Background(), updates)
+	if err == nil || !strings.Contains(err.Error(), "error getting ref of base branch release/2.x") {
+		t.Errorf("CreatePR() error = %v, want missing base branch", err)
+	}
+}
 
-				// Add version comment (already included in tt.newRef)
-				newLine = fmt.Sprintf("%s%s %s", indentation, beforeUses, strings.TrimPrefix(tt.newRef, "uses: "))
-			} else if isStepDefinition {
-				// Case 2: This is a step definition line, should remain unchanged
-				newLine = formatted
-			} else {
-				// Case 3: This line should have "uses:" but doesn't
-				if strings.Contains(formatted, "- name:") {
-					// Just a safety check, but this is already covered by isStepDefinition
-					newLine = formatted
-				} else if strings.HasPrefix(strings.TrimSpace(formatted), "-") {
-					// This is a step line but not a name line, add proper indentation
-					newLine = fmt.Sprintf("%s      uses: %s", indentation, strings.TrimPrefix(tt.newRef, "uses: "))
-				} else {
-					// Some other line, add standard indentation
-					newLine = fmt.Sprintf("%s  %s", indentation, tt.newRef)
-				}
-			}
+func TestCreatePR_Fork(t *testing.T) {
+	defer func(interval time.Duration) { forkPollInterval = interval }(forkPollInterval)
+	forkPollInterval = time.Millisecond
 
-			// Check if the line matches what we expect from createCommit
-			if newLine != tt.expected {
-				t.Errorf("formatting failed, got %q, want %q", newLine, tt.expected)
+	var pull github.NewPullRequest
+	forkRequests, forkPolls := 0, 0
+	var forkWrites []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /repos/o/r", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"default_branch":"main"}`)
+	})
+	mux.HandleFunc("GET /repos/o/r/git/ref/heads/main", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"ref":"refs/heads/main","object":{"sha":"base-sha","type":"commit"}}`)
+	})
+	mux.HandleFunc("POST /repos/o/r/forks", func(w http.ResponseWriter, r *http.Request) {
+		forkRequests++
+		w.WriteHeader(http.StatusAccepted)
+		fmt.Fprint(w, `{"name":"r-fork","owner":{"login":"me"},"default_branch":"main"}`)
+	})
+	// The new fork takes a moment before its branches exist
+	mux.HandleFunc("GET /repos/me/r-fork/git/ref/heads/{ref...}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("ref") == "main" {
+			if forkPolls++; forkPolls < 3 {
+				http.NotFound(w, r)
+				return
 			}
+		}
+		fmt.Fprint(w, `{"ref":"refs/heads/x","object":{"sha":"base-sha","type":"commit"}}`)
+	})
+	mux.HandleFunc("/repos/me/r-fork/git/refs", func(w http.ResponseWriter, r *http.Request) {
+		forkWrites = append(forkWrites, "ref")
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"ref":"refs/heads/x","object":{"sha":"base-sha","type":"commit"}}`)
+	})
+	mux.HandleFunc("/repos/me/r-fork/git/refs/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"ref":"refs/heads/x","object":{"sha":"new-commit-sha","type":"commit"}}`)
+	})
+	// Files are read from the base branch of the repository itself
+	mux.HandleFunc("/repos/o/r/contents/", func(w http.ResponseWriter, r *http.Request) {
+		content := base64.StdEncoding.EncodeToString([]byte(defaultWorkflowContent()))
+		fmt.Fprintf(w, `{"type":"file","encoding":"base64","content":"%s"}`, content)
+	})
+	for _, path := range []string{"blobs", "trees", "commits"} {
+		mux.HandleFunc("POST /repos/me/r-fork/git/"+path, func(w http.ResponseWriter, r *http.Request) {
+			forkWrites = append(forkWrites, path)
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{"sha":"new-sha"}`)
 		})
 	}
-}
+	mux.HandleFunc("POST /repos/o/r/pulls", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&pull)
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"number":1,"html_url":"https://github.com/o/r/pull/1"}`)
+	})
+	mux.HandleFunc("POST /repos/o/r/issues/1/labels", func(w http.ResponseWriter, r *http.Request) {
+		// Contributors cannot label pull requests of others' repositories
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"message":"forbidden"}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
 
-// TestCreatePR_NonExistentFile tests handling non-existent files
-func TestCreatePR_NonExistentFile(t *testing.T) {
-	owner := "test-owner"
-	repo := "test-repo"
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	creator := &DefaultPRCreator{client: client, owner: "o", repo: "r"}
+	creator.SetFork(true)
 
-	// Create update for non-existent file
-	update := CreateTestUpdate("actions", "checkout", "v2", "v3", "non-existent-file.yml")
+	updates := CreateTestUpdates(1, "actions", "checkout", "v2", "v3", ".github/workflows/test.yml")
+	for i := 0; i < 2; i++ {
+		if err := creator.CreatePR(context.Background(), updates); err != nil {
+			t.Fatalf("CreatePR() error = %v", err)
+		}
+	}
+	if !strings.HasPrefix(pull.GetHead(), "me:action-updates-") || pull.GetBase() != "main" || !pull.GetMaintainerCanModify() {
+		t.Errorf("pull request head = %q, base = %q, maintainer can modify = %v", pull.GetHead(), pull.GetBase(), pull.GetMaintainerCanModify())
+	}
+	if forkRequests != 1 || forkPolls != 3 {
+		t.Errorf("fork created %d times after %d polls, want once after 3", forkRequests, forkPolls)
+	}
+	if got := strings.Join(forkWrites, ","); got != "blobs,ref,trees,commits,blobs,ref,trees,commits" {
+		t.Errorf("writes to the fork = %s", got)
+	}
+}
 
-	server, creator := setupTestServerWithRefHandlers(t, owner, repo, []*Update{update})
+// committedContent returns the content treeEntries commits for a workflow
+// whose content on the base branch is content
+func committedContent(t *testing.T, creator *DefaultPRCreator, content string, updates []*Update) string {
+	t.Helper()
+	var committed string
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /repos/o/r/contents/{path...}", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"type":"file","encoding":"base64","content":"%s"}`, base64.StdEncoding.EncodeToString([]byte(content)))
+	})
+	mux.HandleFunc("POST /repos/o/r/git/blobs", func(w http.ResponseWriter, r *http.Request) {
+		var blob github.Blob
+		_ = json.NewDecoder(r.Body).Decode(&blob)
+		committed = blob.GetContent()
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"sha":"blob-sha"}`)
+	})
+	server := httptest.NewServer(mux)
 	defer server.Close()
 
-	err := creator.CreatePR(context.Background(), []*Update{update})
-	if err != nil {
-		t.Errorf("CreatePR() with non-existent file error = %v", err)
+	creator.client = github.NewClient(nil)
+	creator.client.BaseURL, _ = url.Parse(server.URL + "/")
+	creator.owner, creator.repo = "o", "r"
+	if _, err := creator.treeEntries(context.Background(), "base-sha", updates); err != nil {
+		t.Fatalf("treeEntries() error = %v", err)
+	}
+	return committed
+}
+
+func TestTreeEntriesKeepUserComments(t *testing.T) {
+	const newHash = "1111111111111111111111111111111111111111"
+	steps := "    steps:\n      - uses: actions/checkout@v3 # keep: team note\n"
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{name: "yaml", content: "on: push\njobs:\n  build:\n    runs-on: ubuntu-latest\n" + steps},
+		// Files the YAML parser rejects are edited line by line
+		{name: "unparsable", content: "on: push\njobs:\n  build:\n    runs-on: [ubuntu-latest\n" + steps},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			update := &Update{
+				Action:     ActionReference{Owner: "actions", Name: "checkout", Version: "v3"},
+				OldVersion: "v3", NewVersion: "v4", NewHash: newHash, VersionComment: "# v4",
+				FilePath: ".github/workflows/ci.yml", LineNumber: 6,
+			}
+
+			got := committedContent(t, &DefaultPRCreator{}, tt.content, []*Update{update})
+			want := strings.Replace(tt.content, "actions/checkout@v3 # keep: team note", "actions/checkout@"+newHash+" # v4  # keep: team note", 1)
+			if got != want {
+				t.Errorf("committed content =\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestTreeEntriesRewriteStrategy(t *testing.T) {
+	const newHash = "1111111111111111111111111111111111111111"
+	content := "on: push\njobs:\n  build:\n    runs-on: ubuntu-latest\n    steps:\n      - uses: actions/checkout@v3 # v3\n"
+	want := strings.Replace(content, "actions/checkout@v3 # v3", "actions/checkout@"+newHash+" # v4", 1)
+	custom := RewriteStrategyFunc(func(content string, updates []*Update) (string, error) {
+		return content + "# rewritten\n", nil
+	})
+	tests := []struct {
+		name     string
+		strategy RewriteStrategy
+		want     string
+	}{
+		{name: "default", want: want},
+		{name: "yaml", strategy: YAMLRewriteStrategy, want: want},
+		{name: "line", strategy: LineRewriteStrategy, want: want},
+		{name: "custom", strategy: custom, want: content + "# rewritten\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			creator := &DefaultPRCreator{}
+			if tt.strategy != nil {
+				creator.SetRewriteStrategy(RewriteStrategyFunc(func(content string, updates []*Update) (string, error) {
+					calls++
+					return tt.strategy.Rewrite(content, updates)
+				}))
+			}
+			update := &Update{
+				Action:     ActionReference{Owner: "actions", Name: "checkout", Version: "v3"},
+				OldVersion: "v3", NewVersion: "v4", NewHash: newHash, VersionComment: "# v4",
+				FilePath: ".github/workflows/ci.yml", LineNumber: 6,
+			}
+
+			got := committedContent(t, creator, content, []*Update{update})
+			if got != tt.want || (tt.strategy != nil && calls != 1) {
+				t.Errorf("committed content (%d strategy calls) =\n%s\nwant\n%s", calls, got, tt.want)
+			}
+		})
 	}
 }
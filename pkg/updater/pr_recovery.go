@@ -0,0 +1,102 @@
+package updater
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+	"github.com/google/go-github/v72/github"
+)
+
+// PRRecoverer is implemented by PR creators whose CreatePR can fail after
+// creating a branch or even the pull request, e.g. when the response to the
+// request opening it is lost. RetryingPRCreator calls RecoverPR before
+// retrying CreatePR, so retries leave no orphan branches or duplicates.
+type PRRecoverer interface {
+	// RecoverPR records the pull request the failed CreatePR call for
+	// updates opened after all and reports true, or removes the branch the
+	// call left behind so CreatePR can start over
+	RecoverPR(ctx context.Context, updates []*Update) (bool, error)
+}
+
+// RecoverPR implements PRRecoverer
+func (c *DefaultPRCreator) RecoverPR(ctx context.Context, updates []*Update) (bool, error) {
+	branch := c.pending
+	if branch == "" {
+		return false, nil
+	}
+	updates, err := mergeUpdates(updates)
+	if err != nil {
+		return false, err
+	}
+	headOwner, headRepo := c.headRepository()
+	pulls, _, err := c.client.PullRequests.List(ctx, c.owner, c.repo, &github.PullRequestListOptions{
+		State: "open",
+		Head:  headOwner + ":" + branch,
+	})
+	if err != nil {
+		return false, fmt.Errorf(common.ErrRecoveringPR, branch, err)
+	}
+	if len(pulls) > 0 {
+		c.recordPR(ctx, branch, pulls[0].GetHead().GetSHA(), pulls[0], updates)
+		return true, nil
+	}
+
+	_, err = c.client.Git.DeleteRef(ctx, headOwner, headRepo, "refs/heads/"+branch)
+	if err != nil && common.APIErrorStatus(err) != http.StatusNotFound && common.APIErrorStatus(err) != http.StatusUnprocessableEntity {
+		return false, fmt.Errorf(common.ErrRecoveringPR, branch, err)
+	}
+	c.pending = ""
+	return false, nil
+}
+
+// RecoverPR implements PRRecoverer
+func (c *GiteaPRCreator) RecoverPR(ctx context.Context, updates []*Update) (bool, error) {
+	branch := c.pending
+	if branch == "" {
+		return false, nil
+	}
+	updates, err := mergeUpdates(updates)
+	if err != nil {
+		return false, err
+	}
+	var pulls []giteaPullRequest
+	query := url.Values{"state": []string{"open"}, "limit": []string{"50"}}
+	if err := c.client.do(ctx, http.MethodGet, repoPath(c.owner, c.repo, "pulls"), query, nil, &pulls); err != nil {
+		return false, fmt.Errorf(common.ErrRecoveringPR, branch, err)
+	}
+	for _, pr := range pulls {
+		if pr.Head.Ref == branch {
+			c.recordPR(ctx, branch, pr, updates)
+			return true, nil
+		}
+	}
+
+	// Gitea takes the slashes of branch names unescaped
+	segments := strings.Split(branch, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	err = c.client.do(ctx, http.MethodDelete, repoPath(c.owner, c.repo, append([]string{"branches"}, segments...)...), nil, nil, nil)
+	if err != nil && !isGiteaStatus(err, http.StatusNotFound) {
+		return false, fmt.Errorf(common.ErrRecoveringPR, branch, err)
+	}
+	c.pending = ""
+	return false, nil
+}
+
+// RecoverPR implements PRRecoverer when the wrapped creator does
+func (c *TicketingPRCreator) RecoverPR(ctx context.Context, updates []*Update) (bool, error) {
+	recoverer, ok := c.creator.(PRRecoverer)
+	if !ok {
+		return false, nil
+	}
+	recovered, err := recoverer.RecoverPR(ctx, updates)
+	if recovered {
+		c.ticketCreated()
+	}
+	return recovered, err
+}
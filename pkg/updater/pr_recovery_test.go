@@ -0,0 +1,175 @@
+package updater
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+	"github.com/google/go-github/v72/github"
+)
+
+func TestRetryingPRCreatorRecovers(t *testing.T) {
+	tests := []struct {
+		name        string
+		failCommit  bool // The first attempt fails after creating the branch
+		lostPR      bool // The first attempt opens the pull request but gets a 502
+		wantBranch  int
+		wantDeleted int
+		wantPulls   int
+	}{
+		{name: "failure after the branch", failCommit: true, wantBranch: 2, wantDeleted: 1, wantPulls: 1},
+		{name: "lost pull request response", lostPR: true, wantBranch: 1, wantPulls: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			branches := map[string]bool{}
+			var created, deleted, pulls, commits int
+			var openHead string
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /repos/o/r", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `{"default_branch":"main"}`)
+			})
+			mux.HandleFunc("GET /repos/o/r/git/ref/heads/{ref...}", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `{"ref":"refs/heads/x","object":{"sha":"base-sha","type":"commit"}}`)
+			})
+			mux.HandleFunc("POST /repos/o/r/git/refs", func(w http.ResponseWriter, r *http.Request) {
+				var ref github.Reference
+				_ = json.NewDecoder(r.Body).Decode(&ref)
+				if branches[ref.GetRef()] {
+					w.WriteHeader(http.StatusUnprocessableEntity)
+					fmt.Fprint(w, `{"message":"Reference already exists"}`)
+					return
+				}
+				created++
+				branches[ref.GetRef()] = true
+				w.WriteHeader(http.StatusCreated)
+				fmt.Fprint(w, `{"ref":"refs/heads/x","object":{"sha":"base-sha","type":"commit"}}`)
+			})
+			mux.HandleFunc("DELETE /repos/o/r/git/refs/{ref...}", func(w http.ResponseWriter, r *http.Request) {
+				deleted++
+				delete(branches, "refs/"+r.PathValue("ref"))
+				w.WriteHeader(http.StatusNoContent)
+			})
+			mux.HandleFunc("PATCH /repos/o/r/git/refs/{ref...}", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `{"ref":"refs/heads/x","object":{"sha":"new-commit-sha","type":"commit"}}`)
+			})
+			mux.HandleFunc("/repos/o/r/contents/", func(w http.ResponseWriter, r *http.Request) {
+				content := base64.StdEncoding.EncodeToString([]byte(defaultWorkflowContent()))
+				fmt.Fprintf(w, `{"type":"file","encoding":"base64","content":"%s"}`, content)
+			})
+			for _, path := range []string{"blobs", "trees"} {
+				mux.HandleFunc("POST /repos/o/r/git/"+path, func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusCreated)
+					fmt.Fprint(w, `{"sha":"new-sha"}`)
+				})
+			}
+			mux.HandleFunc("POST /repos/o/r/git/commits", func(w http.ResponseWriter, r *http.Request) {
+				if commits++; tt.failCommit && commits == 1 {
+					w.WriteHeader(http.StatusBadGateway)
+					fmt.Fprint(w, `{"message":"Bad Gateway"}`)
+					return
+				}
+				w.WriteHeader(http.StatusCreated)
+				fmt.Fprint(w, `{"sha":"new-commit-sha"}`)
+			})
+			mux.HandleFunc("POST /repos/o/r/pulls", func(w http.ResponseWriter, r *http.Request) {
+				var pull github.NewPullRequest
+				_ = json.NewDecoder(r.Body).Decode(&pull)
+				pulls++
+				openHead = pull.GetHead()
+				if tt.lostPR && pulls == 1 {
+					w.WriteHeader(http.StatusBadGateway)
+					fmt.Fprint(w, `{"message":"Bad Gateway"}`)
+					return
+				}
+				w.WriteHeader(http.StatusCreated)
+				fmt.Fprint(w, `{"number":1,"html_url":"https://github.com/o/r/pull/1","head":{"sha":"new-commit-sha"}}`)
+			})
+			mux.HandleFunc("GET /repos/o/r/pulls", func(w http.ResponseWriter, r *http.Request) {
+				if openHead == "" || r.URL.Query().Get("head") != "o:"+openHead {
+					fmt.Fprint(w, `[]`)
+					return
+				}
+				fmt.Fprint(w, `[{"number":1,"html_url":"https://github.com/o/r/pull/1","head":{"sha":"new-commit-sha"}}]`)
+			})
+			mux.HandleFunc("POST /repos/o/r/issues/1/labels", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `[]`)
+			})
+			server := httptest.NewServer(mux)
+			defer server.Close()
+
+			client := github.NewClient(nil)
+			client.BaseURL, _ = url.Parse(server.URL + "/")
+			inner := &DefaultPRCreator{client: client, owner: "o", repo: "r"}
+			creator := NewRetryingPRCreator(inner, common.RetryPolicy{MaxAttempts: 3})
+
+			updates := CreateTestUpdates(1, "actions", "checkout", "v2", "v3", ".github/workflows/test.yml")
+			if err := creator.CreatePR(context.Background(), updates); err != nil {
+				t.Fatalf("CreatePR() error = %v", err)
+			}
+			if created != tt.wantBranch || deleted != tt.wantDeleted || pulls != tt.wantPulls {
+				t.Errorf("created %d branches, deleted %d, opened %d pull requests; want %d, %d, %d",
+					created, deleted, pulls, tt.wantBranch, tt.wantDeleted, tt.wantPulls)
+			}
+			if pulls := inner.PullRequests(); len(branches) != 1 || len(pulls) != 1 || pulls[0].Number != 1 || pulls[0].HeadSHA != "new-commit-sha" {
+				t.Errorf("branches = %v, pull requests = %+v; want one branch and #1", branches, pulls)
+			}
+		})
+	}
+}
+
+func TestGiteaPRCreatorRecoverPR(t *testing.T) {
+	var pulls []string // Heads of the open pull requests
+	var deleted []string
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/repos/o/r/pulls", func(w http.ResponseWriter, r *http.Request) {
+		var sb strings.Builder
+		sb.WriteString("[")
+		for i, head := range pulls {
+			if i > 0 {
+				sb.WriteString(",")
+			}
+			fmt.Fprintf(&sb, `{"number":%d,"head":{"ref":%q}}`, i+1, head)
+		}
+		sb.WriteString("]")
+		fmt.Fprint(w, sb.String())
+	})
+	mux.HandleFunc("DELETE /api/v1/repos/o/r/branches/{branch...}", func(w http.ResponseWriter, r *http.Request) {
+		deleted = append(deleted, r.PathValue("branch"))
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("/api/v1/repos/o/r/labels", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[]`)
+	})
+
+	creator := NewGiteaPRCreator(newGiteaTestClient(t, mux), "o", "r")
+	updates := []*Update{CreateTestUpdate("actions", "checkout", "v3", "v4", "ci.yml")}
+
+	// The branch of a failed attempt without pull request is removed
+	creator.pending = "deps/checkout"
+	if recovered, err := creator.RecoverPR(context.Background(), updates); err != nil || recovered {
+		t.Errorf("RecoverPR() = %v, %v; want the branch removed", recovered, err)
+	}
+	if fmt.Sprint(deleted) != "[deps/checkout]" || creator.pending != "" {
+		t.Errorf("deleted branches = %v, pending %q", deleted, creator.pending)
+	}
+
+	// A pull request opened after all is kept
+	pulls = []string{"other", "deps/checkout"}
+	creator.pending = "deps/checkout"
+	if recovered, err := creator.RecoverPR(context.Background(), updates); err != nil || !recovered {
+		t.Errorf("RecoverPR() = %v, %v; want the pull request recovered", recovered, err)
+	}
+	if pulls := creator.PullRequests(); len(deleted) != 1 || len(pulls) != 1 || pulls[0].Number != 2 || pulls[0].Branch != "deps/checkout" {
+		t.Errorf("deleted branches = %v, pull requests = %+v", deleted, pulls)
+	}
+}
@@ -0,0 +1,138 @@
+package updater
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+	"github.com/google/go-github/v72/github"
+)
+
+// PreflightErrorKind classifies why a pull request cannot be created
+type PreflightErrorKind int
+
+const (
+	// BaseBranchMissing means the branch pull requests target does not exist
+	BaseBranchMissing PreflightErrorKind = iota
+	// NoWriteAccess means the token cannot push branches to the repository
+	NoWriteAccess
+)
+
+// PreflightError reports a check made before creating a pull request that
+// would make the creation fail part way
+type PreflightError struct {
+	Owner string
+	Repo  string
+	Kind  PreflightErrorKind
+	Err   error
+}
+
+// Error implements error
+func (e *PreflightError) Error() string {
+	format := common.ErrPreflightBaseBranch
+	if e.Kind == NoWriteAccess {
+		format = common.ErrPreflightWriteAccess
+	}
+	return fmt.Sprintf(format, e.Owner, e.Repo, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *PreflightError) Unwrap() error {
+	return e.Err
+}
+
+// preflight checks that a pull request from branchName can be created and
+// returns the base branch and its head. Missing branches and permissions
+// fail with a PreflightError; rules that may block the update branch, which
+// the token might be allowed to bypass, and the checks required on the base
+// branch are logged as warnings.
+func (c *DefaultPRCreator) preflight(ctx context.Context, branchName string) (string, *github.Reference, error) {
+	base := c.baseBranch
+	if base == "" {
+		repo, _, err := c.client.Repositories.Get(ctx, c.owner, c.repo)
+		if err != nil {
+			return "", nil, fmt.Errorf(common.ErrCreatingBranch, c.accessError(ctx, fmt.Errorf(common.ErrGettingRepository, err)))
+		}
+		base = repo.GetDefaultBranch()
+
+		// Tokens report their permissions on the repository; without push
+		// access only a fork can take the branch
+		if repo.Permissions != nil && !repo.Permissions["push"] && !c.fork {
+			return "", nil, &PreflightError{Owner: c.owner, Repo: c.repo, Kind: NoWriteAccess, Err: fmt.Errorf(common.HintFork)}
+		}
+	}
+
+	// Get the base branch's latest commit
+	ref, _, err := c.client.Git.GetRef(ctx, c.owner, c.repo, "refs/heads/"+base)
+	if err != nil && c.baseBranch != "" && common.APIErrorStatus(err) == http.StatusNotFound {
+		return "", nil, &PreflightError{Owner: c.owner, Repo: c.repo, Kind: BaseBranchMissing, Err: fmt.Errorf(common.ErrGettingBaseBranchRef, base, err)}
+	}
+	if err != nil && c.baseBranch != "" {
+		return "", nil, fmt.Errorf(common.ErrCreatingBranch, c.accessError(ctx, fmt.Errorf(common.ErrGettingBaseBranchRef, base, err)))
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf(common.ErrCreatingBranch, c.accessError(ctx, fmt.Errorf(common.ErrGettingDefaultBranchRef, err)))
+	}
+
+	for _, warning := range c.branchRuleWarnings(ctx, branchName, base) {
+		log.Printf("Warning: %s", warning)
+	}
+	return base, ref, nil
+}
+
+// branchRuleWarnings describes the rules of the update branch that may
+// block it and the status checks required on the base branch. Rules the
+// token cannot read are skipped.
+func (c *DefaultPRCreator) branchRuleWarnings(ctx context.Context, branchName, base string) []string {
+	var warnings []string
+	headOwner, headRepo := c.headRepository()
+	if rules, _, err := c.client.Repositories.GetRulesForBranch(ctx, headOwner, headRepo, branchName, nil); err == nil && rules != nil {
+		if len(rules.Creation) > 0 {
+			warnings = append(warnings, fmt.Sprintf(common.WarnBranchCreationRestricted, headOwner, headRepo, branchName))
+		}
+		if len(rules.RequiredSignatures) > 0 {
+			warnings = append(warnings, fmt.Sprintf(common.WarnSignaturesRequired, headOwner, headRepo, branchName))
+		}
+	}
+
+	var checks []string
+	if rules, _, err := c.client.Repositories.GetRulesForBranch(ctx, c.owner, c.repo, base, nil); err == nil && rules != nil {
+		for _, rule := range rules.RequiredStatusChecks {
+			for _, check := range rule.Parameters.RequiredStatusChecks {
+				checks = append(checks, check.Context)
+			}
+		}
+	}
+	if branch, _, err := c.client.Repositories.GetBranch(ctx, c.owner, c.repo, base, 1); err == nil && branch.GetProtection() != nil {
+		if required := branch.GetProtection().GetRequiredStatusChecks(); required != nil {
+			if required.Contexts != nil {
+				checks = append(checks, *required.Contexts...)
+			}
+			if required.Checks != nil {
+				for _, check := range *required.Checks {
+					checks = append(checks, check.Context)
+				}
+			}
+		}
+	}
+	if len(checks) > 0 {
+		warnings = append(warnings, fmt.Sprintf(common.WarnRequiredStatusChecks, base, strings.Join(uniqueStrings(checks), ", ")))
+	}
+	return warnings
+}
+
+// uniqueStrings returns values without repetitions, in order
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	unique := values[:0]
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,104 @@
+package updater
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v72/github"
+)
+
+func TestPreflight(t *testing.T) {
+	tests := []struct {
+		name       string
+		repo       string // Response of GET /repos/o/r
+		baseBranch string
+		fork       bool
+		wantKind   PreflightErrorKind
+		wantErr    bool
+		wantBase   string
+	}{
+		{name: "writable", repo: `{"default_branch":"main","permissions":{"pull":true,"push":true}}`, wantBase: "main"},
+		{name: "permissions not reported", repo: `{"default_branch":"main"}`, wantBase: "main"},
+		{name: "read-only token", repo: `{"default_branch":"main","permissions":{"pull":true,"push":false}}`, wantErr: true, wantKind: NoWriteAccess},
+		{name: "read-only token with fork", repo: `{"default_branch":"main","permissions":{"pull":true}}`, fork: true, wantBase: "main"},
+		{name: "base branch", baseBranch: "release/1.x", wantBase: "release/1.x"},
+		{name: "missing base branch", baseBranch: "release/2.x", wantErr: true, wantKind: BaseBranchMissing},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /repos/o/r", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, tt.repo)
+			})
+			mux.HandleFunc("GET /repos/o/r/git/ref/heads/{ref...}", func(w http.ResponseWriter, r *http.Request) {
+				if ref := r.PathValue("ref"); ref != "main" && ref != "release/1.x" {
+					http.NotFound(w, r)
+					return
+				}
+				fmt.Fprint(w, `{"ref":"refs/heads/main","object":{"sha":"base-sha","type":"commit"}}`)
+			})
+			server := httptest.NewServer(mux)
+			defer server.Close()
+
+			client := github.NewClient(nil)
+			client.BaseURL, _ = url.Parse(server.URL + "/")
+			creator := &DefaultPRCreator{client: client, owner: "o", repo: "r", baseBranch: tt.baseBranch, fork: tt.fork}
+
+			base, ref, err := creator.preflight(context.Background(), "action-updates-1")
+			if tt.wantErr {
+				var preflightErr *PreflightError
+				if !errors.As(err, &preflightErr) || preflightErr.Kind != tt.wantKind {
+					t.Fatalf("preflight() error = %v, want kind %d", err, tt.wantKind)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("preflight() error = %v", err)
+			}
+			if base != tt.wantBase || ref.GetObject().GetSHA() != "base-sha" {
+				t.Errorf("preflight() = %q, %v, want %q at base-sha", base, ref, tt.wantBase)
+			}
+		})
+	}
+}
+
+func TestBranchRuleWarnings(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /repos/o/r/rules/branches/action-updates-1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"type":"creation","ruleset_id":1},{"type":"required_signatures","ruleset_id":1}]`)
+	})
+	mux.HandleFunc("GET /repos/o/r/rules/branches/main", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"type":"required_status_checks","ruleset_id":2,"parameters":{"strict_required_status_checks_policy":false,"required_status_checks":[{"context":"build"}]}}]`)
+	})
+	mux.HandleFunc("GET /repos/o/r/branches/main", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"name":"main","protected":true,"protection":{"required_status_checks":{"contexts":["build","lint"]}}}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	creator := &DefaultPRCreator{client: client, owner: "o", repo: "r"}
+
+	warnings := creator.branchRuleWarnings(context.Background(), "action-updates-1", "main")
+	want := []string{"restrict creating branch action-updates-1", "require signed commits", "requires status checks build, lint"}
+	if len(warnings) != len(want) {
+		t.Fatalf("branchRuleWarnings() = %q", warnings)
+	}
+	for i, warning := range warnings {
+		if !strings.Contains(warning, want[i]) {
+			t.Errorf("warning %d = %q, want containing %q", i, warning, want[i])
+		}
+	}
+
+	// Rules the token cannot read give no warnings
+	if warnings := creator.branchRuleWarnings(context.Background(), "other", "develop"); len(warnings) != 0 {
+		t.Errorf("branchRuleWarnings() without rules = %q", warnings)
+	}
+}
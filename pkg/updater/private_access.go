@@ -0,0 +1,103 @@
+package updater
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+	"github.com/google/go-github/v72/github"
+)
+
+// ActionTokens maps an owner or owner/repo (lowercase) to the token used to
+// resolve actions hosted there, e.g. a GitHub App installation token that is
+// scoped to a private organization or a subset of its repositories
+type ActionTokens map[string]string
+
+// ParseActionTokens parses a comma separated list of owner[/repo]=ENV_VAR
+// entries. Tokens are read from the named environment variables so they
+// never appear on the command line.
+func ParseActionTokens(spec string) (ActionTokens, error) {
+	tokens := make(ActionTokens)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		scope, envVar, ok := strings.Cut(entry, "=")
+		scope, envVar = strings.TrimSpace(scope), strings.TrimSpace(envVar)
+		if !ok || scope == "" || envVar == "" || strings.Count(scope, "/") > 1 {
+			return nil, fmt.Errorf(common.ErrInvalidActionToken, entry)
+		}
+		value := os.Getenv(envVar)
+		if value == "" {
+			return nil, fmt.Errorf(common.ErrActionTokenEnvEmpty, envVar, scope)
+		}
+		tokens[strings.ToLower(scope)] = value
+	}
+	return tokens, nil
+}
+
+// tokenFor returns the most specific token configured for an action
+func (t ActionTokens) tokenFor(action ActionReference) (string, bool) {
+	owner := strings.ToLower(action.Owner)
+	if token, ok := t[owner+"/"+strings.ToLower(action.Repo())]; ok {
+		return token, true
+	}
+	token, ok := t[owner]
+	return token, ok
+}
+
+// SetActionTokens configures scoped tokens for actions hosted in private
+// repositories. Actions without a matching entry use the default token.
+func (c *DefaultVersionChecker) SetActionTokens(tokens ActionTokens) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.actionTokens = tokens
+	c.scopedClients = nil
+}
+
+// clientFor returns the client to use for an action's repository
+func (c *DefaultVersionChecker) clientFor(action ActionReference) *github.Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if action.Host != "" {
+		return c.hostClientLocked(action.Host)
+	}
+	token, ok := c.actionTokens.tokenFor(action)
+	if !ok {
+		return c.client
+	}
+	if client, ok := c.scopedClients[token]; ok {
+		return client
+	}
+	newClient := c.newClient
+	if newClient == nil {
+		newClient = c.clientOptions.newClient
+	}
+	if c.scopedClients == nil {
+		c.scopedClients = make(map[string]*github.Client)
+	}
+	client := newClient(token)
+	c.scopedClients[token] = client
+	return client
+}
+
+// accessError explains a 403 or 404 from an action's repository, adding
+// advice on granting access to private actions
+func (c *DefaultVersionChecker) accessError(ctx context.Context, action ActionReference, err error) error {
+	diagnosed := common.DiagnoseAccessError(ctx, c.clientFor(action), action.Owner, action.Repo(), err)
+	var accessErr *common.AccessError
+	if errors.As(diagnosed, &accessErr) && accessErr.Kind != common.ResourceNotFound {
+		accessErr.Hints = append(accessErr.Hints, common.HintPrivateAction)
+	}
+	return diagnosed
+}
+
+// isAccessDenied reports whether err is a 403 that is not caused by rate limiting
+func isAccessDenied(err error) bool {
+	return common.APIErrorStatus(err) == http.StatusForbidden && !common.IsRateLimitError(err)
+}
@@ -0,0 +1,152 @@
+package updater
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v72/github"
+)
+
+// newAccessTestChecker returns a checker whose default and scoped clients talk
+// to server. Scoped clients send their token so handlers can check it.
+func newAccessTestChecker(server *httptest.Server) *DefaultVersionChecker {
+	newClient := func(token string) *github.Client {
+		client := github.NewClient(nil)
+		if token != "" {
+			client = client.WithAuthToken(token)
+		}
+		client.BaseURL, _ = url.Parse(server.URL + "/")
+		return client
+	}
+	return &DefaultVersionChecker{client: newClient(""), newClient: newClient}
+}
+
+func TestPrivateActionAccessErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		repo    int // Status of GET /repos/o/r
+		tags    int // Status of the tags and ref endpoints
+		wantErr string
+	}{
+		{name: "repository not visible", repo: http.StatusNotFound, tags: http.StatusNotFound, wantErr: "not found or is not visible to the token"},
+		{name: "access denied", repo: http.StatusOK, tags: http.StatusForbidden, wantErr: "access to o/r was denied"},
+		{name: "repository visible but tag missing", repo: http.StatusOK, tags: http.StatusNotFound, wantErr: "error getting ref for tag v1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/repos/o/r", func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.repo)
+				fmt.Fprint(w, `{"name":"r","private":true}`)
+			})
+			mux.HandleFunc("/repos/o/r/releases/latest", func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.tags)
+				fmt.Fprint(w, `{"message":"error"}`)
+			})
+			mux.HandleFunc("/repos/o/r/tags", func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.tags)
+				fmt.Fprint(w, `{"message":"error"}`)
+			})
+			mux.HandleFunc("/repos/o/r/git/ref/tags/v1", func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.tags)
+				fmt.Fprint(w, `{"message":"error"}`)
+			})
+			server := httptest.NewServer(mux)
+			defer server.Close()
+
+			checker := newAccessTestChecker(server)
+			action := ActionReference{Owner: "o", Name: "r", Version: "v1"}
+
+			_, err := checker.GetCommitHash(context.Background(), action, "v1")
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("GetCommitHash() error = %v, want containing %q", err, tt.wantErr)
+			}
+
+			_, _, err = checker.GetLatestVersion(context.Background(), action)
+			if err == nil {
+				t.Fatal("GetLatestVersion() expected error")
+			}
+			if tt.tags == http.StatusForbidden && checker.releaseFailures != 0 {
+				t.Errorf("access denied counted as releases API failure")
+			}
+		})
+	}
+}
+
+func TestScopedActionTokens(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/private/action/git/ref/tags/v1", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer scoped-token" {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message":"Not Found"}`)
+			return
+		}
+		fmt.Fprint(w, `{"ref":"refs/tags/v1","object":{"type":"commit","sha":"abc123"}}`)
+	})
+	mux.HandleFunc("/repos/private/action", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"message":"Not Found"}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	checker := newAccessTestChecker(server)
+	action := ActionReference{Owner: "private", Name: "action"}
+
+	if _, err := checker.GetCommitHash(context.Background(), action, "v1"); err == nil {
+		t.Fatal("GetCommitHash() without scoped token expected error")
+	}
+
+	checker.SetActionTokens(ActionTokens{"private/action": "scoped-token", "private": "org-token"}) // The repository entry wins
+	hash, err := checker.GetCommitHash(context.Background(), action, "v1")
+	if err != nil {
+		t.Fatalf("GetCommitHash() error = %v", err)
+	}
+	if hash != "abc123" {
+		t.Errorf("GetCommitHash() = %s, want abc123", hash)
+	}
+}
+
+func TestParseActionTokens(t *testing.T) {
+	t.Setenv("ORG_TOKEN", "org")
+	t.Setenv("REPO_TOKEN", "repo")
+
+	tests := []struct {
+		name    string
+		spec    string
+		want    ActionTokens
+		wantErr bool
+	}{
+		{name: "owner and repo", spec: "My-Org=ORG_TOKEN, other/repo=REPO_TOKEN", want: ActionTokens{"my-org": "org", "other/repo": "repo"}},
+		{name: "empty entries ignored", spec: ",My-Org=ORG_TOKEN,", want: ActionTokens{"my-org": "org"}},
+		{name: "missing variable name", spec: "my-org=", wantErr: true},
+		{name: "too many slashes", spec: "a/b/c=ORG_TOKEN", wantErr: true},
+		{name: "unset variable", spec: "my-org=UNSET_ACTION_TOKEN", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseActionTokens(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseActionTokens() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("ParseActionTokens() = %v, want %v", got, tt.want)
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("ParseActionTokens()[%s] = %q, want %q", k, got[k], v)
+				}
+			}
+		})
+	}
+}
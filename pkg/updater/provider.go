@@ -0,0 +1,69 @@
+package updater
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+)
+
+// Supported providers
+const (
+	ProviderGitHub = "github"
+	ProviderGitea  = "gitea" // Also serves Forgejo
+)
+
+// Provider creates the version checker and pull request creator for a forge
+type Provider interface {
+	// NewVersionChecker returns a version checker authenticated with token
+	NewVersionChecker(token string) VersionChecker
+	// NewPRCreator returns a pull request creator for owner/repo
+	NewPRCreator(token, owner, repo string) PRCreator
+}
+
+// NewProvider returns the provider with the given name. baseURL is the
+// instance URL for Gitea and is ignored for GitHub.
+func NewProvider(name, baseURL string) (Provider, error) {
+	switch strings.ToLower(name) {
+	case "", ProviderGitHub:
+		return GitHubProvider{}, nil
+	case ProviderGitea, "forgejo":
+		apiURL, err := parseGiteaURL(baseURL)
+		if err != nil {
+			return nil, err
+		}
+		return GiteaProvider{apiURL: apiURL}, nil
+	default:
+		return nil, fmt.Errorf(common.ErrUnknownProvider, name)
+	}
+}
+
+// GitHubProvider uses the GitHub API
+type GitHubProvider struct{}
+
+// NewVersionChecker implements Provider
+func (GitHubProvider) NewVersionChecker(token string) VersionChecker {
+	return NewDefaultVersionChecker(token)
+}
+
+// NewPRCreator implements Provider
+func (GitHubProvider) NewPRCreator(token, owner, repo string) PRCreator {
+	return NewPRCreator(token, owner, repo)
+}
+
+// GiteaProvider uses the API of a Gitea or Forgejo instance. Create it
+// with NewProvider.
+type GiteaProvider struct {
+	apiURL *url.URL
+}
+
+// NewVersionChecker implements Provider
+func (p GiteaProvider) NewVersionChecker(token string) VersionChecker {
+	return NewGiteaVersionChecker(newGiteaClient(p.apiURL, token))
+}
+
+// NewPRCreator implements Provider
+func (p GiteaProvider) NewPRCreator(token, owner, repo string) PRCreator {
+	return NewGiteaPRCreator(newGiteaClient(p.apiURL, token), owner, repo)
+}
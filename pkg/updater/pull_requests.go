@@ -0,0 +1,73 @@
+package updater
+
+// PullRequest is a pull request opened by a PRCreator
+type PullRequest struct {
+	Number  int
+	URL     string // Web URL
+	HeadSHA string // Commit the pull request proposes
+	Branch  string
+	Updates []*Update // Updates it applies
+	Ticket  *Ticket   // Change ticket gating its auto-merge, if any
+}
+
+// PullRequestLister is implemented by PR creators that report every pull
+// request they opened, so runs opening several can follow up on each
+type PullRequestLister interface {
+	PullRequests() []PullRequest
+}
+
+// PullRequests implements PullRequestLister
+func (c *DefaultPRCreator) PullRequests() []PullRequest {
+	return append([]PullRequest(nil), c.pulls...)
+}
+
+// PullRequests implements PullRequestLister
+func (c *GiteaPRCreator) PullRequests() []PullRequest {
+	return append([]PullRequest(nil), c.pulls...)
+}
+
+// PullRequests implements PullRequestLister when the wrapped creator does
+func (c *RetryingPRCreator) PullRequests() []PullRequest {
+	if lister, ok := c.creator.(PullRequestLister); ok {
+		return lister.PullRequests()
+	}
+	return nil
+}
+
+// PullRequests implements PullRequestLister when the wrapped creator does,
+// adding the change ticket opened for each pull request
+func (c *TicketingPRCreator) PullRequests() []PullRequest {
+	lister, ok := c.creator.(PullRequestLister)
+	if !ok {
+		return nil
+	}
+	pulls := lister.PullRequests()
+	for i := range pulls {
+		if ticket, ok := c.tickets[pulls[i].Number]; ok {
+			pulls[i].Ticket = ticket
+		}
+	}
+	return pulls
+}
+
+// branchesOf returns the branches of pulls
+func branchesOf(pulls []PullRequest) []string {
+	branches := make([]string, 0, len(pulls))
+	for _, pull := range pulls {
+		branches = append(branches, pull.Branch)
+	}
+	return branches
+}
+
+// lastPullRequest returns the pull request creator opened last
+func lastPullRequest(creator PRCreator) (PullRequest, bool) {
+	lister, ok := creator.(PullRequestLister)
+	if !ok {
+		return PullRequest{}, false
+	}
+	pulls := lister.PullRequests()
+	if len(pulls) == 0 {
+		return PullRequest{}, false
+	}
+	return pulls[len(pulls)-1], true
+}
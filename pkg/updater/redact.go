@@ -0,0 +1,95 @@
+package updater
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+)
+
+// RedactedText replaces secrets in pull request bodies and commit messages
+const RedactedText = "[REDACTED]"
+
+// DefaultRedactPatterns match common token formats. Where a pattern has a
+// group named "secret", only that group is redacted.
+var DefaultRedactPatterns = []string{
+	`gh[pousr]_[A-Za-z0-9]{36,}`,                                // GitHub tokens
+	`github_pat_[A-Za-z0-9_]{22,}`,                              // GitHub fine-grained tokens
+	`glpat-[A-Za-z0-9_-]{20,}`,                                  // GitLab tokens
+	`(?:AKIA|ASIA)[0-9A-Z]{16}`,                                 // AWS access key IDs
+	`AIza[0-9A-Za-z_-]{35}`,                                     // Google API keys
+	`xox[abposr]-[A-Za-z0-9-]{10,}`,                             // Slack tokens
+	`eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]+`, // JSON web tokens
+	`-----BEGIN [A-Z ]*PRIVATE KEY-----`,                        // Private keys
+	// Assignments such as "password: hunter22", but not ${{ secrets.X }}
+	`(?i)\b(?:password|passwd|secret|token|api[_-]?key|access[_-]?key)\b["']?\s*[:=]\s*["']?(?P<secret>[^\s"'$]{8,})`,
+}
+
+// defaultRedactor redacts with DefaultRedactPatterns
+var defaultRedactor = mustRedactor(DefaultRedactPatterns)
+
+// Redactor removes tokens and secret-looking strings from generated text,
+// such as values quoted from workflow comments or release notes, before it
+// is published
+type Redactor struct {
+	patterns []*regexp.Regexp
+}
+
+// NewRedactor returns a Redactor matching DefaultRedactPatterns and patterns
+func NewRedactor(patterns []string) (*Redactor, error) {
+	r := &Redactor{patterns: append([]*regexp.Regexp(nil), defaultRedactor.patterns...)}
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf(common.ErrInvalidRedactPattern, pattern, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	return r, nil
+}
+
+// mustRedactor compiles patterns known to be valid
+func mustRedactor(patterns []string) *Redactor {
+	r := &Redactor{}
+	for _, pattern := range patterns {
+		r.patterns = append(r.patterns, regexp.MustCompile(pattern))
+	}
+	return r
+}
+
+// Redact replaces every match in s with RedactedText. A nil Redactor uses
+// DefaultRedactPatterns.
+func (r *Redactor) Redact(s string) string {
+	if r == nil {
+		r = defaultRedactor
+	}
+	for _, re := range r.patterns {
+		group := re.SubexpIndex("secret")
+		if group < 0 {
+			s = re.ReplaceAllLiteralString(s, RedactedText)
+			continue
+		}
+		s = replaceGroup(s, re, group)
+	}
+	return s
+}
+
+// replaceGroup replaces submatch group of every match of re in s
+func replaceGroup(s string, re *regexp.Regexp, group int) string {
+	matches := re.FindAllStringSubmatchIndex(s, -1)
+	if len(matches) == 0 {
+		return s
+	}
+	out := make([]byte, 0, len(s))
+	last := 0
+	for _, match := range matches {
+		start, end := match[2*group], match[2*group+1]
+		if start < 0 {
+			continue
+		}
+		out = append(out, s[last:start]...)
+		out = append(out, RedactedText...)
+		last = end
+	}
+	return string(append(out, s[last:]...))
+}
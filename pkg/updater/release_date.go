@@ -0,0 +1,67 @@
+package updater
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+)
+
+// ReleaseDateProvider is implemented by version checkers that can report
+// when a version of an action was published
+type ReleaseDateProvider interface {
+	GetReleaseDate(ctx context.Context, action ActionReference, version string) (time.Time, error)
+}
+
+// GetReleaseDate returns the publication date of the release for version
+func (c *DefaultVersionChecker) GetReleaseDate(ctx context.Context, action ActionReference, version string) (time.Time, error) {
+	release, _, err := c.clientFor(action).Repositories.GetReleaseByTag(ctx, action.Owner, action.Repo(), version)
+	if err != nil {
+		return time.Time{}, fmt.Errorf(common.ErrGettingReleaseDate, version, err)
+	}
+	if release.PublishedAt != nil {
+		return release.PublishedAt.Time, nil
+	}
+	if release.CreatedAt != nil {
+		return release.CreatedAt.Time, nil
+	}
+	return time.Time{}, fmt.Errorf(common.ErrGettingReleaseDate, version, fmt.Errorf("no date in release"))
+}
+
+// GetReleaseDate implements ReleaseDateProvider when the wrapped checker does
+func (c *CachingVersionChecker) GetReleaseDate(ctx context.Context, action ActionReference, version string) (time.Time, error) {
+	provider, ok := c.checker.(ReleaseDateProvider)
+	if !ok {
+		return time.Time{}, fmt.Errorf(common.ErrGettingReleaseDate, version, fmt.Errorf("not supported"))
+	}
+	return provider.GetReleaseDate(ctx, action, version)
+}
+
+// CommitDateProvider is implemented by version checkers that can report
+// when a commit of an action was made
+type CommitDateProvider interface {
+	GetCommitDate(ctx context.Context, action ActionReference, sha string) (time.Time, error)
+}
+
+// GetCommitDate returns the committer date of sha
+func (c *DefaultVersionChecker) GetCommitDate(ctx context.Context, action ActionReference, sha string) (time.Time, error) {
+	// The commits API also accepts abbreviated SHAs
+	commit, _, err := c.clientFor(action).Repositories.GetCommit(ctx, action.Owner, action.Repo(), sha, nil)
+	if err != nil {
+		return time.Time{}, fmt.Errorf(common.ErrGettingCommitDate, sha, err)
+	}
+	if date := commit.GetCommit().GetCommitter().GetDate(); !date.IsZero() {
+		return date.Time, nil
+	}
+	return commit.GetCommit().GetAuthor().GetDate().Time, nil
+}
+
+// GetCommitDate implements CommitDateProvider when the wrapped checker does
+func (c *CachingVersionChecker) GetCommitDate(ctx context.Context, action ActionReference, sha string) (time.Time, error) {
+	provider, ok := c.checker.(CommitDateProvider)
+	if !ok {
+		return time.Time{}, fmt.Errorf(common.ErrGettingCommitDate, sha, fmt.Errorf("not supported"))
+	}
+	return provider.GetCommitDate(ctx, action, sha)
+}
@@ -0,0 +1,39 @@
+package updater
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v72/github"
+)
+
+func TestGetReleaseDate(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/o/r/releases/tags/v1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"tag_name":"v1","published_at":"2024-03-01T10:00:00Z"}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	checker := NewCachingVersionChecker(&DefaultVersionChecker{client: client}, nil, 0)
+
+	action := ActionReference{Owner: "o", Name: "r"}
+	date, err := checker.GetReleaseDate(context.Background(), action, "v1")
+	if err != nil {
+		t.Fatalf("GetReleaseDate() error = %v", err)
+	}
+	if !date.Equal(time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("GetReleaseDate() = %v", date)
+	}
+
+	if _, err := checker.GetReleaseDate(context.Background(), action, "v2"); err == nil {
+		t.Error("GetReleaseDate() expected error for missing release")
+	}
+}
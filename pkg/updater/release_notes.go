@@ -0,0 +1,116 @@
+package updater
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+)
+
+// ReleaseNotes holds the notes of a release and whether its repository is public
+type ReleaseNotes struct {
+	Body   string
+	Public bool
+}
+
+// ReleaseNotesProvider is implemented by version checkers that can fetch the
+// notes of a release
+type ReleaseNotesProvider interface {
+	GetReleaseNotes(ctx context.Context, action ActionReference, version string) (ReleaseNotes, error)
+}
+
+// GetReleaseNotes returns the notes of the release for version. Actions
+// resolved with a scoped token or hosted on another host, and repositories
+// whose visibility cannot be confirmed, are reported as not public.
+func (c *DefaultVersionChecker) GetReleaseNotes(ctx context.Context, action ActionReference, version string) (ReleaseNotes, error) {
+	client := c.clientFor(action)
+	release, _, err := client.Repositories.GetReleaseByTag(ctx, action.Owner, action.Repo(), version)
+	if err != nil {
+		return ReleaseNotes{}, fmt.Errorf(common.ErrGettingReleaseNotes, version, err)
+	}
+	notes := ReleaseNotes{Body: release.GetBody()}
+
+	c.mu.Lock()
+	_, scoped := c.actionTokens.tokenFor(action)
+	c.mu.Unlock()
+	if scoped || action.Host != "" {
+		return notes, nil
+	}
+	repo, _, err := client.Repositories.Get(ctx, action.Owner, action.Repo())
+	if err == nil {
+		notes.Public = !repo.GetPrivate() && (repo.GetVisibility() == "" || repo.GetVisibility() == "public")
+	}
+	return notes, nil
+}
+
+// GetReleaseNotes implements ReleaseNotesProvider when the wrapped checker does
+func (c *CachingVersionChecker) GetReleaseNotes(ctx context.Context, action ActionReference, version string) (ReleaseNotes, error) {
+	provider, ok := c.checker.(ReleaseNotesProvider)
+	if !ok {
+		return ReleaseNotes{}, fmt.Errorf(common.ErrGettingReleaseNotes, version, fmt.Errorf("not supported"))
+	}
+	return provider.GetReleaseNotes(ctx, action, version)
+}
+
+// SummarizeUpdates sets the ReleaseSummary of each update from the notes of
+// its new version. Only notes of public actions are passed to the summarizer,
+// each release is summarized once, and failures are logged and skipped.
+func SummarizeUpdates(ctx context.Context, checker VersionChecker, summarizer Summarizer, updates []*Update) {
+	provider, ok := checker.(ReleaseNotesProvider)
+	if !ok || summarizer == nil {
+		return
+	}
+
+	summaries := make(map[string]string)
+	for _, update := range updates {
+		if update.Action.GitLabInclude {
+			continue
+		}
+		// Actions in subdirectories of a repository share its releases
+		key := strings.ToLower(update.Action.Repository()) + "@" + update.NewVersion
+		summary, done := summaries[key]
+		if !done {
+			summary = summarizeRelease(ctx, provider, summarizer, update)
+			summaries[key] = summary
+		}
+		update.ReleaseSummary = summary
+	}
+}
+
+// summarizeRelease fetches and summarizes the notes of an update's new version
+func summarizeRelease(ctx context.Context, provider ReleaseNotesProvider, summarizer Summarizer, update *Update) string {
+	notes, err := provider.GetReleaseNotes(ctx, update.Action, update.NewVersion)
+	if err != nil || !notes.Public || strings.TrimSpace(notes.Body) == "" {
+		return ""
+	}
+
+	summary, err := summarizer.Summarize(ctx, SummaryRequest{
+		Action:       update.Action.FullName(),
+		FromVersion:  update.OldVersion,
+		ToVersion:    update.NewVersion,
+		ReleaseNotes: truncate(notes.Body, maxReleaseNotesLength),
+	})
+	if err != nil {
+		log.Printf("Warning: %v", fmt.Errorf(common.ErrSummarizerFailed, update.Action.FullName(), update.NewVersion, err))
+		return ""
+	}
+	return truncate(strings.TrimSpace(summary), maxSummaryLength)
+}
+
+// truncate shortens s to at most n bytes without splitting a UTF-8 sequence
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !isRuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// isRuneStart reports whether b starts a UTF-8 sequence
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
@@ -0,0 +1,199 @@
+package updater
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v72/github"
+)
+
+func TestGetReleaseNotes(t *testing.T) {
+	mux := http.NewServeMux()
+	for _, repo := range []string{"public", "private", "scoped"} {
+		repo := repo
+		mux.HandleFunc("/repos/o/"+repo+"/releases/tags/v2", func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `{"tag_name":"v2","body":"notes of %s"}`, repo)
+		})
+		mux.HandleFunc("/repos/o/"+repo, func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `{"name":%q,"private":%v}`, repo, repo != "public")
+		})
+	}
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	newClient := func(string) *github.Client {
+		client := github.NewClient(nil)
+		client.BaseURL, _ = url.Parse(server.URL + "/")
+		return client
+	}
+	checker := &DefaultVersionChecker{client: newClient(""), newClient: newClient}
+	checker.SetActionTokens(ActionTokens{"o/scoped": "scoped-token"})
+
+	tests := []struct {
+		repo       string
+		wantPublic bool
+	}{
+		{repo: "public", wantPublic: true},
+		{repo: "private", wantPublic: false},
+		{repo: "scoped", wantPublic: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.repo, func(t *testing.T) {
+			notes, err := checker.GetReleaseNotes(context.Background(), ActionReference{Owner: "o", Name: tt.repo}, "v2")
+			if err != nil {
+				t.Fatalf("GetReleaseNotes() error = %v", err)
+			}
+			if notes.Body != "notes of "+tt.repo || notes.Public != tt.wantPublic {
+				t.Errorf("GetReleaseNotes() = %+v, want public %v", notes, tt.wantPublic)
+			}
+		})
+	}
+}
+
+// notesChecker is a version checker serving fixed release notes
+type notesChecker struct {
+	countingChecker
+	notes map[string]ReleaseNotes
+}
+
+func (c *notesChecker) GetReleaseNotes(_ context.Context, action ActionReference, version string) (ReleaseNotes, error) {
+	notes, ok := c.notes[action.Name+"@"+version]
+	if !ok {
+		return ReleaseNotes{}, fmt.Errorf("no release")
+	}
+	return notes, nil
+}
+
+// recordingSummarizer records every request it summarizes
+type recordingSummarizer struct {
+	requests []SummaryRequest
+}
+
+func (s *recordingSummarizer) Summarize(_ context.Context, req SummaryRequest) (string, error) {
+	s.requests = append(s.requests, req)
+	return "  Summary of " + req.Action + " " + req.ToVersion + "\n", nil
+}
+
+func TestSummarizeUpdates(t *testing.T) {
+	checker := &notesChecker{notes: map[string]ReleaseNotes{
+		"public@v2":  {Body: "Long notes", Public: true},
+		"private@v2": {Body: "Secret notes", Public: false},
+		"empty@v2":   {Body: " ", Public: true},
+	}}
+	updates := []*Update{
+		{Action: ActionReference{Owner: "o", Name: "public"}, OldVersion: "v1", NewVersion: "v2", FilePath: "a.yml"},
+		{Action: ActionReference{Owner: "o", Name: "public"}, OldVersion: "v1", NewVersion: "v2", FilePath: "b.yml"},
+		{Action: ActionReference{Owner: "o", Name: "private"}, OldVersion: "v1", NewVersion: "v2"},
+		{Action: ActionReference{Owner: "o", Name: "empty"}, OldVersion: "v1", NewVersion: "v2"},
+		{Action: ActionReference{Owner: "o", Name: "missing"}, OldVersion: "v1", NewVersion: "v2"},
+	}
+	summarizer := &recordingSummarizer{}
+
+	SummarizeUpdates(context.Background(), NewCachingVersionChecker(checker, nil, 0), summarizer, updates)
+
+	if len(summarizer.requests) != 1 {
+		t.Fatalf("summarizer called %d times, want 1: %+v", len(summarizer.requests), summarizer.requests)
+	}
+	want := SummaryRequest{Action: "o/public", FromVersion: "v1", ToVersion: "v2", ReleaseNotes: "Long notes"}
+	if summarizer.requests[0] != want {
+		t.Errorf("request = %+v, want %+v", summarizer.requests[0], want)
+	}
+	for i, update := range updates {
+		wantSummary := ""
+		if i < 2 {
+			wantSummary = "Summary of o/public v2"
+		}
+		if update.ReleaseSummary != wantSummary {
+			t.Errorf("updates[%d].ReleaseSummary = %q, want %q", i, update.ReleaseSummary, wantSummary)
+		}
+	}
+
+	body := prBody(updates)
+	if strings.Count(body, "> Summary of o/public v2") != 1 {
+		t.Errorf("prBody() should show the summary once:\n%s", body)
+	}
+}
+
+func TestNewSummarizer(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    string
+		wantErr bool
+	}{
+		{spec: "", want: "<nil>"},
+		{spec: "command:summarize --short", want: "*updater.CommandSummarizer"},
+		{spec: "https://llm.example.com/summarize", want: "*updater.HTTPSummarizer"},
+		{spec: "http://localhost:8080/summarize", want: "*updater.HTTPSummarizer"},
+		{spec: "http://llm.example.com/summarize", wantErr: true},
+		{spec: "command:", wantErr: true},
+		{spec: "openai", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			s, err := NewSummarizer(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewSummarizer() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && fmt.Sprintf("%T", s) != tt.want && !(s == nil && tt.want == "<nil>") {
+				t.Errorf("NewSummarizer() = %T, want %s", s, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTPSummarizer(t *testing.T) {
+	var got SummaryRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		fmt.Fprint(w, `{"summary":"Adds node 20."}`)
+	}))
+	defer server.Close()
+
+	req := SummaryRequest{Action: "o/r", FromVersion: "v1", ToVersion: "v2", ReleaseNotes: "notes"}
+	s := &HTTPSummarizer{URL: server.URL, Token: "key"}
+	summary, err := s.Summarize(context.Background(), req)
+	if err != nil || summary != "Adds node 20." {
+		t.Fatalf("Summarize() = %q, %v", summary, err)
+	}
+	if got != req {
+		t.Errorf("server received %+v, want %+v", got, req)
+	}
+
+	s.Token = ""
+	if _, err := s.Summarize(context.Background(), req); err == nil {
+		t.Error("Summarize() without token succeeded, want status error")
+	}
+}
+
+func TestCommandSummarizer(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "summarize.sh")
+	content := "#!/bin/sh\nread -r input\necho \"token=${GITHUB_TOKEN} input=${input}\"\n"
+	if err := os.WriteFile(script, []byte(content), 0700); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GITHUB_TOKEN", "ghp_secret")
+
+	s := &CommandSummarizer{Args: []string{script}}
+	summary, err := s.Summarize(context.Background(), SummaryRequest{Action: "o/r", ToVersion: "v2"})
+	if err != nil {
+		t.Fatalf("Summarize() error = %v", err)
+	}
+	if strings.Contains(summary, "ghp_secret") {
+		t.Errorf("summarizer program received the GitHub token: %q", summary)
+	}
+	if !strings.Contains(summary, `"action":"o/r"`) {
+		t.Errorf("summarizer program did not receive the request: %q", summary)
+	}
+}
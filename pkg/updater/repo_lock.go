@@ -0,0 +1,221 @@
+package updater
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+	"github.com/google/go-github/v72/github"
+)
+
+// RepositoryLockRef is the hidden ref holding a repository's lock. It is
+// outside refs/heads, so it shows up neither as a branch nor in clones.
+const RepositoryLockRef = "refs/ghactions-updater/lock"
+
+// repoLockPollInterval is how often a waiting instance retries the lock
+const repoLockPollInterval = 10 * time.Second
+
+// LockInfo is who holds a repository lock and until when, as recorded in the
+// message of the lock commit
+type LockInfo struct {
+	Holder  string    `json:"holder"`
+	Expires time.Time `json:"expires"`
+}
+
+// RepositoryLock keeps concurrent updater instances, e.g. a scheduled and a
+// manual run, from creating branches and pull requests in the same
+// repository at once. The lock is a commit referenced by RepositoryLockRef:
+// creating the ref takes a free lock, and an expired lock is taken over by
+// fast-forwarding the ref to a commit on top of it, so of two instances
+// racing for it only one succeeds. Locks expire after their TTL, so a
+// crashed instance blocks others no longer than that.
+type RepositoryLock struct {
+	client *github.Client
+	owner  string
+	repo   string
+	holder string
+	ttl    time.Duration
+	poll   time.Duration
+	now    func() time.Time
+
+	tree string // SHA of the lock commits' tree, created once
+	sha  string // SHA of the lock commit while held
+}
+
+// NewRepositoryLock creates a lock on owner/repo taken by holder for ttl
+func NewRepositoryLock(client *github.Client, owner, repo, holder string, ttl time.Duration) *RepositoryLock {
+	return &RepositoryLock{
+		client: client,
+		owner:  owner,
+		repo:   repo,
+		holder: holder,
+		ttl:    ttl,
+		poll:   repoLockPollInterval,
+		now:    time.Now,
+	}
+}
+
+// Acquire takes the lock, waiting up to wait for another holder to release
+// it or for its lock to expire. It fails with ErrRepositoryLocked when the
+// lock is still held after wait.
+func (l *RepositoryLock) Acquire(ctx context.Context, wait time.Duration) error {
+	deadline := l.now().Add(wait)
+	for {
+		held, err := l.TryAcquire(ctx)
+		if err != nil || held == nil {
+			return err
+		}
+		if !l.now().Before(deadline) {
+			return fmt.Errorf(common.ErrRepositoryLocked, l.owner, l.repo, held.Holder, held.Expires.Format(time.RFC3339))
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(l.poll):
+		}
+	}
+}
+
+// TryAcquire takes the lock if it is free, expired or already ours. It
+// returns the current holder when another instance holds the lock.
+func (l *RepositoryLock) TryAcquire(ctx context.Context) (*LockInfo, error) {
+	ref, resp, err := l.client.Git.GetRef(ctx, l.owner, l.repo, RepositoryLockRef)
+	if err != nil && (resp == nil || resp.StatusCode != http.StatusNotFound) {
+		return nil, fmt.Errorf(common.ErrAcquiringRepoLock, l.owner, l.repo, err)
+	}
+
+	if ref == nil || ref.GetObject().GetSHA() == "" {
+		sha, err := l.commit(ctx, "")
+		if err != nil {
+			return nil, err
+		}
+		_, resp, err := l.client.Git.CreateRef(ctx, l.owner, l.repo, &github.Reference{
+			Ref:    github.Ptr(RepositoryLockRef),
+			Object: &github.GitObject{SHA: github.Ptr(sha)},
+		})
+		if lostRace(resp) {
+			return l.current(ctx)
+		}
+		if err != nil {
+			return nil, fmt.Errorf(common.ErrAcquiringRepoLock, l.owner, l.repo, err)
+		}
+		l.sha = sha
+		return nil, nil
+	}
+
+	current := ref.GetObject().GetSHA()
+	info, err := l.info(ctx, current)
+	if err != nil {
+		return nil, err
+	}
+	if info.Holder != l.holder && l.now().Before(info.Expires) {
+		return info, nil
+	}
+
+	// Take over the expired lock; the update is refused if another
+	// instance moved the ref since it was read
+	sha, err := l.commit(ctx, current)
+	if err != nil {
+		return nil, err
+	}
+	_, resp, err = l.client.Git.UpdateRef(ctx, l.owner, l.repo, &github.Reference{
+		Ref:    github.Ptr(RepositoryLockRef),
+		Object: &github.GitObject{SHA: github.Ptr(sha)},
+	}, false)
+	if lostRace(resp) {
+		return l.current(ctx)
+	}
+	if err != nil {
+		return nil, fmt.Errorf(common.ErrAcquiringRepoLock, l.owner, l.repo, err)
+	}
+	l.sha = sha
+	return nil, nil
+}
+
+// Release deletes the lock if this instance still holds it
+func (l *RepositoryLock) Release(ctx context.Context) error {
+	if l.sha == "" {
+		return nil
+	}
+	sha := l.sha
+	l.sha = ""
+	ref, _, err := l.client.Git.GetRef(ctx, l.owner, l.repo, RepositoryLockRef)
+	if err != nil {
+		return fmt.Errorf(common.ErrReleasingRepoLock, l.owner, l.repo, err)
+	}
+	if ref.GetObject().GetSHA() != sha {
+		// Our lock expired and was taken over
+		return nil
+	}
+	if _, err := l.client.Git.DeleteRef(ctx, l.owner, l.repo, RepositoryLockRef); err != nil {
+		return fmt.Errorf(common.ErrReleasingRepoLock, l.owner, l.repo, err)
+	}
+	return nil
+}
+
+// current returns the holder of a lock just taken by another instance
+func (l *RepositoryLock) current(ctx context.Context) (*LockInfo, error) {
+	ref, _, err := l.client.Git.GetRef(ctx, l.owner, l.repo, RepositoryLockRef)
+	if err != nil {
+		return nil, fmt.Errorf(common.ErrAcquiringRepoLock, l.owner, l.repo, err)
+	}
+	return l.info(ctx, ref.GetObject().GetSHA())
+}
+
+// info reads the holder and expiry from the lock commit sha. Commits that
+// are not lock commits count as expired.
+func (l *RepositoryLock) info(ctx context.Context, sha string) (*LockInfo, error) {
+	commit, _, err := l.client.Git.GetCommit(ctx, l.owner, l.repo, sha)
+	if err != nil {
+		return nil, fmt.Errorf(common.ErrAcquiringRepoLock, l.owner, l.repo, err)
+	}
+	info := &LockInfo{}
+	_, body, _ := strings.Cut(commit.GetMessage(), "\n\n")
+	if err := json.Unmarshal([]byte(body), info); err != nil {
+		return &LockInfo{}, nil
+	}
+	return info, nil
+}
+
+// commit creates a lock commit held by this instance on top of parent
+func (l *RepositoryLock) commit(ctx context.Context, parent string) (string, error) {
+	if l.tree == "" {
+		tree, _, err := l.client.Git.CreateTree(ctx, l.owner, l.repo, "", []*github.TreeEntry{{
+			Path:    github.Ptr("LOCK"),
+			Mode:    github.Ptr("100644"),
+			Type:    github.Ptr("blob"),
+			Content: github.Ptr("Held by a ghactions-updater run; see the commit message.\n"),
+		}})
+		if err != nil {
+			return "", fmt.Errorf(common.ErrAcquiringRepoLock, l.owner, l.repo, err)
+		}
+		l.tree = tree.GetSHA()
+	}
+
+	info, err := json.Marshal(LockInfo{Holder: l.holder, Expires: l.now().Add(l.ttl).UTC()})
+	if err != nil {
+		return "", err
+	}
+	commit := &github.Commit{
+		Message: github.Ptr("ghactions-updater lock\n\n" + string(info)),
+		Tree:    &github.Tree{SHA: github.Ptr(l.tree)},
+	}
+	if parent != "" {
+		commit.Parents = []*github.Commit{{SHA: github.Ptr(parent)}}
+	}
+	created, _, err := l.client.Git.CreateCommit(ctx, l.owner, l.repo, commit, nil)
+	if err != nil {
+		return "", fmt.Errorf(common.ErrAcquiringRepoLock, l.owner, l.repo, err)
+	}
+	return created.GetSHA(), nil
+}
+
+// lostRace reports whether a ref update was refused because another
+// instance created or moved the ref first
+func lostRace(resp *github.Response) bool {
+	return resp != nil && resp.StatusCode == http.StatusUnprocessableEntity
+}
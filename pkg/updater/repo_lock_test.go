@@ -0,0 +1,205 @@
+package updater
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeLockRepository serves the git refs and commits API a RepositoryLock
+// uses, refusing ref updates that are not fast-forwards
+type fakeLockRepository struct {
+	mu      sync.Mutex
+	ref     string
+	commits map[string]string // SHA to message
+	parents map[string]string // SHA to parent SHA
+
+	beforeUpdate func() // Called before a ref update, to move the ref in between
+}
+
+func newFakeLockRepository() *fakeLockRepository {
+	return &fakeLockRepository{commits: map[string]string{}, parents: map[string]string{}}
+}
+
+// hold makes the lock held by holder until expires
+func (f *fakeLockRepository) hold(holder string, expires time.Time) {
+	info, _ := json.Marshal(LockInfo{Holder: holder, Expires: expires})
+	f.ref = fmt.Sprintf("c%d", len(f.commits)+1)
+	f.commits[f.ref] = "ghactions-updater lock\n\n" + string(info)
+}
+
+func (f *fakeLockRepository) mux() *http.ServeMux {
+	mux := http.NewServeMux()
+	refJSON := func(w http.ResponseWriter) {
+		fmt.Fprintf(w, `{"ref":%q,"object":{"sha":%q}}`, RepositoryLockRef, f.ref)
+	}
+	mux.HandleFunc("GET /repos/acme/api/git/ref/ghactions-updater/lock", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if f.ref == "" {
+			http.Error(w, `{"message":"Not Found"}`, http.StatusNotFound)
+			return
+		}
+		refJSON(w)
+	})
+	mux.HandleFunc("POST /repos/acme/api/git/refs", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		var body struct{ SHA string }
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if f.ref != "" {
+			http.Error(w, `{"message":"Reference already exists"}`, http.StatusUnprocessableEntity)
+			return
+		}
+		f.ref = body.SHA
+		w.WriteHeader(http.StatusCreated)
+		refJSON(w)
+	})
+	mux.HandleFunc("PATCH /repos/acme/api/git/refs/ghactions-updater/lock", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		var body struct{ SHA string }
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if f.beforeUpdate != nil {
+			f.beforeUpdate()
+		}
+		if f.parents[body.SHA] != f.ref {
+			http.Error(w, `{"message":"Update is not a fast forward"}`, http.StatusUnprocessableEntity)
+			return
+		}
+		f.ref = body.SHA
+		refJSON(w)
+	})
+	mux.HandleFunc("DELETE /repos/acme/api/git/refs/ghactions-updater/lock", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.ref = ""
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("POST /repos/acme/api/git/trees", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"sha":"tree"}`)
+	})
+	mux.HandleFunc("POST /repos/acme/api/git/commits", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		var body struct {
+			Message string
+			Parents []string
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		sha := fmt.Sprintf("c%d", len(f.commits)+1)
+		f.commits[sha] = body.Message
+		if len(body.Parents) > 0 {
+			f.parents[sha] = body.Parents[0]
+		}
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"sha":%q}`, sha)
+	})
+	mux.HandleFunc("GET /repos/acme/api/git/commits/{sha}", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		_ = json.NewEncoder(w).Encode(map[string]string{"sha": r.PathValue("sha"), "message": f.commits[r.PathValue("sha")]})
+	})
+	return mux
+}
+
+func TestRepositoryLock(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		setup      func(f *fakeLockRepository)
+		wantHeld   string
+		wantParent bool
+	}{
+		{name: "free"},
+		{name: "held", setup: func(f *fakeLockRepository) { f.hold("other:1", now.Add(time.Minute)) }, wantHeld: "other:1"},
+		{name: "expired", setup: func(f *fakeLockRepository) { f.hold("other:1", now.Add(-time.Minute)) }, wantParent: true},
+		{name: "ours", setup: func(f *fakeLockRepository) { f.hold("me:1", now.Add(time.Minute)) }, wantParent: true},
+		{name: "foreign commit", setup: func(f *fakeLockRepository) { f.ref = "c1"; f.commits["c1"] = "not a lock" }, wantParent: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeLockRepository()
+			if tt.setup != nil {
+				tt.setup(repo)
+			}
+			before := repo.ref
+			lock := NewRepositoryLock(newRepositoriesTestClient(t, repo.mux()), "acme", "api", "me:1", 10*time.Minute)
+			lock.now = func() time.Time { return now }
+
+			held, err := lock.TryAcquire(context.Background())
+			if err != nil {
+				t.Fatalf("TryAcquire() error = %v", err)
+			}
+			if tt.wantHeld != "" {
+				if held == nil || held.Holder != tt.wantHeld {
+					t.Fatalf("TryAcquire() = %+v, want held by %s", held, tt.wantHeld)
+				}
+				if err := lock.Release(context.Background()); err != nil || repo.ref != before {
+					t.Errorf("Release() of a lock not held changed the ref: %v", err)
+				}
+				return
+			}
+			if held != nil {
+				t.Fatalf("TryAcquire() = %+v, want the lock", held)
+			}
+			if !strings.Contains(repo.commits[repo.ref], `"holder":"me:1","expires":"2026-05-01T12:10:00Z"`) {
+				t.Errorf("lock commit = %q", repo.commits[repo.ref])
+			}
+			wantParent := ""
+			if tt.wantParent {
+				wantParent = before
+			}
+			if got := repo.parents[repo.ref]; got != wantParent {
+				t.Errorf("lock commit parent = %q, want %q", got, wantParent)
+			}
+			if err := lock.Release(context.Background()); err != nil {
+				t.Fatalf("Release() error = %v", err)
+			}
+			if repo.ref != "" {
+				t.Errorf("Release() left the ref at %s", repo.ref)
+			}
+		})
+	}
+}
+
+func TestRepositoryLockRace(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	repo := newFakeLockRepository()
+	repo.hold("crashed:1", now.Add(-time.Minute))
+	// Another instance takes over the expired lock after this one read it
+	repo.beforeUpdate = func() {
+		repo.beforeUpdate = nil
+		repo.hold("other:1", now.Add(time.Hour))
+	}
+
+	lock := NewRepositoryLock(newRepositoriesTestClient(t, repo.mux()), "acme", "api", "me:1", time.Hour)
+	lock.now = func() time.Time { return now }
+	lock.poll = time.Millisecond
+	held, err := lock.TryAcquire(context.Background())
+	if err != nil || held == nil || held.Holder != "other:1" {
+		t.Fatalf("TryAcquire() = %+v, %v, want held by other:1", held, err)
+	}
+
+	err = lock.Acquire(context.Background(), 0)
+	if err == nil || !strings.Contains(err.Error(), "acme/api is locked by other:1 until 2026-05-01T13:00:00Z") {
+		t.Errorf("Acquire() error = %v, want locked by other:1", err)
+	}
+
+	// Waiting instances get the lock once it is released
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		repo.mu.Lock()
+		repo.ref = ""
+		repo.mu.Unlock()
+	}()
+	if err := lock.Acquire(context.Background(), time.Minute); err != nil {
+		t.Errorf("Acquire() after release error = %v", err)
+	}
+}
@@ -0,0 +1,164 @@
+package updater
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+	"github.com/google/go-github/v72/github"
+)
+
+// ListOrganizationRepositories returns the full names (owner/repo) of all
+// non-archived repositories in an organization
+func ListOrganizationRepositories(ctx context.Context, client *github.Client, org string) ([]string, error) {
+	var names []string
+	opts := &github.RepositoryListByOrgOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		repos, resp, err := client.Repositories.ListByOrg(ctx, org, opts)
+		if err != nil {
+			return nil, fmt.Errorf(common.ErrListingRepositories, err)
+		}
+		for _, repo := range repos {
+			if repo.GetArchived() {
+				continue
+			}
+			names = append(names, repo.GetFullName())
+		}
+		if resp == nil || resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return names, nil
+}
+
+// ReadRepositoryList reads owner/repo names from a file, one per line.
+// Blank lines and lines starting with # are ignored.
+func ReadRepositoryList(listPath string) ([]string, error) {
+	// #nosec G304 - path is provided by the user running the tool
+	file, err := os.Open(listPath)
+	if err != nil {
+		return nil, fmt.Errorf(common.ErrReadingRepositoryList, err)
+	}
+	defer func() { _ = file.Close() }()
+
+	var names []string
+	lines := bufio.NewScanner(file)
+	for lines.Scan() {
+		line := strings.TrimSpace(lines.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if _, _, err := SplitRepositoryName(line); err != nil {
+			return nil, err
+		}
+		names = append(names, line)
+	}
+	if err := lines.Err(); err != nil {
+		return nil, fmt.Errorf(common.ErrReadingRepositoryList, err)
+	}
+	return names, nil
+}
+
+// SplitRepositoryName splits a full repository name into owner and repo
+func SplitRepositoryName(name string) (string, string, error) {
+	parts := strings.Split(name, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf(common.ErrInvalidRepositoryName, name)
+	}
+	return parts[0], parts[1], nil
+}
+
+// FetchWorkflows downloads the workflow files of a remote repository into
+// destDir, keeping them under workflowsPath so the normal scanner and
+// PR creator can process them. It returns the number of files written.
+func FetchWorkflows(ctx context.Context, client *github.Client, owner, repo, workflowsPath, destDir string) (int, error) {
+	return FetchWorkflowsAt(ctx, client, owner, repo, "", workflowsPath, destDir)
+}
+
+// FetchWorkflowsAt is FetchWorkflows for the given branch, tag or commit;
+// an empty ref reads the default branch
+func FetchWorkflowsAt(ctx context.Context, client *github.Client, owner, repo, ref, workflowsPath, destDir string) (int, error) {
+	fullName := owner + "/" + repo
+	dirPath := path.Clean(filepath.ToSlash(workflowsPath))
+	var opts *github.RepositoryContentGetOptions
+	if ref != "" {
+		opts = &github.RepositoryContentGetOptions{Ref: ref}
+	}
+
+	_, entries, resp, err := client.Repositories.GetContents(ctx, owner, repo, dirPath, opts)
+	if err != nil {
+		if resp != nil && resp.StatusCode == 404 {
+			return 0, nil
+		}
+		return 0, fmt.Errorf(common.ErrFetchingWorkflows, fullName, err)
+	}
+
+	localDir := filepath.Join(destDir, filepath.FromSlash(dirPath))
+	count := 0
+	for _, entry := range entries {
+		name := entry.GetName()
+		if entry.GetType() != "file" || !isWorkflowFile(name) {
+			continue
+		}
+
+		file, _, _, err := client.Repositories.GetContents(ctx, owner, repo, path.Join(dirPath, name), opts)
+		if err != nil {
+			return count, fmt.Errorf(common.ErrFetchingWorkflows, fullName, err)
+		}
+		content, err := file.GetContent()
+		if err != nil {
+			return count, fmt.Errorf(common.ErrFetchingWorkflows, fullName, err)
+		}
+
+		target, err := common.JoinAndValidatePath(localDir, localDir, name)
+		if err != nil {
+			return count, fmt.Errorf(common.ErrFetchingWorkflows, fullName, err)
+		}
+		if err := common.WriteFile(target, []byte(content)); err != nil {
+			return count, fmt.Errorf(common.ErrFetchingWorkflows, fullName, err)
+		}
+		count++
+	}
+	return count, nil
+}
+
+// FetchDependabotConfig downloads the Dependabot configuration of a
+// repository at ref (the default branch when empty) into destDir, keeping
+// its path, when the repository has one
+func FetchDependabotConfig(ctx context.Context, client *github.Client, owner, repo, ref, destDir string) error {
+	var opts *github.RepositoryContentGetOptions
+	if ref != "" {
+		opts = &github.RepositoryContentGetOptions{Ref: ref}
+	}
+	for _, name := range DependabotConfigPaths {
+		source := owner + "/" + repo + "/" + name
+		file, _, resp, err := client.Repositories.GetContents(ctx, owner, repo, name, opts)
+		if err != nil {
+			if resp != nil && resp.StatusCode == 404 {
+				continue
+			}
+			return fmt.Errorf(common.ErrReadingDependabot, source, err)
+		}
+		content, err := file.GetContent()
+		if err != nil {
+			return fmt.Errorf(common.ErrReadingDependabot, source, err)
+		}
+		if err := common.WriteFile(filepath.Join(destDir, filepath.FromSlash(name)), []byte(content)); err != nil {
+			return fmt.Errorf(common.ErrReadingDependabot, source, err)
+		}
+		return nil
+	}
+	return nil
+}
+
+// isWorkflowFile reports whether name has a workflow file extension
+func isWorkflowFile(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	return ext == ".yml" || ext == ".yaml"
+}
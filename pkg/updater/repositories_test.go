@@ -0,0 +1,161 @@
+package updater
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v72/github"
+)
+
+func newRepositoriesTestClient(t *testing.T, mux *http.ServeMux) *github.Client {
+	t.Helper()
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	return client
+}
+
+func TestListOrganizationRepositories(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/orgs/acme/repos", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "2" {
+			fmt.Fprint(w, `[{"full_name":"acme/three"}]`)
+			return
+		}
+		w.Header().Set("Link", fmt.Sprintf(`<%s?page=2>; rel="next"`, r.URL.Path))
+		fmt.Fprint(w, `[{"full_name":"acme/one"},{"full_name":"acme/old","archived":true},{"full_name":"acme/two"}]`)
+	})
+
+	names, err := ListOrganizationRepositories(context.Background(), newRepositoriesTestClient(t, mux), "acme")
+	if err != nil {
+		t.Fatalf("ListOrganizationRepositories() error = %v", err)
+	}
+	want := []string{"acme/one", "acme/two", "acme/three"}
+	if fmt.Sprint(names) != fmt.Sprint(want) {
+		t.Errorf("ListOrganizationRepositories() = %v, want %v", names, want)
+	}
+}
+
+func TestReadRepositoryList(t *testing.T) {
+	dir := t.TempDir()
+	list := filepath.Join(dir, "repos.txt")
+	if err := os.WriteFile(list, []byte("# comment\nacme/one\n\n  acme/two  \n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	names, err := ReadRepositoryList(list)
+	if err != nil {
+		t.Fatalf("ReadRepositoryList() error = %v", err)
+	}
+	if fmt.Sprint(names) != "[acme/one acme/two]" {
+		t.Errorf("ReadRepositoryList() = %v", names)
+	}
+
+	bad := filepath.Join(dir, "bad.txt")
+	if err := os.WriteFile(bad, []byte("not-a-repo\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadRepositoryList(bad); err == nil {
+		t.Error("ReadRepositoryList() expected error for invalid name")
+	}
+	if _, err := ReadRepositoryList(filepath.Join(dir, "missing.txt")); err == nil {
+		t.Error("ReadRepositoryList() expected error for missing file")
+	}
+}
+
+func TestFetchWorkflows(t *testing.T) {
+	workflow := "on: push\njobs:\n  a:\n    steps:\n      - uses: actions/checkout@v3\n"
+	var refs []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/acme/one/contents/.github/workflows", func(w http.ResponseWriter, r *http.Request) {
+		refs = append(refs, r.URL.Query().Get("ref"))
+		fmt.Fprint(w, `[
+			{"type":"file","name":"ci.yml","path":".github/workflows/ci.yml"},
+			{"type":"file","name":"README.md","path":".github/workflows/README.md"},
+			{"type":"dir","name":"nested","path":".github/workflows/nested"}
+		]`)
+	})
+	mux.HandleFunc("/repos/acme/one/contents/.github/workflows/ci.yml", func(w http.ResponseWriter, r *http.Request) {
+		refs = append(refs, r.URL.Query().Get("ref"))
+		fmt.Fprintf(w, `{"type":"file","name":"ci.yml","encoding":"base64","content":%q}`,
+			base64.StdEncoding.EncodeToString([]byte(workflow)))
+	})
+	mux.HandleFunc("/repos/acme/empty/contents/.github/workflows", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"Not Found"}`, http.StatusNotFound)
+	})
+	client := newRepositoriesTestClient(t, mux)
+
+	dest := t.TempDir()
+	count, err := FetchWorkflows(context.Background(), client, "acme", "one", ".github/workflows", dest)
+	if err != nil {
+		t.Fatalf("FetchWorkflows() error = %v", err)
+	}
+	if count != 1 {
+		t.Errorf("FetchWorkflows() count = %d, want 1", count)
+	}
+	content, err := os.ReadFile(filepath.Join(dest, ".github", "workflows", "ci.yml"))
+	if err != nil {
+		t.Fatalf("workflow not written: %v", err)
+	}
+	if string(content) != workflow {
+		t.Errorf("workflow content = %q, want %q", content, workflow)
+	}
+
+	count, err = FetchWorkflows(context.Background(), client, "acme", "empty", ".github/workflows", t.TempDir())
+	if err != nil || count != 0 {
+		t.Errorf("FetchWorkflows() for repository without workflows = %d, %v", count, err)
+	}
+
+	// A ref reads the workflows of another branch
+	refs = nil
+	if _, err := FetchWorkflowsAt(context.Background(), client, "acme", "one", "release/1.x", ".github/workflows", t.TempDir()); err != nil {
+		t.Fatalf("FetchWorkflowsAt() error = %v", err)
+	}
+	if strings.Join(refs, ",") != "release/1.x,release/1.x" {
+		t.Errorf("FetchWorkflowsAt() requested refs %v", refs)
+	}
+}
+
+func TestFetchDependabotConfig(t *testing.T) {
+	config := "version: 2\nupdates:\n  - package-ecosystem: github-actions\n    directory: /\n"
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/acme/one/contents/.github/dependabot.yml", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"Not Found"}`, http.StatusNotFound)
+	})
+	mux.HandleFunc("/repos/acme/one/contents/.github/dependabot.yaml", func(w http.ResponseWriter, r *http.Request) {
+		if ref := r.URL.Query().Get("ref"); ref != "main" {
+			t.Errorf("ref = %q, want main", ref)
+		}
+		fmt.Fprintf(w, `{"type":"file","name":"dependabot.yaml","encoding":"base64","content":%q}`,
+			base64.StdEncoding.EncodeToString([]byte(config)))
+	})
+	mux.HandleFunc("/repos/acme/none/contents/", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"Not Found"}`, http.StatusNotFound)
+	})
+	client := newRepositoriesTestClient(t, mux)
+
+	dest := t.TempDir()
+	if err := FetchDependabotConfig(context.Background(), client, "acme", "one", "main", dest); err != nil {
+		t.Fatalf("FetchDependabotConfig() error = %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(dest, ".github", "dependabot.yaml"))
+	if err != nil || string(content) != config {
+		t.Errorf("configuration = %q, %v", content, err)
+	}
+
+	dest = t.TempDir()
+	if err := FetchDependabotConfig(context.Background(), client, "acme", "none", "", dest); err != nil {
+		t.Errorf("FetchDependabotConfig() without a configuration error = %v", err)
+	}
+	if rules, err := LoadDependabotRules(dest); rules != nil || err != nil {
+		t.Errorf("LoadDependabotRules() = %v, %v, want none", rules, err)
+	}
+}
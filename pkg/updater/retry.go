@@ -0,0 +1,139 @@
+package updater
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+)
+
+// RetryingVersionChecker wraps a VersionChecker and retries lookups that
+// fail with a transient API error
+type RetryingVersionChecker struct {
+	checker VersionChecker
+	policy  common.RetryPolicy
+}
+
+// NewRetryingVersionChecker creates a retrying wrapper around checker
+func NewRetryingVersionChecker(checker VersionChecker, policy common.RetryPolicy) *RetryingVersionChecker {
+	return &RetryingVersionChecker{checker: checker, policy: policy}
+}
+
+// GetLatestVersion implements VersionChecker
+func (c *RetryingVersionChecker) GetLatestVersion(ctx context.Context, action ActionReference) (string, string, error) {
+	var version, hash string
+	err := c.policy.Do(ctx, func() error {
+		var err error
+		version, hash, err = c.checker.GetLatestVersion(ctx, action)
+		return err
+	})
+	return version, hash, err
+}
+
+// IsUpdateAvailable implements VersionChecker
+func (c *RetryingVersionChecker) IsUpdateAvailable(ctx context.Context, action ActionReference) (bool, string, string, error) {
+	var available bool
+	var version, hash string
+	err := c.policy.Do(ctx, func() error {
+		var err error
+		available, version, hash, err = c.checker.IsUpdateAvailable(ctx, action)
+		return err
+	})
+	return available, version, hash, err
+}
+
+// GetCommitHash implements VersionChecker
+func (c *RetryingVersionChecker) GetCommitHash(ctx context.Context, action ActionReference, version string) (string, error) {
+	var hash string
+	err := c.policy.Do(ctx, func() error {
+		var err error
+		hash, err = c.checker.GetCommitHash(ctx, action, version)
+		return err
+	})
+	return hash, err
+}
+
+// GetReleaseDate implements ReleaseDateProvider when the wrapped checker does
+func (c *RetryingVersionChecker) GetReleaseDate(ctx context.Context, action ActionReference, version string) (time.Time, error) {
+	dates, ok := c.checker.(ReleaseDateProvider)
+	if !ok {
+		return time.Time{}, fmt.Errorf(common.ErrGettingReleaseDate, version, fmt.Errorf("not supported"))
+	}
+	var date time.Time
+	err := c.policy.Do(ctx, func() error {
+		var err error
+		date, err = dates.GetReleaseDate(ctx, action, version)
+		return err
+	})
+	return date, err
+}
+
+// GetCommitDate implements CommitDateProvider when the wrapped checker does
+func (c *RetryingVersionChecker) GetCommitDate(ctx context.Context, action ActionReference, sha string) (time.Time, error) {
+	dates, ok := c.checker.(CommitDateProvider)
+	if !ok {
+		return time.Time{}, fmt.Errorf(common.ErrGettingCommitDate, sha, fmt.Errorf("not supported"))
+	}
+	var date time.Time
+	err := c.policy.Do(ctx, func() error {
+		var err error
+		date, err = dates.GetCommitDate(ctx, action, sha)
+		return err
+	})
+	return date, err
+}
+
+// GetReleaseNotes implements ReleaseNotesProvider when the wrapped checker does
+func (c *RetryingVersionChecker) GetReleaseNotes(ctx context.Context, action ActionReference, version string) (ReleaseNotes, error) {
+	provider, ok := c.checker.(ReleaseNotesProvider)
+	if !ok {
+		return ReleaseNotes{}, fmt.Errorf(common.ErrGettingReleaseNotes, version, fmt.Errorf("not supported"))
+	}
+	var notes ReleaseNotes
+	err := c.policy.Do(ctx, func() error {
+		var err error
+		notes, err = provider.GetReleaseNotes(ctx, action, version)
+		return err
+	})
+	return notes, err
+}
+
+// Prefetch implements BatchPrefetcher when the wrapped checker does
+func (c *RetryingVersionChecker) Prefetch(ctx context.Context, actions []ActionReference) error {
+	prefetcher, ok := c.checker.(BatchPrefetcher)
+	if !ok {
+		return nil
+	}
+	return c.policy.Do(ctx, func() error {
+		return prefetcher.Prefetch(ctx, actions)
+	})
+}
+
+// RetryingPRCreator wraps a PRCreator and retries pull request creation
+// that fails with a transient API error
+type RetryingPRCreator struct {
+	creator PRCreator
+	policy  common.RetryPolicy
+}
+
+// NewRetryingPRCreator creates a retrying wrapper around creator
+func NewRetryingPRCreator(creator PRCreator, policy common.RetryPolicy) *RetryingPRCreator {
+	return &RetryingPRCreator{creator: creator, policy: policy}
+}
+
+// CreatePR implements PRCreator. Creating a pull request takes several
+// requests, so before a retry a creator implementing PRRecoverer picks up
+// the pull request of the failed attempt or removes the branch it left.
+func (c *RetryingPRCreator) CreatePR(ctx context.Context, updates []*Update) error {
+	recoverer, _ := c.creator.(PRRecoverer)
+	attempts := 0
+	return c.policy.Do(ctx, func() error {
+		if attempts++; attempts > 1 && recoverer != nil {
+			if recovered, err := recoverer.RecoverPR(ctx, updates); err != nil || recovered {
+				return err
+			}
+		}
+		return c.creator.CreatePR(ctx, updates)
+	})
+}
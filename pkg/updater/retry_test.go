@@ -0,0 +1,71 @@
+package updater
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+	"github.com/google/go-github/v72/github"
+)
+
+// flakyChecker fails its first lookups with the given errors
+type flakyChecker struct {
+	countingChecker
+	errs []error
+}
+
+func (c *flakyChecker) GetLatestVersion(ctx context.Context, action ActionReference) (string, string, error) {
+	if c.latestCalls < len(c.errs) {
+		c.latestCalls++
+		return "", "", c.errs[c.latestCalls-1]
+	}
+	return c.countingChecker.GetLatestVersion(ctx, action)
+}
+
+func TestRetryingVersionChecker(t *testing.T) {
+	serverError := &github.ErrorResponse{Response: &http.Response{StatusCode: http.StatusBadGateway}}
+	notFound := &github.ErrorResponse{Response: &http.Response{StatusCode: http.StatusNotFound}}
+	policy := common.RetryPolicy{MaxAttempts: 3}
+	action := ActionReference{Owner: "actions", Name: "checkout", Version: "v3"}
+
+	inner := &flakyChecker{errs: []error{serverError, serverError}}
+	version, _, err := NewRetryingVersionChecker(inner, policy).GetLatestVersion(context.Background(), action)
+	if err != nil || version != "v4.0.0" || inner.latestCalls != 3 {
+		t.Errorf("GetLatestVersion() = %q, %v after %d calls, want success on the third", version, err, inner.latestCalls)
+	}
+
+	inner = &flakyChecker{errs: []error{notFound}}
+	if _, _, err := NewRetryingVersionChecker(inner, policy).GetLatestVersion(context.Background(), action); !errors.Is(err, notFound) || inner.latestCalls != 1 {
+		t.Errorf("GetLatestVersion() = %v after %d calls, want the 404 without retries", err, inner.latestCalls)
+	}
+}
+
+// flakyCreator fails its first CreatePR calls with err
+type flakyCreator struct {
+	capturingPRCreator
+	failures int
+	calls    int
+}
+
+func (c *flakyCreator) CreatePR(ctx context.Context, updates []*Update) error {
+	c.calls++
+	if c.calls <= c.failures {
+		return &github.ErrorResponse{Response: &http.Response{StatusCode: http.StatusServiceUnavailable}}
+	}
+	return c.capturingPRCreator.CreatePR(ctx, updates)
+}
+
+func TestRetryingPRCreator(t *testing.T) {
+	creator := &flakyCreator{failures: 1}
+	err := NewRetryingPRCreator(creator, common.RetryPolicy{MaxAttempts: 2}).CreatePR(context.Background(), []*Update{{}})
+	if err != nil || creator.calls != 2 || len(creator.updates) != 1 {
+		t.Errorf("CreatePR() = %v after %d calls, want success on the second", err, creator.calls)
+	}
+
+	creator = &flakyCreator{failures: 2}
+	if err := NewRetryingPRCreator(creator, common.RetryPolicy{MaxAttempts: 2}).CreatePR(context.Background(), nil); err == nil || creator.calls != 2 {
+		t.Errorf("CreatePR() = %v after %d calls, want failure after 2 attempts", err, creator.calls)
+	}
+}
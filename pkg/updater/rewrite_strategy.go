@@ -0,0 +1,159 @@
+package updater
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+)
+
+// RewriteStrategy rewrites the contents of a single workflow file for the
+// updates found in it. Embedders can supply their own strategy to control
+// how files are written.
+type RewriteStrategy interface {
+	Rewrite(content string, updates []*Update) (string, error)
+}
+
+// RewriteStrategyFunc adapts a function to the RewriteStrategy interface
+type RewriteStrategyFunc func(content string, updates []*Update) (string, error)
+
+// Rewrite calls f(content, updates)
+func (f RewriteStrategyFunc) Rewrite(content string, updates []*Update) (string, error) {
+	return f(content, updates)
+}
+
+var (
+	// YAMLRewriteStrategy locates references through the YAML syntax tree and
+	// falls back to line-based editing for anything it cannot locate. This
+	// is the default strategy.
+	YAMLRewriteStrategy RewriteStrategy = RewriteStrategyFunc(rewriteYAML)
+
+	// LineRewriteStrategy edits the line recorded for each uses update
+	// without parsing the file. GitLab includes and templated values have no
+	// uses line and are still located through the syntax tree.
+	LineRewriteStrategy RewriteStrategy = RewriteStrategyFunc(rewriteLineBased)
+)
+
+// rewriteStrategyOr returns strategy, or YAMLRewriteStrategy when it is nil
+func rewriteStrategyOr(strategy RewriteStrategy) RewriteStrategy {
+	if strategy == nil {
+		return YAMLRewriteStrategy
+	}
+	return strategy
+}
+
+// ParseRewriteStrategy returns the built-in strategy named "yaml" or "line"
+func ParseRewriteStrategy(name string) (RewriteStrategy, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "yaml":
+		return YAMLRewriteStrategy, nil
+	case "line":
+		return LineRewriteStrategy, nil
+	}
+	return nil, fmt.Errorf(common.ErrUnknownRewriteMode, name)
+}
+
+// rewriteYAML rewrites the include refs, templated values and uses values
+// located through the YAML syntax tree and edits the remaining updates line
+// by line
+func rewriteYAML(content string, updates []*Update) (string, error) {
+	rewritten, remaining := rewriteReferences(content, updates)
+	return rewriteLines(rewritten, remaining)
+}
+
+// rewriteLineBased edits uses references line by line
+func rewriteLineBased(content string, updates []*Update) (string, error) {
+	content, updates = rewriteIncludeRefs(content, updates)
+	content, updates = rewriteTemplateValues(content, updates)
+	return rewriteLines(content, updates)
+}
+
+// rewriteLines applies updates to the lines recorded in them
+func rewriteLines(content string, updates []*Update) (string, error) {
+	// Split content into lines
+	lines := strings.Split(content, "\n")
+
+	// Apply updates bottom-up, so a line that is rewritten into several
+	// never shifts the lines of the updates still to apply
+	sortUpdatesByLine(updates)
+
+	// Apply each update
+	for _, update := range updates {
+		if update.LineNumber <= 0 || update.LineNumber > len(lines) {
+			return "", fmt.Errorf(common.ErrInvalidUpdatePath,
+				fmt.Errorf("invalid line number %d", update.LineNumber))
+		}
+
+		// Get the line and preserve indentation and structure
+		line := lines[update.LineNumber-1]
+
+		// Extract indentation (whitespace at the beginning of the line)
+		indentation := ""
+		for i, c := range line {
+			if !unicode.IsSpace(c) {
+				indentation = line[:i]
+				break
+			}
+		}
+
+		// Check if the line starts with "- name:" which indicates it's a step definition
+		isStepDefinition := strings.Contains(line, "- name:")
+
+		// Apply the update with improved formatting
+		parts := strings.SplitN(line, "#", 2)
+		mainPart := strings.TrimSpace(parts[0])
+
+		// Check if the line contains "uses:" to avoid duplication
+		usesIdx := strings.Index(mainPart, "uses:")
+
+		// References through an alias (steps: *steps, <<: *step) have no
+		// text of their own; the anchor's line carries it
+		if fields := strings.Fields(mainPart); usesIdx < 0 && len(fields) > 0 && strings.HasPrefix(fields[len(fields)-1], "*") {
+			continue
+		}
+
+		// Format the action reference with the new hash
+		actionFullName := update.NewFullName()
+		newActionRef := fmt.Sprintf("%s@%s", actionFullName, update.NewRef())
+		versionComment := ""
+		if comment := updateVersionComment(update); comment != "" {
+			versionComment = "  " + comment
+		}
+
+		var newLine string
+
+		if edit, ok := locateUsesRef(line, update); ok {
+			// Replace only the reference and its version comment
+			newLine = applyScalarEdit(line, edit)
+		} else if usesIdx >= 0 {
+			// Case 1: Line contains "uses:" - preserve the format
+			beforeUses := mainPart[:usesIdx+5] // +5 to include "uses:"
+
+			newLine = fmt.Sprintf("%s%s %s%s", indentation, beforeUses, newActionRef, versionComment)
+		} else if isStepDefinition {
+			// Case 2: This is a step definition line, the "uses:" line will be on the next line
+			// Just keep it as is
+			newLine = line
+		} else {
+			// Case 3: This is a line that should have "uses:" but doesn't (possibly already processed incorrectly)
+			// Add proper indentation and "uses:" prefix
+			// Check if this is a step line (should start with "- " or "  - ")
+			if strings.Contains(line, "- name:") {
+				// This is a step definition line, keep it as is
+				newLine = line
+			} else if strings.HasPrefix(strings.TrimSpace(line), "-") {
+				// This is a step line but not a name line, it should have proper indentation
+				newLine = fmt.Sprintf("%s      uses: %s%s", indentation, newActionRef, versionComment)
+			} else {
+				// This is some other line, add standard indentation
+				newLine = fmt.Sprintf("%s  uses: %s%s", indentation, newActionRef, versionComment)
+			}
+		}
+
+		// Update the lines array
+		lines[update.LineNumber-1] = newLine
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
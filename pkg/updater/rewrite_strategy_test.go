@@ -0,0 +1,127 @@
+package updater
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseRewriteStrategy(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    RewriteStrategy
+		wantErr bool
+	}{
+		{name: "", want: YAMLRewriteStrategy},
+		{name: "yaml", want: YAMLRewriteStrategy},
+		{name: "Line", want: LineRewriteStrategy},
+		{name: "ast", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRewriteStrategy(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRewriteStrategy(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			// Function values cannot be compared, so compare their output
+			in := "steps:\n  - uses: actions/checkout@v3\n"
+			update := &Update{
+				Action:     ActionReference{Owner: "actions", Name: "checkout", Version: "v3"},
+				NewVersion: "v4", NewHash: "abc123", LineNumber: 2, VersionComment: "# v4",
+			}
+			gotOut, _ := got.Rewrite(in, []*Update{update})
+			wantOut, _ := tt.want.Rewrite(in, []*Update{update})
+			if gotOut != wantOut {
+				t.Errorf("ParseRewriteStrategy(%q) rewrote to %q, want %q", tt.name, gotOut, wantOut)
+			}
+		})
+	}
+}
+
+func TestBuiltinRewriteStrategies(t *testing.T) {
+	content := `on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v3  # keep me
+      - name: Setup
+        uses: "actions/setup-go@v4"
+`
+	want := `on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@abc123  # v4  # keep me
+      - name: Setup
+        uses: "actions/setup-go@def456"  # v5
+`
+	for name, strategy := range map[string]RewriteStrategy{"yaml": YAMLRewriteStrategy, "line": LineRewriteStrategy} {
+		t.Run(name, func(t *testing.T) {
+			updates := []*Update{
+				{
+					Action:     ActionReference{Owner: "actions", Name: "checkout", Version: "v3"},
+					NewVersion: "v4", NewHash: "abc123", LineNumber: 6, VersionComment: "# v4",
+				},
+				{
+					Action:     ActionReference{Owner: "actions", Name: "setup-go", Version: "v4"},
+					NewVersion: "v5", NewHash: "def456", LineNumber: 8, VersionComment: "# v5",
+				},
+			}
+			got, err := strategy.Rewrite(content, updates)
+			if err != nil {
+				t.Fatalf("Rewrite() error = %v", err)
+			}
+			if got != want {
+				t.Errorf("Rewrite() =\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestCustomRewriteStrategy(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "ci.yml")
+	if err := os.WriteFile(file, []byte("uses: actions/checkout@v3\n"), 0600); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	update := &Update{FilePath: file, LineNumber: 1, NewVersion: "v4"}
+
+	var calls int
+	manager := NewUpdateManagerWithOptions(dir, UpdateManagerOptions{
+		RewriteStrategy: RewriteStrategyFunc(func(content string, updates []*Update) (string, error) {
+			calls++
+			return strings.ToUpper(content), nil
+		}),
+	})
+	if err := manager.ApplyUpdates(context.Background(), []*Update{update}); err != nil {
+		t.Fatalf("ApplyUpdates() error = %v", err)
+	}
+	got, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	if calls != 1 || string(got) != "USES: ACTIONS/CHECKOUT@V3\n" {
+		t.Errorf("custom strategy called %d times, file = %q", calls, got)
+	}
+
+	// A failing strategy leaves the file untouched
+	manager.SetRewriteStrategy(RewriteStrategyFunc(func(string, []*Update) (string, error) {
+		return "", errors.New("boom")
+	}))
+	err = manager.ApplyUpdates(context.Background(), []*Update{update})
+	if err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("ApplyUpdates() error = %v, want strategy error", err)
+	}
+	if after, _ := os.ReadFile(file); string(after) != string(got) {
+		t.Errorf("file changed after failed rewrite: %q", after)
+	}
+}
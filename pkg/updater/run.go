@@ -0,0 +1,668 @@
+package updater
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/metrics"
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/storage"
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/versions"
+)
+
+// Modes of Run
+const (
+	ModePR     = "pr"      // Create a pull request with the updates
+	ModeStage  = "stage"   // Apply the updates to the local checkout only
+	ModeDryRun = "dry-run" // Only report the updates
+)
+
+// Options configures Run. Checker is required, and so is Creator in ModePR.
+type Options struct {
+	RepoPath           string     // Repository checkout (required)
+	WorkflowsPath      string     // Relative to RepoPath; defaults to .github/workflows
+	GitLabCI           bool       // Also pin project includes in .gitlab-ci.yml
+	WorkflowTemplates  bool       // Also scan workflow-templates directories (organization .github repositories)
+	FollowLocalActions bool       // Also check remote actions used inside local composite actions
+	Mode               string     // ModePR (default), ModeStage or ModeDryRun
+	Paths              PathFilter // Selects the workflow files scanned
+	MaxDepth           int        // Directory levels scanned in each workflows directory; 0 for no limit
+	DiscoverDepth      int        // Also scan the workflows directories of subprojects up to this many levels deep
+	Symlinks           LinkPolicy // Treatment of symbolic links; defaults to DefaultSymlinkPolicy
+	Submodules         LinkPolicy // Treatment of git submodules; defaults to DefaultSubmodulePolicy
+
+	// ParseCache keeps the references parsed from each workflow by content
+	// hash across runs, so only changed files are parsed again; nil parses
+	// every file
+	ParseCache storage.Store
+
+	Checker    VersionChecker
+	Manager    UpdateManager // Defaults to NewUpdateManager(RepoPath)
+	Creator    PRCreator
+	Summarizer Summarizer // Optional release notes summarizer (ModePR only)
+	Policy     UpdatePolicy
+	Snoozes    Snoozes          // Updates deferred for this repository
+	Dependabot *DependabotRules // Allow and ignore rules of the repository's Dependabot configuration
+
+	// FloatingTags keeps references to a major tag, such as @<sha> # v4 or
+	// @v4, on the commit the tag points to instead of the latest release
+	FloatingTags bool
+	// SeparateMajor opens major version updates in a pull request of their
+	// own (ModePR)
+	SeparateMajor bool
+	// MaxUpdatesPerPR splits the updates of a pull request into several of
+	// at most this many updates (ModePR); 0 does not limit them
+	MaxUpdatesPerPR int
+
+	// MinReleaseAge holds back updates to versions released less than this
+	// long ago, in case they are yanked; 0 proposes them at once
+	MinReleaseAge time.Duration
+	// MaxPinAge reports references to versions older than this, even when
+	// no newer version exists; 0 disables the check
+	MaxPinAge time.Duration
+
+	// CommentDrift checks that pinned references match their version
+	// comment: DriftReport, DriftFixComment or DriftFixPin; "" skips the check
+	CommentDrift string
+
+	// ValidateSchema checks each workflow against the GitHub Actions
+	// workflow schema and reports its structural errors
+	ValidateSchema bool
+
+	// CheckPermissions reports jobs that use third-party actions with
+	// write-all permissions or without any permissions set
+	CheckPermissions bool
+
+	// DetectMoves looks up whether the repository of each action was
+	// renamed or transferred, and renames its references to the new
+	// location along with their updates
+	DetectMoves bool
+
+	// Filter, when set, limits the checked actions to those it accepts
+	Filter func(ref ActionReference) bool
+	// Select, when set, picks the updates to apply from the ones found
+	Select func(ctx context.Context, updates []*Update) []*Update
+	// Metrics records run metrics; nil discards them
+	Metrics *metrics.Registry
+}
+
+// Report describes the outcome of Run
+type Report struct {
+	FilesScanned  int
+	Files         []string            // Scanned workflow (and GitLab CI) files
+	RemoteActions []ActionReference   // Remote action references found, checked or not
+	LocalActions  []ActionReference   // Local action references (never checked remotely)
+	Updates       []*Update           // Updates found and selected
+	Applied       bool                // Updates were written (ModeStage) or a PR was created (ModePR)
+	PullRequests  []PullRequest       // Pull requests created (ModePR), when the creator lists them
+	Warnings      []string            // Failures that did not stop the run, such as a failed lookup
+	Failures      []Failure           // Files and references that could not be parsed, checked or updated
+	CommentDrift  []CommentDrift      // Pinned references whose version comment names another commit
+	StalePins     []StalePin          // References older than Options.MaxPinAge
+	SchemaErrors  []SchemaError       // Structural errors in workflows (Options.ValidateSchema)
+	Permissions   []PermissionFinding // Jobs with broad permissions and third-party actions (Options.CheckPermissions)
+}
+
+// Stages of a run at which a file or reference can fail
+const (
+	FailureParse  = "parse"
+	FailureCheck  = "check"
+	FailureUpdate = "update"
+)
+
+// Failure is a file or reference the run skipped because of an error
+type Failure struct {
+	Stage   string           // FailureParse, FailureCheck or FailureUpdate
+	File    string           // File the failure occurred in
+	Action  *ActionReference // Reference that failed; nil for a file that could not be parsed
+	Message string
+	Reason  string // The error alone, for messages that name the file themselves
+}
+
+// warnf logs a failure that does not stop the run, records it and returns
+// the message
+func (r *Report) warnf(format string, args ...any) string {
+	msg := fmt.Sprintf(format, args...)
+	log.Print(msg)
+	r.Warnings = append(r.Warnings, msg)
+	return msg
+}
+
+// failf records a failure of a file or reference with warnf
+func (r *Report) failf(failure Failure, format string, args ...any) {
+	failure.Message = r.warnf(format, args...)
+	r.Failures = append(r.Failures, failure)
+}
+
+// Run scans the workflows of a repository checkout, checks every action for
+// a newer version and, depending on the mode, applies the updates locally
+// or creates a pull request. The report is returned even when a later step
+// fails. Run stops between files once ctx is done, and nothing is applied
+// after cancellation.
+func Run(ctx context.Context, opts Options) (*Report, error) {
+	if opts.RepoPath == "" {
+		return nil, fmt.Errorf(common.ErrMissingRunOption, "RepoPath")
+	}
+	if opts.Checker == nil {
+		return nil, fmt.Errorf(common.ErrMissingRunOption, "Checker")
+	}
+	switch opts.Mode {
+	case "":
+		opts.Mode = ModePR
+	case ModePR, ModeStage, ModeDryRun:
+	default:
+		return nil, fmt.Errorf(common.ErrUnknownRunMode, opts.Mode)
+	}
+	if err := ValidDriftMode(opts.CommentDrift); err != nil {
+		return nil, err
+	}
+	if opts.Mode == ModePR && opts.Creator == nil {
+		return nil, fmt.Errorf(common.ErrMissingRunOption, "Creator")
+	}
+	if opts.WorkflowsPath == "" {
+		opts.WorkflowsPath = ".github/workflows"
+	}
+	if opts.Manager == nil {
+		opts.Manager = NewUpdateManager(opts.RepoPath)
+	}
+	if opts.Metrics == nil {
+		opts.Metrics = metrics.NewRegistry()
+	}
+	rec := opts.Metrics
+
+	report := &Report{}
+	uses, err := scanReferences(ctx, opts, report)
+	if err != nil || len(report.Files) == 0 {
+		return report, err
+	}
+
+	updates, err := checkReferences(ctx, opts, report, uses)
+	if err != nil {
+		return report, err
+	}
+
+	if opts.Select != nil && len(updates) > 0 {
+		updates = opts.Select(ctx, updates)
+	}
+	report.Updates = updates
+
+	if len(updates) == 0 {
+		log.Println(common.ErrNoUpdatesAvailable)
+		return report, nil
+	}
+
+	switch opts.Mode {
+	case ModeStage:
+		if err := opts.Manager.ApplyUpdates(ctx, updates); err != nil {
+			rec.IncError(metrics.CategoryUpdate)
+			return report, fmt.Errorf(common.ErrApplyingUpdates, err)
+		}
+		report.Applied = true
+	case ModePR:
+		if opts.Summarizer != nil {
+			SummarizeUpdates(ctx, opts.Checker, opts.Summarizer, updates)
+		}
+		// Files that could not be parsed would otherwise go unnoticed
+		if setter, ok := opts.Creator.(SkippedFilesSetter); ok {
+			setter.SetSkippedFiles(skippedFiles(report.Failures))
+		}
+		groups := [][]*Update{updates}
+		if opts.SeparateMajor {
+			groups = splitMajor(updates)
+		}
+		if opts.MaxUpdatesPerPR > 0 {
+			var batches [][]*Update
+			for _, group := range groups {
+				batches = append(batches, splitBatches(group, opts.MaxUpdatesPerPR)...)
+			}
+			if len(batches) > len(groups) {
+				log.Printf("Splitting %d updates into %d pull requests of at most %d updates", len(updates), len(batches), opts.MaxUpdatesPerPR)
+			}
+			groups = batches
+		}
+		// A failed pull request leaves those created before it in the report
+		var applied []*Update
+		for _, group := range groups {
+			err := opts.Creator.CreatePR(ctx, group)
+			var noChanges *NoChangesError
+			if errors.As(err, &noChanges) {
+				log.Printf(common.ErrNothingToDo, err)
+				continue
+			}
+			if err != nil {
+				rec.IncError(metrics.CategoryPR)
+				report.setCreated(opts.Creator, applied)
+				return report, fmt.Errorf(common.ErrCreatingPR, err)
+			}
+			applied = append(applied, group...)
+		}
+		report.setCreated(opts.Creator, applied)
+	}
+	return report, nil
+}
+
+// setCreated records the updates applied by the pull requests of creator
+func (r *Report) setCreated(creator PRCreator, applied []*Update) {
+	r.Updates = applied
+	r.Applied = len(applied) > 0
+	if lister, ok := creator.(PullRequestLister); ok {
+		r.PullRequests = lister.PullRequests()
+	}
+}
+
+// ScanReferences scans the workflows of a repository checkout like Run,
+// filling the files and references of the report without checking any
+// action. Checker, Creator and Mode are not used.
+func ScanReferences(ctx context.Context, opts Options) (*Report, error) {
+	if opts.RepoPath == "" {
+		return nil, fmt.Errorf(common.ErrMissingRunOption, "RepoPath")
+	}
+	if opts.WorkflowsPath == "" {
+		opts.WorkflowsPath = ".github/workflows"
+	}
+	if opts.Metrics == nil {
+		opts.Metrics = metrics.NewRegistry()
+	}
+	report := &Report{}
+	_, err := scanReferences(ctx, opts, report)
+	return report, err
+}
+
+// scanReferences scans the workflows selected by opts into report and
+// returns the uses of remote actions accepted by opts.Filter
+func scanReferences(ctx context.Context, opts Options, report *Report) ([]referenceUse, error) {
+	rec := opts.Metrics
+	scanner := NewScanner(opts.RepoPath)
+	scanner.SetPathFilter(opts.Paths)
+	scanner.SetMaxDepth(opts.MaxDepth)
+	scanner.SetSymlinkPolicy(opts.Symlinks)
+	scanner.SetSubmodulePolicy(opts.Submodules)
+	scanner.SetParseCache(opts.ParseCache)
+
+	// GitLab CI includes are pinned alongside the workflows when requested
+	gitlabFile := ""
+	if opts.GitLabCI {
+		if _, err := os.Stat(filepath.Join(opts.RepoPath, GitLabCIFile)); err == nil {
+			gitlabFile = filepath.Join(opts.RepoPath, GitLabCIFile)
+		}
+	}
+
+	// Monorepos can keep workflows in subprojects too
+	var nested []string
+	if opts.DiscoverDepth > 0 {
+		var err error
+		if nested, err = FindWorkflowDirs(ctx, opts.RepoPath, opts.WorkflowsPath, opts.DiscoverDepth, opts.Submodules); err != nil {
+			if ctx.Err() != nil {
+				return nil, fmt.Errorf(common.ErrRunCancelled, ctx.Err())
+			}
+			rec.IncError(metrics.CategoryScan)
+			return nil, fmt.Errorf(common.ErrReadingUpdateFile, err)
+		}
+	}
+
+	// An organization's .github repository keeps workflow templates too
+	if opts.WorkflowTemplates {
+		nested = append(nested, FindWorkflowTemplateDirs(opts.RepoPath)...)
+	}
+
+	// Repositories with only a GitLab CI file, only subproject workflows or
+	// only workflow templates need no workflows directory of their own
+	workflowsDir := filepath.Join(opts.RepoPath, opts.WorkflowsPath)
+	dirs := nested
+	if _, statErr := os.Stat(workflowsDir); (gitlabFile == "" && len(nested) == 0) || statErr == nil {
+		dirs = append([]string{workflowsDir}, nested...)
+	}
+	var files []string
+	seenFiles := make(map[string]bool)
+	for _, dir := range dirs {
+		dirFiles, err := scanner.ScanWorkflowsContext(ctx, dir)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, fmt.Errorf(common.ErrRunCancelled, ctx.Err())
+			}
+			rec.IncError(metrics.CategoryScan)
+			return nil, fmt.Errorf(common.ErrReadingUpdateFile, err)
+		}
+		// Nested directories can also lie inside a scanned one
+		for _, file := range dirFiles {
+			if !seenFiles[file] {
+				seenFiles[file] = true
+				files = append(files, file)
+			}
+		}
+	}
+	if gitlabFile != "" {
+		files = append(files, gitlabFile)
+	}
+	rec.Add(metrics.FilesScanned, float64(len(files)))
+	report.FilesScanned = len(files)
+	report.Files = files
+
+	if len(files) == 0 {
+		log.Println(common.ErrNoWorkflowsFound)
+		return nil, nil
+	}
+
+	// References are collected first so identical ones are checked once
+	var uses []referenceUse
+	checkRef := func(file string, ref ActionReference) {
+		report.RemoteActions = append(report.RemoteActions, ref)
+		if opts.Filter != nil && !opts.Filter(ref) {
+			return
+		}
+		uses = append(uses, referenceUse{file: file, ref: ref})
+	}
+
+	// Local actions are never looked up remotely; they are reported and,
+	// when requested, their own remote uses are checked once per action
+	followedLocal := make(map[string]bool)
+
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf(common.ErrRunCancelled, err)
+		}
+		if file == gitlabFile {
+			includes, err := scanner.ParseGitLabIncludes(file)
+			if err != nil {
+				report.failf(Failure{Stage: FailureParse, File: file, Reason: err.Error()}, common.ErrFailedToParseWorkflow, file, err)
+				rec.IncError(metrics.CategoryParse)
+				continue
+			}
+			for _, ref := range includes {
+				checkRef(file, ref)
+			}
+			continue
+		}
+
+		if opts.ValidateSchema {
+			validateSchema(file, report)
+		}
+		if opts.CheckPermissions {
+			checkPermissions(scanner, file, report)
+		}
+
+		refs, err := scanner.ParseActionReferences(file)
+		if err != nil {
+			report.failf(Failure{Stage: FailureParse, File: file, Reason: err.Error()}, common.ErrFailedToParseWorkflow, file, err)
+			rec.IncError(metrics.CategoryParse)
+			continue
+		}
+
+		for _, ref := range refs {
+			if !ref.IsLocal() {
+				checkRef(file, ref)
+				continue
+			}
+
+			report.LocalActions = append(report.LocalActions, ref)
+			if !opts.FollowLocalActions || followedLocal[ref.LocalPath] {
+				continue
+			}
+			followedLocal[ref.LocalPath] = true
+
+			nested, err := scanner.ParseLocalAction(ref)
+			if err != nil {
+				report.failf(Failure{Stage: FailureParse, File: ref.LocalPath, Reason: err.Error()}, common.ErrFailedToParseWorkflow, ref.LocalPath, err)
+				rec.IncError(metrics.CategoryParse)
+				continue
+			}
+			for _, nestedRef := range nested {
+				checkRef(nestedRef.Path, nestedRef)
+			}
+		}
+	}
+
+	if len(report.LocalActions) > 0 {
+		log.Printf("Found %d local action references (not checked remotely)", len(report.LocalActions))
+	}
+	return uses, nil
+}
+
+// referenceUse is a remote action reference at one location
+type referenceUse struct {
+	file string
+	ref  ActionReference
+}
+
+// referenceCheck is the outcome of checking a unique reference
+type referenceCheck struct {
+	version   string
+	hash      string
+	available bool
+	failed    bool
+	failure   string        // Why the check failed
+	drift     *CommentDrift // Set when the version comment names another commit
+	resolved  string        // Version of a bare hash pin, when known
+	released  time.Time     // Release of the latest version, with Options.MinReleaseAge
+	pinned    time.Time     // Date of the version in use, with Options.MaxPinAge
+}
+
+// uniqueReferenceKey identifies references that resolve to the same update:
+// actions of the same repository at the same version and pinned commit
+func uniqueReferenceKey(ref ActionReference) string {
+	return ref.Repository() + "@" + ref.Version + "#" + ref.CommitHash
+}
+
+// checkReferences checks every unique reference once and creates an update
+// for each location of the references that are out of date
+func checkReferences(ctx context.Context, opts Options, report *Report, uses []referenceUse) ([]*Update, error) {
+	rec := opts.Metrics
+	checks := make(map[string]*referenceCheck)
+	moves := make(map[string]string) // Current location of each repository, with Options.DetectMoves
+	var updates []*Update
+	prefetch(ctx, opts.Checker, uses)
+
+	for _, use := range uses {
+		ref := use.ref
+		rec.Inc(metrics.ActionsChecked)
+
+		key := uniqueReferenceKey(ref)
+		check, ok := checks[key]
+		if !ok {
+			if err := ctx.Err(); err != nil {
+				return nil, fmt.Errorf(common.ErrRunCancelled, err)
+			}
+			check = checkReference(ctx, opts, report, ref)
+			checks[key] = check
+		}
+		if check.failed {
+			report.Failures = append(report.Failures, Failure{Stage: FailureCheck, File: use.file, Action: &use.ref, Message: check.failure})
+			continue
+		}
+		if opts.MaxPinAge > 0 && !check.pinned.IsZero() && time.Since(check.pinned) > opts.MaxPinAge {
+			log.Printf(common.ErrStalePin, use.file, ref.Line, ref.FullName(), ref.Version, check.pinned.Format("2006-01-02"), FormatAge(opts.MaxPinAge))
+			report.StalePins = append(report.StalePins, StalePin{Action: ref, File: use.file, Date: check.pinned})
+		}
+
+		update, err := createUpdate(ctx, opts, use, check)
+		if err != nil {
+			report.failf(Failure{Stage: FailureUpdate, File: use.file, Action: &use.ref}, common.ErrFailedToCreateUpdate, ref.Owner, ref.Name, err)
+			rec.IncError(metrics.CategoryUpdate)
+			continue
+		}
+		if check.drift != nil {
+			drift := *check.drift
+			drift.Action, drift.File = ref, use.file
+			if update != nil {
+				drift.Fixed = DriftFixedByUpdate
+			} else if update, err = fixDrift(ctx, opts.Manager, opts.CommentDrift, &drift); err != nil {
+				report.failf(Failure{Stage: FailureUpdate, File: use.file, Action: &use.ref}, common.ErrFailedToCreateUpdate, ref.Owner, ref.Name, err)
+				rec.IncError(metrics.CategoryUpdate)
+			}
+			log.Printf(common.ErrCommentDrift, use.file, ref.Line, ref.FullName(), ref.CommitHash, ref.Version)
+			report.CommentDrift = append(report.CommentDrift, drift)
+		}
+		if movedTo := detectMove(ctx, opts, ref, moves); movedTo != "" {
+			if update == nil {
+				if update, err = moveUpdate(ctx, opts, use, movedTo); err != nil {
+					report.failf(Failure{Stage: FailureUpdate, File: use.file, Action: &use.ref}, common.ErrFailedToCreateUpdate, ref.Owner, ref.Name, err)
+					rec.IncError(metrics.CategoryUpdate)
+					continue
+				}
+			} else {
+				update.MovedTo = movedTo
+				update.Description += ", moved to " + movedTo
+			}
+		}
+		if update == nil {
+			continue
+		}
+		if check.resolved != "" && update.OldVersion == update.OldHash {
+			update.OldVersion = check.resolved
+		}
+		updates = append(updates, update)
+		rec.Inc(metrics.UpdatesFound)
+	}
+
+	if len(checks) < len(uses) {
+		log.Printf("Checked %d unique action references for %d uses", len(checks), len(uses))
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf(common.ErrRunCancelled, err)
+	}
+	return updates, nil
+}
+
+// prefetch resolves the actions of uses in batches when the checker can,
+// so the lookups that follow are answered from memory. Failures only cost
+// the batching; each action is still looked up on its own.
+func prefetch(ctx context.Context, checker VersionChecker, uses []referenceUse) {
+	prefetcher, ok := checker.(BatchPrefetcher)
+	if !ok || len(uses) == 0 {
+		return
+	}
+	actions := make([]ActionReference, 0, len(uses))
+	for _, use := range uses {
+		actions = append(actions, use.ref)
+	}
+	if err := prefetcher.Prefetch(ctx, actions); err != nil {
+		log.Printf(common.ErrGraphQLPrefetch, err)
+	}
+}
+
+// createUpdate creates the update of a use whose reference is out of date,
+// or returns nil when it is current or the update is not wanted
+func createUpdate(ctx context.Context, opts Options, use referenceUse, check *referenceCheck) (*Update, error) {
+	ref := use.ref
+	if !check.available {
+		return nil, nil
+	}
+	if !opts.Policy.AllowsChannel(ref, check.version) {
+		log.Printf(common.ErrUpdatePrerelease, ref.FullName(), ref.Version, check.version)
+		return nil, nil
+	}
+	if opts.Policy.SkipMajor && VersionDelta(ref.Version, check.version) == DeltaMajor {
+		log.Printf(common.ErrUpdateMajor, ref.FullName(), ref.Version, check.version)
+		return nil, nil
+	}
+	if !opts.Policy.Allows(ref, check.version) {
+		log.Printf(common.ErrUpdateBelowPolicy, ref.FullName(), ref.Version, check.version)
+		return nil, nil
+	}
+	if opts.Snoozes.Covers(ref, check.version, time.Now()) {
+		log.Printf(common.ErrUpdateSnoozed, ref.FullName(), ref.Version, check.version)
+		return nil, nil
+	}
+	if !opts.Dependabot.Allows(ref, check.version) {
+		log.Printf(common.ErrUpdateIgnored, ref.FullName(), ref.Version, check.version)
+		return nil, nil
+	}
+	if opts.MinReleaseAge > 0 && time.Since(check.released) < opts.MinReleaseAge {
+		log.Printf(common.ErrUpdateTooNew, ref.FullName(), ref.Version, check.version, check.released.Format("2006-01-02"), FormatAge(opts.MinReleaseAge))
+		return nil, nil
+	}
+	return opts.Manager.CreateUpdate(ctx, use.file, ref, check.version, check.hash)
+}
+
+// isFloatingTag reports whether ref uses a major tag such as v4, which
+// actions move along with each release of the major version
+func isFloatingTag(ref ActionReference) bool {
+	if ref.RefType() == RefTypeSHA && (ref.CommitHash == "" || ref.Version == ref.CommitHash) {
+		return false
+	}
+	version, err := versions.Parse(ref.Version)
+	return err == nil && version.IsMajorOnly()
+}
+
+// checkFloatingTag checks a reference to a major tag against the commit the
+// tag points to now; the version stays the same
+func checkFloatingTag(ctx context.Context, opts Options, report *Report, ref ActionReference) *referenceCheck {
+	hash, err := opts.Checker.GetCommitHash(ctx, ref, ref.Version)
+	if err != nil {
+		opts.Metrics.IncError(metrics.CategoryCheck)
+		return &referenceCheck{failed: true, failure: report.warnf(common.ErrFailedToCheckAction, ref.Owner, ref.Name, err)}
+	}
+	return &referenceCheck{version: ref.Version, hash: hash, available: ref.CommitHash != hash}
+}
+
+// checkLatest checks a reference against the latest version of its action
+func checkLatest(ctx context.Context, opts Options, report *Report, ref ActionReference) *referenceCheck {
+	latestVersion, latestHash, err := opts.Checker.GetLatestVersion(ctx, ref)
+	if err != nil {
+		opts.Metrics.IncError(metrics.CategoryCheck)
+		return &referenceCheck{failed: true, failure: report.warnf(common.ErrFailedToCheckAction, ref.Owner, ref.Name, err)}
+	}
+
+	available, _, _, err := opts.Checker.IsUpdateAvailable(ctx, ref)
+	if err != nil {
+		opts.Metrics.IncError(metrics.CategoryCheck)
+		return &referenceCheck{failed: true, failure: report.warnf(common.ErrFailedToCheckUpdate, ref.Owner, ref.Name, err)}
+	}
+	return &referenceCheck{version: latestVersion, hash: latestHash, available: available}
+}
+
+// checkReference looks up the latest version of a single reference
+func checkReference(ctx context.Context, opts Options, report *Report, ref ActionReference) *referenceCheck {
+	rec := opts.Metrics
+	var check *referenceCheck
+	if opts.FloatingTags && isFloatingTag(ref) {
+		check = checkFloatingTag(ctx, opts, report, ref)
+	} else {
+		check = checkLatest(ctx, opts, report, ref)
+	}
+	if check.failed {
+		return check
+	}
+	available, latestVersion, latestHash := check.available, check.version, check.hash
+
+	// Show which version a bare hash pin is instead of the hash
+	if available {
+		resolved, err := resolveBareHash(ctx, opts.Checker, ref)
+		if err != nil {
+			log.Printf(common.ErrFailedToResolve, ref.FullName(), ref.CommitHash, err)
+		}
+		check.resolved = resolved
+	}
+
+	// Releases of unknown age are not held back
+	if available && opts.MinReleaseAge > 0 {
+		released, err := publishedAt(ctx, opts.Checker, ref, latestVersion, latestHash)
+		if err != nil {
+			log.Printf(common.ErrReleaseAgeUnknown, ref.FullName(), latestVersion, err)
+		}
+		check.released = released
+	}
+	if opts.MaxPinAge > 0 {
+		pinned, err := pinDate(ctx, opts.Checker, ref)
+		if err != nil {
+			report.warnf(common.ErrFailedToCheckAction, ref.Owner, ref.Name, err)
+			rec.IncError(metrics.CategoryCheck)
+		}
+		check.pinned = pinned
+	}
+
+	if opts.CommentDrift != "" {
+		drift, err := CheckCommentDrift(ctx, opts.Checker, ref)
+		if err != nil {
+			report.warnf(common.ErrCheckingDrift, ref.FullName(), ref.Version, err)
+			rec.IncError(metrics.CategoryCheck)
+		}
+		check.drift = drift
+	}
+	return check
+}
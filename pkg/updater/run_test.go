@@ -0,0 +1,409 @@
+package updater
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/metrics"
+)
+
+// capturingPRCreator records the updates of each created PR
+type capturingPRCreator struct {
+	updates []*Update
+	prs     int
+	err     error
+	pulls   []PullRequest
+}
+
+func (c *capturingPRCreator) CreatePR(ctx context.Context, updates []*Update) error {
+	c.updates = append(c.updates, updates...)
+	c.prs++
+	if c.err == nil {
+		c.pulls = append(c.pulls, PullRequest{Number: c.prs, HeadSHA: fmt.Sprintf("sha%d", c.prs), Updates: updates})
+	}
+	return c.err
+}
+
+func (c *capturingPRCreator) PullRequests() []PullRequest {
+	return c.pulls
+}
+
+const runWorkflow = `on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v3
+      - uses: ./.github/actions/build
+      - uses: octo/tool@v1
+`
+
+// writeRunRepo creates a repository checkout with a single workflow
+func writeRunRepo(t *testing.T) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	workflow := filepath.Join(dir, ".github", "workflows", "ci.yml")
+	if err := os.MkdirAll(filepath.Dir(workflow), 0750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(workflow, []byte(runWorkflow), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return dir, workflow
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name        string
+		mode        string
+		filter      func(ActionReference) bool
+		wantUpdates int
+		wantApplied bool
+		wantPR      int
+		wantChanged bool
+	}{
+		{name: "pr", mode: ModePR, wantUpdates: 2, wantApplied: true, wantPR: 2},
+		{name: "default mode", wantUpdates: 2, wantApplied: true, wantPR: 2},
+		{name: "stage", mode: ModeStage, wantUpdates: 2, wantApplied: true, wantChanged: true},
+		{name: "dry run", mode: ModeDryRun, wantUpdates: 2},
+		{
+			name:        "filter",
+			mode:        ModePR,
+			filter:      func(ref ActionReference) bool { return ref.Owner == "octo" },
+			wantUpdates: 1, wantApplied: true, wantPR: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, workflow := writeRunRepo(t)
+			creator := &capturingPRCreator{}
+			reg := metrics.NewRegistry()
+
+			rep, err := Run(context.Background(), Options{
+				RepoPath: dir,
+				Mode:     tt.mode,
+				Checker:  &countingChecker{},
+				Creator:  creator,
+				Filter:   tt.filter,
+				Metrics:  reg,
+			})
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if rep.FilesScanned != 1 || len(rep.LocalActions) != 1 {
+				t.Errorf("report = %+v, want 1 file and 1 local action", rep)
+			}
+			if len(rep.Updates) != tt.wantUpdates || rep.Applied != tt.wantApplied || len(creator.updates) != tt.wantPR {
+				t.Errorf("updates = %d, applied = %v, PR updates = %d", len(rep.Updates), rep.Applied, len(creator.updates))
+			}
+			if got := reg.Get(metrics.UpdatesFound); got != float64(tt.wantUpdates) {
+				t.Errorf("updates found metric = %v, want %d", got, tt.wantUpdates)
+			}
+			content, _ := os.ReadFile(workflow)
+			if changed := string(content) != runWorkflow; changed != tt.wantChanged {
+				t.Errorf("workflow changed = %v, want %v:\n%s", changed, tt.wantChanged, content)
+			}
+		})
+	}
+}
+
+func TestRunAlreadyApplied(t *testing.T) {
+	dir, _ := writeRunRepo(t)
+	creator := &capturingPRCreator{err: fmt.Errorf("retrying: %w", &NoChangesError{Base: "main"})}
+	rep, err := Run(context.Background(), Options{RepoPath: dir, Checker: &countingChecker{}, Creator: creator})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(creator.updates) != 2 || len(rep.Updates) != 0 || rep.Applied {
+		t.Errorf("PR updates = %d, updates = %d, applied = %v, want nothing to do", len(creator.updates), len(rep.Updates), rep.Applied)
+	}
+}
+
+func TestRunSelectAndPolicy(t *testing.T) {
+	dir, _ := writeRunRepo(t)
+	creator := &capturingPRCreator{}
+
+	rep, err := Run(context.Background(), Options{
+		RepoPath: dir,
+		Checker:  &countingChecker{},
+		Creator:  creator,
+		// v1 -> v4.0.0 is a major bump, v3 -> v4.0.0 as well
+		Policy: UpdatePolicy{MinDelta: DeltaMajor},
+		Select: func(ctx context.Context, updates []*Update) []*Update {
+			return updates[:1]
+		},
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(rep.Updates) != 1 || len(creator.updates) != 1 {
+		t.Errorf("updates = %d, PR updates = %d, want 1 selected", len(rep.Updates), len(creator.updates))
+	}
+}
+
+func TestRunMajorUpdates(t *testing.T) {
+	workflow := "on: push\njobs:\n  build:\n    runs-on: ubuntu-latest\n    steps:\n      - uses: actions/checkout@v3\n      - uses: octo/tool@v4\n"
+	tests := []struct {
+		name        string
+		opts        Options
+		wantUpdates int
+		wantPRs     int
+	}{
+		{name: "one pull request", wantUpdates: 2, wantPRs: 1},
+		// actions/checkout@v3 to v4.0.0 is a major update, octo/tool@v4 is
+		// only pinned
+		{name: "separate major", opts: Options{SeparateMajor: true}, wantUpdates: 2, wantPRs: 2},
+		{name: "skip major", opts: Options{Policy: UpdatePolicy{SkipMajor: true}}, wantUpdates: 1, wantPRs: 1},
+		{name: "batches", opts: Options{MaxUpdatesPerPR: 1}, wantUpdates: 2, wantPRs: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, file := writeRunRepo(t)
+			if err := os.WriteFile(file, []byte(workflow), 0600); err != nil {
+				t.Fatal(err)
+			}
+			creator := &capturingPRCreator{}
+			opts := tt.opts
+			opts.RepoPath, opts.Checker, opts.Creator = dir, &countingChecker{}, creator
+
+			rep, err := Run(context.Background(), opts)
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if len(rep.Updates) != tt.wantUpdates || creator.prs != tt.wantPRs || len(rep.PullRequests) != tt.wantPRs || !rep.Applied {
+				t.Errorf("updates = %d, pull requests = %d (%d reported), applied = %v; want %d and %d",
+					len(rep.Updates), creator.prs, len(rep.PullRequests), rep.Applied, tt.wantUpdates, tt.wantPRs)
+			}
+		})
+	}
+}
+
+// failingPRCreator fails every CreatePR call after the first succeeded
+type failingPRCreator struct {
+	capturingPRCreator
+}
+
+func (c *failingPRCreator) CreatePR(ctx context.Context, updates []*Update) error {
+	if c.prs > 0 {
+		c.err = errors.New("unavailable")
+	}
+	return c.capturingPRCreator.CreatePR(ctx, updates)
+}
+
+func TestRunPartialFailure(t *testing.T) {
+	workflow := "on: push\njobs:\n  build:\n    runs-on: ubuntu-latest\n    steps:\n      - uses: actions/checkout@v3\n      - uses: octo/tool@v4\n"
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{name: "separate major", opts: Options{SeparateMajor: true}},
+		{name: "batches", opts: Options{MaxUpdatesPerPR: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, file := writeRunRepo(t)
+			if err := os.WriteFile(file, []byte(workflow), 0600); err != nil {
+				t.Fatal(err)
+			}
+			creator := &failingPRCreator{}
+			opts := tt.opts
+			opts.RepoPath, opts.Checker, opts.Creator = dir, &countingChecker{}, creator
+
+			// The second pull request fails, the first one stays
+			rep, err := Run(context.Background(), opts)
+			if err == nil {
+				t.Fatal("Run() expected error")
+			}
+			if rep == nil || !rep.Applied || len(rep.Updates) != 1 || len(rep.PullRequests) != 1 || rep.PullRequests[0].Number != 1 {
+				t.Fatalf("report = %+v, want the first pull request", rep)
+			}
+		})
+	}
+}
+
+func TestRunFloatingTags(t *testing.T) {
+	dir, file := writeRunRepo(t)
+	workflow := `on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@1111111111111111111111111111111111111111 # v4
+      - uses: octo/tool@v1
+      - uses: octo/setup@2222222222222222222222222222222222222222 # v2
+      - uses: octo/lint@v1.2.0
+`
+	if err := os.WriteFile(file, []byte(workflow), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	rep, err := Run(context.Background(), Options{RepoPath: dir, Mode: ModeStage, Checker: &countingChecker{}, FloatingTags: true})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	// octo/setup already points to where v2 is, octo/lint@v1.2.0 is not a
+	// major tag and is updated to the latest release
+	if len(rep.Updates) != 3 {
+		t.Fatalf("got %d updates, want 3", len(rep.Updates))
+	}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"actions/checkout@2222222222222222222222222222222222222222 # v4\n",
+		"octo/tool@2222222222222222222222222222222222222222  # v1\n",
+		"octo/setup@2222222222222222222222222222222222222222 # v2\n",
+		"octo/lint@1111111111111111111111111111111111111111  # v4.0.0\n",
+	} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("workflow does not contain %q:\n%s", want, content)
+		}
+	}
+}
+
+func TestRunChecksIdenticalReferencesOnce(t *testing.T) {
+	dir, workflow := writeRunRepo(t)
+	release := `on: push
+jobs:
+  release:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v3
+      - uses: actions/checkout@v2
+      - uses: actions/checkout@v3
+`
+	if err := os.WriteFile(filepath.Join(filepath.Dir(workflow), "release.yml"), []byte(release), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	checker := &countingChecker{}
+	reg := metrics.NewRegistry()
+	rep, err := Run(context.Background(), Options{RepoPath: dir, Mode: ModeStage, Checker: checker, Metrics: reg})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	// actions/checkout@v3, actions/checkout@v2 and octo/tool@v1 are each
+	// looked up once (GetLatestVersion and IsUpdateAvailable) for five uses
+	if checker.latestCalls != 6 {
+		t.Errorf("checker lookups = %d, want 6", checker.latestCalls)
+	}
+	if len(rep.Updates) != 5 {
+		t.Fatalf("got %d updates, want one per use (5)", len(rep.Updates))
+	}
+	if got := reg.Get(metrics.ActionsChecked); got != 5 {
+		t.Errorf("actions checked = %v, want 5", got)
+	}
+	content, err := os.ReadFile(filepath.Join(filepath.Dir(workflow), "release.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(content), "@v3") || strings.Contains(string(content), "@v2") {
+		t.Errorf("not every use was updated:\n%s", content)
+	}
+}
+
+func TestRunFailures(t *testing.T) {
+	dir, workflow := writeRunRepo(t)
+	files := map[string]string{
+		"release.yml": "on: push\njobs:\n  release:\n    runs-on: ubuntu-latest\n    steps:\n      - uses: actions/checkout@v3\n",
+		"broken.yml":  "jobs: [\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(filepath.Dir(workflow), name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rep, err := Run(context.Background(), Options{RepoPath: dir, Mode: ModeDryRun, Checker: &countingChecker{err: errors.New("lookup failed")}})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	// Every use of a reference that could not be checked is a failure; the
+	// warning is logged once per reference
+	var got []string
+	for _, failure := range rep.Failures {
+		entry := failure.Stage + " " + filepath.Base(failure.File)
+		if failure.Action != nil {
+			entry += fmt.Sprintf(":%d %s@%s", failure.Action.Line, failure.Action.FullName(), failure.Action.Version)
+		}
+		if !strings.Contains(failure.Message, "broken.yml") && !strings.Contains(failure.Message, "lookup failed") {
+			t.Errorf("failure %s message = %q", entry, failure.Message)
+		}
+		got = append(got, entry)
+	}
+	want := []string{"parse broken.yml", "check ci.yml:6 actions/checkout@v3", "check ci.yml:8 octo/tool@v1", "check release.yml:6 actions/checkout@v3"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("failures = %q, want %q", got, want)
+	}
+	if len(rep.Warnings) != 3 {
+		t.Errorf("warnings = %q, want 3", rep.Warnings)
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	dir, _ := writeRunRepo(t)
+	checker := &countingChecker{}
+
+	tests := []struct {
+		name    string
+		opts    Options
+		wantErr string
+	}{
+		{name: "missing repo path", opts: Options{Checker: checker, Mode: ModeDryRun}, wantErr: "RepoPath"},
+		{name: "missing checker", opts: Options{RepoPath: dir, Mode: ModeDryRun}, wantErr: "Checker"},
+		{name: "missing creator", opts: Options{RepoPath: dir, Checker: checker}, wantErr: "Creator"},
+		{name: "unknown mode", opts: Options{RepoPath: dir, Checker: checker, Mode: "apply"}, wantErr: "unknown run mode"},
+		{
+			name:    "PR failure",
+			opts:    Options{RepoPath: dir, Checker: checker, Creator: &capturingPRCreator{err: errors.New("boom")}},
+			wantErr: "boom",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Run(context.Background(), tt.opts)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Run() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRunCancelled(t *testing.T) {
+	dir, workflow := writeRunRepo(t)
+	creator := &capturingPRCreator{}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// Cancel while the first action is being checked
+	checker := &cancellingChecker{cancel: cancel}
+	_, err := Run(ctx, Options{RepoPath: dir, Mode: ModeStage, Checker: checker, Creator: creator})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Run() error = %v, want context.Canceled", err)
+	}
+	if checker.calls != 1 {
+		t.Errorf("checker called %d times after cancellation, want 1", checker.calls)
+	}
+	if content, _ := os.ReadFile(workflow); string(content) != runWorkflow {
+		t.Errorf("workflow changed after cancellation:\n%s", content)
+	}
+}
+
+// cancellingChecker cancels the run on its first lookup
+type cancellingChecker struct {
+	countingChecker
+	cancel context.CancelFunc
+	calls  int
+}
+
+func (c *cancellingChecker) GetLatestVersion(ctx context.Context, action ActionReference) (string, string, error) {
+	c.calls++
+	c.cancel()
+	return c.countingChecker.GetLatestVersion(ctx, action)
+}
@@ -0,0 +1,158 @@
+package updater
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultRunnerPins maps mutable GitHub-hosted runner labels to the pinned
+// image each one currently resolves to
+var DefaultRunnerPins = map[string]string{
+	"ubuntu-latest":  "ubuntu-24.04",
+	"windows-latest": "windows-2025",
+	"macos-latest":   "macos-15",
+}
+
+// matrixExpression matches a runs-on value taken from the job matrix
+var matrixExpression = regexp.MustCompile(`\$\{\{\s*matrix\.([A-Za-z0-9_-]+)\s*\}\}`)
+
+// RunnerLabel is a mutable runner image label (e.g. ubuntu-latest) used by
+// a job, which silently moves to a new image when GitHub updates it
+type RunnerLabel struct {
+	Path       string `json:"path"`
+	Line       int    `json:"line"`
+	Job        string `json:"job"`
+	Label      string `json:"label"`
+	Suggestion string `json:"suggestion,omitempty"` // Pinned label, if known
+}
+
+// ScanRunnerLabels lists the mutable runner labels in the runs-on values of
+// a workflow, including labels supplied through the job's matrix
+func (s *Scanner) ScanRunnerLabels(path string) ([]RunnerLabel, error) {
+	if err := s.validatePath(path); err != nil {
+		return nil, fmt.Errorf(common.ErrInvalidFilePath, err)
+	}
+	content, err := common.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf(common.ErrReadingWorkflowFile, err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, fmt.Errorf(common.ErrParsingWorkflowYAML, err)
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+
+	var labels []RunnerLabel
+	jobs := mappingValue(doc.Content[0], "jobs")
+	if jobs == nil || jobs.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	for i := 0; i+1 < len(jobs.Content); i += 2 {
+		name, job := jobs.Content[i].Value, resolveAlias(jobs.Content[i+1])
+		for _, label := range runsOnLabels(job) {
+			if !isMutableRunnerLabel(label.Value) {
+				continue
+			}
+			labels = append(labels, RunnerLabel{
+				Path:       path,
+				Line:       label.Line,
+				Job:        name,
+				Label:      label.Value,
+				Suggestion: DefaultRunnerPins[strings.ToLower(label.Value)],
+			})
+		}
+	}
+	return labels, nil
+}
+
+// runsOnLabels returns the label scalars of a job's runs-on value. Matrix
+// expressions are expanded to the values of the matrix key they name.
+func runsOnLabels(job *yaml.Node) []*yaml.Node {
+	runsOn := mappingValue(job, "runs-on")
+	if runsOn == nil {
+		return nil
+	}
+	if runsOn.Kind == yaml.MappingNode {
+		runsOn = mappingValue(runsOn, "labels")
+	}
+
+	var labels []*yaml.Node
+	for _, node := range scalarItems(runsOn) {
+		m := matrixExpression.FindStringSubmatch(node.Value)
+		if m == nil {
+			labels = append(labels, node)
+			continue
+		}
+		matrix := mappingValue(mappingValue(job, "strategy"), "matrix")
+		labels = append(labels, scalarItems(mappingValue(matrix, m[1]))...)
+		for _, entry := range sequenceItems(mappingValue(matrix, "include")) {
+			labels = append(labels, scalarItems(mappingValue(entry, m[1]))...)
+		}
+	}
+	return labels
+}
+
+// isMutableRunnerLabel reports whether a runner label tracks the latest image
+func isMutableRunnerLabel(label string) bool {
+	return strings.HasSuffix(strings.ToLower(label), "-latest")
+}
+
+// mappingValue returns the value of key in a mapping node, or nil
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	node = resolveAlias(node)
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return resolveAlias(node.Content[i+1])
+		}
+	}
+	return nil
+}
+
+// scalarItems returns a scalar node, or the scalar items of a sequence
+func scalarItems(node *yaml.Node) []*yaml.Node {
+	node = resolveAlias(node)
+	if node == nil {
+		return nil
+	}
+	if node.Kind == yaml.ScalarNode {
+		return []*yaml.Node{node}
+	}
+	var items []*yaml.Node
+	for _, item := range sequenceItems(node) {
+		if item.Kind == yaml.ScalarNode {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// sequenceItems returns the items of a sequence node with aliases resolved
+func sequenceItems(node *yaml.Node) []*yaml.Node {
+	node = resolveAlias(node)
+	if node == nil || node.Kind != yaml.SequenceNode {
+		return nil
+	}
+	items := make([]*yaml.Node, 0, len(node.Content))
+	for _, item := range node.Content {
+		items = append(items, resolveAlias(item))
+	}
+	return items
+}
+
+// resolveAlias follows alias nodes to their anchor
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	for node != nil && node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	return node
+}
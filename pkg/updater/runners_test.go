@@ -0,0 +1,78 @@
+package updater
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestScanRunnerLabels(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "ci.yml")
+	content := `on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps: []
+  pinned:
+    runs-on: ubuntu-22.04
+    steps: []
+  labels:
+    runs-on: [self-hosted, Windows-Latest]
+  group:
+    runs-on:
+      group: large
+      labels: macos-latest
+  matrix:
+    strategy:
+      matrix:
+        os: [ubuntu-latest, macos-14]
+        include:
+          - os: windows-latest
+    runs-on: ${{ matrix.os }}
+  custom:
+    runs-on: my-runner-latest
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	labels, err := NewScanner(dir).ScanRunnerLabels(path)
+	if err != nil {
+		t.Fatalf("ScanRunnerLabels() error = %v", err)
+	}
+	want := []RunnerLabel{
+		{Line: 4, Job: "build", Label: "ubuntu-latest", Suggestion: "ubuntu-24.04"},
+		{Line: 10, Job: "labels", Label: "Windows-Latest", Suggestion: "windows-2025"},
+		{Line: 14, Job: "group", Label: "macos-latest", Suggestion: "macos-15"},
+		{Line: 18, Job: "matrix", Label: "ubuntu-latest", Suggestion: "ubuntu-24.04"},
+		{Line: 20, Job: "matrix", Label: "windows-latest", Suggestion: "windows-2025"},
+		{Line: 23, Job: "custom", Label: "my-runner-latest"},
+	}
+	if len(labels) != len(want) {
+		t.Fatalf("ScanRunnerLabels() = %+v, want %d labels", labels, len(want))
+	}
+	for i, w := range want {
+		w.Path = path
+		if labels[i] != w {
+			t.Errorf("labels[%d] = %+v, want %+v", i, labels[i], w)
+		}
+	}
+}
+
+func TestScanRunnerLabelsErrors(t *testing.T) {
+	dir := t.TempDir()
+	scanner := NewScanner(dir)
+
+	if _, err := scanner.ScanRunnerLabels(filepath.Join(t.TempDir(), "outside.yml")); err == nil {
+		t.Error("ScanRunnerLabels() outside the base directory succeeded, want error")
+	}
+
+	invalid := filepath.Join(dir, "invalid.yml")
+	if err := os.WriteFile(invalid, []byte("jobs: [\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := scanner.ScanRunnerLabels(invalid); err == nil {
+		t.Error("ScanRunnerLabels() of invalid YAML succeeded, want error")
+	}
+}
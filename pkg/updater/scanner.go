@@ -2,6 +2,7 @@ package updater
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -10,6 +11,7 @@ import (
 	"time"
 
 	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/storage"
 	"gopkg.in/yaml.v3"
 )
 
@@ -20,7 +22,14 @@ type Scanner struct {
 	lastOp       time.Time
 	opCount      int
 	mu           sync.Mutex
-	baseDir      string // Base directory for path validation
+	baseDir      string     // Base directory for path validation
+	paths        PathFilter // Selects the files ScanWorkflows returns
+	maxDepth     int        // Directory levels ScanWorkflows reads; 0 for no limit
+	symlinks     LinkPolicy // Treatment of symbolic links by ScanWorkflows
+	submodules   LinkPolicy // Treatment of git submodules by ScanWorkflows
+
+	// References parsed from each workflow content; see SetParseCache
+	parseCache storage.Store
 }
 
 // validatePath ensures the path is within the allowed directory
@@ -35,6 +44,15 @@ func (s *Scanner) validatePath(path string) error {
 
 // parseActionReference parses an action reference string (e.g., "actions/checkout@v2" or "actions/checkout@a81bbbf8298c0fa03ea29cdc473d45769f953675")
 func parseActionReference(ref string, path string, comments []string) (*ActionReference, error) {
+	// Local actions live in the same repository and have no version to check
+	if strings.HasPrefix(ref, "./") {
+		return &ActionReference{
+			LocalPath: ref,
+			Path:      path,
+			Comments:  comments,
+		}, nil
+	}
+
 	parts := strings.Split(ref, "@")
 	if len(parts) != 2 {
 		return nil, fmt.Errorf(common.ErrInvalidActionRefFormat, ref)
@@ -45,6 +63,14 @@ func parseActionReference(ref string, path string, comments []string) (*ActionRe
 		return nil, fmt.Errorf(common.ErrInvalidActionNameFormat, parts[0])
 	}
 
+	// Actions on another host are written as host/owner/repo, and a GitHub
+	// owner never contains a dot or a port
+	host := ""
+	if len(nameParts) >= 3 && strings.ContainsAny(nameParts[0], ".:") {
+		host = strings.ToLower(nameParts[0])
+		nameParts = nameParts[1:]
+	}
+
 	// For actions with more than two parts (e.g., github/codeql-action/init)
 	// we'll consider the first part as the owner and join the rest as the name
 	owner := nameParts[0]
@@ -62,12 +88,9 @@ func parseActionReference(ref string, path string, comments []string) (*ActionRe
 		commitHash = version
 		// Look for version in comments
 		for _, comment := range comments {
-			if strings.Contains(comment, "Original version:") {
-				parts := strings.SplitN(comment, ":", 2)
-				if len(parts) == 2 {
-					version = strings.TrimSpace(parts[1])
-					break
-				}
+			if v, ok := ParseVersionComment(comment); ok {
+				version = v
+				break
 			}
 		}
 	}
@@ -79,15 +102,27 @@ func parseActionReference(ref string, path string, comments []string) (*ActionRe
 		CommitHash: commitHash,
 		Path:       path,
 		Comments:   comments,
+		Host:       host,
 	}, nil
 }
 
+// actionKey returns a key identifying the referenced action and version.
+// The full action name may include multiple path segments.
+func actionKey(action *ActionReference) string {
+	if action.IsLocal() {
+		return action.LocalPath
+	}
+	return action.FullName() + "@" + action.Version
+}
+
 // NewScanner creates a new Scanner instance
 func NewScanner(baseDir string) *Scanner {
 	return &Scanner{
 		rateLimit:    60,          // Default to 60 operations
 		rateDuration: time.Minute, // Per minute
 		baseDir:      filepath.Clean(baseDir),
+		symlinks:     DefaultSymlinkPolicy,
+		submodules:   DefaultSubmodulePolicy,
 	}
 }
 
@@ -139,27 +174,178 @@ func (s *Scanner) checkTimeout(ctx context.Context) error {
 	}
 }
 
-// ScanWorkflows finds all GitHub Actions workflow files in the repository
+// SetPathFilter limits the files returned by ScanWorkflows to those selected
+// by filter, matched relative to the scanned directory
+func (s *Scanner) SetPathFilter(filter PathFilter) {
+	s.paths = filter
+}
+
+// SetMaxDepth limits ScanWorkflows to depth directory levels: 1 reads only
+// the files of the scanned directory, 2 also those of its subdirectories,
+// and so on. 0 removes the limit.
+func (s *Scanner) SetMaxDepth(depth int) {
+	s.maxDepth = depth
+}
+
+// ScanWorkflows finds all GitHub Actions workflow files in the repository,
+// in lexical order
 func (s *Scanner) ScanWorkflows(dir string) ([]string, error) {
+	return s.ScanWorkflowsContext(context.Background(), dir)
+}
+
+// SetSymlinkPolicy sets how ScanWorkflows treats symbolic links to files
+// and directories; "" restores DefaultSymlinkPolicy. Followed links must
+// stay inside the base directory.
+func (s *Scanner) SetSymlinkPolicy(policy LinkPolicy) {
+	if policy == "" {
+		policy = DefaultSymlinkPolicy
+	}
+	s.symlinks = policy
+}
+
+// SetSubmodulePolicy sets how ScanWorkflows treats git submodules; ""
+// restores DefaultSubmodulePolicy
+func (s *Scanner) SetSubmodulePolicy(policy LinkPolicy) {
+	if policy == "" {
+		policy = DefaultSubmodulePolicy
+	}
+	s.submodules = policy
+}
+
+// ScanWorkflowsContext is like ScanWorkflows but stops once ctx is done
+func (s *Scanner) ScanWorkflowsContext(ctx context.Context, dir string) ([]string, error) {
+	var workflows []string
+	err := s.visitWorkflows(ctx, dir, func(path string) error {
+		workflows = append(workflows, path)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return workflows, nil
+}
+
+// SkipFile is returned by the function passed to ScanWorkflowsFunc to skip
+// the remaining references of the current workflow file
+var SkipFile = errors.New("skip the rest of this workflow file")
+
+// ScanWorkflowsFunc calls fn for each action reference of the workflow files
+// below dir, file by file as they are found, instead of collecting the files
+// and references of the whole directory first. An error returned by fn stops
+// the scan and is returned, except SkipFile, which skips the rest of the
+// current file. Files that cannot be parsed stop the scan as well.
+func (s *Scanner) ScanWorkflowsFunc(dir string, fn func(file string, ref ActionReference) error) error {
+	return s.ScanWorkflowsFuncContext(context.Background(), dir, fn)
+}
+
+// ScanWorkflowsFuncContext is like ScanWorkflowsFunc but stops once ctx is
+// done
+func (s *Scanner) ScanWorkflowsFuncContext(ctx context.Context, dir string, fn func(file string, ref ActionReference) error) error {
+	return s.visitWorkflows(ctx, dir, func(path string) error {
+		refs, err := s.ParseActionReferences(path)
+		if err != nil {
+			return fmt.Errorf(common.ErrParsingWorkflowFile, path, err)
+		}
+		for _, ref := range refs {
+			if err := fn(path, ref); errors.Is(err, SkipFile) {
+				return nil
+			} else if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// visitWorkflows calls visit for each workflow file below dir. Errors
+// returned by visit are passed through as they are.
+func (s *Scanner) visitWorkflows(ctx context.Context, dir string, visit func(path string) error) error {
 	// Validate the directory path
 	if err := s.validatePath(dir); err != nil {
-		return nil, fmt.Errorf(common.ErrInvalidDirectoryPath, err)
+		return fmt.Errorf(common.ErrInvalidDirectoryPath, err)
 	}
 
 	// Check if workflows directory exists
 	if _, err := os.Stat(dir); os.IsNotExist(err) {
-		return nil, fmt.Errorf(common.ErrWorkflowDirNotFound, dir)
+		return fmt.Errorf(common.ErrWorkflowDirNotFound, dir)
 	}
 
-	var workflows []string
-	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+	seen := make(map[string]bool)
+	if real, err := filepath.EvalSymlinks(dir); err == nil {
+		seen[real] = true
+	}
+	var visitErr error
+	err := s.walkWorkflows(ctx, dir, "", seen, func(path string) error {
+		visitErr = visit(path)
+		return visitErr
+	})
+	if err != nil && err != visitErr {
+		return fmt.Errorf(common.ErrScanningWorkflows, err)
+	}
+	return err
+}
+
+// walkWorkflows calls visit for the workflow files below dir, at rel from
+// the scanned directory. Followed symbolic links are visited as their
+// target, and seen holds the real paths visited so link cycles and files
+// reachable twice are read once.
+func (s *Scanner) walkWorkflows(ctx context.Context, dir, rel string, seen map[string]bool, visit func(path string) error) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := s.checkTimeout(ctx); err != nil {
+			return err
+		}
+		path := filepath.Join(dir, entry.Name())
+		entryRel := filepath.Join(rel, entry.Name())
+
+		// Symbolic links are followed, skipped or rejected by policy
+		isDir := entry.IsDir()
+		if entry.Type()&os.ModeSymlink != 0 {
+			switch s.symlinks {
+			case LinkSkip:
+				continue
+			case LinkError:
+				return fmt.Errorf(common.ErrSymlinkNotAllowed, path)
+			}
+			target, err := resolveLink(s.baseDir, path)
+			if err != nil {
+				return err
+			}
+			info, err := os.Stat(target)
+			if err != nil {
+				return err
+			}
+			path, isDir = target, info.IsDir()
+		}
+		real, err := filepath.EvalSymlinks(path)
 		if err != nil {
 			return err
 		}
 
-		// Skip directories
-		if info.IsDir() {
-			return nil
+		if isDir {
+			// Skip directories below the depth limit entirely
+			if s.maxDepth > 0 && len(strings.Split(filepath.ToSlash(entryRel), "/")) >= s.maxDepth {
+				continue
+			}
+			if isSubmodule(path) {
+				switch s.submodules {
+				case LinkSkip:
+					continue
+				case LinkError:
+					return fmt.Errorf(common.ErrSubmoduleNotAllowed, path)
+				}
+			}
+			if seen[real] {
+				continue
+			}
+			seen[real] = true
+			if err := s.walkWorkflows(ctx, path, entryRel, seen, visit); err != nil {
+				return err
+			}
+			continue
 		}
 
 		// Validate each file path
@@ -167,23 +353,24 @@ func (s *Scanner) ScanWorkflows(dir string) ([]string, error) {
 			return err
 		}
 
+		// Skip files left out by the path filter
+		if !s.paths.IsZero() && !s.paths.Match(filepath.ToSlash(entryRel)) {
+			continue
+		}
+
 		// Check for YAML files
-		if strings.HasSuffix(info.Name(), ".yml") || strings.HasSuffix(info.Name(), ".yaml") {
+		if (strings.HasSuffix(entry.Name(), ".yml") || strings.HasSuffix(entry.Name(), ".yaml")) && !seen[real] {
 			// Check if file is readable
 			if _, err := common.ReadFile(path); err != nil {
 				return err
 			}
-			workflows = append(workflows, path)
+			seen[real] = true
+			if err := visit(path); err != nil {
+				return err
+			}
 		}
-
-		return nil
-	})
-
-	if err != nil {
-		return nil, fmt.Errorf(common.ErrScanningWorkflows, err)
 	}
-
-	return workflows, nil
+	return nil
 }
 
 // ParseActionReferences extracts action references from a workflow file
@@ -198,6 +385,9 @@ func (s *Scanner) ParseActionReferences(path string) ([]ActionReference, error)
 	if err != nil {
 		return nil, fmt.Errorf(common.ErrReadingWorkflowFile, err)
 	}
+	if refs, ok := s.cachedReferences(content, path); ok {
+		return refs, nil
+	}
 
 	// Split content into lines to preserve comments
 	lines := strings.Split(string(content), "\n")
@@ -236,6 +426,36 @@ func (s *Scanner) ParseActionReferences(path string) ([]ActionReference, error)
 		return nil, fmt.Errorf(common.ErrParsingWorkflowContent, err)
 	}
 
+	// Uses values templated from matrix or env values defined in the file
+	// replace their placeholder once resolved
+	templated, resolvedLines := resolveTemplatedUses(doc.Content[0], path)
+	if len(templated) > 0 {
+		kept := actions[:0]
+		for _, action := range actions {
+			if !resolvedLines[action.Line] || action.Owner != "matrix" || action.Version != "dynamic" {
+				kept = append(kept, action)
+			}
+		}
+		actions = append(kept, templated...)
+	}
+
+	// Record trailing version comments; they take precedence over comments
+	// on preceding lines when recovering the version of a pinned hash
+	for i := range actions {
+		action := &actions[i]
+		if action.Line <= 0 || action.Line > len(lines) {
+			continue
+		}
+		action.VersionComment = trailingComment(lines[action.Line-1])
+		if action.CommitHash == "" {
+			continue
+		}
+		if v, ok := ParseVersionComment(action.VersionComment); ok {
+			action.Version = v
+		}
+	}
+
+	s.cacheReferences(content, actions)
 	return actions, nil
 }
 
@@ -281,6 +501,11 @@ func (s *Scanner) parseNode(node *yaml.Node, path string, actions *[]ActionRefer
 					continue
 				}
 
+				// Workflow templates can refer to $default-branch
+				if isTemplatePlaceholder(value.Value) {
+					continue
+				}
+
 				lineNumber := value.Line
 				comments := lineComments[lineNumber]
 				if lineNumber > 0 && lineComments[lineNumber-1] != nil {
@@ -295,30 +520,14 @@ func (s *Scanner) parseNode(node *yaml.Node, path string, actions *[]ActionRefer
 				action.Comments = comments
 
 				// Include line number in the key to handle same action used in different places
-				// Use the full action name (which may include multiple path segments)
-				actionFullName := action.Owner + "/" + action.Name
-				key := fmt.Sprintf("%s@%s:%d", actionFullName, action.Version, lineNumber)
+				key := fmt.Sprintf("%s:%d", actionKey(action), lineNumber)
 				if !seen[key] {
 					seen[key] = true
 					*actions = append(*actions, *action)
 				}
-			} else if key.Value == "steps" {
-				// Special handling for steps with aliases
-				if value.Kind == yaml.AliasNode {
-					// Get the actual node that this alias refers to
-					aliasedNode := value.Alias
-					if aliasedNode != nil {
-						// Create a copy of the aliased node with the current line number
-						aliasLine := value.Line
-						err := s.parseAliasedNode(aliasedNode, aliasLine, path, actions, lineComments, seen)
-						if err != nil {
-							return err
-						}
-					}
-				} else {
-					if err := s.parseNode(value, path, actions, lineComments, seen); err != nil {
-						return err
-					}
+			} else if key.Value == "with" && value.Kind == yaml.MappingNode {
+				if err := s.parseWith(value, path, actions, lineComments, seen); err != nil {
+					return err
 				}
 			} else if key.Value != "run" { // Skip parsing inside run commands
 				if err := s.parseNode(value, path, actions, lineComments, seen); err != nil {
@@ -338,6 +547,10 @@ func (s *Scanner) parseNode(node *yaml.Node, path string, actions *[]ActionRefer
 				return err
 			}
 		}
+	case yaml.AliasNode:
+		// Steps, jobs or merge keys (<<: *defaults) reused through an alias
+		// are references of their own, on the line of the alias
+		return s.parseAliasedNode(node.Alias, node.Line, path, actions, lineComments, seen)
 	case yaml.ScalarNode:
 		return nil
 	default:
@@ -347,6 +560,42 @@ func (s *Scanner) parseNode(node *yaml.Node, path string, actions *[]ActionRefer
 	return nil
 }
 
+// parseWith parses the inputs of a step. An action reference passed to a
+// wrapper action as its uses input is recorded; inputs that are not valid
+// references are ignored rather than failing the file.
+func (s *Scanner) parseWith(with *yaml.Node, path string, actions *[]ActionReference, lineComments map[int][]string, seen map[string]bool) error {
+	for i := 0; i+1 < len(with.Content); i += 2 {
+		value := with.Content[i+1]
+		if with.Content[i].Value != "uses" || value.Kind != yaml.ScalarNode {
+			if err := s.parseNode(value, path, actions, lineComments, seen); err != nil {
+				return err
+			}
+			continue
+		}
+		if strings.Contains(value.Value, "${{") || isTemplatePlaceholder(value.Value) {
+			continue
+		}
+
+		lineNumber := value.Line
+		comments := lineComments[lineNumber]
+		if lineNumber > 0 && lineComments[lineNumber-1] != nil {
+			comments = append(lineComments[lineNumber-1], comments...)
+		}
+		action, err := parseActionReference(value.Value, path, comments)
+		if err != nil || action.IsLocal() {
+			continue
+		}
+		action.Line = lineNumber
+
+		key := fmt.Sprintf("%s:%d", actionKey(action), lineNumber)
+		if !seen[key] {
+			seen[key] = true
+			*actions = append(*actions, *action)
+		}
+	}
+	return nil
+}
+
 // parseAliasedNode parses a node that is referenced by an alias, using the alias's line number
 func (s *Scanner) parseAliasedNode(node *yaml.Node, aliasLine int, path string, actions *[]ActionReference, lineComments map[int][]string, seen map[string]bool) error {
 	if node == nil {
@@ -370,7 +619,7 @@ func (s *Scanner) parseAliasedNode(node *yaml.Node, aliasLine int, path string,
 
 			if key.Value == "uses" && value.Kind == yaml.ScalarNode {
 				// Skip if it's inside a run command
-				if hasRunCommand {
+				if hasRunCommand || isTemplatePlaceholder(value.Value) {
 					continue
 				}
 
@@ -388,15 +637,17 @@ func (s *Scanner) parseAliasedNode(node *yaml.Node, aliasLine int, path string,
 				action.Comments = comments
 
 				// Include line number in the key to handle same action used in different places
-				// Use the full action name (which may include multiple path segments)
-				actionFullName := action.Owner + "/" + action.Name
-				key := fmt.Sprintf("%s@%s:%d", actionFullName, action.Version, aliasLine)
+				key := fmt.Sprintf("%s:%d", actionKey(action), aliasLine)
 				if !seen[key] {
 					seen[key] = true
 					*actions = append(*actions, *action)
 				}
+			} else if key.Value == "with" && value.Kind == yaml.MappingNode {
+				if err := s.parseWith(value, path, actions, lineComments, seen); err != nil {
+					return err
+				}
 			} else if key.Value != "run" { // Skip parsing inside run commands
-				if err := s.parseNode(value, path, actions, lineComments, seen); err != nil {
+				if err := s.parseAliasedNode(value, aliasLine, path, actions, lineComments, seen); err != nil {
 					return err
 				}
 			}
@@ -407,6 +658,8 @@ func (s *Scanner) parseAliasedNode(node *yaml.Node, aliasLine int, path string,
 				return err
 			}
 		}
+	case yaml.AliasNode:
+		return s.parseAliasedNode(node.Alias, aliasLine, path, actions, lineComments, seen)
 	case yaml.ScalarNode:
 		return nil
 	default:
@@ -415,3 +668,59 @@ func (s *Scanner) parseAliasedNode(node *yaml.Node, aliasLine int, path string,
 	}
 	return nil
 }
+
+// localActionFiles are the metadata file names GitHub accepts for an action
+var localActionFiles = []string{"action.yml", "action.yaml"}
+
+// ParseLocalAction resolves a local action reference to its metadata file and
+// returns the action references used by it. Nested local actions are followed
+// recursively; each local action is visited at most once.
+func (s *Scanner) ParseLocalAction(ref ActionReference) ([]ActionReference, error) {
+	return s.parseLocalAction(ref, make(map[string]bool))
+}
+
+func (s *Scanner) parseLocalAction(ref ActionReference, visited map[string]bool) ([]ActionReference, error) {
+	if !ref.IsLocal() {
+		return nil, nil
+	}
+
+	dir := filepath.Join(s.baseDir, filepath.FromSlash(strings.TrimPrefix(ref.LocalPath, "./")))
+	if err := s.validatePath(dir); err != nil {
+		return nil, fmt.Errorf(common.ErrInvalidLocalAction, ref.LocalPath, err)
+	}
+	if visited[dir] {
+		return nil, nil
+	}
+	visited[dir] = true
+
+	var metadataFile string
+	for _, name := range localActionFiles {
+		candidate := filepath.Join(dir, name)
+		if common.IsRegularFile(candidate) {
+			metadataFile = candidate
+			break
+		}
+	}
+	if metadataFile == "" {
+		return nil, fmt.Errorf(common.ErrLocalActionNotFound, ref.LocalPath)
+	}
+
+	refs, err := s.ParseActionReferences(metadataFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []ActionReference
+	for _, nested := range refs {
+		if !nested.IsLocal() {
+			result = append(result, nested)
+			continue
+		}
+		nestedRefs, err := s.parseLocalAction(nested, visited)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, nestedRefs...)
+	}
+	return result, nil
+}
@@ -1,8 +1,10 @@
 package updater
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"gopkg.in/yaml.v3"
@@ -272,9 +274,9 @@ jobs:
 		t.Fatalf("ParseActionReferences returned error: %v", err)
 	}
 
-	// Check that the aliased node was parsed correctly
-	if len(actions) != 1 {
-		t.Fatalf("Expected 1 action, got %d", len(actions))
+	// The anchored step and the step merging it are both references
+	if len(actions) != 2 {
+		t.Fatalf("Expected 2 actions, got %d", len(actions))
 	}
 
 	action := actions[0]
@@ -476,3 +478,53 @@ func TestParseAliasedNodeExtraTypes(t *testing.T) {
 		t.Errorf("Expected no new actions for run command, got %d more", len(actions)-prevLen)
 	}
 }
+
+func TestParseActionReferencesAliasesAtAnyDepth(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "workflow.yml")
+	content := `name: Test
+x-setup: &setup
+  uses: actions/setup-go@v5
+x-job: &job
+  runs-on: ubuntu-latest
+  steps:
+    - uses: actions/checkout@v4
+jobs:
+  build:
+    strategy:
+      matrix:
+        include:
+          - steps:
+              - uses: actions/cache@v4
+              - *setup
+    steps:
+      - <<: *setup
+        with:
+          go-version: stable
+  test:
+    <<: *job
+`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	actions, err := NewScanner(dir).ParseActionReferences(path)
+	if err != nil {
+		t.Fatalf("ParseActionReferences() error = %v", err)
+	}
+	var got []string
+	for _, action := range actions {
+		got = append(got, fmt.Sprintf("%s:%d", action.FullName(), action.Line))
+	}
+	want := []string{
+		"actions/setup-go:3",
+		"actions/checkout:7",
+		"actions/cache:14",
+		"actions/setup-go:15",
+		"actions/setup-go:17",
+		"actions/checkout:21",
+	}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("references = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,97 @@
+package updater
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseLocalActionReferences(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(name, content string) string {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+		return path
+	}
+
+	workflow := writeFile(".github/workflows/ci.yml", `on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+      - uses: ./.github/actions/outer
+      - uses: ./.github/actions/missing
+`)
+	writeFile(".github/actions/outer/action.yml", `runs:
+  using: composite
+  steps:
+    - uses: actions/setup-node@v4
+    - uses: ./.github/actions/inner
+`)
+	inner := writeFile(".github/actions/inner/action.yaml", `runs:
+  using: composite
+  steps:
+    - uses: actions/cache@v3
+    - uses: ./.github/actions/outer
+`)
+
+	scanner := NewScanner(dir)
+	refs, err := scanner.ParseActionReferences(workflow)
+	if err != nil {
+		t.Fatalf("ParseActionReferences() error = %v", err)
+	}
+	if len(refs) != 3 {
+		t.Fatalf("Expected 3 actions, got %d", len(refs))
+	}
+	if refs[0].IsLocal() {
+		t.Errorf("actions/checkout should not be local")
+	}
+	if !refs[1].IsLocal() || refs[1].LocalPath != "./.github/actions/outer" || refs[1].Line != 7 {
+		t.Errorf("unexpected local reference: %+v", refs[1])
+	}
+
+	nested, err := scanner.ParseLocalAction(refs[1])
+	if err != nil {
+		t.Fatalf("ParseLocalAction() error = %v", err)
+	}
+	if len(nested) != 2 {
+		t.Fatalf("expected 2 nested remote references, got %d: %+v", len(nested), nested)
+	}
+	if nested[0].Name != "setup-node" || nested[1].Name != "cache" {
+		t.Errorf("unexpected nested references: %+v", nested)
+	}
+	if nested[1].Path != inner || nested[1].Line != 4 {
+		t.Errorf("nested reference location = %s:%d, want %s:4", nested[1].Path, nested[1].Line, inner)
+	}
+
+	if _, err := scanner.ParseLocalAction(refs[2]); err == nil {
+		t.Error("ParseLocalAction() expected error for missing action.yml, got nil")
+	}
+
+	escape := ActionReference{LocalPath: "./../outside"}
+	if _, err := scanner.ParseLocalAction(escape); err == nil {
+		t.Error("ParseLocalAction() expected error for path outside repository, got nil")
+	}
+}
+
+func TestFormatRelativePathWithRepoRoot(t *testing.T) {
+	creator := &DefaultPRCreator{workflowsPath: ".github/workflows"}
+	creator.SetRepoRoot("/repo")
+
+	tests := map[string]string{
+		"/repo/.github/workflows/ci.yml":         ".github/workflows/ci.yml",
+		"/repo/.github/actions/setup/action.yml": ".github/actions/setup/action.yml",
+		"/other/.github/workflows/ci.yml":        ".github/workflows/ci.yml",
+	}
+	for in, want := range tests {
+		if got := creator.formatRelativePath(in); got != want {
+			t.Errorf("formatRelativePath(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package updater
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestParseActionReferencesLocations(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{
+			name: "composite action steps",
+			content: `name: Setup
+runs:
+  using: composite
+  steps:
+    - if: ${{ inputs.cache == 'true' }}
+      uses: actions/cache@v3
+    - uses: actions/setup-go@${{ env.GO_ACTION }}
+      env:
+        GO_ACTION: v4
+`,
+			want: []string{"actions/cache@v3", "actions/setup-go@v4"},
+		},
+		{
+			name: "reusable workflow call",
+			content: `on: push
+jobs:
+  call:
+    uses: my-org/workflows/.github/workflows/build.yml@v1
+`,
+			want: []string{"my-org/workflows/.github/workflows/build.yml@v1"},
+		},
+		{
+			name: "wrapper action uses input",
+			content: `on: push
+jobs:
+  test:
+    steps:
+      - uses: my-org/retry@v2
+        with:
+          uses: actions/checkout@v3
+      - uses: my-org/other@v1
+        with:
+          uses: not a reference
+`,
+			want: []string{"actions/checkout@v3", "my-org/other@v1", "my-org/retry@v2"},
+		},
+		{
+			name: "templated uses in workflow level env",
+			content: `on: push
+env:
+  LINT: my-org/lint@v5
+jobs:
+  lint:
+    if: ${{ github.event_name == 'push' }}
+    steps:
+      - uses: ${{ env.LINT }}
+`,
+			want: []string{"my-org/lint@v5"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "action.yml")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			actions, err := NewScanner(dir).ParseActionReferences(path)
+			if err != nil {
+				t.Fatalf("ParseActionReferences() error = %v", err)
+			}
+			var got []string
+			for _, action := range actions {
+				got = append(got, action.FullName()+"@"+action.Version)
+			}
+			sort.Strings(got)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("ParseActionReferences() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
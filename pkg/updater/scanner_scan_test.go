@@ -1,6 +1,8 @@
 package updater
 
 import (
+	"context"
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
@@ -9,6 +11,95 @@ import (
 	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
 )
 
+func TestScanWorkflowsContextCancelled(t *testing.T) {
+	dir := t.TempDir()
+	workflowsDir := filepath.Join(dir, ".github", "workflows")
+	if err := os.MkdirAll(workflowsDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(workflowsDir, "ci.yml"), []byte("on: push\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := NewScanner(dir).ScanWorkflowsContext(ctx, workflowsDir)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("ScanWorkflowsContext() error = %v, want context.Canceled", err)
+	}
+}
+
+func TestScanWorkflowsFunc(t *testing.T) {
+	dir := t.TempDir()
+	workflowsDir := filepath.Join(dir, ".github", "workflows")
+	if err := os.MkdirAll(filepath.Join(workflowsDir, "nested"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"ci.yml":             "on: push\njobs:\n  build:\n    runs-on: ubuntu-latest\n    steps:\n      - uses: actions/checkout@v4\n      - uses: octo/tool@v1\n",
+		"nested/deploy.yaml": "on: push\njobs:\n  deploy:\n    runs-on: ubuntu-latest\n    steps:\n      - uses: octo/deploy@v2\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(workflowsDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	scanner := NewScanner(dir)
+	stop := errors.New("stop")
+
+	tests := []struct {
+		name    string
+		fn      func(file string, ref ActionReference) error
+		want    []string
+		wantErr error
+	}{
+		{name: "all references", want: []string{"ci.yml: actions/checkout", "ci.yml: octo/tool", "deploy.yaml: octo/deploy"}},
+		{
+			name: "skip file",
+			fn: func(file string, ref ActionReference) error {
+				if ref.Owner == "actions" {
+					return SkipFile
+				}
+				return nil
+			},
+			want: []string{"ci.yml: actions/checkout", "deploy.yaml: octo/deploy"},
+		},
+		{
+			name:    "stop",
+			fn:      func(file string, ref ActionReference) error { return stop },
+			want:    []string{"ci.yml: actions/checkout"},
+			wantErr: stop,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			err := scanner.ScanWorkflowsFunc(workflowsDir, func(file string, ref ActionReference) error {
+				got = append(got, filepath.Base(file)+": "+ref.FullName())
+				if tt.fn != nil {
+					return tt.fn(file, ref)
+				}
+				return nil
+			})
+			if err != tt.wantErr {
+				t.Errorf("ScanWorkflowsFunc() error = %v, want %v", err, tt.wantErr)
+			}
+			if strings.Join(got, ", ") != strings.Join(tt.want, ", ") {
+				t.Errorf("ScanWorkflowsFunc() visited %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// Files that cannot be parsed stop the scan
+	if err := os.WriteFile(filepath.Join(workflowsDir, "broken.yml"), []byte("on: [push\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err := scanner.ScanWorkflowsFunc(workflowsDir, func(string, ActionReference) error { return nil })
+	if err == nil || !strings.Contains(err.Error(), "broken.yml") {
+		t.Errorf("ScanWorkflowsFunc() error = %v, want a parse error of broken.yml", err)
+	}
+}
+
 // TestScanWorkflows tests the ScanWorkflows function to improve its coverage from 20%
 func TestScanWorkflows(t *testing.T) {
 	// Create a temporary directory for testing
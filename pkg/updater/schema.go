@@ -0,0 +1,446 @@
+package updater
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+	"gopkg.in/yaml.v3"
+)
+
+// SchemaError is a place where a workflow breaks the GitHub Actions
+// workflow schema, which GitHub would reject or ignore
+type SchemaError struct {
+	File    string
+	Line    int
+	Column  int
+	Path    string // Location in the workflow, e.g. jobs.build.steps[0]
+	Message string
+}
+
+// Error implements error
+func (e SchemaError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return e.Path + ": " + e.Message
+}
+
+// Keys the workflow schema allows at each level
+var (
+	workflowKeys = keySet("name", "run-name", "on", "permissions", "env", "defaults", "concurrency", "jobs")
+	jobKeys      = keySet("name", "needs", "permissions", "runs-on", "environment", "outputs", "env", "defaults", "if",
+		"steps", "timeout-minutes", "strategy", "continue-on-error", "container", "services", "concurrency", "snapshot")
+	callerJobKeys  = keySet("name", "uses", "with", "secrets", "needs", "if", "permissions", "strategy", "concurrency")
+	stepKeys       = keySet("id", "if", "name", "uses", "run", "working-directory", "shell", "with", "env", "continue-on-error", "timeout-minutes")
+	strategyKeys   = keySet("matrix", "fail-fast", "max-parallel")
+	workflowEvents = keySet("branch_protection_rule", "check_run", "check_suite", "create", "delete", "deployment",
+		"deployment_status", "discussion", "discussion_comment", "fork", "gollum", "issue_comment", "issues", "label",
+		"merge_group", "milestone", "page_build", "project", "project_card", "project_column", "public", "pull_request",
+		"pull_request_review", "pull_request_review_comment", "pull_request_target", "push", "registry_package",
+		"release", "repository_dispatch", "schedule", "status", "watch", "workflow_call", "workflow_dispatch", "workflow_run")
+	permissionScopes = keySet("actions", "attestations", "checks", "contents", "deployments", "discussions", "id-token",
+		"issues", "models", "packages", "pages", "pull-requests", "repository-projects", "security-events", "statuses")
+)
+
+// jobID matches the names jobs may have
+var jobID = regexp.MustCompile(`^[_a-zA-Z][a-zA-Z0-9_-]*$`)
+
+// keySet returns a set of the given keys
+func keySet(keys ...string) map[string]bool {
+	set := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		set[key] = true
+	}
+	return set
+}
+
+// ValidateWorkflowFile checks the workflow at path against the workflow
+// schema. It returns an error only when the file cannot be read or is not
+// YAML.
+func ValidateWorkflowFile(path string) ([]SchemaError, error) {
+	content, err := common.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf(common.ErrReadingWorkflowFile, err)
+	}
+	errs, err := ValidateWorkflow(content)
+	for i := range errs {
+		errs[i].File = path
+	}
+	return errs, err
+}
+
+// ValidateWorkflow checks the structure of a workflow against the GitHub
+// Actions workflow schema: the keys allowed at each level, the required
+// ones, the types of their values and the events that trigger it.
+// Expressions such as ${{ matrix.os }} are accepted wherever a scalar is.
+func ValidateWorkflow(content []byte) ([]SchemaError, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, fmt.Errorf(common.ErrParsingWorkflowYAML, err)
+	}
+	if len(doc.Content) == 0 {
+		return nil, fmt.Errorf(common.ErrEmptyYAMLDocument)
+	}
+	v := &schemaValidator{}
+	v.workflow(doc.Content[0])
+	sort.SliceStable(v.errs, func(i, j int) bool {
+		if v.errs[i].Line != v.errs[j].Line {
+			return v.errs[i].Line < v.errs[j].Line
+		}
+		return v.errs[i].Column < v.errs[j].Column
+	})
+	return v.errs, nil
+}
+
+// schemaValidator collects the schema errors of a workflow
+type schemaValidator struct {
+	errs []SchemaError
+}
+
+// fail records a schema error at node
+func (v *schemaValidator) fail(node *yaml.Node, path, format string, args ...any) {
+	v.errs = append(v.errs, SchemaError{Line: node.Line, Column: node.Column, Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// mapping checks that node is a mapping with only allowed keys (any key
+// when allowed is nil) and returns its entries, including those merged in
+// with <<; ok is false when node is not a mapping
+func (v *schemaValidator) mapping(node *yaml.Node, path string, allowed map[string]bool) (map[string]*yaml.Node, bool) {
+	node = resolveAlias(node)
+	if node.Kind != yaml.MappingNode {
+		v.fail(node, path, "must be a mapping")
+		return nil, false
+	}
+	entries := make(map[string]*yaml.Node)
+	for _, pair := range mappingPairs(node) {
+		key := pair[0]
+		if allowed != nil && !allowed[key.Value] {
+			v.fail(key, path, "unknown key %q", key.Value)
+			continue
+		}
+		entries[key.Value] = pair[1]
+	}
+	return entries, true
+}
+
+// mappingPairs returns the keys and values of a mapping node in order, with
+// those of mappings merged in with << in their place
+func mappingPairs(node *yaml.Node) [][2]*yaml.Node {
+	var pairs [][2]*yaml.Node
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if key.Value != "<<" {
+			pairs = append(pairs, [2]*yaml.Node{key, value})
+			continue
+		}
+		merged := []*yaml.Node{resolveAlias(value)}
+		if merged[0].Kind == yaml.SequenceNode {
+			merged = sequenceItems(merged[0])
+		}
+		for _, m := range merged {
+			if m.Kind == yaml.MappingNode {
+				pairs = append(pairs, mappingPairs(m)...)
+			}
+		}
+	}
+	return pairs
+}
+
+// require reports the keys missing from entries
+func (v *schemaValidator) require(node *yaml.Node, path string, entries map[string]*yaml.Node, keys ...string) {
+	for _, key := range keys {
+		if _, ok := entries[key]; !ok {
+			v.fail(node, path, "missing required key %q", key)
+		}
+	}
+}
+
+// scalar checks that node is a single value
+func (v *schemaValidator) scalar(node *yaml.Node, path string) bool {
+	if resolveAlias(node).Kind != yaml.ScalarNode {
+		v.fail(node, path, "must be a single value")
+		return false
+	}
+	return true
+}
+
+// scalarOrSequence checks that node is a value or a list of values
+func (v *schemaValidator) scalarOrSequence(node *yaml.Node, path string) []*yaml.Node {
+	node = resolveAlias(node)
+	switch node.Kind {
+	case yaml.ScalarNode:
+		return []*yaml.Node{node}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			if !v.scalar(item, fmt.Sprintf("%s[%d]", path, i)) {
+				return nil
+			}
+		}
+		return node.Content
+	}
+	v.fail(node, path, "must be a value or a list of values")
+	return nil
+}
+
+// mappingOrExpression checks that node is a mapping or an expression
+func (v *schemaValidator) mappingOrExpression(node *yaml.Node, path string) {
+	if isExpression(node) {
+		return
+	}
+	v.mapping(node, path, nil)
+}
+
+// workflow checks the top level of a workflow
+func (v *schemaValidator) workflow(root *yaml.Node) {
+	entries, ok := v.mapping(root, "", workflowKeys)
+	if !ok {
+		return
+	}
+	v.require(root, "", entries, "on", "jobs")
+	if on, ok := entries["on"]; ok {
+		v.events(on)
+	}
+	if permissions, ok := entries["permissions"]; ok {
+		v.permissions(permissions, "permissions")
+	}
+	if env, ok := entries["env"]; ok {
+		v.mapping(env, "env", nil)
+	}
+	if concurrency, ok := entries["concurrency"]; ok {
+		v.concurrency(concurrency, "concurrency")
+	}
+	if jobs, ok := entries["jobs"]; ok {
+		v.jobs(jobs)
+	}
+}
+
+// events checks the events of the on key
+func (v *schemaValidator) events(on *yaml.Node) {
+	on = resolveAlias(on)
+	if on.Kind != yaml.MappingNode {
+		for _, event := range v.scalarOrSequence(on, "on") {
+			if !workflowEvents[event.Value] {
+				v.fail(event, "on", "unknown event %q", event.Value)
+			}
+		}
+		return
+	}
+	for _, pair := range mappingPairs(on) {
+		key, value := pair[0], resolveAlias(pair[1])
+		path := "on." + key.Value
+		switch {
+		case !workflowEvents[key.Value]:
+			v.fail(key, "on", "unknown event %q", key.Value)
+		case key.Value == "schedule":
+			if value.Kind != yaml.SequenceNode {
+				v.fail(value, path, "must be a list of cron schedules")
+				continue
+			}
+			for j, item := range value.Content {
+				itemPath := fmt.Sprintf("%s[%d]", path, j)
+				if schedule, ok := v.mapping(item, itemPath, keySet("cron", "timezone")); ok {
+					v.require(item, itemPath, schedule, "cron")
+				}
+			}
+		case value.Kind == yaml.ScalarNode && value.Tag == "!!null":
+		case value.Kind != yaml.MappingNode:
+			v.fail(value, path, "must be a mapping")
+		}
+	}
+}
+
+// permissions checks a permissions key of the workflow or a job
+func (v *schemaValidator) permissions(node *yaml.Node, path string) {
+	node = resolveAlias(node)
+	if node.Kind == yaml.ScalarNode {
+		if node.Value != "read-all" && node.Value != "write-all" && !isExpression(node) {
+			v.fail(node, path, "must be read-all, write-all or a mapping of scopes")
+		}
+		return
+	}
+	entries, ok := v.mapping(node, path, permissionScopes)
+	if !ok {
+		return
+	}
+	for scope, level := range entries {
+		if !v.scalar(level, path+"."+scope) {
+			continue
+		}
+		switch level.Value {
+		case "read", "write", "none":
+		default:
+			v.fail(level, path+"."+scope, "must be read, write or none")
+		}
+	}
+}
+
+// concurrency checks a concurrency key of the workflow or a job
+func (v *schemaValidator) concurrency(node *yaml.Node, path string) {
+	if resolveAlias(node).Kind == yaml.ScalarNode {
+		return
+	}
+	if entries, ok := v.mapping(node, path, keySet("group", "cancel-in-progress")); ok {
+		v.require(node, path, entries, "group")
+	}
+}
+
+// jobs checks the jobs of the workflow
+func (v *schemaValidator) jobs(node *yaml.Node) {
+	node = resolveAlias(node)
+	jobs, ok := v.mapping(node, "jobs", nil)
+	if !ok {
+		return
+	}
+	if len(jobs) == 0 {
+		v.fail(node, "jobs", "must define at least one job")
+	}
+	for _, pair := range mappingPairs(node) {
+		key := pair[0]
+		if !jobID.MatchString(key.Value) {
+			v.fail(key, "jobs", "invalid job id %q: must start with a letter or _ and contain only letters, digits, - and _", key.Value)
+		}
+		v.job(pair[1], "jobs."+key.Value, jobs)
+	}
+}
+
+// job checks a single job; jobs holds every job, for its needs
+func (v *schemaValidator) job(node *yaml.Node, path string, jobs map[string]*yaml.Node) {
+	// Jobs calling a reusable workflow take other keys
+	allowed := jobKeys
+	if mappingValue(node, "uses") != nil {
+		allowed = callerJobKeys
+	}
+	entries, ok := v.mapping(node, path, allowed)
+	if !ok {
+		return
+	}
+
+	if uses, ok := entries["uses"]; ok {
+		v.scalar(uses, path+".uses")
+		if with, ok := entries["with"]; ok {
+			v.mapping(with, path+".with", nil)
+		}
+		if secrets, ok := entries["secrets"]; ok && resolveAlias(secrets).Kind != yaml.ScalarNode {
+			v.mapping(secrets, path+".secrets", nil)
+		}
+	} else {
+		v.require(node, path, entries, "runs-on")
+	}
+
+	if needs, ok := entries["needs"]; ok {
+		for _, need := range v.scalarOrSequence(needs, path+".needs") {
+			if _, ok := jobs[need.Value]; !ok {
+				v.fail(need, path+".needs", "unknown job %q", need.Value)
+			}
+		}
+	}
+	if permissions, ok := entries["permissions"]; ok {
+		v.permissions(permissions, path+".permissions")
+	}
+	if concurrency, ok := entries["concurrency"]; ok {
+		v.concurrency(concurrency, path+".concurrency")
+	}
+	if runsOn, ok := entries["runs-on"]; ok && resolveAlias(runsOn).Kind == yaml.MappingNode {
+		v.mapping(runsOn, path+".runs-on", keySet("group", "labels"))
+	} else if ok {
+		v.scalarOrSequence(runsOn, path+".runs-on")
+	}
+	for _, key := range []string{"env", "outputs", "services"} {
+		if value, ok := entries[key]; ok {
+			v.mappingOrExpression(value, path+"."+key)
+		}
+	}
+	if strategy, ok := entries["strategy"]; ok {
+		if strategyEntries, ok := v.mapping(strategy, path+".strategy", strategyKeys); ok {
+			if matrix, ok := strategyEntries["matrix"]; ok {
+				v.mappingOrExpression(matrix, path+".strategy.matrix")
+			}
+		}
+	}
+	if timeout, ok := entries["timeout-minutes"]; ok {
+		v.number(timeout, path+".timeout-minutes")
+	}
+	if steps, ok := entries["steps"]; ok {
+		v.steps(steps, path+".steps")
+	}
+}
+
+// steps checks the steps of a job
+func (v *schemaValidator) steps(node *yaml.Node, path string) {
+	node = resolveAlias(node)
+	if node.Kind != yaml.SequenceNode {
+		v.fail(node, path, "must be a list of steps")
+		return
+	}
+	if len(node.Content) == 0 {
+		v.fail(node, path, "must contain at least one step")
+	}
+	for i, step := range node.Content {
+		stepPath := fmt.Sprintf("%s[%d]", path, i)
+		entries, ok := v.mapping(step, stepPath, stepKeys)
+		if !ok {
+			continue
+		}
+		uses, hasUses := entries["uses"]
+		run, hasRun := entries["run"]
+		switch {
+		case hasUses && hasRun:
+			v.fail(step, stepPath, "must have either uses or run, not both")
+		case hasUses:
+			v.scalar(uses, stepPath+".uses")
+			for _, key := range []string{"shell", "working-directory"} {
+				if _, ok := entries[key]; ok {
+					v.fail(step, stepPath, "%s is only allowed with run", key)
+				}
+			}
+		case hasRun:
+			v.scalar(run, stepPath+".run")
+		default:
+			v.fail(step, stepPath, "must have uses or run")
+		}
+		if with, ok := entries["with"]; ok {
+			v.mappingOrExpression(with, stepPath+".with")
+		}
+		if env, ok := entries["env"]; ok {
+			v.mappingOrExpression(env, stepPath+".env")
+		}
+		if timeout, ok := entries["timeout-minutes"]; ok {
+			v.number(timeout, stepPath+".timeout-minutes")
+		}
+	}
+}
+
+// number checks that node is a number or an expression
+func (v *schemaValidator) number(node *yaml.Node, path string) {
+	node = resolveAlias(node)
+	if isExpression(node) {
+		return
+	}
+	if node.Kind != yaml.ScalarNode || (node.Tag != "!!int" && node.Tag != "!!float") {
+		v.fail(node, path, "must be a number")
+	}
+}
+
+// isExpression reports whether node is a scalar holding a ${{ }} expression
+func isExpression(node *yaml.Node) bool {
+	node = resolveAlias(node)
+	return node.Kind == yaml.ScalarNode && strings.Contains(node.Value, "${{")
+}
+
+// validateSchema adds the schema errors of a workflow file to report.
+// Files that are not YAML are left to the parse failure reported for them.
+func validateSchema(file string, report *Report) {
+	errs, err := ValidateWorkflowFile(file)
+	if err != nil {
+		log.Printf(common.ErrValidatingSchema, file, err)
+		return
+	}
+	for _, schemaErr := range errs {
+		log.Printf(common.ErrSchemaViolation, file, schemaErr.Line, schemaErr.Error())
+	}
+	report.SchemaErrors = append(report.SchemaErrors, errs...)
+}
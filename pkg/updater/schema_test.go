@@ -0,0 +1,139 @@
+package updater
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidateWorkflow(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string // Line: error
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			content: `name: CI
+on:
+  push:
+    branches: [main]
+  schedule:
+    - cron: "0 0 * * *"
+permissions:
+  contents: read
+jobs:
+  build: &build
+    runs-on: ubuntu-latest
+    strategy:
+      matrix: ${{ fromJSON(vars.MATRIX) }}
+    timeout-minutes: 10
+    steps:
+      - uses: actions/checkout@v4
+        with:
+          fetch-depth: 0
+      - run: make
+        shell: bash
+  test:
+    <<: *build
+    needs: build
+  release:
+    needs: [build, test]
+    uses: octo/workflows/.github/workflows/release.yml@v1
+    secrets: inherit
+`,
+		},
+		{
+			name: "structural errors",
+			content: `on: [push, pushed]
+permissions: read
+jobs:
+  1build:
+    runs-on: ubuntu-latest
+    step:
+      - run: make
+  test:
+    needs: lint
+    timeout-minutes: ten
+    steps:
+      - name: nothing to do
+      - uses: actions/checkout@v4
+        run: make
+      - uses: actions/setup-go@v5
+        shell: bash
+  deploy:
+    uses: octo/workflows/.github/workflows/deploy.yml@v1
+    runs-on: ubuntu-latest
+`,
+			want: []string{
+				`1: on: unknown event "pushed"`,
+				`2: permissions: must be read-all, write-all or a mapping of scopes`,
+				`4: jobs: invalid job id "1build": must start with a letter or _ and contain only letters, digits, - and _`,
+				`6: jobs.1build: unknown key "step"`,
+				`9: jobs.test: missing required key "runs-on"`,
+				`9: jobs.test.needs: unknown job "lint"`,
+				`10: jobs.test.timeout-minutes: must be a number`,
+				`12: jobs.test.steps[0]: must have uses or run`,
+				`13: jobs.test.steps[1]: must have either uses or run, not both`,
+				`15: jobs.test.steps[2]: shell is only allowed with run`,
+				`19: jobs.deploy: unknown key "runs-on"`,
+			},
+		},
+		{
+			name:    "missing keys",
+			content: "name: CI\n",
+			want:    []string{`1: missing required key "on"`, `1: missing required key "jobs"`},
+		},
+		{name: "not a mapping", content: "- on\n", want: []string{"1: must be a mapping"}},
+		{name: "invalid YAML", content: "on: [push\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs, err := ValidateWorkflow([]byte(tt.content))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateWorkflow() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var got []string
+			for _, schemaErr := range errs {
+				got = append(got, fmt.Sprintf("%d: %s", schemaErr.Line, schemaErr.Error()))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ValidateWorkflow() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestRunValidateSchema(t *testing.T) {
+	dir, _ := writeRunRepo(t)
+	invalid := filepath.Join(dir, ".github", "workflows", "broken.yml")
+	if err := os.WriteFile(invalid, []byte("on: push\njobs:\n  build:\n    steps:\n      - uses: actions/checkout@v3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, validate := range []bool{false, true} {
+		rep, err := Run(context.Background(), Options{RepoPath: dir, Mode: ModeDryRun, Checker: &countingChecker{}, ValidateSchema: validate})
+		if err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+		if !validate {
+			if len(rep.SchemaErrors) != 0 {
+				t.Errorf("Run() without ValidateSchema reported %v", rep.SchemaErrors)
+			}
+			continue
+		}
+		want := []SchemaError{{File: invalid, Line: 4, Column: 5, Path: "jobs.build", Message: `missing required key "runs-on"`}}
+		if !reflect.DeepEqual(rep.SchemaErrors, want) {
+			t.Errorf("Run() schema errors = %+v, want %+v", rep.SchemaErrors, want)
+		}
+		// Invalid workflows are still pinned
+		if len(rep.Updates) != 3 {
+			t.Errorf("Run() updates = %d, want 3", len(rep.Updates))
+		}
+	}
+}
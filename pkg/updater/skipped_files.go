@@ -0,0 +1,70 @@
+package updater
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SkippedFilesSetter is implemented by PR creators that list the files a run
+// could not parse in the pull request body, so maintainers learn about broken
+// workflows from the pull request itself
+type SkippedFilesSetter interface {
+	SetSkippedFiles(files []Failure)
+}
+
+// skippedFiles returns the files of failures that could not be parsed
+func skippedFiles(failures []Failure) []Failure {
+	var files []Failure
+	for _, failure := range failures {
+		if failure.Stage == FailureParse {
+			files = append(files, failure)
+		}
+	}
+	return files
+}
+
+// skippedFilesSection lists files in a pull request body, with their paths
+// made relative like the updated files; "" when there are none
+func skippedFilesSection(files []Failure, repoRoot, workflowsPath string) string {
+	if len(files) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("### Skipped files\n\n")
+	sb.WriteString("These files could not be parsed, so the actions they use were not checked:\n\n")
+	for _, file := range files {
+		reason := file.Reason
+		if reason == "" {
+			reason = file.Message
+		}
+		// Keep multi-line errors inside their list item
+		reason = strings.ReplaceAll(strings.TrimSpace(reason), "\n", " ")
+		sb.WriteString(fmt.Sprintf("* `%s`: %s\n", relativeRepoPath(file.File, repoRoot, workflowsPath), reason))
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// SetSkippedFiles lists files in the body of the pull requests created next
+func (c *DefaultPRCreator) SetSkippedFiles(files []Failure) {
+	c.skippedFiles = files
+}
+
+// SetSkippedFiles lists files in the body of the pull requests created next
+func (c *GiteaPRCreator) SetSkippedFiles(files []Failure) {
+	c.skippedFiles = files
+}
+
+// SetSkippedFiles implements SkippedFilesSetter when the wrapped creator does
+func (c *RetryingPRCreator) SetSkippedFiles(files []Failure) {
+	if setter, ok := c.creator.(SkippedFilesSetter); ok {
+		setter.SetSkippedFiles(files)
+	}
+}
+
+// SetSkippedFiles implements SkippedFilesSetter when the wrapped creator does
+func (c *TicketingPRCreator) SetSkippedFiles(files []Failure) {
+	if setter, ok := c.creator.(SkippedFilesSetter); ok {
+		setter.SetSkippedFiles(files)
+	}
+}
@@ -0,0 +1,81 @@
+package updater
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+)
+
+// skipRecordingPRCreator records the skipped files passed before CreatePR
+type skipRecordingPRCreator struct {
+	capturingPRCreator
+	skipped []Failure
+}
+
+func (c *skipRecordingPRCreator) SetSkippedFiles(files []Failure) {
+	c.skipped = files
+}
+
+func TestRunSkippedFiles(t *testing.T) {
+	dir, _ := writeRunRepo(t)
+	broken := filepath.Join(dir, ".github", "workflows", "broken.yml")
+	if err := os.WriteFile(broken, []byte("jobs:\n  build:\n    steps: [\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	creator := &skipRecordingPRCreator{}
+	_, err := Run(context.Background(), Options{
+		RepoPath: dir,
+		Mode:     ModePR,
+		Checker:  &countingChecker{},
+		Creator:  NewRetryingPRCreator(creator, common.RetryPolicy{MaxAttempts: 1}),
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if creator.prs != 1 || len(creator.skipped) != 1 || creator.skipped[0].File != broken || creator.skipped[0].Reason == "" {
+		t.Errorf("PRs = %d, skipped files = %+v; want broken.yml with its reason", creator.prs, creator.skipped)
+	}
+}
+
+func TestSkippedFilesInPRBody(t *testing.T) {
+	root := t.TempDir()
+	updates := []*Update{{
+		Action:     ActionReference{Owner: "actions", Name: "checkout"},
+		OldVersion: "v3", NewVersion: "v4",
+		FilePath: filepath.Join(root, ".github", "workflows", "ci.yml"),
+	}}
+	failures := []Failure{
+		{Stage: FailureParse, File: filepath.Join(root, ".github", "workflows", "broken.yml"), Reason: "yaml: line 3: did not find expected node content\nnear [", Message: "Failed to parse broken.yml"},
+		{Stage: FailureCheck, File: filepath.Join(root, ".github", "workflows", "ci.yml"), Message: "Failed to check octo/tool"},
+		{Stage: FailureParse, File: filepath.Join(root, ".github", "actions", "build", "action.yml"), Message: "Failed to parse action.yml: unknown field"},
+	}
+
+	creator := NewPRCreator("", "owner", "repo")
+	creator.SetRepoRoot(root)
+	if body := creator.generatePRBody(updates); strings.Contains(body, "Skipped files") {
+		t.Errorf("generatePRBody() without skipped files:\n%s", body)
+	}
+
+	creator.SetSkippedFiles(skippedFiles(failures))
+	body := creator.generatePRBody(updates)
+	for _, want := range []string{
+		"### Skipped files",
+		"* `.github/workflows/broken.yml`: yaml: line 3: did not find expected node content near [\n",
+		"* `.github/actions/build/action.yml`: Failed to parse action.yml: unknown field\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("generatePRBody() does not contain %q:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "octo/tool") {
+		t.Errorf("generatePRBody() lists a failed lookup as a skipped file:\n%s", body)
+	}
+	if strings.Index(body, "### Skipped files") > strings.Index(body, "---\n") {
+		t.Errorf("generatePRBody() lists skipped files after the footer:\n%s", body)
+	}
+}
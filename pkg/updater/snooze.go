@@ -0,0 +1,111 @@
+package updater
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/storage"
+)
+
+// Snooze defers updates of an action in one repository until a given time
+type Snooze struct {
+	Action  string    `json:"action"`            // Full action name, e.g. "actions/checkout"
+	Version string    `json:"version,omitempty"` // Only this target version; empty snoozes every version
+	Until   time.Time `json:"until"`
+}
+
+// Snoozes are the snoozed updates of a repository
+type Snoozes []Snooze
+
+// snoozesKey returns the store key for a repository's snoozes
+func snoozesKey(owner, repo string) string {
+	return fmt.Sprintf("%s/snoozes/%s/%s.json", stateKeyPrefix, owner, repo)
+}
+
+// ParseSnoozeTarget splits an action[@version] argument
+func ParseSnoozeTarget(target string) (string, string, error) {
+	action, version, _ := strings.Cut(strings.TrimSpace(target), "@")
+	if strings.Count(action, "/") < 1 || strings.HasPrefix(action, "./") {
+		return "", "", fmt.Errorf(common.ErrInvalidSnoozeTarget, target)
+	}
+	return strings.ToLower(action), version, nil
+}
+
+// LoadSnoozes loads the snoozes of a repository. A repository without
+// snoozes has none.
+func LoadSnoozes(ctx context.Context, store storage.Store, owner, repo string) (Snoozes, error) {
+	data, err := store.Get(ctx, snoozesKey(owner, repo))
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var snoozes Snoozes
+	if err := json.Unmarshal(data, &snoozes); err != nil {
+		return nil, fmt.Errorf(common.ErrDecodingCacheEntry, err)
+	}
+	return snoozes, nil
+}
+
+// SaveSnoozes persists the snoozes of a repository, dropping expired ones
+func SaveSnoozes(ctx context.Context, store storage.Store, owner, repo string, snoozes Snoozes, now time.Time) error {
+	active := make(Snoozes, 0, len(snoozes))
+	for _, snooze := range snoozes {
+		if snooze.Until.After(now) {
+			active = append(active, snooze)
+		}
+	}
+	if len(active) == 0 {
+		return store.Delete(ctx, snoozesKey(owner, repo))
+	}
+	sort.Slice(active, func(i, j int) bool {
+		if active[i].Action != active[j].Action {
+			return active[i].Action < active[j].Action
+		}
+		return active[i].Version < active[j].Version
+	})
+	data, err := json.MarshalIndent(active, "", "  ")
+	if err != nil {
+		return err
+	}
+	return store.Put(ctx, snoozesKey(owner, repo), data)
+}
+
+// Add snoozes action (at version, if set) until the given time, replacing
+// an existing snooze of the same update
+func (s Snoozes) Add(action, version string, until time.Time) Snoozes {
+	s = s.Remove(action, version)
+	return append(s, Snooze{Action: strings.ToLower(action), Version: version, Until: until})
+}
+
+// Remove drops the snooze of action at version. An empty version removes
+// every snooze of the action.
+func (s Snoozes) Remove(action, version string) Snoozes {
+	action = strings.ToLower(action)
+	kept := make(Snoozes, 0, len(s))
+	for _, snooze := range s {
+		if snooze.Action == action && (version == "" || snooze.Version == version) {
+			continue
+		}
+		kept = append(kept, snooze)
+	}
+	return kept
+}
+
+// Covers reports whether an update of ref to newVersion is snoozed at now
+func (s Snoozes) Covers(ref ActionReference, newVersion string, now time.Time) bool {
+	action := strings.ToLower(ref.FullName())
+	for _, snooze := range s {
+		if snooze.Action == action && (snooze.Version == "" || snooze.Version == newVersion) && snooze.Until.After(now) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package updater
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/storage"
+)
+
+func TestSnoozesCovers(t *testing.T) {
+	now := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	checkout := ActionReference{Owner: "actions", Name: "checkout", Version: "v3"}
+	snoozes := Snoozes{}.
+		Add("Actions/Checkout", "", now.Add(time.Hour)).
+		Add("octo/tool", "v2", now.Add(time.Hour)).
+		Add("octo/old", "", now.Add(-time.Hour))
+
+	tests := []struct {
+		name    string
+		ref     ActionReference
+		version string
+		want    bool
+	}{
+		{name: "any version", ref: checkout, version: "v4", want: true},
+		{name: "matching version", ref: ActionReference{Owner: "octo", Name: "tool"}, version: "v2", want: true},
+		{name: "newer version", ref: ActionReference{Owner: "octo", Name: "tool"}, version: "v3", want: false},
+		{name: "expired", ref: ActionReference{Owner: "octo", Name: "old"}, version: "v2", want: false},
+		{name: "other action", ref: ActionReference{Owner: "actions", Name: "setup-go"}, version: "v5", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := snoozes.Covers(tt.ref, tt.version, now); got != tt.want {
+				t.Errorf("Covers() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if snoozes.Remove("actions/checkout", "").Covers(checkout, "v4", now) {
+		t.Error("Remove() kept the snooze")
+	}
+}
+
+func TestSnoozesStore(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	now := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	snoozes, err := LoadSnoozes(ctx, store, "owner", "repo")
+	if err != nil || len(snoozes) != 0 {
+		t.Fatalf("LoadSnoozes() = %v, %v, want none", snoozes, err)
+	}
+
+	snoozes = snoozes.Add("actions/checkout", "v4", now.Add(24*time.Hour)).Add("octo/old", "", now.Add(-time.Hour))
+	if err := SaveSnoozes(ctx, store, "owner", "repo", snoozes, now); err != nil {
+		t.Fatalf("SaveSnoozes() error = %v", err)
+	}
+	loaded, err := LoadSnoozes(ctx, store, "owner", "repo")
+	if err != nil {
+		t.Fatalf("LoadSnoozes() error = %v", err)
+	}
+	if len(loaded) != 1 || loaded[0].Action != "actions/checkout" || loaded[0].Version != "v4" {
+		t.Errorf("LoadSnoozes() = %+v, want only the active snooze", loaded)
+	}
+
+	// Saving no active snoozes removes the entry
+	if err := SaveSnoozes(ctx, store, "owner", "repo", loaded.Remove("actions/checkout", ""), now); err != nil {
+		t.Fatalf("SaveSnoozes() error = %v", err)
+	}
+	if keys, _ := store.List(ctx, stateKeyPrefix+"/snoozes/"); len(keys) != 0 {
+		t.Errorf("snooze keys left behind: %v", keys)
+	}
+}
+
+func TestParseSnoozeTarget(t *testing.T) {
+	action, version, err := ParseSnoozeTarget("Actions/Checkout@v4")
+	if err != nil || action != "actions/checkout" || version != "v4" {
+		t.Errorf("ParseSnoozeTarget() = %q, %q, %v", action, version, err)
+	}
+	for _, target := range []string{"checkout", "./local/action", ""} {
+		if _, _, err := ParseSnoozeTarget(target); err == nil {
+			t.Errorf("ParseSnoozeTarget(%q) expected error", target)
+		}
+	}
+}
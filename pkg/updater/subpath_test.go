@@ -0,0 +1,88 @@
+package updater
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v72/github"
+)
+
+func TestActionReferenceRepository(t *testing.T) {
+	tests := []struct {
+		ref            ActionReference
+		wantRepo       string
+		wantSubpath    string
+		wantRepository string
+	}{
+		{ref: ActionReference{Owner: "actions", Name: "checkout"}, wantRepo: "checkout", wantRepository: "actions/checkout"},
+		{ref: ActionReference{Owner: "github", Name: "codeql-action/init"}, wantRepo: "codeql-action", wantSubpath: "init", wantRepository: "github/codeql-action"},
+		{ref: ActionReference{Owner: "tools", Name: "lint/sub/dir", Host: "ghe.example.com"}, wantRepo: "lint", wantSubpath: "sub/dir", wantRepository: "ghe.example.com/tools/lint"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref.FullName(), func(t *testing.T) {
+			if repo, subpath, repository := tt.ref.Repo(), tt.ref.Subpath(), tt.ref.Repository(); repo != tt.wantRepo || subpath != tt.wantSubpath || repository != tt.wantRepository {
+				t.Errorf("Repo() = %q, Subpath() = %q, Repository() = %q", repo, subpath, repository)
+			}
+		})
+	}
+}
+
+func TestRunUpdatesActionsInSubdirectories(t *testing.T) {
+	const hash = "1111111111111111111111111111111111111111"
+	var requests []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Path)
+		switch r.URL.Path {
+		case "/repos/github/codeql-action/releases/latest":
+			fmt.Fprint(w, `{"tag_name":"v3.28.0"}`)
+		case "/repos/github/codeql-action/git/ref/tags/v3.28.0":
+			fmt.Fprintf(w, `{"ref":"refs/tags/v3.28.0","object":{"type":"commit","sha":%q}}`, hash)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	checker := &DefaultVersionChecker{client: client}
+
+	dir := t.TempDir()
+	workflow := filepath.Join(dir, ".github", "workflows", "codeql.yml")
+	if err := os.MkdirAll(filepath.Dir(workflow), 0750); err != nil {
+		t.Fatal(err)
+	}
+	content := "jobs:\n  analyze:\n    steps:\n      - uses: github/codeql-action/init@v3\n      - uses: github/codeql-action/analyze@v3\n"
+	if err := os.WriteFile(workflow, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	rep, err := Run(context.Background(), Options{RepoPath: dir, Mode: ModeStage, Checker: checker})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(rep.Updates) != 2 || len(rep.Warnings) != 0 {
+		t.Fatalf("updates = %d, warnings = %v, want 2 updates", len(rep.Updates), rep.Warnings)
+	}
+	// Both actions share the repository's releases, so it is checked once
+	// (GetLatestVersion and IsUpdateAvailable) rather than per action
+	if len(requests) != 4 {
+		t.Errorf("requests = %v, want a single check of github/codeql-action", requests)
+	}
+
+	got, _ := os.ReadFile(workflow)
+	for _, want := range []string{"uses: github/codeql-action/init@" + hash + "  # v3.28.0\n", "uses: github/codeql-action/analyze@" + hash + "  # v3.28.0\n"} {
+		if !strings.Contains(string(got), want) {
+			t.Errorf("workflow = %q, want containing %q", got, want)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package updater
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+)
+
+const (
+	// maxReleaseNotesLength limits the release notes sent to a summarizer
+	maxReleaseNotesLength = 16 * 1024
+	// maxSummaryLength limits the summary added to a pull request
+	maxSummaryLength = 1000
+	// summarizerTimeout bounds a single summarizer call
+	summarizerTimeout = time.Minute
+	// SummarizerTokenEnv names the variable holding the HTTP summarizer's bearer token
+	SummarizerTokenEnv = "SUMMARIZER_TOKEN"
+)
+
+// SummaryRequest is everything a summarizer receives. It only carries public
+// upstream data: no repository names, file paths or tokens of the run.
+type SummaryRequest struct {
+	Action       string `json:"action"`
+	FromVersion  string `json:"from_version"`
+	ToVersion    string `json:"to_version"`
+	ReleaseNotes string `json:"release_notes"`
+}
+
+// Summarizer condenses release notes into a short "what changed / breaking
+// changes" paragraph
+type Summarizer interface {
+	Summarize(ctx context.Context, req SummaryRequest) (string, error)
+}
+
+// NewSummarizer creates a summarizer from a spec: "command:<program> [args]"
+// runs a local program, and an https:// URL posts to an HTTP endpoint. An
+// empty spec disables summarization and returns nil.
+func NewSummarizer(spec string) (Summarizer, error) {
+	switch {
+	case spec == "":
+		return nil, nil
+	case strings.HasPrefix(spec, "command:"):
+		args := strings.Fields(strings.TrimPrefix(spec, "command:"))
+		if len(args) == 0 {
+			return nil, fmt.Errorf(common.ErrUnknownSummarizer, spec)
+		}
+		return &CommandSummarizer{Args: args}, nil
+	case strings.HasPrefix(spec, "https://"), strings.HasPrefix(spec, "http://"):
+		u, err := url.Parse(spec)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf(common.ErrUnknownSummarizer, spec)
+		}
+		if u.Scheme == "http" && !isLoopbackHost(u.Hostname()) {
+			return nil, fmt.Errorf(common.ErrInsecureSummarizerURL, u.Redacted())
+		}
+		return &HTTPSummarizer{URL: spec, Token: os.Getenv(SummarizerTokenEnv)}, nil
+	default:
+		return nil, fmt.Errorf(common.ErrUnknownSummarizer, spec)
+	}
+}
+
+// CommandSummarizer runs a local program with the request as JSON on stdin
+// and reads the summary from stdout. The program gets a minimal environment
+// so tokens of the run are not passed on.
+type CommandSummarizer struct {
+	Args []string
+}
+
+// Summarize implements Summarizer
+func (s *CommandSummarizer) Summarize(ctx context.Context, req SummaryRequest) (string, error) {
+	input, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, summarizerTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, s.Args[0], s.Args[1:]...) // #nosec G204 - program is configured by the user
+	cmd.Env = minimalEnv()
+	cmd.Stdin = bytes.NewReader(input)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return string(out), nil
+}
+
+// HTTPSummarizer posts the request as JSON to URL and expects a JSON
+// response of the form {"summary": "..."}
+type HTTPSummarizer struct {
+	URL    string
+	Token  string       // Optional bearer token, never a GitHub token
+	Client *http.Client // http.DefaultClient if nil
+}
+
+// Summarize implements Summarizer
+func (s *HTTPSummarizer) Summarize(ctx context.Context, req SummaryRequest) (string, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, summarizerTimeout)
+	defer cancel()
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if s.Token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+s.Token)
+	}
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf(common.ErrSummarizerStatus, resp.StatusCode)
+	}
+
+	var result struct {
+		Summary string `json:"summary"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64*1024)).Decode(&result); err != nil {
+		return "", err
+	}
+	return result.Summary, nil
+}
+
+// minimalEnv returns the environment passed to summarizer programs
+func minimalEnv() []string {
+	var env []string
+	for _, name := range []string{"PATH", "HOME", "TMPDIR", "LANG"} {
+		if value, ok := os.LookupEnv(name); ok {
+			env = append(env, name+"="+value)
+		}
+	}
+	return env
+}
+
+// isLoopbackHost reports whether host is localhost or a loopback address
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,330 @@
+package updater
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+	"gopkg.in/yaml.v3"
+)
+
+// templateExpression matches the ${{ matrix.key }} and ${{ env.KEY }}
+// expressions that can be resolved from values defined in the same file
+var templateExpression = regexp.MustCompile(`\$\{\{\s*(matrix|env)\.([A-Za-z0-9_-]+)\s*\}\}`)
+
+// templatePiece is a literal part of a uses template or the value an
+// expression resolved to
+type templatePiece struct {
+	text string
+	node *yaml.Node // Source of an expression value; nil for literals
+}
+
+// templateScope holds the env and matrix definitions visible to a uses value
+type templateScope struct {
+	envs   []*yaml.Node // Enclosing env mappings, innermost first
+	matrix *yaml.Node
+}
+
+// resolveTemplatedUses finds uses values built from matrix or env values,
+// such as uses: ${{ matrix.action }} or uses: actions/checkout@${{ env.V }},
+// and returns a reference for every combination that can be resolved
+// statically. Each reference points at the value holding its version, which
+// is where it gets updated. The returned lines are the uses lines that
+// resolved to at least one reference.
+func resolveTemplatedUses(root *yaml.Node, path string) ([]ActionReference, map[int]bool) {
+	r := &templateResolver{
+		path:       path,
+		candidates: make(map[*yaml.Node]*templateCandidate),
+		usesLines:  make(map[*yaml.Node][]int),
+	}
+	r.walk(root, templateScope{})
+
+	var refs []ActionReference
+	resolved := make(map[int]bool)
+	for _, node := range r.order {
+		if c := r.candidates[node]; !c.ambiguous {
+			refs = append(refs, *c.ref)
+			for _, line := range r.usesLines[node] {
+				resolved[line] = true
+			}
+		}
+	}
+	return refs, resolved
+}
+
+// templateCandidate is the reference resolved for a value holding a version
+type templateCandidate struct {
+	ref       *ActionReference
+	ambiguous bool // The value serves several actions
+}
+
+// templateResolver collects the references of templated uses values
+type templateResolver struct {
+	path       string
+	candidates map[*yaml.Node]*templateCandidate
+	order      []*yaml.Node
+	usesLines  map[*yaml.Node][]int
+}
+
+// walk visits every mapping of the document, so uses values are found
+// wherever they appear (job steps, composite action steps, reusable
+// workflow calls), with the env and matrix definitions in scope
+func (r *templateResolver) walk(node *yaml.Node, scope templateScope) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		if env := mappingValue(node, "env"); env != nil {
+			scope.envs = append([]*yaml.Node{env}, scope.envs...)
+		}
+		if matrix := mappingValue(mappingValue(node, "strategy"), "matrix"); matrix != nil {
+			scope.matrix = matrix
+		}
+		if uses := mappingValue(node, "uses"); uses != nil && uses.Kind == yaml.ScalarNode && strings.Contains(uses.Value, "${{") {
+			r.resolve(uses, scope)
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value != "run" {
+				r.walk(node.Content[i+1], scope)
+			}
+		}
+	case yaml.SequenceNode, yaml.DocumentNode:
+		for _, child := range node.Content {
+			r.walk(child, scope)
+		}
+	}
+}
+
+// resolve records the references a templated uses value expands to
+func (r *templateResolver) resolve(uses *yaml.Node, scope templateScope) {
+	for _, pieces := range expandTemplate(uses.Value, scope) {
+		node, prefix, ok := versionSource(pieces)
+		if !ok {
+			continue
+		}
+		var concrete strings.Builder
+		for _, piece := range pieces {
+			concrete.WriteString(piece.text)
+		}
+		ref, err := parseActionReference(concrete.String(), r.path, nil)
+		if err != nil || ref.IsLocal() || strings.Contains(ref.Version, "${{") {
+			continue
+		}
+		ref.Line = node.Line
+		ref.TemplatePrefix = prefix
+		ref.TemplateSource = true
+
+		c, exists := r.candidates[node]
+		switch {
+		case !exists:
+			r.candidates[node] = &templateCandidate{ref: ref}
+			r.order = append(r.order, node)
+		case c.ref.FullName() != ref.FullName():
+			// One value serving several actions cannot get one hash
+			c.ambiguous = true
+		}
+		r.usesLines[node] = append(r.usesLines[node], uses.Line)
+	}
+}
+
+// expandTemplate returns the pieces of every combination a uses template
+// expands to, or nil if an expression cannot be resolved statically
+func expandTemplate(template string, scope templateScope) [][]templatePiece {
+	matches := templateExpression.FindAllStringSubmatchIndex(template, -1)
+	var keys []string
+	for _, m := range matches {
+		if template[m[2]:m[3]] == "matrix" && !containsString(keys, template[m[4]:m[5]]) {
+			keys = append(keys, template[m[4]:m[5]])
+		}
+	}
+
+	var combos []map[string]*yaml.Node
+	if len(keys) > 0 {
+		combos = matrixCombinations(scope.matrix, keys)
+	} else {
+		combos = []map[string]*yaml.Node{{}}
+	}
+
+	var expanded [][]templatePiece
+	for _, combo := range combos {
+		var pieces []templatePiece
+		last := 0
+		for _, m := range matches {
+			literal := template[last:m[0]]
+			if strings.Contains(literal, "${{") {
+				return nil
+			}
+			if literal != "" {
+				pieces = append(pieces, templatePiece{text: literal})
+			}
+			name := template[m[4]:m[5]]
+			node := combo[name]
+			if template[m[2]:m[3]] == "env" {
+				node = lookupEnv(scope.envs, name)
+			}
+			if node == nil || strings.Contains(node.Value, "${{") {
+				return nil
+			}
+			pieces = append(pieces, templatePiece{text: node.Value, node: node})
+			last = m[1]
+		}
+		if rest := template[last:]; rest != "" {
+			if strings.Contains(rest, "${{") {
+				return nil
+			}
+			pieces = append(pieces, templatePiece{text: rest})
+		}
+		expanded = append(expanded, pieces)
+	}
+	return expanded
+}
+
+// matrixCombinations returns the values of keys for every combination of a
+// literal matrix: the product of the key's values plus each include entry
+// defining all keys
+func matrixCombinations(matrix *yaml.Node, keys []string) []map[string]*yaml.Node {
+	if matrix == nil || matrix.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	combos := []map[string]*yaml.Node{{}}
+	for _, key := range keys {
+		values := scalarItems(mappingValue(matrix, key))
+		var next []map[string]*yaml.Node
+		for _, combo := range combos {
+			for _, value := range values {
+				c := map[string]*yaml.Node{key: value}
+				for k, v := range combo {
+					c[k] = v
+				}
+				next = append(next, c)
+			}
+		}
+		combos = next
+	}
+
+	for _, entry := range sequenceItems(mappingValue(matrix, "include")) {
+		combo := make(map[string]*yaml.Node)
+		for _, key := range keys {
+			if value := mappingValue(entry, key); value != nil && value.Kind == yaml.ScalarNode {
+				combo[key] = value
+			}
+		}
+		if len(combo) == len(keys) {
+			combos = append(combos, combo)
+		}
+	}
+	return combos
+}
+
+// lookupEnv returns the innermost scalar env value named name
+func lookupEnv(envs []*yaml.Node, name string) *yaml.Node {
+	for _, env := range envs {
+		if value := mappingValue(env, name); value != nil && value.Kind == yaml.ScalarNode {
+			return value
+		}
+	}
+	return nil
+}
+
+// versionSource returns the value holding the version of an expanded
+// template and the text before the version in it. The version must be the
+// whole value following a literal "@", or the end of a value containing it.
+func versionSource(pieces []templatePiece) (*yaml.Node, string, bool) {
+	for i, piece := range pieces {
+		at := strings.LastIndex(piece.text, "@")
+		if at < 0 {
+			continue
+		}
+		last := i == len(pieces)-1
+		switch {
+		case piece.node != nil && last:
+			return piece.node, piece.text[:at+1], true
+		case piece.node == nil && at == len(piece.text)-1 && i == len(pieces)-2 && pieces[i+1].node != nil:
+			return pieces[i+1].node, "", true
+		default:
+			return nil, "", false
+		}
+	}
+	return nil, "", false
+}
+
+// containsString reports whether values contains s
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// rewriteTemplateValues replaces the matrix or env values that templated
+// uses references were resolved from. Other updates are returned unchanged.
+func rewriteTemplateValues(content string, updates []*Update) (string, []*Update) {
+	var templateUpdates, remaining []*Update
+	for _, update := range updates {
+		if update.Action.TemplateSource {
+			templateUpdates = append(templateUpdates, update)
+		} else {
+			remaining = append(remaining, update)
+		}
+	}
+	if len(templateUpdates) == 0 {
+		return content, updates
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(content), &doc); err != nil {
+		log.Printf(common.ErrTemplateValueNotFound, len(templateUpdates), err)
+		return content, remaining
+	}
+	byLine := make(map[int][]*yaml.Node)
+	collectScalars(&doc, byLine, make(map[*yaml.Node]bool))
+
+	lines := strings.Split(content, "\n")
+	var edits []scalarEdit
+	claimed := make(map[*yaml.Node]bool)
+	for _, update := range templateUpdates {
+		current := update.Action.CommitHash
+		if current == "" {
+			current = update.Action.Version
+		}
+		var node *yaml.Node
+		for _, candidate := range byLine[update.LineNumber] {
+			if !claimed[candidate] && candidate.Value == update.Action.TemplatePrefix+current {
+				node = candidate
+				break
+			}
+		}
+		if node == nil {
+			log.Printf(common.ErrTemplateValueNotFound, 1, fmt.Errorf("no value %q on line %d", update.Action.TemplatePrefix+current, update.LineNumber))
+			continue
+		}
+		edit, ok := locateScalar(lines, &usesScalar{node: node, line: node.Line, col: node.Column}, update)
+		if !ok {
+			log.Printf(common.ErrTemplateValueNotFound, 1, fmt.Errorf("unsupported value on line %d", update.LineNumber))
+			continue
+		}
+		claimed[node] = true
+		edit.value = update.Action.TemplatePrefix + update.NewRef()
+		edits = append(edits, edit)
+	}
+	applyScalarEdits(lines, edits)
+	return strings.Join(lines, "\n"), remaining
+}
+
+// collectScalars indexes the scalar values of a document by line
+func collectScalars(node *yaml.Node, byLine map[int][]*yaml.Node, visited map[*yaml.Node]bool) {
+	if node == nil || visited[node] {
+		return
+	}
+	visited[node] = true
+	if node.Kind == yaml.ScalarNode {
+		byLine[node.Line] = append(byLine[node.Line], node)
+		return
+	}
+	for _, child := range node.Content {
+		collectScalars(child, byLine, visited)
+	}
+}
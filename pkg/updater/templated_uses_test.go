@@ -0,0 +1,146 @@
+package updater
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+const templatedWorkflow = `on: push
+env:
+  CHECKOUT: actions/checkout@v3
+jobs:
+  matrix:
+    strategy:
+      matrix:
+        action: [actions/setup-go@v4, 'actions/setup-node@v3']
+    steps:
+      - uses: ${{ matrix.action }}
+      - uses: ${{ env.CHECKOUT }}
+  version:
+    env:
+      CACHE_VERSION: v3 # v3
+    steps:
+      - uses: actions/cache@${{ env.CACHE_VERSION }}
+  include:
+    strategy:
+      matrix:
+        include:
+          - tool: actions/upload-artifact
+            version: v3
+          - tool: actions/download-artifact
+            version: v3
+    steps:
+      - uses: ${{ matrix.tool }}@${{ matrix.version }}
+  shared:
+    strategy:
+      matrix:
+        tool: [github/codeql-action/init, github/codeql-action/analyze]
+        version: [v2]
+    steps:
+      - uses: ${{ matrix.tool }}@${{ matrix.version }}
+  unknown:
+    steps:
+      - uses: ${{ matrix.action }}
+`
+
+func TestResolveTemplatedUses(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "ci.yml")
+	if err := os.WriteFile(path, []byte(templatedWorkflow), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	actions, err := NewScanner(dir).ParseActionReferences(path)
+	if err != nil {
+		t.Fatalf("ParseActionReferences() error = %v", err)
+	}
+
+	var got []string
+	for _, a := range actions {
+		entry := a.FullName() + "@" + a.Version
+		if a.TemplateSource {
+			entry += fmt.Sprintf(" line %d prefix %s", a.Line, a.TemplatePrefix)
+		}
+		got = append(got, entry)
+	}
+	sort.Strings(got)
+	want := []string{
+		"actions/cache@v3 line 14 prefix ",
+		"actions/checkout@v3 line 3 prefix actions/checkout@",
+		"actions/download-artifact@v3 line 24 prefix ",
+		"actions/setup-go@v4 line 8 prefix actions/setup-go@",
+		"actions/setup-node@v3 line 8 prefix actions/setup-node@",
+		"actions/upload-artifact@v3 line 22 prefix ",
+		"matrix/action@dynamic", // Undefined matrix keeps its placeholder
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("ParseActionReferences() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestApplyTemplatedUpdates(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "ci.yml")
+	if err := os.WriteFile(path, []byte(templatedWorkflow), 0644); err != nil {
+		t.Fatal(err)
+	}
+	actions, err := NewScanner(dir).ParseActionReferences(path)
+	if err != nil {
+		t.Fatalf("ParseActionReferences() error = %v", err)
+	}
+
+	manager := NewUpdateManager(dir)
+	var updates []*Update
+	hashes := map[string]string{
+		"actions/checkout":          strings.Repeat("a", 40),
+		"actions/setup-go":          strings.Repeat("b", 40),
+		"actions/setup-node":        strings.Repeat("c", 40),
+		"actions/cache":             strings.Repeat("d", 40),
+		"actions/upload-artifact":   strings.Repeat("e", 40),
+		"actions/download-artifact": strings.Repeat("f", 40),
+	}
+	for _, action := range actions {
+		if !action.TemplateSource {
+			continue
+		}
+		hash := hashes[action.FullName()]
+		update, err := manager.CreateUpdate(context.Background(), path, action, "v9", hash)
+		if err != nil {
+			t.Fatalf("CreateUpdate() error = %v", err)
+		}
+		updates = append(updates, update)
+	}
+	if err := manager.ApplyUpdates(context.Background(), updates); err != nil {
+		t.Fatalf("ApplyUpdates() error = %v", err)
+	}
+
+	content, _ := os.ReadFile(path)
+	for _, want := range []string{
+		"  CHECKOUT: actions/checkout@" + strings.Repeat("a", 40) + "  # v9\n",
+		"        action: [actions/setup-go@" + strings.Repeat("b", 40) + ", 'actions/setup-node@" + strings.Repeat("c", 40) + "']  # v9\n",
+		"      CACHE_VERSION: " + strings.Repeat("d", 40) + " # v9\n",
+		"      - uses: actions/cache@${{ env.CACHE_VERSION }}\n",
+		"            version: " + strings.Repeat("e", 40) + "  # v9\n",
+		"            version: " + strings.Repeat("f", 40) + "  # v9\n",
+		"        version: [v2]\n",
+	} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("updated file missing %q:\n%s", want, content)
+		}
+	}
+	// The updated file resolves to the new hashes with their versions
+	actions, err = NewScanner(dir).ParseActionReferences(path)
+	if err != nil {
+		t.Fatalf("ParseActionReferences() after update error = %v", err)
+	}
+	for _, action := range actions {
+		if action.TemplateSource && (action.CommitHash != hashes[action.FullName()] || action.Version != "v9") {
+			t.Errorf("re-scanned %s = version %q hash %q", action.FullName(), action.Version, action.CommitHash)
+		}
+	}
+}
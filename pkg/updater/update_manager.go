@@ -1,6 +1,7 @@
 package updater
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"log"
@@ -8,15 +9,36 @@ import (
 	"sort"
 	"strings"
 	"sync"
-	"unicode"
 
 	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/versions"
 )
 
+// fileLocks maps absolute file paths to a *sync.Mutex, so concurrent
+// ApplyUpdates calls rewrite a file one at a time, also across managers
+var fileLocks sync.Map
+
+// maxRewriteAttempts bounds how often a file changed by another writer while
+// it was being rewritten is read and rewritten again
+const maxRewriteAttempts = 3
+
 // DefaultUpdateManager implements the UpdateManager interface
 type DefaultUpdateManager struct {
-	fileLocks sync.Map // Map of file paths to sync.Mutex
-	baseDir   string   // Base directory for path validation
+	baseDir              string          // Base directory for path validation
+	versionCommentFormat string          // Format for version comments; empty keeps the existing style
+	keepBackups          bool            // Keep the original of each rewritten file as <file>.bak
+	keepModTime          bool            // Keep the modification time of rewritten files
+	strategy             RewriteStrategy // How files are rewritten; nil uses YAMLRewriteStrategy
+	pinPolicy            PinPolicy       // How updated references are written
+}
+
+// UpdateManagerOptions configures a DefaultUpdateManager
+type UpdateManagerOptions struct {
+	VersionCommentFormat string          // See SetVersionCommentFormat
+	KeepBackups          bool            // See SetKeepBackups
+	KeepModTime          bool            // See SetKeepModTime
+	RewriteStrategy      RewriteStrategy // See SetRewriteStrategy
+	PinPolicy            PinPolicy       // See SetPinPolicy
 }
 
 // validatePath ensures the path is within the allowed directory and has proper permissions
@@ -47,6 +69,70 @@ func NewUpdateManager(baseDir string) *DefaultUpdateManager {
 	}
 }
 
+// NewUpdateManagerWithOptions creates a DefaultUpdateManager configured by opts
+func NewUpdateManagerWithOptions(baseDir string, opts UpdateManagerOptions) *DefaultUpdateManager {
+	m := NewUpdateManager(baseDir)
+	m.SetVersionCommentFormat(opts.VersionCommentFormat)
+	m.SetKeepBackups(opts.KeepBackups)
+	m.SetKeepModTime(opts.KeepModTime)
+	m.SetRewriteStrategy(opts.RewriteStrategy)
+	m.SetPinPolicy(opts.PinPolicy)
+	return m
+}
+
+// SetVersionCommentFormat sets the format used for version comments, e.g.
+// "# pin@{version}". When unset, the style of an existing version comment is
+// kept and "# {version}" is used otherwise.
+func (m *DefaultUpdateManager) SetVersionCommentFormat(format string) {
+	m.versionCommentFormat = format
+}
+
+// SetKeepBackups controls whether the original contents of each rewritten
+// file are kept next to it with a .bak suffix
+func (m *DefaultUpdateManager) SetKeepBackups(keep bool) {
+	m.keepBackups = keep
+}
+
+// SetKeepModTime controls whether rewritten files keep their modification
+// time. Their permissions, owner and group are always kept.
+func (m *DefaultUpdateManager) SetKeepModTime(keep bool) {
+	m.keepModTime = keep
+}
+
+// fileOptions returns the options files are rewritten with
+func (m *DefaultUpdateManager) fileOptions() common.FileOptions {
+	options := common.DefaultFileOptions()
+	options.PreserveMode = true
+	options.PreserveModTime = m.keepModTime
+	return options
+}
+
+// SetRewriteStrategy sets how workflow files are rewritten. A nil strategy
+// selects YAMLRewriteStrategy.
+func (m *DefaultUpdateManager) SetRewriteStrategy(strategy RewriteStrategy) {
+	m.strategy = strategy
+}
+
+// SetPinPolicy sets which actions are written as version tags instead of
+// commit hashes
+func (m *DefaultUpdateManager) SetPinPolicy(policy PinPolicy) {
+	m.pinPolicy = policy
+}
+
+// rewriteStrategy returns the configured strategy or the default
+func (m *DefaultUpdateManager) rewriteStrategy() RewriteStrategy {
+	return rewriteStrategyOr(m.strategy)
+}
+
+// versionComment returns the version comment for an updated action
+func (m *DefaultUpdateManager) versionComment(action ActionReference, version string) string {
+	format := m.versionCommentFormat
+	if format == "" {
+		format = VersionCommentStyle(action.VersionComment)
+	}
+	return FormatVersionComment(format, action, version)
+}
+
 // CreateUpdate creates an update for a given action and its latest version
 func (m *DefaultUpdateManager) CreateUpdate(ctx context.Context, file string, action ActionReference, latestVersion string, commitHash string) (*Update, error) {
 	if action.Version == latestVersion && action.CommitHash == commitHash {
@@ -64,7 +150,7 @@ func (m *DefaultUpdateManager) CreateUpdate(ctx context.Context, file string, ac
 		originalVersion = action.CommitHash
 	}
 
-	return &Update{
+	update := &Update{
 		Action:          action,
 		OldVersion:      action.Version,
 		NewVersion:      latestVersion,
@@ -73,11 +159,23 @@ func (m *DefaultUpdateManager) CreateUpdate(ctx context.Context, file string, ac
 		FilePath:        file,
 		LineNumber:      action.Line,
 		Comments:        comments,
-		VersionComment:  fmt.Sprintf("# %s", latestVersion),
+		VersionComment:  m.versionComment(action, latestVersion),
 		OriginalVersion: originalVersion,
 		// Handle multi-part action names correctly (e.g., github/codeql-action/init)
-		Description: fmt.Sprintf("Update %s from %s to %s", action.Owner+"/"+action.Name, originalVersion, latestVersion),
-	}, nil
+		Description: fmt.Sprintf("Update %s from %s to %s", action.FullName(), originalVersion, latestVersion),
+		PinStyle:    m.pinPolicy.Style(action),
+	}
+	if update.pinsTag() && action.CommitHash == "" && action.Version == update.NewRef() {
+		// A moving tag such as v4 already follows the new version
+		return nil, nil
+	}
+	if action.CommitHash != "" && action.CommitHash == commitHash &&
+		versions.Compare(action.Version, latestVersion) == 0 {
+		// Already pinned to the new commit, e.g. by an interrupted run,
+		// with a comment such as "# 4.1.0" naming the version
+		return nil, nil
+	}
+	return update, nil
 }
 
 // ApplyUpdates applies the given updates to workflow files
@@ -86,151 +184,236 @@ func (m *DefaultUpdateManager) ApplyUpdates(ctx context.Context, updates []*Upda
 	if ctx == nil {
 		log.Println(common.ErrContextIsNil)
 	}
+	updates, err := mergeUpdates(updates)
+	if err != nil {
+		return fmt.Errorf(common.ErrApplyingUpdates, err)
+	}
 	// Group updates by file
 	fileUpdates := make(map[string][]*Update)
 	for _, update := range updates {
 		fileUpdates[update.FilePath] = append(fileUpdates[update.FilePath], update)
 	}
 
+	// Rewrite files in a fixed order
+	files := make([]string, 0, len(fileUpdates))
+	for fileN := range fileUpdates {
+		files = append(files, fileN)
+	}
+	sort.Strings(files)
+
+	// Original contents of rewritten files, restored if a later file fails
+	originals := make(map[string][]byte)
+
 	// Process each file with proper locking
-	for fileN, updates := range fileUpdates {
-		// Get or create mutex for this file
-		lockInterface, _ := m.fileLocks.LoadOrStore(fileN, &sync.Mutex{})
-		lock := lockInterface.(*sync.Mutex)
+	for _, fileN := range files {
+		// Stop before the next file once the run is cancelled
+		if ctx != nil && ctx.Err() != nil {
+			m.restoreFiles(originals)
+			return fmt.Errorf(common.ErrApplyingUpdates, ctx.Err())
+		}
 
 		// Lock the file for exclusive access
-		lock.Lock()
-		err := m.applyFileUpdates(fileN, updates)
+		lock := lockFile(fileN)
+		original, err := m.applyFileUpdates(fileN, fileUpdates[fileN])
 		lock.Unlock()
 
 		if err != nil {
+			m.restoreFiles(originals)
 			return fmt.Errorf(common.ErrApplyingUpdates, err)
 		}
+		if original != nil {
+			originals[fileN] = original
+		}
 	}
 
 	return nil
 }
-func (m *DefaultUpdateManager) applyFileUpdates(fileN string, updates []*Update) error {
+
+// restoreFiles writes back the original contents of files rewritten before
+// a failure so a run never leaves some files updated and others not
+func (m *DefaultUpdateManager) restoreFiles(originals map[string][]byte) {
+	for fileN, content := range originals {
+		lock := lockFile(fileN)
+		err := common.WriteFileWithOptions(fileN, content, m.fileOptions())
+		lock.Unlock()
+		if err != nil {
+			log.Printf("Warning: %v", fmt.Errorf(common.ErrRestoringFile, fileN, err))
+		}
+	}
+}
+
+// applyFileUpdates rewrites a single file and returns its original contents,
+// or nil when every update was already applied
+func (m *DefaultUpdateManager) applyFileUpdates(fileN string, updates []*Update) ([]byte, error) {
 	// Validate file path
 	if err := m.validatePath(fileN); err != nil {
-		return fmt.Errorf(common.ErrInvalidUpdatePath, err)
+		return nil, fmt.Errorf(common.ErrInvalidUpdatePath, err)
 	}
 
 	// Read file content using common utility
 	content, err := common.ReadFile(fileN)
 	if err != nil {
-		return fmt.Errorf(common.ErrReadingUpdateFile, err)
+		return nil, fmt.Errorf(common.ErrReadingUpdateFile, err)
 	}
 
-	// Convert content to string and split into lines
-	lines := strings.Split(string(content), "\n")
-
-	// Sort updates by line number in descending order
-	sortUpdatesByLine(updates)
-
-	// Track line number adjustments
-	lineAdjustments := make(map[int]int)
-
-	// Apply each update
-	for _, update := range updates {
-		// Adjust the line number based on previous updates
-		adjustedLineNumber := update.LineNumber
-		for origLine, adjustment := range lineAdjustments {
-			if update.LineNumber > origLine {
-				adjustedLineNumber += adjustment
-			}
+	for attempt := 1; ; attempt++ {
+		rewritten, changed, err := m.rewrite(fileN, content, updates)
+		if err != nil {
+			return nil, err
 		}
-
-		if adjustedLineNumber <= 0 || adjustedLineNumber > len(lines) {
-			return fmt.Errorf(common.ErrInvalidUpdatePath,
-				fmt.Errorf("invalid line number %d (adjusted from %d)", adjustedLineNumber, update.LineNumber))
+		if !changed {
+			// Leave files whose updates were all applied before untouched
+			return nil, nil
 		}
 
-		// Get the line and preserve indentation and structure
-		line := lines[adjustedLineNumber-1]
-
-		// Extract indentation (whitespace at the beginning of the line)
-		indentation := ""
-		for i, c := range line {
-			if !unicode.IsSpace(c) {
-				indentation = line[:i]
-				break
+		// Writers outside this process do not take the lock; rewrite their
+		// version instead of overwriting it
+		current, err := common.ReadFile(fileN)
+		if err != nil {
+			return nil, fmt.Errorf(common.ErrReadingUpdateFile, err)
+		}
+		if !bytes.Equal(current, content) {
+			if attempt == maxRewriteAttempts {
+				return nil, fmt.Errorf(common.ErrFileChangedDuringUpdate, fileN, attempt)
 			}
+			content = current
+			continue
 		}
 
-		// Check if the line starts with "- name:" which indicates it's a step definition
-		isStepDefinition := strings.Contains(line, "- name:")
-
-		// Apply the update with improved formatting
-		parts := strings.SplitN(line, "#", 2)
-		mainPart := strings.TrimSpace(parts[0])
-
-		// Check if the line contains "uses:" to avoid duplication
-		usesIdx := strings.Index(mainPart, "uses:")
+		// Write updated content back to file using common utility
+		options := m.fileOptions()
+		options.Backup = m.keepBackups
+		if err := common.WriteFileWithOptions(fileN, []byte(rewritten), options); err != nil {
+			return nil, fmt.Errorf(common.ErrWritingUpdateFile, err)
+		}
+		return content, nil
+	}
+}
 
-		// Format the action reference with the new hash
-		actionFullName := update.Action.Owner + "/" + update.Action.Name
-		newActionRef := fmt.Sprintf("%s@%s", actionFullName, update.NewHash)
+// rewrite returns content with the updates not yet applied to it, and
+// false when there are none
+func (m *DefaultUpdateManager) rewrite(fileN string, content []byte, updates []*Update) (string, bool, error) {
+	rewritten, changed, err := rewriteFileContent(string(content), updates, m.rewriteStrategy())
+	if err != nil {
+		return "", false, fmt.Errorf(common.ErrRewritingFile, fileN, err)
+	}
+	return rewritten, changed, nil
+}
 
-		var newLine string
+// rewriteFileContent applies the updates not yet applied to the content of
+// a workflow file with strategy, and returns false when there are none. It
+// only depends on its arguments, so the formatting guarantees of rewriting
+// can be tested on generated content: nothing but the updated references and
+// their version comments changes.
+func rewriteFileContent(content string, updates []*Update, strategy RewriteStrategy) (string, bool, error) {
+	// Rewrite with LF line endings and keep the file's own
+	lf, crlf := toLF(content)
+	pending := PendingUpdates(lf, updates)
+	if len(pending) == 0 {
+		return "", false, nil
+	}
+	rewritten, err := strategy.Rewrite(lf, pending)
+	if err != nil {
+		return "", false, err
+	}
+	return fromLF(rewritten, crlf), true, nil
+}
 
-		if usesIdx >= 0 {
-			// Case 1: Line contains "uses:" - preserve the format
-			beforeUses := mainPart[:usesIdx+5] // +5 to include "uses:"
+// PreviewUpdates implements UpdatePreviewer: it returns the files the
+// updates would change, with their contents before and after, without
+// writing them
+func (m *DefaultUpdateManager) PreviewUpdates(ctx context.Context, updates []*Update) ([]FileChange, error) {
+	updates, err := mergeUpdates(updates)
+	if err != nil {
+		return nil, fmt.Errorf(common.ErrApplyingUpdates, err)
+	}
+	fileUpdates := make(map[string][]*Update)
+	for _, update := range updates {
+		fileUpdates[update.FilePath] = append(fileUpdates[update.FilePath], update)
+	}
+	files := make([]string, 0, len(fileUpdates))
+	for fileN := range fileUpdates {
+		files = append(files, fileN)
+	}
+	sort.Strings(files)
 
-			// Add version comment
-			if update.VersionComment != "" {
-				newLine = fmt.Sprintf("%s%s %s  %s", indentation, beforeUses, newActionRef, update.VersionComment)
-			} else {
-				newLine = fmt.Sprintf("%s%s %s  # %s", indentation, beforeUses, newActionRef, update.NewVersion)
-			}
-		} else if isStepDefinition {
-			// Case 2: This is a step definition line, the "uses:" line will be on the next line
-			// Just keep it as is
-			newLine = line
-		} else {
-			// Case 3: This is a line that should have "uses:" but doesn't (possibly already processed incorrectly)
-			// Add proper indentation and "uses:" prefix
-			// Check if this is a step line (should start with "- " or "  - ")
-			if strings.Contains(line, "- name:") {
-				// This is a step definition line, keep it as is
-				newLine = line
-			} else if strings.HasPrefix(strings.TrimSpace(line), "-") {
-				// This is a step line but not a name line, it should have proper indentation
-				if update.VersionComment != "" {
-					newLine = fmt.Sprintf("%s      uses: %s  %s", indentation, newActionRef, update.VersionComment)
-				} else {
-					newLine = fmt.Sprintf("%s      uses: %s  # %s", indentation, newActionRef, update.NewVersion)
-				}
-			} else {
-				// This is some other line, add standard indentation
-				if update.VersionComment != "" {
-					newLine = fmt.Sprintf("%s  uses: %s  %s", indentation, newActionRef, update.VersionComment)
-				} else {
-					newLine = fmt.Sprintf("%s  uses: %s  # %s", indentation, newActionRef, update.NewVersion)
-				}
-			}
+	var changes []FileChange
+	for _, fileN := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf(common.ErrApplyingUpdates, err)
 		}
-
-		// Update the lines array
-		newLines := make([]string, 0, len(lines))
-		newLines = append(newLines, lines[:adjustedLineNumber-1]...)
-		newLines = append(newLines, newLine)
-		if adjustedLineNumber < len(lines) {
-			newLines = append(newLines, lines[adjustedLineNumber:]...)
+		if err := m.validatePath(fileN); err != nil {
+			return nil, fmt.Errorf(common.ErrInvalidUpdatePath, err)
+		}
+		content, err := common.ReadFile(fileN)
+		if err != nil {
+			return nil, fmt.Errorf(common.ErrReadingUpdateFile, err)
+		}
+		rewritten, changed, err := m.rewrite(fileN, content, fileUpdates[fileN])
+		if err != nil {
+			return nil, err
 		}
-		lines = newLines
+		if changed {
+			changes = append(changes, FileChange{Path: fileN, Old: string(content), New: rewritten})
+		}
+	}
+	return changes, nil
+}
 
-		lineAdjustments[update.LineNumber] = len(lines) - len(newLines)
+// PendingUpdates returns the updates not yet applied to content: those whose
+// line does not already reference the action at the new ref with a version
+// comment naming the new version. Re-running an interrupted run thus
+// neither rewrites files again nor opens empty pull requests.
+func PendingUpdates(content string, updates []*Update) []*Update {
+	lines := strings.Split(content, "\n")
+	var pending []*Update
+	for _, update := range updates {
+		if !updateApplied(lines, update) {
+			pending = append(pending, update)
+		}
 	}
+	return pending
+}
 
-	// Write updated content back to file using common utility
-	fileContent := strings.Join(lines, "\n")
-	if err := common.WriteFileString(fileN, fileContent); err != nil {
-		return fmt.Errorf(common.ErrWritingUpdateFile, err)
+// updateApplied reports whether the line of update already uses the action
+// at the new ref and, for commit pins, comments the new version
+func updateApplied(lines []string, update *Update) bool {
+	ref := update.NewRef()
+	if ref == "" || update.LineNumber < 1 || update.LineNumber > len(lines) {
+		return false
+	}
+	line := strings.TrimSuffix(lines[update.LineNumber-1], "\r")
+	target := update.NewFullName() + "@" + ref
+	for offset := 0; ; {
+		i := strings.Index(line[offset:], target)
+		if i < 0 {
+			return false
+		}
+		end := offset + i + len(target)
+		// v4 must not match v4.1
+		if end == len(line) || strings.ContainsRune(" \t\"'#,]}", rune(line[end])) {
+			break
+		}
+		offset = end
 	}
+	if update.pinsTag() {
+		return true
+	}
+	version, ok := ParseVersionComment(trailingComment(line))
+	return ok && version == update.NewVersion
+}
 
-	return nil
+// lockFile locks the mutex of a file, keyed by its absolute path so
+// relative and absolute paths of the same file share it
+func lockFile(fileN string) *sync.Mutex {
+	key, err := filepath.Abs(fileN)
+	if err != nil {
+		key = filepath.Clean(fileN)
+	}
+	lockInterface, _ := fileLocks.LoadOrStore(key, &sync.Mutex{})
+	lock := lockInterface.(*sync.Mutex)
+	lock.Lock()
+	return lock
 }
 
 // PreserveComments preserves existing comments when updating an action
@@ -242,20 +425,21 @@ func (m *DefaultUpdateManager) PreserveComments(action ActionReference) []string
 	// Keep all comments except the version comment we'll update
 	var preserved []string
 	for _, comment := range action.Comments {
-		if !strings.Contains(comment, "Original version:") {
+		if _, ok := ParseVersionComment(comment); !ok {
 			preserved = append(preserved, comment)
 		}
 	}
 	return preserved
 }
 
-// sortUpdatesByLine sorts updates by line number in descending order
+// sortUpdatesByLine sorts updates by line number in descending order,
+// keeping updates of the same line in their order
 func sortUpdatesByLine(updates []*Update) {
 	if len(updates) <= 1 {
 		return
 	}
 
-	sort.Slice(updates, func(i, j int) bool {
+	sort.SliceStable(updates, func(i, j int) bool {
 		return updates[i].LineNumber > updates[j].LineNumber
 	})
 }
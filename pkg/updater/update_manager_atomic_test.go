@@ -0,0 +1,290 @@
+package updater
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestApplyUpdatesKeepBackups(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "ci.yml")
+	original := "steps:\n  - uses: actions/checkout@v3\n"
+	if err := os.WriteFile(path, []byte(original), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	manager := NewUpdateManager(dir)
+	manager.SetKeepBackups(true)
+	update := &Update{
+		Action:     ActionReference{Owner: "actions", Name: "checkout", Version: "v3"},
+		OldVersion: "v3",
+		NewVersion: "v4",
+		NewHash:    "1111111111111111111111111111111111111111",
+		FilePath:   path,
+		LineNumber: 2,
+	}
+	if err := manager.ApplyUpdates(context.Background(), []*Update{update}); err != nil {
+		t.Fatalf("ApplyUpdates() error = %v", err)
+	}
+
+	backup, err := os.ReadFile(path + ".bak")
+	if err != nil {
+		t.Fatalf("backup not written: %v", err)
+	}
+	if string(backup) != original {
+		t.Errorf("backup = %q, want %q", backup, original)
+	}
+	updated, _ := os.ReadFile(path)
+	if !strings.Contains(string(updated), update.NewHash) {
+		t.Errorf("file not updated: %q", updated)
+	}
+
+	// No temporary files may be left behind
+	entries, _ := os.ReadDir(dir)
+	for _, entry := range entries {
+		if strings.Contains(entry.Name(), ".tmp") {
+			t.Errorf("temporary file left behind: %s", entry.Name())
+		}
+	}
+}
+
+func TestApplyUpdatesKeepsFileAttributes(t *testing.T) {
+	tests := []struct {
+		name        string
+		mode        os.FileMode
+		keepModTime bool
+	}{
+		{name: "group readable", mode: 0644},
+		{name: "read-only", mode: 0444},
+		{name: "modification time", mode: 0600, keepModTime: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "ci.yml")
+			if err := os.WriteFile(path, []byte("steps:\n  - uses: actions/checkout@v3\n"), 0600); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Chmod(path, tt.mode); err != nil {
+				t.Fatal(err)
+			}
+			modTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+			if err := os.Chtimes(path, modTime, modTime); err != nil {
+				t.Fatal(err)
+			}
+
+			manager := NewUpdateManagerWithOptions(dir, UpdateManagerOptions{KeepModTime: tt.keepModTime})
+			update := &Update{
+				Action:     ActionReference{Owner: "actions", Name: "checkout", Version: "v3"},
+				OldVersion: "v3",
+				NewVersion: "v4",
+				NewHash:    "1111111111111111111111111111111111111111",
+				FilePath:   path,
+				LineNumber: 2,
+			}
+			if err := manager.ApplyUpdates(context.Background(), []*Update{update}); err != nil {
+				t.Fatalf("ApplyUpdates() error = %v", err)
+			}
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Mode().Perm() != tt.mode {
+				t.Errorf("mode = %v, want %v", info.Mode().Perm(), tt.mode)
+			}
+			if info.ModTime().Equal(modTime) != tt.keepModTime {
+				t.Errorf("modification time = %v, kept = %v", info.ModTime(), tt.keepModTime)
+			}
+		})
+	}
+}
+
+func TestApplyUpdatesRestoresOnFailure(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "a.yml")
+	bad := filepath.Join(dir, "b.yml")
+	content := "steps:\n  - uses: actions/checkout@v3\n"
+	for _, path := range []string{good, bad} {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	newUpdate := func(path string, line int) *Update {
+		return &Update{
+			Action:     ActionReference{Owner: "actions", Name: "checkout", Version: "v3"},
+			OldVersion: "v3",
+			NewVersion: "v4",
+			NewHash:    "1111111111111111111111111111111111111111",
+			FilePath:   path,
+			LineNumber: line,
+		}
+	}
+
+	// Files are processed in map order, so repeat to also cover the good
+	// file being rewritten before the failing one
+	manager := NewUpdateManager(dir)
+	for i := 0; i < 50; i++ {
+		err := manager.ApplyUpdates(context.Background(), []*Update{newUpdate(good, 2), newUpdate(bad, 100)})
+		if err == nil {
+			t.Fatal("ApplyUpdates() expected error for invalid line")
+		}
+		got, _ := os.ReadFile(good)
+		if string(got) != content {
+			t.Fatalf("file not restored after failure: %q", got)
+		}
+	}
+}
+
+func TestApplyUpdatesCancelled(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "ci.yml")
+	content := "steps:\n  - uses: actions/checkout@v3\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	update := &Update{
+		Action:     ActionReference{Owner: "actions", Name: "checkout", Version: "v3"},
+		NewVersion: "v4",
+		NewHash:    "1111111111111111111111111111111111111111",
+		FilePath:   path,
+		LineNumber: 2,
+	}
+	err := NewUpdateManager(dir).ApplyUpdates(ctx, []*Update{update})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("ApplyUpdates() error = %v, want context.Canceled", err)
+	}
+	if got, _ := os.ReadFile(path); string(got) != content {
+		t.Errorf("file changed after cancellation: %q", got)
+	}
+}
+
+func TestApplyUpdatesConcurrentSameFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "ci.yml")
+	const actions = 20
+	var content strings.Builder
+	content.WriteString("steps:\n")
+	for i := 0; i < actions; i++ {
+		fmt.Fprintf(&content, "  - uses: acme/action-%d@v1\n", i)
+	}
+	if err := os.WriteFile(path, []byte(content.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Separate managers, as concurrent repositories in serve mode use, and
+	// relative paths share the lock of the file
+	relative, err := filepath.Rel(mustGetwd(t), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < actions; i++ {
+		filePath := path
+		if i%2 == 1 {
+			filePath = relative
+		}
+		update := &Update{
+			Action:     ActionReference{Owner: "acme", Name: fmt.Sprintf("action-%d", i), Version: "v1"},
+			OldVersion: "v1",
+			NewVersion: "v2",
+			NewHash:    fmt.Sprintf("%040d", i),
+			FilePath:   filePath,
+			LineNumber: i + 2,
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := NewUpdateManager(dir).ApplyUpdates(context.Background(), []*Update{update}); err != nil {
+				t.Errorf("ApplyUpdates() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	got, _ := os.ReadFile(path)
+	for i := 0; i < actions; i++ {
+		if want := fmt.Sprintf("acme/action-%d@%040d", i, i); !strings.Contains(string(got), want) {
+			t.Errorf("update %s lost:\n%s", want, got)
+		}
+	}
+}
+
+func TestApplyUpdatesExternalChange(t *testing.T) {
+	const external = "# edited elsewhere\n"
+	tests := []struct {
+		name    string
+		changes int // Rewrites during which another writer changes the file
+		wantErr bool
+	}{
+		{name: "merged", changes: 1},
+		{name: "keeps changing", changes: maxRewriteAttempts, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "ci.yml")
+			content := "steps:\n  - uses: actions/checkout@v3\n"
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			changes := 0
+			manager := NewUpdateManager(dir)
+			manager.SetRewriteStrategy(RewriteStrategyFunc(func(content string, updates []*Update) (string, error) {
+				if changes < tt.changes {
+					changes++
+					current, _ := os.ReadFile(path)
+					if err := os.WriteFile(path, append(current, external...), 0644); err != nil {
+						t.Fatal(err)
+					}
+				}
+				return YAMLRewriteStrategy.Rewrite(content, updates)
+			}))
+			update := &Update{
+				Action:     ActionReference{Owner: "actions", Name: "checkout", Version: "v3"},
+				OldVersion: "v3",
+				NewVersion: "v4",
+				NewHash:    "1111111111111111111111111111111111111111",
+				FilePath:   path,
+				LineNumber: 2,
+			}
+			err := manager.ApplyUpdates(context.Background(), []*Update{update})
+			got, _ := os.ReadFile(path)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "kept changing") {
+					t.Errorf("ApplyUpdates() error = %v, want kept changing", err)
+				}
+				if strings.Contains(string(got), "@1111") {
+					t.Errorf("file rewritten despite the conflict:\n%s", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ApplyUpdates() error = %v", err)
+			}
+			if !strings.Contains(string(got), "actions/checkout@1111111111111111111111111111111111111111") || !strings.HasSuffix(string(got), external) {
+				t.Errorf("update and external change not both kept:\n%s", got)
+			}
+		})
+	}
+}
+
+func mustGetwd(t *testing.T) string {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return wd
+}
@@ -2,6 +2,7 @@ package updater
 
 import (
 	"context"
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
@@ -725,9 +726,11 @@ jobs:
 		},
 	}
 
+	// Updates writing the same reference differently conflict
 	err = manager.ApplyUpdates(ctx, sameLineUpdates)
-	if err != nil {
-		t.Errorf("Expected no error for same line updates, got %v", err)
+	var conflict *UpdateConflictError
+	if !errors.As(err, &conflict) || conflict.Line != 7 || len(conflict.Refs) != 2 {
+		t.Errorf("Expected a conflict for same line updates, got %v", err)
 	}
 
 	// Read the updated file
@@ -735,15 +738,23 @@ jobs:
 	if err != nil {
 		t.Fatalf(common.ErrFailedToReadSameLineFile, err)
 	}
+	if string(updatedContent) != sameLineContent {
+		t.Errorf("Expected conflicting updates to leave the file unchanged, got:\n%s", updatedContent)
+	}
 
-	// Check if the last update was applied
-	content = string(updatedContent)
-	expected = "actions/checkout@b4ffde65f46336ab88eb53be808477a3936bae11"
-	if !strings.Contains(content, expected) {
-		t.Errorf(common.ErrExpectedContentNotFound, expected, content)
+	// Repeated updates are applied once
+	repeated := *sameLineUpdates[0]
+	sameLineUpdates[1] = &repeated
+	if err := manager.ApplyUpdates(ctx, sameLineUpdates); err != nil {
+		t.Errorf("Expected no error for repeated updates, got %v", err)
 	}
-	expected = "# v4"
-	if !strings.Contains(content, expected) {
+	updatedContent, err = os.ReadFile(sameLineFile)
+	if err != nil {
+		t.Fatalf(common.ErrFailedToReadSameLineFile, err)
+	}
+	content = string(updatedContent)
+	expected = "actions/checkout@a81bbbf8298c0fa03ea29cdc473d45769f953675"
+	if !strings.Contains(content, expected) || strings.Count(content, "# v3") != 1 {
 		t.Errorf(common.ErrExpectedContentNotFound, expected, content)
 	}
 }
@@ -377,3 +377,67 @@ jobs:
 		t.Errorf("Expected error for invalid line number, got nil")
 	}
 }
+
+func TestPendingUpdates(t *testing.T) {
+	const hash = "1111111111111111111111111111111111111111"
+	content := "steps:\n" +
+		"  - uses: actions/checkout@" + hash + " # v4.1.0\n" +
+		"  - uses: actions/cache@" + hash + "\n" +
+		"  - uses: actions/setup-go@v5\n" +
+		"  - uses: actions/setup-node@v4.1\n" +
+		"  - uses: actions/setup-java@" + hash + " # v3\n"
+	update := func(name string, line int, version, style string) *Update {
+		return &Update{Action: ActionReference{Owner: "actions", Name: name}, NewVersion: version, NewHash: hash, LineNumber: line, PinStyle: style}
+	}
+	tests := []struct {
+		name    string
+		update  *Update
+		pending bool
+	}{
+		{name: "pinned and commented", update: update("checkout", 2, "v4.1.0", "")},
+		{name: "pinned without comment", update: update("cache", 3, "v4.1.0", ""), pending: true},
+		{name: "moving tag", update: update("setup-go", 4, "v5.0.1", PinMajorTag)},
+		{name: "longer tag", update: update("setup-node", 5, "v4.1.0", PinMajorTag), pending: true},
+		{name: "other version comment", update: update("setup-java", 6, "v4.0.0", ""), pending: true},
+		{name: "other line", update: update("checkout", 3, "v4.1.0", ""), pending: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := len(PendingUpdates(content, []*Update{tt.update})) == 1; got != tt.pending {
+				t.Errorf("pending = %v, want %v", got, tt.pending)
+			}
+		})
+	}
+}
+
+func TestApplyUpdatesTwice(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "ci.yml")
+	if err := os.WriteFile(path, []byte("steps:\n  - uses: actions/checkout@v3\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	manager := NewUpdateManager(dir)
+	action := ActionReference{Owner: "actions", Name: "checkout", Version: "v3", Line: 2}
+	update, err := manager.CreateUpdate(context.Background(), path, action, "v4.1.0", "1111111111111111111111111111111111111111")
+	if err != nil || update == nil {
+		t.Fatalf("CreateUpdate() = %v, %v", update, err)
+	}
+	if err := manager.ApplyUpdates(context.Background(), []*Update{update}); err != nil {
+		t.Fatal(err)
+	}
+	applied, _ := os.ReadFile(path)
+
+	// Re-running an interrupted run leaves the file alone
+	if err := manager.ApplyUpdates(context.Background(), []*Update{update}); err != nil {
+		t.Errorf("second ApplyUpdates() error = %v", err)
+	}
+	if got, _ := os.ReadFile(path); string(got) != string(applied) {
+		t.Errorf("second ApplyUpdates() changed the file to %q", got)
+	}
+
+	// Nor does it find the update again
+	rescanned := ActionReference{Owner: "actions", Name: "checkout", Version: "4.1.0", CommitHash: update.NewHash, Line: 2}
+	if again, err := manager.CreateUpdate(context.Background(), path, rescanned, "v4.1.0", update.NewHash); err != nil || again != nil {
+		t.Errorf("CreateUpdate() after applying = %v, %v, want nothing", again, err)
+	}
+}
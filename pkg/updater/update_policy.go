@@ -0,0 +1,202 @@
+package updater
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/versions"
+)
+
+// Version deltas, from smallest to largest
+const (
+	DeltaPatch = "patch"
+	DeltaMinor = "minor"
+	DeltaMajor = "major"
+)
+
+// deltaRank orders the version deltas
+var deltaRank = map[string]int{DeltaPatch: 1, DeltaMinor: 2, DeltaMajor: 3}
+
+// UpdatePolicy decides which available updates are worth proposing, to
+// reduce churn from actions that release often
+type UpdatePolicy struct {
+	// MinDelta is the smallest version change proposed; empty allows all
+	MinDelta string
+	// SkipPatch lists actions (lowercase owner or owner/repo) whose
+	// patch-only bumps are never proposed
+	SkipPatch map[string]bool
+	// AllowPrerelease proposes updates to prereleases such as v2.0.0-rc.1;
+	// without it they are only proposed from another prerelease
+	AllowPrerelease bool
+	// SkipMajor never proposes updates to another major version
+	SkipMajor bool
+}
+
+// ParseUpdatePolicy builds a policy from a minimum delta (patch, minor or
+// major) and a comma separated list of owner[/repo] scopes whose patch-only
+// bumps are skipped
+func ParseUpdatePolicy(minDelta, skipPatch string) (UpdatePolicy, error) {
+	policy := UpdatePolicy{MinDelta: strings.ToLower(strings.TrimSpace(minDelta))}
+	if policy.MinDelta != "" && deltaRank[policy.MinDelta] == 0 {
+		return UpdatePolicy{}, fmt.Errorf(common.ErrInvalidVersionDelta, minDelta)
+	}
+	for _, scope := range strings.Split(skipPatch, ",") {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		if scope == "" {
+			continue
+		}
+		if strings.Count(scope, "/") > 1 {
+			return UpdatePolicy{}, fmt.Errorf(common.ErrInvalidActionScope, scope)
+		}
+		if policy.SkipPatch == nil {
+			policy.SkipPatch = make(map[string]bool)
+		}
+		policy.SkipPatch[scope] = true
+	}
+	return policy, nil
+}
+
+// Allows reports whether an update of action to newVersion should be
+// proposed. Updates whose delta cannot be determined, such as from a branch
+// or a bare commit SHA, are always allowed.
+func (p UpdatePolicy) Allows(action ActionReference, newVersion string) bool {
+	if !p.AllowsChannel(action, newVersion) {
+		return false
+	}
+	if action.RefType() == RefTypeSHA && (action.CommitHash == "" || action.Version == action.CommitHash) {
+		return true
+	}
+	delta := VersionDelta(action.Version, newVersion)
+	if delta == "" {
+		return true
+	}
+	if delta == DeltaPatch && p.skipsPatch(action) {
+		return false
+	}
+	if delta == DeltaMajor && p.SkipMajor {
+		return false
+	}
+	return p.MinDelta == "" || deltaRank[delta] >= deltaRank[p.MinDelta]
+}
+
+// AllowsChannel reports whether newVersion is on a release channel action
+// may be updated to: stable versions always are, prereleases only with
+// AllowPrerelease or when action already uses a prerelease
+func (p UpdatePolicy) AllowsChannel(action ActionReference, newVersion string) bool {
+	return p.AllowPrerelease || !versions.IsPrerelease(newVersion) || versions.IsPrerelease(action.Version)
+}
+
+// skipsPatch reports whether patch-only bumps of action are skipped
+func (p UpdatePolicy) skipsPatch(action ActionReference) bool {
+	owner := strings.ToLower(action.Owner)
+	return p.SkipPatch[owner] || p.SkipPatch[owner+"/"+strings.ToLower(action.Repo())]
+}
+
+// VersionDelta returns the most significant version component that differs
+// between from and to (major, minor or patch). Missing components count as
+// zero. It returns an empty string when either is not a version or to is
+// not newer than from, so a downgrade never counts as a major update.
+func VersionDelta(from, to string) string {
+	fromVersion, err := versions.Parse(from)
+	if err != nil {
+		return ""
+	}
+	toVersion, err := versions.Parse(to)
+	if err != nil {
+		return ""
+	}
+	switch {
+	case toVersion.Compare(fromVersion) <= 0:
+		return ""
+	case fromVersion.Major() != toVersion.Major():
+		return DeltaMajor
+	case fromVersion.Minor() != toVersion.Minor():
+		return DeltaMinor
+	case fromVersion.Patch() != toVersion.Patch():
+		return DeltaPatch
+	}
+	return ""
+}
+
+// IsMajor reports whether the update moves to a newer major version, such
+// as v3 to v5, which usually needs a review of breaking changes
+func (u *Update) IsMajor() bool {
+	return VersionDelta(u.OldVersion, u.NewVersion) == DeltaMajor
+}
+
+// splitMajor separates major version updates from the others, leaving out
+// empty groups
+func splitMajor(updates []*Update) [][]*Update {
+	var others, majors []*Update
+	for _, update := range updates {
+		if update.IsMajor() {
+			majors = append(majors, update)
+		} else {
+			others = append(others, update)
+		}
+	}
+	var groups [][]*Update
+	for _, group := range [][]*Update{others, majors} {
+		if len(group) > 0 {
+			groups = append(groups, group)
+		}
+	}
+	return groups
+}
+
+// splitBatches splits updates into pull requests of at most size updates,
+// ordered by file, line and action so every run splits them alike. The
+// updates of a file share a pull request unless they are more than size,
+// and those of a line always do. size <= 0 keeps a single group.
+func splitBatches(updates []*Update, size int) [][]*Update {
+	if size <= 0 || len(updates) <= size {
+		return [][]*Update{updates}
+	}
+	sorted := slices.Clone(updates)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if a.FilePath != b.FilePath {
+			return a.FilePath < b.FilePath
+		}
+		if a.LineNumber != b.LineNumber {
+			return a.LineNumber < b.LineNumber
+		}
+		return a.Action.FullName() < b.Action.FullName()
+	})
+
+	var batches [][]*Update
+	var batch []*Update
+	flush := func() {
+		if len(batch) > 0 {
+			batches = append(batches, batch)
+			batch = nil
+		}
+	}
+	for start := 0; start < len(sorted); {
+		end := start
+		for end < len(sorted) && sorted[end].FilePath == sorted[start].FilePath {
+			end++
+		}
+		// A file that does not fit starts a new pull request
+		if len(batch)+end-start > size {
+			flush()
+		}
+		for line := start; line < end; {
+			next := line
+			for next < end && sorted[next].LineNumber == sorted[line].LineNumber {
+				next++
+			}
+			if len(batch) > 0 && len(batch)+next-line > size {
+				flush()
+			}
+			batch = append(batch, sorted[line:next]...)
+			line = next
+		}
+		start = end
+	}
+	flush()
+	return batches
+}
@@ -0,0 +1,167 @@
+package updater
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestVersionDelta(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     string
+	}{
+		{"v3", "v4", DeltaMajor},
+		{"v4.1.0", "v4.2.0", DeltaMinor},
+		{"v4.1.0", "v4.1.3", DeltaPatch},
+		{"v4", "v4.0.1", DeltaPatch},
+		{"v4", "v4.2.0", DeltaMinor},
+		{"1.2.3", "v1.2.3", ""},
+		{"main", "v4", ""},
+		{"v4", "", ""},
+		// Downgrades have no delta
+		{"v5", "v4.2.2", ""},
+		{"v4.2.0", "v4.1.0", ""},
+		{"v5.0.0-rc.1", "v4.2.2", ""},
+	}
+	for _, tt := range tests {
+		if got := VersionDelta(tt.from, tt.to); got != tt.want {
+			t.Errorf("VersionDelta(%q, %q) = %q, want %q", tt.from, tt.to, got, tt.want)
+		}
+	}
+
+	downgrade := &Update{OldVersion: "v5.0.0", NewVersion: "v4.2.2"}
+	patch := &Update{OldVersion: "v4.1.0", NewVersion: "v4.1.1"}
+	if downgrade.IsMajor() || len(splitMajor([]*Update{downgrade, patch})) != 1 || len(prLabels([]*Update{downgrade})) != 2 {
+		t.Errorf("downgrade %s to %s counted as a major update", downgrade.OldVersion, downgrade.NewVersion)
+	}
+}
+
+func TestParseUpdatePolicy(t *testing.T) {
+	policy, err := ParseUpdatePolicy("Minor", " Actions , octo/tool ")
+	if err != nil {
+		t.Fatalf("ParseUpdatePolicy() error = %v", err)
+	}
+	if policy.MinDelta != DeltaMinor || !policy.SkipPatch["actions"] || !policy.SkipPatch["octo/tool"] {
+		t.Errorf("ParseUpdatePolicy() = %+v", policy)
+	}
+
+	for _, tc := range []struct{ delta, skip string }{{"huge", ""}, {"", "a/b/c"}} {
+		if _, err := ParseUpdatePolicy(tc.delta, tc.skip); err == nil {
+			t.Errorf("ParseUpdatePolicy(%q, %q) expected error", tc.delta, tc.skip)
+		}
+	}
+}
+
+func TestUpdatePolicyAllows(t *testing.T) {
+	sha := "8e5e7e5ab8b370d6c329ec480221332ada57f0ab"
+	checkout := ActionReference{Owner: "actions", Name: "checkout", Version: "v4.1.0"}
+	tool := ActionReference{Owner: "octo", Name: "tool", Version: "v1.0.0"}
+
+	tests := []struct {
+		name   string
+		policy UpdatePolicy
+		action ActionReference
+		to     string
+		want   bool
+	}{
+		{name: "empty policy", action: checkout, to: "v4.1.1", want: true},
+		{name: "patch below minor", policy: UpdatePolicy{MinDelta: DeltaMinor}, action: checkout, to: "v4.1.1", want: false},
+		{name: "minor meets minor", policy: UpdatePolicy{MinDelta: DeltaMinor}, action: checkout, to: "v4.2.0", want: true},
+		{name: "minor below major", policy: UpdatePolicy{MinDelta: DeltaMajor}, action: checkout, to: "v4.2.0", want: false},
+		{name: "major meets major", policy: UpdatePolicy{MinDelta: DeltaMajor}, action: checkout, to: "v5.0.0", want: true},
+		{name: "skip patch by owner", policy: UpdatePolicy{SkipPatch: map[string]bool{"actions": true}}, action: checkout, to: "v4.1.1", want: false},
+		{name: "skip patch allows minor", policy: UpdatePolicy{SkipPatch: map[string]bool{"actions": true}}, action: checkout, to: "v4.2.0", want: true},
+		{name: "skip patch other action", policy: UpdatePolicy{SkipPatch: map[string]bool{"octo/other": true}}, action: tool, to: "v1.0.1", want: true},
+		{name: "skip patch by repo", policy: UpdatePolicy{SkipPatch: map[string]bool{"octo/tool": true}}, action: tool, to: "v1.0.1", want: false},
+		{name: "pinned with version comment", policy: UpdatePolicy{MinDelta: DeltaMinor}, action: ActionReference{Owner: "actions", Name: "checkout", Version: "v4.1.0", CommitHash: sha}, to: "v4.1.1", want: false},
+		{name: "bare sha", policy: UpdatePolicy{MinDelta: DeltaMajor}, action: ActionReference{Owner: "actions", Name: "checkout", Version: sha, CommitHash: sha}, to: "v4.1.1", want: true},
+		{name: "branch", policy: UpdatePolicy{MinDelta: DeltaMajor}, action: ActionReference{Owner: "actions", Name: "checkout", Version: "main"}, to: "v4.1.1", want: true},
+		{name: "skip major", policy: UpdatePolicy{SkipMajor: true}, action: checkout, to: "v5.0.0", want: false},
+		{name: "skip major allows minor", policy: UpdatePolicy{SkipMajor: true}, action: checkout, to: "v4.2.0", want: true},
+		{name: "prerelease held back", action: checkout, to: "v5.0.0-rc.1", want: false},
+		{name: "prerelease allowed", policy: UpdatePolicy{AllowPrerelease: true}, action: checkout, to: "v5.0.0-rc.1", want: true},
+		{name: "prerelease from prerelease", action: ActionReference{Owner: "actions", Name: "checkout", Version: "v5.0.0-beta.2"}, to: "v5.0.0-rc.1", want: true},
+		{name: "prerelease from bare sha", action: ActionReference{Owner: "actions", Name: "checkout", Version: sha, CommitHash: sha}, to: "v5.0.0-rc.1", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.Allows(tt.action, tt.to); got != tt.want {
+				t.Errorf("Allows() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitMajor(t *testing.T) {
+	checkout := CreateTestUpdate("actions", "checkout", "v3", "v5", "ci.yml")
+	cache := CreateTestUpdate("actions", "cache", "v4.1.0", "v4.2.0", "ci.yml")
+	branch := CreateTestUpdate("octo", "tool", "main", "v2", "ci.yml")
+
+	if !checkout.IsMajor() || cache.IsMajor() || branch.IsMajor() {
+		t.Errorf("IsMajor() = %v, %v, %v; want only v3 to v5", checkout.IsMajor(), cache.IsMajor(), branch.IsMajor())
+	}
+	groups := splitMajor([]*Update{checkout, cache, branch})
+	if len(groups) != 2 || len(groups[0]) != 2 || groups[1][0] != checkout {
+		t.Errorf("splitMajor() = %v, want the others, then the major update", groups)
+	}
+	if groups := splitMajor([]*Update{checkout}); len(groups) != 1 {
+		t.Errorf("splitMajor() = %d groups, want no empty group", len(groups))
+	}
+}
+
+func TestSplitBatches(t *testing.T) {
+	// update describes an update as file:line:repo of an action of octo
+	update := func(spec string) *Update {
+		parts := strings.Split(spec, ":")
+		line := 0
+		_, _ = fmt.Sscan(parts[1], &line)
+		return &Update{Action: ActionReference{Owner: "octo", Name: parts[2]}, FilePath: parts[0], LineNumber: line}
+	}
+	tests := []struct {
+		name    string
+		updates []string
+		size    int
+		want    [][]string
+	}{
+		{name: "no limit", updates: []string{"b.yml:1:x", "a.yml:1:y"}, want: [][]string{{"b.yml:1:x", "a.yml:1:y"}}},
+		{name: "within the limit", updates: []string{"b.yml:1:x", "a.yml:1:y"}, size: 2, want: [][]string{{"b.yml:1:x", "a.yml:1:y"}}},
+		{
+			name:    "ordered by file and line",
+			updates: []string{"b.yml:9:x", "a.yml:5:z", "a.yml:2:y"},
+			size:    1,
+			want:    [][]string{{"a.yml:2:y"}, {"a.yml:5:z"}, {"b.yml:9:x"}},
+		},
+		{
+			name:    "files kept together",
+			updates: []string{"a.yml:1:x", "b.yml:1:x", "b.yml:2:y", "c.yml:1:x"},
+			size:    2,
+			want:    [][]string{{"a.yml:1:x"}, {"b.yml:1:x", "b.yml:2:y"}, {"c.yml:1:x"}},
+		},
+		{
+			name:    "large file split by line",
+			updates: []string{"a.yml:3:z", "a.yml:1:x", "a.yml:2:y", "a.yml:2:y"},
+			size:    2,
+			want:    [][]string{{"a.yml:1:x"}, {"a.yml:2:y", "a.yml:2:y"}, {"a.yml:3:z"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var updates []*Update
+			for _, spec := range tt.updates {
+				updates = append(updates, update(spec))
+			}
+			var got [][]string
+			for _, batch := range splitBatches(updates, tt.size) {
+				var specs []string
+				for _, u := range batch {
+					specs = append(specs, fmt.Sprintf("%s:%d:%s", u.FilePath, u.LineNumber, u.Action.Name))
+				}
+				got = append(got, specs)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("splitBatches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -3,59 +3,103 @@ package updater
 import (
 	"context"
 	"fmt"
+	"log"
 	"net/http"
-	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/versions"
 	"github.com/google/go-github/v72/github"
 )
 
+// releasesFailureThreshold is the number of consecutive releases API errors
+// after which latest versions are resolved from tags only
+const releasesFailureThreshold = 3
+
 // DefaultVersionChecker implements the VersionChecker interface using GitHub API
 type DefaultVersionChecker struct {
 	client *github.Client
 	// For testing
 	mockGetLatestRelease func(ctx context.Context, owner, repo string) (*github.RepositoryRelease, *github.Response, error)
+
+	mu              sync.Mutex
+	releaseFailures int  // Consecutive releases API errors (other than 404)
+	tagsOnly        bool // Set once the releases API is considered unavailable
+	allowPrerelease bool // Prerelease tags newer than the latest release count as latest
+
+	clientOptions ClientOptions                     // How API clients are built
+	actionTokens  ActionTokens                      // Scoped tokens for private actions
+	scopedClients map[string]*github.Client         // Clients for scoped tokens, by token
+	newClient     func(token string) *github.Client // For testing
+
+	actionHosts ActionHosts               // API endpoints of other hosts
+	hostClients map[string]*github.Client // Clients for other hosts, by host
 }
 
 // NewDefaultVersionChecker creates a new DefaultVersionChecker instance
 func NewDefaultVersionChecker(token string) *DefaultVersionChecker {
-	client := common.NewGitHubClientWithToken(token)
-	return &DefaultVersionChecker{client: client}
+	return NewDefaultVersionCheckerWithOptions(token, ClientOptions{})
+}
+
+// NewDefaultVersionCheckerWithOptions creates a DefaultVersionChecker whose
+// API clients, including those for scoped tokens and other hosts, are built
+// with options
+func NewDefaultVersionCheckerWithOptions(token string, options ClientOptions) *DefaultVersionChecker {
+	return &DefaultVersionChecker{client: options.newClient(token), clientOptions: options}
 }
 
 // GetLatestVersion returns the latest version and its commit hash for a given action
 func (c *DefaultVersionChecker) GetLatestVersion(ctx context.Context, action ActionReference) (string, string, error) {
-	// First try to get the latest release
-	var release *github.RepositoryRelease
-	var resp *github.Response
-	var err error
-
-	if c.mockGetLatestRelease != nil {
-		release, resp, err = c.mockGetLatestRelease(ctx, action.Owner, action.Name)
-	} else {
-		release, resp, err = c.client.Repositories.GetLatestRelease(ctx, action.Owner, action.Name)
-	}
+	// Resolve from tags directly once the releases API has proven unreliable
+	c.mu.Lock()
+	tagsOnly, allowPrerelease := c.tagsOnly, c.allowPrerelease
+	c.mu.Unlock()
 
-	// Get the latest tag and its commit hash
 	var tagName string
-	if err == nil && release != nil && release.TagName != nil {
-		tagName = *release.TagName
-	} else if resp != nil && resp.StatusCode == http.StatusNotFound || err != nil {
-		// If no releases found or error occurred, try listing tags
-		opts := &github.ListOptions{
-			PerPage: 1,
+	if !tagsOnly {
+		// First try to get the latest release
+		var release *github.RepositoryRelease
+		var resp *github.Response
+		var err error
+
+		if c.mockGetLatestRelease != nil {
+			release, resp, err = c.mockGetLatestRelease(ctx, action.Owner, action.Repo())
+		} else {
+			release, resp, err = c.clientFor(action).Repositories.GetLatestRelease(ctx, action.Owner, action.Repo())
+		}
+
+		switch {
+		case err == nil && release != nil && release.TagName != nil:
+			c.recordReleasesResult(nil)
+			tagName = *release.TagName
+		case resp != nil && resp.StatusCode == http.StatusNotFound:
+			// The repository simply has no releases
+			c.recordReleasesResult(nil)
+		case isAccessDenied(err):
+			// Missing access to a private action is not an API outage
+			return "", "", c.accessError(ctx, action, err)
+		case err != nil:
+			// The releases API failed; degrade to tags instead of failing the action
+			c.recordReleasesResult(err)
+			log.Printf(common.ErrReleasesFallback, action.Owner, action.Repo(), err)
+		default:
+			return "", "", fmt.Errorf(common.ErrNoVersionInfo, action.Owner, action.Repo())
 		}
-		tags, _, err := c.client.Repositories.ListTags(ctx, action.Owner, action.Name, opts)
+	}
+
+	switch {
+	case tagName == "":
+		var err error
+		tagName, err = c.latestTag(ctx, action)
 		if err != nil {
-			return "", "", fmt.Errorf(common.ErrGettingTags, err)
+			return "", "", err
 		}
-		if len(tags) == 0 || tags[0].Name == nil {
-			return "", "", fmt.Errorf(common.ErrNoVersionInfo, action.Owner, action.Name)
+	case allowPrerelease:
+		// The latest release is never a prerelease; look for a newer tag
+		if tag, err := c.latestTag(ctx, action); err == nil && versions.IsNewer(tag, tagName) {
+			tagName = tag
 		}
-		tagName = *tags[0].Name
-	} else {
-		return "", "", fmt.Errorf(common.ErrNoVersionInfo, action.Owner, action.Name)
 	}
 
 	// Get the commit hash for the tag
@@ -67,6 +111,88 @@ func (c *DefaultVersionChecker) GetLatestVersion(ctx context.Context, action Act
 	return tagName, commitHash, nil
 }
 
+// SetAllowPrerelease makes prerelease tags, such as v2.0.0-rc.1, count as
+// the latest version when they are newer than the latest stable one
+func (c *DefaultVersionChecker) SetAllowPrerelease(allow bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.allowPrerelease = allow
+}
+
+// recordReleasesResult tracks consecutive releases API failures and switches
+// to tags-only resolution once they reach releasesFailureThreshold
+func (c *DefaultVersionChecker) recordReleasesResult(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err == nil {
+		c.releaseFailures = 0
+		return
+	}
+	c.releaseFailures++
+	if !c.tagsOnly && c.releaseFailures >= releasesFailureThreshold {
+		c.tagsOnly = true
+		log.Printf(common.ErrReleasesUnavailable, c.releaseFailures)
+	}
+}
+
+// latestTag returns the highest version tag of an action's repository
+func (c *DefaultVersionChecker) latestTag(ctx context.Context, action ActionReference) (string, error) {
+	opts := &github.ListOptions{
+		PerPage: 100,
+	}
+	tags, _, err := c.clientFor(action).Repositories.ListTags(ctx, action.Owner, action.Repo(), opts)
+	if err != nil {
+		return "", fmt.Errorf(common.ErrGettingTags, c.accessError(ctx, action, err))
+	}
+
+	names := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		names = append(names, tag.GetName())
+	}
+	c.mu.Lock()
+	allowPrerelease := c.allowPrerelease
+	c.mu.Unlock()
+	best := pickTag(names, allowPrerelease)
+	if best == "" {
+		return "", fmt.Errorf(common.ErrNoVersionInfo, action.Owner, action.Repo())
+	}
+	return best, nil
+}
+
+// pickLatestTag returns the highest version among tag names. Prereleases are
+// only considered when no stable version tag exists, and tags that do not
+// look like versions only when there is no version tag at all.
+func pickLatestTag(names []string) string {
+	return pickTag(names, false)
+}
+
+// pickTag is pickLatestTag, also picking a prerelease newer than every
+// stable version tag when allowPrerelease is set
+func pickTag(names []string, allowPrerelease bool) string {
+	best, bestPrerelease := "", ""
+	for _, name := range names {
+		if name == "" || !versions.IsVersion(name) {
+			continue
+		}
+		if versions.IsPrerelease(name) {
+			if bestPrerelease == "" || versions.IsNewer(name, bestPrerelease) {
+				bestPrerelease = name
+			}
+			continue
+		}
+		if best == "" || versions.IsNewer(name, best) {
+			best = name
+		}
+	}
+	if best == "" || (allowPrerelease && bestPrerelease != "" && versions.IsNewer(bestPrerelease, best)) {
+		best = bestPrerelease
+	}
+	if best == "" && len(names) > 0 {
+		best = names[0]
+	}
+	return best
+}
+
 // IsUpdateAvailable checks if a newer version is available
 func (c *DefaultVersionChecker) IsUpdateAvailable(ctx context.Context, action ActionReference) (bool, string, string, error) {
 	latestVersion, latestHash, err := c.GetLatestVersion(ctx, action)
@@ -74,35 +200,44 @@ func (c *DefaultVersionChecker) IsUpdateAvailable(ctx context.Context, action Ac
 		return false, "", "", err
 	}
 
+	return isUpdateAvailable(action, latestVersion, latestHash), latestVersion, latestHash, nil
+}
+
+// isUpdateAvailable compares the current reference against the latest version and hash
+func isUpdateAvailable(action ActionReference, latestVersion, latestHash string) bool {
 	// If current version is a commit SHA (full or abbreviated), compare directly
 	// GitHub typically uses 7+ characters for abbreviated SHAs, but we'll accept 6+ for flexibility
 	if len(action.Version) >= 6 && len(action.Version) <= 40 && common.IsHexString(action.Version) {
 		// For abbreviated SHAs, check if latestHash starts with the abbreviated version
 		if len(action.Version) < 40 {
-			return !strings.HasPrefix(latestHash, action.Version), latestVersion, latestHash, nil
+			return !strings.HasPrefix(latestHash, action.Version)
 		}
-		return action.Version != latestHash, latestVersion, latestHash, nil
+		return action.Version != latestHash
+	}
+
+	// Never move to an older version, e.g. from a prerelease named in the
+	// comment of a hash pin back to the latest stable release
+	if versions.IsVersion(action.Version) && versions.Compare(latestVersion, action.Version) < 0 {
+		return false
 	}
 
 	// If current version is a tag, check if it's older
 	if action.CommitHash != "" {
-		return action.CommitHash != latestHash, latestVersion, latestHash, nil
+		return action.CommitHash != latestHash
 	}
 
 	// If no commit hash is available, check version strings
-	if IsNewer(latestVersion, action.Version) {
-		return true, latestVersion, latestHash, nil
-	}
-
-	return false, latestVersion, latestHash, nil
+	return versions.IsNewer(latestVersion, action.Version)
 }
 
 // GetCommitHash returns the commit hash for a specific version of an action
 func (c *DefaultVersionChecker) GetCommitHash(ctx context.Context, action ActionReference, version string) (string, error) {
+	client := c.clientFor(action)
+
 	// Get the commit hash for the tag/version
-	ref, _, err := c.client.Git.GetRef(ctx, action.Owner, action.Name, "tags/"+version)
+	ref, _, err := client.Git.GetRef(ctx, action.Owner, action.Repo(), "tags/"+version)
 	if err != nil {
-		return "", fmt.Errorf(common.ErrGettingRefForTag, version, err)
+		return "", fmt.Errorf(common.ErrGettingRefForTag, version, c.accessError(ctx, action, err))
 	}
 
 	if ref.Object == nil || ref.Object.SHA == nil {
@@ -111,7 +246,7 @@ func (c *DefaultVersionChecker) GetCommitHash(ctx context.Context, action Action
 
 	// If the tag points to an annotated tag object, we need to get the commit it points to
 	if ref.Object.Type != nil && *ref.Object.Type == "tag" {
-		tag, _, err := c.client.Git.GetTag(ctx, action.Owner, action.Name, *ref.Object.SHA)
+		tag, _, err := client.Git.GetTag(ctx, action.Owner, action.Repo(), *ref.Object.SHA)
 		if err != nil {
 			return "", fmt.Errorf(common.ErrGettingAnnotatedTag, version, err)
 		}
@@ -124,78 +259,8 @@ func (c *DefaultVersionChecker) GetCommitHash(ctx context.Context, action Action
 	return *ref.Object.SHA, nil
 }
 
-// IsNewer compares two version strings and returns true if v1 is newer than v2
+// IsNewer compares two version strings and returns true if v1 is newer than
+// v2; see versions.Compare
 func IsNewer(v1, v2 string) bool {
-	// Remove 'v' prefix if present
-	v1 = strings.TrimPrefix(v1, "v")
-	v2 = strings.TrimPrefix(v2, "v")
-
-	// Split versions into parts
-	parts1 := strings.Split(v1, ".")
-	parts2 := strings.Split(v2, ".")
-
-	// Determine the max length to iterate over
-	maxLen := len(parts1)
-	if len(parts2) > maxLen {
-		maxLen = len(parts2)
-	}
-
-	// Compare each part numerically when possible
-	for i := 0; i < maxLen; i++ {
-		p1 := ""
-		p2 := ""
-		if i < len(parts1) {
-			p1 = parts1[i]
-		}
-		if i < len(parts2) {
-			p2 = parts2[i]
-		}
-
-		n1 := numericPrefix(p1)
-		n2 := numericPrefix(p2)
-
-		if n1 > n2 {
-			return true
-		}
-		if n1 < n2 {
-			return false
-		}
-
-		lenP1 := lenNumericPrefix(p1)
-		lenP2 := lenNumericPrefix(p2)
-		s1 := p1[lenP1:]
-		s2 := p2[lenP2:]
-		if s1 > s2 {
-			return true
-		}
-		if s1 < s2 {
-			return false
-		}
-	}
-
-	return false
-}
-
-// numericPrefix extracts the leading numeric portion of a version part.
-func numericPrefix(part string) int {
-	end := lenNumericPrefix(part)
-	if end == 0 {
-		return 0
-	}
-	n, err := strconv.Atoi(part[:end])
-	if err != nil {
-		fmt.Printf("Warning: failed to parse numeric prefix '%s': %v\n", part[:end], err)
-		return 0
-	}
-	return n
-}
-
-// lenNumericPrefix returns the length of the leading numeric portion of a string.
-func lenNumericPrefix(part string) int {
-	for i, r := range part {
-		if r < '0' || r > '9' {
-			return i
-		}
-	}
-	return len(part)
+	return versions.IsNewer(v1, v2)
 }
@@ -0,0 +1,101 @@
+package updater
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-github/v72/github"
+)
+
+func TestGetLatestVersionFallsBackToTags(t *testing.T) {
+	releaseCalls := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/o/r/releases/latest", func(w http.ResponseWriter, r *http.Request) {
+		releaseCalls++
+		http.Error(w, `{"message":"Server Error"}`, http.StatusInternalServerError)
+	})
+	mux.HandleFunc("/repos/o/r/tags", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"name":"nightly"},{"name":"v1.9.0"},{"name":"v2.0.0-rc.1"},{"name":"v1.10.0"},{"name":"v1"}]`)
+	})
+	mux.HandleFunc("/repos/o/r/git/ref/tags/v1.10.0", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"ref":"refs/tags/v1.10.0","object":{"type":"commit","sha":"abc123"}}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	checker := &DefaultVersionChecker{client: client}
+	action := ActionReference{Owner: "o", Name: "r", Version: "v1.9.0"}
+
+	for i := 0; i < releasesFailureThreshold+2; i++ {
+		version, hash, err := checker.GetLatestVersion(context.Background(), action)
+		if err != nil {
+			t.Fatalf("GetLatestVersion() error = %v", err)
+		}
+		if version != "v1.10.0" || hash != "abc123" {
+			t.Errorf("GetLatestVersion() = %s, %s; want v1.10.0, abc123", version, hash)
+		}
+	}
+
+	// After repeated failures the releases endpoint is no longer called
+	if releaseCalls != releasesFailureThreshold {
+		t.Errorf("releases endpoint called %d times, want %d", releaseCalls, releasesFailureThreshold)
+	}
+}
+
+func TestLatestTagPrereleaseOnly(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/o/r/tags", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"name":"v1.0.0-beta.1"},{"name":"v1.0.0-beta.2"}]`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	checker := &DefaultVersionChecker{client: client}
+
+	tag, err := checker.latestTag(context.Background(), ActionReference{Owner: "o", Name: "r"})
+	if err != nil {
+		t.Fatalf("latestTag() error = %v", err)
+	}
+	if tag != "v1.0.0-beta.2" {
+		t.Errorf("latestTag() = %s, want v1.0.0-beta.2", tag)
+	}
+}
+
+func TestGetLatestVersionAllowPrerelease(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/o/r/releases/latest", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"tag_name":"v1.9.0"}`)
+	})
+	mux.HandleFunc("/repos/o/r/tags", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"name":"v1.9.0"},{"name":"v2.0.0-rc.1"},{"name":"v2.0.0-beta.3"},{"name":"v1.8.0"}]`)
+	})
+	mux.HandleFunc("/repos/o/r/git/ref/tags/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"object":{"type":"commit","sha":"abc123"}}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	checker := &DefaultVersionChecker{client: client}
+	action := ActionReference{Owner: "o", Name: "r", Version: "v1.8.0"}
+
+	for _, tt := range []struct {
+		allow bool
+		want  string
+	}{{false, "v1.9.0"}, {true, "v2.0.0-rc.1"}} {
+		checker.SetAllowPrerelease(tt.allow)
+		version, _, err := checker.GetLatestVersion(context.Background(), action)
+		if err != nil || version != tt.want {
+			t.Errorf("GetLatestVersion(allow prerelease %v) = %s, %v; want %s", tt.allow, version, err, tt.want)
+		}
+	}
+}
@@ -215,6 +215,28 @@ func TestDefaultVersionChecker_IsUpdateAvailable(t *testing.T) {
 	}
 }
 
+func TestIsUpdateAvailableNeverDowngrades(t *testing.T) {
+	const latest, latestHash = "v4.2.2", "4444444444444444444444444444444444444444"
+	tests := []struct {
+		name    string
+		version string // Version named by the reference or the comment of its hash pin
+		want    bool
+	}{
+		{name: "older release", version: "v4.1.0", want: true},
+		{name: "same release, other commit", version: "v4.2.2", want: true},
+		{name: "prerelease of the next major", version: "v5.0.0-rc.1", want: false},
+		{name: "stable release newer than latest", version: "v5.0.0", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			action := ActionReference{Owner: "actions", Name: "checkout", Version: tt.version, CommitHash: "5555555555555555555555555555555555555555"}
+			if got := isUpdateAvailable(action, latest, latestHash); got != tt.want {
+				t.Errorf("isUpdateAvailable(%s) = %v, want %v", tt.version, got, tt.want)
+			}
+		})
+	}
+}
+
 // TestVersionHelperFunctions validates the test helper functions themselves
 func TestVersionHelperFunctions(t *testing.T) {
 	// Define server test configuration
@@ -475,6 +475,11 @@ func GetVersionComparisonTestCases() []SimpleTestCase {
 		{"longer version", []string{"v1.0.0.1", "v1.0.0"}, true, false},
 		{"shorter version", []string{"v1.0", "v1.0.0"}, false, false},
 		{"alpha versions", []string{"v1.0.0-alpha.2", "v1.0.0-alpha.1"}, true, false},
+		{"prerelease older than release", []string{"v1.0.0-alpha.2", "v1.0.0"}, false, false},
+		{"release newer than prerelease", []string{"v1.0.0", "v1.0.0-rc.1"}, true, false},
+		{"numeric prerelease identifiers", []string{"v1.0.0-beta.11", "v1.0.0-beta.2"}, true, false},
+		{"major-only tag", []string{"v2.0.0", "v2"}, false, false},
+		{"build metadata ignored", []string{"v1.0.0+build.2", "v1.0.0+build.1"}, false, false},
 	}
 }
 
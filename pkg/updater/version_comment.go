@@ -0,0 +1,115 @@
+package updater
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Placeholders supported in version comment formats
+const (
+	VersionPlaceholder = "{version}"
+	ActionPlaceholder  = "{action}"
+)
+
+// DefaultVersionCommentFormat is the comment written after a pinned hash
+// when neither a format is configured nor an existing style is recognized
+const DefaultVersionCommentFormat = "# " + VersionPlaceholder
+
+// versionPattern matches version-like strings such as v3, 3.1 or v1.2.3-beta.1
+const versionPattern = `v?\d+(?:\.\d+)*(?:[-+][0-9A-Za-z.-]+)?`
+
+// versionCommentStyles lists the recognized version comment styles. Each
+// pattern captures the version; format rebuilds a comment in the same style.
+var versionCommentStyles = []struct {
+	pattern *regexp.Regexp
+	format  string
+}{
+	{regexp.MustCompile(`^#\s*Original version:\s*(\S+)$`), "# Original version: " + VersionPlaceholder},
+	{regexp.MustCompile(`^#\s*ratchet:[^@\s]+@(\S+)$`), "# ratchet:" + ActionPlaceholder + "@" + VersionPlaceholder},
+	{regexp.MustCompile(`^#\s*pin@(\S+)$`), "# pin@" + VersionPlaceholder},
+	{regexp.MustCompile(`^#\s*tag=(\S+)$`), "# tag=" + VersionPlaceholder},
+	{regexp.MustCompile(`^#\s*@?(` + versionPattern + `)$`), "# " + VersionPlaceholder},
+}
+
+// ParseVersionComment extracts the version from a comment in one of the
+// recognized styles: "# v3", "# pin@v3", "# tag=v3", "# ratchet:owner/repo@v3"
+// or "# Original version: v3". A user comment may follow the version comment,
+// as in "# v3  # pinned for node 16".
+func ParseVersionComment(comment string) (string, bool) {
+	comment, _ = splitComment(strings.TrimSpace(comment))
+	for _, style := range versionCommentStyles {
+		if m := style.pattern.FindStringSubmatch(comment); m != nil {
+			return m[1], true
+		}
+	}
+	return "", false
+}
+
+// VersionCommentStyle returns a format reproducing the style of an existing
+// version comment, or "" if the comment is not a recognized version comment
+func VersionCommentStyle(comment string) string {
+	comment, _ = splitComment(strings.TrimSpace(comment))
+	for _, style := range versionCommentStyles {
+		if style.pattern.MatchString(comment) {
+			return style.format
+		}
+	}
+	return ""
+}
+
+// FormatVersionComment renders a version comment format for an action
+func FormatVersionComment(format string, action ActionReference, version string) string {
+	if format == "" {
+		format = DefaultVersionCommentFormat
+	}
+	comment := strings.NewReplacer(
+		VersionPlaceholder, version,
+		ActionPlaceholder, action.FullName(),
+	).Replace(format)
+	if !strings.HasPrefix(comment, "#") {
+		comment = "# " + comment
+	}
+	return comment
+}
+
+// splitComment splits a comment before the next '#' that follows whitespace.
+// The first part has no trailing whitespace; the rest starts with it.
+func splitComment(comment string) (string, string) {
+	for i := 1; i < len(comment); i++ {
+		if comment[i] == '#' && (comment[i-1] == ' ' || comment[i-1] == '\t') {
+			first := strings.TrimRight(comment[:i], " \t")
+			return first, comment[len(first):]
+		}
+	}
+	first := strings.TrimRight(comment, " \t")
+	return first, comment[len(first):]
+}
+
+// commentIndex returns the byte offset of the comment in a YAML line
+// fragment, or -1. A '#' only starts a comment when preceded by whitespace
+// and outside quotes.
+func commentIndex(line string) int {
+	var quote rune
+	for i, c := range line {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '#' && i > 0 && (line[i-1] == ' ' || line[i-1] == '\t'):
+			return i
+		}
+	}
+	return -1
+}
+
+// trailingComment returns the comment at the end of a YAML line, if any. A
+// '#' only starts a comment when preceded by whitespace and outside quotes.
+func trailingComment(line string) string {
+	if i := commentIndex(line); i >= 0 {
+		return strings.TrimSpace(line[i:])
+	}
+	return ""
+}
@@ -0,0 +1,129 @@
+package updater
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseVersionComment(t *testing.T) {
+	tests := []struct {
+		comment string
+		want    string
+		wantOK  bool
+	}{
+		{comment: "# v3", want: "v3", wantOK: true},
+		{comment: "#v3.1.0", want: "v3.1.0", wantOK: true},
+		{comment: "# 2.0.0-beta.1", want: "2.0.0-beta.1", wantOK: true},
+		{comment: "# pin@v3", want: "v3", wantOK: true},
+		{comment: "# tag=v3.5.2", want: "v3.5.2", wantOK: true},
+		{comment: "# ratchet:actions/checkout@v4", want: "v4", wantOK: true},
+		{comment: "# Original version: v2", want: "v2", wantOK: true},
+		{comment: "# @v1", want: "v1", wantOK: true},
+		{comment: "# ratchet:exclude", wantOK: false},
+		{comment: "# Checkout the code", wantOK: false},
+		{comment: "# step 2", wantOK: false},
+		{comment: "", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.comment, func(t *testing.T) {
+			got, ok := ParseVersionComment(tt.comment)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("ParseVersionComment(%q) = %q, %v; want %q, %v", tt.comment, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestFormatVersionComment(t *testing.T) {
+	action := ActionReference{Owner: "actions", Name: "checkout"}
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{format: "", want: "# v4"},
+		{format: "# pin@{version}", want: "# pin@v4"},
+		{format: "tag={version}", want: "# tag=v4"},
+		{format: "# ratchet:{action}@{version}", want: "# ratchet:actions/checkout@v4"},
+	}
+	for _, tt := range tests {
+		if got := FormatVersionComment(tt.format, action, "v4"); got != tt.want {
+			t.Errorf("FormatVersionComment(%q) = %q, want %q", tt.format, got, tt.want)
+		}
+	}
+}
+
+func TestTrailingComment(t *testing.T) {
+	tests := map[string]string{
+		"      - uses: actions/checkout@abc  # pin@v3": "# pin@v3",
+		"      - uses: actions/checkout@v3":            "",
+		`      - run: echo "#not a comment"  # real`:   "# real",
+		"      - uses: owner/repo#branch@v1":           "",
+	}
+	for line, want := range tests {
+		if got := trailingComment(line); got != want {
+			t.Errorf("trailingComment(%q) = %q, want %q", line, got, want)
+		}
+	}
+}
+
+func TestVersionCommentStylesRoundTrip(t *testing.T) {
+	const hash = "a81bbbf8298c0fa03ea29cdc473d45769f953675"
+	const newHash = "1111111111111111111111111111111111111111"
+
+	tests := []struct {
+		name    string
+		comment string
+		format  string
+		want    string
+	}{
+		{name: "plain", comment: "# v3", want: "# v4"},
+		{name: "pin", comment: "# pin@v3", want: "# pin@v4"},
+		{name: "tag", comment: "# tag=v3", want: "# tag=v4"},
+		{name: "ratchet", comment: "# ratchet:actions/checkout@v3", want: "# ratchet:actions/checkout@v4"},
+		{name: "configured format wins", comment: "# pin@v3", format: "# tag={version}", want: "# tag=v4"},
+		{name: "unrecognized comment", comment: "# keep me", want: "# v4"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "workflow.yml")
+			content := "jobs:\n  a:\n    steps:\n      - uses: actions/checkout@" + hash + "  " + tt.comment + "\n"
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			refs, err := NewScanner(dir).ParseActionReferences(path)
+			if err != nil || len(refs) != 1 {
+				t.Fatalf("ParseActionReferences() = %v, %v", refs, err)
+			}
+			ref := refs[0]
+			if ref.VersionComment != tt.comment {
+				t.Errorf("VersionComment = %q, want %q", ref.VersionComment, tt.comment)
+			}
+			if tt.comment != "# keep me" && ref.Version != "v3" {
+				t.Errorf("recovered version = %q, want v3", ref.Version)
+			}
+
+			manager := NewUpdateManager(dir)
+			manager.SetVersionCommentFormat(tt.format)
+			update, err := manager.CreateUpdate(context.Background(), path, ref, "v4", newHash)
+			if err != nil {
+				t.Fatalf("CreateUpdate() error = %v", err)
+			}
+			if err := manager.ApplyUpdates(context.Background(), []*Update{update}); err != nil {
+				t.Fatalf("ApplyUpdates() error = %v", err)
+			}
+
+			got, _ := os.ReadFile(path)
+			wantLine := "      - uses: actions/checkout@" + newHash + "  " + tt.want
+			if !strings.Contains(string(got), wantLine) {
+				t.Errorf("updated content = %q, want line %q", got, wantLine)
+			}
+		})
+	}
+}
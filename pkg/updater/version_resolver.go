@@ -0,0 +1,82 @@
+package updater
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+)
+
+// VersionResolver is implemented by version checkers that can tell which
+// version a commit is, so references pinned to a bare hash can be shown
+// with a readable version
+type VersionResolver interface {
+	// ResolveVersionForHash returns the latest version tag pointing at hash,
+	// or "" when no version tag does
+	ResolveVersionForHash(ctx context.Context, action ActionReference, hash string) (string, error)
+}
+
+// ResolveVersionForHash returns the latest version tag pointing at hash
+func (c *DefaultVersionChecker) ResolveVersionForHash(ctx context.Context, action ActionReference, hash string) (string, error) {
+	tags, err := c.GetCommitTags(ctx, action, hash)
+	if err != nil {
+		return "", err
+	}
+	return pickLatestTag(tags), nil
+}
+
+// ResolveVersionForHash returns the latest version tag pointing at hash
+func (c *GiteaVersionChecker) ResolveVersionForHash(ctx context.Context, action ActionReference, hash string) (string, error) {
+	tags, err := c.GetCommitTags(ctx, action, hash)
+	if err != nil {
+		return "", err
+	}
+	return pickLatestTag(tags), nil
+}
+
+// ResolveVersionForHash implements VersionResolver when the wrapped checker
+// does. Commits without a version tag are cached as well.
+func (c *CachingVersionChecker) ResolveVersionForHash(ctx context.Context, action ActionReference, hash string) (string, error) {
+	resolver, ok := c.checker.(VersionResolver)
+	if !ok {
+		return "", fmt.Errorf(common.ErrResolvingVersion, hash, fmt.Errorf("not supported"))
+	}
+	key := fmt.Sprintf("%s/versions/%s/%s", cacheKeyPrefix, action.Repository(), hash)
+	if entry, ok := c.load(ctx, key); ok {
+		return entry.Version, nil
+	}
+
+	version, err := resolver.ResolveVersionForHash(ctx, action, hash)
+	if err != nil {
+		return "", err
+	}
+	c.save(ctx, key, cacheEntry{Version: version, Hash: hash})
+	return version, nil
+}
+
+// ResolveVersionForHash implements VersionResolver when the wrapped checker
+// does
+func (c *RetryingVersionChecker) ResolveVersionForHash(ctx context.Context, action ActionReference, hash string) (string, error) {
+	resolver, ok := c.checker.(VersionResolver)
+	if !ok {
+		return "", fmt.Errorf(common.ErrResolvingVersion, hash, fmt.Errorf("not supported"))
+	}
+	var version string
+	err := c.policy.Do(ctx, func() error {
+		var err error
+		version, err = resolver.ResolveVersionForHash(ctx, action, hash)
+		return err
+	})
+	return version, err
+}
+
+// resolveBareHash returns the version of a reference pinned to a hash
+// without a version comment, or "" when it has a version or the checker
+// cannot resolve one
+func resolveBareHash(ctx context.Context, checker VersionChecker, ref ActionReference) (string, error) {
+	resolver, ok := checker.(VersionResolver)
+	if !ok || ref.CommitHash == "" || ref.Version != ref.CommitHash {
+		return "", nil
+	}
+	return resolver.ResolveVersionForHash(ctx, ref, ref.CommitHash)
+}
@@ -0,0 +1,85 @@
+package updater
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/storage"
+)
+
+// resolvingChecker is a version checker resolving a single pinned hash
+type resolvingChecker struct {
+	countingChecker
+	resolveCalls int
+}
+
+func (c *resolvingChecker) ResolveVersionForHash(ctx context.Context, action ActionReference, hash string) (string, error) {
+	c.resolveCalls++
+	if hash == driftPinnedHash {
+		return "v3.4.0", nil
+	}
+	return "", nil
+}
+
+func TestRunResolvesBareHashes(t *testing.T) {
+	dir := t.TempDir()
+	workflow := filepath.Join(dir, ".github", "workflows", "ci.yml")
+	if err := os.MkdirAll(filepath.Dir(workflow), 0750); err != nil {
+		t.Fatal(err)
+	}
+	content := "jobs:\n  a:\n    steps:\n" +
+		"      - uses: actions/checkout@" + driftPinnedHash + "\n" +
+		"      - uses: actions/checkout@" + driftPinnedHash + "\n" +
+		"      - uses: actions/cache@" + driftTagHash + "  # v4.0.0-rc\n" +
+		"      - uses: octo/tool@" + driftTagHash + "\n"
+	if err := os.WriteFile(workflow, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	checker := &resolvingChecker{}
+	rep, err := Run(context.Background(), Options{RepoPath: dir, Mode: ModeDryRun, Checker: checker})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	want := []string{"v3.4.0", "v3.4.0", "v4.0.0-rc", driftTagHash}
+	if len(rep.Updates) != len(want) {
+		t.Fatalf("updates = %d, want %d", len(rep.Updates), len(want))
+	}
+	for i, update := range rep.Updates {
+		if update.OldVersion != want[i] {
+			t.Errorf("update %d OldVersion = %q, want %q", i, update.OldVersion, want[i])
+		}
+	}
+	// Identical references and commented pins are not resolved again
+	if checker.resolveCalls != 2 {
+		t.Errorf("resolve calls = %d, want 2", checker.resolveCalls)
+	}
+}
+
+func TestCachingVersionCheckerResolveVersionForHash(t *testing.T) {
+	ctx := context.Background()
+	inner := &resolvingChecker{}
+	checker := NewCachingVersionChecker(inner, storage.NewMemoryStore(), time.Hour)
+	action := ActionReference{Owner: "actions", Name: "checkout"}
+
+	for _, hash := range []string{driftPinnedHash, driftPinnedHash, driftTagHash, driftTagHash} {
+		version, err := checker.ResolveVersionForHash(ctx, action, hash)
+		if err != nil {
+			t.Fatalf("ResolveVersionForHash() error = %v", err)
+		}
+		if want := map[string]string{driftPinnedHash: "v3.4.0"}[hash]; version != want {
+			t.Errorf("ResolveVersionForHash(%s) = %q, want %q", hash, version, want)
+		}
+	}
+	if inner.resolveCalls != 2 {
+		t.Errorf("resolve calls = %d, want 2", inner.resolveCalls)
+	}
+
+	plain := NewCachingVersionChecker(&countingChecker{}, storage.NewMemoryStore(), time.Hour)
+	if _, err := plain.ResolveVersionForHash(ctx, action, driftPinnedHash); err == nil {
+		t.Error("ResolveVersionForHash() without a resolver succeeded")
+	}
+}
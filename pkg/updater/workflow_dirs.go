@@ -0,0 +1,60 @@
+package updater
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+)
+
+// FindWorkflowDirs returns the workflows directories of the subprojects of
+// a monorepo: every <dir>/<workflowsPath> for directories up to depth levels
+// below root, e.g. services/api/.github/workflows at depth 2. The workflows
+// directory of root itself is not included. Hidden directories such as .git
+// are not searched, nor are symbolic links to directories; submodules are
+// searched, skipped or rejected by the submodules policy. Directories are
+// returned in lexical order.
+func FindWorkflowDirs(ctx context.Context, root, workflowsPath string, depth int, submodules LinkPolicy) ([]string, error) {
+	var dirs []string
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !entry.IsDir() || path == root {
+			return nil
+		}
+		if strings.HasPrefix(entry.Name(), ".") {
+			return filepath.SkipDir
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		level := len(strings.Split(filepath.ToSlash(rel), "/"))
+		if level > depth {
+			return filepath.SkipDir
+		}
+		if submodules != LinkFollow && isSubmodule(path) {
+			if submodules == LinkError {
+				return fmt.Errorf(common.ErrSubmoduleNotAllowed, path)
+			}
+			return filepath.SkipDir
+		}
+		workflows := filepath.Join(path, workflowsPath)
+		if info, err := os.Stat(workflows); err == nil && info.IsDir() {
+			dirs = append(dirs, workflows)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf(common.ErrScanningWorkflows, err)
+	}
+	return dirs, nil
+}
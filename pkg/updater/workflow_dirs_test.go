@@ -0,0 +1,88 @@
+package updater
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeMonorepo creates workflows in the root and in subprojects
+func writeMonorepo(t *testing.T, files ...string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, name := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(runWorkflow), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// relativeFiles names files relative to dir
+func relativeFiles(dir string, files []string) string {
+	rel := make([]string, 0, len(files))
+	for _, file := range files {
+		name, _ := filepath.Rel(dir, file)
+		rel = append(rel, filepath.ToSlash(name))
+	}
+	return strings.Join(rel, ",")
+}
+
+func TestScanWorkflowsMaxDepth(t *testing.T) {
+	dir := writeMonorepo(t, "ci.yml", "team-a/build.yml", "team-a/nested/deploy.yml")
+	tests := []struct {
+		depth int
+		want  string
+	}{
+		{depth: 0, want: "ci.yml,team-a/build.yml,team-a/nested/deploy.yml"},
+		{depth: 1, want: "ci.yml"},
+		{depth: 2, want: "ci.yml,team-a/build.yml"},
+	}
+	for _, tt := range tests {
+		scanner := NewScanner(dir)
+		scanner.SetMaxDepth(tt.depth)
+		files, err := scanner.ScanWorkflows(dir)
+		if err != nil {
+			t.Fatalf("ScanWorkflows() error = %v", err)
+		}
+		if got := relativeFiles(dir, files); got != tt.want {
+			t.Errorf("ScanWorkflows() with depth %d = %s, want %s", tt.depth, got, tt.want)
+		}
+	}
+}
+
+func TestFindWorkflowDirs(t *testing.T) {
+	dir := writeMonorepo(t,
+		".github/workflows/ci.yml",
+		"api/.github/workflows/api.yml",
+		"services/web/.github/workflows/web.yml",
+		"services/deep/x/.github/workflows/deep.yml",
+		".hidden/.github/workflows/hidden.yml",
+	)
+	dirs, err := FindWorkflowDirs(context.Background(), dir, ".github/workflows", 2, LinkSkip)
+	if err != nil {
+		t.Fatalf("FindWorkflowDirs() error = %v", err)
+	}
+	if got := relativeFiles(dir, dirs); got != "api/.github/workflows,services/web/.github/workflows" {
+		t.Errorf("FindWorkflowDirs() = %s", got)
+	}
+
+	// Subproject workflows are scanned even without root workflows
+	if err := os.RemoveAll(filepath.Join(dir, ".github")); err != nil {
+		t.Fatal(err)
+	}
+	rep, err := ScanReferences(context.Background(), Options{RepoPath: dir, DiscoverDepth: 3})
+	if err != nil {
+		t.Fatalf("ScanReferences() error = %v", err)
+	}
+	want := "api/.github/workflows/api.yml,services/deep/x/.github/workflows/deep.yml,services/web/.github/workflows/web.yml"
+	if got := relativeFiles(dir, rep.Files); got != want {
+		t.Errorf("scanned files = %s, want %s", got, want)
+	}
+}
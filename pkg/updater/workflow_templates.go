@@ -0,0 +1,41 @@
+package updater
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// WorkflowTemplateDirs are where an organization's .github repository keeps
+// the workflow templates (starter workflows) offered to its repositories
+var WorkflowTemplateDirs = []string{"workflow-templates", ".github/workflow-templates"}
+
+// templatePlaceholder matches the placeholders GitHub substitutes when a
+// workflow template is used, e.g. $default-branch or $cron-daily
+var templatePlaceholder = regexp.MustCompile(`^\$[a-z][a-z0-9-]*$`)
+
+// FindWorkflowTemplateDirs returns the workflow template directories of the
+// repository at root that exist
+func FindWorkflowTemplateDirs(root string) []string {
+	var dirs []string
+	for _, dir := range WorkflowTemplateDirs {
+		path := filepath.Join(root, dir)
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			dirs = append(dirs, path)
+		}
+	}
+	return dirs
+}
+
+// isTemplatePlaceholder reports whether a uses value is, or refers to, a
+// template placeholder such as org/.github/.github/workflows/ci.yml@$default-branch.
+// Such references are only resolved when the template is used and cannot
+// be pinned.
+func isTemplatePlaceholder(uses string) bool {
+	if templatePlaceholder.MatchString(uses) {
+		return true
+	}
+	_, ref, ok := strings.Cut(uses, "@")
+	return ok && templatePlaceholder.MatchString(ref)
+}
@@ -0,0 +1,73 @@
+package updater
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// starterWorkflow is a workflow template using GitHub's placeholders
+const starterWorkflow = `name: CI
+on:
+  push:
+    branches: [ $default-branch ]
+  schedule:
+    - cron: $cron-daily
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v3
+  shared:
+    uses: my-org/.github/.github/workflows/shared.yml@$default-branch
+`
+
+func TestIsTemplatePlaceholder(t *testing.T) {
+	tests := []struct {
+		uses string
+		want bool
+	}{
+		{"actions/checkout@v4", false},
+		{"my-org/.github/.github/workflows/shared.yml@$default-branch", true},
+		{"$protected-branches", true},
+		{"actions/checkout@$", false},
+		{"actions/checkout@${{ inputs.ref }}", false},
+	}
+	for _, tt := range tests {
+		if got := isTemplatePlaceholder(tt.uses); got != tt.want {
+			t.Errorf("isTemplatePlaceholder(%q) = %v, want %v", tt.uses, got, tt.want)
+		}
+	}
+}
+
+func TestScanWorkflowTemplates(t *testing.T) {
+	dir := t.TempDir()
+	templates := filepath.Join(dir, "workflow-templates")
+	if err := os.MkdirAll(templates, 0750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(templates, "ci.yml"), []byte(starterWorkflow), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(templates, "ci.properties.json"), []byte(`{"name": "CI"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Templates are only scanned when asked for
+	rep, err := ScanReferences(context.Background(), Options{RepoPath: dir})
+	if err == nil && len(rep.Files) > 0 {
+		t.Errorf("scanned %v without WorkflowTemplates", rep.Files)
+	}
+
+	rep, err = ScanReferences(context.Background(), Options{RepoPath: dir, WorkflowTemplates: true})
+	if err != nil {
+		t.Fatalf("ScanReferences() error = %v", err)
+	}
+	if got := relativeFiles(dir, rep.Files); got != "workflow-templates/ci.yml" {
+		t.Errorf("scanned files = %s", got)
+	}
+	if len(rep.RemoteActions) != 1 || rep.RemoteActions[0].FullName() != "actions/checkout" || rep.RemoteActions[0].Line != 11 {
+		t.Errorf("remote actions = %+v, want only actions/checkout on line 11", rep.RemoteActions)
+	}
+}
@@ -0,0 +1,282 @@
+package updater
+
+import (
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// usesScalar is a uses value located in the YAML source
+type usesScalar struct {
+	node *yaml.Node
+	line int // 1-based line of the scalar
+	col  int // 1-based column (in runes) of the scalar, including any quote
+}
+
+// scalarEdit is a resolved replacement of a uses value in one line
+type scalarEdit struct {
+	update *Update
+	line   int    // 0-based line index
+	start  int    // byte offset of the value (excluding quotes)
+	end    int    // byte offset just past the value
+	quote  int    // length of the closing quote, if any
+	value  string // replacement value
+}
+
+// rewriteUses rewrites the uses values targeted by updates using the YAML
+// syntax tree to find the exact scalar, so anchors, flow-style steps and
+// quoted values are handled. Only the value and, where it ends the line, its
+// trailing comment are replaced; the rest of the file is kept byte for byte.
+// Updates that cannot be located this way are returned for line-based editing.
+func rewriteUses(content string, updates []*Update) (string, []*Update) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(content), &doc); err != nil {
+		return content, updates
+	}
+
+	var scalars []usesScalar
+	collectUsesScalars(&doc, &scalars, make(map[*yaml.Node]bool))
+	aliases := make(map[int]bool)
+	collectAliasLines(&doc, aliases)
+
+	lines := strings.Split(content, "\n")
+	var edits []scalarEdit
+	var remaining []*Update
+	claimed := make(map[*yaml.Node]bool)
+
+	for _, update := range updates {
+		// Stale line numbers are left to the line-based editor to report
+		if update.LineNumber < 1 || update.LineNumber > len(lines) {
+			remaining = append(remaining, update)
+			continue
+		}
+		scalar := findUsesScalar(scalars, update, claimed)
+		if scalar == nil && aliases[update.LineNumber] {
+			// A reference through an alias is rewritten at its anchor
+			continue
+		}
+		if scalar == nil {
+			remaining = append(remaining, update)
+			continue
+		}
+		edit, ok := locateScalar(lines, scalar, update)
+		if !ok {
+			remaining = append(remaining, update)
+			continue
+		}
+		claimed[scalar.node] = true
+		edit.value = update.NewFullName() + "@" + update.NewRef()
+		edits = append(edits, edit)
+	}
+	applyScalarEdits(lines, edits)
+
+	return strings.Join(lines, "\n"), remaining
+}
+
+// rewriteReferences rewrites GitLab include refs, templated uses sources and
+// uses values, returning
+// the updates left for line-based editing
+func rewriteReferences(content string, updates []*Update) (string, []*Update) {
+	content, updates = rewriteIncludeRefs(content, updates)
+	content, updates = rewriteTemplateValues(content, updates)
+	return rewriteUses(content, updates)
+}
+
+// applyScalarEdits applies edits right to left so earlier offsets stay valid
+func applyScalarEdits(lines []string, edits []scalarEdit) {
+	sort.Slice(edits, func(i, j int) bool {
+		if edits[i].line != edits[j].line {
+			return edits[i].line > edits[j].line
+		}
+		return edits[i].start > edits[j].start
+	})
+	for _, edit := range edits {
+		lines[edit.line] = applyScalarEdit(lines[edit.line], edit)
+	}
+}
+
+// collectUsesScalars finds all scalar values of uses keys. Aliases are not
+// followed since the aliased text lives at the anchor.
+func collectUsesScalars(node *yaml.Node, scalars *[]usesScalar, visited map[*yaml.Node]bool) {
+	if node == nil || visited[node] {
+		return
+	}
+	visited[node] = true
+
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if key.Value == "uses" && value.Kind == yaml.ScalarNode {
+				*scalars = append(*scalars, usesScalar{node: value, line: value.Line, col: value.Column})
+				continue
+			}
+			collectUsesScalars(value, scalars, visited)
+		}
+		return
+	}
+	for _, child := range node.Content {
+		collectUsesScalars(child, scalars, visited)
+	}
+}
+
+// collectAliasLines records the lines holding aliases
+func collectAliasLines(node *yaml.Node, lines map[int]bool) {
+	if node.Kind == yaml.AliasNode {
+		lines[node.Line] = true
+		return
+	}
+	for _, child := range node.Content {
+		collectAliasLines(child, lines)
+	}
+}
+
+// findUsesScalar returns the uses value an update refers to: the scalar on
+// the update's line, or else the only unclaimed scalar with the old value
+// (e.g. an anchored step referenced through an alias on the update's line)
+func findUsesScalar(scalars []usesScalar, update *Update, claimed map[*yaml.Node]bool) *usesScalar {
+	name := update.Action.FullName() + "@"
+	matches := func(s usesScalar) bool {
+		return !claimed[s.node] && strings.HasPrefix(s.node.Value, name)
+	}
+
+	for i := range scalars {
+		if scalars[i].line == update.LineNumber && matches(scalars[i]) {
+			return &scalars[i]
+		}
+	}
+
+	oldRef := update.OldHash
+	if oldRef == "" {
+		oldRef = update.OldVersion
+	}
+	var found *usesScalar
+	for i := range scalars {
+		if matches(scalars[i]) && scalars[i].node.Value == name+oldRef {
+			if found != nil {
+				return nil
+			}
+			found = &scalars[i]
+		}
+	}
+	return found
+}
+
+// locateScalar finds the byte range of a single-line scalar in the source
+func locateScalar(lines []string, scalar *usesScalar, update *Update) (scalarEdit, bool) {
+	if scalar.line < 1 || scalar.line > len(lines) {
+		return scalarEdit{}, false
+	}
+	line := lines[scalar.line-1]
+	runes := []rune(line)
+	if scalar.col < 1 || scalar.col > len(runes) {
+		return scalarEdit{}, false
+	}
+	start := len(string(runes[:scalar.col-1]))
+	value := scalar.node.Value
+
+	quote := 0
+	switch scalar.node.Style {
+	case 0:
+		// Plain scalar
+	case yaml.DoubleQuotedStyle, yaml.SingleQuotedStyle:
+		quote = 1
+		start++ // skip the opening quote
+	default:
+		return scalarEdit{}, false
+	}
+
+	end := start + len(value)
+	if end+quote > len(line) || line[start:end] != value {
+		return scalarEdit{}, false
+	}
+	return scalarEdit{update: update, line: scalar.line - 1, start: start, end: end, quote: quote}, true
+}
+
+// applyScalarEdit replaces the value in line and refreshes the version
+// comment when the value is the last thing on the line. Everything else on
+// the line, including spacing, quotes and user comments, is kept byte for
+// byte: only a leading version comment is managed, and user comments that
+// follow it are preserved.
+func applyScalarEdit(line string, edit scalarEdit) string {
+	update := edit.update
+	before := line[:edit.start] + edit.value + line[edit.end:edit.end+edit.quote]
+	rest := line[edit.end+edit.quote:]
+
+	code, comment := rest, ""
+	if i := commentIndex(rest); i >= 0 {
+		code, comment = rest[:i], rest[i:]
+	}
+	if strings.Trim(code, " \t}],") != "" {
+		// Other content follows on the same line; only replace the value
+		return before + rest
+	}
+
+	versionComment := updateVersionComment(update)
+	if versionComment == "" {
+		if managed, userComment := splitComment(comment); update.pinsTag() && isVersionComment(managed) {
+			// A tag needs no version comment
+			return before + strings.TrimRight(code, " \t") + userComment
+		}
+		return before + rest
+	}
+
+	switch managed, userComment := splitComment(comment); {
+	case comment == "":
+		// Trailing whitespace, if any, separates the new comment
+		if strings.TrimRight(code, " \t") == code {
+			code += "  "
+		}
+		return before + code + versionComment
+	case isVersionComment(managed):
+		return before + code + versionComment + userComment
+	default:
+		// Only a user comment: the version comment goes in front of it
+		return before + code + versionComment + "  " + comment
+	}
+}
+
+// locateUsesRef finds the reference an update replaces in a single line
+// without the YAML syntax tree, for files the parser cannot locate it in
+func locateUsesRef(line string, update *Update) (scalarEdit, bool) {
+	usesIdx := strings.Index(line, "uses:")
+	if usesIdx < 0 {
+		return scalarEdit{}, false
+	}
+	name := update.Action.FullName() + "@"
+	offset := strings.Index(line[usesIdx:], name)
+	if offset < 0 {
+		return scalarEdit{}, false
+	}
+	start := usesIdx + offset
+	end := start + strings.IndexAny(line[start:]+" ", " \t\"'#,}]")
+	if end == start+len(name) {
+		return scalarEdit{}, false
+	}
+
+	quote := 0
+	if start > 0 && end < len(line) && (line[start-1] == '"' || line[start-1] == '\'') && line[end] == line[start-1] {
+		quote = 1
+	}
+	return scalarEdit{update: update, start: start, end: end, quote: quote, value: update.NewFullName() + "@" + update.NewRef()}, true
+}
+
+// isVersionComment reports whether a single comment is a version comment
+func isVersionComment(comment string) bool {
+	_, ok := ParseVersionComment(comment)
+	return ok
+}
+
+// updateVersionComment returns the comment to write after an updated reference
+func updateVersionComment(update *Update) string {
+	if update.pinsTag() {
+		return ""
+	}
+	if update.VersionComment != "" {
+		return update.VersionComment
+	}
+	if update.NewVersion != "" {
+		return "# " + update.NewVersion
+	}
+	return ""
+}
@@ -0,0 +1,120 @@
+package updater
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRewriteUses(t *testing.T) {
+	const newHash = "1111111111111111111111111111111111111111"
+	checkout := ActionReference{Owner: "actions", Name: "checkout"}
+	setupGo := ActionReference{Owner: "actions", Name: "setup-go"}
+
+	tests := []struct {
+		name          string
+		content       string
+		updates       []*Update
+		want          string
+		wantRemaining int
+	}{
+		{
+			name:    "block style keeps surrounding text",
+			content: "steps:\n  - name: Checkout   # first\n    uses: actions/checkout@v3   # v3\n    with: {fetch-depth: 0}\n",
+			updates: []*Update{{Action: checkout, LineNumber: 3, OldVersion: "v3", NewVersion: "v4", NewHash: newHash, VersionComment: "# v4"}},
+			want:    "steps:\n  - name: Checkout   # first\n    uses: actions/checkout@" + newHash + "   # v4\n    with: {fetch-depth: 0}\n",
+		},
+		{
+			name:    "flow style steps",
+			content: "steps: [{uses: actions/checkout@v3}, {uses: actions/setup-go@v4}]\n",
+			updates: []*Update{
+				{Action: checkout, LineNumber: 1, OldVersion: "v3", NewVersion: "v4", NewHash: newHash},
+				{Action: setupGo, LineNumber: 1, OldVersion: "v4", NewVersion: "v5", NewHash: newHash},
+			},
+			want: "steps: [{uses: actions/checkout@" + newHash + "}, {uses: actions/setup-go@" + newHash + "}]  # v5\n",
+		},
+		{
+			name:    "quoted value keeps user comment",
+			content: "steps:\n  - uses: \"actions/checkout@v3\" # old\n",
+			updates: []*Update{{Action: checkout, LineNumber: 2, OldVersion: "v3", NewVersion: "v4", NewHash: newHash}},
+			want:    "steps:\n  - uses: \"actions/checkout@" + newHash + "\" # v4  # old\n",
+		},
+		{
+			name:    "anchored step updated through alias line",
+			content: "x-checkout: &checkout\n  uses: actions/checkout@v3\njobs:\n  a:\n    steps:\n      - *checkout\n",
+			updates: []*Update{{Action: checkout, LineNumber: 6, OldVersion: "v3", NewVersion: "v4", NewHash: newHash}},
+			want:    "x-checkout: &checkout\n  uses: actions/checkout@" + newHash + "  # v4\njobs:\n  a:\n    steps:\n      - *checkout\n",
+		},
+		{
+			name:    "anchor and merge key alias are rewritten once",
+			content: "x-checkout: &checkout\n  uses: actions/checkout@v3\njobs:\n  a:\n    steps:\n      - <<: *checkout\n",
+			updates: []*Update{
+				{Action: checkout, LineNumber: 2, OldVersion: "v3", NewVersion: "v4", NewHash: newHash},
+				{Action: checkout, LineNumber: 6, OldVersion: "v3", NewVersion: "v4", NewHash: newHash},
+			},
+			want: "x-checkout: &checkout\n  uses: actions/checkout@" + newHash + "  # v4\njobs:\n  a:\n    steps:\n      - <<: *checkout\n",
+		},
+		{
+			name:    "host-qualified action keeps its host",
+			content: "steps:\n  - uses: ghe.example.com/tools/lint@v1\n",
+			updates: []*Update{{Action: ActionReference{Host: "ghe.example.com", Owner: "tools", Name: "lint"}, LineNumber: 2, OldVersion: "v1", NewVersion: "v2", NewHash: newHash}},
+			want:    "steps:\n  - uses: ghe.example.com/tools/lint@" + newHash + "  # v2\n",
+		},
+		{
+			name:          "block scalar falls back to line editing",
+			content:       "steps:\n  - uses: >-\n      actions/checkout@v3\n",
+			updates:       []*Update{{Action: checkout, LineNumber: 2, OldVersion: "v3", NewVersion: "v4", NewHash: newHash}},
+			want:          "steps:\n  - uses: >-\n      actions/checkout@v3\n",
+			wantRemaining: 1,
+		},
+		{
+			name:          "invalid YAML falls back to line editing",
+			content:       "steps: [\n  - uses: actions/checkout@v3\n",
+			updates:       []*Update{{Action: checkout, LineNumber: 2, NewHash: newHash}},
+			want:          "steps: [\n  - uses: actions/checkout@v3\n",
+			wantRemaining: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, remaining := rewriteUses(tt.content, tt.updates)
+			if got != tt.want {
+				t.Errorf("rewriteUses() =\n%s\nwant\n%s", got, tt.want)
+			}
+			if len(remaining) != tt.wantRemaining {
+				t.Errorf("rewriteUses() left %d updates, want %d", len(remaining), tt.wantRemaining)
+			}
+		})
+	}
+}
+
+func TestApplyUpdatesFlowStyleSteps(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "ci.yml")
+	content := "jobs:\n  a:\n    steps: [{uses: actions/checkout@v3}, {run: make}]\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	refs, err := NewScanner(dir).ParseActionReferences(path)
+	if err != nil || len(refs) != 1 {
+		t.Fatalf("ParseActionReferences() = %v, %v", refs, err)
+	}
+
+	manager := NewUpdateManager(dir)
+	update, err := manager.CreateUpdate(context.Background(), path, refs[0], "v4", "2222222222222222222222222222222222222222")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.ApplyUpdates(context.Background(), []*Update{update}); err != nil {
+		t.Fatalf("ApplyUpdates() error = %v", err)
+	}
+
+	got, _ := os.ReadFile(path)
+	want := "jobs:\n  a:\n    steps: [{uses: actions/checkout@2222222222222222222222222222222222222222}, {run: make}]\n"
+	if string(got) != want {
+		t.Errorf("updated content =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,237 @@
+// Package versions parses and compares the version tags actions are released
+// under. Precedence follows SemVer 2.0, relaxed for the tags actions use: an
+// optional v prefix, major-only and major.minor tags such as v4 and v4.1, and
+// more than three components.
+package versions
+
+import (
+	"cmp"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+)
+
+// Version is a parsed version tag
+type Version struct {
+	// Core holds the numeric components as written: [4] for v4, [4 1 0]
+	// for v4.1.0. Missing components count as zero.
+	Core []int
+	// Prerelease holds the dot separated identifiers after "-", such as
+	// [rc 1] for v2.0.0-rc.1. Text directly after a component (1.0.0rc1)
+	// is treated as a prerelease too.
+	Prerelease []string
+	// Build is the metadata after "+", which has no precedence
+	Build string
+}
+
+// Parse parses a version tag. Anything starting with a number, optionally
+// after a v, is a version, except for commit SHAs.
+func Parse(tag string) (Version, error) {
+	if isCommitSHA(tag) {
+		return Version{}, fmt.Errorf(common.ErrInvalidVersion, tag)
+	}
+	s := strings.TrimPrefix(tag, "v")
+	var v Version
+	s, v.Build, _ = strings.Cut(s, "+")
+
+	for {
+		end := lenDigits(s)
+		if end == 0 {
+			if len(v.Core) == 0 {
+				return Version{}, fmt.Errorf(common.ErrInvalidVersion, tag)
+			}
+			break
+		}
+		n, err := strconv.Atoi(s[:end])
+		if err != nil {
+			return Version{}, fmt.Errorf(common.ErrInvalidVersion, tag)
+		}
+		v.Core = append(v.Core, n)
+		s = s[end:]
+		if len(s) < 2 || s[0] != '.' || lenDigits(s[1:]) == 0 {
+			break
+		}
+		s = s[1:]
+	}
+
+	if s = strings.TrimLeft(s, "-."); s != "" {
+		v.Prerelease = strings.Split(s, ".")
+	}
+	return v, nil
+}
+
+// IsVersion reports whether tag is a version tag (v1, 1.2.3, ...) rather
+// than a branch or another tag
+func IsVersion(tag string) bool {
+	_, err := Parse(tag)
+	return err == nil
+}
+
+// IsPrerelease reports whether tag is a version tag with prerelease
+// identifiers, such as v2.0.0-rc.1
+func IsPrerelease(tag string) bool {
+	v, err := Parse(tag)
+	return err == nil && v.IsPrerelease()
+}
+
+// Normalize writes a version tag in its full form, v4 as v4.0.0; other tags
+// are returned unchanged
+func Normalize(tag string) string {
+	v, err := Parse(tag)
+	if err != nil {
+		return tag
+	}
+	return v.String()
+}
+
+// Compare compares two tags by precedence and returns -1, 0 or 1 when a is
+// older than, equal to or newer than b. Tags that are not versions are older
+// than every version and compare as strings among themselves.
+func Compare(a, b string) int {
+	va, errA := Parse(a)
+	vb, errB := Parse(b)
+	switch {
+	case errA != nil && errB != nil:
+		return strings.Compare(a, b)
+	case errA != nil:
+		return -1
+	case errB != nil:
+		return 1
+	}
+	return va.Compare(vb)
+}
+
+// IsNewer reports whether a is newer than b
+func IsNewer(a, b string) bool {
+	return Compare(a, b) > 0
+}
+
+// Major returns the first component
+func (v Version) Major() int {
+	return v.component(0)
+}
+
+// Minor returns the second component, 0 for a major-only tag
+func (v Version) Minor() int {
+	return v.component(1)
+}
+
+// Patch returns the third component, 0 when not given
+func (v Version) Patch() int {
+	return v.component(2)
+}
+
+// IsMajorOnly reports whether v is a major-only tag such as v4, which
+// actions move along with each release of the major version
+func (v Version) IsMajorOnly() bool {
+	return len(v.Core) == 1 && !v.IsPrerelease()
+}
+
+// IsPrerelease reports whether v has prerelease identifiers
+func (v Version) IsPrerelease() bool {
+	return len(v.Prerelease) > 0
+}
+
+// Contains reports whether o is within the range a partial version covers:
+// v4 contains v4.2.1 and v4.1 contains v4.1.3. A full version only contains
+// versions of equal precedence.
+func (v Version) Contains(o Version) bool {
+	if v.IsPrerelease() || len(v.Core) >= 3 {
+		return v.Compare(o) == 0
+	}
+	for i := range v.Core {
+		if v.component(i) != o.component(i) {
+			return false
+		}
+	}
+	return true
+}
+
+// Compare compares v and o by precedence: the components numerically, then
+// a version without prerelease identifiers above one with them, then the
+// identifiers one by one
+func (v Version) Compare(o Version) int {
+	for i := 0; i < max(len(v.Core), len(o.Core)); i++ {
+		if c := cmp.Compare(v.component(i), o.component(i)); c != 0 {
+			return c
+		}
+	}
+
+	switch {
+	case !v.IsPrerelease() && !o.IsPrerelease():
+		return 0
+	case !v.IsPrerelease():
+		return 1
+	case !o.IsPrerelease():
+		return -1
+	}
+	for i := 0; i < min(len(v.Prerelease), len(o.Prerelease)); i++ {
+		if c := compareIdentifiers(v.Prerelease[i], o.Prerelease[i]); c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(len(v.Prerelease), len(o.Prerelease))
+}
+
+// String writes v with a v prefix and at least three components
+func (v Version) String() string {
+	parts := make([]string, 0, max(len(v.Core), 3))
+	for i := 0; i < max(len(v.Core), 3); i++ {
+		parts = append(parts, strconv.Itoa(v.component(i)))
+	}
+	s := "v" + strings.Join(parts, ".")
+	if v.IsPrerelease() {
+		s += "-" + strings.Join(v.Prerelease, ".")
+	}
+	if v.Build != "" {
+		s += "+" + v.Build
+	}
+	return s
+}
+
+// component returns the i-th component, 0 past the given ones
+func (v Version) component(i int) int {
+	if i >= len(v.Core) {
+		return 0
+	}
+	return v.Core[i]
+}
+
+// compareIdentifiers compares prerelease identifiers: numeric ones
+// numerically and below alphanumeric ones, which compare in ASCII order
+func compareIdentifiers(a, b string) int {
+	numericA := a != "" && lenDigits(a) == len(a)
+	numericB := b != "" && lenDigits(b) == len(b)
+	switch {
+	case numericA && numericB:
+		// Compared by length first, so long identifiers cannot overflow
+		a, b = strings.TrimLeft(a, "0"), strings.TrimLeft(b, "0")
+		if c := cmp.Compare(len(a), len(b)); c != 0 {
+			return c
+		}
+		return strings.Compare(a, b)
+	case numericA:
+		return -1
+	case numericB:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// isCommitSHA reports whether s looks like a full or abbreviated commit SHA
+// rather than a number
+func isCommitSHA(s string) bool {
+	return len(s) >= 6 && len(s) <= 40 && common.IsHexString(s) && lenDigits(s) < len(s)
+}
+
+// lenDigits returns the length of the leading run of digits in s
+func lenDigits(s string) int {
+	for i, r := range s {
+		if r < '0' || r > '9' {
+			return i
+		}
+	}
+	return len(s)
+}
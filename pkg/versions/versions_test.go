@@ -0,0 +1,148 @@
+package versions
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		tag     string
+		want    string // Core, Prerelease and Build
+		wantErr bool
+	}{
+		{tag: "v4", want: "[4] [] "},
+		{tag: "4.1", want: "[4 1] [] "},
+		{tag: "v4.1.0", want: "[4 1 0] [] "},
+		{tag: "v1.0.0.1", want: "[1 0 0 1] [] "},
+		{tag: "v2.0.0-rc.1", want: "[2 0 0] [rc 1] "},
+		{tag: "v1.0.0-rc.1+build.5", want: "[1 0 0] [rc 1] build.5"},
+		{tag: "1.0.0rc1", want: "[1 0 0] [rc1] "},
+		{tag: "v1.2.3+20240101", want: "[1 2 3] [] 20240101"},
+		{tag: "main", wantErr: true},
+		{tag: "v", wantErr: true},
+		{tag: "", wantErr: true},
+		{tag: "release-1.0", wantErr: true},
+		{tag: "8e5e7e5ab8b370d6c329ec480221332ada57f0ab", wantErr: true},
+		{tag: "8e5e7e5", wantErr: true},
+		{tag: "99999999999999999999", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			got, err := Parse(tt.tag)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse(%q) error = %v, wantErr %v", tt.tag, err, tt.wantErr)
+			}
+			if !tt.wantErr && fmt.Sprintf("%v %v %s", got.Core, got.Prerelease, got.Build) != tt.want {
+				t.Errorf("Parse(%q) = %v, want %s", tt.tag, got, tt.want)
+			}
+			if IsVersion(tt.tag) == tt.wantErr {
+				t.Errorf("IsVersion(%q) = %v", tt.tag, !tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	// Precedence order from the SemVer 2.0 specification, oldest first
+	ordered := []string{
+		"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta",
+		"1.0.0-beta.2", "1.0.0-beta.11", "1.0.0-rc.1", "1.0.0", "2.0.0", "2.1.0", "2.1.1",
+	}
+	for i := range ordered {
+		for j := range ordered {
+			want := 0
+			if i < j {
+				want = -1
+			} else if i > j {
+				want = 1
+			}
+			if got := Compare(ordered[i], ordered[j]); got != want {
+				t.Errorf("Compare(%s, %s) = %d, want %d", ordered[i], ordered[j], got, want)
+			}
+		}
+	}
+
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"v2", "v2.0.0", 0},
+		{"v2", "2.0", 0},
+		{"v2.1", "v2", 1},
+		{"v1.0.0+build.5", "v1.0.0+build.7", 0},
+		{"v1.0.0-rc.1+build", "v1.0.0", -1},
+		{"v1.10.0", "v1.9.0", 1},
+		{"v1.0.0.1", "v1.0.0", 1},
+		{"v2.0.0-rc.1", "v1.9.9", 1},
+		{"v1.0.0-rc.99999999999999999999", "v1.0.0-rc.2", 1},
+		{"main", "v1", -1},
+		{"v1", "main", 1},
+		{"main", "main", 0},
+		{"dev", "main", -1},
+	}
+	for _, tt := range tests {
+		if got := Compare(tt.a, tt.b); got != tt.want {
+			t.Errorf("Compare(%s, %s) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := IsNewer(tt.a, tt.b); got != (tt.want > 0) {
+			t.Errorf("IsNewer(%s, %s) = %v", tt.a, tt.b, got)
+		}
+	}
+}
+
+func TestVersionParts(t *testing.T) {
+	tests := []struct {
+		tag                 string
+		major, minor, patch int
+		majorOnly           bool
+		prerelease          bool
+		normalized          string
+	}{
+		{tag: "v4", major: 4, majorOnly: true, normalized: "v4.0.0"},
+		{tag: "4.1", major: 4, minor: 1, normalized: "v4.1.0"},
+		{tag: "v4.1.2", major: 4, minor: 1, patch: 2, normalized: "v4.1.2"},
+		{tag: "v5-beta", major: 5, prerelease: true, normalized: "v5.0.0-beta"},
+		{tag: "v1.0.0-rc.1+exp.sha.5114f85", major: 1, prerelease: true, normalized: "v1.0.0-rc.1+exp.sha.5114f85"},
+	}
+	for _, tt := range tests {
+		v, err := Parse(tt.tag)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", tt.tag, err)
+		}
+		if v.Major() != tt.major || v.Minor() != tt.minor || v.Patch() != tt.patch {
+			t.Errorf("%s: parts = %d.%d.%d, want %d.%d.%d", tt.tag, v.Major(), v.Minor(), v.Patch(), tt.major, tt.minor, tt.patch)
+		}
+		if v.IsMajorOnly() != tt.majorOnly || v.IsPrerelease() != tt.prerelease || IsPrerelease(tt.tag) != tt.prerelease {
+			t.Errorf("%s: major only %v, prerelease %v", tt.tag, v.IsMajorOnly(), v.IsPrerelease())
+		}
+		if got := Normalize(tt.tag); got != tt.normalized {
+			t.Errorf("Normalize(%s) = %s, want %s", tt.tag, got, tt.normalized)
+		}
+	}
+	if got := Normalize("main"); got != "main" {
+		t.Errorf("Normalize(main) = %s, want main", got)
+	}
+}
+
+func TestContains(t *testing.T) {
+	tests := []struct {
+		v, o string
+		want bool
+	}{
+		{"v4", "v4.2.1", true},
+		{"v4", "v4", true},
+		{"v4", "v5.0.0", false},
+		{"v4.1", "v4.1.3", true},
+		{"v4.1", "v4.2.0", false},
+		{"v4.1.0", "v4.1", true},
+		{"v4.1.0", "v4.1.3", false},
+	}
+	for _, tt := range tests {
+		v, _ := Parse(tt.v)
+		o, _ := Parse(tt.o)
+		if got := v.Contains(o); got != tt.want {
+			t.Errorf("%s.Contains(%s) = %v, want %v", tt.v, tt.o, got, tt.want)
+		}
+	}
+}
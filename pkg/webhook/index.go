@@ -0,0 +1,95 @@
+// Package webhook receives GitHub release and push webhooks for the actions
+// used across a set of watched repositories and queues targeted updates for
+// only the repositories that use a newly released action.
+package webhook
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/updater"
+)
+
+// ActionRepository returns the lowercase owner/repo hosting an action, e.g.
+// "github/codeql-action" for github/codeql-action/init. Actions on another
+// host keep the host prefix so they never match github.com events.
+func ActionRepository(ref updater.ActionReference) string {
+	return strings.ToLower(ref.Repository())
+}
+
+// Index maps action repositories to the watched repositories using them
+type Index struct {
+	mu    sync.RWMutex
+	users map[string]map[string]bool // action repository -> watched repositories
+	uses  map[string][]string        // watched repository -> action repositories
+}
+
+// NewIndex creates an empty index
+func NewIndex() *Index {
+	return &Index{
+		users: make(map[string]map[string]bool),
+		uses:  make(map[string][]string),
+	}
+}
+
+// Set records the actions used by a watched repository, replacing what was
+// previously recorded for it. Local actions are ignored.
+func (i *Index) Set(repository string, refs []updater.ActionReference) {
+	repository = strings.ToLower(repository)
+	seen := make(map[string]bool)
+	var actions []string
+	for _, ref := range refs {
+		if ref.IsLocal() {
+			continue
+		}
+		action := ActionRepository(ref)
+		if !seen[action] {
+			seen[action] = true
+			actions = append(actions, action)
+		}
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	for _, action := range i.uses[repository] {
+		delete(i.users[action], repository)
+		if len(i.users[action]) == 0 {
+			delete(i.users, action)
+		}
+	}
+	i.uses[repository] = actions
+	for _, action := range actions {
+		if i.users[action] == nil {
+			i.users[action] = make(map[string]bool)
+		}
+		i.users[action][repository] = true
+	}
+}
+
+// Users returns the watched repositories that use an action repository
+func (i *Index) Users(action string) []string {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	users := make([]string, 0, len(i.users[strings.ToLower(action)]))
+	for repository := range i.users[strings.ToLower(action)] {
+		users = append(users, repository)
+	}
+	sort.Strings(users)
+	return users
+}
+
+// Watches reports whether a repository is watched
+func (i *Index) Watches(repository string) bool {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	_, ok := i.uses[strings.ToLower(repository)]
+	return ok
+}
+
+// Len returns the number of watched repositories
+func (i *Index) Len() int {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return len(i.uses)
+}
@@ -0,0 +1,120 @@
+package webhook
+
+import (
+	"context"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Job is a targeted update of one watched repository
+type Job struct {
+	Repository string   // owner/repo of the watched repository
+	Actions    []string // Action repositories with new versions; only these are updated
+	Reindex    bool     // Refresh the actions recorded for the repository first
+}
+
+// Queue dispatches jobs to a handler one at a time. Jobs for a repository
+// that is already waiting are merged into the pending job, so a burst of
+// releases results in a single update per repository.
+type Queue struct {
+	handle func(ctx context.Context, job Job) error
+
+	mu      sync.Mutex
+	pending map[string]*Job
+	order   []string
+	notify  chan struct{}
+}
+
+// NewQueue creates a queue that passes jobs to handle
+func NewQueue(handle func(ctx context.Context, job Job) error) *Queue {
+	return &Queue{
+		handle:  handle,
+		pending: make(map[string]*Job),
+		notify:  make(chan struct{}, 1),
+	}
+}
+
+// Enqueue adds a job, merging it into a pending job for the same repository.
+// It reports whether a new job was queued.
+func (q *Queue) Enqueue(job Job) bool {
+	key := strings.ToLower(job.Repository)
+
+	q.mu.Lock()
+	queued := false
+	if pending, ok := q.pending[key]; ok {
+		pending.Actions = mergeActions(pending.Actions, job.Actions)
+		pending.Reindex = pending.Reindex || job.Reindex
+	} else {
+		job.Actions = mergeActions(nil, job.Actions)
+		q.pending[key] = &job
+		q.order = append(q.order, key)
+		queued = true
+	}
+	q.mu.Unlock()
+
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+	return queued
+}
+
+// Len returns the number of pending jobs
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.order)
+}
+
+// Run processes jobs until ctx is done. Handler errors are logged and do not
+// stop the queue.
+func (q *Queue) Run(ctx context.Context) {
+	for {
+		job, ok := q.next()
+		if !ok {
+			select {
+			case <-ctx.Done():
+				return
+			case <-q.notify:
+				continue
+			}
+		}
+		if err := q.handle(ctx, job); err != nil {
+			log.Printf("Warning: update of %s failed: %v", job.Repository, err)
+		}
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// next removes and returns the oldest pending job
+func (q *Queue) next() (Job, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.order) == 0 {
+		return Job{}, false
+	}
+	key := q.order[0]
+	q.order = q.order[1:]
+	job := q.pending[key]
+	delete(q.pending, key)
+	return *job, true
+}
+
+// mergeActions returns the sorted union of two action lists
+func mergeActions(a, b []string) []string {
+	seen := make(map[string]bool, len(a)+len(b))
+	var merged []string
+	for _, action := range append(append([]string{}, a...), b...) {
+		action = strings.ToLower(action)
+		if !seen[action] {
+			seen[action] = true
+			merged = append(merged, action)
+		}
+	}
+	sort.Strings(merged)
+	return merged
+}
@@ -0,0 +1,116 @@
+package webhook
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/common"
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/updater"
+	"github.com/google/go-github/v72/github"
+)
+
+// maxPayloadSize limits the size of accepted webhook payloads
+const maxPayloadSize = 25 << 20
+
+// Handler verifies GitHub webhook deliveries and queues targeted updates.
+//
+// A published (non-prerelease) release or a newly pushed tag of an action
+// repository queues an update for every watched repository using that
+// action. A push to the default branch of a watched repository queues a
+// reindex so later releases reach the right repositories. A merged pull
+// request of the updater in a watched repository is reported to Merged.
+type Handler struct {
+	Secret []byte
+	Index  *Index
+	Queue  *Queue
+	Merged func(repository string, number int) // Optional
+
+	// Branches names the updater's pull request branches; the default
+	// template when empty
+	Branches updater.BranchTemplate
+}
+
+// ServeHTTP implements http.Handler
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	if len(h.Secret) == 0 {
+		// Never accept unsigned deliveries
+		http.Error(w, common.ErrWebhookNoSecret, http.StatusInternalServerError)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxPayloadSize)
+	payload, err := github.ValidatePayload(r, h.Secret)
+	if err != nil {
+		log.Printf(common.ErrWebhookRejected, github.DeliveryID(r), err)
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	eventType := github.WebHookType(r)
+	if eventType == "ping" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	event, err := github.ParseWebHook(eventType, payload)
+	if err != nil {
+		// Unsupported event types are acknowledged and ignored
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	queued := h.dispatch(event)
+	w.WriteHeader(http.StatusAccepted)
+	_, _ = fmt.Fprintf(w, "queued %d\n", queued)
+}
+
+// dispatch queues the jobs for an event and returns how many were queued
+func (h *Handler) dispatch(event interface{}) int {
+	var jobs []Job
+	switch e := event.(type) {
+	case *github.ReleaseEvent:
+		if e.GetAction() == "published" && !e.GetRelease().GetPrerelease() {
+			jobs = h.actionReleased(e.GetRepo().GetFullName())
+		}
+	case *github.PullRequestEvent:
+		pr := e.GetPullRequest()
+		repository := e.GetRepo().GetFullName()
+		if h.Merged != nil && e.GetAction() == "closed" && pr.GetMerged() &&
+			h.Branches.Matches(pr.GetHead().GetRef()) && h.Index.Watches(repository) {
+			h.Merged(repository, pr.GetNumber())
+		}
+	case *github.PushEvent:
+		repository := e.GetRepo().GetFullName()
+		ref := e.GetRef()
+		switch {
+		case strings.HasPrefix(ref, "refs/tags/") && e.GetCreated() && !e.GetDeleted():
+			jobs = h.actionReleased(repository)
+		case h.Index.Watches(repository) && ref == "refs/heads/"+e.GetRepo().GetDefaultBranch():
+			jobs = []Job{{Repository: repository, Reindex: true}}
+		}
+	}
+
+	queued := 0
+	for _, job := range jobs {
+		if h.Queue.Enqueue(job) {
+			queued++
+		}
+	}
+	return queued
+}
+
+// actionReleased returns a job for each watched repository using an action
+func (h *Handler) actionReleased(action string) []Job {
+	users := h.Index.Users(action)
+	jobs := make([]Job, 0, len(users))
+	for _, repository := range users {
+		jobs = append(jobs, Job{Repository: repository, Actions: []string{strings.ToLower(action)}})
+	}
+	return jobs
+}
@@ -0,0 +1,175 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ThreatFlux/githubWorkFlowChecker/pkg/updater"
+)
+
+func TestIndex(t *testing.T) {
+	index := NewIndex()
+	index.Set("Acme/Web", []updater.ActionReference{
+		{Owner: "actions", Name: "checkout"},
+		{Owner: "github", Name: "codeql-action/init"},
+		{Owner: "github", Name: "codeql-action/analyze"},
+		{LocalPath: "./.github/actions/build"},
+	})
+	index.Set("acme/api", []updater.ActionReference{{Owner: "actions", Name: "checkout"}})
+
+	if got := index.Users("actions/checkout"); !reflect.DeepEqual(got, []string{"acme/api", "acme/web"}) {
+		t.Errorf("Users(actions/checkout) = %v", got)
+	}
+	if got := index.Users("GitHub/CodeQL-Action"); !reflect.DeepEqual(got, []string{"acme/web"}) {
+		t.Errorf("Users(github/codeql-action) = %v", got)
+	}
+
+	// Replacing a repository's entry drops actions it no longer uses
+	index.Set("acme/web", []updater.ActionReference{{Owner: "actions", Name: "setup-go"}})
+	if got := index.Users("github/codeql-action"); len(got) != 0 {
+		t.Errorf("Users(github/codeql-action) after reindex = %v", got)
+	}
+	if !index.Watches("ACME/web") || index.Watches("acme/other") {
+		t.Error("Watches() returned unexpected result")
+	}
+	if index.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", index.Len())
+	}
+}
+
+func TestQueueMergesPendingJobs(t *testing.T) {
+	var handled []Job
+	queue := NewQueue(func(ctx context.Context, job Job) error {
+		handled = append(handled, job)
+		return nil
+	})
+
+	if !queue.Enqueue(Job{Repository: "acme/web", Actions: []string{"actions/checkout"}}) {
+		t.Error("first Enqueue() = false, want true")
+	}
+	queue.Enqueue(Job{Repository: "acme/api", Actions: []string{"actions/checkout"}})
+	if queue.Enqueue(Job{Repository: "Acme/Web", Actions: []string{"actions/setup-go", "actions/checkout"}, Reindex: true}) {
+		t.Error("Enqueue() for pending repository = true, want false")
+	}
+	if queue.Len() != 2 {
+		t.Fatalf("Len() = %d, want 2", queue.Len())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		queue.Run(ctx)
+		close(done)
+	}()
+	deadline := time.Now().Add(5 * time.Second)
+	for queue.Len() > 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	want := []Job{
+		{Repository: "acme/web", Actions: []string{"actions/checkout", "actions/setup-go"}, Reindex: true},
+		{Repository: "acme/api", Actions: []string{"actions/checkout"}},
+	}
+	if !reflect.DeepEqual(handled, want) {
+		t.Errorf("handled jobs = %+v, want %+v", handled, want)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	secret := []byte("s3cret")
+	sign := func(body string) string {
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(body))
+		return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		event      string
+		body       string
+		signature  string // Defaults to a valid signature
+		wantStatus int
+		wantJobs   int
+		branches   updater.BranchTemplate
+		wantMerged string
+	}{
+		{name: "release published", event: "release", body: `{"action":"published","release":{"tag_name":"v5"},"repository":{"full_name":"actions/checkout"}}`, wantStatus: http.StatusAccepted, wantJobs: 2},
+		{name: "prerelease ignored", event: "release", body: `{"action":"published","release":{"tag_name":"v5-rc","prerelease":true},"repository":{"full_name":"actions/checkout"}}`, wantStatus: http.StatusAccepted},
+		{name: "release of unused action", event: "release", body: `{"action":"published","release":{"tag_name":"v1"},"repository":{"full_name":"other/action"}}`, wantStatus: http.StatusAccepted},
+		{name: "tag pushed", event: "push", body: `{"ref":"refs/tags/v5","created":true,"repository":{"full_name":"actions/checkout"}}`, wantStatus: http.StatusAccepted, wantJobs: 2},
+		{name: "push to watched default branch", event: "push", body: `{"ref":"refs/heads/main","repository":{"full_name":"acme/web","default_branch":"main"}}`, wantStatus: http.StatusAccepted, wantJobs: 1},
+		{name: "push to feature branch", event: "push", body: `{"ref":"refs/heads/topic","repository":{"full_name":"acme/web","default_branch":"main"}}`, wantStatus: http.StatusAccepted},
+		{name: "updater pull request merged", event: "pull_request", body: `{"action":"closed","pull_request":{"number":7,"merged":true,"head":{"ref":"action-updates-20260501-000000"}},"repository":{"full_name":"acme/web"}}`, wantStatus: http.StatusAccepted, wantMerged: "acme/web#7"},
+		{name: "templated branch merged", event: "pull_request", body: `{"action":"closed","pull_request":{"number":10,"merged":true,"head":{"ref":"deps/actions-checkout-major"}},"repository":{"full_name":"acme/web"}}`, branches: "deps/{action}-{strategy}", wantStatus: http.StatusAccepted, wantMerged: "acme/web#10"},
+		{name: "default branch name with template", event: "pull_request", body: `{"action":"closed","pull_request":{"number":11,"merged":true,"head":{"ref":"action-updates-20260501-000000"}},"repository":{"full_name":"acme/web"}}`, branches: "deps/{action}-{strategy}", wantStatus: http.StatusAccepted},
+		{name: "other pull request merged", event: "pull_request", body: `{"action":"closed","pull_request":{"number":8,"merged":true,"head":{"ref":"topic"}},"repository":{"full_name":"acme/web"}}`, wantStatus: http.StatusAccepted},
+		{name: "updater pull request closed", event: "pull_request", body: `{"action":"closed","pull_request":{"number":9,"merged":false,"head":{"ref":"action-updates-20260501-000000"}},"repository":{"full_name":"acme/web"}}`, wantStatus: http.StatusAccepted},
+		{name: "ping", event: "ping", body: `{"zen":"hi"}`, wantStatus: http.StatusOK},
+		{name: "invalid signature", event: "release", body: `{"action":"published"}`, signature: "sha256=00", wantStatus: http.StatusUnauthorized},
+		{name: "missing signature", event: "release", body: `{"action":"published"}`, signature: "-", wantStatus: http.StatusUnauthorized},
+		{name: "wrong method", method: http.MethodGet, wantStatus: http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			index := NewIndex()
+			index.Set("acme/web", []updater.ActionReference{{Owner: "actions", Name: "checkout"}})
+			index.Set("acme/api", []updater.ActionReference{{Owner: "actions", Name: "checkout"}})
+			queue := NewQueue(func(ctx context.Context, job Job) error { return nil })
+			var merged string
+			handler := &Handler{Secret: secret, Index: index, Queue: queue, Branches: tt.branches, Merged: func(repository string, number int) {
+				merged = fmt.Sprintf("%s#%d", repository, number)
+			}}
+
+			method := tt.method
+			if method == "" {
+				method = http.MethodPost
+			}
+			req := httptest.NewRequest(method, "/webhook", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("X-GitHub-Event", tt.event)
+			switch tt.signature {
+			case "":
+				req.Header.Set("X-Hub-Signature-256", sign(tt.body))
+			case "-":
+			default:
+				req.Header.Set("X-Hub-Signature-256", tt.signature)
+			}
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if queue.Len() != tt.wantJobs {
+				t.Errorf("queued %d jobs, want %d", queue.Len(), tt.wantJobs)
+			}
+			if merged != tt.wantMerged {
+				t.Errorf("merged = %q, want %q", merged, tt.wantMerged)
+			}
+		})
+	}
+}
+
+func TestHandlerRequiresSecret(t *testing.T) {
+	handler := &Handler{Index: NewIndex(), Queue: NewQueue(nil)}
+	req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(`{}`))
+	req.Header.Set("X-GitHub-Event", "ping")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+}